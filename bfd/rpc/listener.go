@@ -87,6 +87,28 @@ func (h *BFDHandler) ReadSessionParamConfigFromDB(dbHdl redis.Conn) error {
 	return nil
 }
 
+func (h *BFDHandler) ReadKeyChainKeyConfigFromDB(dbHdl redis.Conn) error {
+	h.logger.Info("Reading BfdKeyChainKey")
+	if dbHdl != nil {
+		var dbObj objects.BfdKeyChainKey
+		objList, err := dbObj.GetAllObjFromDb(dbHdl)
+		if err != nil {
+			h.logger.Err("DB query failed for key chain config")
+			return err
+		}
+		for idx := 0; idx < len(objList); idx++ {
+			obj := bfdd.NewBfdKeyChainKey()
+			dbObject := objList[idx].(objects.BfdKeyChainKey)
+			objects.ConvertbfddBfdKeyChainKeyObjToThrift(&dbObject, obj)
+			rv, _ := h.CreateBfdKeyChainKey(obj)
+			if rv == false {
+				h.logger.Err("BfdKeyChainKey create failed for ", dbObject.KeyChain, dbObject.KeyId)
+			}
+		}
+	}
+	return nil
+}
+
 func (h *BFDHandler) ReadSessionConfigFromDB(dbHdl redis.Conn) error {
 	h.logger.Info("Reading BfdSession")
 	if dbHdl != nil {
@@ -112,6 +134,8 @@ func (h *BFDHandler) ReadSessionConfigFromDB(dbHdl redis.Conn) error {
 func (h *BFDHandler) ReadConfigFromDB(dbHdl redis.Conn) error {
 	// BfdGlobalConfig
 	h.ReadGlobalConfigFromDB(dbHdl)
+	// BfdKeyChainKeyConfig
+	h.ReadKeyChainKeyConfigFromDB(dbHdl)
 	// BfdIntfConfig
 	h.ReadSessionParamConfigFromDB(dbHdl)
 	// BfdSessionConfig
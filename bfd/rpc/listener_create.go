@@ -28,6 +28,7 @@ import (
 	"errors"
 	"l3/bfd/bfddCommonDefs"
 	"l3/bfd/server"
+	"time"
 )
 
 func (h *BFDHandler) SendBfdGlobalConfig(bfdGlobalConfig *bfdd.BfdGlobal) bool {
@@ -45,6 +46,7 @@ func (h *BFDHandler) SendBfdSessionConfig(bfdSessionConfig *bfdd.BfdSession) boo
 		ParamName: bfdSessionConfig.ParamName,
 		Interface: bfdSessionConfig.Interface,
 		PerLink:   bfdSessionConfig.PerLink,
+		MultiHop:  bfdSessionConfig.MultiHop,
 		Protocol:  bfddCommonDefs.ConvertBfdSessionOwnerStrToVal(bfdSessionConfig.Owner),
 		Operation: bfddCommonDefs.CREATE,
 	}
@@ -64,11 +66,57 @@ func (h *BFDHandler) SendBfdSessionParamConfig(bfdSessionParamConfig *bfdd.BfdSe
 		AuthenticationType:        h.server.ConvertBfdAuthTypeStrToVal(bfdSessionParamConfig.AuthType),
 		AuthenticationKeyId:       bfdSessionParamConfig.AuthKeyId,
 		AuthenticationData:        bfdSessionParamConfig.AuthData,
+		AuthenticationKeyChain:    bfdSessionParamConfig.AuthKeyChain,
 	}
 	h.server.SessionParamConfigCh <- sessionParamConf
 	return true
 }
 
+// Key lifetimes are in RFC 3339 format, empty string means the lifetime is not bounded
+func parseKeyLifetime(lifetime string) (time.Time, error) {
+	if lifetime == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, lifetime)
+}
+
+func (h *BFDHandler) convertKeyChainKeyConfig(bfdKeyChainKeyConfig *bfdd.BfdKeyChainKey) (server.KeyChainKeyConfig, error) {
+	var err error
+	keyConf := server.KeyChainKeyConfig{
+		KeyChain: bfdKeyChainKeyConfig.KeyChain,
+		KeyId:    bfdKeyChainKeyConfig.KeyId,
+		AuthKey:  bfdKeyChainKeyConfig.AuthKey,
+	}
+	if keyConf.KeyChain == "" {
+		return keyConf, errors.New("Invalid Key Chain name")
+	}
+	if keyConf.KeyId < 0 || keyConf.KeyId > 255 {
+		return keyConf, errors.New("Invalid Key Id, valid range is 0-255")
+	}
+	if keyConf.SendLifetimeStart, err = parseKeyLifetime(bfdKeyChainKeyConfig.SendLifetimeStart); err != nil {
+		return keyConf, errors.New("Invalid send lifetime start: " + err.Error())
+	}
+	if keyConf.SendLifetimeEnd, err = parseKeyLifetime(bfdKeyChainKeyConfig.SendLifetimeEnd); err != nil {
+		return keyConf, errors.New("Invalid send lifetime end: " + err.Error())
+	}
+	if keyConf.AcceptLifetimeStart, err = parseKeyLifetime(bfdKeyChainKeyConfig.AcceptLifetimeStart); err != nil {
+		return keyConf, errors.New("Invalid accept lifetime start: " + err.Error())
+	}
+	if keyConf.AcceptLifetimeEnd, err = parseKeyLifetime(bfdKeyChainKeyConfig.AcceptLifetimeEnd); err != nil {
+		return keyConf, errors.New("Invalid accept lifetime end: " + err.Error())
+	}
+	return keyConf, nil
+}
+
+func (h *BFDHandler) SendBfdKeyChainKeyConfig(bfdKeyChainKeyConfig *bfdd.BfdKeyChainKey) (bool, error) {
+	keyConf, err := h.convertKeyChainKeyConfig(bfdKeyChainKeyConfig)
+	if err != nil {
+		return false, err
+	}
+	h.server.KeyChainKeyConfigCh <- keyConf
+	return true, nil
+}
+
 func (h *BFDHandler) CreateBfdGlobal(bfdGlobalConf *bfdd.BfdGlobal) (bool, error) {
 	if bfdGlobalConf == nil {
 		err := errors.New("Invalid Global Configuration")
@@ -94,3 +142,12 @@ func (h *BFDHandler) CreateBfdSessionParam(bfdSessionParamConf *bfdd.BfdSessionP
 	h.logger.Info("Create session param config attrs:", bfdSessionParamConf)
 	return h.SendBfdSessionParamConfig(bfdSessionParamConf), nil
 }
+
+func (h *BFDHandler) CreateBfdKeyChainKey(bfdKeyChainKeyConf *bfdd.BfdKeyChainKey) (bool, error) {
+	if bfdKeyChainKeyConf == nil {
+		err := errors.New("Invalid Key Chain Configuration")
+		return false, err
+	}
+	h.logger.Info("Create key chain config attrs:", bfdKeyChainKeyConf.KeyChain, bfdKeyChainKeyConf.KeyId)
+	return h.SendBfdKeyChainKeyConfig(bfdKeyChainKeyConf)
+}
@@ -66,3 +66,17 @@ func (h *BFDHandler) DeleteBfdSessionParam(bfdSessionParamConf *bfdd.BfdSessionP
 	h.server.SessionParamDeleteCh <- paramName
 	return true, nil
 }
+
+func (h *BFDHandler) DeleteBfdKeyChainKey(bfdKeyChainKeyConf *bfdd.BfdKeyChainKey) (bool, error) {
+	if bfdKeyChainKeyConf == nil {
+		err := errors.New("Invalid Key Chain Configuration")
+		return false, err
+	}
+	h.logger.Info("Delete key chain config attrs:", bfdKeyChainKeyConf.KeyChain, bfdKeyChainKeyConf.KeyId)
+	keyConf := server.KeyChainKeyConfig{
+		KeyChain: bfdKeyChainKeyConf.KeyChain,
+		KeyId:    bfdKeyChainKeyConf.KeyId,
+	}
+	h.server.KeyChainKeyDeleteCh <- keyConf
+	return true, nil
+}
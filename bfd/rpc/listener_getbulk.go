@@ -86,6 +86,7 @@ func (h *BFDHandler) convertSessionStateToThrift(ent server.SessionState) *bfdd.
 	sessionState.IntfRef = string(ent.Interface)
 	sessionState.InterfaceSpecific = ent.InterfaceSpecific
 	sessionState.PerLinkSession = ent.PerLinkSession
+	sessionState.MultiHopSession = ent.MultiHopSession
 	sessionState.LocalMacAddr = string(ent.LocalMacAddr.String())
 	sessionState.RemoteMacAddr = string(ent.RemoteMacAddr.String())
 	sessionState.RegisteredProtocols = string(h.convertBfdSessionProtocolsToString(ent.RegisteredProtocols))
@@ -97,6 +98,9 @@ func (h *BFDHandler) convertSessionStateToThrift(ent server.SessionState) *bfdd.
 	sessionState.DesiredMinTxInterval = string(strconv.Itoa(int(ent.DesiredMinTxInterval)) + "(us)")
 	sessionState.RequiredMinRxInterval = string(strconv.Itoa(int(ent.RequiredMinRxInterval)) + "(us)")
 	sessionState.RemoteMinRxInterval = string(strconv.Itoa(int(ent.RemoteMinRxInterval)) + "(us)")
+	sessionState.RequiredMinEchoRxInterval = string(strconv.Itoa(int(ent.RequiredMinEchoRxInterval)) + "(us)")
+	sessionState.RemoteMinEchoRxInterval = string(strconv.Itoa(int(ent.RemoteMinEchoRxInterval)) + "(us)")
+	sessionState.EchoActive = ent.EchoActive
 	sessionState.DetectionMultiplier = int32(ent.DetectionMultiplier)
 	sessionState.RemoteDetectionMultiplier = int32(ent.RemoteDetectionMultiplier)
 	sessionState.DemandMode = ent.DemandMode
@@ -107,6 +111,10 @@ func (h *BFDHandler) convertSessionStateToThrift(ent server.SessionState) *bfdd.
 	sessionState.SentAuthSeq = int32(ent.SentAuthSeq)
 	sessionState.NumTxPackets = int32(ent.NumTxPackets)
 	sessionState.NumRxPackets = int32(ent.NumRxPackets)
+	sessionState.NumRxAuthFailures = int32(ent.NumRxAuthFailures)
+	sessionState.NumRxAuthSeqFailures = int32(ent.NumRxAuthSeqFailures)
+	sessionState.NumTxEchoPackets = int32(ent.NumTxEchoPackets)
+	sessionState.NumRxEchoPackets = int32(ent.NumRxEchoPackets)
 	sessionState.ToDownCount = int32(ent.ToDownCount)
 	sessionState.ToUpCount = int32(ent.ToUpCount)
 	if ent.SessionState == server.STATE_UP {
@@ -130,6 +138,7 @@ func (h *BFDHandler) convertSessionParamStateToThrift(ent server.SessionParamSta
 	sessionParamState.AuthenticationType = string(h.server.ConvertBfdAuthTypeValToStr(ent.AuthenticationType))
 	sessionParamState.AuthenticationKeyId = int32(ent.AuthenticationKeyId)
 	sessionParamState.AuthenticationData = string(ent.AuthenticationData)
+	sessionParamState.AuthenticationKeyChain = string(ent.AuthenticationKeyChain)
 	return sessionParamState
 }
 
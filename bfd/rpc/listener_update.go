@@ -51,3 +51,12 @@ func (h *BFDHandler) UpdateBfdSessionParam(origConf *bfdd.BfdSessionParam, newCo
 	h.logger.Info("Update session Param config attrs:", newConf)
 	return h.SendBfdSessionParamConfig(newConf), nil
 }
+
+func (h *BFDHandler) UpdateBfdKeyChainKey(origConf *bfdd.BfdKeyChainKey, newConf *bfdd.BfdKeyChainKey, attrset []bool, op []*bfdd.PatchOpInfo) (bool, error) {
+	if newConf == nil {
+		err := errors.New("Invalid Key Chain Configuration")
+		return false, err
+	}
+	h.logger.Info("Update key chain config attrs:", newConf.KeyChain, newConf.KeyId)
+	return h.SendBfdKeyChainKeyConfig(newConf)
+}
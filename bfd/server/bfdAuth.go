@@ -25,8 +25,12 @@ package server
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
 	"encoding/binary"
 	"errors"
+	"math/rand"
+	"time"
 )
 
 type BfdAuthHeader struct {
@@ -47,6 +51,13 @@ const (
 	BFD_AUTH_TYPE_METICULOUS_SHA1 AuthenticationType = 5 // Meticulous Keyed SHA1
 )
 
+const (
+	BFD_AUTH_HEADER_LEN      = 8  // Type, Len, Key ID, Reserved and Sequence Number
+	BFD_AUTH_MD5_DIGEST_LEN  = 16 // Auth Key/Digest
+	BFD_AUTH_SHA1_DIGEST_LEN = 20 // Auth Key/Hash
+	BFD_AUTH_SIMPLE_MAX_LEN  = 16 // Password
+)
+
 func (server *BFDServer) ConvertBfdAuthTypeStrToVal(authType string) AuthenticationType {
 	var authVal AuthenticationType
 	switch authType {
@@ -161,3 +172,68 @@ func decodeBfdAuthHeader(data []byte) (*BfdAuthHeader, error) {
 
 	return h, nil
 }
+
+func IsMeticulousAuthType(authType AuthenticationType) bool {
+	return authType == BFD_AUTH_TYPE_METICULOUS_MD5 || authType == BFD_AUTH_TYPE_METICULOUS_SHA1
+}
+
+/*
+ * Initial value of bfd.XmitAuthSeq is random (RFC 5880 section 6.8.1)
+ */
+func (server *BFDServer) GetInitialAuthSeq() uint32 {
+	s1 := rand.NewSource(time.Now().UnixNano())
+	r1 := rand.New(s1)
+	return r1.Uint32()
+}
+
+/*
+ * Auth Key is padded with zeros (or truncated) to the length of the digest field
+ */
+func padBfdAuthKey(key []byte, length int) []byte {
+	paddedKey := make([]byte, length)
+	copy(paddedKey, key)
+	return paddedKey
+}
+
+/*
+ * Digest is calculated over the complete packet, with the Auth Key in place
+ * of the digest field (RFC 5880 sections 6.7.3 and 6.7.4)
+ */
+func computeBfdAuthDigest(authType AuthenticationType, packet []byte) []byte {
+	switch authType {
+	case BFD_AUTH_TYPE_KEYED_MD5, BFD_AUTH_TYPE_METICULOUS_MD5:
+		digest := md5.Sum(packet)
+		return digest[:]
+	case BFD_AUTH_TYPE_KEYED_SHA1, BFD_AUTH_TYPE_METICULOUS_SHA1:
+		digest := sha1.Sum(packet)
+		return digest[:]
+	}
+	return nil
+}
+
+/*
+ * Verify the digest of a received packet using the given Auth Key
+ */
+func verifyBfdAuthDigest(authType AuthenticationType, packet []byte, key []byte, digest []byte) bool {
+	digestStart := DEFAULT_CONTROL_PACKET_LEN + BFD_AUTH_HEADER_LEN
+	if len(packet) <= digestStart {
+		return false
+	}
+	verifyBuf := make([]byte, len(packet))
+	copy(verifyBuf, packet)
+	copy(verifyBuf[digestStart:], padBfdAuthKey(key, len(packet)-digestStart))
+	return bytes.Equal(computeBfdAuthDigest(authType, verifyBuf), digest)
+}
+
+/*
+ * Check the received sequence number against the replay window, which is
+ * bfd.RcvAuthSeq to bfd.RcvAuthSeq+(3*Detect Mult) in circular number space.
+ * Meticulous modes require the sequence number to always advance.
+ */
+func checkBfdAuthSeqWindow(authType AuthenticationType, rcvAuthSeq uint32, seqNum uint32, detectMult uint8) bool {
+	diff := seqNum - rcvAuthSeq
+	if IsMeticulousAuthType(authType) && diff == 0 {
+		return false
+	}
+	return diff <= 3*uint32(detectMult)
+}
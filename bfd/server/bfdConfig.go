@@ -48,6 +48,7 @@ type SessionConfig struct {
 	ParamName string
 	Interface string
 	PerLink   bool
+	MultiHop  bool
 	Protocol  bfddCommonDefs.BfdSessionOwner
 	Operation bfddCommonDefs.BfdSessionOperation
 }
@@ -60,6 +61,7 @@ type SessionState struct {
 	Interface                 string
 	InterfaceSpecific         bool
 	PerLinkSession            bool
+	MultiHopSession           bool
 	LocalMacAddr              net.HardwareAddr
 	RemoteMacAddr             net.HardwareAddr
 	RegisteredProtocols       []bool
@@ -71,6 +73,9 @@ type SessionState struct {
 	DesiredMinTxInterval      int32
 	RequiredMinRxInterval     int32
 	RemoteMinRxInterval       int32
+	RequiredMinEchoRxInterval int32
+	RemoteMinEchoRxInterval   int32
+	EchoActive                bool
 	DetectionMultiplier       int32
 	RemoteDetectionMultiplier int32
 	DemandMode                bool
@@ -81,6 +86,10 @@ type SessionState struct {
 	SentAuthSeq               uint32
 	NumTxPackets              uint32
 	NumRxPackets              uint32
+	NumRxAuthFailures         uint32
+	NumRxAuthSeqFailures      uint32
+	NumTxEchoPackets          uint32
+	NumRxEchoPackets          uint32
 	ToDownCount               uint32
 	ToUpCount                 uint32
 	UpTime                    time.Time
@@ -97,6 +106,7 @@ type SessionParamConfig struct {
 	AuthenticationType        AuthenticationType
 	AuthenticationKeyId       int32
 	AuthenticationData        string
+	AuthenticationKeyChain    string
 }
 
 type SessionParamState struct {
@@ -111,4 +121,5 @@ type SessionParamState struct {
 	AuthenticationType        AuthenticationType
 	AuthenticationKeyId       int32
 	AuthenticationData        string
+	AuthenticationKeyChain    string
 }
@@ -25,11 +25,8 @@ package server
 
 import (
 	"bytes"
-	"crypto/md5"
-	"crypto/sha1"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"time"
 )
 
@@ -67,6 +64,7 @@ const (
 	ADMIN_DOWN        BfdSessionEvent = 5
 	ADMIN_UP          BfdSessionEvent = 6
 	REMOTE_ADMIN_DOWN BfdSessionEvent = 7
+	ECHO_TIMEOUT      BfdSessionEvent = 8
 )
 
 type BfdDiagnostic int
@@ -125,6 +123,7 @@ type BfdControlPacket struct {
 	RequiredMinRxInterval     time.Duration
 	RequiredMinEchoRxInterval time.Duration
 	AuthHeader                *BfdAuthHeader
+	authPacket                []byte // Received packet, used to verify the auth digest
 }
 
 // Constants
@@ -139,6 +138,11 @@ const (
 	SRC_PORT                              = 49152
 	DEST_PORT_LAG                         = 6784
 	SRC_PORT_LAG                          = 49153
+	DEST_PORT_ECHO                        = 3785
+	SRC_PORT_ECHO                         = 50176 // Above the source ports used by the control packets
+	DEST_PORT_MULTI_HOP                   = 4784
+	BFD_TX_TTL                            = 255
+	MAX_MULTI_HOP_COUNT                   = 64 // Received TTL of multi-hop packets must not be below 255 - MAX_MULTI_HOP_COUNT
 	STARTUP_TX_INTERVAL                   = 2000000
 	STARTUP_RX_INTERVAL                   = 2000000
 	TX_JITTER                             = 10 //Timer will be running at 0 to 10% less than TX_INTERVAL
@@ -213,22 +217,16 @@ func (p *BfdControlPacket) CreateBfdControlPacket() ([]byte, error) {
 			binary.Write(buf, binary.BigEndian, uint8(0))
 			binary.Write(buf, binary.BigEndian, p.AuthHeader.SequenceNumber)
 		}
-		copiedBuf := bytes.NewBuffer(buf.Bytes())
 		switch p.AuthHeader.Type {
 		case BFD_AUTH_TYPE_SIMPLE:
 			binary.Write(buf, binary.BigEndian, p.AuthHeader.AuthData)
-		case BFD_AUTH_TYPE_KEYED_MD5, BFD_AUTH_TYPE_METICULOUS_MD5:
-			var authData [16]byte
-			binary.Write(copiedBuf, binary.BigEndian, p.AuthHeader.AuthData)
-			authData = md5.Sum(copiedBuf.Bytes())
-			binary.Write(buf, binary.BigEndian, authData)
-			fmt.Println("MD5 sum ", authData)
-		case BFD_AUTH_TYPE_KEYED_SHA1, BFD_AUTH_TYPE_METICULOUS_SHA1:
-			var authData [20]byte
-			binary.Write(copiedBuf, binary.BigEndian, p.AuthHeader.AuthData)
-			authData = sha1.Sum(copiedBuf.Bytes())
-			binary.Write(buf, binary.BigEndian, authData)
-			fmt.Println("SHA1 sum ", authData)
+		case BFD_AUTH_TYPE_KEYED_MD5, BFD_AUTH_TYPE_METICULOUS_MD5,
+			BFD_AUTH_TYPE_KEYED_SHA1, BFD_AUTH_TYPE_METICULOUS_SHA1:
+			// Digest is calculated with the padded key in place of the digest field
+			digestStart := buf.Len()
+			binary.Write(buf, binary.BigEndian, padBfdAuthKey(p.AuthHeader.AuthData, int(authLength)-BFD_AUTH_HEADER_LEN))
+			packet := buf.Bytes()
+			copy(packet[digestStart:], computeBfdAuthDigest(p.AuthHeader.Type, packet))
 		}
 	}
 
@@ -270,7 +268,10 @@ func DecodeBfdControlPacket(data []byte) (*BfdControlPacket, error) {
 
 	if packet.AuthPresent {
 		if len(data) > 24 {
-			packet.AuthHeader, err = decodeBfdAuthHeader(data[24:])
+			// Receive buffer is reused, keep a copy of the packet for authentication
+			packet.authPacket = make([]byte, len(data))
+			copy(packet.authPacket, data)
+			packet.AuthHeader, err = decodeBfdAuthHeader(packet.authPacket[24:])
 		} else {
 			err = errors.New("Header flag set, but packet too short!")
 		}
@@ -0,0 +1,339 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Echo packets are sent to our own address through the neighbor's MAC, so the neighbor's
+// forwarding plane loops them back to us on DEST_PORT_ECHO. The contents are a local matter.
+type BfdEchoPacket struct {
+	Version         uint8
+	MyDiscriminator uint32
+	SequenceNumber  uint32
+}
+
+const (
+	DEFAULT_ECHO_PACKET_LEN = 12
+)
+
+var bfdEchoPcapTimeout time.Duration = time.Second
+var bfdEchoRetryInterval time.Duration = 5 * time.Second
+
+/*
+ * Create an echo packet
+ */
+func (p *BfdEchoPacket) CreateBfdEchoPacket() ([]byte, error) {
+	buf := bytes.NewBuffer([]uint8{})
+	binary.Write(buf, binary.BigEndian, p.Version<<5)
+	binary.Write(buf, binary.BigEndian, [3]uint8{})
+	binary.Write(buf, binary.BigEndian, p.MyDiscriminator)
+	binary.Write(buf, binary.BigEndian, p.SequenceNumber)
+	return buf.Bytes(), nil
+}
+
+/*
+ * Decode the echo packet
+ */
+func DecodeBfdEchoPacket(data []byte) (*BfdEchoPacket, error) {
+	if len(data) < DEFAULT_ECHO_PACKET_LEN {
+		return nil, errors.New("Echo packet too short!")
+	}
+	packet := &BfdEchoPacket{}
+	packet.Version = uint8((data[0] & 0xE0) >> 5)
+	if packet.Version != DEFAULT_BFD_VERSION {
+		return nil, errors.New("Echo packet version mis-match!")
+	}
+	packet.MyDiscriminator = binary.BigEndian.Uint32(data[4:8])
+	packet.SequenceNumber = binary.BigEndian.Uint32(data[8:12])
+	return packet, nil
+}
+
+// StartBfdEchoServer receives the echo packets looped back by the neighbors.
+func (server *BFDServer) StartBfdEchoServer() error {
+	destAddr := net.JoinHostPort("", strconv.Itoa(DEST_PORT_ECHO))
+	ServerAddr, err := net.ResolveUDPAddr("udp4", destAddr)
+	if err != nil {
+		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
+		return err
+	}
+	ServerConn, err := net.ListenUDP("udp4", ServerAddr)
+	if err != nil {
+		server.logger.Info("Failed ListenUDP ", err)
+		return err
+	}
+	defer ServerConn.Close()
+	buf := make([]byte, 1024)
+	server.logger.Info("Started BFD echo server on ", destAddr)
+	for {
+		length, _, err := ServerConn.ReadFromUDP(buf)
+		if err != nil {
+			server.logger.Info("Failed to read from ", ServerAddr)
+			continue
+		}
+		echoPacket, err := DecodeBfdEchoPacket(buf[0:length])
+		if err != nil {
+			server.logger.Info("Failed to decode echo packet - ", err)
+			continue
+		}
+		server.DispatchReceivedEchoPacket(echoPacket)
+	}
+	return nil
+}
+
+func (server *BFDServer) DispatchReceivedEchoPacket(echoPacket *BfdEchoPacket) error {
+	sessionId := int32(echoPacket.MyDiscriminator)
+	session, exist := server.bfdGlobal.Sessions[sessionId]
+	if !exist || session == nil {
+		return nil
+	}
+	session.sessionLock.Lock()
+	if session.state.EchoActive && session.echoTimer != nil {
+		session.state.NumRxEchoPackets++
+		session.echoTimer.Reset(session.GetEchoDetectionTime())
+	}
+	session.sessionLock.Unlock()
+	return nil
+}
+
+// CanRunEchoFunction checks if echo packets can be sent for the session. Echo is used only by
+// single hop IPv4 sessions which are up, when it is enabled locally by a non zero
+// RequiredMinEchoRxInterval and the neighbor is willing to loop back the echo packets.
+func (session *BfdSession) CanRunEchoFunction() bool {
+	if session.state.MultiHopSession || session.state.PerLinkSession {
+		return false
+	}
+	if session.state.SessionState != STATE_UP || session.state.RemoteSessionState != STATE_UP {
+		return false
+	}
+	if session.state.RequiredMinEchoRxInterval == 0 || session.state.RemoteMinEchoRxInterval == 0 {
+		return false
+	}
+	ipAddr := net.ParseIP(session.state.IpAddr)
+	if ipAddr == nil || ipAddr.To4() == nil {
+		return false
+	}
+	return true
+}
+
+// Echo packets are not sent faster than the neighbor is willing to receive them.
+func (session *BfdSession) GetEchoTxInterval() int32 {
+	if session.state.RemoteMinEchoRxInterval > session.state.RequiredMinEchoRxInterval {
+		return session.state.RemoteMinEchoRxInterval
+	}
+	return session.state.RequiredMinEchoRxInterval
+}
+
+func (session *BfdSession) GetEchoDetectionTime() time.Duration {
+	return time.Duration(session.echoTxInterval*session.state.DetectionMultiplier/1000) * time.Millisecond
+}
+
+// CheckEchoFunction starts or stops the echo function after a change in session state or parameters.
+func (session *BfdSession) CheckEchoFunction() {
+	if session.CanRunEchoFunction() {
+		session.echoTxInterval = session.GetEchoTxInterval()
+		if !session.state.EchoActive {
+			session.StartEchoFunction()
+		}
+	} else {
+		session.StopEchoFunction()
+	}
+}
+
+func (session *BfdSession) StartEchoFunction() {
+	session.server.logger.Info("Starting echo function for session ", session.state.SessionId)
+	session.state.EchoActive = true
+	session.echoStopCh = make(chan bool, 1)
+	go session.StartSessionEchoClient(session.server, session.echoStopCh)
+}
+
+func (session *BfdSession) StopEchoFunction() {
+	session.sessionLock.Lock()
+	if session.echoRetryTimer != nil {
+		session.echoRetryTimer.Stop()
+		session.echoRetryTimer = nil
+	}
+	session.sessionLock.Unlock()
+	if session.state.EchoActive {
+		session.server.logger.Info("Stopping echo function for session ", session.state.SessionId)
+		session.state.EchoActive = false
+		session.echoStopCh <- true
+	}
+}
+
+func (server *BFDServer) getEchoIntfName(session *BfdSession) (string, error) {
+	if session.state.Interface != "" {
+		return session.state.Interface, nil
+	}
+	reachabilityInfo, err := server.ribdClient.ClientHdl.GetRouteReachabilityInfo(session.state.IpAddr, -1)
+	if err != nil || !reachabilityInfo.IsReachable {
+		return "", errors.New(session.state.IpAddr + " is not reachable")
+	}
+	return server.getLinuxIntfName(int32(reachabilityInfo.NextHopIfIndex))
+}
+
+// echoClientFailed marks the echo function inactive when the echo client could not start, and checks it
+// again after the retry interval. Nothing is done if the echo function was stopped or restarted meanwhile.
+func (session *BfdSession) echoClientFailed(stopCh chan bool) {
+	session.sessionLock.Lock()
+	defer session.sessionLock.Unlock()
+	if !session.state.EchoActive || session.echoStopCh != stopCh {
+		return
+	}
+	session.state.EchoActive = false
+	session.echoStopCh = nil
+	session.echoRetryTimer = time.AfterFunc(bfdEchoRetryInterval, func() { session.CheckEchoFunction() })
+}
+
+// StartSessionEchoClient transmits echo packets for the session. The neighbor's MAC is learnt
+// from the control packets it sends to us, the echo detection timer is started with the first
+// echo packet sent.
+func (session *BfdSession) StartSessionEchoClient(server *BFDServer, stopCh chan bool) error {
+	ifName, err := server.getEchoIntfName(session)
+	if err != nil {
+		server.logger.Err("Unable to find the interface for echo on session ", session.state.SessionId, err)
+		session.echoClientFailed(stopCh)
+		return err
+	}
+	myMacAddr, err := server.getMacAddrFromIntfName(ifName)
+	if err != nil {
+		server.logger.Err("Unable to get the MAC addr of ", ifName, err)
+		session.echoClientFailed(stopCh)
+		return err
+	}
+	pcapHandle, err := pcap.OpenLive(ifName, bfdSnapshotLen, bfdPromiscuous, bfdEchoPcapTimeout)
+	if pcapHandle == nil {
+		server.logger.Err("Failed to open echo pcap handle for ", ifName, err)
+		session.echoClientFailed(stopCh)
+		return err
+	}
+	defer pcapHandle.Close()
+	filter := fmt.Sprintf("udp and src host %s and dst port %d", session.state.IpAddr, DEST_PORT)
+	err = pcapHandle.SetBPFFilter(filter)
+	if err != nil {
+		server.logger.Err("Unable to set filter on", ifName, err)
+		session.echoClientFailed(stopCh)
+		return err
+	}
+	server.logger.Info("Started echo client for session ", session.state.SessionId, " on ", ifName)
+	packets := gopacket.NewPacketSource(pcapHandle, layers.LayerTypeEthernet).Packets()
+	txTimer := time.NewTimer(0)
+	defer txTimer.Stop()
+	defer func() {
+		session.sessionLock.Lock()
+		if session.echoTimer != nil {
+			session.echoTimer.Stop()
+			session.echoTimer = nil
+		}
+		session.sessionLock.Unlock()
+	}()
+	for {
+		select {
+		case receivedPacket, ok := <-packets:
+			if !ok {
+				server.logger.Err("Echo pcap handle closed for session ", session.state.SessionId)
+				session.echoClientFailed(stopCh)
+				return nil
+			}
+			ethLayer := receivedPacket.Layer(layers.LayerTypeEthernet)
+			if ethPacket, ok := ethLayer.(*layers.Ethernet); ok {
+				session.state.RemoteMacAddr = ethPacket.SrcMAC
+			}
+		case <-txTimer.C:
+			if session.state.RemoteMacAddr != nil {
+				session.SendEchoPacket(pcapHandle, myMacAddr)
+			}
+			txTimer.Reset(time.Duration(session.echoTxInterval/1000) * time.Millisecond)
+		case <-stopCh:
+			server.logger.Info("Exiting echo client ", session.state.SessionId)
+			return nil
+		}
+	}
+}
+
+func (session *BfdSession) SendEchoPacket(pcapHandle *pcap.Handle, myMacAddr net.HardwareAddr) error {
+	localIp := net.ParseIP(session.state.LocalAddr)
+	ethLayer := &layers.Ethernet{
+		SrcMAC:       myMacAddr,
+		DstMAC:       session.state.RemoteMacAddr,
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ipLayer := &layers.IPv4{
+		Version:  4,
+		TTL:      BFD_TX_TTL,
+		SrcIP:    localIp,
+		DstIP:    localIp,
+		Protocol: layers.IPProtocolUDP,
+	}
+	udpLayer := &layers.UDP{
+		SrcPort: layers.UDPPort(SRC_PORT_ECHO),
+		DstPort: layers.UDPPort(DEST_PORT_ECHO),
+	}
+	udpLayer.SetNetworkLayerForChecksum(ipLayer)
+	options := gopacket.SerializeOptions{
+		FixLengths:       true,
+		ComputeChecksums: true,
+	}
+	session.echoSeqNum++
+	echoPacket := &BfdEchoPacket{
+		Version:         DEFAULT_BFD_VERSION,
+		MyDiscriminator: session.state.LocalDiscriminator,
+		SequenceNumber:  session.echoSeqNum,
+	}
+	echoBuf, _ := echoPacket.CreateBfdEchoPacket()
+	buffer := gopacket.NewSerializeBuffer()
+	gopacket.SerializeLayers(buffer, options, ethLayer, ipLayer, udpLayer, gopacket.Payload(echoBuf))
+	err := pcapHandle.WritePacketData(buffer.Bytes())
+	if err != nil {
+		session.server.logger.Info("Failed to send echo packet for session ", session.state.SessionId)
+		return err
+	}
+	session.sessionLock.Lock()
+	session.state.NumTxEchoPackets++
+	if session.echoTimer == nil {
+		session.echoTimer = time.AfterFunc(session.GetEchoDetectionTime(), func() { session.HandleEchoTimeout() })
+	}
+	session.sessionLock.Unlock()
+	return nil
+}
+
+// HandleEchoTimeout brings the session down when the echo packets stop coming back.
+func (session *BfdSession) HandleEchoTimeout() {
+	if !session.state.EchoActive {
+		return
+	}
+	session.server.logger.Info("Echo timer expired for: ", session.state.IpAddr, " session id ", session.state.SessionId, " at ", time.Now().String())
+	session.state.LocalDiagType = DIAG_ECHO_FAILED
+	session.EventHandler(ECHO_TIMEOUT)
+}
@@ -0,0 +1,173 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"time"
+)
+
+// Lifetimes with zero start/end time are not bounded on that side
+type KeyChainKeyConfig struct {
+	KeyChain            string
+	KeyId               int32
+	AuthKey             string
+	SendLifetimeStart   time.Time
+	SendLifetimeEnd     time.Time
+	AcceptLifetimeStart time.Time
+	AcceptLifetimeEnd   time.Time
+}
+
+type BfdKeyChainKey struct {
+	keyId               uint8
+	authKey             string
+	sendLifetimeStart   time.Time
+	sendLifetimeEnd     time.Time
+	acceptLifetimeStart time.Time
+	acceptLifetimeEnd   time.Time
+}
+
+type BfdKeyChain struct {
+	name string
+	keys map[uint8]*BfdKeyChainKey
+}
+
+func isWithinKeyLifetime(start time.Time, end time.Time, now time.Time) bool {
+	if !start.IsZero() && now.Before(start) {
+		return false
+	}
+	if !end.IsZero() && !now.Before(end) {
+		return false
+	}
+	return true
+}
+
+/*
+ * Key used for transmit is the one with most recent send lifetime start, so that a new key
+ * takes over as soon as it becomes valid while the old key is still accepted by the peer.
+ */
+func (keyChain *BfdKeyChain) getSendKey(now time.Time) *BfdKeyChainKey {
+	var sendKey *BfdKeyChainKey
+	for _, key := range keyChain.keys {
+		if !isWithinKeyLifetime(key.sendLifetimeStart, key.sendLifetimeEnd, now) {
+			continue
+		}
+		if sendKey == nil ||
+			key.sendLifetimeStart.After(sendKey.sendLifetimeStart) ||
+			(key.sendLifetimeStart.Equal(sendKey.sendLifetimeStart) && key.keyId > sendKey.keyId) {
+			sendKey = key
+		}
+	}
+	return sendKey
+}
+
+func (keyChain *BfdKeyChain) getAcceptKey(keyId uint8, now time.Time) *BfdKeyChainKey {
+	key, exist := keyChain.keys[keyId]
+	if !exist || !isWithinKeyLifetime(key.acceptLifetimeStart, key.acceptLifetimeEnd, now) {
+		return nil
+	}
+	return key
+}
+
+func (server *BFDServer) processKeyChainKeyConfig(keyConfig KeyChainKeyConfig) error {
+	keyChain, exist := server.bfdGlobal.KeyChains[keyConfig.KeyChain]
+	if !exist {
+		server.logger.Info("Creating key chain: ", keyConfig.KeyChain)
+		keyChain = &BfdKeyChain{
+			name: keyConfig.KeyChain,
+			keys: make(map[uint8]*BfdKeyChainKey),
+		}
+		server.bfdGlobal.KeyChains[keyConfig.KeyChain] = keyChain
+	}
+	server.logger.Info("Updating key ", keyConfig.KeyId, " in key chain: ", keyConfig.KeyChain)
+	keyChain.keys[uint8(keyConfig.KeyId)] = &BfdKeyChainKey{
+		keyId:               uint8(keyConfig.KeyId),
+		authKey:             keyConfig.AuthKey,
+		sendLifetimeStart:   keyConfig.SendLifetimeStart,
+		sendLifetimeEnd:     keyConfig.SendLifetimeEnd,
+		acceptLifetimeStart: keyConfig.AcceptLifetimeStart,
+		acceptLifetimeEnd:   keyConfig.AcceptLifetimeEnd,
+	}
+	server.UpdateBfdSessionsUsingKeyChain(keyConfig.KeyChain)
+	return nil
+}
+
+func (server *BFDServer) processKeyChainKeyDelete(keyConfig KeyChainKeyConfig) error {
+	keyChain, exist := server.bfdGlobal.KeyChains[keyConfig.KeyChain]
+	if exist {
+		server.logger.Info("Deleting key ", keyConfig.KeyId, " from key chain: ", keyConfig.KeyChain)
+		delete(keyChain.keys, uint8(keyConfig.KeyId))
+		if len(keyChain.keys) == 0 {
+			server.logger.Info("Deleting key chain: ", keyConfig.KeyChain)
+			delete(server.bfdGlobal.KeyChains, keyConfig.KeyChain)
+		}
+		server.UpdateBfdSessionsUsingKeyChain(keyConfig.KeyChain)
+	}
+	return nil
+}
+
+func (server *BFDServer) UpdateBfdSessionsUsingKeyChain(keyChainName string) error {
+	for _, session := range server.bfdGlobal.Sessions {
+		if session.authEnabled && session.authKeyChain == keyChainName {
+			session.paramChanged = true
+		}
+	}
+	return nil
+}
+
+/*
+ * Key ID and key used for transmit. Sessions without a key chain use the
+ * static key from the session param.
+ */
+func (session *BfdSession) getAuthSendKey() (uint8, string, bool) {
+	if session.authKeyChain == "" {
+		return uint8(session.authKeyId), session.authData, true
+	}
+	keyChain, exist := session.server.bfdGlobal.KeyChains[session.authKeyChain]
+	if !exist {
+		return 0, "", false
+	}
+	key := keyChain.getSendKey(time.Now())
+	if key == nil {
+		return 0, "", false
+	}
+	return key.keyId, key.authKey, true
+}
+
+func (session *BfdSession) getAuthAcceptKey(keyId uint8) (string, bool) {
+	if session.authKeyChain == "" {
+		if uint32(keyId) != session.authKeyId {
+			return "", false
+		}
+		return session.authData, true
+	}
+	keyChain, exist := session.server.bfdGlobal.KeyChains[session.authKeyChain]
+	if !exist {
+		return "", false
+	}
+	key := keyChain.getAcceptKey(keyId, time.Now())
+	if key == nil {
+		return "", false
+	}
+	return key.authKey, true
+}
@@ -174,6 +174,7 @@ func (session *BfdSession) StartPerLinkSessionClient(bfdServer *BFDServer) error
 					bfdSession.useDedicatedMac = false
 				}
 				bfdSession.state.NumTxPackets++
+				bfdSession.AuthenticatedPacketSent()
 				txTimerMS = time.Duration(bfdSession.state.DesiredMinTxInterval / 1000)
 				bfdSession.txTimer.Reset(time.Millisecond * txTimerMS)
 			}
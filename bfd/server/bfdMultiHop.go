@@ -0,0 +1,124 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+	"net"
+	"strconv"
+)
+
+// setBfdTxTTL sets the TTL/Hop Limit of the transmitted control packets to 255 as required by
+// RFC 5881 for single hop sessions and RFC 5883 for multi-hop sessions.
+func setBfdTxTTL(conn *net.UDPConn) error {
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if ok && localAddr.IP.To4() == nil {
+		return ipv6.NewConn(conn).SetHopLimit(BFD_TX_TTL)
+	}
+	return ipv4.NewConn(conn).SetTTL(BFD_TX_TTL)
+}
+
+func isValidMultiHopTTL(ttl int) bool {
+	return ttl >= BFD_TX_TTL-MAX_MULTI_HOP_COUNT
+}
+
+// StartBfdMultiHopSessionServer receives the control packets of multi-hop sessions on DEST_PORT_MULTI_HOP.
+// Separate IPv4 and IPv6 sockets are used so that the TTL/Hop Limit of the received packets can be checked.
+func (server *BFDServer) StartBfdMultiHopSessionServer() error {
+	go server.StartBfdMultiHopSessionServerV6()
+	return server.StartBfdMultiHopSessionServerV4()
+}
+
+func (server *BFDServer) StartBfdMultiHopSessionServerV4() error {
+	destAddr := net.JoinHostPort("", strconv.Itoa(DEST_PORT_MULTI_HOP))
+	ServerAddr, err := net.ResolveUDPAddr("udp4", destAddr)
+	if err != nil {
+		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
+		return err
+	}
+	ServerConn, err := net.ListenUDP("udp4", ServerAddr)
+	if err != nil {
+		server.logger.Info("Failed ListenUDP ", err)
+		return err
+	}
+	defer ServerConn.Close()
+	packetConn := ipv4.NewPacketConn(ServerConn)
+	err = packetConn.SetControlMessage(ipv4.FlagTTL, true)
+	if err != nil {
+		server.logger.Info("Failed to set control message on ", destAddr, err)
+		return err
+	}
+	buf := make([]byte, 1024)
+	server.logger.Info("Started BFD multi-hop session server on ", destAddr)
+	for {
+		length, cm, srcAddr, err := packetConn.ReadFrom(buf)
+		if err != nil {
+			server.logger.Info("Failed to read from ", ServerAddr)
+			continue
+		}
+		if cm == nil || !isValidMultiHopTTL(cm.TTL) {
+			server.logger.Info("Dropped multi-hop packet from ", srcAddr, " invalid TTL")
+			continue
+		}
+		server.ProcessReceivedBfdPacket(buf[0:length], srcAddr, true)
+	}
+	return nil
+}
+
+func (server *BFDServer) StartBfdMultiHopSessionServerV6() error {
+	destAddr := net.JoinHostPort("", strconv.Itoa(DEST_PORT_MULTI_HOP))
+	ServerAddr, err := net.ResolveUDPAddr("udp6", destAddr)
+	if err != nil {
+		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
+		return err
+	}
+	ServerConn, err := net.ListenUDP("udp6", ServerAddr)
+	if err != nil {
+		server.logger.Info("Failed ListenUDP ", err)
+		return err
+	}
+	defer ServerConn.Close()
+	packetConn := ipv6.NewPacketConn(ServerConn)
+	err = packetConn.SetControlMessage(ipv6.FlagHopLimit, true)
+	if err != nil {
+		server.logger.Info("Failed to set control message on ", destAddr, err)
+		return err
+	}
+	buf := make([]byte, 1024)
+	server.logger.Info("Started BFD IPv6 multi-hop session server on ", destAddr)
+	for {
+		length, cm, srcAddr, err := packetConn.ReadFrom(buf)
+		if err != nil {
+			server.logger.Info("Failed to read from ", ServerAddr)
+			continue
+		}
+		if cm == nil || !isValidMultiHopTTL(cm.HopLimit) {
+			server.logger.Info("Dropped multi-hop packet from ", srcAddr, " invalid hop limit")
+			continue
+		}
+		server.ProcessReceivedBfdPacket(buf[0:length], srcAddr, true)
+	}
+	return nil
+}
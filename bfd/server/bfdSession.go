@@ -24,10 +24,6 @@
 package server
 
 import (
-	"bytes"
-	"crypto/md5"
-	"crypto/sha1"
-	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"l3/bfd/bfddCommonDefs"
@@ -55,7 +51,11 @@ func (session *BfdSession) StartSessionServer() error {
 func (session *BfdSession) StartSessionClient(server *BFDServer) error {
 	var err error
 	server.logger.Info("Starting session client for ", session.state.SessionId)
-	destAddr := net.JoinHostPort(session.state.IpAddr, strconv.Itoa(DEST_PORT))
+	destPort := DEST_PORT
+	if session.state.MultiHopSession {
+		destPort = DEST_PORT_MULTI_HOP
+	}
+	destAddr := net.JoinHostPort(session.state.IpAddr, strconv.Itoa(destPort))
 	ServerAddr, err := net.ResolveUDPAddr("udp", destAddr)
 	if err != nil {
 		server.logger.Info("Failed ResolveUDPAddr ", destAddr, err)
@@ -75,6 +75,10 @@ func (session *BfdSession) StartSessionClient(server *BFDServer) error {
 		server.FailedSessionClientCh <- session.state.SessionId
 		return err
 	}
+	err = setBfdTxTTL(Conn)
+	if err != nil {
+		server.logger.Info("Failed to set TTL for ", destAddr, err)
+	}
 	session.sessionLock.Lock()
 	session.txConn = Conn
 	server.logger.Info("Started session client for ", destAddr, localAddr)
@@ -137,7 +141,7 @@ func (session *BfdSession) EventHandler(event BfdSessionEvent) error {
 			session.LocalAdminDown()
 		case REMOTE_ADMIN_DOWN:
 			session.RemoteAdminDown()
-		case TIMEOUT, REMOTE_UP:
+		case TIMEOUT, REMOTE_UP, ECHO_TIMEOUT:
 		}
 	case STATE_INIT:
 		switch event {
@@ -149,11 +153,11 @@ func (session *BfdSession) EventHandler(event BfdSessionEvent) error {
 			session.LocalAdminDown()
 		case REMOTE_ADMIN_DOWN:
 			session.RemoteAdminDown()
-		case REMOTE_DOWN, ADMIN_UP:
+		case REMOTE_DOWN, ADMIN_UP, ECHO_TIMEOUT:
 		}
 	case STATE_UP:
 		switch event {
-		case REMOTE_DOWN, TIMEOUT:
+		case REMOTE_DOWN, TIMEOUT, ECHO_TIMEOUT:
 			session.MoveToDownState()
 		case ADMIN_DOWN:
 			session.LocalAdminDown()
@@ -209,55 +213,61 @@ func (session *BfdSession) CanProcessBfdControlPacket(bfdPacket *BfdControlPacke
 	return canProcess
 }
 
+/*
+ * Authenticate received control packet as per RFC 5880 section 6.7. Packets failing the
+ * sequence number check are counted separately from the ones failing the key/digest check.
+ */
 func (session *BfdSession) AuthenticateReceivedControlPacket(bfdPacket *BfdControlPacket) bool {
-	var authenticated bool
-	if !bfdPacket.AuthPresent {
-		authenticated = true
-	} else {
-		copiedPacket := &BfdControlPacket{}
-		*copiedPacket = *bfdPacket
-		authType := bfdPacket.AuthHeader.Type
-		keyId := uint32(bfdPacket.AuthHeader.AuthKeyID)
-		authData := bfdPacket.AuthHeader.AuthData
-		seqNum := bfdPacket.AuthHeader.SequenceNumber
-		if authType == session.authType {
-			if authType == BFD_AUTH_TYPE_SIMPLE {
-				session.server.logger.Info("Authentication type simple: keyId, authData ", keyId, string(authData))
-				if keyId == session.authKeyId && string(authData) == session.authData {
-					authenticated = true
-				}
-			} else {
-				if seqNum >= session.state.ReceivedAuthSeq && keyId == session.authKeyId {
-					var binBuf bytes.Buffer
-					copiedPacket.AuthHeader.AuthData = []byte(session.authData)
-					binary.Write(&binBuf, binary.BigEndian, copiedPacket)
-					switch authType {
-					case BFD_AUTH_TYPE_KEYED_MD5, BFD_AUTH_TYPE_METICULOUS_MD5:
-						var authDataSum [16]byte
-						authDataSum = md5.Sum(binBuf.Bytes())
-						if bytes.Equal(authData[:], authDataSum[:]) {
-							authenticated = true
-						} else {
-							session.server.logger.Info("Authentication data did't match for type: ", authType)
-						}
-					case BFD_AUTH_TYPE_KEYED_SHA1, BFD_AUTH_TYPE_METICULOUS_SHA1:
-						var authDataSum [20]byte
-						authDataSum = sha1.Sum(binBuf.Bytes())
-						if bytes.Equal(authData[:], authDataSum[:]) {
-							authenticated = true
-						} else {
-							session.server.logger.Info("Authentication data did't match for type: ", authType)
-						}
-					}
-				} else {
-					session.server.logger.Info("Sequence number and key id check failed: ", seqNum, session.state.ReceivedAuthSeq, keyId, session.authKeyId)
-				}
-			}
-		} else {
-			session.server.logger.Info("Authentication type did't match: ", authType, session.authType)
+	if !session.authEnabled {
+		if bfdPacket.AuthPresent {
+			session.server.logger.Info("Authentication not enabled, but received packet with authentication for session ", session.state.SessionId)
+			session.state.NumRxAuthFailures++
+			return false
+		}
+		return true
+	}
+	if !bfdPacket.AuthPresent || bfdPacket.AuthHeader == nil {
+		session.server.logger.Info("Authentication enabled, but received packet without authentication for session ", session.state.SessionId)
+		session.state.NumRxAuthFailures++
+		return false
+	}
+	authType := bfdPacket.AuthHeader.Type
+	keyId := bfdPacket.AuthHeader.AuthKeyID
+	authData := bfdPacket.AuthHeader.AuthData
+	seqNum := bfdPacket.AuthHeader.SequenceNumber
+	if authType != session.authType {
+		session.server.logger.Info("Authentication type did't match: ", authType, session.authType)
+		session.state.NumRxAuthFailures++
+		return false
+	}
+	authKey, found := session.getAuthAcceptKey(keyId)
+	if !found {
+		session.server.logger.Info("No valid key for key id ", keyId, " session ", session.state.SessionId)
+		session.state.NumRxAuthFailures++
+		return false
+	}
+	if authType == BFD_AUTH_TYPE_SIMPLE {
+		if string(authData) != authKey {
+			session.server.logger.Info("Authentication password did't match for session ", session.state.SessionId)
+			session.state.NumRxAuthFailures++
+			return false
 		}
+		return true
+	}
+	if session.state.AuthSeqKnown &&
+		!checkBfdAuthSeqWindow(authType, session.state.ReceivedAuthSeq, seqNum, bfdPacket.DetectMult) {
+		session.server.logger.Info("Sequence number check failed: ", seqNum, session.state.ReceivedAuthSeq, " session ", session.state.SessionId)
+		session.state.NumRxAuthSeqFailures++
+		return false
+	}
+	if !verifyBfdAuthDigest(authType, bfdPacket.authPacket, []byte(authKey), authData) {
+		session.server.logger.Info("Authentication data did't match for type: ", authType, " session ", session.state.SessionId)
+		session.state.NumRxAuthFailures++
+		return false
 	}
-	return authenticated
+	session.state.ReceivedAuthSeq = seqNum
+	session.state.AuthSeqKnown = true
+	return true
 }
 
 func (session *BfdSession) ProcessBfdPacket(bfdPacket *BfdControlPacket) error {
@@ -305,6 +315,7 @@ func (session *BfdSession) ProcessBfdPacket(bfdPacket *BfdControlPacket) error {
 		event = REMOTE_ADMIN_DOWN
 	}
 	session.EventHandler(event)
+	session.CheckEchoFunction()
 	return nil
 }
 
@@ -317,6 +328,7 @@ func (session *BfdSession) CheckAnyRemoteParamChanged(bfdPacket *BfdControlPacke
 	session.state.RemoteSessionState = bfdPacket.State
 	session.state.RemoteDiscriminator = bfdPacket.MyDiscriminator
 	session.state.RemoteMinRxInterval = int32(bfdPacket.RequiredMinRxInterval)
+	session.state.RemoteMinEchoRxInterval = int32(bfdPacket.RequiredMinEchoRxInterval)
 	session.state.RemoteDetectionMultiplier = int32(bfdPacket.DetectMult)
 	return nil
 }
@@ -327,6 +339,12 @@ func (session *BfdSession) UpdateBfdSessionControlPacket() error {
 	session.bfdPacket.DetectMult = uint8(session.state.DetectionMultiplier)
 	session.bfdPacket.MyDiscriminator = session.state.LocalDiscriminator
 	session.bfdPacket.YourDiscriminator = session.state.RemoteDiscriminator
+	// Echo function is not used over multiple hops
+	if session.state.MultiHopSession {
+		session.bfdPacket.RequiredMinEchoRxInterval = 0
+	} else {
+		session.bfdPacket.RequiredMinEchoRxInterval = time.Duration(session.state.RequiredMinEchoRxInterval)
+	}
 	if session.state.SessionState == STATE_UP && session.state.RemoteSessionState == STATE_UP {
 		if session.bfdPacket.DesiredMinTxInterval == time.Duration(STARTUP_TX_INTERVAL) ||
 			session.bfdPacket.RequiredMinRxInterval == time.Duration(STARTUP_RX_INTERVAL) {
@@ -354,20 +372,7 @@ func (session *BfdSession) UpdateBfdSessionControlPacket() error {
 	session.pollSequence = false
 	session.bfdPacket.Final = session.pollSequenceFinal
 	session.pollSequenceFinal = false
-	if session.authEnabled {
-		session.bfdPacket.AuthPresent = true
-		session.bfdPacket.AuthHeader.Type = session.authType
-		if session.authType != BFD_AUTH_TYPE_SIMPLE {
-			session.bfdPacket.AuthHeader.SequenceNumber = session.authSeqNum
-		}
-		if session.authType == BFD_AUTH_TYPE_METICULOUS_MD5 || session.authType == BFD_AUTH_TYPE_METICULOUS_SHA1 {
-			session.authSeqNum++
-		}
-		session.bfdPacket.AuthHeader.AuthKeyID = uint8(session.authKeyId)
-		session.bfdPacket.AuthHeader.AuthData = []byte(session.authData)
-	} else {
-		session.bfdPacket.AuthPresent = false
-	}
+	session.UpdateBfdSessionAuthHeader()
 	session.pollChanged = false
 	session.paramChanged = false
 	session.stateChanged = false
@@ -375,6 +380,60 @@ func (session *BfdSession) UpdateBfdSessionControlPacket() error {
 	return nil
 }
 
+func (session *BfdSession) UpdateBfdSessionAuthHeader() error {
+	if !session.authEnabled {
+		session.bfdPacket.AuthPresent = false
+		return nil
+	}
+	keyId, authKey, found := session.getAuthSendKey()
+	if !found {
+		session.server.logger.Info("No valid key to send for session ", session.state.SessionId, " key chain ", session.authKeyChain)
+		session.bfdPacket.AuthPresent = false
+		return nil
+	}
+	if session.bfdPacket.AuthHeader == nil {
+		session.bfdPacket.AuthHeader = &BfdAuthHeader{}
+	}
+	session.bfdPacket.AuthPresent = true
+	session.bfdPacket.AuthHeader.Type = session.authType
+	session.bfdPacket.AuthHeader.AuthKeyID = keyId
+	if session.authType != BFD_AUTH_TYPE_SIMPLE {
+		session.bfdPacket.AuthHeader.SequenceNumber = session.authSeqNum
+	}
+	session.bfdPacket.AuthHeader.AuthData = []byte(authKey)
+	return nil
+}
+
+// Sequence number has to be advanced for every packet in meticulous modes, and
+// the send key changes when a key chain rolls over to a new key.
+func (session *BfdSession) NeedBfdAuthUpdate() bool {
+	if !session.authEnabled {
+		return false
+	}
+	if !session.bfdPacket.AuthPresent || session.bfdPacket.AuthHeader == nil {
+		return true
+	}
+	if session.authType != BFD_AUTH_TYPE_SIMPLE &&
+		session.bfdPacket.AuthHeader.SequenceNumber != session.authSeqNum {
+		return true
+	}
+	keyId, authKey, found := session.getAuthSendKey()
+	if !found {
+		return false
+	}
+	return keyId != session.bfdPacket.AuthHeader.AuthKeyID || authKey != string(session.bfdPacket.AuthHeader.AuthData)
+}
+
+func (session *BfdSession) AuthenticatedPacketSent() {
+	if !session.bfdPacket.AuthPresent || session.authType == BFD_AUTH_TYPE_SIMPLE {
+		return
+	}
+	session.state.SentAuthSeq = session.bfdPacket.AuthHeader.SequenceNumber
+	if IsMeticulousAuthType(session.authType) {
+		session.authSeqNum++
+	}
+}
+
 func (session *BfdSession) CheckIfAnyProtocolRegistered() bool {
 	for i := bfddCommonDefs.BfdSessionOwner(1); i < bfddCommonDefs.MAX_APPS; i++ {
 		if session.state.RegisteredProtocols[i] == true {
@@ -449,6 +508,8 @@ func (session *BfdSession) ResetLocalSessionParams() error {
 func (session *BfdSession) ResetRemoteSessionParams() error {
 	session.state.RemoteDiscriminator = 0
 	session.state.RemoteSessionState = STATE_DOWN
+	// No packets received for twice the detection time
+	session.state.AuthSeqKnown = false
 	session.remoteParamChanged = true
 	return nil
 }
@@ -457,6 +518,7 @@ func (session *BfdSession) LocalAdminDown() error {
 	session.state.SessionState = STATE_ADMIN_DOWN
 	session.state.LocalDiagType = DIAG_ADMIN_DOWN
 	session.stateChanged = true
+	session.StopEchoFunction()
 	session.SendBfdNotification()
 	session.txInterval = STARTUP_TX_INTERVAL / 1000
 	session.txTimer.Reset(0)
@@ -468,6 +530,7 @@ func (session *BfdSession) LocalAdminDown() error {
 func (session *BfdSession) RemoteAdminDown() error {
 	session.state.RemoteSessionState = STATE_ADMIN_DOWN
 	session.state.LocalDiagType = DIAG_NEIGHBOR_SIGNAL_DOWN
+	session.StopEchoFunction()
 	session.SendBfdNotification()
 	session.txInterval = STARTUP_TX_INTERVAL / 1000
 	session.txTimer.Reset(0)
@@ -486,6 +549,7 @@ func (session *BfdSession) MoveToDownState() error {
 	if session.authType == BFD_AUTH_TYPE_KEYED_MD5 || session.authType == BFD_AUTH_TYPE_KEYED_SHA1 {
 		session.authSeqNum++
 	}
+	session.StopEchoFunction()
 	session.SendBfdNotification()
 	session.txInterval = STARTUP_TX_INTERVAL / 1000
 	session.txTimer.Reset(time.Duration(session.txInterval) * time.Millisecond)
@@ -535,12 +599,19 @@ func (session *BfdSession) SendPeriodicControlPackets() {
 		if err != nil {
 			session.server.logger.Info("Failed to create control packet for session ", session.state.SessionId)
 		}
+	} else if session.NeedBfdAuthUpdate() {
+		session.UpdateBfdSessionAuthHeader()
+		session.bfdPacketBuf, err = session.bfdPacket.CreateBfdControlPacket()
+		if err != nil {
+			session.server.logger.Info("Failed to create control packet for session ", session.state.SessionId)
+		}
 	}
 	_, err = session.txConn.Write(session.bfdPacketBuf)
 	if err != nil {
 		session.server.logger.Info("failed to send control packet for session ", session.state.SessionId)
 	} else {
 		session.state.NumTxPackets++
+		session.AuthenticatedPacketSent()
 	}
 	if packetUpdated {
 		// Re-compute the packet to clear any flag set in the previously sent packet
@@ -67,6 +67,8 @@ func (server *BFDServer) GetBulkBfdSessionStates(idx int, cnt int) (int, int, []
 		result[i].SentAuthSeq = server.bfdGlobal.Sessions[sessionId].state.SentAuthSeq
 		result[i].NumTxPackets = server.bfdGlobal.Sessions[sessionId].state.NumTxPackets
 		result[i].NumRxPackets = server.bfdGlobal.Sessions[sessionId].state.NumRxPackets
+		result[i].NumRxAuthFailures = server.bfdGlobal.Sessions[sessionId].state.NumRxAuthFailures
+		result[i].NumRxAuthSeqFailures = server.bfdGlobal.Sessions[sessionId].state.NumRxAuthSeqFailures
 		result[i].ToDownCount = server.bfdGlobal.Sessions[sessionId].state.ToDownCount
 		result[i].ToUpCount = server.bfdGlobal.Sessions[sessionId].state.ToUpCount
 		result[i].UpTime = server.bfdGlobal.Sessions[sessionId].state.UpTime
@@ -106,6 +108,8 @@ func (server *BFDServer) GetBfdSessionState(ipAddr string) (*SessionState, bool)
 		sessionState.SentAuthSeq = server.bfdGlobal.Sessions[sessionId].state.SentAuthSeq
 		sessionState.NumTxPackets = server.bfdGlobal.Sessions[sessionId].state.NumTxPackets
 		sessionState.NumRxPackets = server.bfdGlobal.Sessions[sessionId].state.NumRxPackets
+		sessionState.NumRxAuthFailures = server.bfdGlobal.Sessions[sessionId].state.NumRxAuthFailures
+		sessionState.NumRxAuthSeqFailures = server.bfdGlobal.Sessions[sessionId].state.NumRxAuthSeqFailures
 		sessionState.ToDownCount = server.bfdGlobal.Sessions[sessionId].state.ToDownCount
 		sessionState.ToUpCount = server.bfdGlobal.Sessions[sessionId].state.ToUpCount
 		sessionState.UpTime = server.bfdGlobal.Sessions[sessionId].state.UpTime
@@ -131,6 +135,7 @@ func (server *BFDServer) GetBulkBfdSessionParamStates(idx int, cnt int) (int, in
 		result[i].AuthenticationType = sessionParam.state.AuthenticationType
 		result[i].AuthenticationKeyId = sessionParam.state.AuthenticationKeyId
 		result[i].AuthenticationData = sessionParam.state.AuthenticationData
+		result[i].AuthenticationKeyChain = sessionParam.state.AuthenticationKeyChain
 		i++
 	}
 	count = i
@@ -153,6 +158,7 @@ func (server *BFDServer) GetBfdSessionParamState(paramName string) (*SessionPara
 		sessionParamState.AuthenticationType = server.bfdGlobal.SessionParams[paramName].state.AuthenticationType
 		sessionParamState.AuthenticationKeyId = server.bfdGlobal.SessionParams[paramName].state.AuthenticationKeyId
 		sessionParamState.AuthenticationData = server.bfdGlobal.SessionParams[paramName].state.AuthenticationData
+		sessionParamState.AuthenticationKeyChain = server.bfdGlobal.SessionParams[paramName].state.AuthenticationKeyChain
 	}
 	return sessionParamState, found
 }
@@ -62,6 +62,7 @@ func (server *BFDServer) processSessionParamConfig(paramConfig SessionParamConfi
 	sessionParam.state.AuthenticationType = paramConfig.AuthenticationType
 	sessionParam.state.AuthenticationKeyId = paramConfig.AuthenticationKeyId
 	sessionParam.state.AuthenticationData = paramConfig.AuthenticationData
+	sessionParam.state.AuthenticationKeyChain = paramConfig.AuthenticationKeyChain
 	if !exist {
 		server.bfdGlobal.NumSessionParams++
 	}
@@ -51,6 +51,8 @@ func (server *BFDServer) StartSessionHandler() error {
 	server.FailedSessionClientCh = make(chan int32, MAX_NUM_SESSIONS)
 	server.tobeCreatedSessions = make(map[string]BfdSessionMgmt)
 	go server.StartBfdSesionServer()
+	go server.StartBfdMultiHopSessionServer()
+	go server.StartBfdEchoServer()
 	go server.StartBfdSessionRxTx()
 	go server.StartSessionRetryHandler()
 	for {
@@ -71,12 +73,16 @@ func (server *BFDServer) StartSessionHandler() error {
 	return nil
 }
 
-func (server *BFDServer) DispatchReceivedBfdPacket(ipAddr string, bfdPacket *BfdControlPacket) error {
+func (server *BFDServer) DispatchReceivedBfdPacket(ipAddr string, bfdPacket *BfdControlPacket, multiHop bool) error {
 	sessionId := int32(bfdPacket.YourDiscriminator)
 	session, exist := server.bfdGlobal.Sessions[sessionId]
 	if !exist {
 		session, exist = server.bfdGlobal.SessionsByIp[ipAddr]
 	}
+	if exist && session != nil && session.state.MultiHopSession != multiHop {
+		server.logger.Info("Received packet on wrong port for session ", session.state.SessionId, " multihop ", session.state.MultiHopSession)
+		return nil
+	}
 	if exist && session != nil {
 		session.sessionLock.Lock()
 		if session.IsSessionActive() && session.rxInterval != 0 {
@@ -102,8 +108,6 @@ func (server *BFDServer) DispatchReceivedBfdPacket(ipAddr string, bfdPacket *Bfd
 }
 
 func (server *BFDServer) StartBfdSesionServer() error {
-	var ipAddr string
-	var bfdPacket *BfdControlPacket
 	var err error
 	destAddr := net.JoinHostPort("", strconv.Itoa(DEST_PORT))
 	ServerAddr, err := net.ResolveUDPAddr("udp", destAddr)
@@ -124,26 +128,31 @@ func (server *BFDServer) StartBfdSesionServer() error {
 		if err != nil {
 			server.logger.Info("Failed to read from ", ServerAddr)
 		} else {
-			if length >= DEFAULT_CONTROL_PACKET_LEN {
-				bfdPacket, err = DecodeBfdControlPacket(buf[0:length])
-				if err != nil {
-					server.logger.Info("Failed to decode packet - ", err)
-					continue
-				} else {
-					ipAddr, _, err = net.SplitHostPort(udpAddr.String())
-					if err == nil {
-						err = server.DispatchReceivedBfdPacket(ipAddr, bfdPacket)
-						if err != nil {
-							server.logger.Info("Failed to dispatch received packet")
-						}
-					}
-				}
-			}
+			server.ProcessReceivedBfdPacket(buf[0:length], udpAddr, false)
 		}
 	}
 	return nil
 }
 
+func (server *BFDServer) ProcessReceivedBfdPacket(buf []byte, srcAddr net.Addr, multiHop bool) error {
+	if len(buf) < DEFAULT_CONTROL_PACKET_LEN {
+		return nil
+	}
+	bfdPacket, err := DecodeBfdControlPacket(buf)
+	if err != nil {
+		server.logger.Info("Failed to decode packet - ", err)
+		return err
+	}
+	ipAddr, _, err := net.SplitHostPort(srcAddr.String())
+	if err == nil {
+		err = server.DispatchReceivedBfdPacket(ipAddr, bfdPacket, multiHop)
+		if err != nil {
+			server.logger.Info("Failed to dispatch received packet")
+		}
+	}
+	return err
+}
+
 func (server *BFDServer) StartBfdSessionRxTx() error {
 	for {
 		select {
@@ -212,6 +221,7 @@ func (server *BFDServer) processSessionConfig(sessionConfig SessionConfig) error
 		Interface: sessionConfig.Interface,
 		Protocol:  sessionConfig.Protocol,
 		PerLink:   sessionConfig.PerLink,
+		MultiHop:  sessionConfig.MultiHop,
 	}
 	switch sessionConfig.Operation {
 	case bfddCommonDefs.CREATE:
@@ -293,7 +303,7 @@ func (server *BFDServer) GetTxJitter() int32 {
 	return jitter
 }
 
-func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, DestIp string, ParamName string, PerLink bool, Protocol bfddCommonDefs.BfdSessionOwner) *BfdSession {
+func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, DestIp string, ParamName string, PerLink bool, MultiHop bool, Protocol bfddCommonDefs.BfdSessionOwner) *BfdSession {
 	bfdSession := &BfdSession{}
 	sessionId := server.GetNewSessionId()
 	if sessionId == 0 {
@@ -305,6 +315,7 @@ func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, D
 	bfdSession.state.LocalAddr = LocalIp
 	bfdSession.state.Interface = Interface
 	bfdSession.state.PerLinkSession = PerLink
+	bfdSession.state.MultiHopSession = MultiHop
 	if PerLink {
 		bfdSession.state.LocalMacAddr, _ = server.getMacAddrFromIntfName(Interface)
 		bfdSession.state.RemoteMacAddr, _ = net.ParseMAC(bfdDedicatedMac)
@@ -329,13 +340,18 @@ func (server *BFDServer) NewNormalBfdSession(Interface string, LocalIp string, D
 	bfdSession.rxInterval = (STARTUP_RX_INTERVAL * sessionParam.state.LocalMultiplier) / 1000
 	bfdSession.state.DesiredMinTxInterval = sessionParam.state.DesiredMinTxInterval
 	bfdSession.state.RequiredMinRxInterval = sessionParam.state.RequiredMinRxInterval
+	bfdSession.state.RequiredMinEchoRxInterval = sessionParam.state.RequiredMinEchoRxInterval
 	bfdSession.state.DetectionMultiplier = sessionParam.state.LocalMultiplier
 	bfdSession.state.DemandMode = sessionParam.state.DemandEnabled
 	bfdSession.authEnabled = sessionParam.state.AuthenticationEnabled
 	bfdSession.authType = AuthenticationType(sessionParam.state.AuthenticationType)
-	bfdSession.authSeqNum = 1
+	bfdSession.authSeqNum = server.GetInitialAuthSeq()
 	bfdSession.authKeyId = uint32(sessionParam.state.AuthenticationKeyId)
 	bfdSession.authData = sessionParam.state.AuthenticationData
+	bfdSession.authKeyChain = sessionParam.state.AuthenticationKeyChain
+	if bfdSession.authEnabled {
+		bfdSession.state.AuthType = bfdSession.authType
+	}
 	bfdSession.paramChanged = true
 	bfdSession.bfdPacket = NewBfdControlPacketDefault()
 	bfdSession.server = server
@@ -354,7 +370,7 @@ func (server *BFDServer) NewPerLinkBfdSessions(IfIndex int32, LocalIp string, De
 	if exist {
 		for _, link := range lag.Links {
 			IfName, _ := server.getLinuxIntfName(IfIndex)
-			bfdSession := server.NewNormalBfdSession(IfName, LocalIp, DestIp, ParamName, true, Protocol)
+			bfdSession := server.NewNormalBfdSession(IfName, LocalIp, DestIp, ParamName, true, false, Protocol)
 			if bfdSession == nil {
 				server.logger.Info("Failed to create perlink session on ", link)
 			}
@@ -365,9 +381,14 @@ func (server *BFDServer) NewPerLinkBfdSessions(IfIndex int32, LocalIp string, De
 	return nil
 }
 
-func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interface string, Protocol bfddCommonDefs.BfdSessionOwner, PerLink bool) *BfdSession {
+func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interface string, Protocol bfddCommonDefs.BfdSessionOwner, PerLink bool, MultiHop bool) *BfdSession {
 	var IfType int
 	var interfaceSpecific bool
+	// Multi-hop sessions are not bound to the outgoing interface
+	if MultiHop {
+		Interface = ""
+		PerLink = false
+	}
 	if Interface != "" {
 		interfaceSpecific = true
 	}
@@ -388,8 +409,10 @@ func (server *BFDServer) NewBfdSession(DestIp string, ParamName string, Interfac
 	if IfType == commonDefs.IfTypeLag && PerLink {
 		server.NewPerLinkBfdSessions(IfIndex, localIp, DestIp, ParamName, Protocol)
 	} else {
-		bfdSession := server.NewNormalBfdSession(Interface, localIp, DestIp, ParamName, false, Protocol)
-		bfdSession.state.InterfaceSpecific = interfaceSpecific
+		bfdSession := server.NewNormalBfdSession(Interface, localIp, DestIp, ParamName, false, MultiHop, Protocol)
+		if bfdSession != nil {
+			bfdSession.state.InterfaceSpecific = interfaceSpecific
+		}
 		return bfdSession
 	}
 	return nil
@@ -402,21 +425,30 @@ func (server *BFDServer) UpdateBfdSessionsUsingParam(paramName string) error {
 			if paramExist {
 				session.state.DesiredMinTxInterval = sessionParam.state.DesiredMinTxInterval
 				session.state.RequiredMinRxInterval = sessionParam.state.RequiredMinRxInterval
+				session.state.RequiredMinEchoRxInterval = sessionParam.state.RequiredMinEchoRxInterval
 				session.state.DetectionMultiplier = sessionParam.state.LocalMultiplier
 				session.state.DemandMode = sessionParam.state.DemandEnabled
 				session.authEnabled = sessionParam.state.AuthenticationEnabled
 				session.authType = AuthenticationType(sessionParam.state.AuthenticationType)
 				session.authKeyId = uint32(sessionParam.state.AuthenticationKeyId)
 				session.authData = sessionParam.state.AuthenticationData
+				session.authKeyChain = sessionParam.state.AuthenticationKeyChain
 			} else {
 				session.state.DesiredMinTxInterval = DEFAULT_DESIRED_MIN_TX_INTERVAL
 				session.state.RequiredMinRxInterval = DEFAULT_REQUIRED_MIN_RX_INTERVAL
+				session.state.RequiredMinEchoRxInterval = DEFAULT_REQUIRED_MIN_ECHO_RX_INTERVAL
 				session.state.DetectionMultiplier = DEFAULT_DETECT_MULTI
 				session.state.DemandMode = false
 				session.authEnabled = false
 			}
+			if session.authEnabled {
+				session.state.AuthType = session.authType
+			} else {
+				session.state.AuthType = BFD_AUTH_TYPE_RESERVED
+			}
 			session.paramChanged = true
 			session.InitiatePollSequence()
+			session.CheckEchoFunction()
 		}
 	}
 	return nil
@@ -474,6 +506,7 @@ func (server *BFDServer) CreateBfdSession(sessionMgmt BfdSessionMgmt) (*BfdSessi
 	Interface := sessionMgmt.Interface
 	Protocol := sessionMgmt.Protocol
 	PerLink := sessionMgmt.PerLink
+	MultiHop := sessionMgmt.MultiHop
 	sessionIp := DestIp
 	if Interface != "" {
 		ipAddr := net.ParseIP(DestIp)
@@ -485,8 +518,8 @@ func (server *BFDServer) CreateBfdSession(sessionMgmt BfdSessionMgmt) (*BfdSessi
 	}
 	sessionId, found := server.FindBfdSession(sessionIp)
 	if !found {
-		server.logger.Info("CreateSession ", sessionIp, ParamName, Interface, Protocol, PerLink)
-		bfdSession = server.NewBfdSession(sessionIp, ParamName, Interface, Protocol, PerLink)
+		server.logger.Info("CreateSession ", sessionIp, ParamName, Interface, Protocol, PerLink, MultiHop)
+		bfdSession = server.NewBfdSession(sessionIp, ParamName, Interface, Protocol, PerLink, MultiHop)
 		if bfdSession != nil {
 			server.logger.Info("Bfd session created ", bfdSession.state.SessionId, bfdSession.state.IpAddr)
 		} else {
@@ -513,6 +546,7 @@ func (server *BFDServer) SessionDeleteHandler(session *BfdSession, Protocol bfdd
 	sessionId := session.state.SessionId
 	session.state.RegisteredProtocols[Protocol] = false
 	if ForceDel || session.CheckIfAnyProtocolRegistered() == false {
+		session.StopEchoFunction()
 		if session.IsSessionActive() {
 			session.SessionStopClientCh <- true
 		}
@@ -577,6 +611,7 @@ func (server *BFDServer) DeleteBfdSession(sessionMgmt BfdSessionMgmt) error {
 func (server *BFDServer) ResetBfdSession(sessionId int32) error {
 	server.logger.Info("ResetSession: SessionId", sessionId)
 	session := server.bfdGlobal.Sessions[sessionId]
+	session.StopEchoFunction()
 	if session.IsSessionActive() {
 		session.SessionStopClientCh <- true
 	}
@@ -67,6 +67,7 @@ type BfdSessionMgmt struct {
 	Interface string
 	Protocol  bfddCommonDefs.BfdSessionOwner
 	PerLink   bool
+	MultiHop  bool
 	ForceDel  bool
 }
 
@@ -92,7 +93,13 @@ type BfdSession struct {
 	authSeqNum          uint32
 	authKeyId           uint32
 	authData            string
+	authKeyChain        string
 	txConn              net.Conn
+	echoTxInterval      int32
+	echoTimer           *time.Timer
+	echoSeqNum          uint32
+	echoStopCh          chan bool
+	echoRetryTimer      *time.Timer
 	sendPcapHandle      *pcap.Handle
 	recvPcapHandle      *pcap.Handle
 	useDedicatedMac     bool
@@ -120,6 +127,7 @@ type BfdGlobal struct {
 	InactiveSessionsIdSlice []int32
 	NumSessionParams        uint32
 	SessionParams           map[string]*BfdSessionParam
+	KeyChains               map[string]*BfdKeyChain
 	NumUpSessions           uint32
 	NumDownSessions         uint32
 	NumAdminDownSessions    uint32
@@ -159,6 +167,8 @@ type BFDServer struct {
 	BfdPacketRecvCh       chan RecvedBfdPacket
 	SessionParamConfigCh  chan SessionParamConfig
 	SessionParamDeleteCh  chan string
+	KeyChainKeyConfigCh   chan KeyChainKeyConfig
+	KeyChainKeyDeleteCh   chan KeyChainKeyConfig
 	tobeCreatedSessions   map[string]BfdSessionMgmt
 	bfdGlobal             BfdGlobal
 }
@@ -179,6 +189,8 @@ func NewBFDServer(logger *logging.Writer) *BFDServer {
 	bfdServer.notificationCh = make(chan []byte)
 	bfdServer.SessionParamConfigCh = make(chan SessionParamConfig)
 	bfdServer.SessionParamDeleteCh = make(chan string)
+	bfdServer.KeyChainKeyConfigCh = make(chan KeyChainKeyConfig)
+	bfdServer.KeyChainKeyDeleteCh = make(chan KeyChainKeyConfig)
 	bfdServer.bfdGlobal.Enabled = false
 	bfdServer.bfdGlobal.NumSessions = 0
 	bfdServer.bfdGlobal.Sessions = make(map[int32]*BfdSession)
@@ -187,6 +199,7 @@ func NewBFDServer(logger *logging.Writer) *BFDServer {
 	bfdServer.bfdGlobal.InactiveSessionsIdSlice = []int32{}
 	bfdServer.bfdGlobal.NumSessionParams = 0
 	bfdServer.bfdGlobal.SessionParams = make(map[string]*BfdSessionParam)
+	bfdServer.bfdGlobal.KeyChains = make(map[string]*BfdKeyChain)
 	bfdServer.bfdGlobal.NumUpSessions = 0
 	bfdServer.bfdGlobal.NumDownSessions = 0
 	bfdServer.bfdGlobal.NumAdminDownSessions = 0
@@ -362,6 +375,12 @@ func (server *BFDServer) StartServer(paramFile string, dbHdl *dbutils.DBUtil) {
 		case paramName := <-server.SessionParamDeleteCh:
 			server.logger.Info("Received call for performing Session Param Delete", paramName)
 			server.processSessionParamDelete(paramName)
+		case keyConfig := <-server.KeyChainKeyConfigCh:
+			server.logger.Info("Received call for performing Key Chain Configuration", keyConfig.KeyChain, keyConfig.KeyId)
+			server.processKeyChainKeyConfig(keyConfig)
+		case keyConfig := <-server.KeyChainKeyDeleteCh:
+			server.logger.Info("Received call for performing Key Chain Delete", keyConfig.KeyChain, keyConfig.KeyId)
+			server.processKeyChainKeyDelete(keyConfig)
 		}
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"infra/sysd/sysdCommonDefs"
 	"log/syslog"
 	"testing"
+	"time"
 	"utils/logging"
 )
 
@@ -83,6 +84,8 @@ func startTestServerChans() {
 		case <-bfdTestServer.BfdPacketRecvCh:
 		case <-bfdTestServer.SessionParamConfigCh:
 		case <-bfdTestServer.SessionParamDeleteCh:
+		case <-bfdTestServer.KeyChainKeyConfigCh:
+		case <-bfdTestServer.KeyChainKeyDeleteCh:
 		}
 	}
 }
@@ -120,7 +123,7 @@ func TestCreateRIBdSubscriber(t *testing.T) {
 func TestNewNormalBfdSession(t *testing.T) {
 	bfdTestServer.createDefaultSessionParam()
 	fmt.Println("Creating BFD session to 10.1.1.1")
-	bfdTestSession = bfdTestServer.NewNormalBfdSession("", "", "10.1.1.1", "default", false, 2)
+	bfdTestSession = bfdTestServer.NewNormalBfdSession("", "", "10.1.1.1", "default", false, false, 2)
 	if bfdTestSession != nil {
 		t.Log("Created BFD session to ", bfdTestSession.state.IpAddr, " session id ", bfdTestSession.state.SessionId)
 		if bfdTestSession.state.SessionState != STATE_DOWN {
@@ -237,3 +240,131 @@ func TestDecodeBfdControlPacket(t *testing.T) {
 	bfdPacketBuf, _ := bfdTestSession.bfdPacket.CreateBfdControlPacket()
 	DecodeBfdControlPacket(bfdPacketBuf)
 }
+
+func TestBfdEchoPacket(t *testing.T) {
+	echoPacket := &BfdEchoPacket{
+		Version:         DEFAULT_BFD_VERSION,
+		MyDiscriminator: bfdTestSession.state.LocalDiscriminator,
+		SequenceNumber:  10,
+	}
+	echoPacketBuf, _ := echoPacket.CreateBfdEchoPacket()
+	if len(echoPacketBuf) != DEFAULT_ECHO_PACKET_LEN {
+		t.Fatal("Echo packet length ", len(echoPacketBuf), " expected ", DEFAULT_ECHO_PACKET_LEN)
+	}
+	decodedPacket, err := DecodeBfdEchoPacket(echoPacketBuf)
+	if err != nil {
+		t.Fatal("Failed to decode echo packet ", err)
+	}
+	if *decodedPacket != *echoPacket {
+		t.Fatal("Decoded echo packet ", decodedPacket, " expected ", echoPacket)
+	}
+}
+
+func TestCanRunEchoFunction(t *testing.T) {
+	bfdTestSession.state.SessionState = STATE_UP
+	bfdTestSession.state.RemoteSessionState = STATE_UP
+	bfdTestSession.state.RequiredMinEchoRxInterval = 100000
+	bfdTestSession.state.RemoteMinEchoRxInterval = 0
+	if bfdTestSession.CanRunEchoFunction() {
+		t.Fatal("Echo function can't run when neighbor does not loop back echo packets")
+	}
+	bfdTestSession.state.RemoteMinEchoRxInterval = 200000
+	if !bfdTestSession.CanRunEchoFunction() {
+		t.Fatal("Echo function should run for session ", bfdTestSession.state.SessionId)
+	}
+	if bfdTestSession.GetEchoTxInterval() != 200000 {
+		t.Fatal("Echo tx interval ", bfdTestSession.GetEchoTxInterval(), " expected 200000")
+	}
+	bfdTestSession.state.MultiHopSession = true
+	if bfdTestSession.CanRunEchoFunction() {
+		t.Fatal("Echo function can't run on multi-hop session")
+	}
+	bfdTestSession.state.MultiHopSession = false
+	bfdTestSession.state.RequiredMinEchoRxInterval = 0
+	bfdTestSession.state.RemoteMinEchoRxInterval = 0
+}
+
+func TestBfdAuthDigest(t *testing.T) {
+	for _, authType := range []AuthenticationType{BFD_AUTH_TYPE_KEYED_MD5, BFD_AUTH_TYPE_METICULOUS_SHA1} {
+		bfdPacket := NewBfdControlPacketDefault()
+		bfdPacket.MyDiscriminator = bfdTestSession.state.LocalDiscriminator
+		bfdPacket.AuthPresent = true
+		bfdPacket.AuthHeader = &BfdAuthHeader{
+			Type:           authType,
+			AuthKeyID:      1,
+			SequenceNumber: 10,
+			AuthData:       []byte("bfdtestkey"),
+		}
+		bfdPacketBuf, _ := bfdPacket.CreateBfdControlPacket()
+		decodedPacket, err := DecodeBfdControlPacket(bfdPacketBuf)
+		if err != nil {
+			t.Fatal("Failed to decode authenticated packet ", err)
+		}
+		if !verifyBfdAuthDigest(authType, decodedPacket.authPacket, []byte("bfdtestkey"), decodedPacket.AuthHeader.AuthData) {
+			t.Fatal("Digest verification failed for auth type ", authType)
+		}
+		if verifyBfdAuthDigest(authType, decodedPacket.authPacket, []byte("wrongkey"), decodedPacket.AuthHeader.AuthData) {
+			t.Fatal("Digest verification passed with wrong key for auth type ", authType)
+		}
+	}
+}
+
+func TestBfdAuthSeqWindow(t *testing.T) {
+	if !checkBfdAuthSeqWindow(BFD_AUTH_TYPE_KEYED_MD5, 10, 10, 3) {
+		t.Fatal("Keyed auth should accept the same sequence number")
+	}
+	if checkBfdAuthSeqWindow(BFD_AUTH_TYPE_METICULOUS_MD5, 10, 10, 3) {
+		t.Fatal("Meticulous auth should not accept the same sequence number")
+	}
+	if !checkBfdAuthSeqWindow(BFD_AUTH_TYPE_METICULOUS_SHA1, 0xfffffffe, 3, 3) {
+		t.Fatal("Sequence number wrap around should be accepted")
+	}
+	if checkBfdAuthSeqWindow(BFD_AUTH_TYPE_KEYED_SHA1, 10, 9, 3) || checkBfdAuthSeqWindow(BFD_AUTH_TYPE_KEYED_SHA1, 10, 20, 3) {
+		t.Fatal("Sequence number out of replay window should not be accepted")
+	}
+}
+
+func TestKeyChainSendKey(t *testing.T) {
+	now := time.Now()
+	keyChain := &BfdKeyChain{
+		name: "test",
+		keys: make(map[uint8]*BfdKeyChainKey),
+	}
+	keyChain.keys[1] = &BfdKeyChainKey{keyId: 1, authKey: "oldkey", sendLifetimeEnd: now.Add(time.Hour)}
+	keyChain.keys[2] = &BfdKeyChainKey{keyId: 2, authKey: "newkey", sendLifetimeStart: now.Add(-time.Minute)}
+	keyChain.keys[3] = &BfdKeyChainKey{keyId: 3, authKey: "nextkey", sendLifetimeStart: now.Add(time.Hour),
+		acceptLifetimeStart: now.Add(time.Hour)}
+	if key := keyChain.getSendKey(now); key == nil || key.keyId != 2 {
+		t.Fatal("Send key should be the most recently started key 2, got ", key)
+	}
+	if key := keyChain.getSendKey(now.Add(2 * time.Hour)); key == nil || key.keyId != 3 {
+		t.Fatal("Send key should roll over to key 3, got ", key)
+	}
+	if keyChain.getAcceptKey(1, now) == nil {
+		t.Fatal("Key 1 should be accepted")
+	}
+	if keyChain.getAcceptKey(3, now) != nil {
+		t.Fatal("Key 3 should not be accepted before its lifetime")
+	}
+}
+
+func TestEchoClientFailure(t *testing.T) {
+	bfdEchoRetryInterval = time.Hour
+	bfdTestSession.state.Interface = "bfdechotest0"
+	bfdTestSession.state.EchoActive = true
+	bfdTestSession.echoStopCh = make(chan bool, 1)
+	if err := bfdTestSession.StartSessionEchoClient(bfdTestServer, bfdTestSession.echoStopCh); err == nil {
+		t.Fatal("Echo client started on a missing interface")
+	}
+	if bfdTestSession.state.EchoActive || bfdTestSession.echoStopCh != nil {
+		t.Fatal("Echo function still active after the echo client failed")
+	}
+	if bfdTestSession.echoRetryTimer == nil {
+		t.Fatal("Echo function retry not scheduled after the echo client failed")
+	}
+	bfdTestSession.StopEchoFunction()
+	if bfdTestSession.echoRetryTimer != nil {
+		t.Fatal("Echo function retry not stopped")
+	}
+	bfdTestSession.state.Interface = ""
+}
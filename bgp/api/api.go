@@ -63,6 +63,17 @@ func SendBfdNotification(DestIp string, State bool, Oper config.Operation) {
 	}
 }
 
+/*  Send the MAC learnt or aged out by asicd to server
+ */
+func SendEVPNMacNotification(vlanId int32, macAddr string, ipAddr string, oper config.Operation) {
+	bgpapi.server.EVPNMacCh <- config.EVPNMacInfo{
+		Oper:    oper,
+		VlanId:  vlanId,
+		MacAddr: macAddr,
+		IPAddr:  ipAddr,
+	}
+}
+
 /*  Send interface state notification to server
  */
 func SendIntfNotification(ifIndex int32, ipAddr string, linklocalIp string, state config.Operation) {
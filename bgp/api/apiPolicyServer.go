@@ -65,6 +65,14 @@ func UpdatePolicyCondition(condition utilspolicy.PolicyConditionConfig) {
 	return
 }
 
+func AddCommunityCondition(condition bgppolicy.CommunityConditionConfig) {
+	bgppolicyapi.policyManager.CommunityConditionCfgCh <- condition
+}
+
+func AddCommunityAction(action bgppolicy.CommunityActionConfig) {
+	bgppolicyapi.policyManager.CommunityActionCfgCh <- action
+}
+
 func AddPolicyStmt(stmt utilspolicy.PolicyStmtConfig) {
 	bgppolicyapi.policyManager.StmtCfgCh <- stmt
 }
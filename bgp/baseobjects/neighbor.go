@@ -45,6 +45,13 @@ type NeighborConf struct {
 	RunningConf          config.NeighborConfig
 	BGPId                net.IP
 	ASSize               uint8
+	RouteRefresh         bool
+	EnhancedRouteRefresh bool
+	GracefulRestart      bool
+	PeerRestarting       bool
+	PeerRestartTime      uint16
+	RestartFamilies      map[uint32]bool
+	Restarting           bool
 	AfiSafiMap           map[uint32]bool
 	MaxPrefixesThreshold uint32
 	ignoreBfdFaultsTimer *time.Timer
@@ -57,6 +64,7 @@ func NewNeighborConf(logger *logging.Writer, globalConf *config.GlobalConfig, pe
 		Global:               globalConf,
 		Group:                peerGroup,
 		AfiSafiMap:           make(map[uint32]bool),
+		RestartFamilies:      make(map[uint32]bool),
 		BGPId:                net.IP{},
 		MaxPrefixesThreshold: 0,
 		RunningConf:          config.NeighborConfig{},
@@ -115,6 +123,7 @@ func (n *NeighborConf) SetNeighborState(peerConf *config.NeighborConfig) {
 		TotalPrefixes:           0,
 		AdjRIBInFilter:          peerConf.AdjRIBInFilter,
 		AdjRIBOutFilter:         peerConf.AdjRIBOutFilter,
+		MRTDumpUpdates:          peerConf.MRTDumpUpdates,
 	}
 	n.MaxPrefixesThreshold = uint32(float64(peerConf.MaxPrefixes*uint32(peerConf.MaxPrefixesThresholdPct)) / 100)
 }
@@ -289,6 +298,10 @@ func (n *NeighborConf) GetConfFromNeighbor(inConf *config.NeighborConfig, outCon
 		outConf.AdjRIBOutFilter = inConf.AdjRIBOutFilter
 	}
 
+	if inConf.MRTDumpUpdates != false {
+		outConf.MRTDumpUpdates = inConf.MRTDumpUpdates
+	}
+
 	n.setDefaults(outConf)
 	outConf.PeerAddressType = inConf.PeerAddressType
 	outConf.NeighborAddress = inConf.NeighborAddress
@@ -383,9 +396,13 @@ func (n *NeighborConf) FSMStateChange(state uint32) {
 }
 
 func (n *NeighborConf) SetPeerAttrs(bgpId net.IP, asSize uint8, holdTime uint32, keepaliveTime uint32,
-	addPathFamily map[packet.AFI]map[packet.SAFI]uint8) {
+	addPathFamily map[packet.AFI]map[packet.SAFI]uint8, routeRefresh, enhancedRouteRefresh bool,
+	gracefulRestart *packet.BGPCapGracefulRestart) {
 	n.BGPId = bgpId
 	n.ASSize = asSize
+	n.RouteRefresh = routeRefresh
+	n.EnhancedRouteRefresh = enhancedRouteRefresh
+	n.setGracefulRestartAttrs(gracefulRestart)
 	n.Neighbor.State.HoldTime = holdTime
 	n.Neighbor.State.KeepaliveTime = keepaliveTime
 	for afi, safiMap := range addPathFamily {
@@ -406,6 +423,29 @@ func (n *NeighborConf) SetPeerAttrs(bgpId net.IP, asSize uint8, holdTime uint32,
 	}
 }
 
+// setGracefulRestartAttrs saves the graceful restart capability advertised by the peer. Graceful restart is
+// used with the peer only if it is enabled locally as well. The attributes are kept after the connection
+// breaks since they decide whether the routes from the peer are retained.
+func (n *NeighborConf) setGracefulRestartAttrs(gracefulRestart *packet.BGPCapGracefulRestart) {
+	n.GracefulRestart = false
+	n.PeerRestarting = false
+	n.PeerRestartTime = 0
+	n.RestartFamilies = make(map[uint32]bool)
+	if gracefulRestart == nil || !n.Global.GracefulRestart {
+		return
+	}
+
+	n.GracefulRestart = true
+	n.PeerRestarting = gracefulRestart.IsRestarting()
+	n.PeerRestartTime = gracefulRestart.RestartTime
+	for _, afiSafi := range gracefulRestart.Value {
+		protoFamily := packet.GetProtocolFamily(afiSafi.AFI, afiSafi.SAFI)
+		n.RestartFamilies[protoFamily] = afiSafi.IsForwardingPreserved()
+	}
+	n.logger.Infof("SetPeerAttrs - Neighbor %s graceful restart time %d, restarting %t, families %v",
+		n.Neighbor.NeighborAddress, n.PeerRestartTime, n.PeerRestarting, n.RestartFamilies)
+}
+
 func (n *NeighborConf) BfdFaultSet() {
 	n.Neighbor.State.BfdNeighborState = "down"
 	if n.ignoreBfdFaultsTimer != nil {
@@ -430,11 +470,15 @@ func (n *NeighborConf) PeerConnEstablished() {
 	n.Neighbor.State.UseBfdState = true
 }
 
-func (n *NeighborConf) PeerConnBroken() {
+func (n *NeighborConf) PeerConnBroken(retainPrefixes bool) {
 	n.Neighbor.State.ConnectRetryTime = n.RunningConf.ConnectRetryTime
 	n.Neighbor.State.HoldTime = n.RunningConf.HoldTime
 	n.Neighbor.State.KeepaliveTime = n.RunningConf.KeepaliveTime
 	n.Neighbor.State.AddPathsRx = false
 	n.Neighbor.State.AddPathsMaxTx = 0
-	n.Neighbor.State.TotalPrefixes = 0
+	if !retainPrefixes {
+		n.Neighbor.State.TotalPrefixes = 0
+	}
+	n.RouteRefresh = false
+	n.EnhancedRouteRefresh = false
 }
@@ -0,0 +1,55 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// defs.go
+package bgpdCommonDefs
+
+const (
+	PUB_SOCKET_ADDR = "ipc:///tmp/bgpd.ipc"
+)
+
+// Notifications published for the EVPN routes, the remote VTEPs are learnt from the inclusive multicast routes
+// and the remote MACs from the MAC/IP advertisement routes
+const (
+	NOTIFY_EVPN_VTEP_CREATE uint8 = iota + 1
+	NOTIFY_EVPN_VTEP_DELETE
+	NOTIFY_EVPN_MAC_CREATE
+	NOTIFY_EVPN_MAC_DELETE
+)
+
+type BGPdNotification struct {
+	MsgType uint8
+	Msg     []byte
+}
+
+type EVPNVtepNotifyMsg struct {
+	Vni      uint32
+	RemoteIp string
+}
+
+type EVPNMacNotifyMsg struct {
+	Vni      uint32
+	MacAddr  string
+	IpAddr   string
+	RemoteIp string
+}
@@ -0,0 +1,270 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// station.go
+package bmp
+
+import (
+	"fmt"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"utils/logging"
+)
+
+const StationConnectRetryTimeDefault uint16 = 30 // seconds
+const stationConnectTimeout = 10 * time.Second
+const stationMsgQueueLen = 4096
+
+type StationEventType uint8
+
+const (
+	StationEventUp StationEventType = iota
+	StationEventDown
+	StationEventStats
+)
+
+type StationEvent struct {
+	Name  string
+	Event StationEventType
+}
+
+// Station is a connection to a BMP monitoring station. The station connects to the collector, sends the
+// Initiation message and then streams the messages queued with Send. The owner is notified with a
+// StationEventUp every time the connection comes up so that it can send the Peer Up messages and the
+// contents of the Adj-RIB-In for the peers that are already established.
+type Station struct {
+	logger   *logging.Writer
+	Config   config.BMPStationConfig
+	sysName  string
+	sysDescr string
+	eventCh  chan StationEvent
+	msgCh    chan []byte
+	stopCh   chan bool
+	up       int32
+}
+
+func NewStation(logger *logging.Writer, stationConf config.BMPStationConfig, sysName, sysDescr string,
+	eventCh chan StationEvent) *Station {
+	if stationConf.ConnectRetryTime == 0 {
+		stationConf.ConnectRetryTime = StationConnectRetryTimeDefault
+	}
+
+	return &Station{
+		logger:   logger,
+		Config:   stationConf,
+		sysName:  sysName,
+		sysDescr: sysDescr,
+		eventCh:  eventCh,
+		msgCh:    make(chan []byte, stationMsgQueueLen),
+		stopCh:   make(chan bool),
+	}
+}
+
+func (s *Station) Start() {
+	go s.run()
+}
+
+// Stop sends the Termination message to the station and closes the connection. It does not wait for the
+// station to exit since the station may be blocked on sending an event to the owner.
+func (s *Station) Stop() {
+	close(s.stopCh)
+}
+
+func (s *Station) IsUp() bool {
+	return atomic.LoadInt32(&s.up) == 1
+}
+
+func (s *Station) setUp(up bool) {
+	if up {
+		atomic.StoreInt32(&s.up, 1)
+	} else {
+		atomic.StoreInt32(&s.up, 0)
+	}
+}
+
+// Send queues a message for the station. Messages are dropped while the station is not connected. If the
+// queue is full the connection is reset, the owner sends the current state again when it comes back up.
+func (s *Station) Send(msg *packet.BMPMessage) {
+	if !s.IsUp() {
+		return
+	}
+
+	pkt, err := msg.Encode()
+	if err != nil {
+		s.logger.Errf("BMP station %s: failed to encode %s message with error %s", s.Config.Name,
+			packet.BMPMsgTypeToStr[msg.Header.Type], err)
+		return
+	}
+
+	select {
+	case s.msgCh <- pkt:
+	default:
+		s.logger.Errf("BMP station %s: message queue is full, reset the connection", s.Config.Name)
+		s.setUp(false)
+	}
+}
+
+func (s *Station) getAddress() string {
+	return net.JoinHostPort(s.Config.Address.String(), strconv.Itoa(int(s.Config.Port)))
+}
+
+func (s *Station) sendEvent(event StationEventType) {
+	if s.eventCh == nil {
+		return
+	}
+
+	select {
+	case s.eventCh <- StationEvent{s.Config.Name, event}:
+	case <-s.stopCh:
+	}
+}
+
+func (s *Station) drainMsgs() {
+	for {
+		select {
+		case <-s.msgCh:
+		default:
+			return
+		}
+	}
+}
+
+func (s *Station) run() {
+	retryTimer := time.NewTimer(0)
+	for {
+		select {
+		case <-s.stopCh:
+			retryTimer.Stop()
+			return
+
+		case <-retryTimer.C:
+			conn, err := net.DialTimeout("tcp", s.getAddress(), stationConnectTimeout)
+			if err != nil {
+				s.logger.Infof("BMP station %s: connect to %s failed with error %s", s.Config.Name,
+					s.getAddress(), err)
+				retryTimer.Reset(time.Duration(s.Config.ConnectRetryTime) * time.Second)
+				break
+			}
+
+			if stopped := s.serve(conn); stopped {
+				return
+			}
+			retryTimer.Reset(time.Duration(s.Config.ConnectRetryTime) * time.Second)
+		}
+	}
+}
+
+func (s *Station) write(conn net.Conn, pkt []byte) error {
+	for len(pkt) > 0 {
+		n, err := conn.Write(pkt)
+		if err != nil {
+			return err
+		}
+		pkt = pkt[n:]
+	}
+	return nil
+}
+
+func (s *Station) readConn(conn net.Conn, errCh chan error) {
+	// Monitoring stations do not send any messages, a read only detects that the connection is closed
+	buf := make([]byte, 1024)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			errCh <- err
+			return
+		}
+	}
+}
+
+// serve sends the queued messages to a connected station. It returns true if the station is stopped.
+func (s *Station) serve(conn net.Conn) bool {
+	s.logger.Infof("BMP station %s: connected to %s", s.Config.Name, s.getAddress())
+	defer conn.Close()
+
+	s.drainMsgs()
+	pkt, _ := packet.NewBMPInitiationMessage(s.sysName, s.sysDescr).Encode()
+	if err := s.write(conn, pkt); err != nil {
+		s.logger.Errf("BMP station %s: failed to send Initiation message with error %s", s.Config.Name, err)
+		return false
+	}
+
+	var statsCh <-chan time.Time
+	if s.Config.StatsInterval > 0 {
+		statsTicker := time.NewTicker(time.Duration(s.Config.StatsInterval) * time.Second)
+		defer statsTicker.Stop()
+		statsCh = statsTicker.C
+	}
+
+	errCh := make(chan error, 1)
+	go s.readConn(conn, errCh)
+	s.setUp(true)
+	s.sendEvent(StationEventUp)
+
+	for {
+		select {
+		case pkt := <-s.msgCh:
+			if err := s.write(conn, pkt); err != nil {
+				s.logger.Errf("BMP station %s: write failed with error %s", s.Config.Name, err)
+				s.connDown()
+				return false
+			}
+
+			if !s.IsUp() {
+				// Message queue overflowed
+				s.connDown()
+				return false
+			}
+
+		case err := <-errCh:
+			s.logger.Infof("BMP station %s: connection closed, %s", s.Config.Name, err)
+			s.connDown()
+			return false
+
+		case <-statsCh:
+			s.sendEvent(StationEventStats)
+
+		case <-s.stopCh:
+			s.setUp(false)
+			pkt, _ := packet.NewBMPTerminationMessage(packet.BMPTermReasonAdminClose).Encode()
+			if err := s.write(conn, pkt); err != nil {
+				s.logger.Infof("BMP station %s: failed to send Termination message with error %s",
+					s.Config.Name, err)
+			}
+			return true
+		}
+	}
+}
+
+func (s *Station) connDown() {
+	s.setUp(false)
+	s.drainMsgs()
+	s.sendEvent(StationEventDown)
+}
+
+func (s *Station) String() string {
+	return fmt.Sprintf("BMP station %s(%s)", s.Config.Name, s.getAddress())
+}
@@ -0,0 +1,208 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// station_test.go
+package bmp
+
+import (
+	"encoding/binary"
+	"io"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	"net"
+	"testing"
+	"time"
+	"utils/logging"
+)
+
+type collector struct {
+	t        *testing.T
+	listener *net.TCPListener
+	msgCh    chan *packet.BMPMessage
+}
+
+func newCollector(t *testing.T) *collector {
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal("Failed to start the BMP collector, error:", err)
+	}
+
+	c := &collector{t, listener, make(chan *packet.BMPMessage, 100)}
+	go c.accept()
+	return c
+}
+
+func (c *collector) port() uint16 {
+	return uint16(c.listener.Addr().(*net.TCPAddr).Port)
+}
+
+func (c *collector) accept() {
+	for {
+		conn, err := c.listener.AcceptTCP()
+		if err != nil {
+			return
+		}
+		go c.read(conn)
+	}
+}
+
+func (c *collector) read(conn *net.TCPConn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, packet.BMPMsgHeaderLen)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+
+		pkt := make([]byte, binary.BigEndian.Uint32(header[1:5]))
+		copy(pkt, header)
+		if _, err := io.ReadFull(conn, pkt[packet.BMPMsgHeaderLen:]); err != nil {
+			return
+		}
+
+		msg := packet.NewBMPMessage()
+		if err := msg.Decode(pkt); err != nil {
+			c.t.Error("BMP collector failed to decode message, error:", err)
+			return
+		}
+		c.msgCh <- msg
+	}
+}
+
+func (c *collector) receive(msgType uint8) *packet.BMPMessage {
+	select {
+	case msg := <-c.msgCh:
+		if msg.Header.Type != msgType {
+			c.t.Fatalf("BMP collector expected %s message, received %s message", packet.BMPMsgTypeToStr[msgType],
+				packet.BMPMsgTypeToStr[msg.Header.Type])
+		}
+		return msg
+
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("BMP collector did not receive", packet.BMPMsgTypeToStr[msgType], "message")
+	}
+	return nil
+}
+
+func waitForEvent(t *testing.T, eventCh chan StationEvent, event StationEventType) {
+	select {
+	case stationEvent := <-eventCh:
+		if stationEvent.Event != event {
+			t.Fatal("Expected BMP station event", event, "received", stationEvent)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("BMP station event", event, "not received")
+	}
+}
+
+func TestStation(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+
+	c := newCollector(t)
+	defer c.listener.Close()
+
+	eventCh := make(chan StationEvent)
+	stationConf := config.BMPStationConfig{
+		Name:          "collector1",
+		Address:       net.ParseIP("127.0.0.1"),
+		Port:          c.port(),
+		StatsInterval: 1,
+	}
+	station := NewStation(logger, stationConf, "router1", "bgpd", eventCh)
+
+	// Messages are dropped until the station is connected
+	peerHeader := packet.NewBMPPeerHeader(net.ParseIP("10.1.1.2"), 65002, net.ParseIP("10.1.1.2"), false)
+	station.Send(packet.NewBMPStatsReportMessage(peerHeader, nil))
+
+	station.Start()
+	waitForEvent(t, eventCh, StationEventUp)
+	initMsg := c.receive(packet.BMPMsgTypeInitiation)
+	tlvs := initMsg.Body.(*packet.BMPInitiation).TLVs
+	if len(tlvs) != 2 || string(tlvs[1].Value) != "router1" {
+		t.Fatalf("BMP initiation message TLVs %+v", tlvs)
+	}
+
+	update := packet.NewBGPEndOfRIBMessage(packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast))
+	station.Send(packet.NewBMPRouteMonitoringMessage(peerHeader, update))
+	rmMsg := c.receive(packet.BMPMsgTypeRouteMonitoring)
+	if !rmMsg.PeerHeader.Address.Equal(peerHeader.Address) || rmMsg.PeerHeader.AS != 65002 {
+		t.Fatalf("BMP route monitoring message peer header %+v", rmMsg.PeerHeader)
+	}
+
+	waitForEvent(t, eventCh, StationEventStats)
+	stats := []packet.BMPStat{packet.BMPStat{Type: packet.BMPStatAdjRIBInRoutes, Value: 10}}
+	station.Send(packet.NewBMPStatsReportMessage(peerHeader, stats))
+	statsMsg := c.receive(packet.BMPMsgTypeStatsReport)
+	if rxStats := statsMsg.Body.(*packet.BMPStatsReport).Stats; len(rxStats) != 1 || rxStats[0] != stats[0] {
+		t.Fatalf("BMP statistics report %+v", rxStats)
+	}
+
+	station.Stop()
+	c.receive(packet.BMPMsgTypeTermination)
+}
+
+func TestStationReconnect(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+
+	// Reserve a port and close the listener so that the first connect fails
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatal("Failed to reserve a port, error:", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	eventCh := make(chan StationEvent)
+	stationConf := config.BMPStationConfig{
+		Name:             "collector2",
+		Address:          net.ParseIP("127.0.0.1"),
+		Port:             uint16(port),
+		ConnectRetryTime: 1,
+	}
+	station := NewStation(logger, stationConf, "router1", "bgpd", eventCh)
+	station.Start()
+	defer station.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+	if station.IsUp() {
+		t.Fatal("BMP station is up without a collector")
+	}
+
+	listener, err = net.ListenTCP("tcp", &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: port})
+	if err != nil {
+		t.Skip("Failed to listen on the reserved port, error:", err)
+	}
+	c := &collector{t, listener, make(chan *packet.BMPMessage, 100)}
+	go c.accept()
+	defer listener.Close()
+
+	waitForEvent(t, eventCh, StationEventUp)
+	c.receive(packet.BMPMsgTypeInitiation)
+}
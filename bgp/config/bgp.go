@@ -43,6 +43,15 @@ type GlobalBase struct {
 	EBGPMaxPaths        uint32
 	EBGPAllowMultipleAS bool
 	IBGPMaxPaths        uint32
+	GracefulRestart     bool
+	RestartTime         uint32
+	StalePathTime       uint32
+	AlwaysCompareMED    bool
+	DeterministicMED    bool
+	MRTRIBDumpFile      string
+	MRTRIBDumpInterval  uint32
+	MRTUpdatesFile      string
+	MRTUpdatesInterval  uint32
 }
 
 type GlobalConfig struct {
@@ -52,9 +61,11 @@ type GlobalConfig struct {
 
 type GlobalState struct {
 	GlobalBase
-	TotalPaths      uint32
-	Totalv4Prefixes uint32
-	Totalv6Prefixes uint32
+	TotalPaths         uint32
+	Totalv4Prefixes    uint32
+	Totalv6Prefixes    uint32
+	TotalVPNv4Prefixes uint32
+	TotalVPNv6Prefixes uint32
 }
 
 type Global struct {
@@ -80,6 +91,7 @@ const (
 type BgpCounters struct {
 	Update       uint64
 	Notification uint64
+	RouteRefresh uint64
 }
 
 type Messages struct {
@@ -117,6 +129,7 @@ type BaseConfig struct {
 	MaxPrefixesRestartTimer uint8
 	AdjRIBInFilter          string
 	AdjRIBOutFilter         string
+	MRTDumpUpdates          bool
 }
 
 type NeighborConfig struct {
@@ -161,6 +174,7 @@ type NeighborState struct {
 	TotalPrefixes           uint32
 	AdjRIBInFilter          string
 	AdjRIBOutFilter         string
+	MRTDumpUpdates          bool
 	SessionStateUpdatedTime time.Time
 }
 
@@ -233,6 +247,12 @@ type PeerCommand struct {
 	Command int
 }
 
+type PeerSoftClear struct {
+	IP  net.IP
+	In  bool
+	Out bool
+}
+
 type Neighbor struct {
 	NeighborAddress net.IP
 	Config          NeighborConfig
@@ -259,6 +279,31 @@ type BGPAggregate struct {
 	AddressFamily   uint32
 }
 
+type BMPStationConfig struct {
+	Name             string
+	Address          net.IP
+	Port             uint16
+	StatsInterval    uint16
+	ConnectRetryTime uint16
+}
+
+type VRFConfig struct {
+	Name               string
+	RouteDistinguisher string
+	ImportRouteTargets []string
+	ExportRouteTargets []string
+	Label              uint32
+	L3VNI              uint32
+}
+
+type EVIConfig struct {
+	VNI                uint32
+	VlanId             int32
+	RouteDistinguisher string
+	ImportRouteTargets []string
+	ExportRouteTargets []string
+}
+
 type AddressFamily struct {
 	BgpAggs map[string]*BGPAggregate
 }
@@ -287,4 +332,6 @@ type RouteConfig struct {
 	OutgoingInterface string
 	IsIPv6            bool
 	NullRoute         bool
+	Vrf               string
+	Labels            []uint32
 }
@@ -63,6 +63,8 @@ const (
 	NOTIFY_POLICY_DEFINITION_CREATED
 	NOTIFY_POLICY_DEFINITION_DELETED
 	NOTIFY_POLICY_DEFINITION_UPDATED
+	EVPN_MAC_CREATED
+	EVPN_MAC_DELETED
 )
 
 type BfdInfo struct {
@@ -71,6 +73,13 @@ type BfdInfo struct {
 	State  bool
 }
 
+type EVPNMacInfo struct {
+	Oper    Operation
+	VlanId  int32
+	MacAddr string
+	IPAddr  string
+}
+
 type IntfStateInfo struct {
 	Idx         int32
 	IPAddr      string
@@ -102,6 +111,7 @@ type RouteInfo struct {
 	NetworkStatement bool
 	RouteOrigin      string
 	AddressType      ribdCommonDefs.IPType
+	Vrf              string
 }
 
 type RouteCh struct {
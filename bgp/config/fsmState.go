@@ -24,8 +24,14 @@
 // fsmState.go
 package config
 
-const BGPConnectRetryTime uint32 = 120 // seconds
-const BGPHoldTimeDefault uint32 = 180  // 180 seconds
+const BGPConnectRetryTime uint32 = 120           // seconds
+const BGPHoldTimeDefault uint32 = 180            // 180 seconds
+const BGPGracefulRestartTimeDefault uint32 = 120 // seconds
+const BGPStalePathTimeDefault uint32 = 360       // seconds
+const BGPSelectionDeferralTime uint32 = 360      // seconds
+const BGPRouteRefreshStaleTime uint32 = 60       // seconds
+
+const BGPMRTRIBDumpIntervalDefault uint32 = 7200 // seconds
 
 type BGPFSMState int
 
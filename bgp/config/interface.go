@@ -66,10 +66,20 @@ type PolicyMgrIntf interface {
  */
 type BfdMgrIntf interface {
 	Start()
-	CreateBfdSession(ipAddr string, iface string, sessionParam string) (bool, error)
+	CreateBfdSession(ipAddr string, iface string, sessionParam string, multiHop bool) (bool, error)
 	DeleteBfdSession(ipAddr string, iface string) (bool, error)
 }
 
+/*  Interface for programming the remote VTEPs and MACs learnt from the EVPN routes
+ */
+type EVPNMgrIntf interface {
+	Start()
+	CreateVtep(vni uint32, remoteIP string)
+	DeleteVtep(vni uint32, remoteIP string)
+	CreateRemoteMac(vni uint32, mac string, ip string, remoteIP string)
+	DeleteRemoteMac(vni uint32, mac string, ip string, remoteIP string)
+}
+
 type ModelRouteIntf interface {
 	GetModelObject() objects.ConfigObj
 	GetThriftObject() interface{}
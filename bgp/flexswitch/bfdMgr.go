@@ -101,11 +101,12 @@ func (mgr *FSBfdMgr) handleBfdNotifications(rxBuf []byte) {
 	}
 }
 
-func (mgr *FSBfdMgr) CreateBfdSession(ipAddr string, iface string, sessionParam string) (bool, error) {
+func (mgr *FSBfdMgr) CreateBfdSession(ipAddr string, iface string, sessionParam string, multiHop bool) (bool, error) {
 	bfdSession := bfdd.NewBfdSession()
 	bfdSession.IpAddr = ipAddr
 	bfdSession.ParamName = sessionParam
 	bfdSession.Interface = iface
+	bfdSession.MultiHop = multiHop
 	bfdSession.Owner = "bgp"
 	mgr.logger.Info("Creating BFD Session: ", bfdSession)
 	ret, err := mgr.bfddClient.CreateBfdSession(bfdSession)
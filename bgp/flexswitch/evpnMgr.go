@@ -0,0 +1,176 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package FSMgr
+
+import (
+	"asicd/asicdCommonDefs"
+	"encoding/json"
+	"l3/bgp/api"
+	"l3/bgp/bgpdCommonDefs"
+	"l3/bgp/config"
+	"syscall"
+	"utils/logging"
+
+	nanomsg "github.com/op/go-nanomsg"
+)
+
+/*  Init evpn manager, the remote VTEPs and MACs are published to vxland
+ */
+func NewFSEVPNMgr(logger *logging.Writer, fileName string) (*FSEVPNMgr, error) {
+	mgr := &FSEVPNMgr{
+		plugin: "ovsdb",
+		logger: logger,
+	}
+
+	return mgr, nil
+}
+
+/*  Do any necessary init. Called from server..
+ */
+func (mgr *FSEVPNMgr) Start() {
+	mgr.evpnPubSocket = mgr.setupPubSocket(bgpdCommonDefs.PUB_SOCKET_ADDR)
+	mgr.asicdSubSocket, _ = mgr.setupSubSocket(asicdCommonDefs.PUB_SOCKET_ADDR)
+	go mgr.listenForAsicdMacEvents()
+}
+
+func (mgr *FSEVPNMgr) setupPubSocket(address string) *nanomsg.PubSocket {
+	pub, err := nanomsg.NewPubSocket()
+	if err != nil {
+		mgr.logger.Errf("Failed to open publisher socket %s, error:%s", address, err)
+		return nil
+	}
+
+	if _, err = pub.Bind(address); err != nil {
+		mgr.logger.Errf("Failed to bind publisher socket %s, error:%s", address, err)
+		return nil
+	}
+
+	if err = pub.SetSendBuffer(1024 * 1024); err != nil {
+		mgr.logger.Errf("Failed to set the buffer size for publisher socket %s, error:%s", address, err)
+		return nil
+	}
+	return pub
+}
+
+func (mgr *FSEVPNMgr) setupSubSocket(address string) (*nanomsg.SubSocket, error) {
+	var err error
+	var socket *nanomsg.SubSocket
+	if socket, err = nanomsg.NewSubSocket(); err != nil {
+		mgr.logger.Errf("Failed to create subscribe socket %s, error:%s", address, err)
+		return nil, err
+	}
+
+	if err = socket.Subscribe(""); err != nil {
+		mgr.logger.Errf("Failed to subscribe to \"\" on subscribe socket %s, error:%s", address, err)
+		return nil, err
+	}
+
+	if _, err = socket.Connect(address); err != nil {
+		mgr.logger.Errf("Failed to connect to publisher socket %s, error:%s", address, err)
+		return nil, err
+	}
+
+	mgr.logger.Infof("Connected to publisher socket %s", address)
+	if err = socket.SetRecvBuffer(1024 * 1024); err != nil {
+		mgr.logger.Err("Failed to set the buffer size for subsriber socket %s, error:", address, err)
+		return nil, err
+	}
+	return socket, nil
+}
+
+/*  Listen for the MACs learnt and aged out by asicd
+ */
+func (mgr *FSEVPNMgr) listenForAsicdMacEvents() {
+	for {
+		rxBuf, err := mgr.asicdSubSocket.Recv(0)
+		if err != nil {
+			mgr.logger.Err("Recv on asicd subscriber socket failed with error:", err)
+			continue
+		}
+
+		event := asicdCommonDefs.AsicdNotification{}
+		err = json.Unmarshal(rxBuf, &event)
+		if err != nil {
+			continue
+		}
+
+		switch event.MsgType {
+		case asicdCommonDefs.NOTIFY_MAC_CREATE, asicdCommonDefs.NOTIFY_MAC_DELETE:
+			var msg asicdCommonDefs.MacNotifyMsg
+			err = json.Unmarshal(event.Msg, &msg)
+			if err != nil {
+				mgr.logger.Errf("Unmarshal asicd MAC notification failed with err %s", err)
+				continue
+			}
+			oper := config.EVPN_MAC_CREATED
+			if event.MsgType == asicdCommonDefs.NOTIFY_MAC_DELETE {
+				oper = config.EVPN_MAC_DELETED
+			}
+			api.SendEVPNMacNotification(msg.VlanId, msg.MacAddr, "", oper)
+		}
+	}
+}
+
+func (mgr *FSEVPNMgr) publish(msgType uint8, msg interface{}) {
+	if mgr.evpnPubSocket == nil {
+		return
+	}
+
+	msgBuf, err := json.Marshal(msg)
+	if err != nil {
+		mgr.logger.Errf("Marshal EVPN notification %+v failed with err %s", msg, err)
+		return
+	}
+
+	notification := bgpdCommonDefs.BGPdNotification{
+		MsgType: msgType,
+		Msg:     msgBuf,
+	}
+	buf, err := json.Marshal(notification)
+	if err != nil {
+		mgr.logger.Errf("Marshal EVPN notification failed with err %s", err)
+		return
+	}
+
+	mgr.logger.Info("Publish EVPN notification type", msgType, "msg", msg)
+	if _, err = mgr.evpnPubSocket.Send(buf, nanomsg.DontWait); err == syscall.EAGAIN {
+		mgr.logger.Err("Failed to publish EVPN notification type", msgType)
+	}
+}
+
+func (mgr *FSEVPNMgr) CreateVtep(vni uint32, remoteIP string) {
+	mgr.publish(bgpdCommonDefs.NOTIFY_EVPN_VTEP_CREATE, bgpdCommonDefs.EVPNVtepNotifyMsg{vni, remoteIP})
+}
+
+func (mgr *FSEVPNMgr) DeleteVtep(vni uint32, remoteIP string) {
+	mgr.publish(bgpdCommonDefs.NOTIFY_EVPN_VTEP_DELETE, bgpdCommonDefs.EVPNVtepNotifyMsg{vni, remoteIP})
+}
+
+func (mgr *FSEVPNMgr) CreateRemoteMac(vni uint32, mac string, ip string, remoteIP string) {
+	mgr.publish(bgpdCommonDefs.NOTIFY_EVPN_MAC_CREATE, bgpdCommonDefs.EVPNMacNotifyMsg{vni, mac, ip, remoteIP})
+}
+
+func (mgr *FSEVPNMgr) DeleteRemoteMac(vni uint32, mac string, ip string, remoteIP string) {
+	mgr.publish(bgpdCommonDefs.NOTIFY_EVPN_MAC_DELETE, bgpdCommonDefs.EVPNMacNotifyMsg{vni, mac, ip, remoteIP})
+}
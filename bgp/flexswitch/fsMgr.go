@@ -71,6 +71,15 @@ type FSBfdMgr struct {
 	bfdSubSocket *nanomsg.SubSocket
 }
 
+/*  EVPN manager will publish the EVPN routes to vxland and listen for the MACs learnt by asicd
+ */
+type FSEVPNMgr struct {
+	plugin         string
+	logger         *logging.Writer
+	evpnPubSocket  *nanomsg.PubSocket
+	asicdSubSocket *nanomsg.SubSocket
+}
+
 func (mgr *FSIntfMgr) PortStateChange() {
 
 }
@@ -121,6 +121,7 @@ func (mgr *FSRouteMgr) populateConfigRoute(route *ribdInt.Routes) *config.RouteI
 		NetworkStatement: route.NetworkStatement,
 		RouteOrigin:      route.RouteOrigin,
 		AddressType:      ribdCommonDefs.IPType(route.IPAddrType),
+		Vrf:              route.Vrf,
 	}
 	return rv
 }
@@ -184,6 +185,7 @@ func (mgr *FSRouteMgr) createRibdIPv4RouteCfg(cfg *config.RouteConfig, create bo
 		NetworkMask:   cfg.NetworkMask,
 		DestinationNw: cfg.DestinationNw,
 		NullRoute:     cfg.NullRoute,
+		Vrf:           cfg.Vrf,
 	}
 	nextHop := ribd.NextHopInfo{
 		NextHopIp:     cfg.NextHopIp,
@@ -201,6 +203,7 @@ func (mgr *FSRouteMgr) createRibdIPv6RouteCfg(cfg *config.RouteConfig, create bo
 		NetworkMask:   cfg.NetworkMask,
 		DestinationNw: cfg.DestinationNw,
 		NullRoute:     cfg.NullRoute,
+		Vrf:           cfg.Vrf,
 	}
 	nextHop := ribd.NextHopInfo{
 		NextHopIp:     cfg.NextHopIp,
@@ -211,7 +214,37 @@ func (mgr *FSRouteMgr) createRibdIPv6RouteCfg(cfg *config.RouteConfig, create bo
 	return &rCfg
 }
 
+/*  VRF routes are installed with the vrf route api of RIBd, which also carries the VPN labels of the next hop
+ */
+func (mgr *FSRouteMgr) createRibdVrfRouteCfg(cfg *config.RouteConfig) *ribdInt.VrfRouteConfig {
+	rCfg := ribdInt.VrfRouteConfig{
+		Vrf:           cfg.Vrf,
+		Cost:          cfg.Cost,
+		Protocol:      cfg.Protocol,
+		NetworkMask:   cfg.NetworkMask,
+		DestinationNw: cfg.DestinationNw,
+		NullRoute:     cfg.NullRoute,
+	}
+	nextHop := ribdInt.RouteNextHopInfo{
+		NextHopIp:     cfg.NextHopIp,
+		NextHopIntRef: cfg.OutgoingInterface,
+		Labels:        make([]int32, 0, len(cfg.Labels)),
+	}
+	for _, label := range cfg.Labels {
+		nextHop.Labels = append(nextHop.Labels, int32(label))
+	}
+	rCfg.NextHop = make([]*ribdInt.RouteNextHopInfo, 0)
+	rCfg.NextHop = append(rCfg.NextHop, &nextHop)
+	return &rCfg
+}
+
 func (mgr *FSRouteMgr) CreateRoute(cfg *config.RouteConfig) {
+	if cfg.Vrf != "" {
+		if _, err := mgr.ribdClient.CreateVrfRoute(mgr.createRibdVrfRouteCfg(cfg)); err != nil {
+			mgr.logger.Err("Create route", cfg.DestinationNw, "in vrf", cfg.Vrf, "failed, error:", err)
+		}
+		return
+	}
 	if cfg.IsIPv6 {
 		mgr.ribdClient.OnewayCreateIPv6Route(mgr.createRibdIPv6RouteCfg(cfg, true /*create*/))
 	} else {
@@ -220,6 +253,12 @@ func (mgr *FSRouteMgr) CreateRoute(cfg *config.RouteConfig) {
 }
 
 func (mgr *FSRouteMgr) DeleteRoute(cfg *config.RouteConfig) {
+	if cfg.Vrf != "" {
+		if _, err := mgr.ribdClient.DeleteVrfRoute(mgr.createRibdVrfRouteCfg(cfg)); err != nil {
+			mgr.logger.Err("Delete route", cfg.DestinationNw, "in vrf", cfg.Vrf, "failed, error:", err)
+		}
+		return
+	}
 	if cfg.IsIPv6 {
 		mgr.ribdClient.OnewayDeleteIPv6Route(mgr.createRibdIPv6RouteCfg(cfg, false /*delete*/))
 	} else {
@@ -234,6 +273,7 @@ func (mgr *FSRouteMgr) UpdateV4Route(cfg *config.RouteConfig, nhInfo []*ribd.Nex
 		NetworkMask:   cfg.NetworkMask,
 		DestinationNw: cfg.DestinationNw,
 		NullRoute:     cfg.NullRoute,
+		Vrf:           cfg.Vrf,
 	}
 	rCfg.NextHop = nhInfo
 	mgr.ribdClient.UpdateIPv4Route(&rCfg, &rCfg, nil, patch)
@@ -246,12 +286,23 @@ func (mgr *FSRouteMgr) UpdateV6Route(cfg *config.RouteConfig, nhInfo []*ribd.Nex
 		NetworkMask:   cfg.NetworkMask,
 		DestinationNw: cfg.DestinationNw,
 		NullRoute:     cfg.NullRoute,
+		Vrf:           cfg.Vrf,
 	}
 	rCfg.NextHop = nhInfo
 	mgr.ribdClient.UpdateIPv6Route(&rCfg, &rCfg, nil, patch)
 }
 
 func (mgr *FSRouteMgr) UpdateRoute(cfg *config.RouteConfig, op string) {
+	if cfg.Vrf != "" {
+		// The next hops of a VRF route are added and removed one at a time along with their labels
+		if op == "add" {
+			mgr.CreateRoute(cfg)
+		} else {
+			mgr.DeleteRoute(cfg)
+		}
+		return
+	}
+
 	nextHop := ribd.NextHopInfo{
 		NextHopIp:     cfg.NextHopIp,
 		NextHopIntRef: cfg.OutgoingInterface,
@@ -368,6 +368,33 @@ func (p *PeerConn) DecodeMessage(header *packet.BGPHeader, buf []byte) (*packet.
 	return msg, msgErr, msgOk
 }
 
+func (p *PeerConn) isMRTDumpEnabled() bool {
+	mrtWriter := p.fsm.Manager.mrtWriter
+	return p.fsm.pConf.MRTDumpUpdates && mrtWriter != nil && mrtWriter.IsEnabled()
+}
+
+// dumpMRTMessage writes a BGP message sent to or received from the peer to the MRT updates file.
+func (p *PeerConn) dumpMRTMessage(pkt []byte, local bool) {
+	if !p.isMRTDumpEnabled() {
+		return
+	}
+
+	var localIP, remoteIP net.IP
+	if addr, ok := (*p.conn).LocalAddr().(*net.TCPAddr); ok {
+		localIP = addr.IP
+	}
+	if addr, ok := (*p.conn).RemoteAddr().(*net.TCPAddr); ok {
+		remoteIP = addr.IP
+	}
+	if localIP == nil || remoteIP == nil {
+		return
+	}
+
+	msg := packet.NewMRTBGP4MPMessage(time.Now(), p.fsm.pConf.PeerAS, p.fsm.pConf.LocalAS, 0, remoteIP, localIP,
+		pkt, local)
+	p.fsm.Manager.mrtWriter.Write(msg)
+}
+
 func (p *PeerConn) ReadPkt(doneCh chan bool, stopCh chan bool, exitCh chan bool) {
 	p.logger.Info("Neighbor:", p.fsm.pConf.NeighborAddress, "FSM", p.fsm.id, "conn:ReadPkt called")
 	var t time.Time
@@ -391,6 +418,7 @@ func (p *PeerConn) ReadPkt(doneCh chan bool, stopCh chan bool, exitCh chan bool)
 				}
 			}
 
+			hdrBuf := buf
 			header = packet.NewBGPHeader()
 			err = header.Decode(buf)
 			if err != nil {
@@ -424,6 +452,10 @@ func (p *PeerConn) ReadPkt(doneCh chan bool, stopCh chan bool, exitCh chan bool)
 				p.logger.Infof("Neighbor:%s FSM %d Received BGP packet %x", p.fsm.pConf.NeighborAddress, p.fsm.id, buf)
 			}
 
+			if p.isMRTDumpEnabled() {
+				p.dumpMRTMessage(append(hdrBuf, buf...), false)
+			}
+
 			msg, msgErr, msgOk := p.DecodeMessage(header, buf)
 			p.fsm.pktRxCh <- packet.NewBGPPktInfo(msg, msgErr)
 			doneCh <- msgOk
@@ -84,6 +84,8 @@ const (
 	BGPEventKeepAliveMsg
 	BGPEventUpdateMsg
 	BGPEventUpdateMsgErr
+	BGPEventRouteRefreshMsg
+	BGPEventRouteRefreshMsgErr
 )
 
 var BGPEventTypeToStr = map[BGPFSMEvent]string{
@@ -115,6 +117,8 @@ var BGPEventTypeToStr = map[BGPFSMEvent]string{
 	BGPEventKeepAliveMsg:                    "KeepAliveMsg",
 	BGPEventUpdateMsg:                       "UpdateMsg",
 	BGPEventUpdateMsgErr:                    "UpdateMsgErr",
+	BGPEventRouteRefreshMsg:                 "RouteRefreshMsg",
+	BGPEventRouteRefreshMsgErr:              "RouteRefreshMsgErr",
 }
 
 type BaseStateIface interface {
@@ -287,7 +291,8 @@ func (st *ConnectState) processEvent(event BGPFSMEvent, data interface{}) {
 
 	case BGPEventAutoStop, BGPEventHoldTimerExp, BGPEventKeepAliveTimerExp, BGPEventIdleHoldTimerExp,
 		BGPEventBGPOpen, BGPEventOpenCollisionDump, BGPEventNotifMsg, BGPEventKeepAliveMsg,
-		BGPEventUpdateMsg, BGPEventUpdateMsgErr: // 8, 10, 11, 13, 19, 23, 25-28
+		BGPEventUpdateMsg, BGPEventUpdateMsgErr, BGPEventRouteRefreshMsg,
+		BGPEventRouteRefreshMsgErr: // 8, 10, 11, 13, 19, 23, 25-28
 		st.fsm.StopConnectRetryTimer()
 		st.fsm.StopConnToPeer()
 		st.fsm.IncrConnectRetryCounter()
@@ -387,7 +392,8 @@ func (st *ActiveState) processEvent(event BGPFSMEvent, data interface{}) {
 
 	case BGPEventAutoStop, BGPEventHoldTimerExp, BGPEventKeepAliveTimerExp, BGPEventIdleHoldTimerExp,
 		BGPEventBGPOpen, BGPEventOpenCollisionDump, BGPEventNotifMsg, BGPEventKeepAliveMsg,
-		BGPEventUpdateMsg, BGPEventUpdateMsgErr: // 8, 10, 11, 13, 19, 23, 25-28
+		BGPEventUpdateMsg, BGPEventUpdateMsgErr, BGPEventRouteRefreshMsg,
+		BGPEventRouteRefreshMsgErr: // 8, 10, 11, 13, 19, 23, 25-28
 		st.fsm.StopConnectRetryTimer()
 		st.fsm.ClearPeerConn()
 		st.fsm.StopConnToPeer()
@@ -498,7 +504,8 @@ func (st *OpenSentState) processEvent(event BGPFSMEvent, data interface{}) {
 
 	case BGPEventConnRetryTimerExp, BGPEventKeepAliveTimerExp, BGPEventDelayOpenTimerExp,
 		BGPEventIdleHoldTimerExp, BGPEventBGPOpenDelayOpenTimer, BGPEventNotifMsg,
-		BGPEventKeepAliveMsg, BGPEventUpdateMsg, BGPEventUpdateMsgErr: // 9, 11, 12, 13, 20, 25-28
+		BGPEventKeepAliveMsg, BGPEventUpdateMsg, BGPEventUpdateMsgErr, BGPEventRouteRefreshMsg,
+		BGPEventRouteRefreshMsgErr: // 9, 11, 12, 13, 20, 25-28
 		st.fsm.SendNotificationMessage(packet.BGPFSMError, 0, nil)
 		st.fsm.StopConnectRetryTimer()
 		st.fsm.ClearPeerConn()
@@ -610,7 +617,8 @@ func (st *OpenConfirmState) processEvent(event BGPFSMEvent, data interface{}) {
 		st.fsm.ChangeState(NewEstablishedState(st.fsm))
 
 	case BGPEventConnRetryTimerExp, BGPEventDelayOpenTimerExp, BGPEventIdleHoldTimerExp,
-		BGPEventBGPOpenDelayOpenTimer, BGPEventUpdateMsg, BGPEventUpdateMsgErr: // 9, 12, 13, 20, 27, 28
+		BGPEventBGPOpenDelayOpenTimer, BGPEventUpdateMsg, BGPEventUpdateMsgErr, BGPEventRouteRefreshMsg,
+		BGPEventRouteRefreshMsgErr: // 9, 12, 13, 20, 27, 28
 		st.fsm.SendNotificationMessage(packet.BGPCease, 0, nil)
 		st.fsm.StopConnectRetryTimer()
 		st.fsm.ClearPeerConn()
@@ -723,6 +731,26 @@ func (st *EstablishedState) processEvent(event BGPFSMEvent, data interface{}) {
 		st.fsm.IncrConnectRetryCounter()
 		st.fsm.ChangeState(NewIdleState(st.fsm))
 
+	case BGPEventRouteRefreshMsg:
+		st.fsm.StartHoldTimer()
+		bgpMsg := data.(*packet.BGPMessage)
+		st.fsm.ProcessRouteRefreshMessage(bgpMsg)
+
+	case BGPEventRouteRefreshMsgErr:
+		// RFC 7313 - A malformed Route Refresh message is an error only if Enhanced Route Refresh is negotiated
+		bgpMsgErr := data.(*packet.BGPMessageError)
+		if !st.fsm.enhancedRouteRefresh {
+			st.logger.Info("Neighbor:", st.fsm.pConf.NeighborAddress, "FSM:", st.fsm.id,
+				"Ignore malformed route refresh message, err:", bgpMsgErr)
+			break
+		}
+		st.fsm.SendNotificationMessage(bgpMsgErr.TypeCode, bgpMsgErr.SubTypeCode, bgpMsgErr.Data)
+		st.fsm.StopConnectRetryTimer()
+		st.fsm.ClearPeerConn()
+		st.fsm.StopConnToPeer()
+		st.fsm.IncrConnectRetryCounter()
+		st.fsm.ChangeState(NewIdleState(st.fsm))
+
 	case BGPEventConnRetryTimerExp, BGPEventDelayOpenTimerExp, BGPEventIdleHoldTimerExp,
 		BGPEventOpenMsgErr, BGPEventBGPOpenDelayOpenTimer, BGPEventHeaderErr: // 9, 12, 13, 20, 21, 22
 		st.fsm.SendNotificationMessage(packet.BGPFSMError, 0, nil)
@@ -827,12 +855,20 @@ type FSM struct {
 	delayOpenTime  uint16
 	delayOpenTimer *time.Timer
 
-	afiSafiMap  map[uint32]bool
-	pktTxCh     chan *packet.BGPMessage
-	pktRxCh     chan *packet.BGPPktInfo
-	eventRxCh   chan PeerFSMEvent
-	bfdStatusCh chan bool
-	rxPktsFlag  bool
+	afiSafiMap           map[uint32]bool
+	routeRefresh         bool
+	enhancedRouteRefresh bool
+	gracefulRestartCap   *packet.BGPCapGracefulRestart
+	pktTxCh              chan *packet.BGPMessage
+	pktRxCh              chan *packet.BGPPktInfo
+	eventRxCh            chan PeerFSMEvent
+	bfdStatusCh          chan bool
+	rxPktsFlag           bool
+
+	sentOpenMsg *packet.BGPMessage
+	rcvdOpenMsg *packet.BGPMessage
+	notifMsg    *packet.BGPMessage
+	notifLocal  bool
 
 	close bool
 }
@@ -951,10 +987,14 @@ func (fsm *FSM) StartFSM() {
 		case bgpMsg := <-fsm.pktTxCh:
 			if fsm.State.state() != config.BGPFSMEstablished {
 				fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
-					"is not in Established state, can't send the UPDATE message")
+					"is not in Established state, can't send the message type", bgpMsg.Header.Type)
 				continue
 			}
-			fsm.sendUpdateMessage(bgpMsg)
+			if bgpMsg.Header.Type == packet.BGPMsgTypeRouteRefresh {
+				fsm.sendRouteRefreshMessage(bgpMsg)
+			} else {
+				fsm.sendUpdateMessage(bgpMsg)
+			}
 
 		case bgpPktInfo := <-fsm.pktRxCh:
 			fsm.ProcessPacket(bgpPktInfo.Msg, bgpPktInfo.MsgError)
@@ -1015,6 +1055,9 @@ func (fsm *FSM) ProcessPacket(msg *packet.BGPMessage, msgErr *packet.BGPMessageE
 
 		case packet.BGPUpdateMsgError:
 			event = BGPEventUpdateMsgErr
+
+		case packet.BGPRouteRefreshMsgError:
+			event = BGPEventRouteRefreshMsgErr
 		}
 	} else {
 		data = msg
@@ -1027,6 +1070,8 @@ func (fsm *FSM) ProcessPacket(msg *packet.BGPMessage, msgErr *packet.BGPMessageE
 
 		case packet.BGPMsgTypeNotification:
 			fsm.neighborConf.Neighbor.State.Messages.Received.Notification++
+			fsm.notifMsg = msg
+			fsm.notifLocal = false
 			event = BGPEventNotifMsg
 			notifyMsg := msg.Body.(*packet.BGPNotification)
 			fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "Received notification message:",
@@ -1034,6 +1079,9 @@ func (fsm *FSM) ProcessPacket(msg *packet.BGPMessage, msgErr *packet.BGPMessageE
 
 		case packet.BGPMsgTypeKeepAlive:
 			event = BGPEventKeepAliveMsg
+
+		case packet.BGPMsgTypeRouteRefresh:
+			event = BGPEventRouteRefreshMsg
 		}
 	}
 	if event != BGPEventKeepAliveMsg {
@@ -1188,6 +1236,10 @@ func (fsm *FSM) ProcessOpenMessage(pkt *packet.BGPMessage) bool {
 			fsm.afiSafiMap[protoFamily] = true
 		}
 	}
+	fsm.routeRefresh = packet.IsRouteRefreshSupported(body)
+	fsm.enhancedRouteRefresh = packet.IsEnhancedRouteRefreshSupported(body)
+	fsm.gracefulRestartCap = packet.GetGracefulRestartCap(body)
+	fsm.rcvdOpenMsg = pkt
 
 	return fsm.Manager.receivedBGPOpenMessage(fsm.id, fsm.peerConn.dir, body)
 }
@@ -1211,6 +1263,36 @@ func (fsm *FSM) ProcessUpdateMessage(pkt *packet.BGPMessage) {
 	}()
 }
 
+func (fsm *FSM) ProcessRouteRefreshMessage(pkt *packet.BGPMessage) {
+	refreshMsg := pkt.Body.(*packet.BGPRouteRefresh)
+	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "Received route refresh message for AFI",
+		refreshMsg.AFI, "SAFI", refreshMsg.SAFI, "subtype", refreshMsg.Subtype)
+	if !fsm.routeRefresh {
+		fsm.logger.Warning("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Received route refresh message, but the capability was not negotiated")
+	}
+
+	// RFC 2918 - Ignore the message if the AFI/SAFI was not advertised to the peer
+	protoFamily := packet.GetProtocolFamily(refreshMsg.AFI, refreshMsg.SAFI)
+	if !fsm.afiSafiMap[protoFamily] {
+		fsm.logger.Warning("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Ignore route refresh message for AFI", refreshMsg.AFI, "SAFI", refreshMsg.SAFI)
+		return
+	}
+
+	// RFC 7313 - Ignore the message with unknown subtype
+	if _, ok := packet.BGPRouteRefreshSubtypeToStr[refreshMsg.Subtype]; !ok {
+		fsm.logger.Warning("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Ignore route refresh message with subtype", refreshMsg.Subtype)
+		return
+	}
+
+	fsm.neighborConf.Neighbor.State.Messages.Received.RouteRefresh++
+	go func() {
+		fsm.Manager.bgpPktSrcCh <- packet.NewBGPPktSrc(fsm.Manager.neighborConf.Neighbor.NeighborAddress.String(), pkt)
+	}()
+}
+
 func (fsm *FSM) sendUpdateMessage(bgpMsg *packet.BGPMessage) {
 	fsm.logger.Infof("Neighbor:%s FSM %d Send BGP update message %+v", fsm.pConf.NeighborAddress, fsm.id, bgpMsg)
 	updateMsgs := packet.ConstructMaxSizedUpdatePackets(bgpMsg)
@@ -1230,6 +1312,7 @@ func (fsm *FSM) sendUpdateMessage(bgpMsg *packet.BGPMessage) {
 				"Conn.Write failed to send Update message with error:", err)
 			return
 		}
+		fsm.peerConn.dumpMRTMessage(packet, true)
 		fsm.StartKeepAliveTimer()
 		fsm.neighborConf.Neighbor.State.Messages.Sent.Update++
 		fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
@@ -1237,12 +1320,62 @@ func (fsm *FSM) sendUpdateMessage(bgpMsg *packet.BGPMessage) {
 	}
 }
 
+func (fsm *FSM) sendRouteRefreshMessage(bgpMsg *packet.BGPMessage) {
+	refreshMsg := bgpMsg.Body.(*packet.BGPRouteRefresh)
+	if !fsm.routeRefresh || (refreshMsg.Subtype != packet.BGPRouteRefreshNormal && !fsm.enhancedRouteRefresh) {
+		fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Route refresh capability is not negotiated, can't send route refresh message subtype",
+			refreshMsg.Subtype)
+		return
+	}
+
+	// RFC 2918 - Route refresh must not be sent for an AFI/SAFI that was not advertised by the peer
+	if !fsm.afiSafiMap[packet.GetProtocolFamily(refreshMsg.AFI, refreshMsg.SAFI)] {
+		fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Can't send route refresh message for AFI", refreshMsg.AFI, "SAFI", refreshMsg.SAFI)
+		return
+	}
+
+	packet, _ := bgpMsg.Encode()
+	num, err := (*fsm.peerConn.conn).Write(packet)
+	if err != nil {
+		fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+			"Conn.Write failed to send Route Refresh message with error:", err)
+		return
+	}
+	fsm.peerConn.dumpMRTMessage(packet, true)
+	fsm.StartKeepAliveTimer()
+	fsm.neighborConf.Neighbor.State.Messages.Sent.RouteRefresh++
+	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
+		"Conn.Write succeeded. sent Route Refresh message of", num, "bytes")
+}
+
+// getGracefulRestartCap returns the graceful restart capability to be sent in the OPEN message. Forwarding
+// state is preserved for all the address families since the routes stay in the RIB manager across restarts.
+func (fsm *FSM) getGracefulRestartCap() *packet.BGPCapGracefulRestart {
+	if !fsm.gConf.GracefulRestart {
+		return nil
+	}
+
+	grCap := packet.NewBGPCapGracefulRestart(fsm.neighborConf.Restarting, uint16(fsm.gConf.RestartTime))
+	for protoFamily, ok := range fsm.neighborConf.AfiSafiMap {
+		if ok {
+			afi, safi := packet.GetAfiSafi(protoFamily)
+			grCap.AddGracefulRestartAFISAFI(packet.NewGracefulRestartAFISAFI(afi, safi,
+				packet.BGPCapGracefulRestartFlagForwarding))
+		}
+	}
+	return grCap
+}
+
 func (fsm *FSM) sendOpenMessage() {
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
 		"sendOpenMessage: send address family", fsm.neighborConf.AfiSafiMap)
 	optParams := packet.ConstructOptParams(uint32(fsm.pConf.LocalAS), fsm.neighborConf.AfiSafiMap,
-		fsm.neighborConf.RunningConf.AddPathsRx, fsm.neighborConf.RunningConf.AddPathsMaxTx)
+		fsm.neighborConf.RunningConf.AddPathsRx, fsm.neighborConf.RunningConf.AddPathsMaxTx,
+		fsm.getGracefulRestartCap())
 	bgpOpenMsg := packet.NewBGPOpenMessage(fsm.pConf.LocalAS, uint16(fsm.holdTime), fsm.gConf.RouterId.To4().String(), optParams)
+	fsm.sentOpenMsg = bgpOpenMsg
 	packet, _ := bgpOpenMsg.Encode()
 	num, err := (*fsm.peerConn.conn).Write(packet)
 	if err != nil {
@@ -1250,6 +1383,7 @@ func (fsm *FSM) sendOpenMessage() {
 			"Conn.Write failed to send Open message with error:", err)
 		return
 	}
+	fsm.peerConn.dumpMRTMessage(packet, true)
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
 		"Conn.Write succeeded. sent Open message of", num, "bytes")
 }
@@ -1261,6 +1395,8 @@ func (fsm *FSM) sendKeepAliveMessage() {
 	if err != nil {
 		fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
 			"Conn.Write failed to send KeepAlive message with error:", err)
+	} else {
+		fsm.peerConn.dumpMRTMessage(packet, true)
 	}
 	fsm.StartKeepAliveTimer()
 }
@@ -1274,7 +1410,10 @@ func (fsm *FSM) SendNotificationMessage(code uint8, subCode uint8, data []byte)
 			"Conn.Write failed to send Notification message with error:", err)
 		return
 	}
+	fsm.peerConn.dumpMRTMessage(packet, true)
 	fsm.neighborConf.Neighbor.State.Messages.Sent.Notification++
+	fsm.notifMsg = bgpNotifMsg
+	fsm.notifLocal = true
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id,
 		"Conn.Write succeeded. sent Notification message with", num, "bytes")
 }
@@ -1319,13 +1458,14 @@ func (fsm *FSM) ClearPeerConn() {
 
 func (fsm *FSM) ConnEstablished() {
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "ConnEstablished - start")
+	fsm.notifMsg = nil
 	fsm.Manager.fsmEstablished(fsm.id, fsm.peerConn.conn)
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "ConnEstablished - end")
 }
 
 func (fsm *FSM) ConnBroken() {
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "ConnBroken - start")
-	fsm.Manager.fsmBroken(fsm.id, false)
+	fsm.Manager.fsmBroken(fsm.id, false, fsm.event == BGPEventTcpConnFails)
 	fsm.logger.Info("Neighbor:", fsm.pConf.NeighborAddress, "FSM", fsm.id, "ConnBroken - end")
 }
 
@@ -28,6 +28,7 @@ import (
 	_ "fmt"
 	"l3/bgp/baseobjects"
 	"l3/bgp/config"
+	"l3/bgp/mrt"
 	"l3/bgp/packet"
 	"net"
 	"sync"
@@ -35,9 +36,15 @@ import (
 )
 
 type PeerFSMConn struct {
-	PeerIP      string
-	Established bool
-	Conn        *net.Conn
+	PeerIP          string
+	Established     bool
+	Conn            *net.Conn
+	GracefulRestart bool
+	SentOpen        *packet.BGPMessage
+	RcvdOpen        *packet.BGPMessage
+	Notification    *packet.BGPMessage
+	NotifLocal      bool
+	Event           BGPFSMEvent
 }
 
 type PeerFSMState struct {
@@ -85,6 +92,7 @@ type FSMManager struct {
 	activeFSM      uint8
 	newConnCh      chan PeerFSMConnState
 	fsmMutex       sync.RWMutex
+	mrtWriter      *mrt.FileWriter
 }
 
 func NewFSMManager(logger *logging.Writer, neighborConf *base.NeighborConf, bgpPktSrcCh chan *packet.BGPPktSrc,
@@ -112,6 +120,11 @@ func NewFSMManager(logger *logging.Writer, neighborConf *base.NeighborConf, bgpP
 	return &mgr
 }
 
+// SetMRTWriter sets the writer for the MRT dump of the messages exchanged with the peer.
+func (mgr *FSMManager) SetMRTWriter(mrtWriter *mrt.FileWriter) {
+	mgr.mrtWriter = mrtWriter
+}
+
 func (mgr *FSMManager) Init() {
 	fsmId := uint8(config.ConnDirOut)
 	fsm := NewFSM(mgr, fsmId, mgr.neighborConf)
@@ -213,15 +226,15 @@ func (mgr *FSMManager) fsmTcpConnFailed(id uint8) {
 
 	mgr.logger.Infof("FSMManager: Peer %s FSM %d TCP conn failed", mgr.pConf.NeighborAddress.String(), id)
 	if len(mgr.fsms) != 1 && mgr.activeFSM != id {
-		mgr.fsmClose(id)
+		mgr.fsmClose(id, false)
 	}
 }
 
-func (mgr *FSMManager) fsmClose(id uint8) {
+func (mgr *FSMManager) fsmClose(id uint8, gracefulRestart bool) {
 	if closeFSM, ok := mgr.fsms[id]; ok {
 		mgr.logger.Infof("FSMManager: Peer %s, close FSM %d", mgr.pConf.NeighborAddress.String(), id)
 		closeFSM.closeCh <- true
-		mgr.fsmBroken(id, false)
+		mgr.fsmBroken(id, false, gracefulRestart)
 		mgr.fsms[id] = nil
 		delete(mgr.fsms, id)
 		mgr.logger.Infof("FSMManager: Peer %s, closed FSM %d", mgr.pConf.NeighborAddress.String(), id)
@@ -233,9 +246,15 @@ func (mgr *FSMManager) fsmClose(id uint8) {
 
 func (mgr *FSMManager) fsmEstablished(id uint8, conn *net.Conn) {
 	mgr.logger.Infof("FSMManager: Peer %s FSM %d connection established", mgr.pConf.NeighborAddress.String(), id)
-	if _, ok := mgr.fsms[id]; ok {
+	if fsm, ok := mgr.fsms[id]; ok {
 		mgr.activeFSM = id
-		mgr.fsmConnCh <- PeerFSMConn{mgr.neighborConf.Neighbor.NeighborAddress.String(), true, conn}
+		mgr.fsmConnCh <- PeerFSMConn{
+			PeerIP:      mgr.neighborConf.Neighbor.NeighborAddress.String(),
+			Established: true,
+			Conn:        conn,
+			SentOpen:    fsm.sentOpenMsg,
+			RcvdOpen:    fsm.rcvdOpenMsg,
+		}
 	} else {
 		mgr.logger.Infof("FSMManager: Peer %s FSM %d not found in fsms dict %v", mgr.pConf.NeighborAddress.String(),
 			id, mgr.fsms)
@@ -243,11 +262,22 @@ func (mgr *FSMManager) fsmEstablished(id uint8, conn *net.Conn) {
 	//mgr.Peer.PeerConnEstablished(conn)
 }
 
-func (mgr *FSMManager) fsmBroken(id uint8, fsmDelete bool) {
-	mgr.logger.Infof("FSMManager: Peer %s FSM %d connection broken", mgr.pConf.NeighborAddress.String(), id)
+func (mgr *FSMManager) fsmBroken(id uint8, fsmDelete bool, gracefulRestart bool) {
+	mgr.logger.Infof("FSMManager: Peer %s FSM %d connection broken, graceful restart %t",
+		mgr.pConf.NeighborAddress.String(), id, gracefulRestart)
 	if mgr.activeFSM == id {
 		mgr.activeFSM = uint8(config.ConnDirInvalid)
-		mgr.fsmConnCh <- PeerFSMConn{mgr.neighborConf.Neighbor.NeighborAddress.String(), false, nil}
+		fsmConn := PeerFSMConn{
+			PeerIP:          mgr.neighborConf.Neighbor.NeighborAddress.String(),
+			Established:     false,
+			GracefulRestart: gracefulRestart,
+		}
+		if fsm, ok := mgr.fsms[id]; ok && fsm != nil {
+			fsmConn.Notification = fsm.notifMsg
+			fsmConn.NotifLocal = fsm.notifLocal
+			fsmConn.Event = fsm.event
+		}
+		mgr.fsmConnCh <- fsmConn
 		//mgr.Peer.PeerConnBroken(fsmDelete)
 	}
 }
@@ -273,6 +303,19 @@ func (mgr *FSMManager) SendUpdateMsg(bgpMsg *packet.BGPMessage) {
 	mgr.fsms[mgr.activeFSM].pktTxCh <- bgpMsg
 }
 
+func (mgr *FSMManager) SendRouteRefreshMsg(afi packet.AFI, safi packet.SAFI, subtype uint8) {
+	mgr.fsmMutex.RLock()
+	defer mgr.fsmMutex.RUnlock()
+
+	if mgr.activeFSM == uint8(config.ConnDirInvalid) {
+		mgr.logger.Infof("FSMManager: Neighbor %s FSM is not in ESTABLISHED state", mgr.pConf.NeighborAddress)
+		return
+	}
+	mgr.logger.Infof("FSMManager: Neighbor %s FSM %d - send route refresh afi %d safi %d subtype %d",
+		mgr.pConf.NeighborAddress, mgr.activeFSM, afi, safi, subtype)
+	mgr.fsms[mgr.activeFSM].pktTxCh <- packet.NewBGPRouteRefreshMessage(afi, safi, subtype)
+}
+
 func (mgr *FSMManager) Cleanup() {
 	mgr.fsmMutex.Lock()
 	defer mgr.fsmMutex.Unlock()
@@ -282,7 +325,7 @@ func (mgr *FSMManager) Cleanup() {
 			mgr.logger.Infof("FSMManager: Neighbor %s FSM %d - cleanup FSM", mgr.pConf.NeighborAddress, id)
 			fsm.closeCh <- true
 			fsm = nil
-			mgr.fsmBroken(id, true)
+			mgr.fsmBroken(id, true, false)
 			mgr.fsmStateChange(id, config.BGPFSMIdle)
 			mgr.fsms[id] = nil
 			delete(mgr.fsms, id)
@@ -298,7 +341,7 @@ func (mgr *FSMManager) StopFSM(stopMsg string) {
 		if fsm != nil {
 			mgr.logger.Infof("FSMManager: Neighbor %s FSM %d - Stop FSM", mgr.pConf.NeighborAddress, id)
 			fsm.eventRxCh <- PeerFSMEvent{BGPEventTcpConnFails, BGPCmdReasonNone}
-			mgr.fsmBroken(id, false)
+			mgr.fsmBroken(id, false, false)
 		}
 	}
 }
@@ -363,7 +406,15 @@ func (mgr *FSMManager) receivedBGPOpenMessage(id uint8, connDir config.ConnDir,
 	bgpIdInt := packet.ConvertIPBytesToUint(openMsg.BGPId.To4())
 	for fsmId, fsm := range mgr.fsms {
 		if fsmId != id && fsm != nil && fsm.State.state() >= config.BGPFSMOpensent {
-			if fsm.State.state() == config.BGPFSMEstablished {
+			if fsm.State.state() == config.BGPFSMEstablished && mgr.gConf.GracefulRestart &&
+				packet.GetGracefulRestartCap(openMsg) != nil {
+				// RFC 4724 - The peer restarted and re-connected before the old session was detected to be down.
+				// Close the old session as if the TCP connection failed and retain the routes from the peer.
+				mgr.logger.Infof("FSMManager - Neighbor %s: Peer restarted, close established FSM id %d",
+					mgr.pConf.NeighborAddress, fsmId)
+				mgr.fsmClose(fsmId, true)
+				continue
+			} else if fsm.State.state() == config.BGPFSMEstablished {
 				closeConnDir = connDir
 			} else if localBGPId > bgpIdInt {
 				closeConnDir = config.ConnDirIn
@@ -372,7 +423,7 @@ func (mgr *FSMManager) receivedBGPOpenMessage(id uint8, connDir config.ConnDir,
 			}
 			closeFSMId := mgr.getFSMIdByDir(closeConnDir)
 			mgr.logger.Infof("FSMManager - Neighbor %s: Close FSM id %d", mgr.pConf.NeighborAddress, closeFSMId)
-			mgr.fsmClose(closeFSMId)
+			mgr.fsmClose(closeFSMId, false)
 		}
 	}
 
@@ -382,7 +433,8 @@ func (mgr *FSMManager) receivedBGPOpenMessage(id uint8, connDir config.ConnDir,
 		if mgr.fsms[id] != nil {
 			mgr.logger.Infof("FSMManager - Neighbor %s: FSM %d set peer attr", mgr.pConf.NeighborAddress, id)
 			mgr.neighborConf.SetPeerAttrs(openMsg.BGPId, asSize, mgr.fsms[id].holdTime, mgr.fsms[id].keepAliveTime,
-				addPathFamily)
+				addPathFamily, mgr.fsms[id].routeRefresh, mgr.fsms[id].enhancedRouteRefresh,
+				mgr.fsms[id].gracefulRestartCap)
 		}
 	}
 
@@ -112,6 +112,7 @@ func main() {
 		pMgr := ovsMgr.NewOvsPolicyMgr()
 		iMgr := ovsMgr.NewOvsIntfMgr()
 		bMgr := ovsMgr.NewOvsBfdMgr()
+		eMgr := ovsMgr.NewOvsEVPNMgr()
 		sDBMgr, err := statedbclient.NewStateDBClient(statedbclient.OVSPlugin, logger)
 		if err != nil {
 			logger.Info(fmt.Sprintln("Starting OVDB state DB client failed ERROR:", err))
@@ -121,7 +122,7 @@ func main() {
 		// starting bgp policy engine...
 		logger.Info(fmt.Sprintln("Starting BGP policy engine..."))
 		bgpPolicyMgr := bgppolicy.NewPolicyManager(logger, pMgr)
-		bgpServer := server.NewBGPServer(logger, bgpPolicyMgr, iMgr, rMgr, bMgr, sDBMgr)
+		bgpServer := server.NewBGPServer(logger, bgpPolicyMgr, iMgr, rMgr, bMgr, eMgr, sDBMgr)
 
 		doneCh := make(chan bool)
 		go bgpPolicyMgr.StartPolicyEngine(dbUtil, doneCh)
@@ -160,6 +161,10 @@ func main() {
 		if err != nil {
 			return
 		}
+		eMgr, err := FSMgr.NewFSEVPNMgr(logger, fileName)
+		if err != nil {
+			return
+		}
 		sDBMgr, err := statedbclient.NewStateDBClient(statedbclient.FlexSwitchPlugin, logger)
 		if err != nil {
 			return
@@ -169,7 +174,7 @@ func main() {
 		pMgr := FSMgr.NewFSPolicyMgr(logger, fileName)
 		bgpPolicyMgr := bgppolicy.NewPolicyManager(logger, pMgr)
 		logger.Info(fmt.Sprintln("Starting BGP Server..."))
-		bgpServer := server.NewBGPServer(logger, bgpPolicyMgr, iMgr, rMgr, bMgr, sDBMgr)
+		bgpServer := server.NewBGPServer(logger, bgpPolicyMgr, iMgr, rMgr, bMgr, eMgr, sDBMgr)
 
 		doneCh := make(chan bool)
 		go bgpPolicyMgr.StartPolicyEngine(dbUtil, doneCh)
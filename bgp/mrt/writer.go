@@ -0,0 +1,178 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// writer.go
+package mrt
+
+import (
+	"l3/bgp/packet"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"utils/logging"
+)
+
+var fileNameReplacer = []string{"%Y", "2006", "%m", "01", "%d", "02", "%H", "15", "%M", "04", "%S", "05"}
+
+// FileName returns the name of the dump file for the time t. The strftime style conversions %Y, %m, %d,
+// %H, %M and %S in fileFormat are replaced with the corresponding fields of t.
+func FileName(fileFormat string, t time.Time) string {
+	replacer := make([]string, 0, len(fileNameReplacer))
+	for i := 0; i < len(fileNameReplacer); i += 2 {
+		replacer = append(replacer, fileNameReplacer[i], t.Format(fileNameReplacer[i+1]))
+	}
+	return strings.NewReplacer(replacer...).Replace(fileFormat)
+}
+
+// FileWriter writes MRT messages to a dump file. If an interval is configured a new file is opened at the
+// start of every interval, otherwise all the messages are appended to the same file until Rotate is called.
+// WriteDump writes a complete dump, such as a RIB snapshot, to a file of its own.
+type FileWriter struct {
+	logger     *logging.Writer
+	mutex      sync.Mutex
+	fileFormat string
+	interval   time.Duration
+	file       *os.File
+	rotateTime time.Time
+}
+
+func NewFileWriter(logger *logging.Writer) *FileWriter {
+	return &FileWriter{
+		logger: logger,
+	}
+}
+
+// Configure sets the file name format and the rotation interval in seconds. An empty file name format
+// disables the writer.
+func (w *FileWriter) Configure(fileFormat string, interval uint32) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if fileFormat == w.fileFormat && time.Duration(interval)*time.Second == w.interval {
+		return
+	}
+
+	w.closeFile()
+	w.fileFormat = fileFormat
+	w.interval = time.Duration(interval) * time.Second
+}
+
+func (w *FileWriter) IsEnabled() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.fileFormat != ""
+}
+
+func (w *FileWriter) closeFile() {
+	if w.file == nil {
+		return
+	}
+
+	if err := w.file.Close(); err != nil {
+		w.logger.Errf("MRT: failed to close dump file %s with error %s", w.file.Name(), err)
+	}
+	w.file = nil
+}
+
+func (w *FileWriter) openFile(now time.Time, flag int) error {
+	w.closeFile()
+	fileName := FileName(w.fileFormat, now)
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|flag, 0644)
+	if err != nil {
+		w.logger.Errf("MRT: failed to open dump file %s with error %s", fileName, err)
+		return err
+	}
+
+	w.logger.Infof("MRT: opened dump file %s", fileName)
+	w.file = file
+	if w.interval > 0 {
+		w.rotateTime = now.Truncate(w.interval).Add(w.interval)
+	}
+	return nil
+}
+
+// Rotate closes the current dump file. The next Write opens a new file.
+func (w *FileWriter) Rotate() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closeFile()
+}
+
+func (w *FileWriter) Write(msgs ...*packet.MRTMessage) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.fileFormat == "" {
+		return nil
+	}
+
+	now := time.Now()
+	if w.file == nil || (w.interval > 0 && !now.Before(w.rotateTime)) {
+		if err := w.openFile(now, os.O_APPEND); err != nil {
+			return err
+		}
+	}
+	return w.writeMsgs(msgs)
+}
+
+// WriteDump writes the messages to a new dump file and closes it. A file of the same name left by an earlier
+// dump is truncated, so every file holds exactly one dump.
+func (w *FileWriter) WriteDump(msgs ...*packet.MRTMessage) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.fileFormat == "" {
+		return nil
+	}
+
+	if err := w.openFile(time.Now(), os.O_TRUNC); err != nil {
+		return err
+	}
+	defer w.closeFile()
+	return w.writeMsgs(msgs)
+}
+
+func (w *FileWriter) writeMsgs(msgs []*packet.MRTMessage) error {
+	for _, msg := range msgs {
+		pkt, err := msg.Encode()
+		if err != nil {
+			w.logger.Errf("MRT: failed to encode message type %d subtype %d with error %s", msg.Header.Type,
+				msg.Header.Subtype, err)
+			continue
+		}
+
+		if _, err = w.file.Write(pkt); err != nil {
+			w.logger.Errf("MRT: failed to write to dump file %s with error %s", w.file.Name(), err)
+			w.closeFile()
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *FileWriter) Close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closeFile()
+}
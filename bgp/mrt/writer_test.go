@@ -0,0 +1,144 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// writer_test.go
+package mrt
+
+import (
+	"io"
+	"io/ioutil"
+	"l3/bgp/packet"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"utils/logging"
+)
+
+func newTestWriter(t *testing.T) (*FileWriter, string) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+
+	dir, err := ioutil.TempDir("", "mrt")
+	if err != nil {
+		t.Fatal("Failed to create the dump directory, error:", err)
+	}
+	return NewFileWriter(logger), dir
+}
+
+func readDump(t *testing.T, fileName string) []*packet.MRTMessage {
+	file, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal("Failed to open dump file", fileName, "error:", err)
+	}
+	defer file.Close()
+
+	msgs := make([]*packet.MRTMessage, 0)
+	reader := packet.NewMRTReader(file)
+	for {
+		msg, err := reader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal("Failed to read dump file", fileName, "error:", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestFileName(t *testing.T) {
+	now := time.Date(2016, time.March, 7, 9, 5, 3, 0, time.Local)
+	if name := FileName("/tmp/updates.%Y%m%d.%H%M%S", now); name != "/tmp/updates.20160307.090503" {
+		t.Fatal("MRT file name expanded to", name)
+	}
+}
+
+func TestFileWriter(t *testing.T) {
+	w, dir := newTestWriter(t)
+	defer os.RemoveAll(dir)
+
+	keepAlive, _ := packet.NewBGPKeepAliveMessage().Encode()
+	msg := packet.NewMRTBGP4MPMessage(time.Now(), 65002, 65001, 0, net.ParseIP("10.1.1.2"),
+		net.ParseIP("10.1.1.1"), keepAlive, false)
+	if err := w.Write(msg); err != nil || w.IsEnabled() {
+		t.Fatal("MRT writer without a file wrote the message, error:", err)
+	}
+
+	fileName := filepath.Join(dir, "updates")
+	w.Configure(fileName, 0)
+	if !w.IsEnabled() {
+		t.Fatal("MRT writer is not enabled after configuring file", fileName)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := w.Write(msg); err != nil {
+			t.Fatal("MRT writer failed to write the message, error:", err)
+		}
+	}
+	w.Rotate()
+	if err := w.Write(msg, msg); err != nil {
+		t.Fatal("MRT writer failed to write the message, error:", err)
+	}
+	w.Close()
+
+	msgs := readDump(t, fileName)
+	if len(msgs) != 5 {
+		t.Fatalf("MRT dump file %s has %d messages, expected 5", fileName, len(msgs))
+	}
+
+	for _, rxMsg := range msgs {
+		if rxMsg.Header.Type != packet.MRTTypeBGP4MP || len(rxMsg.GetBGPMessages()) != 1 {
+			t.Fatalf("MRT dump file %s has message %+v", fileName, rxMsg)
+		}
+	}
+}
+
+func TestFileWriterDump(t *testing.T) {
+	w, dir := newTestWriter(t)
+	defer os.RemoveAll(dir)
+
+	keepAlive, _ := packet.NewBGPKeepAliveMessage().Encode()
+	msg := packet.NewMRTBGP4MPMessage(time.Now(), 65002, 65001, 0, net.ParseIP("10.1.1.2"),
+		net.ParseIP("10.1.1.1"), keepAlive, false)
+	fileName := filepath.Join(dir, "rib")
+	w.Configure(fileName, 0)
+
+	if err := w.WriteDump(msg, msg, msg); err != nil {
+		t.Fatal("MRT writer failed to write the dump, error:", err)
+	}
+	if msgs := readDump(t, fileName); len(msgs) != 3 {
+		t.Fatalf("MRT dump file %s has %d messages, expected 3", fileName, len(msgs))
+	}
+
+	// The next dump to the same file name replaces the earlier one
+	if err := w.WriteDump(msg, msg); err != nil {
+		t.Fatal("MRT writer failed to write the dump, error:", err)
+	}
+	if msgs := readDump(t, fileName); len(msgs) != 2 {
+		t.Fatalf("MRT dump file %s has %d messages after the second dump, expected 2", fileName, len(msgs))
+	}
+}
@@ -37,7 +37,7 @@ func (mgr *OvsBfdMgr) Start() {
 
 }
 
-func (mgr *OvsBfdMgr) CreateBfdSession(ipAddr string, iface string, sessionParam string) (bool, error) {
+func (mgr *OvsBfdMgr) CreateBfdSession(ipAddr string, iface string, sessionParam string, multiHop bool) (bool, error) {
 	return true, nil
 }
 
@@ -0,0 +1,50 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package ovsMgr
+
+/*  Constructor for evpn manager
+ */
+func NewOvsEVPNMgr() *OvsEVPNMgr {
+	mgr := &OvsEVPNMgr{
+		plugin: "ovsdb",
+	}
+
+	return mgr
+}
+
+func (mgr *OvsEVPNMgr) Start() {
+
+}
+
+func (mgr *OvsEVPNMgr) CreateVtep(vni uint32, remoteIP string) {
+}
+
+func (mgr *OvsEVPNMgr) DeleteVtep(vni uint32, remoteIP string) {
+}
+
+func (mgr *OvsEVPNMgr) CreateRemoteMac(vni uint32, mac string, ip string, remoteIP string) {
+}
+
+func (mgr *OvsEVPNMgr) DeleteRemoteMac(vni uint32, mac string, ip string, remoteIP string) {
+}
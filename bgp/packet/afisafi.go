@@ -40,14 +40,26 @@ const (
 	AfiIP6
 )
 
+const (
+	AfiL2VPN AFI = 25
+)
+
 const (
 	SafiUnicast SAFI = iota + 1
 	SafiMulticast
 )
 
+const (
+	SafiEVPN    SAFI = 70
+	SafiMPLSVPN SAFI = 128
+)
+
 var ProtocolFamilyMap = map[string]uint32{
-	"ipv4-unicast": GetProtocolFamily(AfiIP, SafiUnicast),
-	"ipv6-unicast": GetProtocolFamily(AfiIP6, SafiUnicast),
+	"ipv4-unicast":       GetProtocolFamily(AfiIP, SafiUnicast),
+	"ipv6-unicast":       GetProtocolFamily(AfiIP6, SafiUnicast),
+	"l3vpn-ipv4-unicast": GetProtocolFamily(AfiIP, SafiMPLSVPN),
+	"l3vpn-ipv6-unicast": GetProtocolFamily(AfiIP6, SafiMPLSVPN),
+	"l2vpn-evpn":         GetProtocolFamily(AfiL2VPN, SafiEVPN),
 	//"ipv4-multicast": GetProtocolFamily(AfiIP, SafiMulticast),
 	//"ipv6-multicast": GetProtocolFamily(AfiIP6, SafiMulticast),
 }
@@ -102,6 +114,11 @@ func GetAfiSafi(protocolFamily uint32) (AFI, SAFI) {
 	return AFI(protocolFamily >> 8), SAFI(protocolFamily & 0xFF)
 }
 
+func IsUnicastFamily(protoFamily uint32) bool {
+	_, safi := GetAfiSafi(protoFamily)
+	return safi == SafiUnicast
+}
+
 func GetAddressLengthForFamily(protoFamily uint32) int {
 	afi, _ := GetAfiSafi(protoFamily)
 	if addrLen, ok := AFINextHopLenMap[afi]; ok {
@@ -67,6 +67,7 @@ const (
 	BGPMsgTypeUpdate
 	BGPMsgTypeNotification
 	BGPMsgTypeKeepAlive
+	BGPMsgTypeRouteRefresh
 )
 
 const (
@@ -85,6 +86,7 @@ const (
 	BGPHoldTimerExpired
 	BGPFSMError
 	BGPCease
+	BGPRouteRefreshMsgError
 )
 
 const (
@@ -120,6 +122,23 @@ const (
 	BGPMalformedASPath
 )
 
+const (
+	_ uint8 = iota
+	BGPInvalidRouteRefreshMsgLen
+)
+
+const (
+	BGPRouteRefreshNormal uint8 = iota
+	BGPRouteRefreshBoRR
+	BGPRouteRefreshEoRR
+)
+
+var BGPRouteRefreshSubtypeToStr = map[uint8]string{
+	BGPRouteRefreshNormal: "Normal",
+	BGPRouteRefreshBoRR:   "BoRR",
+	BGPRouteRefreshEoRR:   "EoRR",
+}
+
 type BGPOptParamType uint8
 
 const (
@@ -137,14 +156,20 @@ type BGPCapabilityType uint8
 const (
 	_ BGPCapabilityType = iota
 	BGPCapTypeMPExt
-	BGPCapTypeAS4Path BGPCapabilityType = 65
-	BGPCapTypeAddPath BGPCapabilityType = 69
+	BGPCapTypeRouteRefresh
+	BGPCapTypeGracefulRestart      BGPCapabilityType = 64
+	BGPCapTypeAS4Path              BGPCapabilityType = 65
+	BGPCapTypeAddPath              BGPCapabilityType = 69
+	BGPCapTypeEnhancedRouteRefresh BGPCapabilityType = 70
 )
 
 var BGPCapTypeToStruct = map[BGPCapabilityType]BGPCapability{
-	BGPCapTypeMPExt:   &BGPCapMPExt{},
-	BGPCapTypeAS4Path: &BGPCapAS4Path{},
-	BGPCapTypeAddPath: &BGPCapAddPath{},
+	BGPCapTypeMPExt:                &BGPCapMPExt{},
+	BGPCapTypeRouteRefresh:         &BGPCapRouteRefresh{},
+	BGPCapTypeGracefulRestart:      &BGPCapGracefulRestart{},
+	BGPCapTypeAS4Path:              &BGPCapAS4Path{},
+	BGPCapTypeAddPath:              &BGPCapAddPath{},
+	BGPCapTypeEnhancedRouteRefresh: &BGPCapEnhancedRouteRefresh{},
 }
 
 const (
@@ -152,6 +177,12 @@ const (
 	BGPCapAddPathTx
 )
 
+const (
+	BGPCapGracefulRestartFlagRestart    uint8  = 0x8
+	BGPCapGracefulRestartFlagForwarding uint8  = 0x80
+	BGPCapGracefulRestartTimeMax        uint16 = 0xFFF
+)
+
 type BGPPathAttrFlag uint8
 
 const (
@@ -176,7 +207,7 @@ const (
 	BGPPathAttrTypeLocalPref
 	BGPPathAttrTypeAtomicAggregate
 	BGPPathAttrTypeAggregator
-	_
+	BGPPathAttrTypeCommunities
 	BGPPathAttrTypeOriginatorId
 	BGPPathAttrTypeClusterList
 	_
@@ -184,10 +215,12 @@ const (
 	_
 	BGPPathAttrTypeMPReachNLRI
 	BGPPathAttrTypeMPUnreachNLRI
-	_
+	BGPPathAttrTypeExtCommunities
 	BGPPathAttrTypeAS4Path
 	BGPPathAttrTypeAS4Aggregator
 	BGPPathAttrTypeUnknown
+	BGPPathAttrTypePMSITunnel       BGPPathAttrType = 22
+	BGPPathAttrTypeLargeCommunities BGPPathAttrType = 32
 )
 
 type BGPPathAttrOriginType uint8
@@ -218,35 +251,43 @@ var BGPPathAttrWellKnownMandatory = []BGPPathAttrType{
 	BGPPathAttrTypeOrigin, BGPPathAttrTypeASPath, BGPPathAttrTypeNextHop}
 
 var BGPPathAttrTypeToStructMap = map[BGPPathAttrType]BGPPathAttr{
-	BGPPathAttrTypeOrigin:          &BGPPathAttrOrigin{},
-	BGPPathAttrTypeASPath:          &BGPPathAttrASPath{},
-	BGPPathAttrTypeNextHop:         &BGPPathAttrNextHop{},
-	BGPPathAttrTypeMultiExitDisc:   &BGPPathAttrMultiExitDisc{},
-	BGPPathAttrTypeLocalPref:       &BGPPathAttrLocalPref{},
-	BGPPathAttrTypeAtomicAggregate: &BGPPathAttrAtomicAggregate{},
-	BGPPathAttrTypeAggregator:      &BGPPathAttrAggregator{},
-	BGPPathAttrTypeOriginatorId:    &BGPPathAttrOriginatorId{},
-	BGPPathAttrTypeClusterList:     &BGPPathAttrClusterList{},
-	BGPPathAttrTypeMPReachNLRI:     &BGPPathAttrMPReachNLRI{},
-	BGPPathAttrTypeMPUnreachNLRI:   &BGPPathAttrMPUnreachNLRI{},
-	BGPPathAttrTypeAS4Path:         &BGPPathAttrAS4Path{},
-	BGPPathAttrTypeAS4Aggregator:   &BGPPathAttrAS4Aggregator{},
+	BGPPathAttrTypeOrigin:           &BGPPathAttrOrigin{},
+	BGPPathAttrTypeASPath:           &BGPPathAttrASPath{},
+	BGPPathAttrTypeNextHop:          &BGPPathAttrNextHop{},
+	BGPPathAttrTypeMultiExitDisc:    &BGPPathAttrMultiExitDisc{},
+	BGPPathAttrTypeLocalPref:        &BGPPathAttrLocalPref{},
+	BGPPathAttrTypeAtomicAggregate:  &BGPPathAttrAtomicAggregate{},
+	BGPPathAttrTypeAggregator:       &BGPPathAttrAggregator{},
+	BGPPathAttrTypeCommunities:      &BGPPathAttrCommunities{},
+	BGPPathAttrTypeOriginatorId:     &BGPPathAttrOriginatorId{},
+	BGPPathAttrTypeClusterList:      &BGPPathAttrClusterList{},
+	BGPPathAttrTypeMPReachNLRI:      &BGPPathAttrMPReachNLRI{},
+	BGPPathAttrTypeMPUnreachNLRI:    &BGPPathAttrMPUnreachNLRI{},
+	BGPPathAttrTypeExtCommunities:   &BGPPathAttrExtCommunities{},
+	BGPPathAttrTypeAS4Path:          &BGPPathAttrAS4Path{},
+	BGPPathAttrTypeAS4Aggregator:    &BGPPathAttrAS4Aggregator{},
+	BGPPathAttrTypePMSITunnel:       &BGPPathAttrPMSITunnel{},
+	BGPPathAttrTypeLargeCommunities: &BGPPathAttrLargeCommunities{},
 }
 
 var BGPPathAttrTypeFlagsMap = map[BGPPathAttrType][]BGPPathAttrFlag{
-	BGPPathAttrTypeOrigin:          []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeASPath:          []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeNextHop:         []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeMultiExitDisc:   []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeLocalPref:       []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeAtomicAggregate: []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeAggregator:      []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeOriginatorId:    []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeClusterList:     []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeMPReachNLRI:     []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeMPUnreachNLRI:   []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeAS4Path:         []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
-	BGPPathAttrTypeAS4Aggregator:   []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeOrigin:           []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeASPath:           []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeNextHop:          []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeMultiExitDisc:    []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeLocalPref:        []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeAtomicAggregate:  []BGPPathAttrFlag{BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeAggregator:       []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeCommunities:      []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeOriginatorId:     []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeClusterList:      []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeMPReachNLRI:      []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeMPUnreachNLRI:    []BGPPathAttrFlag{BGPPathAttrFlagOptional, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeExtCommunities:   []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeAS4Path:          []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeAS4Aggregator:    []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypePMSITunnel:       []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
+	BGPPathAttrTypeLargeCommunities: []BGPPathAttrFlag{BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive, BGPPathAttrFlagAllMinusExtendedLen},
 }
 
 var BGPPathAttrTypeLenMap = map[BGPPathAttrType]uint16{
@@ -438,6 +479,165 @@ func NewBGPCap4ByteASPath(as uint32) *BGPCapAS4Path {
 	}
 }
 
+type BGPCapRouteRefresh struct {
+	BGPCapabilityBase
+}
+
+func (msg *BGPCapRouteRefresh) New() BGPCapability {
+	return &BGPCapRouteRefresh{}
+}
+
+func NewBGPCapRouteRefresh() *BGPCapRouteRefresh {
+	return &BGPCapRouteRefresh{
+		BGPCapabilityBase: BGPCapabilityBase{
+			Type: BGPCapTypeRouteRefresh,
+			Len:  0,
+		},
+	}
+}
+
+type BGPCapEnhancedRouteRefresh struct {
+	BGPCapabilityBase
+}
+
+func (msg *BGPCapEnhancedRouteRefresh) New() BGPCapability {
+	return &BGPCapEnhancedRouteRefresh{}
+}
+
+func NewBGPCapEnhancedRouteRefresh() *BGPCapEnhancedRouteRefresh {
+	return &BGPCapEnhancedRouteRefresh{
+		BGPCapabilityBase: BGPCapabilityBase{
+			Type: BGPCapTypeEnhancedRouteRefresh,
+			Len:  0,
+		},
+	}
+}
+
+type GracefulRestartAFISAFI struct {
+	AFI   AFI
+	SAFI  SAFI
+	Flags uint8
+}
+
+func (g *GracefulRestartAFISAFI) Encode(pkt []byte) error {
+	binary.BigEndian.PutUint16(pkt, uint16(g.AFI))
+	pkt[2] = uint8(g.SAFI)
+	pkt[3] = g.Flags
+	return nil
+}
+
+func (g *GracefulRestartAFISAFI) Decode(pkt []byte) error {
+	if len(pkt) < 4 {
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil,
+			"Not enough data to decode Graceful restart capability"}
+	}
+
+	g.AFI = AFI(binary.BigEndian.Uint16(pkt))
+	g.SAFI = SAFI(pkt[2])
+	g.Flags = pkt[3]
+	return nil
+}
+
+func (g *GracefulRestartAFISAFI) Len() uint8 {
+	return 4
+}
+
+func (g *GracefulRestartAFISAFI) IsForwardingPreserved() bool {
+	return (g.Flags & BGPCapGracefulRestartFlagForwarding) != 0
+}
+
+func NewGracefulRestartAFISAFI(afi AFI, safi SAFI, flags uint8) *GracefulRestartAFISAFI {
+	return &GracefulRestartAFISAFI{
+		AFI:   afi,
+		SAFI:  safi,
+		Flags: flags,
+	}
+}
+
+type BGPCapGracefulRestart struct {
+	BGPCapabilityBase
+	Flags       uint8
+	RestartTime uint16
+	Value       []GracefulRestartAFISAFI
+}
+
+func (msg *BGPCapGracefulRestart) New() BGPCapability {
+	return &BGPCapGracefulRestart{}
+}
+
+func (msg *BGPCapGracefulRestart) Encode() ([]byte, error) {
+	pkt, err := msg.BGPCapabilityBase.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	binary.BigEndian.PutUint16(pkt[2:], (uint16(msg.Flags)<<12)|(msg.RestartTime&BGPCapGracefulRestartTimeMax))
+	offset := uint8(4)
+	for _, val := range msg.Value {
+		val.Encode(pkt[offset:])
+		offset += val.Len()
+	}
+	return pkt, nil
+}
+
+func (msg *BGPCapGracefulRestart) Decode(pkt []byte) error {
+	err := msg.BGPCapabilityBase.Decode(pkt)
+	if err != nil {
+		return err
+	}
+
+	if msg.Len < 2 {
+		return BGPMessageError{BGPOpenMsgError, BGPUnspecific, nil,
+			"Not enough data to decode Graceful restart capability"}
+	}
+
+	flagsAndTime := binary.BigEndian.Uint16(pkt[2:])
+	msg.Flags = uint8(flagsAndTime >> 12)
+	msg.RestartTime = flagsAndTime & BGPCapGracefulRestartTimeMax
+
+	msg.Value = make([]GracefulRestartAFISAFI, 0)
+	offset := uint16(4)
+	for offset < msg.TotalLen() {
+		grAFISAFI := GracefulRestartAFISAFI{}
+		err := grAFISAFI.Decode(pkt[offset:msg.TotalLen()])
+		if err != nil {
+			return err
+		}
+		msg.Value = append(msg.Value, grAFISAFI)
+		offset += uint16(grAFISAFI.Len())
+	}
+	return nil
+}
+
+func (msg *BGPCapGracefulRestart) AddGracefulRestartAFISAFI(grAFISAFI *GracefulRestartAFISAFI) {
+	msg.Value = append(msg.Value, *grAFISAFI)
+	msg.Len += grAFISAFI.Len()
+}
+
+func (msg *BGPCapGracefulRestart) IsRestarting() bool {
+	return (msg.Flags & BGPCapGracefulRestartFlagRestart) != 0
+}
+
+func NewBGPCapGracefulRestart(restarting bool, restartTime uint16) *BGPCapGracefulRestart {
+	flags := uint8(0)
+	if restarting {
+		flags |= BGPCapGracefulRestartFlagRestart
+	}
+	if restartTime > BGPCapGracefulRestartTimeMax {
+		restartTime = BGPCapGracefulRestartTimeMax
+	}
+
+	return &BGPCapGracefulRestart{
+		BGPCapabilityBase: BGPCapabilityBase{
+			Type: BGPCapTypeGracefulRestart,
+			Len:  2,
+		},
+		Flags:       flags,
+		RestartTime: restartTime,
+		Value:       make([]GracefulRestartAFISAFI, 0),
+	}
+}
+
 type AddPathAFISAFI struct {
 	AFI   AFI
 	SAFI  SAFI
@@ -862,6 +1062,47 @@ func NewBGPNotificationMessage(errorCode uint8, errorSubCode uint8, data []byte)
 	}
 }
 
+type BGPRouteRefresh struct {
+	AFI     AFI
+	Subtype uint8
+	SAFI    SAFI
+}
+
+func (msg *BGPRouteRefresh) Clone() BGPBody {
+	x := *msg
+	return &x
+}
+
+func (msg *BGPRouteRefresh) Encode() ([]byte, error) {
+	pkt := make([]byte, 4)
+	binary.BigEndian.PutUint16(pkt, uint16(msg.AFI))
+	pkt[2] = msg.Subtype
+	pkt[3] = uint8(msg.SAFI)
+	return pkt, nil
+}
+
+func (msg *BGPRouteRefresh) Decode(header *BGPHeader, pkt []byte, data interface{}) error {
+	if len(pkt) != 4 {
+		// RFC 7313 - Data field of the notification carries the complete Route Refresh message
+		data, _ := header.Encode()
+		data = append(data, pkt...)
+		return BGPMessageError{BGPRouteRefreshMsgError, BGPInvalidRouteRefreshMsgLen, data,
+			fmt.Sprintf("Route refresh message length %d is not valid", header.Len())}
+	}
+
+	msg.AFI = AFI(binary.BigEndian.Uint16(pkt))
+	msg.Subtype = pkt[2]
+	msg.SAFI = SAFI(pkt[3])
+	return nil
+}
+
+func NewBGPRouteRefreshMessage(afi AFI, safi SAFI, subtype uint8) *BGPMessage {
+	return &BGPMessage{
+		Header: BGPHeader{Length: 23, Type: BGPMsgTypeRouteRefresh},
+		Body:   &BGPRouteRefresh{afi, subtype, safi},
+	}
+}
+
 type NLRI interface {
 	Clone() NLRI
 	Encode(AFI) ([]byte, error)
@@ -2313,7 +2554,11 @@ func decodeNLRI(pkt []byte, ipPrefix *[]NLRI, length uint32, afi AFI, safi SAFI,
 	peerAttrs := data.(BGPPeerAttrs)
 
 	for ptr < length {
-		if peerAttrs.AddPathsRxActual {
+		if safi == SafiMPLSVPN {
+			ip = &VPNPrefix{}
+		} else if safi == SafiEVPN {
+			ip = &EVPNPrefix{}
+		} else if peerAttrs.AddPathsRxActual {
 			ip = &ExtNLRI{}
 		} else {
 			ip = &IPPrefix{}
@@ -2413,6 +2658,16 @@ func NewBGPUpdateMessage(wdRoutes []NLRI, pa []BGPPathAttr, nlri []NLRI) *BGPMes
 	}
 }
 
+// NewBGPEndOfRIBMessage constructs the RFC 4724 End-of-RIB marker for the protocol family. The marker for
+// IPv4 unicast is an empty UPDATE, for the other families it is an UPDATE with an empty MP_UNREACH_NLRI.
+func NewBGPEndOfRIBMessage(protoFamily uint32) *BGPMessage {
+	pathAttrs := make([]BGPPathAttr, 0)
+	if protoFamily != GetProtocolFamily(AfiIP, SafiUnicast) {
+		pathAttrs = append(pathAttrs, ConstructMPUnreachNLRIFromProtoFamily(protoFamily, make([]NLRI, 0)))
+	}
+	return NewBGPUpdateMessage(make([]NLRI, 0), pathAttrs, make([]NLRI, 0))
+}
+
 type BGPMessage struct {
 	Header BGPHeader
 	Body   BGPBody
@@ -2464,6 +2719,9 @@ func (msg *BGPMessage) Decode(header *BGPHeader, pkt []byte, data interface{}) e
 	case BGPMsgTypeNotification:
 		msg.Body = &BGPNotification{}
 
+	case BGPMsgTypeRouteRefresh:
+		msg.Body = &BGPRouteRefresh{}
+
 	default:
 		return nil
 	}
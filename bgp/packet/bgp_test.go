@@ -436,3 +436,198 @@ func TestBGPUpdateEncode(t *testing.T) {
 		t.Fatal("Cloned update message is not the same as the original message")
 	}
 }
+
+func TestBGPRouteRefreshEncodeDecode(t *testing.T) {
+	refreshMsg := NewBGPRouteRefreshMessage(AfiIP6, SafiUnicast, BGPRouteRefreshBoRR)
+	pkt, err := refreshMsg.Encode()
+	if err != nil {
+		t.Fatal("BGP route refresh message encode failed with error:", err)
+	}
+
+	expected, _ := hex.DecodeString("ffffffffffffffffffffffffffffffff00170500020101")
+	if !bytes.Equal(pkt, expected) {
+		t.Fatalf("BGP route refresh message encoded to %x, expected %x", pkt, expected)
+	}
+
+	bgpHeader := NewBGPHeader()
+	err = bgpHeader.Decode(pkt[:BGPMsgHeaderLen])
+	if err != nil {
+		t.Fatal("BGP packet header decode failed with error", err)
+	}
+
+	bgpMessage := NewBGPMessage()
+	err = bgpMessage.Decode(bgpHeader, pkt[BGPMsgHeaderLen:], BGPPeerAttrs{ASSize: 4})
+	if err != nil {
+		t.Fatal("BGP route refresh message decode failed with error:", err)
+	}
+
+	refresh, ok := bgpMessage.Body.(*BGPRouteRefresh)
+	if !ok {
+		t.Fatal("BGP route refresh message decoded to the wrong body type", bgpMessage.Body)
+	}
+	if refresh.AFI != AfiIP6 || refresh.SAFI != SafiUnicast || refresh.Subtype != BGPRouteRefreshBoRR {
+		t.Fatalf("BGP route refresh message decoded to %+v", refresh)
+	}
+}
+
+func TestBGPRouteRefreshBadLength(t *testing.T) {
+	hexPkt, _ := hex.DecodeString("0001000100")
+	bgpHeader := &BGPHeader{Length: uint16(BGPMsgHeaderLen + len(hexPkt)), Type: BGPMsgTypeRouteRefresh}
+	bgpMessage := NewBGPMessage()
+	err := bgpMessage.Decode(bgpHeader, hexPkt, BGPPeerAttrs{ASSize: 4})
+	if err == nil {
+		t.Fatal("BGP route refresh message decode called... expected failure, got NO error")
+	}
+
+	msgErr := err.(BGPMessageError)
+	if msgErr.TypeCode != BGPRouteRefreshMsgError || msgErr.SubTypeCode != BGPInvalidRouteRefreshMsgLen {
+		t.Fatal("BGP route refresh message decode failed with unexpected error:", msgErr)
+	}
+	if len(msgErr.Data) != int(bgpHeader.Length) {
+		t.Fatalf("BGP route refresh error data is %d bytes, expected %d", len(msgErr.Data), bgpHeader.Length)
+	}
+}
+
+func TestBGPOpenRouteRefreshCapability(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+	utils.SetLogger(logger)
+
+	afiSafiMap := map[uint32]bool{GetProtocolFamily(AfiIP, SafiUnicast): true}
+	optParams := ConstructOptParams(65001, afiSafiMap, false, 0, nil)
+	openMsg := NewBGPOpenMessage(65001, 180, "10.1.1.1", optParams)
+	pkt, err := openMsg.Encode()
+	if err != nil {
+		t.Fatal("BGP open message encode failed with error:", err)
+	}
+
+	bgpHeader := NewBGPHeader()
+	bgpHeader.Decode(pkt[:BGPMsgHeaderLen])
+	bgpMessage := NewBGPMessage()
+	err = bgpMessage.Decode(bgpHeader, pkt[BGPMsgHeaderLen:], BGPPeerAttrs{ASSize: 2})
+	if err != nil {
+		t.Fatal("BGP open message decode failed with error:", err)
+	}
+
+	body := bgpMessage.Body.(*BGPOpen)
+	if !IsRouteRefreshSupported(body) {
+		t.Fatal("Route refresh capability not found in the open message")
+	}
+	if !IsEnhancedRouteRefreshSupported(body) {
+		t.Fatal("Enhanced route refresh capability not found in the open message")
+	}
+
+	body.OptParams = nil
+	if IsRouteRefreshSupported(body) || IsEnhancedRouteRefreshSupported(body) {
+		t.Fatal("Route refresh reported as supported for an open message without capabilities")
+	}
+}
+
+func TestBGPOpenGracefulRestartCapability(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+	utils.SetLogger(logger)
+
+	afiSafiMap := map[uint32]bool{GetProtocolFamily(AfiIP, SafiUnicast): true}
+	grCap := NewBGPCapGracefulRestart(true, 120)
+	grCap.AddGracefulRestartAFISAFI(NewGracefulRestartAFISAFI(AfiIP, SafiUnicast,
+		BGPCapGracefulRestartFlagForwarding))
+	grCap.AddGracefulRestartAFISAFI(NewGracefulRestartAFISAFI(AfiIP6, SafiUnicast, 0))
+	optParams := ConstructOptParams(65001, afiSafiMap, false, 0, grCap)
+	openMsg := NewBGPOpenMessage(65001, 180, "10.1.1.1", optParams)
+	pkt, err := openMsg.Encode()
+	if err != nil {
+		t.Fatal("BGP open message encode failed with error:", err)
+	}
+
+	expected, _ := hex.DecodeString("400a80780001018000020100")
+	if !bytes.Contains(pkt, expected) {
+		t.Fatalf("BGP open message %x does not contain the graceful restart capability %x", pkt, expected)
+	}
+
+	bgpHeader := NewBGPHeader()
+	bgpHeader.Decode(pkt[:BGPMsgHeaderLen])
+	bgpMessage := NewBGPMessage()
+	err = bgpMessage.Decode(bgpHeader, pkt[BGPMsgHeaderLen:], BGPPeerAttrs{ASSize: 2})
+	if err != nil {
+		t.Fatal("BGP open message decode failed with error:", err)
+	}
+
+	rxCap := GetGracefulRestartCap(bgpMessage.Body.(*BGPOpen))
+	if rxCap == nil {
+		t.Fatal("Graceful restart capability not found in the open message")
+	}
+	if !rxCap.IsRestarting() || rxCap.RestartTime != 120 || len(rxCap.Value) != 2 {
+		t.Fatalf("Graceful restart capability decoded to %+v", rxCap)
+	}
+	if !rxCap.Value[0].IsForwardingPreserved() || rxCap.Value[1].IsForwardingPreserved() {
+		t.Fatalf("Graceful restart capability forwarding state decoded to %+v", rxCap.Value)
+	}
+	if rxCap.Value[1].AFI != AfiIP6 || rxCap.Value[1].SAFI != SafiUnicast {
+		t.Fatalf("Graceful restart capability address family decoded to %+v", rxCap.Value[1])
+	}
+}
+
+func TestBGPEndOfRIB(t *testing.T) {
+	for _, protoFamily := range []uint32{GetProtocolFamily(AfiIP, SafiUnicast),
+		GetProtocolFamily(AfiIP6, SafiUnicast)} {
+		eorMsg := NewBGPEndOfRIBMessage(protoFamily)
+		updateMsgs := ConstructMaxSizedUpdatePackets(eorMsg)
+		if len(updateMsgs) != 1 {
+			t.Fatalf("End-of-RIB for protocol family %d constructed %d update messages", protoFamily,
+				len(updateMsgs))
+		}
+
+		pkt, err := updateMsgs[0].Encode()
+		if err != nil {
+			t.Fatal("BGP End-of-RIB message encode failed with error:", err)
+		}
+
+		bgpHeader := NewBGPHeader()
+		bgpHeader.Decode(pkt[:BGPMsgHeaderLen])
+		bgpMessage := NewBGPMessage()
+		err = bgpMessage.Decode(bgpHeader, pkt[BGPMsgHeaderLen:], BGPPeerAttrs{ASSize: 4})
+		if err != nil {
+			t.Fatal("BGP End-of-RIB message decode failed with error:", err)
+		}
+
+		rxFamily, ok := IsEndOfRIB(bgpMessage.Body.(*BGPUpdate))
+		if !ok || rxFamily != protoFamily {
+			t.Fatalf("End-of-RIB for protocol family %d decoded as %d, End-of-RIB %t", protoFamily, rxFamily, ok)
+		}
+	}
+
+	withdrawMsg := NewBGPUpdateMessage([]NLRI{NewIPPrefix(net.ParseIP("10.1.1.0"), 24)}, nil, nil)
+	if _, ok := IsEndOfRIB(withdrawMsg.Body.(*BGPUpdate)); ok {
+		t.Fatal("UPDATE message with withdrawn routes detected as End-of-RIB")
+	}
+}
+
+func TestGetNeighborAS(t *testing.T) {
+	asSeq := NewBGPAS4PathSegmentSeq()
+	asSeq.AppendAS(65001)
+	asSeq.AppendAS(65002)
+	asPath := NewBGPPathAttrASPath()
+	asPath.ASSize = 4
+	asPath.AppendASPathSegment(asSeq)
+	if neighborAS := GetNeighborAS([]BGPPathAttr{asPath}); neighborAS != 65001 {
+		t.Fatal("GetNeighborAS returned", neighborAS, "expected 65001")
+	}
+
+	asSet := NewBGPAS4PathSegmentSet()
+	asSet.AppendAS(65003)
+	asPath = NewBGPPathAttrASPath()
+	asPath.ASSize = 4
+	asPath.AppendASPathSegment(asSet)
+	if neighborAS := GetNeighborAS([]BGPPathAttr{asPath}); neighborAS != 0 {
+		t.Fatal("GetNeighborAS returned", neighborAS, "for AS path starting with AS_SET, expected 0")
+	}
+
+	if neighborAS := GetNeighborAS([]BGPPathAttr{NewBGPPathAttrASPath()}); neighborAS != 0 {
+		t.Fatal("GetNeighborAS returned", neighborAS, "for empty AS path, expected 0")
+	}
+}
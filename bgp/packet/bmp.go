@@ -0,0 +1,650 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// bmp.go
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// BGP Monitoring Protocol, RFC 7854
+const BMPVersion uint8 = 3
+
+const (
+	BMPMsgHeaderLen     = 6
+	BMPPeerHeaderLen    = 42
+	BMPInfoTLVHeaderLen = 4
+	BMPStatHeaderLen    = 4
+)
+
+const (
+	BMPMsgTypeRouteMonitoring uint8 = iota
+	BMPMsgTypeStatsReport
+	BMPMsgTypePeerDown
+	BMPMsgTypePeerUp
+	BMPMsgTypeInitiation
+	BMPMsgTypeTermination
+)
+
+var BMPMsgTypeToStr = map[uint8]string{
+	BMPMsgTypeRouteMonitoring: "Route Monitoring",
+	BMPMsgTypeStatsReport:     "Statistics Report",
+	BMPMsgTypePeerDown:        "Peer Down",
+	BMPMsgTypePeerUp:          "Peer Up",
+	BMPMsgTypeInitiation:      "Initiation",
+	BMPMsgTypeTermination:     "Termination",
+}
+
+const (
+	BMPPeerTypeGlobal uint8 = iota
+	BMPPeerTypeRD
+	BMPPeerTypeLocal
+)
+
+const (
+	BMPPeerFlagASPath2Byte uint8 = 0x20
+	BMPPeerFlagPostPolicy  uint8 = 0x40
+	BMPPeerFlagIPv6        uint8 = 0x80
+)
+
+const (
+	BMPInfoTypeString uint16 = iota
+	BMPInfoTypeSysDescr
+	BMPInfoTypeSysName
+)
+
+const (
+	BMPTermTypeString uint16 = iota
+	BMPTermTypeReason
+)
+
+const (
+	BMPTermReasonAdminClose uint16 = iota
+	BMPTermReasonUnspecified
+	BMPTermReasonOutOfResources
+	BMPTermReasonRedundantConn
+	BMPTermReasonPermAdminClose
+)
+
+const (
+	_ uint8 = iota
+	BMPPeerDownLocalNotification
+	BMPPeerDownLocalNoNotification
+	BMPPeerDownRemoteNotification
+	BMPPeerDownRemoteNoNotification
+	BMPPeerDownDeconfigured
+)
+
+const (
+	BMPStatRejectedPrefixes uint16 = iota
+	BMPStatDuplicatePrefixes
+	BMPStatDuplicateWithdraws
+	BMPStatClusterListLoop
+	BMPStatASPathLoop
+	BMPStatOriginatorIdLoop
+	BMPStatASConfedLoop
+	BMPStatAdjRIBInRoutes
+	BMPStatLocRIBRoutes
+)
+
+// The message counters of a peer have no standard statistics type, they are reported with types from the
+// experimental range.
+const (
+	BMPStatUpdatesReceived uint16 = 65531 + iota
+	BMPStatUpdatesSent
+	BMPStatNotificationsReceived
+	BMPStatNotificationsSent
+)
+
+type BMPHeader struct {
+	Version uint8
+	Length  uint32
+	Type    uint8
+}
+
+func (header *BMPHeader) Encode() ([]byte, error) {
+	pkt := make([]byte, BMPMsgHeaderLen)
+	pkt[0] = header.Version
+	binary.BigEndian.PutUint32(pkt[1:5], header.Length)
+	pkt[5] = header.Type
+	return pkt, nil
+}
+
+func (header *BMPHeader) Decode(pkt []byte) error {
+	if len(pkt) < BMPMsgHeaderLen {
+		return errors.New(fmt.Sprintf("BMP header needs %d bytes, only %d bytes available", BMPMsgHeaderLen,
+			len(pkt)))
+	}
+
+	header.Version = pkt[0]
+	header.Length = binary.BigEndian.Uint32(pkt[1:5])
+	header.Type = pkt[5]
+	if header.Version != BMPVersion {
+		return errors.New(fmt.Sprintf("BMP version %d is not supported", header.Version))
+	}
+	return nil
+}
+
+func encodeBMPAddress(pkt []byte, ip net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		copy(pkt[12:16], ip4)
+	} else if ip != nil {
+		copy(pkt[:16], ip.To16())
+	}
+}
+
+func decodeBMPAddress(pkt []byte, isIPv6 bool) net.IP {
+	if isIPv6 {
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, pkt[:16])
+		return ip
+	}
+	return net.IPv4(pkt[12], pkt[13], pkt[14], pkt[15]).To4()
+}
+
+type BMPPeerHeader struct {
+	PeerType      uint8
+	Flags         uint8
+	Distinguisher uint64
+	Address       net.IP
+	AS            uint32
+	BGPId         net.IP
+	Timestamp     time.Time
+}
+
+func NewBMPPeerHeader(address net.IP, as uint32, bgpId net.IP, postPolicy bool) *BMPPeerHeader {
+	peerHeader := &BMPPeerHeader{
+		PeerType:  BMPPeerTypeGlobal,
+		Address:   address,
+		AS:        as,
+		BGPId:     bgpId,
+		Timestamp: time.Now(),
+	}
+	if address.To4() == nil {
+		peerHeader.Flags |= BMPPeerFlagIPv6
+	}
+	if postPolicy {
+		peerHeader.Flags |= BMPPeerFlagPostPolicy
+	}
+	return peerHeader
+}
+
+func (p *BMPPeerHeader) IsIPv6() bool {
+	return p.Flags&BMPPeerFlagIPv6 != 0
+}
+
+func (p *BMPPeerHeader) IsPostPolicy() bool {
+	return p.Flags&BMPPeerFlagPostPolicy != 0
+}
+
+func (p *BMPPeerHeader) Encode() ([]byte, error) {
+	pkt := make([]byte, BMPPeerHeaderLen)
+	pkt[0] = p.PeerType
+	pkt[1] = p.Flags
+	binary.BigEndian.PutUint64(pkt[2:10], p.Distinguisher)
+	encodeBMPAddress(pkt[10:26], p.Address)
+	binary.BigEndian.PutUint32(pkt[26:30], p.AS)
+	if bgpId := p.BGPId.To4(); bgpId != nil {
+		copy(pkt[30:34], bgpId)
+	}
+	if !p.Timestamp.IsZero() {
+		binary.BigEndian.PutUint32(pkt[34:38], uint32(p.Timestamp.Unix()))
+		binary.BigEndian.PutUint32(pkt[38:42], uint32(p.Timestamp.Nanosecond()/1000))
+	}
+	return pkt, nil
+}
+
+func (p *BMPPeerHeader) Decode(pkt []byte) error {
+	if len(pkt) < BMPPeerHeaderLen {
+		return errors.New(fmt.Sprintf("BMP per-peer header needs %d bytes, only %d bytes available",
+			BMPPeerHeaderLen, len(pkt)))
+	}
+
+	p.PeerType = pkt[0]
+	p.Flags = pkt[1]
+	p.Distinguisher = binary.BigEndian.Uint64(pkt[2:10])
+	p.Address = decodeBMPAddress(pkt[10:26], p.IsIPv6())
+	p.AS = binary.BigEndian.Uint32(pkt[26:30])
+	p.BGPId = net.IPv4(pkt[30], pkt[31], pkt[32], pkt[33]).To4()
+	p.Timestamp = time.Unix(int64(binary.BigEndian.Uint32(pkt[34:38])),
+		int64(binary.BigEndian.Uint32(pkt[38:42]))*1000)
+	return nil
+}
+
+// encodeBGPPDU encodes a BGP message carried in a BMP message. The length is always computed from the
+// body since the body of a received message may have been modified after it was decoded.
+func encodeBGPPDU(msg *BGPMessage) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("BGP PDU is not set")
+	}
+
+	body, err := msg.Body.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	header := BGPHeader{Length: uint16(BGPMsgHeaderLen + len(body)), Type: msg.Header.Type}
+	pkt, _ := header.Encode()
+	return append(pkt, body...), nil
+}
+
+func decodeBGPPDU(pkt []byte) (*BGPMessage, int, error) {
+	if len(pkt) < BGPMsgHeaderLen {
+		return nil, 0, errors.New(fmt.Sprintf("BGP PDU needs %d bytes, only %d bytes available", BGPMsgHeaderLen,
+			len(pkt)))
+	}
+
+	header := NewBGPHeader()
+	header.Decode(pkt)
+	if int(header.Length) < BGPMsgHeaderLen || int(header.Length) > len(pkt) {
+		return nil, 0, errors.New(fmt.Sprintf("BGP PDU length %d is not valid, %d bytes available",
+			header.Length, len(pkt)))
+	}
+
+	peerAttrs := BGPPeerAttrs{ASSize: 4, AddPathFamily: make(map[AFI]map[SAFI]uint8)}
+	msg := NewBGPMessage()
+	if err := msg.Decode(header, pkt[BGPMsgHeaderLen:header.Length], peerAttrs); err != nil {
+		return nil, 0, err
+	}
+	return msg, int(header.Length), nil
+}
+
+type BMPInfoTLV struct {
+	Type  uint16
+	Value []byte
+}
+
+func encodeBMPInfoTLVs(tlvs []BMPInfoTLV) []byte {
+	pkt := make([]byte, 0)
+	for _, tlv := range tlvs {
+		tlvHeader := make([]byte, BMPInfoTLVHeaderLen)
+		binary.BigEndian.PutUint16(tlvHeader[0:2], tlv.Type)
+		binary.BigEndian.PutUint16(tlvHeader[2:4], uint16(len(tlv.Value)))
+		pkt = append(pkt, tlvHeader...)
+		pkt = append(pkt, tlv.Value...)
+	}
+	return pkt
+}
+
+func decodeBMPInfoTLVs(pkt []byte) ([]BMPInfoTLV, error) {
+	tlvs := make([]BMPInfoTLV, 0)
+	for len(pkt) > 0 {
+		if len(pkt) < BMPInfoTLVHeaderLen {
+			return tlvs, errors.New(fmt.Sprintf("BMP information TLV needs %d bytes, only %d bytes available",
+				BMPInfoTLVHeaderLen, len(pkt)))
+		}
+
+		length := int(binary.BigEndian.Uint16(pkt[2:4]))
+		if len(pkt) < BMPInfoTLVHeaderLen+length {
+			return tlvs, errors.New(fmt.Sprintf("BMP information TLV length %d is more than %d bytes available",
+				length, len(pkt)-BMPInfoTLVHeaderLen))
+		}
+
+		value := make([]byte, length)
+		copy(value, pkt[BMPInfoTLVHeaderLen:BMPInfoTLVHeaderLen+length])
+		tlvs = append(tlvs, BMPInfoTLV{binary.BigEndian.Uint16(pkt[0:2]), value})
+		pkt = pkt[BMPInfoTLVHeaderLen+length:]
+	}
+	return tlvs, nil
+}
+
+type BMPBody interface {
+	Encode() ([]byte, error)
+	Decode(pkt []byte, peerHeader *BMPPeerHeader) error
+}
+
+type BMPInitiation struct {
+	TLVs []BMPInfoTLV
+}
+
+func (msg *BMPInitiation) Encode() ([]byte, error) {
+	return encodeBMPInfoTLVs(msg.TLVs), nil
+}
+
+func (msg *BMPInitiation) Decode(pkt []byte, peerHeader *BMPPeerHeader) (err error) {
+	msg.TLVs, err = decodeBMPInfoTLVs(pkt)
+	return err
+}
+
+type BMPTermination struct {
+	TLVs []BMPInfoTLV
+}
+
+func (msg *BMPTermination) Encode() ([]byte, error) {
+	return encodeBMPInfoTLVs(msg.TLVs), nil
+}
+
+func (msg *BMPTermination) Decode(pkt []byte, peerHeader *BMPPeerHeader) (err error) {
+	msg.TLVs, err = decodeBMPInfoTLVs(pkt)
+	return err
+}
+
+type BMPPeerUp struct {
+	LocalAddress net.IP
+	LocalPort    uint16
+	RemotePort   uint16
+	SentOpen     *BGPMessage
+	ReceivedOpen *BGPMessage
+}
+
+func (msg *BMPPeerUp) Encode() ([]byte, error) {
+	pkt := make([]byte, 20)
+	encodeBMPAddress(pkt[0:16], msg.LocalAddress)
+	binary.BigEndian.PutUint16(pkt[16:18], msg.LocalPort)
+	binary.BigEndian.PutUint16(pkt[18:20], msg.RemotePort)
+
+	sentOpen, err := encodeBGPPDU(msg.SentOpen)
+	if err != nil {
+		return nil, err
+	}
+	receivedOpen, err := encodeBGPPDU(msg.ReceivedOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	pkt = append(pkt, sentOpen...)
+	return append(pkt, receivedOpen...), nil
+}
+
+func (msg *BMPPeerUp) Decode(pkt []byte, peerHeader *BMPPeerHeader) error {
+	if len(pkt) < 20 {
+		return errors.New(fmt.Sprintf("BMP peer up message needs at least 20 bytes, only %d bytes available",
+			len(pkt)))
+	}
+
+	msg.LocalAddress = decodeBMPAddress(pkt[0:16], peerHeader.IsIPv6())
+	msg.LocalPort = binary.BigEndian.Uint16(pkt[16:18])
+	msg.RemotePort = binary.BigEndian.Uint16(pkt[18:20])
+
+	var err error
+	var length int
+	pkt = pkt[20:]
+	if msg.SentOpen, length, err = decodeBGPPDU(pkt); err != nil {
+		return err
+	}
+	pkt = pkt[length:]
+	msg.ReceivedOpen, _, err = decodeBGPPDU(pkt)
+	return err
+}
+
+type BMPPeerDown struct {
+	Reason       uint8
+	Notification *BGPMessage
+	FSMEvent     uint16
+}
+
+func (msg *BMPPeerDown) Encode() ([]byte, error) {
+	pkt := make([]byte, 1)
+	pkt[0] = msg.Reason
+	switch msg.Reason {
+	case BMPPeerDownLocalNotification, BMPPeerDownRemoteNotification:
+		notification, err := encodeBGPPDU(msg.Notification)
+		if err != nil {
+			return nil, err
+		}
+		pkt = append(pkt, notification...)
+
+	case BMPPeerDownLocalNoNotification:
+		event := make([]byte, 2)
+		binary.BigEndian.PutUint16(event, msg.FSMEvent)
+		pkt = append(pkt, event...)
+	}
+	return pkt, nil
+}
+
+func (msg *BMPPeerDown) Decode(pkt []byte, peerHeader *BMPPeerHeader) (err error) {
+	if len(pkt) < 1 {
+		return errors.New("BMP peer down message does not have the reason")
+	}
+
+	msg.Reason = pkt[0]
+	switch msg.Reason {
+	case BMPPeerDownLocalNotification, BMPPeerDownRemoteNotification:
+		msg.Notification, _, err = decodeBGPPDU(pkt[1:])
+
+	case BMPPeerDownLocalNoNotification:
+		if len(pkt) < 3 {
+			return errors.New("BMP peer down message does not have the FSM event")
+		}
+		msg.FSMEvent = binary.BigEndian.Uint16(pkt[1:3])
+	}
+	return err
+}
+
+type BMPRouteMonitoring struct {
+	Update *BGPMessage
+}
+
+func (msg *BMPRouteMonitoring) Encode() ([]byte, error) {
+	return encodeBGPPDU(msg.Update)
+}
+
+func (msg *BMPRouteMonitoring) Decode(pkt []byte, peerHeader *BMPPeerHeader) (err error) {
+	msg.Update, _, err = decodeBGPPDU(pkt)
+	return err
+}
+
+type BMPStat struct {
+	Type  uint16
+	Value uint64
+}
+
+// Len returns the length of the value of the statistics. Counters are 32 bits long and gauges are 64 bits long.
+func (s BMPStat) Len() int {
+	if s.Type == BMPStatAdjRIBInRoutes || s.Type == BMPStatLocRIBRoutes || s.Type >= BMPStatUpdatesReceived {
+		return 8
+	}
+	return 4
+}
+
+type BMPStatsReport struct {
+	Stats []BMPStat
+}
+
+func (msg *BMPStatsReport) Encode() ([]byte, error) {
+	pkt := make([]byte, 4)
+	binary.BigEndian.PutUint32(pkt, uint32(len(msg.Stats)))
+	for _, stat := range msg.Stats {
+		statPkt := make([]byte, BMPStatHeaderLen+stat.Len())
+		binary.BigEndian.PutUint16(statPkt[0:2], stat.Type)
+		binary.BigEndian.PutUint16(statPkt[2:4], uint16(stat.Len()))
+		if stat.Len() == 8 {
+			binary.BigEndian.PutUint64(statPkt[4:], stat.Value)
+		} else {
+			binary.BigEndian.PutUint32(statPkt[4:], uint32(stat.Value))
+		}
+		pkt = append(pkt, statPkt...)
+	}
+	return pkt, nil
+}
+
+func (msg *BMPStatsReport) Decode(pkt []byte, peerHeader *BMPPeerHeader) error {
+	if len(pkt) < 4 {
+		return errors.New("BMP statistics report does not have the statistics count")
+	}
+
+	count := binary.BigEndian.Uint32(pkt[0:4])
+	pkt = pkt[4:]
+	msg.Stats = make([]BMPStat, 0, count)
+	for i := uint32(0); i < count; i++ {
+		if len(pkt) < BMPStatHeaderLen {
+			return errors.New(fmt.Sprintf("BMP statistics %d needs %d bytes, only %d bytes available", i,
+				BMPStatHeaderLen, len(pkt)))
+		}
+
+		stat := BMPStat{Type: binary.BigEndian.Uint16(pkt[0:2])}
+		length := int(binary.BigEndian.Uint16(pkt[2:4]))
+		if len(pkt) < BMPStatHeaderLen+length {
+			return errors.New(fmt.Sprintf("BMP statistics %d length %d is more than %d bytes available", i,
+				length, len(pkt)-BMPStatHeaderLen))
+		}
+
+		switch length {
+		case 4:
+			stat.Value = uint64(binary.BigEndian.Uint32(pkt[4:8]))
+		case 8:
+			stat.Value = binary.BigEndian.Uint64(pkt[4:12])
+		}
+		msg.Stats = append(msg.Stats, stat)
+		pkt = pkt[BMPStatHeaderLen+length:]
+	}
+	return nil
+}
+
+type BMPMessage struct {
+	Header     BMPHeader
+	PeerHeader *BMPPeerHeader
+	Body       BMPBody
+}
+
+func NewBMPMessage() *BMPMessage {
+	return &BMPMessage{}
+}
+
+func (msg *BMPMessage) Encode() ([]byte, error) {
+	pkt := make([]byte, 0)
+	if msg.PeerHeader != nil {
+		peerHeader, err := msg.PeerHeader.Encode()
+		if err != nil {
+			return nil, err
+		}
+		pkt = append(pkt, peerHeader...)
+	}
+
+	body, err := msg.Body.Encode()
+	if err != nil {
+		return nil, err
+	}
+	pkt = append(pkt, body...)
+
+	msg.Header.Version = BMPVersion
+	msg.Header.Length = uint32(BMPMsgHeaderLen + len(pkt))
+	header, err := msg.Header.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, pkt...), nil
+}
+
+// Decode decodes a complete BMP message. The header is decoded again from pkt.
+func (msg *BMPMessage) Decode(pkt []byte) error {
+	if err := msg.Header.Decode(pkt); err != nil {
+		return err
+	}
+
+	if int(msg.Header.Length) < BMPMsgHeaderLen || int(msg.Header.Length) > len(pkt) {
+		return errors.New(fmt.Sprintf("BMP message length %d is not valid, %d bytes available", msg.Header.Length,
+			len(pkt)))
+	}
+	pkt = pkt[BMPMsgHeaderLen:msg.Header.Length]
+
+	switch msg.Header.Type {
+	case BMPMsgTypeRouteMonitoring:
+		msg.Body = &BMPRouteMonitoring{}
+
+	case BMPMsgTypeStatsReport:
+		msg.Body = &BMPStatsReport{}
+
+	case BMPMsgTypePeerDown:
+		msg.Body = &BMPPeerDown{}
+
+	case BMPMsgTypePeerUp:
+		msg.Body = &BMPPeerUp{}
+
+	case BMPMsgTypeInitiation:
+		msg.Body = &BMPInitiation{}
+
+	case BMPMsgTypeTermination:
+		msg.Body = &BMPTermination{}
+
+	default:
+		return errors.New(fmt.Sprintf("BMP message type %d is not supported", msg.Header.Type))
+	}
+
+	if msg.Header.Type != BMPMsgTypeInitiation && msg.Header.Type != BMPMsgTypeTermination {
+		msg.PeerHeader = &BMPPeerHeader{}
+		if err := msg.PeerHeader.Decode(pkt); err != nil {
+			return err
+		}
+		pkt = pkt[BMPPeerHeaderLen:]
+	}
+	return msg.Body.Decode(pkt, msg.PeerHeader)
+}
+
+func NewBMPInitiationMessage(sysName, sysDescr string) *BMPMessage {
+	return &BMPMessage{
+		Header: BMPHeader{Version: BMPVersion, Type: BMPMsgTypeInitiation},
+		Body: &BMPInitiation{[]BMPInfoTLV{
+			BMPInfoTLV{BMPInfoTypeSysDescr, []byte(sysDescr)},
+			BMPInfoTLV{BMPInfoTypeSysName, []byte(sysName)},
+		}},
+	}
+}
+
+func NewBMPTerminationMessage(reason uint16) *BMPMessage {
+	value := make([]byte, 2)
+	binary.BigEndian.PutUint16(value, reason)
+	return &BMPMessage{
+		Header: BMPHeader{Version: BMPVersion, Type: BMPMsgTypeTermination},
+		Body:   &BMPTermination{[]BMPInfoTLV{BMPInfoTLV{BMPTermTypeReason, value}}},
+	}
+}
+
+func NewBMPPeerUpMessage(peerHeader *BMPPeerHeader, localAddress net.IP, localPort, remotePort uint16,
+	sentOpen, receivedOpen *BGPMessage) *BMPMessage {
+	return &BMPMessage{
+		Header:     BMPHeader{Version: BMPVersion, Type: BMPMsgTypePeerUp},
+		PeerHeader: peerHeader,
+		Body:       &BMPPeerUp{localAddress, localPort, remotePort, sentOpen, receivedOpen},
+	}
+}
+
+func NewBMPPeerDownMessage(peerHeader *BMPPeerHeader, reason uint8, notification *BGPMessage,
+	fsmEvent uint16) *BMPMessage {
+	return &BMPMessage{
+		Header:     BMPHeader{Version: BMPVersion, Type: BMPMsgTypePeerDown},
+		PeerHeader: peerHeader,
+		Body:       &BMPPeerDown{reason, notification, fsmEvent},
+	}
+}
+
+func NewBMPRouteMonitoringMessage(peerHeader *BMPPeerHeader, update *BGPMessage) *BMPMessage {
+	return &BMPMessage{
+		Header:     BMPHeader{Version: BMPVersion, Type: BMPMsgTypeRouteMonitoring},
+		PeerHeader: peerHeader,
+		Body:       &BMPRouteMonitoring{update},
+	}
+}
+
+func NewBMPStatsReportMessage(peerHeader *BMPPeerHeader, stats []BMPStat) *BMPMessage {
+	return &BMPMessage{
+		Header:     BMPHeader{Version: BMPVersion, Type: BMPMsgTypeStatsReport},
+		PeerHeader: peerHeader,
+		Body:       &BMPStatsReport{stats},
+	}
+}
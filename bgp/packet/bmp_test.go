@@ -0,0 +1,195 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// bmp_test.go
+package packet
+
+import (
+	"l3/bgp/utils"
+	"net"
+	"testing"
+	"utils/logging"
+)
+
+func encodeDecodeBMPMessage(t *testing.T, msg *BMPMessage) *BMPMessage {
+	pkt, err := msg.Encode()
+	if err != nil {
+		t.Fatal("BMP", BMPMsgTypeToStr[msg.Header.Type], "message encode failed with error:", err)
+	}
+
+	if int(msg.Header.Length) != len(pkt) {
+		t.Fatalf("BMP %s message length %d, encoded %d bytes", BMPMsgTypeToStr[msg.Header.Type],
+			msg.Header.Length, len(pkt))
+	}
+
+	rxMsg := NewBMPMessage()
+	if err = rxMsg.Decode(pkt); err != nil {
+		t.Fatal("BMP", BMPMsgTypeToStr[msg.Header.Type], "message decode failed with error:", err)
+	}
+
+	if rxMsg.Header.Type != msg.Header.Type {
+		t.Fatalf("BMP message type %d decoded as %d", msg.Header.Type, rxMsg.Header.Type)
+	}
+	return rxMsg
+}
+
+func TestBMPInitiationEncodeDecode(t *testing.T) {
+	msg := NewBMPInitiationMessage("router1", "FlexSwitch bgpd")
+	rxMsg := encodeDecodeBMPMessage(t, msg)
+	if rxMsg.PeerHeader != nil {
+		t.Fatal("BMP initiation message decoded with a per-peer header")
+	}
+
+	tlvs := rxMsg.Body.(*BMPInitiation).TLVs
+	if len(tlvs) != 2 || tlvs[0].Type != BMPInfoTypeSysDescr || string(tlvs[0].Value) != "FlexSwitch bgpd" ||
+		tlvs[1].Type != BMPInfoTypeSysName || string(tlvs[1].Value) != "router1" {
+		t.Fatalf("BMP initiation message TLVs decoded to %+v", tlvs)
+	}
+}
+
+func TestBMPPeerUpEncodeDecode(t *testing.T) {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+	}
+	utils.SetLogger(logger)
+
+	afiSafiMap := map[uint32]bool{GetProtocolFamily(AfiIP, SafiUnicast): true}
+	sentOpen := NewBGPOpenMessage(65001, 180, "10.1.1.1", ConstructOptParams(65001, afiSafiMap, false, 0, nil))
+	rcvdOpen := NewBGPOpenMessage(65002, 90, "10.1.1.2", ConstructOptParams(65002, afiSafiMap, false, 0, nil))
+	peerHeader := NewBMPPeerHeader(net.ParseIP("2001::2"), 65002, net.ParseIP("10.1.1.2"), false)
+	msg := NewBMPPeerUpMessage(peerHeader, net.ParseIP("2001::1"), 179, 50000, sentOpen, rcvdOpen)
+	rxMsg := encodeDecodeBMPMessage(t, msg)
+
+	rxPeerHeader := rxMsg.PeerHeader
+	if !rxPeerHeader.IsIPv6() || rxPeerHeader.IsPostPolicy() || !rxPeerHeader.Address.Equal(peerHeader.Address) ||
+		rxPeerHeader.AS != 65002 || !rxPeerHeader.BGPId.Equal(peerHeader.BGPId) ||
+		rxPeerHeader.Timestamp.Unix() != peerHeader.Timestamp.Unix() {
+		t.Fatalf("BMP per-peer header %+v decoded to %+v", peerHeader, rxPeerHeader)
+	}
+
+	peerUp := rxMsg.Body.(*BMPPeerUp)
+	if !peerUp.LocalAddress.Equal(net.ParseIP("2001::1")) || peerUp.LocalPort != 179 || peerUp.RemotePort != 50000 {
+		t.Fatalf("BMP peer up message decoded to %+v", peerUp)
+	}
+
+	if peerUp.SentOpen.Body.(*BGPOpen).HoldTime != 180 || peerUp.ReceivedOpen.Body.(*BGPOpen).HoldTime != 90 {
+		t.Fatal("BMP peer up message OPEN messages decoded to", peerUp.SentOpen.Body, peerUp.ReceivedOpen.Body)
+	}
+}
+
+func TestBMPRouteMonitoringEncodeDecode(t *testing.T) {
+	pa := make([]BGPPathAttr, 0)
+	pa = append(pa, NewBGPPathAttrOrigin(BGPPathAttrOriginIncomplete))
+	asPathSeq := NewBGPAS4PathSegmentSeq()
+	asPathSeq.AppendAS(65002)
+	asPath := NewBGPPathAttrASPath()
+	asPath.AppendASPathSegment(asPathSeq)
+	pa = append(pa, asPath)
+	nextHop := NewBGPPathAttrNextHop()
+	nextHop.Value = net.ParseIP("10.1.1.2")
+	pa = append(pa, nextHop)
+	nlri := []NLRI{NewIPPrefix(net.ParseIP("20.1.0.0"), 16)}
+	updateMsg := NewBGPUpdateMessage(make([]NLRI, 0), pa, nlri)
+
+	// Length of a received message must not be used for the BGP PDU
+	updateMsg.Header.Length = 1000
+	peerHeader := NewBMPPeerHeader(net.ParseIP("10.1.1.2"), 65002, net.ParseIP("10.1.1.2"), true)
+	msg := NewBMPRouteMonitoringMessage(peerHeader, updateMsg)
+	rxMsg := encodeDecodeBMPMessage(t, msg)
+
+	if rxMsg.PeerHeader.IsIPv6() || !rxMsg.PeerHeader.IsPostPolicy() ||
+		!rxMsg.PeerHeader.Address.Equal(peerHeader.Address) {
+		t.Fatalf("BMP per-peer header %+v decoded to %+v", peerHeader, rxMsg.PeerHeader)
+	}
+
+	rxUpdate := rxMsg.Body.(*BMPRouteMonitoring).Update.Body.(*BGPUpdate)
+	if len(rxUpdate.NLRI) != 1 || rxUpdate.NLRI[0].GetPrefix().String() != "20.1.0.0" || GetNumASes(
+		rxUpdate.PathAttributes) != 1 {
+		t.Fatalf("BMP route monitoring message UPDATE decoded to %+v", rxUpdate)
+	}
+}
+
+func TestBMPPeerDownEncodeDecode(t *testing.T) {
+	peerHeader := NewBMPPeerHeader(net.ParseIP("10.1.1.2"), 65002, net.ParseIP("10.1.1.2"), false)
+	notification := NewBGPNotificationMessage(BGPCease, 2, nil)
+	msg := NewBMPPeerDownMessage(peerHeader, BMPPeerDownRemoteNotification, notification, 0)
+	peerDown := encodeDecodeBMPMessage(t, msg).Body.(*BMPPeerDown)
+	if peerDown.Reason != BMPPeerDownRemoteNotification || peerDown.Notification == nil {
+		t.Fatalf("BMP peer down message decoded to %+v", peerDown)
+	}
+
+	rxNotification := peerDown.Notification.Body.(*BGPNotification)
+	if rxNotification.ErrorCode != BGPCease || rxNotification.ErrorSubcode != 2 {
+		t.Fatalf("BMP peer down message NOTIFICATION decoded to %+v", rxNotification)
+	}
+
+	msg = NewBMPPeerDownMessage(peerHeader, BMPPeerDownLocalNoNotification, nil, 18)
+	peerDown = encodeDecodeBMPMessage(t, msg).Body.(*BMPPeerDown)
+	if peerDown.Reason != BMPPeerDownLocalNoNotification || peerDown.FSMEvent != 18 {
+		t.Fatalf("BMP peer down message decoded to %+v", peerDown)
+	}
+}
+
+func TestBMPStatsReportEncodeDecode(t *testing.T) {
+	stats := []BMPStat{
+		BMPStat{BMPStatRejectedPrefixes, 10},
+		BMPStat{BMPStatAdjRIBInRoutes, 1 << 40},
+		BMPStat{BMPStatUpdatesReceived, 1234},
+	}
+	peerHeader := NewBMPPeerHeader(net.ParseIP("10.1.1.2"), 65002, net.ParseIP("10.1.1.2"), false)
+	msg := NewBMPStatsReportMessage(peerHeader, stats)
+	pkt, _ := msg.Encode()
+	if len(pkt) != BMPMsgHeaderLen+BMPPeerHeaderLen+4+3*BMPStatHeaderLen+4+8+8 {
+		t.Fatal("BMP statistics report encoded to", len(pkt), "bytes")
+	}
+
+	rxStats := encodeDecodeBMPMessage(t, msg).Body.(*BMPStatsReport).Stats
+	if len(rxStats) != len(stats) {
+		t.Fatalf("BMP statistics report %v decoded to %v", stats, rxStats)
+	}
+	for idx, stat := range stats {
+		if rxStats[idx] != stat {
+			t.Fatalf("BMP statistics %+v decoded to %+v", stat, rxStats[idx])
+		}
+	}
+}
+
+func TestBMPTerminationAndBadMessage(t *testing.T) {
+	msg := NewBMPTerminationMessage(BMPTermReasonAdminClose)
+	tlvs := encodeDecodeBMPMessage(t, msg).Body.(*BMPTermination).TLVs
+	if len(tlvs) != 1 || tlvs[0].Type != BMPTermTypeReason || len(tlvs[0].Value) != 2 {
+		t.Fatalf("BMP termination message TLVs decoded to %+v", tlvs)
+	}
+
+	pkt, _ := msg.Encode()
+	pkt[0] = 1
+	if err := NewBMPMessage().Decode(pkt); err == nil {
+		t.Fatal("BMP message with version 1 decoded without error")
+	}
+
+	pkt[0] = BMPVersion
+	if err := NewBMPMessage().Decode(pkt[:len(pkt)-1]); err == nil {
+		t.Fatal("Truncated BMP message decoded without error")
+	}
+}
@@ -0,0 +1,522 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// community.go
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	BGPCommunityNoExport          uint32 = 0xFFFFFF01
+	BGPCommunityNoAdvertise       uint32 = 0xFFFFFF02
+	BGPCommunityNoExportSubconfed uint32 = 0xFFFFFF03
+)
+
+var BGPWellKnownCommunityToStrMap = map[uint32]string{
+	BGPCommunityNoExport:          "no-export",
+	BGPCommunityNoAdvertise:       "no-advertise",
+	BGPCommunityNoExportSubconfed: "no-export-subconfed",
+}
+
+// Extended community type high octets, RFC 4360 and RFC 5668
+const (
+	BGPExtCommunityTypeTwoOctetAS  uint8 = 0x00
+	BGPExtCommunityTypeIPv4Addr    uint8 = 0x01
+	BGPExtCommunityTypeFourOctetAS uint8 = 0x02
+	BGPExtCommunityTypeOpaque      uint8 = 0x03
+)
+
+const (
+	BGPExtCommunitySubTypeRouteTarget uint8 = 0x02
+	BGPExtCommunitySubTypeRouteOrigin uint8 = 0x03
+)
+
+var BGPExtCommunitySubTypeToStrMap = map[uint8]string{
+	BGPExtCommunitySubTypeRouteTarget: "rt",
+	BGPExtCommunitySubTypeRouteOrigin: "soo",
+}
+
+var BGPExtCommunityStrToSubTypeMap = map[string]uint8{
+	"rt":  BGPExtCommunitySubTypeRouteTarget,
+	"soo": BGPExtCommunitySubTypeRouteOrigin,
+}
+
+func (pa *BGPPathAttrBase) setValueLen(length int) {
+	if length > 255 {
+		pa.Flags |= BGPPathAttrFlagExtendedLen
+		pa.BGPPathAttrLen = 4
+	} else {
+		pa.Flags &^= BGPPathAttrFlagExtendedLen
+		pa.BGPPathAttrLen = 3
+	}
+	pa.Length = uint16(length)
+}
+
+func CommunityToStr(comm uint32) string {
+	if str, ok := BGPWellKnownCommunityToStrMap[comm]; ok {
+		return str
+	}
+	return fmt.Sprintf("%d:%d", comm>>16, comm&0xFFFF)
+}
+
+// ParseCommunity accepts a well known community name, AS:value or a plain 32 bit number
+func ParseCommunity(str string) (uint32, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	for comm, name := range BGPWellKnownCommunityToStrMap {
+		if name == str {
+			return comm, nil
+		}
+	}
+
+	parts := strings.Split(str, ":")
+	if len(parts) == 1 {
+		val, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return 0, errors.New(fmt.Sprintf("Invalid community %s", str))
+		}
+		return uint32(val), nil
+	} else if len(parts) != 2 {
+		return 0, errors.New(fmt.Sprintf("Invalid community %s", str))
+	}
+
+	as, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid AS %s in community %s", parts[0], str))
+	}
+	val, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid value %s in community %s", parts[1], str))
+	}
+	return uint32(as<<16 | val), nil
+}
+
+type BGPExtCommunity uint64
+
+func (e BGPExtCommunity) Type() uint8 {
+	return uint8(e >> 56)
+}
+
+func (e BGPExtCommunity) SubType() uint8 {
+	return uint8(e >> 48)
+}
+
+func (e BGPExtCommunity) IsTransitive() bool {
+	return e.Type()&0x40 == 0
+}
+
+func (e BGPExtCommunity) String() string {
+	subType, ok := BGPExtCommunitySubTypeToStrMap[e.SubType()]
+	if !ok {
+		return fmt.Sprintf("0x%016x", uint64(e))
+	}
+
+	switch e.Type() &^ 0x40 {
+	case BGPExtCommunityTypeTwoOctetAS:
+		return fmt.Sprintf("%s:%d:%d", subType, uint16(e>>32), uint32(e))
+	case BGPExtCommunityTypeIPv4Addr:
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, uint32(e>>16))
+		return fmt.Sprintf("%s:%s:%d", subType, ip.String(), uint16(e))
+	case BGPExtCommunityTypeFourOctetAS:
+		return fmt.Sprintf("%s:%d:%d", subType, uint32(e>>16), uint16(e))
+	}
+	return fmt.Sprintf("0x%016x", uint64(e))
+}
+
+func NewBGPExtCommunity(extType uint8, subType uint8, admin uint32, local uint32) BGPExtCommunity {
+	val := uint64(extType)<<56 | uint64(subType)<<48
+	switch extType &^ 0x40 {
+	case BGPExtCommunityTypeTwoOctetAS:
+		val |= uint64(uint16(admin))<<32 | uint64(local)
+	default:
+		val |= uint64(admin)<<16 | uint64(uint16(local))
+	}
+	return BGPExtCommunity(val)
+}
+
+// ParseExtCommunity accepts rt:<admin>:<value> or soo:<admin>:<value> where admin is
+// a 2 byte AS, a 4 byte AS or an IPv4 address
+func ParseExtCommunity(str string) (BGPExtCommunity, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return 0, errors.New(fmt.Sprintf("Invalid extended community %s", str))
+	}
+
+	subType, ok := BGPExtCommunityStrToSubTypeMap[parts[0]]
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("Unknown extended community type %s in %s", parts[0], str))
+	}
+
+	local, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid value %s in extended community %s", parts[2], str))
+	}
+
+	if ip := net.ParseIP(parts[1]); ip != nil {
+		if ip = ip.To4(); ip == nil || local > 0xFFFF {
+			return 0, errors.New(fmt.Sprintf("Invalid IPv4 extended community %s", str))
+		}
+		return NewBGPExtCommunity(BGPExtCommunityTypeIPv4Addr, subType, binary.BigEndian.Uint32(ip), uint32(local)), nil
+	}
+
+	admin, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid admin %s in extended community %s", parts[1], str))
+	}
+
+	if admin > 0xFFFF {
+		if local > 0xFFFF {
+			return 0, errors.New(fmt.Sprintf("Invalid value %s in extended community %s", parts[2], str))
+		}
+		return NewBGPExtCommunity(BGPExtCommunityTypeFourOctetAS, subType, uint32(admin), uint32(local)), nil
+	}
+	return NewBGPExtCommunity(BGPExtCommunityTypeTwoOctetAS, subType, uint32(admin), uint32(local)), nil
+}
+
+type BGPLargeCommunity struct {
+	GlobalAdmin uint32
+	LocalData1  uint32
+	LocalData2  uint32
+}
+
+func (l BGPLargeCommunity) String() string {
+	return fmt.Sprintf("%d:%d:%d", l.GlobalAdmin, l.LocalData1, l.LocalData2)
+}
+
+func ParseLargeCommunity(str string) (BGPLargeCommunity, error) {
+	var comm BGPLargeCommunity
+	parts := strings.Split(strings.TrimSpace(str), ":")
+	if len(parts) != 3 {
+		return comm, errors.New(fmt.Sprintf("Invalid large community %s", str))
+	}
+
+	vals := make([]uint32, 3)
+	for idx, part := range parts {
+		val, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return comm, errors.New(fmt.Sprintf("Invalid field %s in large community %s", part, str))
+		}
+		vals[idx] = uint32(val)
+	}
+	comm.GlobalAdmin, comm.LocalData1, comm.LocalData2 = vals[0], vals[1], vals[2]
+	return comm, nil
+}
+
+type BGPPathAttrCommunities struct {
+	BGPPathAttrBase
+	Value []uint32
+}
+
+func (c *BGPPathAttrCommunities) Clone() BGPPathAttr {
+	x := *c
+	x.BGPPathAttrBase = c.BGPPathAttrBase.Clone()
+	x.Value = make([]uint32, len(c.Value))
+	copy(x.Value, c.Value)
+	return &x
+}
+
+func (c *BGPPathAttrCommunities) Encode() ([]byte, error) {
+	pkt, err := c.BGPPathAttrBase.Encode()
+	if err != nil {
+		return pkt, err
+	}
+
+	for i, comm := range c.Value {
+		binary.BigEndian.PutUint32(pkt[int(c.BGPPathAttrLen)+(4*i):], comm)
+	}
+	return pkt, nil
+}
+
+func (c *BGPPathAttrCommunities) Decode(pkt []byte, data interface{}) error {
+	err := c.BGPPathAttrBase.Decode(pkt, data)
+	if err != nil {
+		return err
+	}
+
+	if c.Length%4 != 0 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:c.TotalLen()],
+			fmt.Sprintf("Communities attribute length %d is not a multiple of 4", c.Length)}
+	}
+
+	c.Value = make([]uint32, c.Length/4)
+	for i := 0; i < len(c.Value); i++ {
+		start := int(c.BGPPathAttrLen) + (4 * i)
+		c.Value[i] = binary.BigEndian.Uint32(pkt[start : start+4])
+	}
+	return nil
+}
+
+func (c *BGPPathAttrCommunities) New() BGPPathAttr {
+	return &BGPPathAttrCommunities{}
+}
+
+func (c *BGPPathAttrCommunities) String() string {
+	strs := make([]string, 0, len(c.Value))
+	for _, comm := range c.Value {
+		strs = append(strs, CommunityToStr(comm))
+	}
+	return fmt.Sprintf("{COMMUNITIES %s}", strings.Join(strs, " "))
+}
+
+func (c *BGPPathAttrCommunities) SetValue(comms []uint32) {
+	c.Value = make([]uint32, len(comms))
+	copy(c.Value, comms)
+	c.setValueLen(4 * len(c.Value))
+}
+
+func NewBGPPathAttrCommunities(comms []uint32) *BGPPathAttrCommunities {
+	c := &BGPPathAttrCommunities{
+		BGPPathAttrBase: BGPPathAttrBase{
+			Flags:          BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive,
+			Code:           BGPPathAttrTypeCommunities,
+			Length:         0,
+			BGPPathAttrLen: 3,
+		},
+	}
+	c.SetValue(comms)
+	return c
+}
+
+type BGPPathAttrExtCommunities struct {
+	BGPPathAttrBase
+	Value []BGPExtCommunity
+}
+
+func (e *BGPPathAttrExtCommunities) Clone() BGPPathAttr {
+	x := *e
+	x.BGPPathAttrBase = e.BGPPathAttrBase.Clone()
+	x.Value = make([]BGPExtCommunity, len(e.Value))
+	copy(x.Value, e.Value)
+	return &x
+}
+
+func (e *BGPPathAttrExtCommunities) Encode() ([]byte, error) {
+	pkt, err := e.BGPPathAttrBase.Encode()
+	if err != nil {
+		return pkt, err
+	}
+
+	for i, comm := range e.Value {
+		binary.BigEndian.PutUint64(pkt[int(e.BGPPathAttrLen)+(8*i):], uint64(comm))
+	}
+	return pkt, nil
+}
+
+func (e *BGPPathAttrExtCommunities) Decode(pkt []byte, data interface{}) error {
+	err := e.BGPPathAttrBase.Decode(pkt, data)
+	if err != nil {
+		return err
+	}
+
+	if e.Length%8 != 0 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:e.TotalLen()],
+			fmt.Sprintf("Extended communities attribute length %d is not a multiple of 8", e.Length)}
+	}
+
+	e.Value = make([]BGPExtCommunity, e.Length/8)
+	for i := 0; i < len(e.Value); i++ {
+		start := int(e.BGPPathAttrLen) + (8 * i)
+		e.Value[i] = BGPExtCommunity(binary.BigEndian.Uint64(pkt[start : start+8]))
+	}
+	return nil
+}
+
+func (e *BGPPathAttrExtCommunities) New() BGPPathAttr {
+	return &BGPPathAttrExtCommunities{}
+}
+
+func (e *BGPPathAttrExtCommunities) String() string {
+	strs := make([]string, 0, len(e.Value))
+	for _, comm := range e.Value {
+		strs = append(strs, comm.String())
+	}
+	return fmt.Sprintf("{EXT_COMMUNITIES %s}", strings.Join(strs, " "))
+}
+
+func (e *BGPPathAttrExtCommunities) SetValue(comms []BGPExtCommunity) {
+	e.Value = make([]BGPExtCommunity, len(comms))
+	copy(e.Value, comms)
+	e.setValueLen(8 * len(e.Value))
+}
+
+func NewBGPPathAttrExtCommunities(comms []BGPExtCommunity) *BGPPathAttrExtCommunities {
+	e := &BGPPathAttrExtCommunities{
+		BGPPathAttrBase: BGPPathAttrBase{
+			Flags:          BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive,
+			Code:           BGPPathAttrTypeExtCommunities,
+			Length:         0,
+			BGPPathAttrLen: 3,
+		},
+	}
+	e.SetValue(comms)
+	return e
+}
+
+type BGPPathAttrLargeCommunities struct {
+	BGPPathAttrBase
+	Value []BGPLargeCommunity
+}
+
+func (l *BGPPathAttrLargeCommunities) Clone() BGPPathAttr {
+	x := *l
+	x.BGPPathAttrBase = l.BGPPathAttrBase.Clone()
+	x.Value = make([]BGPLargeCommunity, len(l.Value))
+	copy(x.Value, l.Value)
+	return &x
+}
+
+func (l *BGPPathAttrLargeCommunities) Encode() ([]byte, error) {
+	pkt, err := l.BGPPathAttrBase.Encode()
+	if err != nil {
+		return pkt, err
+	}
+
+	for i, comm := range l.Value {
+		start := int(l.BGPPathAttrLen) + (12 * i)
+		binary.BigEndian.PutUint32(pkt[start:], comm.GlobalAdmin)
+		binary.BigEndian.PutUint32(pkt[start+4:], comm.LocalData1)
+		binary.BigEndian.PutUint32(pkt[start+8:], comm.LocalData2)
+	}
+	return pkt, nil
+}
+
+func (l *BGPPathAttrLargeCommunities) Decode(pkt []byte, data interface{}) error {
+	err := l.BGPPathAttrBase.Decode(pkt, data)
+	if err != nil {
+		return err
+	}
+
+	if l.Length%12 != 0 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:l.TotalLen()],
+			fmt.Sprintf("Large communities attribute length %d is not a multiple of 12", l.Length)}
+	}
+
+	l.Value = make([]BGPLargeCommunity, l.Length/12)
+	for i := 0; i < len(l.Value); i++ {
+		start := int(l.BGPPathAttrLen) + (12 * i)
+		l.Value[i].GlobalAdmin = binary.BigEndian.Uint32(pkt[start : start+4])
+		l.Value[i].LocalData1 = binary.BigEndian.Uint32(pkt[start+4 : start+8])
+		l.Value[i].LocalData2 = binary.BigEndian.Uint32(pkt[start+8 : start+12])
+	}
+	return nil
+}
+
+func (l *BGPPathAttrLargeCommunities) New() BGPPathAttr {
+	return &BGPPathAttrLargeCommunities{}
+}
+
+func (l *BGPPathAttrLargeCommunities) String() string {
+	strs := make([]string, 0, len(l.Value))
+	for _, comm := range l.Value {
+		strs = append(strs, comm.String())
+	}
+	return fmt.Sprintf("{LARGE_COMMUNITIES %s}", strings.Join(strs, " "))
+}
+
+func (l *BGPPathAttrLargeCommunities) SetValue(comms []BGPLargeCommunity) {
+	l.Value = make([]BGPLargeCommunity, len(comms))
+	copy(l.Value, comms)
+	l.setValueLen(12 * len(l.Value))
+}
+
+func NewBGPPathAttrLargeCommunities(comms []BGPLargeCommunity) *BGPPathAttrLargeCommunities {
+	l := &BGPPathAttrLargeCommunities{
+		BGPPathAttrBase: BGPPathAttrBase{
+			Flags:          BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive,
+			Code:           BGPPathAttrTypeLargeCommunities,
+			Length:         0,
+			BGPPathAttrLen: 3,
+		},
+	}
+	l.SetValue(comms)
+	return l
+}
+
+func GetCommunities(pathAttrs []BGPPathAttr) []uint32 {
+	pa := getTypeFromPathAttrs(pathAttrs, BGPPathAttrTypeCommunities)
+	if pa == nil {
+		return nil
+	}
+	return pa.(*BGPPathAttrCommunities).Value
+}
+
+func GetExtCommunities(pathAttrs []BGPPathAttr) []BGPExtCommunity {
+	pa := getTypeFromPathAttrs(pathAttrs, BGPPathAttrTypeExtCommunities)
+	if pa == nil {
+		return nil
+	}
+	return pa.(*BGPPathAttrExtCommunities).Value
+}
+
+func GetLargeCommunities(pathAttrs []BGPPathAttr) []BGPLargeCommunity {
+	pa := getTypeFromPathAttrs(pathAttrs, BGPPathAttrTypeLargeCommunities)
+	if pa == nil {
+		return nil
+	}
+	return pa.(*BGPPathAttrLargeCommunities).Value
+}
+
+func HasCommunity(pathAttrs []BGPPathAttr, comm uint32) bool {
+	for _, val := range GetCommunities(pathAttrs) {
+		if val == comm {
+			return true
+		}
+	}
+	return false
+}
+
+// SetCommunities replaces the COMMUNITIES attribute, the attribute is removed if comms is empty
+func SetCommunities(pathAttrs []BGPPathAttr, comms []uint32) []BGPPathAttr {
+	removeTypeFromPathAttrs(&pathAttrs, BGPPathAttrTypeCommunities)
+	if len(comms) == 0 {
+		return pathAttrs
+	}
+	return AddPathAttrToPathAttrsByCode(pathAttrs, BGPPathAttrTypeCommunities, NewBGPPathAttrCommunities(comms))
+}
+
+// SetExtCommunities replaces the EXTENDED COMMUNITIES attribute, the attribute is removed if comms is empty
+func SetExtCommunities(pathAttrs []BGPPathAttr, comms []BGPExtCommunity) []BGPPathAttr {
+	removeTypeFromPathAttrs(&pathAttrs, BGPPathAttrTypeExtCommunities)
+	if len(comms) == 0 {
+		return pathAttrs
+	}
+	return AddPathAttrToPathAttrsByCode(pathAttrs, BGPPathAttrTypeExtCommunities, NewBGPPathAttrExtCommunities(comms))
+}
+
+// SetLargeCommunities replaces the LARGE COMMUNITIES attribute, the attribute is removed if comms is empty
+func SetLargeCommunities(pathAttrs []BGPPathAttr, comms []BGPLargeCommunity) []BGPPathAttr {
+	removeTypeFromPathAttrs(&pathAttrs, BGPPathAttrTypeLargeCommunities)
+	if len(comms) == 0 {
+		return pathAttrs
+	}
+	return AddPathAttrToPathAttrsByCode(pathAttrs, BGPPathAttrTypeLargeCommunities,
+		NewBGPPathAttrLargeCommunities(comms))
+}
@@ -0,0 +1,187 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// community_test.go
+package packet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestCommunitiesDecode(t *testing.T) {
+	hexPkt, err := hex.DecodeString("C0080CFFFFFF0100640001FFFFFF02")
+	if err != nil {
+		t.Fatal("Failed to decode the string to hex, err:", err)
+	}
+
+	comms := &BGPPathAttrCommunities{}
+	err = comms.Decode(hexPkt, nil)
+	if err != nil {
+		t.Fatal("BGPPathAttrCommunities.Decode failed with error:", err)
+	}
+
+	expected := []uint32{BGPCommunityNoExport, 0x00640001, BGPCommunityNoAdvertise}
+	if len(comms.Value) != len(expected) {
+		t.Fatal("BGPPathAttrCommunities.Decode expected", len(expected), "communities, got", len(comms.Value))
+	}
+	for idx, comm := range expected {
+		if comms.Value[idx] != comm {
+			t.Fatal("BGPPathAttrCommunities.Decode expected community", comm, "got", comms.Value[idx])
+		}
+	}
+	t.Log("Decoded communities:", comms)
+
+	badPkt, _ := hex.DecodeString("C00806FFFFFF010064")
+	err = comms.Decode(badPkt, nil)
+	if err == nil {
+		t.Fatal("BGPPathAttrCommunities.Decode with length 6, expected failure, got NO error")
+	}
+	t.Log("BGPPathAttrCommunities.Decode with length 6 failed with error:", err)
+}
+
+func TestCommunitiesEncode(t *testing.T) {
+	comms := make([]uint32, 70)
+	for idx := range comms {
+		comms[idx] = uint32(idx)
+	}
+	pa := NewBGPPathAttrCommunities(comms)
+	if pa.Flags&BGPPathAttrFlagExtendedLen == 0 || pa.BGPPathAttrLen != 4 {
+		t.Fatal("Extended length not set for communities attribute of length", pa.Length)
+	}
+
+	pkt, err := pa.Encode()
+	if err != nil {
+		t.Fatal("BGPPathAttrCommunities.Encode failed with error:", err)
+	}
+
+	decoded := &BGPPathAttrCommunities{}
+	err = decoded.Decode(pkt, nil)
+	if err != nil {
+		t.Fatal("BGPPathAttrCommunities.Decode failed with error:", err)
+	}
+	if len(decoded.Value) != len(comms) {
+		t.Fatal("Communities round trip expected", len(comms), "communities, got", len(decoded.Value))
+	}
+	for idx := range comms {
+		if decoded.Value[idx] != comms[idx] {
+			t.Fatal("Communities round trip expected", comms[idx], "got", decoded.Value[idx])
+		}
+	}
+}
+
+func TestExtCommunitiesDecode(t *testing.T) {
+	hexPkt, err := hex.DecodeString("C0101000020064000000C8010350010101000A")
+	if err != nil {
+		t.Fatal("Failed to decode the string to hex, err:", err)
+	}
+
+	extComms := &BGPPathAttrExtCommunities{}
+	err = extComms.Decode(hexPkt, nil)
+	if err != nil {
+		t.Fatal("BGPPathAttrExtCommunities.Decode failed with error:", err)
+	}
+
+	expected := []string{"rt:100:200", "soo:80.1.1.1:10"}
+	if len(extComms.Value) != len(expected) {
+		t.Fatal("BGPPathAttrExtCommunities.Decode expected", len(expected), "communities, got", len(extComms.Value))
+	}
+	for idx, str := range expected {
+		if extComms.Value[idx].String() != str {
+			t.Fatal("BGPPathAttrExtCommunities.Decode expected", str, "got", extComms.Value[idx].String())
+		}
+
+		comm, err := ParseExtCommunity(str)
+		if err != nil {
+			t.Fatal("ParseExtCommunity failed for", str, "with error:", err)
+		}
+		if comm != extComms.Value[idx] {
+			t.Fatal("ParseExtCommunity for", str, "expected", extComms.Value[idx], "got", comm)
+		}
+	}
+}
+
+func TestLargeCommunitiesEncodeDecode(t *testing.T) {
+	comm, err := ParseLargeCommunity("4200000000:1:2")
+	if err != nil {
+		t.Fatal("ParseLargeCommunity failed with error:", err)
+	}
+
+	pa := NewBGPPathAttrLargeCommunities([]BGPLargeCommunity{comm})
+	pkt, err := pa.Encode()
+	if err != nil {
+		t.Fatal("BGPPathAttrLargeCommunities.Encode failed with error:", err)
+	}
+	if hex.EncodeToString(pkt) != "c0200cfa56ea000000000100000002" {
+		t.Fatal("BGPPathAttrLargeCommunities.Encode unexpected packet", hex.EncodeToString(pkt))
+	}
+
+	decoded := &BGPPathAttrLargeCommunities{}
+	err = decoded.Decode(pkt, nil)
+	if err != nil {
+		t.Fatal("BGPPathAttrLargeCommunities.Decode failed with error:", err)
+	}
+	if len(decoded.Value) != 1 || decoded.Value[0] != comm {
+		t.Fatal("BGPPathAttrLargeCommunities.Decode expected", comm, "got", decoded.Value)
+	}
+}
+
+func TestParseCommunity(t *testing.T) {
+	valid := map[string]uint32{
+		"no-export":           BGPCommunityNoExport,
+		"NO-ADVERTISE":        BGPCommunityNoAdvertise,
+		"no-export-subconfed": BGPCommunityNoExportSubconfed,
+		"100:1":               0x00640001,
+		"65536":               0x00010000,
+	}
+	for str, expected := range valid {
+		comm, err := ParseCommunity(str)
+		if err != nil {
+			t.Fatal("ParseCommunity failed for", str, "with error:", err)
+		}
+		if comm != expected {
+			t.Fatal("ParseCommunity for", str, "expected", expected, "got", comm)
+		}
+	}
+
+	invalid := []string{"70000:1", "1:2:3", "abc"}
+	for _, str := range invalid {
+		_, err := ParseCommunity(str)
+		if err == nil {
+			t.Fatal("ParseCommunity for", str, "expected failure, got NO error")
+		}
+	}
+}
+
+func TestSetCommunities(t *testing.T) {
+	pathAttrs := ConstructPathAttrForConnRoutes(100)
+	pathAttrs = SetCommunities(pathAttrs, []uint32{BGPCommunityNoExport})
+	if !HasCommunity(pathAttrs, BGPCommunityNoExport) {
+		t.Fatal("SetCommunities did not add NO_EXPORT to path attrs")
+	}
+
+	pathAttrs = SetCommunities(pathAttrs, nil)
+	if GetCommunities(pathAttrs) != nil {
+		t.Fatal("SetCommunities with no communities did not remove the attribute")
+	}
+}
@@ -0,0 +1,522 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// evpn.go
+package packet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// EVPN route types, RFC 7432 and RFC 9136
+const (
+	EVPNRouteTypeEthernetAD uint8 = iota + 1
+	EVPNRouteTypeMACIP
+	EVPNRouteTypeInclusiveMulticast
+	EVPNRouteTypeEthernetSegment
+	EVPNRouteTypeIPPrefix
+)
+
+const (
+	EVPNESILen         = 10
+	EVPNEthernetTagLen = 4
+	EVPNMACLen         = 6
+)
+
+const (
+	VXLANVNIMax uint32 = 0xFFFFFF
+)
+
+// Tunnel type of the BGP encapsulation extended community, RFC 5512 and RFC 8365
+const (
+	BGPExtCommunitySubTypeEncapsulation uint8  = 0x0c
+	BGPEncapTunnelTypeVXLAN             uint16 = 8
+)
+
+// Tunnel type of the PMSI tunnel attribute, RFC 6514
+const (
+	PMSITunnelTypeIngressReplication uint8 = 6
+)
+
+func IsEVPNFamily(protoFamily uint32) bool {
+	afi, safi := GetAfiSafi(protoFamily)
+	return afi == AfiL2VPN && safi == SafiEVPN
+}
+
+// NewEncapsulationExtCommunity returns the opaque encapsulation extended community for the tunnel type
+func NewEncapsulationExtCommunity(tunnelType uint16) BGPExtCommunity {
+	return NewBGPExtCommunity(BGPExtCommunityTypeOpaque, BGPExtCommunitySubTypeEncapsulation, 0, uint32(tunnelType))
+}
+
+// GetEncapsulation returns the tunnel type of the encapsulation extended community in the path attributes
+func GetEncapsulation(pathAttrs []BGPPathAttr) (uint16, bool) {
+	for _, comm := range GetExtCommunities(pathAttrs) {
+		if comm.Type() == BGPExtCommunityTypeOpaque && comm.SubType() == BGPExtCommunitySubTypeEncapsulation {
+			return uint16(comm), true
+		}
+	}
+	return 0, false
+}
+
+func evpnIPBytes(ip net.IP) []byte {
+	if ip == nil {
+		return nil
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip.To16()
+}
+
+// EVPNPrefix is the EVPN NLRI. The IP prefix holds the IP address of the MAC/IP advertisement route, the
+// originating router's IP address of the inclusive multicast route and the IP prefix of the IP prefix route.
+// The label fields are kept as the 24 bits of the NLRI, they carry the VNI when the encapsulation is VXLAN.
+// The route types that are not supported are kept as is.
+type EVPNPrefix struct {
+	*IPPrefix
+	RouteType   uint8
+	RD          RouteDistinguisher
+	ESI         [EVPNESILen]byte
+	EthernetTag uint32
+	MAC         net.HardwareAddr
+	GatewayIP   net.IP
+	Labels      []uint32
+	value       []byte
+}
+
+func (e *EVPNPrefix) Clone() NLRI {
+	x := *e
+	x.IPPrefix = e.IPPrefix.Clone().(*IPPrefix)
+	x.MAC = make(net.HardwareAddr, len(e.MAC))
+	copy(x.MAC, e.MAC)
+	x.GatewayIP = make(net.IP, len(e.GatewayIP))
+	copy(x.GatewayIP, e.GatewayIP)
+	x.Labels = make([]uint32, len(e.Labels))
+	copy(x.Labels, e.Labels)
+	x.value = make([]byte, len(e.value))
+	copy(x.value, e.value)
+	return &x
+}
+
+func (e *EVPNPrefix) ipBytes() []byte {
+	if e.Length == 0 {
+		return nil
+	}
+	return evpnIPBytes(e.Prefix)
+}
+
+func (e *EVPNPrefix) routeLen() int {
+	switch e.RouteType {
+	case EVPNRouteTypeMACIP:
+		return RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen + 1 + EVPNMACLen + 1 +
+			len(e.ipBytes()) + MPLSLabelLen*len(e.Labels)
+	case EVPNRouteTypeInclusiveMulticast:
+		return RouteDistinguisherLen + EVPNEthernetTagLen + 1 + len(e.ipBytes())
+	case EVPNRouteTypeIPPrefix:
+		ipLen := net.IPv6len
+		if e.Prefix.To4() != nil {
+			ipLen = net.IPv4len
+		}
+		return RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen + 1 + 2*ipLen + MPLSLabelLen
+	}
+	return len(e.value)
+}
+
+func (e *EVPNPrefix) Len() uint32 {
+	return uint32(2 + e.routeLen())
+}
+
+func putLabel(pkt []byte, label uint32) {
+	pkt[0] = uint8(label >> 16)
+	pkt[1] = uint8(label >> 8)
+	pkt[2] = uint8(label)
+}
+
+func getLabel(pkt []byte) uint32 {
+	return uint32(pkt[0])<<16 | uint32(pkt[1])<<8 | uint32(pkt[2])
+}
+
+func (e *EVPNPrefix) Encode(afi AFI) ([]byte, error) {
+	routeLen := e.routeLen()
+	if routeLen > 0xFF {
+		return nil, errors.New(fmt.Sprintf("EVPN route %s length %d is too long", e.GetCIDR(), routeLen))
+	}
+
+	pkt := make([]byte, 2+routeLen)
+	pkt[0] = e.RouteType
+	pkt[1] = uint8(routeLen)
+	if e.RouteType != EVPNRouteTypeMACIP && e.RouteType != EVPNRouteTypeInclusiveMulticast &&
+		e.RouteType != EVPNRouteTypeIPPrefix {
+		copy(pkt[2:], e.value)
+		return pkt, nil
+	}
+
+	idx := 2
+	binary.BigEndian.PutUint64(pkt[idx:idx+RouteDistinguisherLen], uint64(e.RD))
+	idx += RouteDistinguisherLen
+	if e.RouteType != EVPNRouteTypeInclusiveMulticast {
+		copy(pkt[idx:idx+EVPNESILen], e.ESI[:])
+		idx += EVPNESILen
+	}
+	binary.BigEndian.PutUint32(pkt[idx:idx+EVPNEthernetTagLen], e.EthernetTag)
+	idx += EVPNEthernetTagLen
+
+	switch e.RouteType {
+	case EVPNRouteTypeMACIP:
+		if len(e.MAC) != EVPNMACLen {
+			return nil, errors.New(fmt.Sprintf("EVPN MAC/IP route %s does not have a MAC address", e.GetCIDR()))
+		}
+		if len(e.Labels) == 0 || len(e.Labels) > 2 {
+			return nil, errors.New(fmt.Sprintf("EVPN MAC/IP route %s has %d labels", e.GetCIDR(), len(e.Labels)))
+		}
+		pkt[idx] = EVPNMACLen * 8
+		copy(pkt[idx+1:idx+1+EVPNMACLen], e.MAC)
+		idx += 1 + EVPNMACLen
+		ipBytes := e.ipBytes()
+		pkt[idx] = uint8(len(ipBytes) * 8)
+		copy(pkt[idx+1:], ipBytes)
+		idx += 1 + len(ipBytes)
+		for _, label := range e.Labels {
+			putLabel(pkt[idx:], label)
+			idx += MPLSLabelLen
+		}
+
+	case EVPNRouteTypeInclusiveMulticast:
+		ipBytes := e.ipBytes()
+		pkt[idx] = uint8(len(ipBytes) * 8)
+		copy(pkt[idx+1:], ipBytes)
+
+	case EVPNRouteTypeIPPrefix:
+		if len(e.Labels) != 1 {
+			return nil, errors.New(fmt.Sprintf("EVPN IP prefix route %s has %d labels", e.GetCIDR(), len(e.Labels)))
+		}
+		ipLen := (routeLen - RouteDistinguisherLen - EVPNESILen - EVPNEthernetTagLen - 1 - MPLSLabelLen) / 2
+		pkt[idx] = e.Length
+		idx++
+		prefix := e.Prefix.To16()
+		copy(pkt[idx:idx+ipLen], prefix[net.IPv6len-ipLen:])
+		idx += ipLen
+		if e.GatewayIP != nil {
+			gwIP := e.GatewayIP.To16()
+			copy(pkt[idx:idx+ipLen], gwIP[net.IPv6len-ipLen:])
+		}
+		idx += ipLen
+		putLabel(pkt[idx:], e.Labels[0])
+	}
+	return pkt, nil
+}
+
+func decodeEVPNIP(pkt []byte, bits uint8) (net.IP, error) {
+	switch bits {
+	case 0:
+		return nil, nil
+	case net.IPv4len * 8:
+		if len(pkt) < net.IPv4len {
+			break
+		}
+		return net.IPv4(pkt[0], pkt[1], pkt[2], pkt[3]).To4(), nil
+	case net.IPv6len * 8:
+		if len(pkt) < net.IPv6len {
+			break
+		}
+		ip := make(net.IP, net.IPv6len)
+		copy(ip, pkt[:net.IPv6len])
+		return ip, nil
+	}
+	return nil, BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+		fmt.Sprintf("EVPN NLRI IP address length %d is invalid", bits)}
+}
+
+func (e *EVPNPrefix) Decode(pkt []byte, afi AFI) error {
+	if len(pkt) < 2 || len(pkt) < 2+int(pkt[1]) {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil, "EVPN NLRI length invalid"}
+	}
+
+	e.RouteType = pkt[0]
+	routeLen := int(pkt[1])
+	route := pkt[2 : 2+routeLen]
+	e.IPPrefix = NewIPPrefix(net.IPv4zero.To4(), 0)
+	e.MAC = nil
+	e.GatewayIP = nil
+	e.Labels = make([]uint32, 0)
+	e.value = nil
+
+	var err error
+	switch e.RouteType {
+	case EVPNRouteTypeMACIP:
+		idx := RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen
+		if routeLen < idx+2+EVPNMACLen || route[idx] != EVPNMACLen*8 {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil, "EVPN MAC/IP route invalid"}
+		}
+		e.decodeHeader(route, true)
+		e.MAC = make(net.HardwareAddr, EVPNMACLen)
+		copy(e.MAC, route[idx+1:idx+1+EVPNMACLen])
+		idx += 1 + EVPNMACLen
+		ipBits := route[idx]
+		idx++
+		var ip net.IP
+		if ip, err = decodeEVPNIP(route[idx:], ipBits); err != nil {
+			return err
+		}
+		if ip != nil {
+			e.IPPrefix = NewIPPrefix(ip, ipBits)
+		}
+		idx += int(ipBits) / 8
+		labelsLen := routeLen - idx
+		if labelsLen != MPLSLabelLen && labelsLen != 2*MPLSLabelLen {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				"EVPN MAC/IP route label length invalid"}
+		}
+		for ; idx < routeLen; idx += MPLSLabelLen {
+			e.Labels = append(e.Labels, getLabel(route[idx:]))
+		}
+
+	case EVPNRouteTypeInclusiveMulticast:
+		idx := RouteDistinguisherLen + EVPNEthernetTagLen
+		if routeLen < idx+1 || routeLen != idx+1+int(route[idx])/8 {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				"EVPN inclusive multicast route invalid"}
+		}
+		e.decodeHeader(route, false)
+		var ip net.IP
+		if ip, err = decodeEVPNIP(route[idx+1:], route[idx]); err != nil {
+			return err
+		}
+		if ip == nil {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				"EVPN inclusive multicast route does not have the originating router's IP address"}
+		}
+		e.IPPrefix = NewIPPrefix(ip, route[idx])
+
+	case EVPNRouteTypeIPPrefix:
+		var ipLen int
+		switch routeLen {
+		case RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen + 1 + 2*net.IPv4len + MPLSLabelLen:
+			ipLen = net.IPv4len
+		case RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen + 1 + 2*net.IPv6len + MPLSLabelLen:
+			ipLen = net.IPv6len
+		default:
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				fmt.Sprintf("EVPN IP prefix route length %d invalid", routeLen)}
+		}
+		idx := RouteDistinguisherLen + EVPNESILen + EVPNEthernetTagLen
+		if int(route[idx]) > ipLen*8 {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+				fmt.Sprintf("EVPN IP prefix route prefix length %d invalid", route[idx])}
+		}
+		e.decodeHeader(route, true)
+		length := route[idx]
+		idx++
+		prefix := make(net.IP, ipLen)
+		copy(prefix, route[idx:idx+ipLen])
+		e.IPPrefix = NewIPPrefix(prefix.Mask(net.CIDRMask(int(length), ipLen*8)), length)
+		idx += ipLen
+		gwIP := make(net.IP, ipLen)
+		copy(gwIP, route[idx:idx+ipLen])
+		if !gwIP.IsUnspecified() {
+			e.GatewayIP = gwIP
+		}
+		idx += ipLen
+		e.Labels = append(e.Labels, getLabel(route[idx:]))
+
+	default:
+		e.value = make([]byte, routeLen)
+		copy(e.value, route)
+	}
+	return nil
+}
+
+func (e *EVPNPrefix) decodeHeader(route []byte, hasESI bool) {
+	e.RD = RouteDistinguisher(binary.BigEndian.Uint64(route[:RouteDistinguisherLen]))
+	idx := RouteDistinguisherLen
+	if hasESI {
+		copy(e.ESI[:], route[idx:idx+EVPNESILen])
+		idx += EVPNESILen
+	}
+	e.EthernetTag = binary.BigEndian.Uint32(route[idx : idx+EVPNEthernetTagLen])
+}
+
+func (e *EVPNPrefix) GetCIDR() string {
+	switch e.RouteType {
+	case EVPNRouteTypeMACIP:
+		cidr := fmt.Sprintf("[%d]:[%s]:[%d]:[%s]", e.RouteType, e.RD, e.EthernetTag, e.MAC)
+		if e.Length > 0 {
+			cidr += fmt.Sprintf(":[%s]", e.Prefix)
+		}
+		return cidr
+	case EVPNRouteTypeInclusiveMulticast:
+		return fmt.Sprintf("[%d]:[%s]:[%d]:[%s]", e.RouteType, e.RD, e.EthernetTag, e.Prefix)
+	case EVPNRouteTypeIPPrefix:
+		return fmt.Sprintf("[%d]:[%s]:[%d]:[%s]", e.RouteType, e.RD, e.EthernetTag, e.IPPrefix.GetCIDR())
+	}
+	return fmt.Sprintf("[%d]:[%s]", e.RouteType, hex.EncodeToString(e.value))
+}
+
+func (e *EVPNPrefix) String() string {
+	return "{" + e.GetCIDR() + " label " + fmt.Sprint(e.Labels) + "}"
+}
+
+// GetVNI returns the VNI of the MAC/IP advertisement and the IP prefix routes, RFC 8365
+func (e *EVPNPrefix) GetVNI() uint32 {
+	if len(e.Labels) == 0 {
+		return 0
+	}
+	return e.Labels[0]
+}
+
+func NewEVPNMACIPPrefix(rd RouteDistinguisher, ethTag uint32, mac net.HardwareAddr, ip net.IP,
+	vni uint32) *EVPNPrefix {
+	prefix := NewIPPrefix(net.IPv4zero.To4(), 0)
+	if ipBytes := evpnIPBytes(ip); ipBytes != nil {
+		prefix = NewIPPrefix(ipBytes, uint8(len(ipBytes)*8))
+	}
+	return &EVPNPrefix{
+		IPPrefix:    prefix,
+		RouteType:   EVPNRouteTypeMACIP,
+		RD:          rd,
+		EthernetTag: ethTag,
+		MAC:         mac,
+		Labels:      []uint32{vni},
+	}
+}
+
+func NewEVPNInclusiveMulticastPrefix(rd RouteDistinguisher, ethTag uint32, originatorIP net.IP) *EVPNPrefix {
+	ip := evpnIPBytes(originatorIP)
+	return &EVPNPrefix{
+		IPPrefix:    NewIPPrefix(ip, uint8(len(ip)*8)),
+		RouteType:   EVPNRouteTypeInclusiveMulticast,
+		RD:          rd,
+		EthernetTag: ethTag,
+		Labels:      make([]uint32, 0),
+	}
+}
+
+func NewEVPNIPPrefix(rd RouteDistinguisher, ethTag uint32, prefix *IPPrefix, gatewayIP net.IP,
+	vni uint32) *EVPNPrefix {
+	return &EVPNPrefix{
+		IPPrefix:    prefix,
+		RouteType:   EVPNRouteTypeIPPrefix,
+		RD:          rd,
+		EthernetTag: ethTag,
+		GatewayIP:   gatewayIP,
+		Labels:      []uint32{vni},
+	}
+}
+
+// BGPPathAttrPMSITunnel is the P-Multicast Service Interface tunnel attribute, RFC 6514. The inclusive
+// multicast routes of EVPN carry the ingress replication tunnel to the VTEP, the label is the VNI, RFC 8365.
+type BGPPathAttrPMSITunnel struct {
+	BGPPathAttrBase
+	TunnelFlags uint8
+	TunnelType  uint8
+	Label       uint32
+	TunnelId    net.IP
+}
+
+func (p *BGPPathAttrPMSITunnel) Clone() BGPPathAttr {
+	x := *p
+	x.BGPPathAttrBase = p.BGPPathAttrBase.Clone()
+	x.TunnelId = make(net.IP, len(p.TunnelId))
+	copy(x.TunnelId, p.TunnelId)
+	return &x
+}
+
+func (p *BGPPathAttrPMSITunnel) Encode() ([]byte, error) {
+	pkt, err := p.BGPPathAttrBase.Encode()
+	if err != nil {
+		return pkt, err
+	}
+
+	idx := p.BGPPathAttrLen
+	pkt[idx] = p.TunnelFlags
+	pkt[idx+1] = p.TunnelType
+	putLabel(pkt[idx+2:], p.Label)
+	copy(pkt[idx+5:], p.TunnelId)
+	return pkt, nil
+}
+
+func (p *BGPPathAttrPMSITunnel) Decode(pkt []byte, data interface{}) error {
+	err := p.BGPPathAttrBase.Decode(pkt, data)
+	if err != nil {
+		return err
+	}
+
+	if p.Length < 5 {
+		return BGPMessageError{BGPUpdateMsgError, BGPOptionalAttrError, pkt[:p.TotalLen()],
+			fmt.Sprintf("PMSI tunnel attribute length %d is less than 5", p.Length)}
+	}
+
+	idx := p.BGPPathAttrLen
+	p.TunnelFlags = pkt[idx]
+	p.TunnelType = pkt[idx+1]
+	p.Label = getLabel(pkt[idx+2:])
+	p.TunnelId = make(net.IP, p.Length-5)
+	copy(p.TunnelId, pkt[idx+5:idx+p.Length])
+	return nil
+}
+
+func (p *BGPPathAttrPMSITunnel) New() BGPPathAttr {
+	return &BGPPathAttrPMSITunnel{}
+}
+
+func (p *BGPPathAttrPMSITunnel) String() string {
+	return fmt.Sprintf("{PMSI_TUNNEL type %d label %d id %s}", p.TunnelType, p.Label, p.TunnelId)
+}
+
+func NewBGPPathAttrPMSITunnel(tunnelType uint8, label uint32, tunnelId net.IP) *BGPPathAttrPMSITunnel {
+	if ip := tunnelId.To4(); ip != nil {
+		tunnelId = ip
+	}
+	return &BGPPathAttrPMSITunnel{
+		BGPPathAttrBase: BGPPathAttrBase{
+			Flags:          BGPPathAttrFlagOptional | BGPPathAttrFlagTransitive,
+			Code:           BGPPathAttrTypePMSITunnel,
+			Length:         uint16(5 + len(tunnelId)),
+			BGPPathAttrLen: 3,
+		},
+		TunnelType: tunnelType,
+		Label:      label,
+		TunnelId:   tunnelId,
+	}
+}
+
+func GetPMSITunnel(pathAttrs []BGPPathAttr) *BGPPathAttrPMSITunnel {
+	pa := getTypeFromPathAttrs(pathAttrs, BGPPathAttrTypePMSITunnel)
+	if pa == nil {
+		return nil
+	}
+	return pa.(*BGPPathAttrPMSITunnel)
+}
+
+// SetPMSITunnel replaces the PMSI TUNNEL attribute, the attribute is removed if pmsi is nil
+func SetPMSITunnel(pathAttrs []BGPPathAttr, pmsi *BGPPathAttrPMSITunnel) []BGPPathAttr {
+	removeTypeFromPathAttrs(&pathAttrs, BGPPathAttrTypePMSITunnel)
+	if pmsi == nil {
+		return pathAttrs
+	}
+	return AddPathAttrToPathAttrsByCode(pathAttrs, BGPPathAttrTypePMSITunnel, pmsi)
+}
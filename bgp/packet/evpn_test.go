@@ -0,0 +1,141 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// evpn_test.go
+package packet
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEVPNPrefix(t *testing.T) {
+	rd, _ := ParseRouteDistinguisher("10.1.1.1:100")
+	mac, _ := net.ParseMAC("00:11:22:33:44:55")
+	prefixes := []*EVPNPrefix{
+		NewEVPNMACIPPrefix(rd, 0, mac, nil, 5000),
+		NewEVPNMACIPPrefix(rd, 0, mac, net.ParseIP("192.168.1.10"), 5000),
+		NewEVPNInclusiveMulticastPrefix(rd, 0, net.ParseIP("10.1.1.1")),
+		NewEVPNIPPrefix(rd, 0, NewIPPrefix(net.ParseIP("192.168.1.0").To4(), 24), nil, 6000),
+		NewEVPNIPPrefix(rd, 0, NewIPPrefix(net.ParseIP("2001:db8::"), 64), nil, 6000),
+	}
+	expectedLen := []uint32{35, 39, 19, 36, 60}
+	expectedCIDR := []string{
+		"[2]:[10.1.1.1:100]:[0]:[00:11:22:33:44:55]",
+		"[2]:[10.1.1.1:100]:[0]:[00:11:22:33:44:55]:[192.168.1.10]",
+		"[3]:[10.1.1.1:100]:[0]:[10.1.1.1]",
+		"[5]:[10.1.1.1:100]:[0]:[192.168.1.0/24]",
+		"[5]:[10.1.1.1:100]:[0]:[2001:db8::/64]",
+	}
+	for i, prefix := range prefixes {
+		pkt, err := prefix.Encode(AfiL2VPN)
+		if err != nil {
+			t.Fatal("EVPNPrefix.Encode failed with error:", err)
+		}
+		if uint32(len(pkt)) != expectedLen[i] || prefix.Len() != expectedLen[i] {
+			t.Fatal("EVPNPrefix.Encode expected len", expectedLen[i], "got", len(pkt), "Len()", prefix.Len())
+		}
+
+		evpnPrefix := &EVPNPrefix{}
+		if err = evpnPrefix.Decode(pkt, AfiL2VPN); err != nil {
+			t.Fatal("EVPNPrefix.Decode failed with error:", err)
+		}
+		if evpnPrefix.GetCIDR() != expectedCIDR[i] || prefix.GetCIDR() != expectedCIDR[i] {
+			t.Fatal("EVPNPrefix.Decode expected", expectedCIDR[i], "got", evpnPrefix.GetCIDR())
+		}
+		if evpnPrefix.Len() != prefix.Len() || evpnPrefix.GetVNI() != prefix.GetVNI() {
+			t.Fatal("EVPNPrefix.Decode expected", prefix, "got", evpnPrefix)
+		}
+	}
+
+	// MAC address length is not 48 bits
+	pkt, _ := prefixes[0].Encode(AfiL2VPN)
+	pkt[24] = 32
+	if err := (&EVPNPrefix{}).Decode(pkt, AfiL2VPN); err == nil {
+		t.Fatal("EVPNPrefix.Decode for invalid MAC length, expected failure, got NO error")
+	}
+
+	// route types that are not supported are kept as is
+	pkt = []byte{EVPNRouteTypeEthernetSegment, 3, 0x01, 0x02, 0x03}
+	evpnPrefix := &EVPNPrefix{}
+	if err := evpnPrefix.Decode(pkt, AfiL2VPN); err != nil || evpnPrefix.Len() != 5 {
+		t.Fatal("EVPNPrefix.Decode for route type 4 failed, err", err, "len", evpnPrefix.Len())
+	}
+}
+
+func TestEVPNMPReachNLRI(t *testing.T) {
+	rd, _ := ParseRouteDistinguisher("65000:10")
+	protoFamily := GetProtocolFamily(AfiL2VPN, SafiEVPN)
+	nlri := NewEVPNInclusiveMulticastPrefix(rd, 0, net.ParseIP("10.0.0.1"))
+	mpReach := ConstructIPv6MPReachNLRI(protoFamily, net.ParseIP("10.0.0.1"), nil, []NLRI{nlri})
+	pkt, err := mpReach.Encode()
+	if err != nil {
+		t.Fatal("MPReachNLRI.Encode failed with error:", err)
+	}
+
+	decoded := NewBGPPathAttrMPReachNLRI()
+	peerAttrs := BGPPeerAttrs{
+		ASSize:           4,
+		AddPathsRxActual: true,
+	}
+	if err = decoded.Decode(pkt, peerAttrs); err != nil {
+		t.Fatal("MPReachNLRI.Decode failed with error:", err)
+	}
+
+	if !decoded.NextHop.GetNextHop().Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatal("MPReachNLRI.Decode expected next hop 10.0.0.1, got", decoded.NextHop)
+	}
+	if len(decoded.NLRI) != 1 || decoded.NLRI[0].GetCIDR() != nlri.GetCIDR() {
+		t.Fatal("MPReachNLRI.Decode expected NLRI", nlri, "got", decoded.NLRI)
+	}
+}
+
+func TestPMSITunnel(t *testing.T) {
+	pmsi := NewBGPPathAttrPMSITunnel(PMSITunnelTypeIngressReplication, 5000, net.ParseIP("10.0.0.1"))
+	pkt, err := pmsi.Encode()
+	if err != nil {
+		t.Fatal("BGPPathAttrPMSITunnel.Encode failed with error:", err)
+	}
+	if len(pkt) != 12 {
+		t.Fatal("BGPPathAttrPMSITunnel.Encode expected len 12, got", len(pkt))
+	}
+
+	decoded := &BGPPathAttrPMSITunnel{}
+	if err = decoded.Decode(pkt, BGPPeerAttrs{ASSize: 4}); err != nil {
+		t.Fatal("BGPPathAttrPMSITunnel.Decode failed with error:", err)
+	}
+	if decoded.TunnelType != PMSITunnelTypeIngressReplication || decoded.Label != 5000 ||
+		!decoded.TunnelId.Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatal("BGPPathAttrPMSITunnel.Decode expected", pmsi, "got", decoded)
+	}
+
+	pa := SetExtCommunities(make([]BGPPathAttr, 0),
+		[]BGPExtCommunity{NewEncapsulationExtCommunity(BGPEncapTunnelTypeVXLAN)})
+	pa = SetPMSITunnel(pa, pmsi)
+	if tunnelType, ok := GetEncapsulation(pa); !ok || tunnelType != BGPEncapTunnelTypeVXLAN {
+		t.Fatal("GetEncapsulation expected VXLAN, got", tunnelType, ok)
+	}
+	if GetPMSITunnel(pa) != pmsi {
+		t.Fatal("GetPMSITunnel expected", pmsi, "got", GetPMSITunnel(pa))
+	}
+}
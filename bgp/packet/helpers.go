@@ -316,6 +316,34 @@ func GetNumASes(pathAttrs []BGPPathAttr) uint32 {
 	return total
 }
 
+// GetNeighborAS returns the left most AS in the AS_PATH, i.e. the AS of the neighbor that advertised the route.
+// It returns 0 if the AS_PATH does not start with an AS_SEQUENCE.
+func GetNeighborAS(pathAttrs []BGPPathAttr) uint32 {
+	for _, attr := range pathAttrs {
+		if attr.GetCode() == BGPPathAttrTypeASPath {
+			asPath := attr.(*BGPPathAttrASPath)
+			if len(asPath.Value) == 0 {
+				return 0
+			}
+
+			if asPath.ASSize == 4 {
+				seg := asPath.Value[0].(*BGPAS4PathSegment)
+				if seg.Type == BGPASPathSegmentSequence && len(seg.AS) > 0 {
+					return seg.AS[0]
+				}
+			} else {
+				seg := asPath.Value[0].(*BGPAS2PathSegment)
+				if seg.Type == BGPASPathSegmentSequence && len(seg.AS) > 0 {
+					return uint32(seg.AS[0])
+				}
+			}
+			break
+		}
+	}
+
+	return 0
+}
+
 func GetOrigin(pathAttrs []BGPPathAttr) uint8 {
 	for _, attr := range pathAttrs {
 		if attr.GetCode() == BGPPathAttrTypeOrigin {
@@ -510,6 +538,17 @@ func ConstructMPReachNLRIForAggRoutes(protoFamily uint32) *BGPPathAttrMPReachNLR
 	return pa
 }
 
+func ConstructMPReachNLRIForNextHop(protoFamily uint32, nextHop net.IP) *BGPPathAttrMPReachNLRI {
+	nh := NewMPNextHopIP()
+	nh.SetNextHop(nextHop)
+	afi, safi := GetAfiSafi(protoFamily)
+	pa := NewBGPPathAttrMPReachNLRI()
+	pa.AFI = afi
+	pa.SAFI = safi
+	pa.SetNextHop(nh)
+	return pa
+}
+
 func ConstructPathAttrForConnRoutes(as uint32) []BGPPathAttr {
 	pathAttrs := make([]BGPPathAttr, 0)
 
@@ -584,6 +623,14 @@ func ConstructMPUnreachNLRIFromProtoFamily(protoFamily uint32, nlriList []NLRI)
 func ConstructIPv6MPReachNLRI(protoFamily uint32, nextHop, nextHopLinkLocal net.IP,
 	nlriList []NLRI) *BGPPathAttrMPReachNLRI {
 	afi, safi := GetAfiSafi(protoFamily)
+	if safi == SafiMPLSVPN {
+		return ConstructVPNMPReachNLRI(protoFamily, nextHop, nlriList)
+	} else if safi == SafiEVPN {
+		mpReachNLRI := ConstructMPReachNLRIForNextHop(protoFamily, nextHop)
+		mpReachNLRI.SetNLRIList(nlriList)
+		return mpReachNLRI
+	}
+
 	mpReachNLRI := NewBGPPathAttrMPReachNLRI()
 	mpReachNLRI.AFI = afi
 	mpReachNLRI.SAFI = safi
@@ -687,12 +734,19 @@ func ConvertIPBytesToUint(bytes []byte) uint32 {
 	return uint32(bytes[0])<<24 | uint32(bytes[1]<<16) | uint32(bytes[2]<<8) | uint32(bytes[3])
 }
 
-func ConstructOptParams(as uint32, afiSAfiMap map[uint32]bool, addPathsRx bool, addPathsMaxTx uint8) []BGPOptParam {
+func ConstructOptParams(as uint32, afiSAfiMap map[uint32]bool, addPathsRx bool, addPathsMaxTx uint8,
+	gracefulRestart *BGPCapGracefulRestart) []BGPOptParam {
 	optParams := make([]BGPOptParam, 0)
 	capParams := make([]BGPCapability, 0)
 
 	cap4ByteASPath := NewBGPCap4ByteASPath(as)
 	capParams = append(capParams, cap4ByteASPath)
+	capParams = append(capParams, NewBGPCapRouteRefresh())
+	capParams = append(capParams, NewBGPCapEnhancedRouteRefresh())
+	if gracefulRestart != nil {
+		utils.Logger.Infof("Advertising capability for graceful restart %+v", gracefulRestart)
+		capParams = append(capParams, gracefulRestart)
+	}
 	capAddPaths := NewBGPCapAddPath()
 	addPathFlags := uint8(0)
 	if addPathsRx {
@@ -708,6 +762,9 @@ func ConstructOptParams(as uint32, afiSAfiMap map[uint32]bool, addPathsRx bool,
 		capAfiSafi := NewBGPCapMPExt(afi, safi)
 		capParams = append(capParams, capAfiSafi)
 
+		if safi != SafiUnicast {
+			continue
+		}
 		addPathAfiSafi := NewAddPathAFISAFI(afi, safi, addPathFlags)
 		capAddPaths.AddAddPathAFISAFI(addPathAfiSafi)
 	}
@@ -738,6 +795,61 @@ func GetASSize(openMsg *BGPOpen) uint8 {
 	return 2
 }
 
+func hasCapability(openMsg *BGPOpen, capType BGPCapabilityType) bool {
+	for _, optParam := range openMsg.OptParams {
+		if capabilities, ok := optParam.(*BGPOptParamCapability); ok {
+			for _, capability := range capabilities.Value {
+				if capability.GetCode() == capType {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func IsRouteRefreshSupported(openMsg *BGPOpen) bool {
+	return hasCapability(openMsg, BGPCapTypeRouteRefresh)
+}
+
+func IsEnhancedRouteRefreshSupported(openMsg *BGPOpen) bool {
+	return IsRouteRefreshSupported(openMsg) && hasCapability(openMsg, BGPCapTypeEnhancedRouteRefresh)
+}
+
+func GetGracefulRestartCap(openMsg *BGPOpen) *BGPCapGracefulRestart {
+	for _, optParam := range openMsg.OptParams {
+		if capabilities, ok := optParam.(*BGPOptParamCapability); ok {
+			for _, capability := range capabilities.Value {
+				if grCap, ok := capability.(*BGPCapGracefulRestart); ok {
+					return grCap
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsEndOfRIB checks if the UPDATE message is an End-of-RIB marker and returns the protocol family of the marker.
+func IsEndOfRIB(updateMsg *BGPUpdate) (uint32, bool) {
+	if len(updateMsg.WithdrawnRoutes) > 0 || len(updateMsg.NLRI) > 0 {
+		return 0, false
+	}
+
+	if len(updateMsg.PathAttributes) == 0 {
+		return GetProtocolFamily(AfiIP, SafiUnicast), true
+	}
+
+	if len(updateMsg.PathAttributes) == 1 {
+		if mpUnreach, ok := updateMsg.PathAttributes[0].(*BGPPathAttrMPUnreachNLRI); ok && len(mpUnreach.NLRI) == 0 {
+			return GetProtocolFamily(mpUnreach.AFI, mpUnreach.SAFI), true
+		}
+	}
+
+	return 0, false
+}
+
 func GetAddPathFamily(openMsg *BGPOpen) map[AFI]map[SAFI]uint8 {
 	addPathFamily := make(map[AFI]map[SAFI]uint8)
 	for _, optParam := range openMsg.OptParams {
@@ -960,6 +1072,11 @@ func ConstructMaxSizedUpdatePackets(bgpMsg *BGPMessage) []*BGPMessage {
 	updateMsg := bgpMsg.Body.(*BGPUpdate)
 	pathAttrs := make([]BGPPathAttr, 0)
 
+	if _, ok := IsEndOfRIB(updateMsg); ok {
+		newUpdateMsgs = append(newUpdateMsgs, bgpMsg)
+		return newUpdateMsgs
+	}
+
 	if updateMsg.WithdrawnRoutes != nil {
 		for lastIdx = 0; lastIdx < len(updateMsg.WithdrawnRoutes); lastIdx++ {
 			nlriLen := updateMsg.WithdrawnRoutes[lastIdx].Len()
@@ -0,0 +1,624 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// mrt.go
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Multi-Threaded Routing Toolkit (MRT) export format, RFC 6396
+const (
+	MRTHeaderLen         = 12
+	MRTRIBEntryHeaderLen = 8
+)
+
+const (
+	MRTTypeTableDumpV2 uint16 = 13
+	MRTTypeBGP4MP      uint16 = 16
+)
+
+const (
+	MRTSubtypePeerIndexTable uint16 = 1
+	MRTSubtypeRIBIPv4Unicast uint16 = 2
+	MRTSubtypeRIBIPv6Unicast uint16 = 4
+)
+
+// BGP4MP_MESSAGE_AS4 is used for the messages received from the peer and BGP4MP_MESSAGE_AS4_LOCAL for the
+// messages sent to the peer.
+const (
+	MRTSubtypeBGP4MPMessageAS4      uint16 = 4
+	MRTSubtypeBGP4MPMessageAS4Local uint16 = 7
+)
+
+const (
+	MRTPeerTypeIPv6 uint8 = 0x01
+	MRTPeerTypeAS4  uint8 = 0x02
+)
+
+type MRTHeader struct {
+	Timestamp time.Time
+	Type      uint16
+	Subtype   uint16
+	Length    uint32
+}
+
+func (header *MRTHeader) Encode() ([]byte, error) {
+	pkt := make([]byte, MRTHeaderLen)
+	binary.BigEndian.PutUint32(pkt[0:4], uint32(header.Timestamp.Unix()))
+	binary.BigEndian.PutUint16(pkt[4:6], header.Type)
+	binary.BigEndian.PutUint16(pkt[6:8], header.Subtype)
+	binary.BigEndian.PutUint32(pkt[8:12], header.Length)
+	return pkt, nil
+}
+
+func (header *MRTHeader) Decode(pkt []byte) error {
+	if len(pkt) < MRTHeaderLen {
+		return errors.New(fmt.Sprintf("MRT header needs %d bytes, only %d bytes available", MRTHeaderLen,
+			len(pkt)))
+	}
+
+	header.Timestamp = time.Unix(int64(binary.BigEndian.Uint32(pkt[0:4])), 0)
+	header.Type = binary.BigEndian.Uint16(pkt[4:6])
+	header.Subtype = binary.BigEndian.Uint16(pkt[6:8])
+	header.Length = binary.BigEndian.Uint32(pkt[8:12])
+	return nil
+}
+
+func encodeMRTAddress(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		pkt := make([]byte, net.IPv4len)
+		copy(pkt, ip4)
+		return pkt
+	}
+
+	pkt := make([]byte, net.IPv6len)
+	copy(pkt, ip.To16())
+	return pkt
+}
+
+func decodeMRTAddress(pkt []byte, isIPv6 bool) (net.IP, int, error) {
+	ipLen := net.IPv4len
+	if isIPv6 {
+		ipLen = net.IPv6len
+	}
+
+	if len(pkt) < ipLen {
+		return nil, 0, errors.New(fmt.Sprintf("MRT address needs %d bytes, only %d bytes available", ipLen,
+			len(pkt)))
+	}
+
+	ip := make(net.IP, ipLen)
+	copy(ip, pkt[:ipLen])
+	return ip, ipLen, nil
+}
+
+// encodeMRTPathAttrs encodes the path attributes of a RIB entry. The MP_REACH_NLRI attribute is encoded with
+// only the next hop length and the next hop address as described in RFC 6396 section 4.3.4.
+func encodeMRTPathAttrs(pathAttrs []BGPPathAttr) ([]byte, error) {
+	pkt := make([]byte, 0)
+	for _, pa := range pathAttrs {
+		if mpReach, ok := pa.(*BGPPathAttrMPReachNLRI); ok {
+			nextHop := make([]byte, mpReach.NextHop.Len())
+			if err := mpReach.NextHop.Encode(nextHop); err != nil {
+				return nil, err
+			}
+
+			paHeader := make([]byte, 4)
+			paHeader[0] = uint8(BGPPathAttrFlagOptional | BGPPathAttrFlagExtendedLen)
+			paHeader[1] = uint8(BGPPathAttrTypeMPReachNLRI)
+			binary.BigEndian.PutUint16(paHeader[2:4], uint16(len(nextHop)))
+			pkt = append(pkt, paHeader...)
+			pkt = append(pkt, nextHop...)
+			continue
+		}
+
+		if _, ok := pa.(*BGPPathAttrMPUnreachNLRI); ok {
+			continue
+		}
+
+		bytes, err := pa.Encode()
+		if err != nil {
+			return nil, err
+		}
+		pkt = append(pkt, bytes...)
+	}
+	return pkt, nil
+}
+
+// decodeMRTMPReachNLRI decodes the MP_REACH_NLRI attribute of a RIB entry and returns the number of bytes
+// consumed. Both the abbreviated form and the full attribute are accepted.
+func decodeMRTMPReachNLRI(pkt []byte, afi AFI, data interface{}) (BGPPathAttr, int, error) {
+	base := BGPPathAttrBase{}
+	if err := base.Decode(pkt, data); err != nil {
+		return nil, 0, err
+	}
+
+	idx := int(base.BGPPathAttrLen)
+	if base.Length == 0 || int(pkt[idx]) != int(base.Length)-1 {
+		mpReach := NewBGPPathAttrMPReachNLRI()
+		err := mpReach.Decode(pkt, data)
+		return mpReach, int(base.TotalLen()), err
+	}
+
+	nextHop := BGPGetMPNextHop(afi, SafiUnicast)
+	if err := nextHop.Decode(pkt[idx:]); err != nil {
+		return nil, 0, err
+	}
+
+	mpReach := NewBGPPathAttrMPReachNLRI()
+	mpReach.AFI = afi
+	mpReach.SAFI = SafiUnicast
+	mpReach.SetNextHop(nextHop)
+	return mpReach, int(base.TotalLen()), nil
+}
+
+func decodeMRTPathAttrs(pkt []byte, afi AFI) ([]BGPPathAttr, error) {
+	data := BGPPeerAttrs{ASSize: 4, AddPathFamily: make(map[AFI]map[SAFI]uint8)}
+	pathAttrs := make([]BGPPathAttr, 0)
+	for len(pkt) > 0 {
+		if len(pkt) < 3 {
+			return nil, errors.New(fmt.Sprintf("MRT path attribute needs at least 3 bytes, only %d bytes available",
+				len(pkt)))
+		}
+
+		var pa BGPPathAttr
+		var length int
+		var err error
+		if BGPPathAttrType(pkt[1]) == BGPPathAttrTypeMPReachNLRI {
+			pa, length, err = decodeMRTMPReachNLRI(pkt, afi, data)
+		} else {
+			pa = BGPGetPathAttr(pkt)
+			err = pa.Decode(pkt, data)
+			length = int(pa.TotalLen())
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pathAttrs = append(pathAttrs, pa)
+		pkt = pkt[length:]
+	}
+	return pathAttrs, nil
+}
+
+type MRTBody interface {
+	Encode() ([]byte, error)
+	Decode(pkt []byte, header *MRTHeader) error
+}
+
+type MRTPeerEntry struct {
+	BGPId   net.IP
+	Address net.IP
+	AS      uint32
+}
+
+type MRTPeerIndexTable struct {
+	CollectorBGPId net.IP
+	ViewName       string
+	Peers          []MRTPeerEntry
+}
+
+func (t *MRTPeerIndexTable) Encode() ([]byte, error) {
+	pkt := make([]byte, 6)
+	copy(pkt[0:4], encodeMRTAddress(t.CollectorBGPId))
+	binary.BigEndian.PutUint16(pkt[4:6], uint16(len(t.ViewName)))
+	pkt = append(pkt, []byte(t.ViewName)...)
+
+	count := make([]byte, 2)
+	binary.BigEndian.PutUint16(count, uint16(len(t.Peers)))
+	pkt = append(pkt, count...)
+	for _, peer := range t.Peers {
+		peerType := MRTPeerTypeAS4
+		if peer.Address.To4() == nil {
+			peerType |= MRTPeerTypeIPv6
+		}
+		pkt = append(pkt, peerType)
+		pkt = append(pkt, encodeMRTAddress(peer.BGPId)...)
+		pkt = append(pkt, encodeMRTAddress(peer.Address)...)
+		as := make([]byte, 4)
+		binary.BigEndian.PutUint32(as, peer.AS)
+		pkt = append(pkt, as...)
+	}
+	return pkt, nil
+}
+
+func (t *MRTPeerIndexTable) Decode(pkt []byte, header *MRTHeader) error {
+	if len(pkt) < 6 {
+		return errors.New("MRT peer index table is too short")
+	}
+
+	t.CollectorBGPId, _, _ = decodeMRTAddress(pkt[0:4], false)
+	viewNameLen := int(binary.BigEndian.Uint16(pkt[4:6]))
+	if len(pkt) < 8+viewNameLen {
+		return errors.New(fmt.Sprintf("MRT peer index table view name length %d is not valid", viewNameLen))
+	}
+	t.ViewName = string(pkt[6 : 6+viewNameLen])
+	pkt = pkt[6+viewNameLen:]
+
+	count := int(binary.BigEndian.Uint16(pkt[0:2]))
+	pkt = pkt[2:]
+	t.Peers = make([]MRTPeerEntry, 0, count)
+	for i := 0; i < count; i++ {
+		if len(pkt) < 1 {
+			return errors.New(fmt.Sprintf("MRT peer index table has only %d of %d peers", i, count))
+		}
+
+		var peer MRTPeerEntry
+		var length int
+		var err error
+		peerType := pkt[0]
+		pkt = pkt[1:]
+		if peer.BGPId, length, err = decodeMRTAddress(pkt, false); err != nil {
+			return err
+		}
+		pkt = pkt[length:]
+
+		if peer.Address, length, err = decodeMRTAddress(pkt, peerType&MRTPeerTypeIPv6 != 0); err != nil {
+			return err
+		}
+		pkt = pkt[length:]
+
+		if peerType&MRTPeerTypeAS4 != 0 {
+			if len(pkt) < 4 {
+				return errors.New("MRT peer entry does not have the AS number")
+			}
+			peer.AS = binary.BigEndian.Uint32(pkt[0:4])
+			pkt = pkt[4:]
+		} else {
+			if len(pkt) < 2 {
+				return errors.New("MRT peer entry does not have the AS number")
+			}
+			peer.AS = uint32(binary.BigEndian.Uint16(pkt[0:2]))
+			pkt = pkt[2:]
+		}
+		t.Peers = append(t.Peers, peer)
+	}
+	return nil
+}
+
+type MRTRIBEntry struct {
+	PeerIndex      uint16
+	OriginatedTime time.Time
+	PathAttrs      []BGPPathAttr
+}
+
+type MRTRIB struct {
+	AFI            AFI
+	SequenceNumber uint32
+	Prefix         *IPPrefix
+	Entries        []MRTRIBEntry
+}
+
+func (r *MRTRIB) Encode() ([]byte, error) {
+	pkt := make([]byte, 4)
+	binary.BigEndian.PutUint32(pkt[0:4], r.SequenceNumber)
+
+	prefix, err := r.Prefix.Encode(r.AFI)
+	if err != nil {
+		return nil, err
+	}
+	pkt = append(pkt, prefix...)
+
+	count := make([]byte, 2)
+	binary.BigEndian.PutUint16(count, uint16(len(r.Entries)))
+	pkt = append(pkt, count...)
+	for _, entry := range r.Entries {
+		pathAttrs, err := encodeMRTPathAttrs(entry.PathAttrs)
+		if err != nil {
+			return nil, err
+		}
+
+		entryHeader := make([]byte, MRTRIBEntryHeaderLen)
+		binary.BigEndian.PutUint16(entryHeader[0:2], entry.PeerIndex)
+		binary.BigEndian.PutUint32(entryHeader[2:6], uint32(entry.OriginatedTime.Unix()))
+		binary.BigEndian.PutUint16(entryHeader[6:8], uint16(len(pathAttrs)))
+		pkt = append(pkt, entryHeader...)
+		pkt = append(pkt, pathAttrs...)
+	}
+	return pkt, nil
+}
+
+func (r *MRTRIB) Decode(pkt []byte, header *MRTHeader) error {
+	r.AFI = AfiIP
+	if header.Subtype == MRTSubtypeRIBIPv6Unicast {
+		r.AFI = AfiIP6
+	}
+
+	if len(pkt) < 5 {
+		return errors.New("MRT RIB message is too short")
+	}
+	r.SequenceNumber = binary.BigEndian.Uint32(pkt[0:4])
+	pkt = pkt[4:]
+
+	r.Prefix = &IPPrefix{}
+	if err := r.Prefix.Decode(pkt, r.AFI); err != nil {
+		return err
+	}
+	pkt = pkt[r.Prefix.Len():]
+
+	if len(pkt) < 2 {
+		return errors.New("MRT RIB message does not have the entry count")
+	}
+	count := int(binary.BigEndian.Uint16(pkt[0:2]))
+	pkt = pkt[2:]
+	r.Entries = make([]MRTRIBEntry, 0, count)
+	for i := 0; i < count; i++ {
+		if len(pkt) < MRTRIBEntryHeaderLen {
+			return errors.New(fmt.Sprintf("MRT RIB message has only %d of %d entries", i, count))
+		}
+
+		var entry MRTRIBEntry
+		entry.PeerIndex = binary.BigEndian.Uint16(pkt[0:2])
+		entry.OriginatedTime = time.Unix(int64(binary.BigEndian.Uint32(pkt[2:6])), 0)
+		length := int(binary.BigEndian.Uint16(pkt[6:8]))
+		pkt = pkt[MRTRIBEntryHeaderLen:]
+		if len(pkt) < length {
+			return errors.New(fmt.Sprintf("MRT RIB entry attribute length %d is more than %d bytes available",
+				length, len(pkt)))
+		}
+
+		var err error
+		if entry.PathAttrs, err = decodeMRTPathAttrs(pkt[:length], r.AFI); err != nil {
+			return err
+		}
+		pkt = pkt[length:]
+		r.Entries = append(r.Entries, entry)
+	}
+	return nil
+}
+
+// GetBGPMessages returns an Update message that advertises the prefix for every entry of the RIB.
+func (r *MRTRIB) GetBGPMessages() []*BGPMessage {
+	msgs := make([]*BGPMessage, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		pathAttrs := make([]BGPPathAttr, 0, len(entry.PathAttrs))
+		var mpReach *BGPPathAttrMPReachNLRI
+		for _, pa := range entry.PathAttrs {
+			if reach, ok := pa.(*BGPPathAttrMPReachNLRI); ok {
+				mpReach = reach
+				continue
+			}
+			pathAttrs = append(pathAttrs, pa)
+		}
+
+		nlri := []NLRI{r.Prefix.Clone()}
+		if r.AFI == AfiIP && mpReach == nil {
+			msgs = append(msgs, NewBGPUpdateMessage(make([]NLRI, 0), pathAttrs, nlri))
+			continue
+		}
+
+		if mpReach == nil {
+			continue
+		}
+		pathAttrs = append(pathAttrs, CloneMPReachNLRIWithNewNLRI(mpReach, nlri))
+		msgs = append(msgs, NewBGPUpdateMessage(make([]NLRI, 0), pathAttrs, make([]NLRI, 0)))
+	}
+	return msgs
+}
+
+type MRTBGP4MP struct {
+	PeerAS  uint32
+	LocalAS uint32
+	IfIndex uint16
+	PeerIP  net.IP
+	LocalIP net.IP
+	Data    []byte
+	Message *BGPMessage
+}
+
+func (b *MRTBGP4MP) Encode() ([]byte, error) {
+	pkt := make([]byte, 12)
+	binary.BigEndian.PutUint32(pkt[0:4], b.PeerAS)
+	binary.BigEndian.PutUint32(pkt[4:8], b.LocalAS)
+	binary.BigEndian.PutUint16(pkt[8:10], b.IfIndex)
+	afi := AfiIP
+	if b.PeerIP.To4() == nil {
+		afi = AfiIP6
+	}
+	binary.BigEndian.PutUint16(pkt[10:12], uint16(afi))
+	pkt = append(pkt, encodeMRTAddress(b.PeerIP)...)
+	if afi == AfiIP {
+		pkt = append(pkt, encodeMRTAddress(b.LocalIP)...)
+	} else {
+		localIP := make([]byte, net.IPv6len)
+		copy(localIP, b.LocalIP.To16())
+		pkt = append(pkt, localIP...)
+	}
+
+	data := b.Data
+	if data == nil {
+		var err error
+		if data, err = encodeBGPPDU(b.Message); err != nil {
+			return nil, err
+		}
+	}
+	return append(pkt, data...), nil
+}
+
+func (b *MRTBGP4MP) Decode(pkt []byte, header *MRTHeader) error {
+	if len(pkt) < 12 {
+		return errors.New("MRT BGP4MP message is too short")
+	}
+
+	b.PeerAS = binary.BigEndian.Uint32(pkt[0:4])
+	b.LocalAS = binary.BigEndian.Uint32(pkt[4:8])
+	b.IfIndex = binary.BigEndian.Uint16(pkt[8:10])
+	isIPv6 := AFI(binary.BigEndian.Uint16(pkt[10:12])) == AfiIP6
+	pkt = pkt[12:]
+
+	var length int
+	var err error
+	if b.PeerIP, length, err = decodeMRTAddress(pkt, isIPv6); err != nil {
+		return err
+	}
+	pkt = pkt[length:]
+	if b.LocalIP, length, err = decodeMRTAddress(pkt, isIPv6); err != nil {
+		return err
+	}
+	pkt = pkt[length:]
+
+	b.Data = make([]byte, len(pkt))
+	copy(b.Data, pkt)
+	b.Message, _, err = decodeBGPPDU(b.Data)
+	return err
+}
+
+type MRTMessage struct {
+	Header MRTHeader
+	Body   MRTBody
+}
+
+func NewMRTMessage() *MRTMessage {
+	return &MRTMessage{}
+}
+
+func (msg *MRTMessage) Encode() ([]byte, error) {
+	body, err := msg.Body.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	msg.Header.Length = uint32(len(body))
+	header, err := msg.Header.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// Decode decodes a complete MRT message. The header is decoded again from pkt.
+func (msg *MRTMessage) Decode(pkt []byte) error {
+	if err := msg.Header.Decode(pkt); err != nil {
+		return err
+	}
+
+	if uint32(len(pkt)) < MRTHeaderLen+msg.Header.Length {
+		return errors.New(fmt.Sprintf("MRT message length %d is more than %d bytes available", msg.Header.Length,
+			len(pkt)-MRTHeaderLen))
+	}
+	pkt = pkt[MRTHeaderLen : MRTHeaderLen+msg.Header.Length]
+
+	switch {
+	case msg.Header.Type == MRTTypeTableDumpV2 && msg.Header.Subtype == MRTSubtypePeerIndexTable:
+		msg.Body = &MRTPeerIndexTable{}
+
+	case msg.Header.Type == MRTTypeTableDumpV2 && (msg.Header.Subtype == MRTSubtypeRIBIPv4Unicast ||
+		msg.Header.Subtype == MRTSubtypeRIBIPv6Unicast):
+		msg.Body = &MRTRIB{}
+
+	case msg.Header.Type == MRTTypeBGP4MP && (msg.Header.Subtype == MRTSubtypeBGP4MPMessageAS4 ||
+		msg.Header.Subtype == MRTSubtypeBGP4MPMessageAS4Local):
+		msg.Body = &MRTBGP4MP{}
+
+	default:
+		return errors.New(fmt.Sprintf("MRT message type %d subtype %d is not supported", msg.Header.Type,
+			msg.Header.Subtype))
+	}
+	return msg.Body.Decode(pkt, &msg.Header)
+}
+
+// GetBGPMessages returns the BGP messages in a BGP4MP or a RIB message.
+func (msg *MRTMessage) GetBGPMessages() []*BGPMessage {
+	switch body := msg.Body.(type) {
+	case *MRTBGP4MP:
+		if body.Message != nil {
+			return []*BGPMessage{body.Message}
+		}
+
+	case *MRTRIB:
+		return body.GetBGPMessages()
+	}
+	return nil
+}
+
+func NewMRTPeerIndexTableMessage(timestamp time.Time, collectorBGPId net.IP, viewName string,
+	peers []MRTPeerEntry) *MRTMessage {
+	return &MRTMessage{
+		Header: MRTHeader{Timestamp: timestamp, Type: MRTTypeTableDumpV2, Subtype: MRTSubtypePeerIndexTable},
+		Body:   &MRTPeerIndexTable{collectorBGPId, viewName, peers},
+	}
+}
+
+func NewMRTRIBMessage(timestamp time.Time, afi AFI, sequenceNumber uint32, prefix *IPPrefix,
+	entries []MRTRIBEntry) *MRTMessage {
+	subtype := MRTSubtypeRIBIPv4Unicast
+	if afi == AfiIP6 {
+		subtype = MRTSubtypeRIBIPv6Unicast
+	}
+	return &MRTMessage{
+		Header: MRTHeader{Timestamp: timestamp, Type: MRTTypeTableDumpV2, Subtype: subtype},
+		Body:   &MRTRIB{afi, sequenceNumber, prefix, entries},
+	}
+}
+
+// NewMRTBGP4MPMessage constructs a BGP4MP message for the BGP message pkt. local is true if the message was
+// sent to the peer.
+func NewMRTBGP4MPMessage(timestamp time.Time, peerAS, localAS uint32, ifIndex uint16, peerIP, localIP net.IP,
+	pkt []byte, local bool) *MRTMessage {
+	subtype := MRTSubtypeBGP4MPMessageAS4
+	if local {
+		subtype = MRTSubtypeBGP4MPMessageAS4Local
+	}
+	return &MRTMessage{
+		Header: MRTHeader{Timestamp: timestamp, Type: MRTTypeBGP4MP, Subtype: subtype},
+		Body:   &MRTBGP4MP{PeerAS: peerAS, LocalAS: localAS, IfIndex: ifIndex, PeerIP: peerIP, LocalIP: localIP, Data: pkt},
+	}
+}
+
+// MRTReader reads the MRT messages from a dump.
+type MRTReader struct {
+	reader io.Reader
+}
+
+func NewMRTReader(reader io.Reader) *MRTReader {
+	return &MRTReader{reader}
+}
+
+// Next returns the next message of the dump. It returns io.EOF at the end of the dump.
+func (r *MRTReader) Next() (*MRTMessage, error) {
+	header := MRTHeader{}
+	pkt := make([]byte, MRTHeaderLen)
+	if _, err := io.ReadFull(r.reader, pkt); err != nil {
+		return nil, err
+	}
+	header.Decode(pkt)
+
+	body := make([]byte, header.Length)
+	if _, err := io.ReadFull(r.reader, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	msg := NewMRTMessage()
+	err := msg.Decode(append(pkt, body...))
+	return msg, err
+}
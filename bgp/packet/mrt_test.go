@@ -0,0 +1,227 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// mrt_test.go
+package packet
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func constructMRTTestPathAttrs(peerAS uint32, nextHopIP string) []BGPPathAttr {
+	pa := make([]BGPPathAttr, 0)
+	pa = append(pa, NewBGPPathAttrOrigin(BGPPathAttrOriginIncomplete))
+	asPathSeq := NewBGPAS4PathSegmentSeq()
+	asPathSeq.AppendAS(peerAS)
+	asPath := NewBGPPathAttrASPath()
+	asPath.AppendASPathSegment(asPathSeq)
+	pa = append(pa, asPath)
+	if nextHopIP != "" {
+		nextHop := NewBGPPathAttrNextHop()
+		nextHop.Value = net.ParseIP(nextHopIP)
+		pa = append(pa, nextHop)
+	}
+	return pa
+}
+
+func encodeDecodeMRTMessage(t *testing.T, msg *MRTMessage) *MRTMessage {
+	pkt, err := msg.Encode()
+	if err != nil {
+		t.Fatal("MRT message encode failed with error:", err)
+	}
+
+	if int(msg.Header.Length)+MRTHeaderLen != len(pkt) {
+		t.Fatalf("MRT message length %d, encoded %d bytes", msg.Header.Length, len(pkt))
+	}
+
+	rxMsg := NewMRTMessage()
+	if err = rxMsg.Decode(pkt); err != nil {
+		t.Fatal("MRT message decode failed with error:", err)
+	}
+
+	if rxMsg.Header.Type != msg.Header.Type || rxMsg.Header.Subtype != msg.Header.Subtype ||
+		rxMsg.Header.Timestamp.Unix() != msg.Header.Timestamp.Unix() {
+		t.Fatalf("MRT message header %+v decoded as %+v", msg.Header, rxMsg.Header)
+	}
+	return rxMsg
+}
+
+func TestMRTPeerIndexTableEncodeDecode(t *testing.T) {
+	peers := []MRTPeerEntry{
+		{net.ParseIP("10.1.1.1"), net.ParseIP("0.0.0.0"), 65001},
+		{net.ParseIP("10.1.1.2"), net.ParseIP("10.1.1.2"), 65002},
+		{net.ParseIP("10.1.1.3"), net.ParseIP("2001::3"), 4200000000},
+	}
+	msg := NewMRTPeerIndexTableMessage(time.Now(), net.ParseIP("10.1.1.1"), "default", peers)
+	rxMsg := encodeDecodeMRTMessage(t, msg)
+
+	table := rxMsg.Body.(*MRTPeerIndexTable)
+	if !table.CollectorBGPId.Equal(net.ParseIP("10.1.1.1")) || table.ViewName != "default" ||
+		len(table.Peers) != len(peers) {
+		t.Fatalf("MRT peer index table decoded to %+v", table)
+	}
+
+	for idx, peer := range peers {
+		if !table.Peers[idx].BGPId.Equal(peer.BGPId) || !table.Peers[idx].Address.Equal(peer.Address) ||
+			table.Peers[idx].AS != peer.AS {
+			t.Fatalf("MRT peer entry %+v decoded to %+v", peer, table.Peers[idx])
+		}
+	}
+}
+
+func TestMRTRIBIPv4EncodeDecode(t *testing.T) {
+	entries := []MRTRIBEntry{
+		{0, time.Now(), constructMRTTestPathAttrs(65002, "10.1.1.2")},
+		{1, time.Now(), constructMRTTestPathAttrs(65003, "10.1.1.3")},
+	}
+	msg := NewMRTRIBMessage(time.Now(), AfiIP, 5, NewIPPrefix(net.ParseIP("20.1.0.0"), 16), entries)
+	rxMsg := encodeDecodeMRTMessage(t, msg)
+
+	rib := rxMsg.Body.(*MRTRIB)
+	if rib.AFI != AfiIP || rib.SequenceNumber != 5 || rib.Prefix.Length != 16 ||
+		!rib.Prefix.Prefix.Equal(net.ParseIP("20.1.0.0")) || len(rib.Entries) != 2 {
+		t.Fatalf("MRT IPv4 RIB message decoded to %+v", rib)
+	}
+
+	if rib.Entries[1].PeerIndex != 1 || len(rib.Entries[1].PathAttrs) != 3 {
+		t.Fatalf("MRT IPv4 RIB entry decoded to %+v", rib.Entries[1])
+	}
+
+	updates := rxMsg.GetBGPMessages()
+	if len(updates) != 2 {
+		t.Fatalf("MRT IPv4 RIB message converted to %d UPDATE messages, expected 2", len(updates))
+	}
+
+	update := updates[0].Body.(*BGPUpdate)
+	if len(update.NLRI) != 1 || update.NLRI[0].GetPrefix().String() != "20.1.0.0" ||
+		GetNumASes(update.PathAttributes) != 1 {
+		t.Fatalf("MRT IPv4 RIB entry converted to UPDATE %+v", update)
+	}
+}
+
+func TestMRTRIBIPv6EncodeDecode(t *testing.T) {
+	pa := constructMRTTestPathAttrs(65002, "")
+	pa = append(pa, ConstructIPv6MPReachNLRI(GetProtocolFamily(AfiIP6, SafiUnicast), net.ParseIP("2001::2"), nil,
+		make([]NLRI, 0)))
+	entries := []MRTRIBEntry{{0, time.Now(), pa}}
+	msg := NewMRTRIBMessage(time.Now(), AfiIP6, 7, NewIPPrefix(net.ParseIP("3001::"), 64), entries)
+	rxMsg := encodeDecodeMRTMessage(t, msg)
+
+	rib := rxMsg.Body.(*MRTRIB)
+	if rib.AFI != AfiIP6 || rib.Prefix.Length != 64 || len(rib.Entries) != 1 ||
+		len(rib.Entries[0].PathAttrs) != 3 {
+		t.Fatalf("MRT IPv6 RIB message decoded to %+v", rib)
+	}
+
+	mpReach, _ := GetMPAttrs(rib.Entries[0].PathAttrs)
+	if mpReach == nil || mpReach.AFI != AfiIP6 || !mpReach.NextHop.GetNextHop().Equal(net.ParseIP("2001::2")) {
+		t.Fatalf("MRT IPv6 RIB entry MP_REACH_NLRI decoded to %+v", mpReach)
+	}
+
+	updates := rxMsg.GetBGPMessages()
+	if len(updates) != 1 {
+		t.Fatalf("MRT IPv6 RIB message converted to %d UPDATE messages, expected 1", len(updates))
+	}
+
+	pkt, err := updates[0].Encode()
+	if err != nil {
+		t.Fatal("UPDATE message converted from MRT IPv6 RIB entry failed to encode with error:", err)
+	}
+	rxUpdate, _, err := decodeBGPPDU(pkt)
+	if err != nil {
+		t.Fatal("UPDATE message converted from MRT IPv6 RIB entry failed to decode with error:", err)
+	}
+
+	mpReach, _ = GetMPAttrs(rxUpdate.Body.(*BGPUpdate).PathAttributes)
+	if mpReach == nil || len(mpReach.NLRI) != 1 || mpReach.NLRI[0].GetPrefix().String() != "3001::" {
+		t.Fatalf("MRT IPv6 RIB entry converted to MP_REACH_NLRI %+v", mpReach)
+	}
+}
+
+func TestMRTBGP4MPEncodeDecode(t *testing.T) {
+	pkt, _ := NewBGPKeepAliveMessage().Encode()
+	msg := NewMRTBGP4MPMessage(time.Now(), 65002, 65001, 0, net.ParseIP("2001::2"), net.ParseIP("2001::1"), pkt,
+		true)
+	rxMsg := encodeDecodeMRTMessage(t, msg)
+	if rxMsg.Header.Subtype != MRTSubtypeBGP4MPMessageAS4Local {
+		t.Fatalf("MRT BGP4MP message subtype %d, expected %d", rxMsg.Header.Subtype,
+			MRTSubtypeBGP4MPMessageAS4Local)
+	}
+
+	body := rxMsg.Body.(*MRTBGP4MP)
+	if body.PeerAS != 65002 || body.LocalAS != 65001 || !body.PeerIP.Equal(net.ParseIP("2001::2")) ||
+		!body.LocalIP.Equal(net.ParseIP("2001::1")) || !bytes.Equal(body.Data, pkt) {
+		t.Fatalf("MRT BGP4MP message decoded to %+v", body)
+	}
+
+	msgs := rxMsg.GetBGPMessages()
+	if len(msgs) != 1 || msgs[0].Header.Type != BGPMsgTypeKeepAlive {
+		t.Fatalf("MRT BGP4MP message converted to BGP messages %+v", msgs)
+	}
+}
+
+func TestMRTReader(t *testing.T) {
+	buf := new(bytes.Buffer)
+	peers := []MRTPeerEntry{{net.ParseIP("10.1.1.2"), net.ParseIP("10.1.1.2"), 65002}}
+	entries := []MRTRIBEntry{{0, time.Now(), constructMRTTestPathAttrs(65002, "10.1.1.2")}}
+	keepAlive, _ := NewBGPKeepAliveMessage().Encode()
+	msgs := []*MRTMessage{
+		NewMRTPeerIndexTableMessage(time.Now(), net.ParseIP("10.1.1.1"), "", peers),
+		NewMRTRIBMessage(time.Now(), AfiIP, 0, NewIPPrefix(net.ParseIP("20.1.0.0"), 16), entries),
+		NewMRTBGP4MPMessage(time.Now(), 65002, 65001, 0, net.ParseIP("10.1.1.2"), net.ParseIP("10.1.1.1"),
+			keepAlive, false),
+	}
+	for _, msg := range msgs {
+		pkt, err := msg.Encode()
+		if err != nil {
+			t.Fatal("MRT message encode failed with error:", err)
+		}
+		buf.Write(pkt)
+	}
+
+	reader := NewMRTReader(buf)
+	for idx, msg := range msgs {
+		rxMsg, err := reader.Next()
+		if err != nil {
+			t.Fatal("MRT reader failed to read message", idx, "with error:", err)
+		}
+
+		if rxMsg.Header.Type != msg.Header.Type || rxMsg.Header.Subtype != msg.Header.Subtype {
+			t.Fatalf("MRT reader read message %d as %+v, expected %+v", idx, rxMsg.Header, msg.Header)
+		}
+	}
+
+	if _, err := reader.Next(); err != io.EOF {
+		t.Fatal("MRT reader did not return EOF at the end of the dump, error:", err)
+	}
+
+	pkt, _ := msgs[2].Encode()
+	reader = NewMRTReader(bytes.NewReader(pkt[:len(pkt)-2]))
+	if _, err := reader.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatal("MRT reader did not fail for a truncated dump, error:", err)
+	}
+}
@@ -0,0 +1,406 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// vpn.go
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Route distinguisher types, RFC 4364
+const (
+	RouteDistinguisherTypeTwoOctetAS  uint16 = 0
+	RouteDistinguisherTypeIPv4Addr    uint16 = 1
+	RouteDistinguisherTypeFourOctetAS uint16 = 2
+)
+
+const (
+	RouteDistinguisherLen = 8
+	MPLSLabelLen          = 3
+)
+
+const (
+	MPLSLabelMax         uint32 = 0xFFFFF
+	MPLSLabelBottomStack uint32 = 0x1
+	// Label field of the withdrawn routes, RFC 3107
+	MPLSLabelWithdraw uint32 = 0x800000
+)
+
+type RouteDistinguisher uint64
+
+func (r RouteDistinguisher) Type() uint16 {
+	return uint16(r >> 48)
+}
+
+func (r RouteDistinguisher) String() string {
+	switch r.Type() {
+	case RouteDistinguisherTypeTwoOctetAS:
+		return fmt.Sprintf("%d:%d", uint16(r>>32), uint32(r))
+	case RouteDistinguisherTypeIPv4Addr:
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, uint32(r>>16))
+		return fmt.Sprintf("%s:%d", ip.String(), uint16(r))
+	case RouteDistinguisherTypeFourOctetAS:
+		return fmt.Sprintf("%d:%d", uint32(r>>16), uint16(r))
+	}
+	return fmt.Sprintf("0x%016x", uint64(r))
+}
+
+func NewRouteDistinguisher(rdType uint16, admin uint32, assigned uint32) RouteDistinguisher {
+	val := uint64(rdType) << 48
+	switch rdType {
+	case RouteDistinguisherTypeTwoOctetAS:
+		val |= uint64(uint16(admin))<<32 | uint64(assigned)
+	default:
+		val |= uint64(admin)<<16 | uint64(uint16(assigned))
+	}
+	return RouteDistinguisher(val)
+}
+
+// ParseRouteDistinguisher accepts <admin>:<value> where admin is a 2 byte AS, a 4 byte AS or an IPv4 address
+func ParseRouteDistinguisher(str string) (RouteDistinguisher, error) {
+	str = strings.TrimSpace(str)
+	idx := strings.LastIndex(str, ":")
+	if idx <= 0 {
+		return 0, errors.New(fmt.Sprintf("Invalid route distinguisher %s", str))
+	}
+
+	assigned, err := strconv.ParseUint(str[idx+1:], 10, 32)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid value %s in route distinguisher %s", str[idx+1:], str))
+	}
+
+	if ip := net.ParseIP(str[:idx]); ip != nil {
+		if ip = ip.To4(); ip == nil || assigned > 0xFFFF {
+			return 0, errors.New(fmt.Sprintf("Invalid IPv4 route distinguisher %s", str))
+		}
+		return NewRouteDistinguisher(RouteDistinguisherTypeIPv4Addr, binary.BigEndian.Uint32(ip),
+			uint32(assigned)), nil
+	}
+
+	admin, err := strconv.ParseUint(str[:idx], 10, 32)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Invalid admin %s in route distinguisher %s", str[:idx], str))
+	}
+
+	if admin > 0xFFFF {
+		if assigned > 0xFFFF {
+			return 0, errors.New(fmt.Sprintf("Invalid value %s in route distinguisher %s", str[idx+1:], str))
+		}
+		return NewRouteDistinguisher(RouteDistinguisherTypeFourOctetAS, uint32(admin), uint32(assigned)), nil
+	}
+	return NewRouteDistinguisher(RouteDistinguisherTypeTwoOctetAS, uint32(admin), uint32(assigned)), nil
+}
+
+// ParseRouteTarget accepts <admin>:<value> or rt:<admin>:<value> and returns the route target extended community
+func ParseRouteTarget(str string) (BGPExtCommunity, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	if !strings.HasPrefix(str, "rt:") {
+		str = "rt:" + str
+	}
+	return ParseExtCommunity(str)
+}
+
+func IsRouteTarget(comm BGPExtCommunity) bool {
+	switch comm.Type() {
+	case BGPExtCommunityTypeTwoOctetAS, BGPExtCommunityTypeIPv4Addr, BGPExtCommunityTypeFourOctetAS:
+		return comm.SubType() == BGPExtCommunitySubTypeRouteTarget
+	}
+	return false
+}
+
+// GetRouteTargets returns the route target extended communities in the path attributes
+func GetRouteTargets(pathAttrs []BGPPathAttr) []BGPExtCommunity {
+	rts := make([]BGPExtCommunity, 0)
+	for _, comm := range GetExtCommunities(pathAttrs) {
+		if IsRouteTarget(comm) {
+			rts = append(rts, comm)
+		}
+	}
+	return rts
+}
+
+// SetRouteTargets replaces the route targets in the EXTENDED COMMUNITIES attribute, the other extended
+// communities are retained
+func SetRouteTargets(pathAttrs []BGPPathAttr, rts []BGPExtCommunity) []BGPPathAttr {
+	comms := make([]BGPExtCommunity, 0)
+	for _, comm := range GetExtCommunities(pathAttrs) {
+		if !IsRouteTarget(comm) {
+			comms = append(comms, comm)
+		}
+	}
+	comms = append(comms, rts...)
+	return SetExtCommunities(pathAttrs, comms)
+}
+
+func IsVPNFamily(protoFamily uint32) bool {
+	_, safi := GetAfiSafi(protoFamily)
+	return safi == SafiMPLSVPN
+}
+
+// GetVPNFamily returns the VPN family that carries the routes of the unicast family
+func GetVPNFamily(protoFamily uint32) uint32 {
+	afi, _ := GetAfiSafi(protoFamily)
+	return GetProtocolFamily(afi, SafiMPLSVPN)
+}
+
+// GetUnicastFamily returns the unicast family of the routes carried in a VPN family
+func GetUnicastFamily(protoFamily uint32) uint32 {
+	afi, _ := GetAfiSafi(protoFamily)
+	return GetProtocolFamily(afi, SafiUnicast)
+}
+
+// VPNPrefix is the labeled VPN-IPv4 and VPN-IPv6 NLRI, RFC 4364 and RFC 4659
+type VPNPrefix struct {
+	*IPPrefix
+	Labels []uint32
+	RD     RouteDistinguisher
+}
+
+func (v *VPNPrefix) Clone() NLRI {
+	x := *v
+	prefix := v.IPPrefix.Clone()
+	x.IPPrefix = prefix.(*IPPrefix)
+	x.Labels = make([]uint32, len(v.Labels))
+	copy(x.Labels, v.Labels)
+	return &x
+}
+
+func (v *VPNPrefix) Len() uint32 {
+	return uint32(MPLSLabelLen*len(v.Labels)+RouteDistinguisherLen) + v.IPPrefix.Len()
+}
+
+func (v *VPNPrefix) Encode(afi AFI) ([]byte, error) {
+	if len(v.Labels) == 0 {
+		return nil, errors.New(fmt.Sprintf("VPN prefix %s does not have a label", v.GetCIDR()))
+	}
+
+	ipBytes, err := v.IPPrefix.Encode(afi)
+	if err != nil {
+		return nil, err
+	}
+
+	pkt := make([]byte, v.Len())
+	pkt[0] = uint8(MPLSLabelLen*8*len(v.Labels)+RouteDistinguisherLen*8) + v.Length
+	idx := 1
+	for i, label := range v.Labels {
+		val := label << 4
+		if label == MPLSLabelWithdraw {
+			val = label
+		} else if i == len(v.Labels)-1 {
+			val |= MPLSLabelBottomStack
+		}
+		pkt[idx] = uint8(val >> 16)
+		pkt[idx+1] = uint8(val >> 8)
+		pkt[idx+2] = uint8(val)
+		idx += MPLSLabelLen
+	}
+	binary.BigEndian.PutUint64(pkt[idx:idx+RouteDistinguisherLen], uint64(v.RD))
+	idx += RouteDistinguisherLen
+	copy(pkt[idx:], ipBytes[1:])
+	return pkt, nil
+}
+
+func (v *VPNPrefix) Decode(pkt []byte, afi AFI) error {
+	if len(pkt) < 1 {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil, "NLRI does not contain prefix lenght"}
+	}
+
+	bits := int(pkt[0])
+	idx := 1
+	v.Labels = make([]uint32, 0)
+	for {
+		if bits < MPLSLabelLen*8 || len(pkt) < idx+MPLSLabelLen {
+			return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil, "VPN NLRI label stack invalid"}
+		}
+		val := uint32(pkt[idx])<<16 | uint32(pkt[idx+1])<<8 | uint32(pkt[idx+2])
+		idx += MPLSLabelLen
+		bits -= MPLSLabelLen * 8
+		if val == MPLSLabelWithdraw {
+			v.Labels = append(v.Labels, val)
+			break
+		}
+		v.Labels = append(v.Labels, val>>4)
+		if val&MPLSLabelBottomStack != 0 {
+			break
+		}
+	}
+
+	if bits < RouteDistinguisherLen*8 || len(pkt) < idx+RouteDistinguisherLen {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil,
+			"VPN NLRI does not contain route distinguisher"}
+	}
+	v.RD = RouteDistinguisher(binary.BigEndian.Uint64(pkt[idx : idx+RouteDistinguisherLen]))
+	idx += RouteDistinguisherLen
+	bits -= RouteDistinguisherLen * 8
+
+	ipLen := (bits + 7) / 8
+	if len(pkt) < idx+ipLen {
+		return BGPMessageError{BGPUpdateMsgError, BGPInvalidNetworkField, nil, "Prefix length invalid"}
+	}
+	ipBytes := make([]byte, ipLen+1)
+	ipBytes[0] = uint8(bits)
+	copy(ipBytes[1:], pkt[idx:idx+ipLen])
+	v.IPPrefix = &IPPrefix{}
+	return v.IPPrefix.Decode(ipBytes, afi)
+}
+
+func (v *VPNPrefix) GetCIDR() string {
+	return v.RD.String() + ":" + v.IPPrefix.GetCIDR()
+}
+
+func (v *VPNPrefix) String() string {
+	return "{" + v.RD.String() + " " + v.IPPrefix.GetCIDR() + " label " + fmt.Sprint(v.Labels) + "}"
+}
+
+func NewVPNPrefix(rd RouteDistinguisher, labels []uint32, prefix *IPPrefix) *VPNPrefix {
+	return &VPNPrefix{
+		IPPrefix: prefix,
+		Labels:   labels,
+		RD:       rd,
+	}
+}
+
+// StripPathId returns the NLRI without the add path id. VPN and EVPN NLRIs are returned as is, the add path
+// capability is only advertised for the unicast families.
+func StripPathId(nlri NLRI) NLRI {
+	switch prefix := nlri.(type) {
+	case *VPNPrefix:
+		return prefix
+	case *EVPNPrefix:
+		return prefix
+	}
+	return nlri.GetIPPrefix()
+}
+
+// MPNextHopVPN is the next hop of the VPN families, the route distinguisher of the next hop is always 0
+type MPNextHopVPN struct {
+	Length uint8
+	RD     RouteDistinguisher
+	Value  net.IP
+}
+
+func (v *MPNextHopVPN) Clone() MPNextHop {
+	x := *v
+	x.Value = make(net.IP, len(v.Value), cap(v.Value))
+	copy(x.Value, v.Value)
+	return &x
+}
+
+func (v *MPNextHopVPN) Encode(pkt []byte) error {
+	pkt[0] = v.Length
+	if v.Length != 12 && v.Length != 24 {
+		return errors.New(fmt.Sprintf("Wrong VPN next hop len %d", v.Length))
+	}
+
+	ipLen := int(v.Length) - RouteDistinguisherLen
+	binary.BigEndian.PutUint64(pkt[1:1+RouteDistinguisherLen], uint64(v.RD))
+	copy(pkt[1+RouteDistinguisherLen:], v.Value[len(v.Value)-ipLen:])
+	return nil
+}
+
+func (v *MPNextHopVPN) Decode(pkt []byte) error {
+	v.Length = pkt[0]
+	if v.Length != 12 && v.Length != 24 && v.Length != 48 {
+		return errors.New(fmt.Sprintf("Wrong VPN next hop len %d", v.Length))
+	}
+
+	if len(pkt) < int(v.Length)+1 {
+		return errors.New(fmt.Sprintf("VPN next hop len %d is more than the available %d bytes", v.Length,
+			len(pkt)-1))
+	}
+
+	// The link local address that follows the global address of the VPN-IPv6 next hop is ignored
+	ipLen := net.IPv6len
+	if v.Length == 12 {
+		ipLen = net.IPv4len
+	}
+	v.RD = RouteDistinguisher(binary.BigEndian.Uint64(pkt[1 : 1+RouteDistinguisherLen]))
+	v.Value = make(net.IP, ipLen)
+	copy(v.Value, pkt[1+RouteDistinguisherLen:1+RouteDistinguisherLen+ipLen])
+	if ipLen == net.IPv4len {
+		v.Value = v.Value.To16()
+	}
+	return nil
+}
+
+func (v *MPNextHopVPN) Len() uint8 {
+	return v.Length + 1
+}
+
+func (v *MPNextHopVPN) New() MPNextHop {
+	return &MPNextHopVPN{}
+}
+
+func (v *MPNextHopVPN) String() string {
+	return fmt.Sprintf("{NEXTHOP %v:%v}", v.RD, v.Value)
+}
+
+func (v *MPNextHopVPN) GetNextHop() net.IP {
+	return v.Value
+}
+
+// SetNextHop sets the next hop for the address family. IPv4 next hops of the VPN-IPv6 routes are encoded as
+// IPv4 mapped IPv6 addresses, RFC 4659.
+func (v *MPNextHopVPN) SetNextHop(ip net.IP, afi AFI) error {
+	if ip.To16() == nil {
+		return errors.New(fmt.Sprintf("VPN next hop %s is not an IPv4 or IPv6 address", ip))
+	}
+
+	if afi == AfiIP {
+		if ip.To4() == nil {
+			return errors.New(fmt.Sprintf("VPN-IPv4 next hop %s is not an IPv4 address", ip))
+		}
+		v.Length = uint8(RouteDistinguisherLen + net.IPv4len)
+	} else {
+		v.Length = uint8(RouteDistinguisherLen + net.IPv6len)
+	}
+	v.RD = 0
+	v.Value = ip.To16()
+	return nil
+}
+
+func NewMPNextHopVPN() *MPNextHopVPN {
+	return &MPNextHopVPN{
+		Length: 0,
+		Value:  net.IP{},
+	}
+}
+
+func ConstructVPNMPReachNLRI(protoFamily uint32, nextHop net.IP, nlriList []NLRI) *BGPPathAttrMPReachNLRI {
+	afi, safi := GetAfiSafi(protoFamily)
+	mpReachNLRI := NewBGPPathAttrMPReachNLRI()
+	mpReachNLRI.AFI = afi
+	mpReachNLRI.SAFI = safi
+	mpNextHop := NewMPNextHopVPN()
+	mpNextHop.SetNextHop(nextHop, afi)
+	mpReachNLRI.SetNextHop(mpNextHop)
+	mpReachNLRI.SetNLRIList(nlriList)
+	return mpReachNLRI
+}
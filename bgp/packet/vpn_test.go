@@ -0,0 +1,146 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// vpn_test.go
+package packet
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRouteDistinguisher(t *testing.T) {
+	rds := []string{"65000:100", "10.1.1.1:20", "4200000000:30"}
+	rdTypes := []uint16{RouteDistinguisherTypeTwoOctetAS, RouteDistinguisherTypeIPv4Addr,
+		RouteDistinguisherTypeFourOctetAS}
+	for i, str := range rds {
+		rd, err := ParseRouteDistinguisher(str)
+		if err != nil {
+			t.Fatal("ParseRouteDistinguisher failed for", str, "with error:", err)
+		}
+		if rd.Type() != rdTypes[i] {
+			t.Fatal("ParseRouteDistinguisher for", str, "expected type", rdTypes[i], "got", rd.Type())
+		}
+		if rd.String() != str {
+			t.Fatal("RouteDistinguisher.String expected", str, "got", rd.String())
+		}
+	}
+
+	invalid := []string{"65000", "abc:1", "10.1.1.1:70000", "4200000000:70000", "2001::1:10"}
+	for _, str := range invalid {
+		if _, err := ParseRouteDistinguisher(str); err == nil {
+			t.Fatal("ParseRouteDistinguisher for", str, "expected failure, got NO error")
+		}
+	}
+}
+
+func TestRouteTargets(t *testing.T) {
+	rt, err := ParseRouteTarget("65000:1")
+	if err != nil {
+		t.Fatal("ParseRouteTarget failed with error:", err)
+	}
+	if !IsRouteTarget(rt) || rt.String() != "rt:65000:1" {
+		t.Fatal("ParseRouteTarget expected rt:65000:1, got", rt)
+	}
+
+	soo, _ := ParseExtCommunity("soo:65000:2")
+	pa := SetExtCommunities(make([]BGPPathAttr, 0), []BGPExtCommunity{soo, rt})
+	newRT, _ := ParseRouteTarget("rt:10.1.1.1:5")
+	pa = SetRouteTargets(pa, []BGPExtCommunity{newRT})
+	rts := GetRouteTargets(pa)
+	if len(rts) != 1 || rts[0] != newRT {
+		t.Fatal("SetRouteTargets expected route targets", newRT, "got", rts)
+	}
+	if comms := GetExtCommunities(pa); len(comms) != 2 || comms[0] != soo {
+		t.Fatal("SetRouteTargets did not retain the extended community", soo, "got", comms)
+	}
+}
+
+func TestVPNPrefix(t *testing.T) {
+	rd, _ := ParseRouteDistinguisher("65000:100")
+	prefixes := []*VPNPrefix{
+		NewVPNPrefix(rd, []uint32{1000}, NewIPPrefix(net.ParseIP("10.1.0.0"), 16)),
+		NewVPNPrefix(rd, []uint32{16, 2000}, NewIPPrefix(net.ParseIP("2001:db8::"), 64)),
+	}
+	afis := []AFI{AfiIP, AfiIP6}
+	expectedLen := []uint32{14, 23}
+	for i, prefix := range prefixes {
+		pkt, err := prefix.Encode(afis[i])
+		if err != nil {
+			t.Fatal("VPNPrefix.Encode failed with error:", err)
+		}
+		if uint32(len(pkt)) != expectedLen[i] || prefix.Len() != expectedLen[i] {
+			t.Fatal("VPNPrefix.Encode expected len", expectedLen[i], "got", len(pkt), "Len()", prefix.Len())
+		}
+
+		vpnPrefix := &VPNPrefix{}
+		if err = vpnPrefix.Decode(pkt, afis[i]); err != nil {
+			t.Fatal("VPNPrefix.Decode failed with error:", err)
+		}
+		if vpnPrefix.GetCIDR() != prefix.GetCIDR() || vpnPrefix.Len() != prefix.Len() {
+			t.Fatal("VPNPrefix.Decode expected", prefix, "got", vpnPrefix)
+		}
+		if len(vpnPrefix.Labels) != len(prefix.Labels) ||
+			vpnPrefix.Labels[len(prefix.Labels)-1] != prefix.Labels[len(prefix.Labels)-1] {
+			t.Fatal("VPNPrefix.Decode expected labels", prefix.Labels, "got", vpnPrefix.Labels)
+		}
+	}
+
+	if prefixes[0].GetCIDR() != "65000:100:10.1.0.0/16" {
+		t.Fatal("VPNPrefix.GetCIDR expected 65000:100:10.1.0.0/16, got", prefixes[0].GetCIDR())
+	}
+
+	// label stack without the bottom of stack bit
+	pkt := []byte{0x70, 0x00, 0x01, 0x00, 0x00, 0x02, 0x00}
+	if err := (&VPNPrefix{}).Decode(pkt, AfiIP); err == nil {
+		t.Fatal("VPNPrefix.Decode for invalid label stack, expected failure, got NO error")
+	}
+}
+
+func TestVPNMPReachNLRI(t *testing.T) {
+	rd, _ := ParseRouteDistinguisher("10.1.1.1:1")
+	protoFamily := GetProtocolFamily(AfiIP6, SafiMPLSVPN)
+	nlri := NewVPNPrefix(rd, []uint32{3000}, NewIPPrefix(net.ParseIP("2001:db8:1::"), 48))
+	mpReach := ConstructIPv6MPReachNLRI(protoFamily, net.ParseIP("10.0.0.1"), nil, []NLRI{nlri})
+	pkt, err := mpReach.Encode()
+	if err != nil {
+		t.Fatal("MPReachNLRI.Encode failed with error:", err)
+	}
+
+	decoded := NewBGPPathAttrMPReachNLRI()
+	peerAttrs := BGPPeerAttrs{
+		ASSize:           4,
+		AddPathsRxActual: true,
+	}
+	if err = decoded.Decode(pkt, peerAttrs); err != nil {
+		t.Fatal("MPReachNLRI.Decode failed with error:", err)
+	}
+
+	nextHop, ok := decoded.NextHop.(*MPNextHopVPN)
+	if !ok || nextHop.Len() != 25 || !nextHop.GetNextHop().Equal(net.ParseIP("10.0.0.1")) {
+		t.Fatal("MPReachNLRI.Decode expected VPN next hop 10.0.0.1, got", decoded.NextHop)
+	}
+	if len(decoded.NLRI) != 1 || decoded.NLRI[0].GetCIDR() != nlri.GetCIDR() {
+		t.Fatal("MPReachNLRI.Decode expected NLRI", nlri, "got", decoded.NLRI)
+	}
+}
@@ -0,0 +1,405 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// communityPolicy.go
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"l3/bgp/packet"
+	"strings"
+	"sync"
+	"utils/logging"
+	utilspolicy "utils/policy"
+)
+
+const (
+	CommunityConditionType = "MatchCommunity"
+)
+
+const (
+	SetCommunityActionType    = "SetCommunity"
+	AddCommunityActionType    = "AddCommunity"
+	DeleteCommunityActionType = "DeleteCommunity"
+)
+
+var CommunityActionTypes = map[string]bool{
+	SetCommunityActionType:    true,
+	AddCommunityActionType:    true,
+	DeleteCommunityActionType: true,
+}
+
+type CommunityConditionConfig struct {
+	Name        string
+	Communities []string
+}
+
+type CommunityActionConfig struct {
+	Name        string
+	ActionType  string
+	Communities []string
+}
+
+// CommunitySet holds standard, extended and large communities parsed from a list of strings.
+// The kind of each entry is derived from its format, rt:/soo: prefixes are extended
+// communities, a:b:c is a large community and everything else is a standard community.
+type CommunitySet struct {
+	Communities      []uint32
+	ExtCommunities   []packet.BGPExtCommunity
+	LargeCommunities []packet.BGPLargeCommunity
+}
+
+func NewCommunitySet(commList []string) (*CommunitySet, error) {
+	set := &CommunitySet{}
+	for _, commStr := range commList {
+		commStr = strings.TrimSpace(commStr)
+		if _, ok := packet.BGPExtCommunityStrToSubTypeMap[strings.ToLower(strings.Split(commStr, ":")[0])]; ok {
+			extComm, err := packet.ParseExtCommunity(commStr)
+			if err != nil {
+				return nil, err
+			}
+			set.ExtCommunities = append(set.ExtCommunities, extComm)
+		} else if strings.Count(commStr, ":") == 2 {
+			largeComm, err := packet.ParseLargeCommunity(commStr)
+			if err != nil {
+				return nil, err
+			}
+			set.LargeCommunities = append(set.LargeCommunities, largeComm)
+		} else {
+			comm, err := packet.ParseCommunity(commStr)
+			if err != nil {
+				return nil, err
+			}
+			set.Communities = append(set.Communities, comm)
+		}
+	}
+	return set, nil
+}
+
+func (c *CommunitySet) IsEmpty() bool {
+	return len(c.Communities) == 0 && len(c.ExtCommunities) == 0 && len(c.LargeCommunities) == 0
+}
+
+func (c *CommunitySet) hasCommunity(comm uint32) bool {
+	for _, val := range c.Communities {
+		if val == comm {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CommunitySet) hasExtCommunity(extComm packet.BGPExtCommunity) bool {
+	for _, val := range c.ExtCommunities {
+		if val == extComm {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CommunitySet) hasLargeCommunity(largeComm packet.BGPLargeCommunity) bool {
+	for _, val := range c.LargeCommunities {
+		if val == largeComm {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny returns true if the path attrs carry at least one of the communities in the set
+func (c *CommunitySet) MatchAny(pathAttrs []packet.BGPPathAttr) bool {
+	for _, comm := range packet.GetCommunities(pathAttrs) {
+		if c.hasCommunity(comm) {
+			return true
+		}
+	}
+	for _, extComm := range packet.GetExtCommunities(pathAttrs) {
+		if c.hasExtCommunity(extComm) {
+			return true
+		}
+	}
+	for _, largeComm := range packet.GetLargeCommunities(pathAttrs) {
+		if c.hasLargeCommunity(largeComm) {
+			return true
+		}
+	}
+	return false
+}
+
+type CommunityCondition struct {
+	Name string
+	Set  *CommunitySet
+}
+
+func (c *CommunityCondition) Match(pathAttrs []packet.BGPPathAttr) bool {
+	return c.Set.MatchAny(pathAttrs)
+}
+
+type CommunityAction struct {
+	Name       string
+	ActionType string
+	Set        *CommunitySet
+}
+
+// Apply returns a copy of the path attrs with the community action applied. The attributes
+// that are not modified are shared with the original path attrs.
+func (a *CommunityAction) Apply(pathAttrs []packet.BGPPathAttr) []packet.BGPPathAttr {
+	pa := packet.CopyPathAttrs(pathAttrs)
+	switch a.ActionType {
+	case SetCommunityActionType:
+		if a.Set.IsEmpty() {
+			pa = packet.SetCommunities(pa, nil)
+			pa = packet.SetExtCommunities(pa, nil)
+			pa = packet.SetLargeCommunities(pa, nil)
+			break
+		}
+		if len(a.Set.Communities) > 0 {
+			pa = packet.SetCommunities(pa, a.Set.Communities)
+		}
+		if len(a.Set.ExtCommunities) > 0 {
+			pa = packet.SetExtCommunities(pa, a.Set.ExtCommunities)
+		}
+		if len(a.Set.LargeCommunities) > 0 {
+			pa = packet.SetLargeCommunities(pa, a.Set.LargeCommunities)
+		}
+
+	case AddCommunityActionType:
+		if len(a.Set.Communities) > 0 {
+			comms := packet.GetCommunities(pa)
+			newComms := make([]uint32, len(comms), len(comms)+len(a.Set.Communities))
+			copy(newComms, comms)
+			for _, comm := range a.Set.Communities {
+				if !packet.HasCommunity(pa, comm) {
+					newComms = append(newComms, comm)
+				}
+			}
+			pa = packet.SetCommunities(pa, newComms)
+		}
+		if len(a.Set.ExtCommunities) > 0 {
+			extComms := packet.GetExtCommunities(pa)
+			existing := &CommunitySet{ExtCommunities: extComms}
+			newExtComms := make([]packet.BGPExtCommunity, len(extComms), len(extComms)+len(a.Set.ExtCommunities))
+			copy(newExtComms, extComms)
+			for _, extComm := range a.Set.ExtCommunities {
+				if !existing.hasExtCommunity(extComm) {
+					newExtComms = append(newExtComms, extComm)
+				}
+			}
+			pa = packet.SetExtCommunities(pa, newExtComms)
+		}
+		if len(a.Set.LargeCommunities) > 0 {
+			largeComms := packet.GetLargeCommunities(pa)
+			existing := &CommunitySet{LargeCommunities: largeComms}
+			newLargeComms := make([]packet.BGPLargeCommunity, len(largeComms),
+				len(largeComms)+len(a.Set.LargeCommunities))
+			copy(newLargeComms, largeComms)
+			for _, largeComm := range a.Set.LargeCommunities {
+				if !existing.hasLargeCommunity(largeComm) {
+					newLargeComms = append(newLargeComms, largeComm)
+				}
+			}
+			pa = packet.SetLargeCommunities(pa, newLargeComms)
+		}
+
+	case DeleteCommunityActionType:
+		if len(a.Set.Communities) > 0 {
+			newComms := make([]uint32, 0)
+			for _, comm := range packet.GetCommunities(pa) {
+				if !a.Set.hasCommunity(comm) {
+					newComms = append(newComms, comm)
+				}
+			}
+			pa = packet.SetCommunities(pa, newComms)
+		}
+		if len(a.Set.ExtCommunities) > 0 {
+			newExtComms := make([]packet.BGPExtCommunity, 0)
+			for _, extComm := range packet.GetExtCommunities(pa) {
+				if !a.Set.hasExtCommunity(extComm) {
+					newExtComms = append(newExtComms, extComm)
+				}
+			}
+			pa = packet.SetExtCommunities(pa, newExtComms)
+		}
+		if len(a.Set.LargeCommunities) > 0 {
+			newLargeComms := make([]packet.BGPLargeCommunity, 0)
+			for _, largeComm := range packet.GetLargeCommunities(pa) {
+				if !a.Set.hasLargeCommunity(largeComm) {
+					newLargeComms = append(newLargeComms, largeComm)
+				}
+			}
+			pa = packet.SetLargeCommunities(pa, newLargeComms)
+		}
+	}
+	return pa
+}
+
+// CommunityPolicyDB stores the community conditions and actions. The generic policy engine does
+// not know about path attributes, so the BGP action callbacks evaluate the community conditions
+// and actions referenced by name in a policy statement.
+type CommunityPolicyDB struct {
+	logger     *logging.Writer
+	conditions map[string]*CommunityCondition
+	actions    map[string]*CommunityAction
+	mutex      sync.RWMutex
+}
+
+func NewCommunityPolicyDB(logger *logging.Writer) *CommunityPolicyDB {
+	return &CommunityPolicyDB{
+		logger:     logger,
+		conditions: make(map[string]*CommunityCondition),
+		actions:    make(map[string]*CommunityAction),
+	}
+}
+
+func (db *CommunityPolicyDB) CreateCondition(cfg CommunityConditionConfig) error {
+	set, err := NewCommunitySet(cfg.Communities)
+	if err != nil {
+		return err
+	}
+	if set.IsEmpty() {
+		return errors.New(fmt.Sprintf("Community condition %s does not have any communities", cfg.Name))
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.conditions[cfg.Name] = &CommunityCondition{Name: cfg.Name, Set: set}
+	db.logger.Info("CommunityPolicyDB:CreateCondition - created community condition", cfg.Name)
+	return nil
+}
+
+func (db *CommunityPolicyDB) DeleteCondition(name string) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.conditions[name]; !ok {
+		return false
+	}
+	delete(db.conditions, name)
+	db.logger.Info("CommunityPolicyDB:DeleteCondition - deleted community condition", name)
+	return true
+}
+
+func (db *CommunityPolicyDB) CreateAction(cfg CommunityActionConfig) error {
+	if !CommunityActionTypes[cfg.ActionType] {
+		return errors.New(fmt.Sprintf("Unknown community action type %s", cfg.ActionType))
+	}
+
+	set, err := NewCommunitySet(cfg.Communities)
+	if err != nil {
+		return err
+	}
+	if set.IsEmpty() && cfg.ActionType != SetCommunityActionType {
+		return errors.New(fmt.Sprintf("Community action %s does not have any communities", cfg.Name))
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	db.actions[cfg.Name] = &CommunityAction{Name: cfg.Name, ActionType: cfg.ActionType, Set: set}
+	db.logger.Info("CommunityPolicyDB:CreateAction - created community action", cfg.Name)
+	return nil
+}
+
+func (db *CommunityPolicyDB) DeleteAction(name string) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if _, ok := db.actions[name]; !ok {
+		return false
+	}
+	delete(db.actions, name)
+	db.logger.Info("CommunityPolicyDB:DeleteAction - deleted community action", name)
+	return true
+}
+
+func (db *CommunityPolicyDB) IsAction(name string) bool {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	_, ok := db.actions[name]
+	return ok
+}
+
+// MatchConditions evaluates the community conditions in the list together with otherMatched, the
+// result of the conditions in the list that are not community conditions. matchType is the policy
+// statement match type, with "any" the statement matches if either matched, otherwise both must match.
+func (db *CommunityPolicyDB) MatchConditions(matchType string, conditions []string, otherMatched bool,
+	pathAttrs []packet.BGPPathAttr) bool {
+	if matchType == "any" && otherMatched {
+		return true
+	} else if matchType != "any" && !otherMatched {
+		return false
+	}
+
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	found := false
+	for _, name := range conditions {
+		condition, ok := db.conditions[name]
+		if !ok {
+			continue
+		}
+
+		found = true
+		matched := condition.Match(pathAttrs)
+		if matched && matchType == "any" {
+			return true
+		} else if !matched && matchType != "any" {
+			return false
+		}
+	}
+
+	return !found || matchType != "any"
+}
+
+// MatchStatement is called from the action callback of a policy statement. The policy engine does
+// not know the community conditions and calls the action with the info of the other conditions that
+// matched, with "all" it only calls the action if all the other conditions matched.
+func (db *CommunityPolicyDB) MatchStatement(policyStmt utilspolicy.PolicyStmt, conditionInfo []interface{},
+	pathAttrs []packet.BGPPathAttr) bool {
+	otherMatched := policyStmt.MatchConditions != "any" || len(conditionInfo) > 0
+	return db.MatchConditions(policyStmt.MatchConditions, policyStmt.Conditions, otherMatched, pathAttrs)
+}
+
+// GetActions returns the names of the community actions in the list in the order they are configured
+func (db *CommunityPolicyDB) GetActions(actions []string) []string {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	commActions := make([]string, 0)
+	for _, name := range actions {
+		if _, ok := db.actions[name]; ok {
+			commActions = append(commActions, name)
+		}
+	}
+	return commActions
+}
+
+// ApplyActions applies the community actions in the list in order and returns the new path attrs
+func (db *CommunityPolicyDB) ApplyActions(actions []string, pathAttrs []packet.BGPPathAttr) []packet.BGPPathAttr {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	for _, name := range actions {
+		if action, ok := db.actions[name]; ok {
+			pathAttrs = action.Apply(pathAttrs)
+		}
+	}
+	return pathAttrs
+}
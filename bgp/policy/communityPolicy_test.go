@@ -0,0 +1,165 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// communityPolicy_test.go
+package policy
+
+import (
+	"l3/bgp/packet"
+	"testing"
+	"utils/logging"
+	utilspolicy "utils/policy"
+)
+
+func getCommunityPolicyDB(t *testing.T) *CommunityPolicyDB {
+	logger, err := logging.NewLogger("bgpd", "BGP", true)
+	if err != nil {
+		t.Fatal("Failed to start the logger. Exiting!!")
+		return nil
+	}
+
+	db := NewCommunityPolicyDB(logger)
+	err = db.CreateCondition(CommunityConditionConfig{Name: "MatchComm100", Communities: []string{"100:1"}})
+	if err != nil {
+		t.Fatal("Failed to create community condition MatchComm100, error:", err)
+	}
+	return db
+}
+
+func getCommunityPathAttrs(t *testing.T, commStr string) []packet.BGPPathAttr {
+	if commStr == "" {
+		return nil
+	}
+
+	comm, err := packet.ParseCommunity(commStr)
+	if err != nil {
+		t.Fatal("Failed to parse community", commStr, "error:", err)
+	}
+	return packet.SetCommunities(nil, []uint32{comm})
+}
+
+// The statements mix the prefix condition MatchPrefix10, evaluated by the policy engine, and the
+// community condition MatchComm100. The engine calls the action with the info of the prefix
+// condition when the prefix matched.
+func TestCommunityMatchStatementWithPrefixCondition(t *testing.T) {
+	db := getCommunityPolicyDB(t)
+	prefixConditionInfo := []interface{}{"10.1.0.0/16"}
+	tests := []struct {
+		matchType     string
+		prefixMatched bool
+		community     string
+		expected      bool
+	}{
+		{"any", true, "100:1", true},
+		{"any", true, "200:1", true},
+		{"any", true, "", true},
+		{"any", false, "100:1", true},
+		{"any", false, "200:1", false},
+		{"any", false, "", false},
+		{"all", true, "100:1", true},
+		{"all", true, "200:1", false},
+		{"all", true, "", false},
+	}
+
+	for _, test := range tests {
+		stmt := utilspolicy.PolicyStmt{
+			Name:            "MixedStmt",
+			MatchConditions: test.matchType,
+			Conditions:      []string{"MatchPrefix10", "MatchComm100"},
+		}
+		var conditionInfo []interface{}
+		if test.prefixMatched {
+			conditionInfo = prefixConditionInfo
+		}
+		pathAttrs := getCommunityPathAttrs(t, test.community)
+		if matched := db.MatchStatement(stmt, conditionInfo, pathAttrs); matched != test.expected {
+			t.Errorf("MatchStatement for match type %s, prefix matched %t, community %q returned %t, expected %t",
+				test.matchType, test.prefixMatched, test.community, matched, test.expected)
+		}
+	}
+}
+
+func TestCommunityMatchStatementWithoutCommunityConditions(t *testing.T) {
+	db := getCommunityPolicyDB(t)
+	pathAttrs := getCommunityPathAttrs(t, "200:1")
+	for _, matchType := range []string{"any", "all"} {
+		stmt := utilspolicy.PolicyStmt{
+			Name:            "PrefixStmt",
+			MatchConditions: matchType,
+			Conditions:      []string{"MatchPrefix10"},
+		}
+		if !db.MatchStatement(stmt, []interface{}{"10.1.0.0/16"}, pathAttrs) {
+			t.Error("MatchStatement failed for statement without community conditions, match type", matchType)
+		}
+	}
+}
+
+func TestCommunityMatchStatementOnlyCommunityConditions(t *testing.T) {
+	db := getCommunityPolicyDB(t)
+	for _, matchType := range []string{"any", "all"} {
+		stmt := utilspolicy.PolicyStmt{
+			Name:            "CommStmt",
+			MatchConditions: matchType,
+			Conditions:      []string{"MatchComm100"},
+		}
+		if !db.MatchStatement(stmt, nil, getCommunityPathAttrs(t, "100:1")) {
+			t.Error("MatchStatement failed for community 100:1, match type", matchType)
+		}
+		if db.MatchStatement(stmt, nil, getCommunityPathAttrs(t, "200:1")) {
+			t.Error("MatchStatement matched community 200:1, match type", matchType)
+		}
+	}
+}
+
+// The actions are applied in the order of the statement, here the set is followed by the add and
+// both communities are on the path. Applied in name order the set would overwrite the add.
+func TestCommunityActionsOrder(t *testing.T) {
+	db := getCommunityPolicyDB(t)
+	err := db.CreateAction(CommunityActionConfig{Name: "SetComm200", ActionType: SetCommunityActionType,
+		Communities: []string{"200:1"}})
+	if err != nil {
+		t.Fatal("Failed to create community action SetComm200, error:", err)
+	}
+	err = db.CreateAction(CommunityActionConfig{Name: "AddComm300", ActionType: AddCommunityActionType,
+		Communities: []string{"300:1"}})
+	if err != nil {
+		t.Fatal("Failed to create community action AddComm300, error:", err)
+	}
+
+	actions := db.GetActions([]string{"SetComm200", "permit", "AddComm300"})
+	if len(actions) != 2 || actions[0] != "SetComm200" || actions[1] != "AddComm300" {
+		t.Fatal("GetActions returned", actions, "expected [SetComm200 AddComm300]")
+	}
+
+	pathAttrs := db.ApplyActions(actions, getCommunityPathAttrs(t, "100:1"))
+	comms := packet.GetCommunities(pathAttrs)
+	if len(comms) != 2 {
+		t.Fatal("Communities after the actions are", comms, "expected 200:1 and 300:1")
+	}
+	for _, commStr := range []string{"200:1", "300:1"} {
+		comm, _ := packet.ParseCommunity(commStr)
+		if !packet.HasCommunity(pathAttrs, comm) {
+			t.Error("Community", commStr, "not found after the actions, communities", comms)
+		}
+	}
+}
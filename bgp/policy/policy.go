@@ -52,11 +52,14 @@ type BGPPolicyEngine interface {
 	SetIsEntityPresentFunc(utilspolicy.PolicyCheckfunc)
 	SetGetPolicyEntityMapIndexFunc(utilspolicy.GetPolicyEnityMapIndexFunc)
 	GetPolicyEngine() *utilspolicy.PolicyEngineDB
+	SetCommunityPolicyDB(*CommunityPolicyDB)
+	GetCommunityPolicyDB() *CommunityPolicyDB
 }
 
 type BasePolicyEngine struct {
 	logger       *logging.Writer
 	PolicyEngine *utilspolicy.PolicyEngineDB
+	CommunityDB  *CommunityPolicyDB
 }
 
 func NewBasePolicyEngine(logger *logging.Writer, policyEngine *utilspolicy.PolicyEngineDB) BasePolicyEngine {
@@ -155,3 +158,11 @@ func (eng *BasePolicyEngine) UpdateUndoApplyPolicy(info utilspolicy.ApplyPolicyI
 func (eng *BasePolicyEngine) GetPolicyEngine() *utilspolicy.PolicyEngineDB {
 	return eng.PolicyEngine
 }
+
+func (eng *BasePolicyEngine) SetCommunityPolicyDB(communityDB *CommunityPolicyDB) {
+	eng.CommunityDB = communityDB
+}
+
+func (eng *BasePolicyEngine) GetCommunityPolicyDB() *CommunityPolicyDB {
+	return eng.CommunityDB
+}
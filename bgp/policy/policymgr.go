@@ -47,6 +47,10 @@ type BGPPolicyManager struct {
 	StmtDelCh       chan string
 	DefinitionDelCh chan string
 	policyPlugin    config.PolicyMgrIntf
+
+	CommunityDB             *CommunityPolicyDB
+	CommunityConditionCfgCh chan CommunityConditionConfig
+	CommunityActionCfgCh    chan CommunityActionConfig
 }
 
 func NewPolicyManager(logger *logging.Writer, pMgr config.PolicyMgrIntf) *BGPPolicyManager {
@@ -63,6 +67,9 @@ func NewPolicyManager(logger *logging.Writer, pMgr config.PolicyMgrIntf) *BGPPol
 		policyManager.StmtDelCh = make(chan string)
 		policyManager.DefinitionDelCh = make(chan string)
 		policyManager.policyPlugin = pMgr
+		policyManager.CommunityDB = NewCommunityPolicyDB(logger)
+		policyManager.CommunityConditionCfgCh = make(chan CommunityConditionConfig)
+		policyManager.CommunityActionCfgCh = make(chan CommunityActionConfig)
 		PolicyManager = policyManager
 	}
 
@@ -70,6 +77,7 @@ func NewPolicyManager(logger *logging.Writer, pMgr config.PolicyMgrIntf) *BGPPol
 }
 
 func (eng *BGPPolicyManager) AddPolicyEngine(bgpPE BGPPolicyEngine) {
+	bgpPE.SetCommunityPolicyDB(eng.CommunityDB)
 	eng.policyEngines = append(eng.policyEngines, bgpPE)
 }
 
@@ -196,6 +204,20 @@ func (eng *BGPPolicyManager) StartPolicyEngine(dbUtil *dbutils.DBUtil, doneCh ch
 				pe.CreatePolicyAction(actionCfg)
 			}
 
+		case commCondCfg := <-eng.CommunityConditionCfgCh:
+			eng.logger.Info("BGPPolicyEngine - create community condition", commCondCfg.Name)
+			if err := eng.CommunityDB.CreateCondition(commCondCfg); err != nil {
+				eng.logger.Err("BGPPolicyEngine - create community condition", commCondCfg.Name,
+					"failed with error", err)
+			}
+
+		case commActionCfg := <-eng.CommunityActionCfgCh:
+			eng.logger.Info("BGPPolicyEngine - create community action", commActionCfg.Name)
+			if err := eng.CommunityDB.CreateAction(commActionCfg); err != nil {
+				eng.logger.Err("BGPPolicyEngine - create community action", commActionCfg.Name,
+					"failed with error", err)
+			}
+
 		case stmtCfg := <-eng.StmtCfgCh:
 			eng.logger.Info("BGPPolicyEngine - create policy statement", stmtCfg.Name)
 			for _, pe := range eng.policyEngines {
@@ -210,12 +232,18 @@ func (eng *BGPPolicyManager) StartPolicyEngine(dbUtil *dbutils.DBUtil, doneCh ch
 
 		case conditionName := <-eng.ConditionDelCh:
 			eng.logger.Info("BGPPolicyEngine - delete policy condition", conditionName)
+			if eng.CommunityDB.DeleteCondition(conditionName) {
+				break
+			}
 			for _, pe := range eng.policyEngines {
 				pe.DeletePolicyCondition(conditionName)
 			}
 
 		case actionName := <-eng.ActionDelCh:
 			eng.logger.Info("BGPPolicyEngine - delete policy action", actionName)
+			if eng.CommunityDB.DeleteAction(actionName) {
+				break
+			}
 			for _, pe := range eng.policyEngines {
 				pe.DeletePolicyAction(actionName)
 			}
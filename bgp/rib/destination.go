@@ -39,6 +39,23 @@ import (
 const BGP_INTERNAL_PREF = 100
 const BGP_EXTERNAL_PREF = 100
 
+// Reasons reported for selecting the best path of a destination. Each one names
+// the last step of the path selection algorithm that eliminated a candidate.
+const (
+	BestPathReasonOnlyPath    = "Only path"
+	BestPathReasonRouteSource = "Route source"
+	BestPathReasonLocalPref   = "Local preference"
+	BestPathReasonASPathLen   = "AS path length"
+	BestPathReasonOrigin      = "Origin"
+	BestPathReasonMED         = "MED"
+	BestPathReasonEBGP        = "eBGP over iBGP"
+	BestPathReasonIGPCost     = "IGP cost"
+	BestPathReasonOldestPath  = "Oldest eBGP path"
+	BestPathReasonRouterId    = "Router id"
+	BestPathReasonClusterLen  = "Cluster list length"
+	BestPathReasonPeerAddress = "Neighbor address"
+)
+
 type PathAndRoute struct {
 	Path
 }
@@ -63,6 +80,7 @@ type Destination struct {
 	BGPRouteState     config.ModelRouteIntf
 	PathInfoRouteMap  map[*bgpd.PathInfo]*Route
 	routeListIdx      int
+	bestPathReason    string
 }
 
 func NewDestination(rib *LocRib, nlri packet.NLRI, protoFamily uint32, gConf *config.GlobalConfig) *Destination {
@@ -83,10 +101,33 @@ func NewDestination(rib *LocRib, nlri packet.NLRI, protoFamily uint32, gConf *co
 		PathInfoRouteMap:  make(map[*bgpd.PathInfo]*Route),
 	}
 
-	dest.setBGPRouteState(protoFamily, nlri.GetPrefix().String(), int16(nlri.GetLength()))
+	dest.setBGPRouteState(protoFamily, dest.getStateNetwork(), int16(nlri.GetLength()))
 	return dest
 }
 
+// getStateNetwork returns the network of the route state. VPN routes are qualified with the route distinguisher
+// and the routes of a VRF with the VRF name so that they don't overwrite the state of the global routes. EVPN
+// routes use the route key.
+func (d *Destination) getStateNetwork() string {
+	network := d.NLRI.GetPrefix().String()
+	switch prefix := d.NLRI.(type) {
+	case *packet.VPNPrefix:
+		network = prefix.RD.String() + ":" + network
+	case *packet.EVPNPrefix:
+		network = prefix.GetCIDR()
+	}
+	if d.rib.vrf != "" {
+		network = d.rib.vrf + ":" + network
+	}
+	return network
+}
+
+// isRIBdRoute returns true if the path is installed in RIBd. Local paths are already in RIBd, the VPN routes
+// are installed in the VRFs that import them and the EVPN routes are installed by the VXLAN daemon.
+func (d *Destination) isRIBdRoute(path *Path) bool {
+	return (!path.IsLocal() || path.IsAggregate()) && packet.IsUnicastFamily(d.protoFamily)
+}
+
 func (d *Destination) setBGPRouteState(protoFamily uint32, network string, cidrLen int16) {
 	afi, _ := packet.GetAfiSafi(protoFamily)
 	if afi == packet.AfiIP6 {
@@ -101,6 +142,10 @@ func (d *Destination) GetLocRibPathRoute() *Route {
 	return d.LocRibPathRoute
 }
 
+func (d *Destination) GetBestPathReason() string {
+	return d.bestPathReason
+}
+
 func (d *Destination) GetBGPRoute() config.ModelRouteIntf {
 	return d.BGPRouteState
 }
@@ -117,6 +162,11 @@ func (d *Destination) GetProtocolFamily() uint32 {
 	return d.protoFamily
 }
 
+// GetPaths returns the paths of the destination keyed by the source IP and the path id.
+func (d *Destination) GetPaths() map[string]map[uint32]*Path {
+	return d.peerPathMap
+}
+
 func (d *Destination) String() string {
 	return d.NLRI.String()
 }
@@ -336,6 +386,15 @@ func (d *Destination) RemoveAllPaths(peerIP string, path *Path) {
 	}
 }
 
+// MarkStalePaths marks all the paths from the peer as stale. The paths continue to be used for forwarding
+// until the peer re-advertises them or they are removed.
+func (d *Destination) MarkStalePaths(peerIP string) {
+	for pathId, path := range d.peerPathMap[peerIP] {
+		d.logger.Info("Mark path id", pathId, "for", d.NLRI.GetCIDR(), "from peer", peerIP, "as stale")
+		path.SetStale(true)
+	}
+}
+
 func (d *Destination) RemoveAllNeighborPaths() {
 	for peerIP, pathMap := range d.peerPathMap {
 		for pathId, path := range pathMap {
@@ -424,6 +483,8 @@ func (d *Destination) ConstructRouteConfig(path *Path, reachInfo *ReachabilityIn
 		OutgoingInterface: strconv.Itoa(int(reachInfo.NextHopIfIdx)),
 		IsIPv6:            isIPv6,
 		NullRoute:         nullRoute,
+		Vrf:               d.rib.vrf,
+		Labels:            path.GetLabels(),
 	}
 
 	return &cfg
@@ -503,6 +564,10 @@ func (d *Destination) SelectRouteForLocRib(addPathCount int) (RouteAction, bool,
 	}
 
 	d.logger.Infof("Destination %s, ECMP routes %v updated paths %v", d.NLRI.GetPrefix(), d.ecmpPaths, updatedPaths)
+	d.bestPathReason = BestPathReasonOnlyPath
+	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonRouteSource
+	}
 	firstRoute := true
 	if len(updatedPaths) > 0 {
 		var ecmpPaths [][]*Path
@@ -565,7 +630,7 @@ func (d *Destination) SelectRouteForLocRib(addPathCount int) (RouteAction, bool,
 				newRoute.setAction(RouteActionAdd)
 				newRoute.SetMultiPath()
 
-				if !paths[0].IsLocal() || paths[0].IsAggregate() {
+				if d.isRIBdRoute(paths[0]) {
 					d.logger.Infof("Add route for ip=%s, mask=%s, next hop=%s", d.NLRI.GetPrefix(),
 						d.constructNetmaskFromLen(int(d.NLRI.GetLength()), ipLength*8),
 						paths[0].GetReachability(d.protoFamily).NextHop)
@@ -582,6 +647,9 @@ func (d *Destination) SelectRouteForLocRib(addPathCount int) (RouteAction, bool,
 
 		d.LocRibPath = ecmpPaths[0][0]
 		d.LocRibPathRoute = d.ecmpPaths[d.LocRibPath]
+		if d.LocRibPathRoute != nil {
+			d.LocRibPathRoute.setBestPathReason(d.bestPathReason)
+		}
 		d.logger.Infof("Destination %s loc rib path %v route %v, d.ecmpPaths %v ecmpPaths %v",
 			d.NLRI.GetPrefix(), d.LocRibPath, d.LocRibPathRoute, d.ecmpPaths, ecmpPaths)
 	} else {
@@ -591,11 +659,12 @@ func (d *Destination) SelectRouteForLocRib(addPathCount int) (RouteAction, bool,
 		}
 		locRibAction = RouteActionDelete
 		d.LocRibPath = nil
+		d.bestPathReason = ""
 	}
 
 	for path, route := range d.ecmpPaths {
 		if route.action == RouteActionNone || route.action == RouteActionDelete {
-			if !path.IsLocal() || path.IsAggregate() {
+			if d.isRIBdRoute(path) {
 				reachInfo := path.GetReachability(d.protoFamily)
 				d.logger.Info("Remove route from ECMP paths, route =", route, "ip =",
 					d.NLRI.GetCIDR(), "next hop =", reachInfo.NextHop)
@@ -662,6 +731,7 @@ func (d *Destination) getRoutesWithHighestPref(updatedPaths []*Path, prunedPaths
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonLocalPref
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: ByPref{removedPaths},
@@ -708,6 +778,7 @@ func (d *Destination) getRoutesWithSmallestAS(updatedPaths []*Path, prunedPaths
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonASPathLen
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: BySmallestAS{removedPaths},
@@ -748,6 +819,7 @@ func (d *Destination) getRoutesWithLowestOrigin(updatedPaths []*Path, prunedPath
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonOrigin
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: ByLowestOrigin{removedPaths},
@@ -765,6 +837,100 @@ func (d *Destination) getRoutesWithLowestOrigin(updatedPaths []*Path, prunedPath
 	return updatedPaths, prunedPaths
 }
 
+func (d *Destination) isMEDComparable(a, b *Path) bool {
+	return d.gConf.AlwaysCompareMED || a.GetNeighborAS() == b.GetNeighborAS()
+}
+
+func (d *Destination) getMEDGroup(path *Path) uint32 {
+	if d.gConf.AlwaysCompareMED {
+		return 0
+	}
+	return path.GetNeighborAS()
+}
+
+func (d *Destination) getRoutesWithLowestMED(updatedPaths []*Path, prunedPaths []PathSortIface) ([]*Path,
+	[]PathSortIface) {
+	removedPaths := make([]*Path, 0)
+	n := len(updatedPaths)
+	idx := 0
+
+	if d.gConf.DeterministicMED {
+		// Group the paths by neighbor AS and keep the paths with the lowest MED in each group.
+		minMED := make(map[uint32]uint32)
+		for _, path := range updatedPaths {
+			group := d.getMEDGroup(path)
+			if med, ok := minMED[group]; !ok || path.MED < med {
+				minMED[group] = path.MED
+			}
+		}
+
+		for i := 0; i < n; i++ {
+			if updatedPaths[i].MED > minMED[d.getMEDGroup(updatedPaths[i])] {
+				removedPaths = append(removedPaths, updatedPaths[i])
+			} else {
+				updatedPaths[idx] = updatedPaths[i]
+				idx++
+			}
+		}
+	} else {
+		// Compare the paths in the order they were received against the current reference path. A path
+		// from a different neighbor AS is kept but does not become the reference, so the outcome can
+		// depend on the arrival order.
+		sort.Stable(ByOldestPath{updatedPaths})
+		var refPath *Path
+		for i := 0; i < n; i++ {
+			path := updatedPaths[i]
+			if refPath == nil || !d.isMEDComparable(refPath, path) {
+				if refPath == nil {
+					refPath = path
+				}
+				updatedPaths[idx] = path
+				idx++
+				continue
+			}
+
+			if path.MED > refPath.MED {
+				removedPaths = append(removedPaths, path)
+				continue
+			} else if path.MED < refPath.MED {
+				d.logger.Infof("Destination %s path %v has lower MED, old MED=%d, new MED=%d",
+					d.NLRI.GetPrefix(), path, refPath.MED, path.MED)
+				kept := 0
+				for j := 0; j < idx; j++ {
+					if d.isMEDComparable(path, updatedPaths[j]) && updatedPaths[j].MED > path.MED {
+						removedPaths = append(removedPaths, updatedPaths[j])
+					} else {
+						updatedPaths[kept] = updatedPaths[j]
+						kept++
+					}
+				}
+				idx = kept
+				refPath = path
+			}
+			updatedPaths[idx] = path
+			idx++
+		}
+	}
+
+	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonMED
+		pathSortIface := PathSortIface{
+			paths: removedPaths,
+			iface: ByLowestMED{removedPaths},
+		}
+		prunedPaths = append(prunedPaths, pathSortIface)
+	}
+
+	if idx > 0 {
+		for i := idx; i < n; i++ {
+			updatedPaths[i] = nil
+		}
+		updatedPaths = updatedPaths[:idx]
+	}
+
+	return updatedPaths, prunedPaths
+}
+
 func deleteIBGPRoutes(updatedPaths []*Path, prunedPaths []PathSortIface) ([]*Path, []PathSortIface) {
 	removedPaths := make([]*Path, 0)
 	n := len(updatedPaths) - 1
@@ -796,10 +962,105 @@ func (d *Destination) removeIBGPRoutesIfEBGPExist(updatedPaths []*Path, prunedPa
 	[]PathSortIface) {
 	for _, path := range updatedPaths {
 		if path.NeighborConf != nil && path.NeighborConf.IsExternal() {
-			return deleteIBGPRoutes(updatedPaths, prunedPaths)
+			n := len(updatedPaths)
+			updatedPaths, prunedPaths = deleteIBGPRoutes(updatedPaths, prunedPaths)
+			if len(updatedPaths) < n {
+				d.bestPathReason = BestPathReasonEBGP
+			}
+			return updatedPaths, prunedPaths
+		}
+	}
+
+	return updatedPaths, prunedPaths
+}
+
+func (d *Destination) getRoutesWithLowestIGPCost(updatedPaths []*Path, prunedPaths []PathSortIface) ([]*Path,
+	[]PathSortIface) {
+	minIGPCost := uint32(math.MaxUint32)
+	removedPaths := make([]*Path, 0)
+	n := len(updatedPaths)
+	idx := 0
+
+	for i := 0; i < n; i++ {
+		igpCost := updatedPaths[i].GetIGPCost(d.protoFamily)
+		if igpCost > minIGPCost {
+			removedPaths = append(removedPaths, updatedPaths[i])
+		} else if igpCost < minIGPCost {
+			removedPaths = append(removedPaths, updatedPaths[:idx]...)
+			minIGPCost = igpCost
+			updatedPaths[0] = updatedPaths[i]
+			idx = 1
+		} else if igpCost == minIGPCost {
+			updatedPaths[idx] = updatedPaths[i]
+			idx++
+		}
+	}
+
+	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonIGPCost
+		pathSortIface := PathSortIface{
+			paths: removedPaths,
+			iface: ByLowestIGPCost{removedPaths, d.protoFamily},
+		}
+		prunedPaths = append(prunedPaths, pathSortIface)
+	}
+
+	if idx > 0 {
+		for i := idx; i < n; i++ {
+			updatedPaths[i] = nil
+		}
+		updatedPaths = updatedPaths[:idx]
+	}
+
+	return updatedPaths, prunedPaths
+}
+
+// getOldestEBGPPath prefers the path that was received first when all the remaining paths are external,
+// to avoid route flaps caused by newer paths winning the later tie breaks.
+func (d *Destination) getOldestEBGPPath(updatedPaths []*Path, prunedPaths []PathSortIface) ([]*Path,
+	[]PathSortIface) {
+	for _, path := range updatedPaths {
+		if !d.isEBGPRoute(path) {
+			return updatedPaths, prunedPaths
+		}
+	}
+
+	removedPaths := make([]*Path, 0)
+	n := len(updatedPaths)
+	oldestTime := updatedPaths[0].GetReceivedTime()
+	idx := 0
+
+	for i := 0; i < n; i++ {
+		receivedTime := updatedPaths[i].GetReceivedTime()
+		if receivedTime.After(oldestTime) {
+			removedPaths = append(removedPaths, updatedPaths[i])
+		} else if receivedTime.Before(oldestTime) {
+			removedPaths = append(removedPaths, updatedPaths[:idx]...)
+			oldestTime = receivedTime
+			updatedPaths[0] = updatedPaths[i]
+			idx = 1
+		} else {
+			updatedPaths[idx] = updatedPaths[i]
+			idx++
 		}
 	}
 
+	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonOldestPath
+		pathSortIface := PathSortIface{
+			paths: removedPaths,
+			iface: ByOldestPath{removedPaths},
+		}
+		prunedPaths = append(prunedPaths, pathSortIface)
+	}
+
+	if idx > 0 {
+		for i := idx; i < n; i++ {
+			updatedPaths[i] = nil
+		}
+		updatedPaths = updatedPaths[:idx]
+	}
+
 	return updatedPaths, prunedPaths
 }
 
@@ -842,6 +1103,7 @@ func (d *Destination) getRoutesWithLowestBGPId(updatedPaths []*Path, prunedPaths
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonRouterId
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: ByLowestBGPId{removedPaths},
@@ -882,6 +1144,7 @@ func (d *Destination) getRoutesWithShorterClusterLen(updatedPaths []*Path, prune
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonClusterLen
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: ByShorterClusterLen{removedPaths},
@@ -942,6 +1205,7 @@ func (d *Destination) getRoutesWithLowestPeerAddress(updatedPaths []*Path, prune
 	}
 
 	if len(removedPaths) > 0 {
+		d.bestPathReason = BestPathReasonPeerAddress
 		pathSortIface := PathSortIface{
 			paths: removedPaths,
 			iface: ByLowestPeerAddress{removedPaths},
@@ -1023,6 +1287,11 @@ func (d *Destination) calculateBestPath(updatedPaths, removedPaths []*Path, ebgp
 		updatedPaths, prunedPaths = d.getRoutesWithLowestOrigin(updatedPaths, prunedPaths)
 	}
 
+	if len(updatedPaths) > 1 {
+		d.logger.Info("calling getRoutesWithLowestMED, update paths =", updatedPaths)
+		updatedPaths, prunedPaths = d.getRoutesWithLowestMED(updatedPaths, prunedPaths)
+	}
+
 	if (len(updatedPaths) > 1) && ebgpMultiPath && ibgpMultiPath {
 		ecmpPaths = d.getECMPPaths(updatedPaths)
 		d.logger.Info("calculateBestPath: IBGP & EBGP multi paths =", ecmpPaths)
@@ -1033,6 +1302,11 @@ func (d *Destination) calculateBestPath(updatedPaths, removedPaths []*Path, ebgp
 		updatedPaths, prunedPaths = d.removeIBGPRoutesIfEBGPExist(updatedPaths, prunedPaths)
 	}
 
+	if len(updatedPaths) > 1 {
+		d.logger.Info("calling getRoutesWithLowestIGPCost, update paths =", updatedPaths)
+		updatedPaths, prunedPaths = d.getRoutesWithLowestIGPCost(updatedPaths, prunedPaths)
+	}
+
 	if len(updatedPaths) > 1 && ibgpMultiPath != ebgpMultiPath {
 		if ebgpMultiPath && d.isEBGPRoute(updatedPaths[0]) {
 			ecmpPaths = d.getECMPPaths(updatedPaths)
@@ -1043,6 +1317,11 @@ func (d *Destination) calculateBestPath(updatedPaths, removedPaths []*Path, ebgp
 		}
 	}
 
+	if len(updatedPaths) > 1 {
+		d.logger.Info("calling getOldestEBGPPath, update paths =", updatedPaths)
+		updatedPaths, prunedPaths = d.getOldestEBGPPath(updatedPaths, prunedPaths)
+	}
+
 	if len(updatedPaths) > 1 {
 		d.logger.Info("calling getRoutesWithLowestBGPId, update paths =", updatedPaths)
 		updatedPaths, prunedPaths = d.getRoutesWithLowestBGPId(updatedPaths, prunedPaths)
@@ -224,7 +224,7 @@ func TestSelectRouteForLocRib(t *testing.T) {
 
 	// Add path with id 2 from neighbor1
 	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
-	nConf.SetPeerAttrs(net.ParseIP(peerIP), 4, 3, 1, nil)
+	nConf.SetPeerAttrs(net.ParseIP(peerIP), 4, 3, 1, nil, false, false, nil)
 	pathAttrs := constructPathAttrs(pConf.NeighborAddress, pConf.PeerAS, pConf.PeerAS+1)
 	path := NewPath(locRib, nConf, pathAttrs, nil, RouteTypeEGP)
 	reachInfo := NewReachabilityInfo("192.168.0.101", 0, 0, 0)
@@ -237,7 +237,7 @@ func TestSelectRouteForLocRib(t *testing.T) {
 	peerIP2 := "172.16.0.1"
 	pConf2 := getNeighborConf(peerIP2, 0, 5432)
 	nConf2 := base.NewNeighborConf(logger, gConf, nil, *pConf2)
-	nConf.SetPeerAttrs(net.ParseIP(peerIP2), 4, 3, 1, nil)
+	nConf.SetPeerAttrs(net.ParseIP(peerIP2), 4, 3, 1, nil, false, false, nil)
 	pathAttrs2 := constructPathAttrs(pConf2.NeighborAddress, pConf2.PeerAS, pConf2.PeerAS+2)
 	path2 := NewPath(locRib, nConf2, pathAttrs2, nil, RouteTypeEGP)
 	reachInfo2 := NewReachabilityInfo("172.16.0.2", 0, 0, 0)
@@ -262,3 +262,87 @@ func TestSelectRouteForLocRib(t *testing.T) {
 	action, addPathsMod, _, _, _ = dest.SelectRouteForLocRib(2)
 	t.Log("SelectRouteForLocRib returned action:", action, "addPaths updated:", addPathsMod)
 }
+
+func constructPathWithMED(logger *logging.Writer, locRib *LocRib, gConf *config.GlobalConfig,
+	peerIP string, peerAS, med uint32) *Path {
+	pConf := getNeighborConf(peerIP, gConf.AS, peerAS)
+	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
+	nConf.SetPeerAttrs(net.ParseIP(peerIP), 4, 3, 1, nil, false, false, nil)
+	pathAttrs := constructPathAttrs(pConf.NeighborAddress, peerAS, peerAS+1)
+	medAttr := packet.NewBGPPathAttrMultiExitDisc()
+	medAttr.Value = med
+	pathAttrs = append(pathAttrs, medAttr)
+	path := NewPath(locRib, nConf, pathAttrs, nil, RouteTypeEGP)
+	path.SetReachabilityForNextHop(pConf.NeighborAddress.String(), NewReachabilityInfo(peerIP, 0, 0, 0))
+	return path
+}
+
+func TestSelectRouteForLocRibMED(t *testing.T) {
+	logger := getLogger(t)
+	gConf, _ := getConfObjects("192.168.0.100", uint32(1234), uint32(4321))
+	locRib, dest := constructRibAndDest(t, logger, gConf)
+
+	// Lower MED from the same neighbor AS wins over the lower neighbor address
+	path := constructPathWithMED(logger, locRib, gConf, "192.168.0.100", 4321, 20)
+	dest.AddOrUpdatePath("192.168.0.100", 1, path)
+	path2 := constructPathWithMED(logger, locRib, gConf, "192.168.0.200", 4321, 10)
+	dest.AddOrUpdatePath("192.168.0.200", 1, path2)
+	dest.SelectRouteForLocRib(0)
+	if dest.LocRibPath != path2 {
+		t.Fatal("Expected path with lower MED to be selected, selected path:", dest.LocRibPath)
+	}
+	if dest.GetBestPathReason() != BestPathReasonMED {
+		t.Fatal("Expected best path reason", BestPathReasonMED, "got", dest.GetBestPathReason())
+	}
+
+	// MED is not compared across neighbor ASes unless always-compare-med is set
+	gConf, _ = getConfObjects("192.168.0.100", uint32(1234), uint32(4321))
+	locRib, dest = constructRibAndDest(t, logger, gConf)
+	path = constructPathWithMED(logger, locRib, gConf, "192.168.0.100", 4321, 20)
+	dest.AddOrUpdatePath("192.168.0.100", 1, path)
+	path2 = constructPathWithMED(logger, locRib, gConf, "192.168.0.200", 5321, 10)
+	dest.AddOrUpdatePath("192.168.0.200", 1, path2)
+	dest.SelectRouteForLocRib(0)
+	if dest.GetBestPathReason() == BestPathReasonMED {
+		t.Fatal("MED compared for paths from different neighbor ASes")
+	}
+
+	gConf.AlwaysCompareMED = true
+	gConf.DeterministicMED = true
+	dest.recalculate = true
+	dest.SelectRouteForLocRib(0)
+	if dest.LocRibPath != path2 || dest.GetBestPathReason() != BestPathReasonMED {
+		t.Fatal("Expected path with lower MED to be selected with always-compare-med, selected path:",
+			dest.LocRibPath, "reason:", dest.GetBestPathReason())
+	}
+}
+
+func TestConstructRouteConfigVPNLabels(t *testing.T) {
+	logger := getLogger(t)
+	peerIP := "192.168.0.100"
+	gConf, pConf := getConfObjects(peerIP, uint32(1234), uint32(4321))
+	locRib := NewVRFLocRib(logger, &RouteMgr{t}, nil, gConf, "red")
+	protoFamily := packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast)
+	dest := NewDestination(locRib, packet.NewIPPrefix(net.ParseIP("20.1.10.0"), 24), protoFamily, gConf)
+
+	// The same prefix imported with two route distinguishers from one PE is kept as two paths
+	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
+	path := NewPath(locRib, nConf, constructPathAttrs(pConf.NeighborAddress, pConf.PeerAS), nil, RouteTypeEGP)
+	path.SetLabels([]uint32{100})
+	dest.AddOrUpdatePath(peerIP, 1, path)
+	path2 := NewPath(locRib, nConf, constructPathAttrs(pConf.NeighborAddress, pConf.PeerAS), nil, RouteTypeEGP)
+	path2.SetLabels([]uint32{200})
+	dest.AddOrUpdatePath(peerIP, 2, path2)
+	if len(dest.peerPathMap[peerIP]) != 2 {
+		t.Fatal("Expected 2 paths from", peerIP, "got", len(dest.peerPathMap[peerIP]))
+	}
+
+	reachInfo := &ReachabilityInfo{NextHop: "30.1.1.1", NextHopIfIdx: 1}
+	cfg := dest.ConstructRouteConfig(path2, reachInfo, 4)
+	if cfg.Vrf != "red" || len(cfg.Labels) != 1 || cfg.Labels[0] != 200 {
+		t.Fatal("Expected route in vrf red with label 200, got vrf", cfg.Vrf, "labels", cfg.Labels)
+	}
+	if cfg.NextHopIp != "30.1.1.1" {
+		t.Fatal("Expected next hop 30.1.1.1, got", cfg.NextHopIp)
+	}
+}
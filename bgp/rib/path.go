@@ -33,6 +33,7 @@ import (
 	_ "ribd"
 	"strconv"
 	"strings"
+	"time"
 	"utils/logging"
 )
 
@@ -83,6 +84,9 @@ type Path struct {
 	MED                uint32
 	LocalPref          uint32
 	AggregatedPaths    map[string]*Path
+	stale              bool
+	receivedTime       time.Time
+	labels             []uint32
 }
 
 func NewPath(locRib *LocRib, peer *base.NeighborConf, pa []packet.BGPPathAttr,
@@ -95,6 +99,7 @@ func NewPath(locRib *LocRib, peer *base.NeighborConf, pa []packet.BGPPathAttr,
 		nhReachabilityInfo: make(map[uint32]*NHReachabilityInfo),
 		routeType:          routeType,
 		AggregatedPaths:    make(map[string]*Path),
+		receivedTime:       time.Now(),
 	}
 
 	path.logger.Info("Path:NewPath - path attr =", pa, "path.path attrs =", path.PathAttrs)
@@ -114,11 +119,21 @@ func (p *Path) Clone() *Path {
 		routeType:          p.routeType,
 		MED:                p.MED,
 		LocalPref:          p.LocalPref,
+		stale:              p.stale,
+		receivedTime:       p.receivedTime,
+		labels:             p.labels,
 	}
 
 	return path
 }
 
+func (p *Path) CloneWithPathAttrs(pa []packet.BGPPathAttr) *Path {
+	path := p.Clone()
+	path.PathAttrs = pa
+	path.AggregatedPaths = make(map[string]*Path)
+	return path
+}
+
 func (p *Path) calculatePref() uint32 {
 	var pref uint32
 
@@ -243,6 +258,37 @@ func (p *Path) GetAS4ByteList() []string {
 	return asList
 }
 
+func (p *Path) GetCommunityList() []string {
+	comms := packet.GetCommunities(p.PathAttrs)
+	commList := make([]string, 0, len(comms))
+	for _, comm := range comms {
+		commList = append(commList, packet.CommunityToStr(comm))
+	}
+	return commList
+}
+
+func (p *Path) GetExtCommunityList() []string {
+	extComms := packet.GetExtCommunities(p.PathAttrs)
+	extCommList := make([]string, 0, len(extComms))
+	for _, extComm := range extComms {
+		extCommList = append(extCommList, extComm.String())
+	}
+	return extCommList
+}
+
+func (p *Path) GetLargeCommunityList() []string {
+	largeComms := packet.GetLargeCommunities(p.PathAttrs)
+	largeCommList := make([]string, 0, len(largeComms))
+	for _, largeComm := range largeComms {
+		largeCommList = append(largeCommList, largeComm.String())
+	}
+	return largeCommList
+}
+
+func (p *Path) HasCommunity(comm uint32) bool {
+	return packet.HasCommunity(p.PathAttrs, comm)
+}
+
 func (p *Path) HasASLoop() bool {
 	if p.NeighborConf == nil {
 		return false
@@ -250,6 +296,26 @@ func (p *Path) HasASLoop() bool {
 	return packet.HasASLoop(p.PathAttrs, p.NeighborConf.RunningConf.LocalAS)
 }
 
+// IsStale returns true if the path was retained after the graceful restart of the neighbor and is not yet
+// refreshed by the neighbor.
+func (p *Path) IsStale() bool {
+	return p.stale
+}
+
+func (p *Path) SetStale(stale bool) {
+	p.stale = stale
+}
+
+// GetLabels returns the MPLS label stack to push toward the next hop of the path. It is set on the VPN paths
+// imported to a VRF.
+func (p *Path) GetLabels() []uint32 {
+	return p.labels
+}
+
+func (p *Path) SetLabels(labels []uint32) {
+	p.labels = labels
+}
+
 func (p *Path) IsLocal() bool {
 	return getRouteSource(p.routeType) == RouteSrcLocal
 }
@@ -279,6 +345,19 @@ func (p *Path) GetOrigin() uint8 {
 	return packet.GetOrigin(p.PathAttrs)
 }
 
+// GetNeighborAS returns the AS from which the path was received. Paths with
+// an empty AS path are treated as coming from the local AS.
+func (p *Path) GetNeighborAS() uint32 {
+	if as := packet.GetNeighborAS(p.PathAttrs); as != 0 {
+		return as
+	}
+	return p.rib.gConf.AS
+}
+
+func (p *Path) GetReceivedTime() time.Time {
+	return p.receivedTime
+}
+
 func (p *Path) GetNextHop(protoFamily uint32) net.IP {
 	if nhReachInfo, ok := p.nhReachabilityInfo[protoFamily]; ok {
 		return nhReachInfo.nextHop
@@ -321,6 +400,15 @@ func (p *Path) GetReachability(protoFamily uint32) *ReachabilityInfo {
 	return nil
 }
 
+// GetIGPCost returns the IGP metric to the next hop of the path for the
+// given family. Unresolved next hops have cost 0.
+func (p *Path) GetIGPCost(protoFamily uint32) uint32 {
+	if reachabilityInfo := p.GetReachability(protoFamily); reachabilityInfo != nil && reachabilityInfo.Metric > 0 {
+		return uint32(reachabilityInfo.Metric)
+	}
+	return 0
+}
+
 func (p *Path) IsReachable(protoFamily uint32) bool {
 	if p.routeType == RouteTypeStatic || p.routeType == RouteTypeConnected || p.routeType == RouteTypeIGP {
 		return true
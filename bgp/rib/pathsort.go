@@ -88,6 +88,14 @@ func (b ByLowestOrigin) Less(i, j int) bool {
 	return b.Paths[i].GetOrigin() < b.Paths[j].GetOrigin()
 }
 
+type ByLowestMED struct {
+	Paths
+}
+
+func (b ByLowestMED) Less(i, j int) bool {
+	return b.Paths[i].MED < b.Paths[j].MED
+}
+
 type ByIBGPOrEBGPRoutes struct {
 	Paths
 }
@@ -96,6 +104,23 @@ func (b ByIBGPOrEBGPRoutes) Less(i, j int) bool {
 	return true
 }
 
+type ByLowestIGPCost struct {
+	Paths
+	protoFamily uint32
+}
+
+func (b ByLowestIGPCost) Less(i, j int) bool {
+	return b.Paths[i].GetIGPCost(b.protoFamily) < b.Paths[j].GetIGPCost(b.protoFamily)
+}
+
+type ByOldestPath struct {
+	Paths
+}
+
+func (b ByOldestPath) Less(i, j int) bool {
+	return b.Paths[i].GetReceivedTime().Before(b.Paths[j].GetReceivedTime())
+}
+
 type ByLowestBGPId struct {
 	Paths
 }
@@ -73,6 +73,7 @@ type LocRib struct {
 	routeListDirty   map[uint32]bool
 	activeGet        map[uint32]bool
 	timer            map[uint32]*time.Timer
+	vrf              string
 }
 
 func NewLocRib(logger *logging.Writer, rMgr config.RouteMgrIntf, sDBMgr statedbclient.StateDBClient,
@@ -96,6 +97,18 @@ func NewLocRib(logger *logging.Writer, rMgr config.RouteMgrIntf, sDBMgr statedbc
 	return rib
 }
 
+// NewVRFLocRib creates the Loc-RIB of a VRF. The routes selected in the VRF Loc-RIB are installed in the VRF.
+func NewVRFLocRib(logger *logging.Writer, rMgr config.RouteMgrIntf, sDBMgr statedbclient.StateDBClient,
+	gConf *config.GlobalConfig, vrf string) *LocRib {
+	rib := NewLocRib(logger, rMgr, sDBMgr, gConf)
+	rib.vrf = vrf
+	return rib
+}
+
+func (l *LocRib) GetVrf() string {
+	return l.vrf
+}
+
 func isIpInList(prefixes []packet.NLRI, ip packet.NLRI) bool {
 	for _, nlri := range prefixes {
 		if nlri.GetPathId() == ip.GetPathId() &&
@@ -239,7 +252,7 @@ func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, r
 			updated, withdrawn, updatedAddPaths = l.updateRibOutInfo(action, addPathsMod, addRoutes, updRoutes,
 				delRoutes, dest, updated, withdrawn, updatedAddPaths)
 
-			if oldPath != nil && remPath != nil {
+			if oldPath != nil && remPath != nil && l.vrf == "" {
 				if neighborConf := remPath.GetNeighborConf(); neighborConf != nil {
 					l.logger.Infof("Decrement prefix count for destination %s from Peer %s",
 						nlri.GetCIDR(), peerIP)
@@ -274,7 +287,9 @@ func (l *LocRib) ProcessRoutes(peerIP string, add, rem []packet.NLRI, addPath, r
 		if !alreadyCreated {
 			op = l.stateDBMgr.AddObject
 		}
-		if oldPath := dest.getPathForIP(peerIP, nlri.GetPathId()); oldPath == nil && addPath.NeighborConf != nil {
+		// Paths imported to a VRF are already counted against the prefix limit of the peer
+		if oldPath := dest.getPathForIP(peerIP, nlri.GetPathId()); oldPath == nil && addPath.NeighborConf != nil &&
+			l.vrf == "" {
 			if !addPath.NeighborConf.CanAcceptNewPrefix() {
 				l.logger.Infof("Max prefixes limit reached for peer %s, can't process %s", peerIP,
 					nlri.GetCIDR())
@@ -509,6 +524,14 @@ func (l *LocRib) RemoveUpdatesFromNeighbor(peerIP string, neighborConf *base.Nei
 	return updated, withdrawn, updatedAddPaths
 }
 
+// MarkStaleUpdatesFromNeighbor marks the paths received from the neighbor for the protocol family as stale
+// when the neighbor restarts gracefully.
+func (l *LocRib) MarkStaleUpdatesFromNeighbor(peerIP string, protoFamily uint32) {
+	for _, dest := range l.destPathMap[protoFamily] {
+		dest.MarkStalePaths(peerIP)
+	}
+}
+
 func (l *LocRib) RemoveUpdatesFromAllNeighbors(addPathCount int) {
 	withdrawn := make([]*Destination, 0)
 	updated := make(map[uint32]map[*Path][]*Destination)
@@ -548,6 +571,14 @@ func (l *LocRib) GetLocRib() map[uint32]map[*Path][]*Destination {
 	return updated
 }
 
+func (l *LocRib) GetDestinations(protoFamily uint32) []*Destination {
+	dests := make([]*Destination, 0, len(l.destPathMap[protoFamily]))
+	for _, dest := range l.destPathMap[protoFamily] {
+		dests = append(dests, dest)
+	}
+	return dests
+}
+
 func (l *LocRib) RemoveRouteFromAggregate(ip *packet.IPPrefix, aggIP *packet.IPPrefix, srcIP string,
 	protoFamily uint32, bgpAgg *config.BGPAggregate, ipDest *Destination, addPathCount int) (
 	map[uint32]map[*Path][]*Destination, []*Destination, []*Destination) {
@@ -54,18 +54,21 @@ type Route struct {
 func NewRoute(dest *Destination, path *Path, action RouteAction, inPathId, outPathId uint32) *Route {
 	currTime := time.Now()
 	pathInfo := &bgpd.PathInfo{
-		NextHop:        path.GetNextHop(dest.protoFamily).String(),
-		Metric:         int32(path.MED),
-		LocalPref:      int32(path.LocalPref),
-		Path:           path.GetAS4ByteList(),
-		PathId:         int32(inPathId),
-		UpdatedTime:    currTime.String(),
-		ValidPath:      path.IsReachable(dest.protoFamily),
-		BestPath:       false,
-		MultiPath:      false,
-		AdditionalPath: false,
-		Origin:         packet.GetOriginTypeStr(path.GetOrigin()),
-		PathType:       path.GetSourceStr(),
+		NextHop:          path.GetNextHop(dest.protoFamily).String(),
+		Metric:           int32(path.MED),
+		LocalPref:        int32(path.LocalPref),
+		Path:             path.GetAS4ByteList(),
+		PathId:           int32(inPathId),
+		UpdatedTime:      currTime.String(),
+		ValidPath:        path.IsReachable(dest.protoFamily),
+		BestPath:         false,
+		MultiPath:        false,
+		AdditionalPath:   false,
+		Origin:           packet.GetOriginTypeStr(path.GetOrigin()),
+		PathType:         path.GetSourceStr(),
+		Communities:      path.GetCommunityList(),
+		ExtCommunities:   path.GetExtCommunityList(),
+		LargeCommunities: path.GetLargeCommunityList(),
 	}
 	return &Route{
 		PathInfo:         pathInfo,
@@ -79,6 +82,10 @@ func NewRoute(dest *Destination, path *Path, action RouteAction, inPathId, outPa
 	}
 }
 
+func (r *Route) GetPath() *Path {
+	return r.path
+}
+
 func (r *Route) setAction(action RouteAction) {
 	r.action = action
 }
@@ -93,6 +100,11 @@ func (r *Route) SetBestPath() {
 
 func (r *Route) ResetBestPath() {
 	r.PathInfo.BestPath = false
+	r.PathInfo.BestPathReason = ""
+}
+
+func (r *Route) setBestPathReason(reason string) {
+	r.PathInfo.BestPathReason = reason
 }
 
 func (r *Route) SetMultiPath() {
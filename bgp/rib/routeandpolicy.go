@@ -41,9 +41,11 @@ type AdjRIBRoute struct {
 	ProtocolFamily   uint32
 	NLRI             packet.NLRI
 	PathMap          map[uint32]*Path
+	PrePolicyPathMap map[uint32]*Path
 	PolicyList       []string
 	PolicyHitCounter int
 	Accept           bool
+	CommunityActions []string
 }
 
 func NewAdjRIBRoute(neighbor net.IP, protoFamily uint32, nlri packet.NLRI) *AdjRIBRoute {
@@ -52,6 +54,7 @@ func NewAdjRIBRoute(neighbor net.IP, protoFamily uint32, nlri packet.NLRI) *AdjR
 		ProtocolFamily:   protoFamily,
 		NLRI:             nlri,
 		PathMap:          make(map[uint32]*Path),
+		PrePolicyPathMap: make(map[uint32]*Path),
 		PolicyList:       make([]string, 0),
 		PolicyHitCounter: 0,
 		Accept:           false,
@@ -60,10 +63,23 @@ func NewAdjRIBRoute(neighbor net.IP, protoFamily uint32, nlri packet.NLRI) *AdjR
 
 func (a *AdjRIBRoute) AddPath(pathId uint32, path *Path) {
 	a.PathMap[pathId] = path
+	delete(a.PrePolicyPathMap, pathId)
+}
+
+// AddPolicyPath adds the path with the policy actions applied. The path as it was received is kept as the
+// pre-policy path.
+func (a *AdjRIBRoute) AddPolicyPath(pathId uint32, path, policyPath *Path) {
+	a.PathMap[pathId] = policyPath
+	if path == policyPath {
+		delete(a.PrePolicyPathMap, pathId)
+		return
+	}
+	a.PrePolicyPathMap[pathId] = path
 }
 
 func (a *AdjRIBRoute) RemovePath(pathId uint32) {
 	delete(a.PathMap, pathId)
+	delete(a.PrePolicyPathMap, pathId)
 }
 
 func (a *AdjRIBRoute) GetPath(pathId uint32) *Path {
@@ -78,9 +94,18 @@ func (a *AdjRIBRoute) GetPathMap() map[uint32]*Path {
 	return a.PathMap
 }
 
+// GetPrePolicyPath returns the path as it was received, before the policy actions were applied.
+func (a *AdjRIBRoute) GetPrePolicyPath(pathId uint32) *Path {
+	if path, ok := a.PrePolicyPathMap[pathId]; ok {
+		return path
+	}
+	return a.PathMap[pathId]
+}
+
 func (a *AdjRIBRoute) RemoveAllPaths() {
 	a.PathMap = nil
 	a.PathMap = make(map[uint32]*Path)
+	a.PrePolicyPathMap = make(map[uint32]*Path)
 }
 
 type FilteredRoutes struct {
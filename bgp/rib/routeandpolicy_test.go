@@ -0,0 +1,69 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// routeandpolicy_test.go
+package rib
+
+import (
+	"l3/bgp/baseobjects"
+	"l3/bgp/packet"
+	"net"
+	"testing"
+)
+
+func TestAdjRIBRoutePrePolicyPath(t *testing.T) {
+	logger := getLogger(t)
+	gConf, pConf := getConfObjects("192.168.0.100", uint32(1234), uint32(4321))
+	nConf := base.NewNeighborConf(logger, gConf, nil, *pConf)
+	pathAttrs := constructPathAttrs(pConf.NeighborAddress, pConf.PeerAS)
+	locRib := NewLocRib(logger, nil, nil, gConf)
+	path := NewPath(locRib, nConf, pathAttrs, nil, RouteTypeEGP)
+	policyPath := path.CloneWithPathAttrs(packet.SetCommunities(pathAttrs, []uint32{0x00640001}))
+
+	nlri := packet.NewIPPrefix(net.ParseIP("20.1.10.0"), 24)
+	route := NewAdjRIBRoute(pConf.NeighborAddress, packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast), nlri)
+	route.AddPath(1, path)
+	if route.GetPrePolicyPath(1) != path {
+		t.Error("Pre-policy path of a path without policy actions is not the received path")
+	}
+
+	route.AddPolicyPath(1, path, policyPath)
+	if route.GetPath(1) != policyPath {
+		t.Error("Path with the policy actions applied is not installed")
+	}
+	if route.GetPrePolicyPath(1) != path {
+		t.Error("Pre-policy path is not the received path")
+	}
+
+	// The path is received again and the policy does not modify it anymore
+	route.AddPath(1, path)
+	if route.GetPrePolicyPath(1) != path || len(route.PrePolicyPathMap) != 0 {
+		t.Error("Pre-policy path not cleared, pre-policy paths:", route.PrePolicyPathMap)
+	}
+
+	route.AddPolicyPath(1, path, policyPath)
+	route.RemovePath(1)
+	if route.GetPrePolicyPath(1) != nil || len(route.PrePolicyPathMap) != 0 {
+		t.Error("Pre-policy path not removed with the path, pre-policy paths:", route.PrePolicyPathMap)
+	}
+}
@@ -91,6 +91,15 @@ func (h *BGPHandler) convertModelToBGPGlobalConfig(obj objects.BGPGlobal) (gConf
 			EBGPMaxPaths:        obj.EBGPMaxPaths,
 			EBGPAllowMultipleAS: obj.EBGPAllowMultipleAS,
 			IBGPMaxPaths:        obj.IBGPMaxPaths,
+			GracefulRestart:     obj.GracefulRestart,
+			RestartTime:         obj.RestartTime,
+			StalePathTime:       obj.StalePathTime,
+			AlwaysCompareMED:    obj.AlwaysCompareMED,
+			DeterministicMED:    obj.DeterministicMED,
+			MRTRIBDumpFile:      obj.MRTRIBDumpFile,
+			MRTRIBDumpInterval:  obj.MRTRIBDumpInterval,
+			MRTUpdatesFile:      obj.MRTUpdatesFile,
+			MRTUpdatesInterval:  obj.MRTUpdatesInterval,
 		},
 	}
 
@@ -107,6 +116,14 @@ func (h *BGPHandler) convertModelToBGPGlobalConfig(obj objects.BGPGlobal) (gConf
 		err = config.IPError{obj.RouterId}
 	}
 
+	if err == nil {
+		err = h.validateGracefulRestartConfig(&gConf.GlobalBase)
+	}
+
+	if err == nil {
+		h.validateMRTConfig(&gConf.GlobalBase)
+	}
+
 	return gConf, err
 }
 
@@ -168,6 +185,7 @@ func (h *BGPHandler) convertModelToBGPv4PeerGroup(obj objects.BGPv4PeerGroup) (g
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			MRTDumpUpdates:          obj.MRTDumpUpdates,
 		},
 		Name: obj.Name,
 	}
@@ -232,6 +250,7 @@ func (h *BGPHandler) convertModelToBGPv6PeerGroup(obj objects.BGPv6PeerGroup) (g
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			MRTDumpUpdates:          obj.MRTDumpUpdates,
 		},
 		Name: obj.Name,
 	}
@@ -309,6 +328,7 @@ func (h *BGPHandler) convertModelToBGPv4Neighbor(obj objects.BGPv4Neighbor) (nei
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			MRTDumpUpdates:          obj.MRTDumpUpdates,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -389,6 +409,7 @@ func (h *BGPHandler) convertModelToBGPv6Neighbor(obj objects.BGPv6Neighbor) (nei
 			MaxPrefixesRestartTimer: uint8(obj.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          obj.AdjRIBInFilter,
 			AdjRIBOutFilter:         obj.AdjRIBOutFilter,
+			MRTDumpUpdates:          obj.MRTDumpUpdates,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -486,6 +507,72 @@ func (h *BGPHandler) handleBGPv6Aggregate() error {
 	return nil
 }
 
+func (h *BGPHandler) handleBGPBMPStation() error {
+	var obj objects.BGPBMPStation
+	objList, err := h.dbUtil.GetAllObjFromDb(obj)
+	if err != nil {
+		h.logger.Errf("GetAllObjFromDb failed for BGPBMPStation with error %s", err)
+		return err
+	}
+
+	for _, confObj := range objList {
+		obj = confObj.(objects.BGPBMPStation)
+
+		stationConf, err := h.convertToBMPStationConfig(obj.Name, obj.Address, uint16(obj.Port),
+			uint16(obj.StatsInterval), uint16(obj.ConnectRetryTime))
+		if err != nil {
+			h.logger.Err("handleBGPBMPStation - Failed to convert Model object BGPBMPStation, error:", err)
+			return err
+		}
+		h.server.AddBMPStationCh <- server.BMPStationUpdate{config.BMPStationConfig{}, stationConf}
+	}
+	return nil
+}
+
+func (h *BGPHandler) handleBGPVRF() error {
+	var obj objects.BGPVRF
+	objList, err := h.dbUtil.GetAllObjFromDb(obj)
+	if err != nil {
+		h.logger.Errf("GetAllObjFromDb failed for BGPVRF with error %s", err)
+		return err
+	}
+
+	for _, confObj := range objList {
+		obj = confObj.(objects.BGPVRF)
+
+		vrfConf, err := h.convertToVRFConfig(obj.Name, obj.RouteDistinguisher, obj.ImportRouteTargets,
+			obj.ExportRouteTargets, uint32(obj.Label), uint32(obj.L3VNI))
+		if err != nil {
+			h.logger.Err("handleBGPVRF - Failed to convert Model object BGPVRF, error:", err)
+			return err
+		}
+		h.server.AddVRFCh <- server.VRFUpdate{config.VRFConfig{}, vrfConf}
+	}
+	return nil
+}
+
+func (h *BGPHandler) handleBGPEVPNInstance() error {
+	var obj objects.BGPEVPNInstance
+	objList, err := h.dbUtil.GetAllObjFromDb(obj)
+	if err != nil {
+		h.logger.Errf("GetAllObjFromDb failed for BGPEVPNInstance with error %s", err)
+		return err
+	}
+
+	for _, confObj := range objList {
+		obj = confObj.(objects.BGPEVPNInstance)
+
+		eviConf, err := h.convertToEVIConfig(uint32(obj.Vni), obj.VlanId, obj.RouteDistinguisher,
+			obj.ImportRouteTargets, obj.ExportRouteTargets)
+		if err != nil {
+			h.logger.Err("handleBGPEVPNInstance - Failed to convert Model object BGPEVPNInstance, error:", err)
+			return err
+		}
+		h.server.AddEVICh <- server.EVIUpdate{config.EVIConfig{}, eviConf}
+	}
+	return nil
+}
+
 func (h *BGPHandler) ReadBGPConfigFromDB() error {
 	var err error
 	if err = h.handleGlobalConfig(); err != nil {
@@ -500,6 +587,18 @@ func (h *BGPHandler) ReadBGPConfigFromDB() error {
 		return err
 	}
 
+	if err = h.handleBGPBMPStation(); err != nil {
+		return err
+	}
+
+	if err = h.handleBGPVRF(); err != nil {
+		return err
+	}
+
+	if err = h.handleBGPEVPNInstance(); err != nil {
+		return err
+	}
+
 	if err = h.handleV4PeerGroup(); err != nil {
 		return err
 	}
@@ -527,6 +626,32 @@ func (h *BGPHandler) convertStrIPToNetIP(ip string) net.IP {
 	return netIP
 }
 
+func (h *BGPHandler) validateGracefulRestartConfig(gConf *config.GlobalBase) error {
+	if !gConf.GracefulRestart {
+		return nil
+	}
+
+	if gConf.RestartTime == 0 {
+		gConf.RestartTime = config.BGPGracefulRestartTimeDefault
+	}
+	if gConf.StalePathTime == 0 {
+		gConf.StalePathTime = config.BGPStalePathTimeDefault
+	}
+
+	if gConf.RestartTime > uint32(packet.BGPCapGracefulRestartTimeMax) {
+		h.logger.Info("SendBGPGlobal: Graceful restart time", gConf.RestartTime, "is more than",
+			packet.BGPCapGracefulRestartTimeMax, "seconds")
+		return errors.New(fmt.Sprintf("BGPGlobal: Graceful restart time %d is not valid", gConf.RestartTime))
+	}
+	return nil
+}
+
+func (h *BGPHandler) validateMRTConfig(gConf *config.GlobalBase) {
+	if gConf.MRTRIBDumpFile != "" && gConf.MRTRIBDumpInterval == 0 {
+		gConf.MRTRIBDumpInterval = config.BGPMRTRIBDumpIntervalDefault
+	}
+}
+
 func (h *BGPHandler) validateBGPGlobal(bgpGlobal *bgpd.BGPGlobal) (gConf config.GlobalConfig, err error) {
 	if bgpGlobal == nil {
 		return gConf, err
@@ -560,9 +685,23 @@ func (h *BGPHandler) validateBGPGlobal(bgpGlobal *bgpd.BGPGlobal) (gConf config.
 			EBGPMaxPaths:        uint32(bgpGlobal.EBGPMaxPaths),
 			EBGPAllowMultipleAS: bgpGlobal.EBGPAllowMultipleAS,
 			IBGPMaxPaths:        uint32(bgpGlobal.IBGPMaxPaths),
+			GracefulRestart:     bgpGlobal.GracefulRestart,
+			RestartTime:         uint32(bgpGlobal.RestartTime),
+			StalePathTime:       uint32(bgpGlobal.StalePathTime),
+			AlwaysCompareMED:    bgpGlobal.AlwaysCompareMED,
+			DeterministicMED:    bgpGlobal.DeterministicMED,
+			MRTRIBDumpFile:      bgpGlobal.MRTRIBDumpFile,
+			MRTRIBDumpInterval:  uint32(bgpGlobal.MRTRIBDumpInterval),
+			MRTUpdatesFile:      bgpGlobal.MRTUpdatesFile,
+			MRTUpdatesInterval:  uint32(bgpGlobal.MRTUpdatesInterval),
 		},
 	}
 
+	if err = h.validateGracefulRestartConfig(&gConf.GlobalBase); err != nil {
+		return gConf, err
+	}
+	h.validateMRTConfig(&gConf.GlobalBase)
+
 	if bgpGlobal.Redistribution != nil {
 		gConf.Redistribution = make([]config.SourcePolicyMap, 0)
 		for i := 0; i < len(bgpGlobal.Redistribution); i++ {
@@ -603,9 +742,23 @@ func (h *BGPHandler) validateBGPGlobalForPatchUpdate(oldConfig *bgpd.BGPGlobal,
 			EBGPMaxPaths:        uint32(oldConfig.EBGPMaxPaths),
 			EBGPAllowMultipleAS: oldConfig.EBGPAllowMultipleAS,
 			IBGPMaxPaths:        uint32(oldConfig.IBGPMaxPaths),
+			GracefulRestart:     oldConfig.GracefulRestart,
+			RestartTime:         uint32(oldConfig.RestartTime),
+			StalePathTime:       uint32(oldConfig.StalePathTime),
+			AlwaysCompareMED:    oldConfig.AlwaysCompareMED,
+			DeterministicMED:    oldConfig.DeterministicMED,
+			MRTRIBDumpFile:      oldConfig.MRTRIBDumpFile,
+			MRTRIBDumpInterval:  uint32(oldConfig.MRTRIBDumpInterval),
+			MRTUpdatesFile:      oldConfig.MRTUpdatesFile,
+			MRTUpdatesInterval:  uint32(oldConfig.MRTUpdatesInterval),
 		},
 	}
 
+	if err = h.validateGracefulRestartConfig(&gConf.GlobalBase); err != nil {
+		return gConf, err
+	}
+	h.validateMRTConfig(&gConf.GlobalBase)
+
 	for idx := 0; idx < len(op); idx++ {
 		h.logger.Debug("patch update")
 		switch op[idx].Path {
@@ -674,9 +827,23 @@ func (h *BGPHandler) validateBGPGlobalForUpdate(oldConfig *bgpd.BGPGlobal, newCo
 			EBGPMaxPaths:        uint32(newConfig.EBGPMaxPaths),
 			EBGPAllowMultipleAS: newConfig.EBGPAllowMultipleAS,
 			IBGPMaxPaths:        uint32(newConfig.IBGPMaxPaths),
+			GracefulRestart:     newConfig.GracefulRestart,
+			RestartTime:         uint32(newConfig.RestartTime),
+			StalePathTime:       uint32(newConfig.StalePathTime),
+			AlwaysCompareMED:    newConfig.AlwaysCompareMED,
+			DeterministicMED:    newConfig.DeterministicMED,
+			MRTRIBDumpFile:      newConfig.MRTRIBDumpFile,
+			MRTRIBDumpInterval:  uint32(newConfig.MRTRIBDumpInterval),
+			MRTUpdatesFile:      newConfig.MRTUpdatesFile,
+			MRTUpdatesInterval:  uint32(newConfig.MRTUpdatesInterval),
 		},
 	}
 
+	if err = h.validateGracefulRestartConfig(&gConf.GlobalBase); err != nil {
+		return gConf, err
+	}
+	h.validateMRTConfig(&gConf.GlobalBase)
+
 	if newConfig.Redistribution != nil {
 		gConf.Redistribution = make([]config.SourcePolicyMap, 0)
 		for i := 0; i < len(newConfig.Redistribution); i++ {
@@ -754,9 +921,20 @@ func (h *BGPHandler) GetBGPGlobalState(vrfId string) (*bgpd.BGPGlobalState, erro
 	bgpGlobalResponse.EBGPMaxPaths = int32(bgpGlobal.EBGPMaxPaths)
 	bgpGlobalResponse.EBGPAllowMultipleAS = bgpGlobal.EBGPAllowMultipleAS
 	bgpGlobalResponse.IBGPMaxPaths = int32(bgpGlobal.IBGPMaxPaths)
+	bgpGlobalResponse.GracefulRestart = bgpGlobal.GracefulRestart
+	bgpGlobalResponse.RestartTime = int32(bgpGlobal.RestartTime)
+	bgpGlobalResponse.StalePathTime = int32(bgpGlobal.StalePathTime)
+	bgpGlobalResponse.AlwaysCompareMED = bgpGlobal.AlwaysCompareMED
+	bgpGlobalResponse.DeterministicMED = bgpGlobal.DeterministicMED
+	bgpGlobalResponse.MRTRIBDumpFile = bgpGlobal.MRTRIBDumpFile
+	bgpGlobalResponse.MRTRIBDumpInterval = int32(bgpGlobal.MRTRIBDumpInterval)
+	bgpGlobalResponse.MRTUpdatesFile = bgpGlobal.MRTUpdatesFile
+	bgpGlobalResponse.MRTUpdatesInterval = int32(bgpGlobal.MRTUpdatesInterval)
 	bgpGlobalResponse.TotalPaths = int32(bgpGlobal.TotalPaths)
 	bgpGlobalResponse.Totalv4Prefixes = int32(bgpGlobal.Totalv4Prefixes)
 	bgpGlobalResponse.Totalv6Prefixes = int32(bgpGlobal.Totalv6Prefixes)
+	bgpGlobalResponse.TotalVPNv4Prefixes = int32(bgpGlobal.TotalVPNv4Prefixes)
+	bgpGlobalResponse.TotalVPNv6Prefixes = int32(bgpGlobal.TotalVPNv6Prefixes)
 	return bgpGlobalResponse, nil
 }
 
@@ -883,6 +1061,7 @@ func (h *BGPHandler) ConvertV4NeighborFromThrift(bgpNeighbor *bgpd.BGPv4Neighbor
 			MaxPrefixesRestartTimer: uint8(bgpNeighbor.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          bgpNeighbor.AdjRIBInFilter,
 			AdjRIBOutFilter:         bgpNeighbor.AdjRIBOutFilter,
+			MRTDumpUpdates:          bgpNeighbor.MRTDumpUpdates,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -1029,6 +1208,7 @@ func (h *BGPHandler) convertToThriftV4Neighbor(neighborState *config.NeighborSta
 	bgpNeighborResponse.TotalPrefixes = int32(neighborState.TotalPrefixes)
 	bgpNeighborResponse.AdjRIBInFilter = neighborState.AdjRIBInFilter
 	bgpNeighborResponse.AdjRIBOutFilter = neighborState.AdjRIBOutFilter
+	bgpNeighborResponse.MRTDumpUpdates = neighborState.MRTDumpUpdates
 
 	received := bgpd.NewBGPCounters()
 	received.Notification = int64(neighborState.Messages.Received.Notification)
@@ -1170,6 +1350,7 @@ func (h *BGPHandler) ConvertV6NeighborFromThrift(bgpNeighbor *bgpd.BGPv6Neighbor
 			MaxPrefixesRestartTimer: uint8(bgpNeighbor.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          bgpNeighbor.AdjRIBInFilter,
 			AdjRIBOutFilter:         bgpNeighbor.AdjRIBOutFilter,
+			MRTDumpUpdates:          bgpNeighbor.MRTDumpUpdates,
 		},
 		NeighborAddress: ip,
 		IfIndex:         ifIndex,
@@ -1309,6 +1490,7 @@ func (h *BGPHandler) convertToThriftV6Neighbor(neighborState *config.NeighborSta
 	bgpNeighborResponse.TotalPrefixes = int32(neighborState.TotalPrefixes)
 	bgpNeighborResponse.AdjRIBInFilter = neighborState.AdjRIBInFilter
 	bgpNeighborResponse.AdjRIBOutFilter = neighborState.AdjRIBOutFilter
+	bgpNeighborResponse.MRTDumpUpdates = neighborState.MRTDumpUpdates
 
 	received := bgpd.NewBGPCounters()
 	received.Notification = int64(neighborState.Messages.Received.Notification)
@@ -1435,6 +1617,7 @@ func (h *BGPHandler) ValidateBGPv4PeerGroup(peerGroup *bgpd.BGPv4PeerGroup) (gro
 			MaxPrefixesRestartTimer: uint8(peerGroup.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          peerGroup.AdjRIBInFilter,
 			AdjRIBOutFilter:         peerGroup.AdjRIBOutFilter,
+			MRTDumpUpdates:          peerGroup.MRTDumpUpdates,
 		},
 		Name: peerGroup.Name,
 	}
@@ -1531,6 +1714,7 @@ func (h *BGPHandler) ValidateBGPv6PeerGroup(peerGroup *bgpd.BGPv6PeerGroup) (gro
 			MaxPrefixesRestartTimer: uint8(peerGroup.MaxPrefixesRestartTimer),
 			AdjRIBInFilter:          peerGroup.AdjRIBInFilter,
 			AdjRIBOutFilter:         peerGroup.AdjRIBOutFilter,
+			MRTDumpUpdates:          peerGroup.MRTDumpUpdates,
 		},
 		Name: peerGroup.Name,
 	}
@@ -1648,6 +1832,15 @@ func (h *BGPHandler) CreateBGPPolicyCondition(cfg *bgpd.BGPPolicyCondition) (val
 		val = true
 		h.bgpPolicyMgr.ConditionCfgCh <- *policyCfg
 		break
+	case bgppolicy.CommunityConditionType:
+		if _, err = bgppolicy.NewCommunitySet(cfg.Communities); err != nil {
+			break
+		}
+		val = true
+		h.bgpPolicyMgr.CommunityConditionCfgCh <- bgppolicy.CommunityConditionConfig{
+			Name:        cfg.Name,
+			Communities: cfg.Communities,
+		}
 	default:
 		h.logger.Info("Unknown condition type ", cfg.ConditionType)
 		err = errors.New(fmt.Sprintf("Unknown condition type %s", cfg.ConditionType))
@@ -1694,6 +1887,16 @@ func (h *BGPHandler) CreateBGPPolicyAction(cfg *bgpd.BGPPolicyAction) (val bool,
 		val = true
 		h.bgpPolicyMgr.ActionCfgCh <- *actionCfg
 		break
+	case bgppolicy.SetCommunityActionType, bgppolicy.AddCommunityActionType, bgppolicy.DeleteCommunityActionType:
+		if _, err = bgppolicy.NewCommunitySet(cfg.Communities); err != nil {
+			break
+		}
+		val = true
+		h.bgpPolicyMgr.CommunityActionCfgCh <- bgppolicy.CommunityActionConfig{
+			Name:        cfg.Name,
+			ActionType:  cfg.ActionType,
+			Communities: cfg.Communities,
+		}
 	default:
 		h.logger.Info("Unknown action type ", cfg.ActionType)
 		err = errors.New(fmt.Sprintf("Unknown action type %s", cfg.ActionType))
@@ -1951,6 +2154,275 @@ func (h *BGPHandler) DeleteBGPv6Aggregate(bgpAgg *bgpd.BGPv6Aggregate) (bool, er
 	return true, nil
 }
 
+func (h *BGPHandler) convertToBMPStationConfig(name, address string, port, statsInterval,
+	connectRetryTime uint16) (stationConf config.BMPStationConfig, err error) {
+	if name == "" {
+		err = errors.New("BGPBMPStation: Name is not set")
+		return stationConf, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		err = errors.New(fmt.Sprintf("BGPBMPStation: Address %s is not a valid IP", address))
+		h.logger.Info("BGPBMPStation: Address", address, "is not a valid IP")
+		return stationConf, err
+	}
+
+	if port == 0 {
+		err = errors.New(fmt.Sprintf("BGPBMPStation: Port is not set for station %s", name))
+		return stationConf, err
+	}
+
+	stationConf = config.BMPStationConfig{
+		Name:             name,
+		Address:          ip,
+		Port:             port,
+		StatsInterval:    statsInterval,
+		ConnectRetryTime: connectRetryTime,
+	}
+	return stationConf, nil
+}
+
+func (h *BGPHandler) validateBGPBMPStation(station *bgpd.BGPBMPStation) (config.BMPStationConfig, error) {
+	if station == nil {
+		return config.BMPStationConfig{}, nil
+	}
+
+	return h.convertToBMPStationConfig(station.Name, station.Address, uint16(station.Port),
+		uint16(station.StatsInterval), uint16(station.ConnectRetryTime))
+}
+
+func (h *BGPHandler) SendBGPBMPStation(oldConfig *bgpd.BGPBMPStation, newConfig *bgpd.BGPBMPStation) (bool, error) {
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	oldStation, err := h.validateBGPBMPStation(oldConfig)
+	if err != nil {
+		return false, err
+	}
+
+	newStation, err := h.validateBGPBMPStation(newConfig)
+	if err != nil {
+		return false, err
+	}
+
+	h.server.AddBMPStationCh <- server.BMPStationUpdate{oldStation, newStation}
+	return true, err
+}
+
+func (h *BGPHandler) CreateBGPBMPStation(station *bgpd.BGPBMPStation) (bool, error) {
+	h.logger.Info("Create BGP BMP station:", station)
+	return h.SendBGPBMPStation(nil, station)
+}
+
+func (h *BGPHandler) UpdateBGPBMPStation(origS *bgpd.BGPBMPStation, updatedS *bgpd.BGPBMPStation, attrSet []bool,
+	op []*bgpd.PatchOpInfo) (bool, error) {
+	h.logger.Info("Update BGP BMP station:", updatedS, "old:", origS)
+	return h.SendBGPBMPStation(origS, updatedS)
+}
+
+func (h *BGPHandler) DeleteBGPBMPStation(station *bgpd.BGPBMPStation) (bool, error) {
+	h.logger.Info("Delete BGP BMP station:", station)
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	h.server.RemBMPStationCh <- config.BMPStationConfig{Name: station.Name}
+	return true, nil
+}
+
+func (h *BGPHandler) convertToVRFConfig(name, rdStr string, importRTs, exportRTs []string,
+	label uint32, l3VNI uint32) (vrfConf config.VRFConfig, err error) {
+	if name == "" {
+		err = errors.New("BGPVRF: Name is not set")
+		return vrfConf, err
+	}
+
+	if _, err = packet.ParseRouteDistinguisher(strings.TrimSpace(rdStr)); err != nil {
+		err = errors.New(fmt.Sprintf("BGPVRF: Route distinguisher %s is not valid for VRF %s, error: %s", rdStr,
+			name, err))
+		return vrfConf, err
+	}
+
+	rtLists := [][]string{importRTs, exportRTs}
+	for _, rtList := range rtLists {
+		for i, rtStr := range rtList {
+			rtList[i] = strings.TrimSpace(rtStr)
+			if _, err = packet.ParseRouteTarget(rtList[i]); err != nil {
+				err = errors.New(fmt.Sprintf("BGPVRF: Route target %s is not valid for VRF %s, error: %s",
+					rtStr, name, err))
+				return vrfConf, err
+			}
+		}
+	}
+
+	if label < 16 || label > packet.MPLSLabelMax {
+		err = errors.New(fmt.Sprintf("BGPVRF: Label %d for VRF %s is not in the range 16-%d", label, name,
+			packet.MPLSLabelMax))
+		return vrfConf, err
+	}
+
+	if l3VNI > packet.VXLANVNIMax {
+		err = errors.New(fmt.Sprintf("BGPVRF: L3 VNI %d for VRF %s is not in the range 0-%d", l3VNI, name,
+			packet.VXLANVNIMax))
+		return vrfConf, err
+	}
+
+	vrfConf = config.VRFConfig{
+		Name:               name,
+		RouteDistinguisher: strings.TrimSpace(rdStr),
+		ImportRouteTargets: rtLists[0],
+		ExportRouteTargets: rtLists[1],
+		Label:              label,
+		L3VNI:              l3VNI,
+	}
+	return vrfConf, nil
+}
+
+func (h *BGPHandler) validateBGPVRF(vrf *bgpd.BGPVRF) (config.VRFConfig, error) {
+	if vrf == nil {
+		return config.VRFConfig{}, nil
+	}
+
+	return h.convertToVRFConfig(vrf.Name, vrf.RouteDistinguisher, vrf.ImportRouteTargets, vrf.ExportRouteTargets,
+		uint32(vrf.Label), uint32(vrf.L3VNI))
+}
+
+func (h *BGPHandler) SendBGPVRF(oldConfig *bgpd.BGPVRF, newConfig *bgpd.BGPVRF) (bool, error) {
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	oldVRF, err := h.validateBGPVRF(oldConfig)
+	if err != nil {
+		return false, err
+	}
+
+	newVRF, err := h.validateBGPVRF(newConfig)
+	if err != nil {
+		return false, err
+	}
+
+	h.server.AddVRFCh <- server.VRFUpdate{oldVRF, newVRF}
+	return true, err
+}
+
+func (h *BGPHandler) CreateBGPVRF(vrf *bgpd.BGPVRF) (bool, error) {
+	h.logger.Info("Create BGP VRF:", vrf)
+	return h.SendBGPVRF(nil, vrf)
+}
+
+func (h *BGPHandler) UpdateBGPVRF(origV *bgpd.BGPVRF, updatedV *bgpd.BGPVRF, attrSet []bool,
+	op []*bgpd.PatchOpInfo) (bool, error) {
+	h.logger.Info("Update BGP VRF:", updatedV, "old:", origV)
+	return h.SendBGPVRF(origV, updatedV)
+}
+
+func (h *BGPHandler) DeleteBGPVRF(vrf *bgpd.BGPVRF) (bool, error) {
+	h.logger.Info("Delete BGP VRF:", vrf)
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	h.server.RemVRFCh <- config.VRFConfig{Name: vrf.Name}
+	return true, nil
+}
+
+func (h *BGPHandler) convertToEVIConfig(vni uint32, vlanId int32, rdStr string, importRTs,
+	exportRTs []string) (eviConf config.EVIConfig, err error) {
+	if vni == 0 || vni > packet.VXLANVNIMax {
+		err = errors.New(fmt.Sprintf("BGPEVPNInstance: VNI %d is not in the range 1-%d", vni, packet.VXLANVNIMax))
+		return eviConf, err
+	}
+
+	if vlanId < 1 || vlanId > 4094 {
+		err = errors.New(fmt.Sprintf("BGPEVPNInstance: VLAN %d for VNI %d is not in the range 1-4094", vlanId,
+			vni))
+		return eviConf, err
+	}
+
+	rdStr = strings.TrimSpace(rdStr)
+	if rdStr != "" {
+		if _, err = packet.ParseRouteDistinguisher(rdStr); err != nil {
+			err = errors.New(fmt.Sprintf("BGPEVPNInstance: Route distinguisher %s is not valid for VNI %d, "+
+				"error: %s", rdStr, vni, err))
+			return eviConf, err
+		}
+	}
+
+	rtLists := [][]string{importRTs, exportRTs}
+	for _, rtList := range rtLists {
+		for i, rtStr := range rtList {
+			rtList[i] = strings.TrimSpace(rtStr)
+			if _, err = packet.ParseRouteTarget(rtList[i]); err != nil {
+				err = errors.New(fmt.Sprintf("BGPEVPNInstance: Route target %s is not valid for VNI %d, "+
+					"error: %s", rtStr, vni, err))
+				return eviConf, err
+			}
+		}
+	}
+
+	eviConf = config.EVIConfig{
+		VNI:                vni,
+		VlanId:             vlanId,
+		RouteDistinguisher: rdStr,
+		ImportRouteTargets: rtLists[0],
+		ExportRouteTargets: rtLists[1],
+	}
+	return eviConf, nil
+}
+
+func (h *BGPHandler) validateBGPEVPNInstance(evi *bgpd.BGPEVPNInstance) (config.EVIConfig, error) {
+	if evi == nil {
+		return config.EVIConfig{}, nil
+	}
+
+	return h.convertToEVIConfig(uint32(evi.Vni), evi.VlanId, evi.RouteDistinguisher, evi.ImportRouteTargets,
+		evi.ExportRouteTargets)
+}
+
+func (h *BGPHandler) SendBGPEVPNInstance(oldConfig *bgpd.BGPEVPNInstance, newConfig *bgpd.BGPEVPNInstance) (
+	bool, error) {
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	oldEVI, err := h.validateBGPEVPNInstance(oldConfig)
+	if err != nil {
+		return false, err
+	}
+
+	newEVI, err := h.validateBGPEVPNInstance(newConfig)
+	if err != nil {
+		return false, err
+	}
+
+	h.server.AddEVICh <- server.EVIUpdate{oldEVI, newEVI}
+	return true, err
+}
+
+func (h *BGPHandler) CreateBGPEVPNInstance(evi *bgpd.BGPEVPNInstance) (bool, error) {
+	h.logger.Info("Create BGP EVPN instance:", evi)
+	return h.SendBGPEVPNInstance(nil, evi)
+}
+
+func (h *BGPHandler) UpdateBGPEVPNInstance(origE *bgpd.BGPEVPNInstance, updatedE *bgpd.BGPEVPNInstance,
+	attrSet []bool, op []*bgpd.PatchOpInfo) (bool, error) {
+	h.logger.Info("Update BGP EVPN instance:", updatedE, "old:", origE)
+	return h.SendBGPEVPNInstance(origE, updatedE)
+}
+
+func (h *BGPHandler) DeleteBGPEVPNInstance(evi *bgpd.BGPEVPNInstance) (bool, error) {
+	h.logger.Info("Delete BGP EVPN instance:", evi)
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	h.server.RemEVICh <- config.EVIConfig{VNI: uint32(evi.Vni)}
+	return true, nil
+}
+
 func (h *BGPHandler) ExecuteActionResetBGPv4NeighborByIPAddr(resetIP *bgpd.ResetBGPv4NeighborByIPAddr) (bool, error) {
 	h.logger.Info("Reset BGP v4 neighbor by IP address", resetIP.IPAddr)
 	if err := h.checkBGPGlobal(); err != nil {
@@ -2052,3 +2524,63 @@ func (h *BGPHandler) ExecuteActionResetBGPv6NeighborByInterface(resetIf *bgpd.Re
 	h.server.PeerCommandCh <- config.PeerCommand{IP: ip, Command: int(fsm.BGPEventManualStop)}
 	return true, nil
 }
+
+func (h *BGPHandler) getSoftClearDir(direction string) (in bool, out bool, err error) {
+	switch strings.ToLower(strings.TrimSpace(direction)) {
+	case "in":
+		in = true
+
+	case "out":
+		out = true
+
+	case "", "both":
+		in = true
+		out = true
+
+	default:
+		err = errors.New(fmt.Sprintf("Soft clear direction %s is not valid, should be in, out or both", direction))
+	}
+	return in, out, err
+}
+
+func (h *BGPHandler) ExecuteActionSoftClearBGPv4NeighborByIPAddr(
+	softClearIP *bgpd.SoftClearBGPv4NeighborByIPAddr) (bool, error) {
+	h.logger.Info("Soft clear BGP v4 neighbor by IP address", softClearIP.IPAddr, "direction",
+		softClearIP.Direction)
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(softClearIP.IPAddr))
+	if ip == nil || ip.To4() == nil {
+		return false, errors.New(fmt.Sprintf("IPv4 Neighbor address %s is not a valid IP", softClearIP.IPAddr))
+	}
+
+	in, out, err := h.getSoftClearDir(softClearIP.Direction)
+	if err != nil {
+		return false, err
+	}
+	h.server.PeerSoftClearCh <- config.PeerSoftClear{IP: ip, In: in, Out: out}
+	return true, nil
+}
+
+func (h *BGPHandler) ExecuteActionSoftClearBGPv6NeighborByIPAddr(
+	softClearIP *bgpd.SoftClearBGPv6NeighborByIPAddr) (bool, error) {
+	h.logger.Info("Soft clear BGP v6 neighbor by IP address", softClearIP.IPAddr, "direction",
+		softClearIP.Direction)
+	if err := h.checkBGPGlobal(); err != nil {
+		return false, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(softClearIP.IPAddr))
+	if ip == nil || ip.To4() != nil {
+		return false, errors.New(fmt.Sprintf("IPv6 Neighbor address %s is not a valid IP", softClearIP.IPAddr))
+	}
+
+	in, out, err := h.getSoftClearDir(softClearIP.Direction)
+	if err != nil {
+		return false, err
+	}
+	h.server.PeerSoftClearCh <- config.PeerSoftClear{IP: ip, In: in, Out: out}
+	return true, nil
+}
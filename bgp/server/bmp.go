@@ -0,0 +1,332 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// bmp.go
+package server
+
+import (
+	"l3/bgp/bmp"
+	"l3/bgp/config"
+	"l3/bgp/fsm"
+	"l3/bgp/packet"
+	bgprib "l3/bgp/rib"
+	"net"
+	"os"
+)
+
+const bmpSysDescr = "FlexSwitch bgpd"
+
+func (s *BGPServer) getBMPSysName() string {
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return s.BgpConfig.Global.Config.RouterId.String()
+}
+
+func (s *BGPServer) stopBMPStation(name string) {
+	if station, ok := s.bmpStations[name]; ok {
+		s.logger.Infof("Stop %s", station)
+		station.Stop()
+		delete(s.bmpStations, name)
+		delete(s.bmpStationsUp, name)
+	}
+}
+
+func (s *BGPServer) AddOrUpdateBMPStation(oldConf config.BMPStationConfig, newConf config.BMPStationConfig) {
+	s.logger.Infof("AddOrUpdateBMPStation - old %+v new %+v", oldConf, newConf)
+	if oldConf.Name != "" {
+		s.stopBMPStation(oldConf.Name)
+	}
+	s.stopBMPStation(newConf.Name)
+
+	station := bmp.NewStation(s.logger, newConf, s.getBMPSysName(), bmpSysDescr, s.bmpEventCh)
+	s.bmpStations[newConf.Name] = station
+	s.logger.Infof("Start %s", station)
+	station.Start()
+}
+
+func (s *BGPServer) DeleteBMPStation(stationConf config.BMPStationConfig) {
+	s.logger.Infof("DeleteBMPStation - %+v", stationConf)
+	s.stopBMPStation(stationConf.Name)
+}
+
+// ProcessBMPStationEvent handles the connection state of the monitoring stations. When a station comes up, the
+// Peer Up messages and the Adj-RIB-In of the established peers are sent to it before any other route monitoring
+// messages.
+func (s *BGPServer) ProcessBMPStationEvent(event bmp.StationEvent) {
+	station, ok := s.bmpStations[event.Name]
+	if !ok {
+		s.logger.Infof("BMP station %s not found, event %d", event.Name, event.Event)
+		return
+	}
+
+	switch event.Event {
+	case bmp.StationEventUp:
+		if !station.IsUp() {
+			return
+		}
+
+		s.logger.Infof("%s is up", station)
+		s.bmpStationsUp[event.Name] = true
+		for _, peer := range s.PeerMap {
+			peer.bmpSendAdjRIBIn(station)
+		}
+
+	case bmp.StationEventDown:
+		s.logger.Infof("%s is down", station)
+		delete(s.bmpStationsUp, event.Name)
+
+	case bmp.StationEventStats:
+		if !s.bmpStationsUp[event.Name] {
+			return
+		}
+
+		for _, peer := range s.PeerMap {
+			if peer.bmpPeerUp != nil {
+				station.Send(peer.bmpStatsReport())
+			}
+		}
+	}
+}
+
+func (s *BGPServer) isBMPActive() bool {
+	return len(s.bmpStationsUp) > 0
+}
+
+func (s *BGPServer) bmpSend(msg *packet.BMPMessage) {
+	for name := range s.bmpStationsUp {
+		if station, ok := s.bmpStations[name]; ok {
+			station.Send(msg)
+		}
+	}
+}
+
+// bmpConstructUpdate constructs an Update message with the NLRIs of the protocol family that use the path and
+// the withdrawn NLRIs. It returns nil if there are no NLRIs.
+func bmpConstructUpdate(protoFamily uint32, path *bgprib.Path, mpReach *packet.BGPPathAttrMPReachNLRI,
+	nlris []packet.NLRI, withdrawn []packet.NLRI) *packet.BGPMessage {
+	if len(nlris) == 0 && len(withdrawn) == 0 {
+		return nil
+	}
+
+	pathAttrs := make([]packet.BGPPathAttr, 0)
+	if len(nlris) > 0 {
+		pathAttrs = packet.CopyPathAttrs(path.PathAttrs)
+	}
+
+	if protoFamily == packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast) {
+		if nlris == nil {
+			nlris = make([]packet.NLRI, 0)
+		}
+		if withdrawn == nil {
+			withdrawn = make([]packet.NLRI, 0)
+		}
+		return packet.NewBGPUpdateMessage(withdrawn, pathAttrs, nlris)
+	}
+
+	if len(nlris) > 0 {
+		if mpReach != nil {
+			mpReach = packet.CloneMPReachNLRIWithNewNLRI(mpReach, nlris)
+		} else {
+			mpReach = packet.ConstructIPv6MPReachNLRI(protoFamily, path.GetNextHop(protoFamily), nil, nlris)
+		}
+		pathAttrs = append(pathAttrs, mpReach)
+	}
+
+	if len(withdrawn) > 0 {
+		pathAttrs = append(pathAttrs, packet.ConstructMPUnreachNLRIFromProtoFamily(protoFamily, withdrawn))
+	}
+	return packet.NewBGPUpdateMessage(make([]packet.NLRI, 0), pathAttrs, make([]packet.NLRI, 0))
+}
+
+func (p *Peer) bmpPeerHeader(postPolicy bool) *packet.BMPPeerHeader {
+	return packet.NewBMPPeerHeader(p.NeighborConf.Neighbor.NeighborAddress, p.NeighborConf.RunningConf.PeerAS,
+		p.NeighborConf.BGPId, postPolicy)
+}
+
+func (p *Peer) bmpPeerUpMessage() *packet.BMPMessage {
+	return packet.NewBMPPeerUpMessage(p.bmpPeerHeader(false), p.bmpPeerUp.LocalAddress, p.bmpPeerUp.LocalPort,
+		p.bmpPeerUp.RemotePort, p.bmpPeerUp.SentOpen, p.bmpPeerUp.ReceivedOpen)
+}
+
+// bmpPeerConnUp saves the transport information and the OPEN messages of the established connection and sends
+// the Peer Up message to the monitoring stations.
+func (p *Peer) bmpPeerConnUp(peerFSMConn fsm.PeerFSMConn) {
+	p.bmpPeerUp = nil
+	if peerFSMConn.Conn == nil || peerFSMConn.SentOpen == nil || peerFSMConn.RcvdOpen == nil {
+		p.logger.Errf("Neighbor %s: Can't send BMP Peer Up, connection info is not available",
+			p.NeighborConf.Neighbor.NeighborAddress)
+		return
+	}
+
+	localAddr, ok := (*peerFSMConn.Conn).LocalAddr().(*net.TCPAddr)
+	if !ok {
+		return
+	}
+	remoteAddr, ok := (*peerFSMConn.Conn).RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return
+	}
+
+	p.bmpPeerUp = &packet.BMPPeerUp{
+		LocalAddress: localAddr.IP,
+		LocalPort:    uint16(localAddr.Port),
+		RemotePort:   uint16(remoteAddr.Port),
+		SentOpen:     peerFSMConn.SentOpen,
+		ReceivedOpen: peerFSMConn.RcvdOpen,
+	}
+	if p.server.isBMPActive() {
+		p.server.bmpSend(p.bmpPeerUpMessage())
+	}
+}
+
+// bmpPeerConnDown sends the Peer Down message with the reason the connection went down.
+func (p *Peer) bmpPeerConnDown(peerFSMConn fsm.PeerFSMConn) {
+	if p.bmpPeerUp == nil {
+		return
+	}
+
+	var reason uint8
+	var fsmEvent uint16
+	if peerFSMConn.Notification != nil && peerFSMConn.NotifLocal {
+		reason = packet.BMPPeerDownLocalNotification
+	} else if peerFSMConn.Notification != nil {
+		reason = packet.BMPPeerDownRemoteNotification
+	} else if peerFSMConn.Event == fsm.BGPEventTcpConnFails {
+		reason = packet.BMPPeerDownRemoteNoNotification
+	} else {
+		reason = packet.BMPPeerDownLocalNoNotification
+		fsmEvent = uint16(peerFSMConn.Event)
+	}
+	p.bmpPeerDown(reason, peerFSMConn.Notification, fsmEvent)
+}
+
+func (p *Peer) bmpPeerDown(reason uint8, notification *packet.BGPMessage, fsmEvent uint16) {
+	if p.bmpPeerUp == nil {
+		return
+	}
+
+	p.bmpPeerUp = nil
+	if p.server.isBMPActive() {
+		p.server.bmpSend(packet.NewBMPPeerDownMessage(p.bmpPeerHeader(false), reason, notification, fsmEvent))
+	}
+}
+
+// bmpMonitorPrePolicy sends the Update message as it was received from the peer.
+func (p *Peer) bmpMonitorPrePolicy(msg *packet.BGPMessage) {
+	if p.bmpPeerUp == nil || !p.server.isBMPActive() {
+		return
+	}
+
+	p.server.bmpSend(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(false), msg))
+}
+
+// bmpMonitorPostPolicy sends the result of the RIB-In policy for an Update message. NLRIs accepted by the
+// policy are sent with the path attributes they are installed with. Withdrawn NLRIs that were accepted and
+// refreshed NLRIs that are rejected by the policy now are sent as withdrawn.
+func (p *Peer) bmpMonitorPostPolicy(protoFamily uint32, path *bgprib.Path, mpReach *packet.BGPPathAttrMPReachNLRI,
+	nlris []packet.NLRI, withdrawn []packet.NLRI, modifiedPaths map[*bgprib.Path][]packet.NLRI,
+	rejected []packet.NLRI) {
+	if p.bmpPeerUp == nil || !p.server.isBMPActive() {
+		return
+	}
+
+	allWithdrawn := make([]packet.NLRI, 0, len(withdrawn)+len(rejected))
+	allWithdrawn = append(allWithdrawn, withdrawn...)
+	allWithdrawn = append(allWithdrawn, rejected...)
+	if update := bmpConstructUpdate(protoFamily, path, mpReach, nlris, allWithdrawn); update != nil {
+		p.server.bmpSend(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(true), update))
+	}
+
+	for modifiedPath, modifiedNLRIs := range modifiedPaths {
+		if update := bmpConstructUpdate(protoFamily, modifiedPath, mpReach, modifiedNLRIs, nil); update != nil {
+			p.server.bmpSend(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(true), update))
+		}
+	}
+}
+
+// bmpMonitorEndOfRIB sends the post-policy End-of-RIB marker for the protocol family. The pre-policy marker is
+// the received Update message.
+func (p *Peer) bmpMonitorEndOfRIB(protoFamily uint32) {
+	if p.bmpPeerUp == nil || !p.server.isBMPActive() {
+		return
+	}
+
+	eor := packet.NewBGPEndOfRIBMessage(protoFamily)
+	p.server.bmpSend(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(true), eor))
+}
+
+// bmpSendAdjRIBIn sends the Peer Up message and the contents of the Adj-RIB-In to a station that just came up.
+// The pre-policy routes are sent with the path attributes as they were received from the peer.
+func (p *Peer) bmpSendAdjRIBIn(station *bmp.Station) {
+	if p.bmpPeerUp == nil {
+		return
+	}
+
+	station.Send(p.bmpPeerUpMessage())
+	for protoFamily, routes := range p.ribIn {
+		prePolicy := make(map[*bgprib.Path][]packet.NLRI)
+		postPolicy := make(map[*bgprib.Path][]packet.NLRI)
+		for _, route := range routes {
+			if route == nil {
+				continue
+			}
+
+			for pathId, path := range route.GetPathMap() {
+				nlri := packet.ConstructNLRIFromPathIdAndNLRI(route.NLRI, pathId)
+				prePolicyPath := route.GetPrePolicyPath(pathId)
+				prePolicy[prePolicyPath] = append(prePolicy[prePolicyPath], nlri)
+				if route.Accept {
+					postPolicy[path] = append(postPolicy[path], nlri)
+				}
+			}
+		}
+
+		for path, nlris := range prePolicy {
+			if update := bmpConstructUpdate(protoFamily, path, nil, nlris, nil); update != nil {
+				station.Send(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(false), update))
+			}
+		}
+		for path, nlris := range postPolicy {
+			if update := bmpConstructUpdate(protoFamily, path, nil, nlris, nil); update != nil {
+				station.Send(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(true), update))
+			}
+		}
+
+		eor := packet.NewBGPEndOfRIBMessage(protoFamily)
+		station.Send(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(false), eor))
+		station.Send(packet.NewBMPRouteMonitoringMessage(p.bmpPeerHeader(true), eor))
+	}
+}
+
+func (p *Peer) bmpStatsReport() *packet.BMPMessage {
+	state := &p.NeighborConf.Neighbor.State
+	stats := []packet.BMPStat{
+		packet.BMPStat{Type: packet.BMPStatAdjRIBInRoutes, Value: uint64(state.TotalPrefixes)},
+		packet.BMPStat{Type: packet.BMPStatUpdatesReceived, Value: state.Messages.Received.Update},
+		packet.BMPStat{Type: packet.BMPStatUpdatesSent, Value: state.Messages.Sent.Update},
+		packet.BMPStat{Type: packet.BMPStatNotificationsReceived, Value: state.Messages.Received.Notification},
+		packet.BMPStat{Type: packet.BMPStatNotificationsSent, Value: state.Messages.Sent.Notification},
+	}
+	return packet.NewBMPStatsReportMessage(p.bmpPeerHeader(false), stats)
+}
@@ -0,0 +1,412 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// evpn.go
+package server
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	bgprib "l3/bgp/rib"
+	"net"
+)
+
+// EVI is an EVPN instance of a VXLAN VNI. The inclusive multicast route of the VTEP and the MAC/IP routes of the
+// MACs learnt in the VLAN of the VNI are advertised with the export route targets. The remote VTEPs and MACs of
+// the EVPN routes with one of the import route targets are programmed in the VXLAN daemon.
+type EVI struct {
+	Config      config.EVIConfig
+	RD          packet.RouteDistinguisher
+	ImportRTs   map[packet.BGPExtCommunity]bool
+	ExportRTs   []packet.BGPExtCommunity
+	imetPath    *bgprib.Path
+	macPath     *bgprib.Path
+	imetNLRI    packet.NLRI
+	localMacs   map[string]packet.NLRI
+	remote      map[string]*eviRemoteRoute
+	remoteVteps map[string]int
+}
+
+type eviRemoteRoute struct {
+	path     *bgprib.Path
+	nlri     *packet.EVPNPrefix
+	remoteIP string
+}
+
+func (e *EVI) String() string {
+	return fmt.Sprintf("EVI vni %d rd %s", e.Config.VNI, e.RD)
+}
+
+func (e *EVI) isImported(path *bgprib.Path) bool {
+	for _, rt := range packet.GetRouteTargets(path.PathAttrs) {
+		if e.ImportRTs[rt] {
+			return true
+		}
+	}
+	return false
+}
+
+func getEVPNFamily() uint32 {
+	return packet.GetProtocolFamily(packet.AfiL2VPN, packet.SafiEVPN)
+}
+
+// newEVI creates the EVPN instance. The route distinguisher defaults to <router id>:<vlan id> and the route
+// targets to <AS>:<VNI>, RFC 8365.
+func (s *BGPServer) newEVI(eviConf config.EVIConfig) (*EVI, error) {
+	if eviConf.VNI == 0 || eviConf.VNI > packet.VXLANVNIMax {
+		return nil, errors.New(fmt.Sprintf("EVI VNI %d is not valid", eviConf.VNI))
+	}
+
+	gConf := &s.BgpConfig.Global.Config
+	var rd packet.RouteDistinguisher
+	var err error
+	if eviConf.RouteDistinguisher == "" {
+		routerId := gConf.RouterId.To4()
+		if routerId == nil {
+			return nil, errors.New(fmt.Sprintf("EVI VNI %d, router id %s is not an IPv4 address", eviConf.VNI,
+				gConf.RouterId))
+		}
+		rd = packet.NewRouteDistinguisher(packet.RouteDistinguisherTypeIPv4Addr,
+			binary.BigEndian.Uint32(routerId), uint32(eviConf.VlanId))
+	} else if rd, err = packet.ParseRouteDistinguisher(eviConf.RouteDistinguisher); err != nil {
+		return nil, err
+	}
+
+	evi := &EVI{
+		Config:      eviConf,
+		RD:          rd,
+		ImportRTs:   make(map[packet.BGPExtCommunity]bool),
+		ExportRTs:   make([]packet.BGPExtCommunity, 0),
+		localMacs:   make(map[string]packet.NLRI),
+		remote:      make(map[string]*eviRemoteRoute),
+		remoteVteps: make(map[string]int),
+	}
+
+	defaultRT := packet.NewBGPExtCommunity(packet.BGPExtCommunityTypeTwoOctetAS,
+		packet.BGPExtCommunitySubTypeRouteTarget, gConf.AS, eviConf.VNI)
+	if len(eviConf.ImportRouteTargets) == 0 {
+		evi.ImportRTs[defaultRT] = true
+	}
+	for _, rtStr := range eviConf.ImportRouteTargets {
+		rt, err := packet.ParseRouteTarget(rtStr)
+		if err != nil {
+			return nil, err
+		}
+		evi.ImportRTs[rt] = true
+	}
+
+	if len(eviConf.ExportRouteTargets) == 0 {
+		evi.ExportRTs = append(evi.ExportRTs, defaultRT)
+	}
+	for _, rtStr := range eviConf.ExportRouteTargets {
+		rt, err := packet.ParseRouteTarget(rtStr)
+		if err != nil {
+			return nil, err
+		}
+		evi.ExportRTs = append(evi.ExportRTs, rt)
+	}
+
+	comms := append([]packet.BGPExtCommunity{packet.NewEncapsulationExtCommunity(packet.BGPEncapTunnelTypeVXLAN)},
+		evi.ExportRTs...)
+	pathAttrs := packet.ConstructPathAttrForConnRoutes(gConf.AS)
+	packet.SetNextHopPathAttrs(pathAttrs, gConf.RouterId)
+	pathAttrs = packet.SetExtCommunities(pathAttrs, comms)
+	evi.macPath = bgprib.NewPath(s.LocRib, nil, pathAttrs, nil, bgprib.RouteTypeConnected)
+
+	pathAttrs = packet.CopyPathAttrs(pathAttrs)
+	pathAttrs = packet.SetPMSITunnel(pathAttrs, packet.NewBGPPathAttrPMSITunnel(
+		packet.PMSITunnelTypeIngressReplication, eviConf.VNI, gConf.RouterId))
+	evi.imetPath = bgprib.NewPath(s.LocRib, nil, pathAttrs, nil, bgprib.RouteTypeConnected)
+	evi.imetNLRI = packet.NewEVPNInclusiveMulticastPrefix(rd, 0, gConf.RouterId)
+	return evi, nil
+}
+
+func (s *BGPServer) AddOrUpdateEVI(oldConf config.EVIConfig, newConf config.EVIConfig) {
+	s.logger.Infof("AddOrUpdateEVI - old %+v new %+v", oldConf, newConf)
+	if oldConf.VNI != 0 {
+		s.removeEVI(oldConf.VNI)
+	}
+	s.removeEVI(newConf.VNI)
+
+	evi, err := s.newEVI(newConf)
+	if err != nil {
+		s.logger.Errf("AddOrUpdateEVI - failed to create EVI for VNI %d with error %s", newConf.VNI, err)
+		return
+	}
+
+	s.evis[newConf.VNI] = evi
+	s.logger.Infof("Created %s", evi)
+	for _, dest := range s.LocRib.GetDestinations(getEVPNFamily()) {
+		s.importEVPNRoute(evi, dest)
+	}
+
+	s.advertiseEVIRoutes(evi, []packet.NLRI{evi.imetNLRI}, nil, evi.imetPath)
+	macs := make([]packet.NLRI, 0)
+	for _, macInfo := range s.evpnLocalMacs[newConf.VlanId] {
+		if nlri := s.addEVILocalMac(evi, macInfo); nlri != nil {
+			macs = append(macs, nlri)
+		}
+	}
+	s.advertiseEVIRoutes(evi, macs, nil, evi.macPath)
+}
+
+func (s *BGPServer) DeleteEVI(eviConf config.EVIConfig) {
+	s.logger.Infof("DeleteEVI - %+v", eviConf)
+	s.removeEVI(eviConf.VNI)
+}
+
+func (s *BGPServer) removeEVI(vni uint32) {
+	evi, ok := s.evis[vni]
+	if !ok {
+		return
+	}
+
+	s.logger.Infof("Remove %s", evi)
+	s.removeEVIRemoteRoutes(evi)
+	macs := make([]packet.NLRI, 0, len(evi.localMacs))
+	for _, nlri := range evi.localMacs {
+		macs = append(macs, nlri)
+	}
+	s.advertiseEVIRoutes(evi, nil, macs, evi.macPath)
+	s.advertiseEVIRoutes(evi, nil, []packet.NLRI{evi.imetNLRI}, evi.imetPath)
+	delete(s.evis, vni)
+}
+
+// advertiseEVIRoutes adds the local EVPN routes to the Loc-RIB and sends them to the peers
+func (s *BGPServer) advertiseEVIRoutes(evi *EVI, valid, invalid []packet.NLRI, path *bgprib.Path) {
+	if len(valid) == 0 && len(invalid) == 0 {
+		return
+	}
+
+	s.logger.Infof("%s - advertise valid routes %v, invalid routes %v", evi, valid, invalid)
+	protoFamily := getEVPNFamily()
+	add := map[uint32][]packet.NLRI{protoFamily: valid}
+	remove := map[uint32][]packet.NLRI{protoFamily: invalid}
+	routerId := s.BgpConfig.Global.Config.RouterId.String()
+	updated, withdrawn, updatedAddPaths := s.LocRib.ProcessConnectedRoutes(routerId, path, add, remove,
+		s.AddPathCount)
+	s.SendUpdate(updated, withdrawn, updatedAddPaths)
+}
+
+func (s *BGPServer) addEVILocalMac(evi *EVI, macInfo config.EVPNMacInfo) packet.NLRI {
+	mac, err := net.ParseMAC(macInfo.MacAddr)
+	if err != nil {
+		s.logger.Errf("%s - MAC %s is not valid, error %s", evi, macInfo.MacAddr, err)
+		return nil
+	}
+
+	nlri := packet.NewEVPNMACIPPrefix(evi.RD, 0, mac, net.ParseIP(macInfo.IPAddr), evi.Config.VNI)
+	evi.localMacs[macInfo.MacAddr] = nlri
+	return nlri
+}
+
+// ProcessEVPNMac advertises the MAC learnt by asicd in the EVI of the VLAN and withdraws the MAC when it is aged
+// out. The MACs are saved so that they are advertised when the EVI of the VLAN is created.
+func (s *BGPServer) ProcessEVPNMac(macInfo config.EVPNMacInfo) {
+	s.logger.Infof("ProcessEVPNMac - %+v", macInfo)
+	var evi *EVI
+	for _, e := range s.evis {
+		if e.Config.VlanId == macInfo.VlanId {
+			evi = e
+			break
+		}
+	}
+
+	if macInfo.Oper == config.EVPN_MAC_CREATED {
+		if _, ok := s.evpnLocalMacs[macInfo.VlanId]; !ok {
+			s.evpnLocalMacs[macInfo.VlanId] = make(map[string]config.EVPNMacInfo)
+		}
+		s.evpnLocalMacs[macInfo.VlanId][macInfo.MacAddr] = macInfo
+		if evi != nil {
+			if nlri := s.addEVILocalMac(evi, macInfo); nlri != nil {
+				s.advertiseEVIRoutes(evi, []packet.NLRI{nlri}, nil, evi.macPath)
+			}
+		}
+		return
+	}
+
+	if macs, ok := s.evpnLocalMacs[macInfo.VlanId]; ok {
+		delete(macs, macInfo.MacAddr)
+		if len(macs) == 0 {
+			delete(s.evpnLocalMacs, macInfo.VlanId)
+		}
+	}
+	if evi != nil {
+		if nlri, ok := evi.localMacs[macInfo.MacAddr]; ok {
+			delete(evi.localMacs, macInfo.MacAddr)
+			s.advertiseEVIRoutes(evi, nil, []packet.NLRI{nlri}, evi.macPath)
+		}
+	}
+}
+
+// getEVPNRemoteIP returns the remote VTEP of the EVPN route. The VTEP of the inclusive multicast route is the
+// ingress replication tunnel endpoint and the VTEP of the MAC/IP route is the next hop.
+func getEVPNRemoteIP(nlri *packet.EVPNPrefix, path *bgprib.Path) net.IP {
+	if tunnelType, ok := packet.GetEncapsulation(path.PathAttrs); ok && tunnelType != packet.BGPEncapTunnelTypeVXLAN {
+		return nil
+	}
+
+	if nlri.RouteType == packet.EVPNRouteTypeInclusiveMulticast {
+		pmsi := packet.GetPMSITunnel(path.PathAttrs)
+		if pmsi != nil && pmsi.TunnelType == packet.PMSITunnelTypeIngressReplication {
+			return pmsi.TunnelId
+		}
+		return nlri.Prefix
+	}
+	return path.GetNextHop(getEVPNFamily())
+}
+
+// importEVPNRoute programs the remote VTEP or the remote MAC of the best path of the EVPN destination if the
+// path has one of the import route targets of the EVI. They are removed if the destination is withdrawn or the
+// path is no longer imported.
+func (s *BGPServer) importEVPNRoute(evi *EVI, dest *bgprib.Destination) {
+	nlri, ok := dest.NLRI.(*packet.EVPNPrefix)
+	if !ok || (nlri.RouteType != packet.EVPNRouteTypeMACIP &&
+		nlri.RouteType != packet.EVPNRouteTypeInclusiveMulticast) {
+		return
+	}
+
+	key := nlri.GetCIDR()
+	remote, ok := evi.remote[key]
+	path := dest.LocRibPath
+	if path == nil || path.IsLocal() || !evi.isImported(path) {
+		if ok {
+			s.removeEVIRemoteRoute(evi, key, remote)
+		}
+		return
+	}
+
+	if ok && remote.path == path {
+		return
+	}
+
+	remoteIP := getEVPNRemoteIP(nlri, path)
+	if remoteIP == nil {
+		s.logger.Errf("%s - can't import %s, remote VTEP not found", evi, key)
+		return
+	}
+
+	if ok {
+		if remote.remoteIP == remoteIP.String() {
+			remote.path = path
+			return
+		}
+		s.removeEVIRemoteRoute(evi, key, remote)
+	}
+
+	remote = &eviRemoteRoute{
+		path:     path,
+		nlri:     nlri,
+		remoteIP: remoteIP.String(),
+	}
+	evi.remote[key] = remote
+	s.logger.Infof("%s - import %s remote VTEP %s", evi, key, remote.remoteIP)
+	if nlri.RouteType == packet.EVPNRouteTypeInclusiveMulticast {
+		evi.remoteVteps[remote.remoteIP]++
+		if evi.remoteVteps[remote.remoteIP] == 1 {
+			s.evpnMgr.CreateVtep(evi.Config.VNI, remote.remoteIP)
+		}
+	} else {
+		s.evpnMgr.CreateRemoteMac(evi.Config.VNI, nlri.MAC.String(), getEVPNMacIP(nlri), remote.remoteIP)
+	}
+}
+
+func getEVPNMacIP(nlri *packet.EVPNPrefix) string {
+	if nlri.Length == 0 {
+		return ""
+	}
+	return nlri.Prefix.String()
+}
+
+func (s *BGPServer) removeEVIRemoteRoute(evi *EVI, key string, remote *eviRemoteRoute) {
+	s.logger.Infof("%s - remove imported route %s remote VTEP %s", evi, key, remote.remoteIP)
+	delete(evi.remote, key)
+	if remote.nlri.RouteType == packet.EVPNRouteTypeInclusiveMulticast {
+		evi.remoteVteps[remote.remoteIP]--
+		if evi.remoteVteps[remote.remoteIP] <= 0 {
+			delete(evi.remoteVteps, remote.remoteIP)
+			s.evpnMgr.DeleteVtep(evi.Config.VNI, remote.remoteIP)
+		}
+	} else {
+		s.evpnMgr.DeleteRemoteMac(evi.Config.VNI, remote.nlri.MAC.String(), getEVPNMacIP(remote.nlri),
+			remote.remoteIP)
+	}
+}
+
+func (s *BGPServer) removeEVIRemoteRoutes(evi *EVI) {
+	for key, remote := range evi.remote {
+		s.removeEVIRemoteRoute(evi, key, remote)
+	}
+}
+
+// RemoveEVPNRemoteRoutes removes the remote VTEPs and MACs of all the EVIs. It is called when the routes from the
+// neighbors are removed from the Loc-RIB without the withdraws being sent.
+func (s *BGPServer) RemoveEVPNRemoteRoutes() {
+	for _, evi := range s.evis {
+		s.removeEVIRemoteRoutes(evi)
+	}
+}
+
+// processEVPNImports imports the updated EVPN destinations to the EVIs and removes the withdrawn ones
+func (s *BGPServer) processEVPNImports(updated map[uint32]map[*bgprib.Path][]*bgprib.Destination,
+	withdrawn []*bgprib.Destination) {
+	if len(s.evis) == 0 {
+		return
+	}
+
+	for _, destinations := range updated[getEVPNFamily()] {
+		for _, dest := range destinations {
+			if dest == nil {
+				continue
+			}
+			for _, evi := range s.evis {
+				s.importEVPNRoute(evi, dest)
+			}
+		}
+	}
+
+	for _, dest := range withdrawn {
+		if dest == nil || !packet.IsEVPNFamily(dest.GetProtocolFamily()) {
+			continue
+		}
+		for _, evi := range s.evis {
+			s.importEVPNRoute(evi, dest)
+		}
+	}
+}
+
+// restartEVIs recreates the EVIs after the global config is changed, the route distinguisher, the next hop and
+// the tunnel endpoint of the local EVPN routes are derived from the router id
+func (s *BGPServer) restartEVIs() {
+	eviConfs := make([]config.EVIConfig, 0, len(s.evis))
+	for _, evi := range s.evis {
+		eviConfs = append(eviConfs, evi.Config)
+	}
+
+	for _, eviConf := range eviConfs {
+		s.AddOrUpdateEVI(config.EVIConfig{}, eviConf)
+	}
+}
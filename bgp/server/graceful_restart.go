@@ -0,0 +1,308 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// graceful_restart.go
+package server
+
+import (
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	bgprib "l3/bgp/rib"
+	"time"
+)
+
+const (
+	GracefulRestartTimerExp int = iota
+	GracefulRestartStalePathTimerExp
+	GracefulRestartSelectionDeferralTimerExp
+)
+
+type GracefulRestartEvent struct {
+	PeerIP string
+	Event  int
+}
+
+func (p *Peer) isEstablished() bool {
+	return p.NeighborConf.Neighbor.Transport.Config.LocalAddress != nil
+}
+
+func (p *Peer) postGracefulRestartEvent(event int) func() {
+	peerIP := p.NeighborConf.Neighbor.NeighborAddress.String()
+	return func() {
+		p.server.GREventCh <- GracefulRestartEvent{peerIP, event}
+	}
+}
+
+func (p *Peer) stopGracefulRestartTimers() {
+	if p.grRestartTimer != nil {
+		p.grRestartTimer.Stop()
+		p.grRestartTimer = nil
+	}
+	if p.grStaleTimer != nil {
+		p.grStaleTimer.Stop()
+		p.grStaleTimer = nil
+	}
+}
+
+// stopGracefulRestart stops the graceful restart timers and forgets the stale routes. The caller is expected to
+// remove the routes from the Loc-RIB.
+func (p *Peer) stopGracefulRestart() {
+	p.stopGracefulRestartTimers()
+	p.grStaleFamilies = make(map[uint32]bool)
+	p.grEndOfRIB = make(map[uint32]bool)
+}
+
+// startGracefulRestart retains the routes of the address families for which the peer preserved the forwarding
+// state and starts the restart timer. It returns the address families whose routes are to be removed and false
+// if graceful restart does not apply to the peer.
+func (p *Peer) startGracefulRestart(gracefulRestart bool) ([]uint32, bool) {
+	p.stopGracefulRestart()
+	if !gracefulRestart || !p.NeighborConf.GracefulRestart || p.NeighborConf.PeerRestartTime == 0 {
+		return nil, false
+	}
+
+	removeFamilies := make([]uint32, 0)
+	peerIP := p.NeighborConf.Neighbor.NeighborAddress.String()
+	for protoFamily, ok := range p.NeighborConf.AfiSafiMap {
+		if !ok {
+			continue
+		}
+
+		if !p.NeighborConf.RestartFamilies[protoFamily] {
+			removeFamilies = append(removeFamilies, protoFamily)
+			continue
+		}
+
+		p.logger.Infof("Neighbor %s: retain routes for protocol family %d during graceful restart",
+			p.NeighborConf.Neighbor.NeighborAddress, protoFamily)
+		p.grStaleFamilies[protoFamily] = true
+		p.markRIBInStale(protoFamily)
+		p.locRib.MarkStaleUpdatesFromNeighbor(peerIP, protoFamily)
+	}
+
+	if len(p.grStaleFamilies) == 0 {
+		return nil, false
+	}
+
+	p.logger.Infof("Neighbor %s: start graceful restart timer for %d seconds",
+		p.NeighborConf.Neighbor.NeighborAddress, p.NeighborConf.PeerRestartTime)
+	p.grRestartTimer = time.AfterFunc(time.Duration(p.NeighborConf.PeerRestartTime)*time.Second,
+		p.postGracefulRestartEvent(GracefulRestartTimerExp))
+	return removeFamilies, true
+}
+
+// gracefulRestartPeerUp removes the stale routes of the address families for which the restarted peer did not
+// preserve the forwarding state and starts the stale path timer for the rest.
+func (p *Peer) gracefulRestartPeerUp() []uint32 {
+	if p.grRestartTimer != nil {
+		p.grRestartTimer.Stop()
+		p.grRestartTimer = nil
+	}
+	p.grEndOfRIB = make(map[uint32]bool)
+
+	removeFamilies := make([]uint32, 0)
+	for protoFamily, _ := range p.grStaleFamilies {
+		if !p.NeighborConf.GracefulRestart || !p.NeighborConf.RestartFamilies[protoFamily] {
+			p.logger.Infof("Neighbor %s: forwarding state not preserved for protocol family %d after restart",
+				p.NeighborConf.Neighbor.NeighborAddress, protoFamily)
+			removeFamilies = append(removeFamilies, protoFamily)
+			delete(p.grStaleFamilies, protoFamily)
+		}
+	}
+
+	if len(p.grStaleFamilies) > 0 {
+		stalePathTime := p.NeighborConf.Global.StalePathTime
+		p.logger.Infof("Neighbor %s: start stale path timer for %d seconds", p.NeighborConf.Neighbor.NeighborAddress,
+			stalePathTime)
+		p.grStaleTimer = time.AfterFunc(time.Duration(stalePathTime)*time.Second,
+			p.postGracefulRestartEvent(GracefulRestartStalePathTimerExp))
+	}
+	return removeFamilies
+}
+
+// receiveEndOfRIB removes the routes of the address family that were not refreshed by the peer after it
+// restarted.
+func (p *Peer) receiveEndOfRIB(protoFamily uint32) (map[uint32]map[*bgprib.Path][]*bgprib.Destination,
+	[]*bgprib.Destination, []*bgprib.Destination) {
+	p.logger.Infof("Neighbor %s: Received End-of-RIB for protocol family %d", p.NeighborConf.Neighbor.NeighborAddress,
+		protoFamily)
+	p.grEndOfRIB[protoFamily] = true
+	if !p.grStaleFamilies[protoFamily] {
+		return make(map[uint32]map[*bgprib.Path][]*bgprib.Destination), make([]*bgprib.Destination, 0),
+			make([]*bgprib.Destination, 0)
+	}
+
+	delete(p.grStaleFamilies, protoFamily)
+	if len(p.grStaleFamilies) == 0 && p.grStaleTimer != nil {
+		p.grStaleTimer.Stop()
+		p.grStaleTimer = nil
+	}
+	return p.removeStaleRoutes(protoFamily)
+}
+
+func (p *Peer) sendEndOfRIB() {
+	for protoFamily, ok := range p.NeighborConf.AfiSafiMap {
+		if ok {
+			p.logger.Infof("Neighbor %s: Send End-of-RIB for protocol family %d",
+				p.NeighborConf.Neighbor.NeighborAddress, protoFamily)
+			p.sendUpdateMsg(packet.NewBGPEndOfRIBMessage(protoFamily), nil)
+		}
+	}
+}
+
+// receivedAllEndOfRIB returns true if the peer sent End-of-RIB for all the address families that it supports
+// graceful restart for.
+func (p *Peer) receivedAllEndOfRIB() bool {
+	if !p.NeighborConf.GracefulRestart {
+		return true
+	}
+
+	for protoFamily, ok := range p.NeighborConf.AfiSafiMap {
+		if _, restart := p.NeighborConf.RestartFamilies[protoFamily]; ok && restart && !p.grEndOfRIB[protoFamily] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *BGPServer) removeStaleRoutes(peer *Peer, protoFamilies []uint32) {
+	for _, protoFamily := range protoFamilies {
+		updated, withdrawn, updatedAddPaths := peer.removeStaleRoutes(protoFamily)
+		updated, withdrawn, updatedAddPaths = s.CheckForAggregation(updated, withdrawn, updatedAddPaths)
+		s.SendUpdate(updated, withdrawn, updatedAddPaths)
+	}
+}
+
+// peerGracefulRestart is called when the connection to the peer breaks. It returns true if the routes from the
+// peer are retained.
+func (s *BGPServer) peerGracefulRestart(peer *Peer, gracefulRestart bool) bool {
+	removeFamilies, ok := peer.startGracefulRestart(gracefulRestart)
+	if !ok {
+		return false
+	}
+
+	for _, protoFamily := range removeFamilies {
+		peer.markRIBInStale(protoFamily)
+	}
+	s.removeStaleRoutes(peer, removeFamilies)
+	return true
+}
+
+func (s *BGPServer) peerGracefulRestartUp(peer *Peer) {
+	s.removeStaleRoutes(peer, peer.gracefulRestartPeerUp())
+	if s.grRestarting {
+		s.checkGracefulRestartComplete()
+		return
+	}
+
+	s.SendAllRoutesToPeer(peer)
+	if peer.NeighborConf.GracefulRestart {
+		peer.sendEndOfRIB()
+	}
+}
+
+// startGracefulRestart puts the server in the restarting mode. The best routes are not advertised to the peers
+// until all the peers send End-of-RIB or the selection deferral timer expires.
+func (s *BGPServer) startGracefulRestart(gConf config.GlobalConfig) {
+	if !gConf.GracefulRestart {
+		return
+	}
+
+	s.logger.Info("Graceful restart - defer route advertisement for", config.BGPSelectionDeferralTime, "seconds")
+	s.grRestarting = true
+	s.grDeferralTimer = time.AfterFunc(time.Duration(config.BGPSelectionDeferralTime)*time.Second, func() {
+		s.GREventCh <- GracefulRestartEvent{"", GracefulRestartSelectionDeferralTimerExp}
+	})
+}
+
+func (s *BGPServer) checkGracefulRestartComplete() {
+	if !s.grRestarting {
+		return
+	}
+
+	for _, peer := range s.PeerMap {
+		if peer.IsActive() && (!peer.isEstablished() || !peer.receivedAllEndOfRIB()) {
+			return
+		}
+	}
+	s.completeGracefulRestart()
+}
+
+func (s *BGPServer) completeGracefulRestart() {
+	s.logger.Info("Graceful restart complete, advertise routes to all peers")
+	s.grRestarting = false
+	if s.grDeferralTimer != nil {
+		s.grDeferralTimer.Stop()
+		s.grDeferralTimer = nil
+	}
+
+	for _, peer := range s.PeerMap {
+		peer.NeighborConf.Restarting = false
+	}
+	s.SendUpdate(s.LocRib.GetLocRib(), make([]*bgprib.Destination, 0), make([]*bgprib.Destination, 0))
+	for _, peer := range s.PeerMap {
+		if peer.isEstablished() && peer.NeighborConf.GracefulRestart {
+			peer.sendEndOfRIB()
+		}
+	}
+}
+
+func (s *BGPServer) ProcessGracefulRestartEvent(grEvent GracefulRestartEvent) {
+	if grEvent.Event == GracefulRestartSelectionDeferralTimerExp {
+		s.logger.Info("Graceful restart - selection deferral timer expired")
+		if s.grDeferralTimer != nil {
+			s.completeGracefulRestart()
+		}
+		return
+	}
+
+	peer, ok := s.PeerMap[grEvent.PeerIP]
+	if !ok {
+		s.logger.Infof("Graceful restart event %d, Peer %s does not exist", grEvent.Event, grEvent.PeerIP)
+		return
+	}
+
+	switch grEvent.Event {
+	case GracefulRestartTimerExp:
+		if peer.grRestartTimer == nil || peer.isEstablished() {
+			return
+		}
+		s.logger.Infof("Neighbor %s: graceful restart timer expired, remove stale routes", grEvent.PeerIP)
+		peer.stopGracefulRestart()
+		peer.clearRibOut()
+		s.ProcessRemoveNeighbor(grEvent.PeerIP, peer)
+
+	case GracefulRestartStalePathTimerExp:
+		if peer.grStaleTimer == nil {
+			return
+		}
+		s.logger.Infof("Neighbor %s: stale path timer expired, remove stale routes", grEvent.PeerIP)
+		peer.grStaleTimer = nil
+		removeFamilies := make([]uint32, 0)
+		for protoFamily, _ := range peer.grStaleFamilies {
+			removeFamilies = append(removeFamilies, protoFamily)
+		}
+		peer.grStaleFamilies = make(map[uint32]bool)
+		s.removeStaleRoutes(peer, removeFamilies)
+	}
+}
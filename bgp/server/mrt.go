@@ -0,0 +1,157 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// mrt.go
+package server
+
+import (
+	"l3/bgp/packet"
+	bgprib "l3/bgp/rib"
+	"net"
+	"time"
+)
+
+var mrtRIBProtoFamilies = []uint32{
+	packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast),
+	packet.GetProtocolFamily(packet.AfiIP6, packet.SafiUnicast),
+}
+
+func (s *BGPServer) stopMRTRIBDumpTimer() {
+	if s.mrtRIBDumpTimer != nil {
+		s.mrtRIBDumpTimer.Stop()
+		s.mrtRIBDumpTimer = nil
+	}
+}
+
+func (s *BGPServer) startMRTRIBDumpTimer() {
+	s.stopMRTRIBDumpTimer()
+	gConf := &s.BgpConfig.Global.Config
+	if gConf.MRTRIBDumpFile == "" || gConf.MRTRIBDumpInterval == 0 {
+		return
+	}
+
+	s.mrtRIBDumpTimer = time.AfterFunc(time.Duration(gConf.MRTRIBDumpInterval)*time.Second, func() {
+		s.mrtRIBDumpCh <- true
+	})
+}
+
+// setupMRT applies the MRT configuration of the global object. The LocRib is dumped every MRTRIBDumpInterval
+// seconds while the RIB dump file is configured.
+func (s *BGPServer) setupMRT() {
+	gConf := &s.BgpConfig.Global.Config
+	s.logger.Infof("setupMRT - RIB dump file %s interval %d, updates file %s interval %d", gConf.MRTRIBDumpFile,
+		gConf.MRTRIBDumpInterval, gConf.MRTUpdatesFile, gConf.MRTUpdatesInterval)
+	s.mrtUpdatesWriter.Configure(gConf.MRTUpdatesFile, gConf.MRTUpdatesInterval)
+	s.mrtRIBWriter.Configure(gConf.MRTRIBDumpFile, 0)
+	s.startMRTRIBDumpTimer()
+}
+
+func (s *BGPServer) ProcessMRTRIBDumpTimer() {
+	if !s.mrtRIBWriter.IsEnabled() {
+		return
+	}
+
+	s.dumpMRTRIB()
+	s.startMRTRIBDumpTimer()
+}
+
+func (s *BGPServer) getMRTPeerIndex(path *bgprib.Path, peers *[]packet.MRTPeerEntry,
+	peerIndexMap map[string]uint16) uint16 {
+	if path.NeighborConf == nil {
+		return 0
+	}
+
+	peerIP := path.NeighborConf.Neighbor.NeighborAddress.String()
+	if idx, ok := peerIndexMap[peerIP]; ok {
+		return idx
+	}
+
+	idx := uint16(len(*peers))
+	*peers = append(*peers, packet.MRTPeerEntry{
+		BGPId:   path.NeighborConf.BGPId,
+		Address: path.NeighborConf.Neighbor.NeighborAddress,
+		AS:      path.NeighborConf.RunningConf.PeerAS,
+	})
+	peerIndexMap[peerIP] = idx
+	return idx
+}
+
+func (s *BGPServer) getMRTPathAttrs(path *bgprib.Path, protoFamily uint32) []packet.BGPPathAttr {
+	afi, _ := packet.GetAfiSafi(protoFamily)
+	if afi == packet.AfiIP {
+		return path.PathAttrs
+	}
+
+	nextHop := path.GetNextHop(protoFamily)
+	if nextHop == nil {
+		nextHop = net.IPv6zero
+	}
+
+	pathAttrs := make([]packet.BGPPathAttr, 0, len(path.PathAttrs)+1)
+	pathAttrs = append(pathAttrs, path.PathAttrs...)
+	return append(pathAttrs, packet.ConstructIPv6MPReachNLRI(protoFamily, nextHop, nil, make([]packet.NLRI, 0)))
+}
+
+// dumpMRTRIB writes all the paths of the LocRib destinations to a new MRT TABLE_DUMP_V2 file. Local paths use
+// the peer index 0 with the router id and the local AS.
+func (s *BGPServer) dumpMRTRIB() {
+	gConf := &s.BgpConfig.Global.Config
+	now := time.Now()
+	peers := []packet.MRTPeerEntry{{BGPId: gConf.RouterId, Address: net.IPv4zero, AS: gConf.AS}}
+	peerIndexMap := make(map[string]uint16)
+	ribMsgs := make([]*packet.MRTMessage, 0)
+	seqNum := uint32(0)
+
+	for _, protoFamily := range mrtRIBProtoFamilies {
+		afi, _ := packet.GetAfiSafi(protoFamily)
+		for _, dest := range s.LocRib.GetDestinations(protoFamily) {
+			entries := make([]packet.MRTRIBEntry, 0)
+			for _, pathMap := range dest.GetPaths() {
+				for _, path := range pathMap {
+					entries = append(entries, packet.MRTRIBEntry{
+						PeerIndex:      s.getMRTPeerIndex(path, &peers, peerIndexMap),
+						OriginatedTime: path.GetReceivedTime(),
+						PathAttrs:      s.getMRTPathAttrs(path, protoFamily),
+					})
+				}
+			}
+
+			if len(entries) == 0 {
+				continue
+			}
+
+			prefix := packet.NewIPPrefix(dest.NLRI.GetPrefix(), dest.NLRI.GetLength())
+			ribMsgs = append(ribMsgs, packet.NewMRTRIBMessage(now, afi, seqNum, prefix, entries))
+			seqNum++
+		}
+	}
+
+	msgs := make([]*packet.MRTMessage, 0, len(ribMsgs)+1)
+	msgs = append(msgs, packet.NewMRTPeerIndexTableMessage(now, gConf.RouterId, gConf.Vrf, peers))
+	msgs = append(msgs, ribMsgs...)
+	if err := s.mrtRIBWriter.WriteDump(msgs...); err != nil {
+		s.logger.Errf("Failed to dump the LocRib to MRT file, error %s", err)
+		return
+	}
+	s.logger.Infof("Dumped %d LocRib destinations to MRT file", len(ribMsgs))
+}
@@ -36,6 +36,7 @@ import (
 	"runtime"
 	"strings"
 	"sync/atomic"
+	"time"
 	"utils/logging"
 	"utils/patriciaDB"
 	utilspolicy "utils/policy"
@@ -48,15 +49,28 @@ const (
 )
 
 type AdjRIBPolicyParams struct {
-	CreateType      int
-	DeleteType      int
-	Route           *bgprib.AdjRIBRoute
-	Peer            *Peer
-	Accept          int
-	PolicyEngine    *bgppolicy.AdjRibPPolicyEngine
-	updated         *(map[uint32]map[*bgprib.Path][]*bgprib.Destination)
-	withdrawn       *([]*bgprib.Destination)
-	updatedAddPaths *([]*bgprib.Destination)
+	CreateType       int
+	DeleteType       int
+	Route            *bgprib.AdjRIBRoute
+	Path             *bgprib.Path
+	Peer             *Peer
+	Accept           int
+	PolicyEngine     *bgppolicy.AdjRibPPolicyEngine
+	updated          *(map[uint32]map[*bgprib.Path][]*bgprib.Destination)
+	withdrawn        *([]*bgprib.Destination)
+	updatedAddPaths  *([]*bgprib.Destination)
+	CommunityActions []string
+}
+
+func (a *AdjRIBPolicyParams) getPath() *bgprib.Path {
+	if a.Path != nil {
+		return a.Path
+	}
+
+	for _, path := range a.Route.GetPathMap() {
+		return path
+	}
+	return nil
 }
 
 type Peer struct {
@@ -69,6 +83,16 @@ type Peer struct {
 	ifIdx        int32
 	ribIn        map[uint32]map[string]*bgprib.AdjRIBRoute
 	ribOut       map[uint32]map[string]*bgprib.AdjRIBRoute
+	ribInStale   map[uint32]map[string]bool
+
+	refreshStaleTimer *time.Timer
+
+	grStaleFamilies map[uint32]bool
+	grEndOfRIB      map[uint32]bool
+	grRestartTimer  *time.Timer
+	grStaleTimer    *time.Timer
+
+	bmpPeerUp *packet.BMPPeerUp
 }
 
 func NewPeer(server *BGPServer, locRib *bgprib.LocRib, globalConf *config.GlobalConfig,
@@ -76,16 +100,21 @@ func NewPeer(server *BGPServer, locRib *bgprib.LocRib, globalConf *config.Global
 	server.logger.Info("NewPeer - ip:", peerConf.NeighborAddress, "ifIndex:", peerConf.IfIndex)
 
 	peer := Peer{
-		server: server,
-		logger: server.logger,
-		locRib: locRib,
-		active: false,
-		ifIdx:  -1,
-		ribIn:  make(map[uint32]map[string]*bgprib.AdjRIBRoute),
-		ribOut: make(map[uint32]map[string]*bgprib.AdjRIBRoute),
+		server:     server,
+		logger:     server.logger,
+		locRib:     locRib,
+		active:     false,
+		ifIdx:      -1,
+		ribIn:      make(map[uint32]map[string]*bgprib.AdjRIBRoute),
+		ribOut:     make(map[uint32]map[string]*bgprib.AdjRIBRoute),
+		ribInStale: make(map[uint32]map[string]bool),
+
+		grStaleFamilies: make(map[uint32]bool),
+		grEndOfRIB:      make(map[uint32]bool),
 	}
 
 	peer.NeighborConf = base.NewNeighborConf(peer.logger, globalConf, peerGroup, peerConf)
+	peer.NeighborConf.Restarting = server.grRestarting
 
 	if !peer.IsConfigured() {
 		peer.logger.Infof("NewPeer - Neighbor is not ready to be started, ip:",
@@ -101,6 +130,7 @@ func NewPeer(server *BGPServer, locRib *bgprib.LocRib, globalConf *config.Global
 
 	peer.fsmManager = fsm.NewFSMManager(peer.logger, peer.NeighborConf, server.BGPPktSrcCh,
 		server.PeerFSMConnCh, server.ReachabilityCh)
+	peer.fsmManager.SetMRTWriter(server.mrtUpdatesWriter)
 	return &peer
 }
 
@@ -301,6 +331,7 @@ func (p *Peer) Init() {
 		p.logger.Infof("Init - Instantiating new FSM Manager for neighbor %s", p.NeighborConf.Neighbor.NeighborAddress)
 		fsmMgr = fsm.NewFSMManager(p.logger, p.NeighborConf, p.server.BGPPktSrcCh,
 			p.server.PeerFSMConnCh, p.server.ReachabilityCh)
+		fsmMgr.SetMRTWriter(p.server.mrtUpdatesWriter)
 	} else {
 		fsmMgr = p.fsmManager
 	}
@@ -344,6 +375,8 @@ func (p *Peer) Cleanup() {
 	}
 
 	p.ProcessBfd(false)
+	p.stopGracefulRestart()
+	p.bmpPeerDown(packet.BMPPeerDownDeconfigured, nil, 0)
 
 	if p.fsmManager == nil {
 		p.logger.Errf("Can't cleanup FSM, FSM Manager is not instantiated for neighbor %s",
@@ -414,20 +447,31 @@ func (p *Peer) getAddPathsMaxTx() int {
 }
 
 func (p *Peer) clearRibOut() {
-	p.ribIn = nil
-	p.ribOut = nil
+	ribIn := p.ribIn
+	ribInStale := p.ribInStale
 	p.ribIn = make(map[uint32]map[string]*bgprib.AdjRIBRoute)
 	p.ribOut = make(map[uint32]map[string]*bgprib.AdjRIBRoute)
+	p.ribInStale = make(map[uint32]map[string]bool)
+	p.stopRefreshStaleTimer()
 	p.initAdjRIBTables()
+
+	// Routes retained during the graceful restart of the peer stay in RIB-In until they are refreshed or removed
+	for protoFamily, _ := range p.grStaleFamilies {
+		if ribIn[protoFamily] != nil {
+			p.ribIn[protoFamily] = ribIn[protoFamily]
+			p.ribInStale[protoFamily] = ribInStale[protoFamily]
+		}
+	}
 }
 
 func (p *Peer) ProcessBfd(add bool) {
 	ipAddr := p.NeighborConf.Neighbor.NeighborAddress.String()
 	iface := p.NeighborConf.RunningConf.IfName
 	sessionParam := p.NeighborConf.RunningConf.BfdSessionParam
+	multiHop := p.NeighborConf.RunningConf.MultiHopEnable
 	if add && p.NeighborConf.RunningConf.BfdEnable {
 		p.logger.Info("Bfd enabled on", p.NeighborConf.Neighbor.NeighborAddress)
-		ret, err := p.server.bfdMgr.CreateBfdSession(ipAddr, iface, sessionParam)
+		ret, err := p.server.bfdMgr.CreateBfdSession(ipAddr, iface, sessionParam, multiHop)
 		if !ret {
 			p.logger.Info("BfdSessionConfig FAILED, ret:", ret, "err:", err)
 		} else {
@@ -470,7 +514,7 @@ func (p *Peer) PeerConnBroken(fsmCleanup bool) {
 		p.NeighborConf.Neighbor.Transport.Config.LocalAddress = nil
 		//p.Server.PeerConnBrokenCh <- p.Neighbor.NeighborAddress.String()
 	}
-	p.NeighborConf.PeerConnBroken()
+	p.NeighborConf.PeerConnBroken(len(p.grStaleFamilies) > 0)
 	p.clearRibOut()
 }
 
@@ -530,7 +574,7 @@ func (p *Peer) processWithdraws(protoFamily uint32, nlris *[]packet.NLRI) {
 		if !route.DoesPathsExist() {
 			p.logger.Infof("Neighbor %s: remove nlri %s protocol family %s from RIB-In",
 				p.NeighborConf.RunningConf.NeighborAddress, ip, protoFamily)
-			p.checkRIBInFilter(nlri, route, false)
+			p.checkRIBInFilter(nlri, route, nil, false)
 			delete(p.ribIn[protoFamily], ip)
 		}
 
@@ -548,8 +592,8 @@ func (p *Peer) processWithdraws(protoFamily uint32, nlris *[]packet.NLRI) {
 	(*nlris) = (*nlris)[:idx]
 }
 
-func (p *Peer) checkAdjRIBFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, pe *bgppolicy.AdjRibPPolicyEngine,
-	policyDir int, create bool) bool {
+func (p *Peer) checkAdjRIBFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, path *bgprib.Path,
+	pe *bgppolicy.AdjRibPPolicyEngine, policyDir int, create bool) bool {
 	if route != nil {
 		if len(route.PolicyList) > 0 {
 			return true
@@ -563,6 +607,7 @@ func (p *Peer) checkAdjRIBFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, pe
 		callbackInfo := &AdjRIBPolicyParams{
 			Peer:  p,
 			Route: route,
+			Path:  path,
 		}
 
 		if create {
@@ -578,32 +623,61 @@ func (p *Peer) checkAdjRIBFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, pe
 		pe.PolicyEngine.PolicyEngineFilter(peEntity, policyDir, callbackInfo)
 		p.logger.Infof("checkAdjRIBFilter - NLRI %s policylist %v hit %v after applying create policy, callbackInfo=%+v",
 			nlri.GetCIDR(), route.PolicyList, route.PolicyHitCounter, callbackInfo)
+		if create {
+			route.CommunityActions = callbackInfo.CommunityActions
+		}
 		return callbackInfo.Accept == Accept
 	}
 	return false
 }
 
-func (p *Peer) checkRIBInFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, create bool) bool {
+func (p *Peer) checkRIBInFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, path *bgprib.Path, create bool) bool {
 	if p.NeighborConf.Neighbor.Config.AdjRIBInFilter == "" {
 		p.logger.Debugf("Peer %s - RIB In filter is not set", p.NeighborConf.Neighbor.NeighborAddress)
 		return true
 	}
 
-	return p.checkAdjRIBFilter(nlri, route, p.server.ribInPE, policyCommonDefs.PolicyPath_Import, create)
+	return p.checkAdjRIBFilter(nlri, route, path, p.server.ribInPE, policyCommonDefs.PolicyPath_Import, create)
 }
 
-func (p *Peer) checkRIBOutFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, create bool) bool {
+func (p *Peer) checkRIBOutFilter(nlri packet.NLRI, route *bgprib.AdjRIBRoute, path *bgprib.Path, create bool) bool {
 	if p.NeighborConf.Neighbor.Config.AdjRIBOutFilter == "" {
 		p.logger.Debugf("Peer %s - RIB Out filter is not set", p.NeighborConf.Neighbor.NeighborAddress)
 		return true
 	}
 
-	return p.checkAdjRIBFilter(nlri, route, p.server.ribOutPE, policyCommonDefs.PolicyPath_Export, create)
+	return p.checkAdjRIBFilter(nlri, route, path, p.server.ribOutPE, policyCommonDefs.PolicyPath_Export, create)
+}
+
+// applyCommunityActions returns a copy of the path with the community actions applied. The paths are cached by
+// the action list so that all the NLRIs with the same actions share one path.
+func (p *Peer) applyCommunityActions(path *bgprib.Path, actions []string,
+	pathCache map[string]*bgprib.Path) *bgprib.Path {
+	if len(actions) == 0 {
+		return path
+	}
+
+	key := strings.Join(actions, ",")
+	if newPath, ok := pathCache[key]; ok {
+		return newPath
+	}
+
+	pa := p.server.ribInPE.GetCommunityPolicyDB().ApplyActions(actions, path.PathAttrs)
+	newPath := path.CloneWithPathAttrs(pa)
+	pathCache[key] = newPath
+	return newPath
 }
 
-func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI, path *bgprib.Path) {
+// processUpdates filters the NLRIs through the RIB-In policy. NLRIs that had community actions applied are
+// removed from nlris and returned with the modified path. NLRIs that were accepted before a route refresh and
+// are rejected now are returned so that they can be withdrawn from the Loc-RIB.
+func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI,
+	path *bgprib.Path) (map[*bgprib.Path][]packet.NLRI, []packet.NLRI) {
 	var ok bool
 	var route *bgprib.AdjRIBRoute
+	modifiedPaths := make(map[*bgprib.Path][]packet.NLRI)
+	rejected := make([]packet.NLRI, 0)
+	pathCache := make(map[string]*bgprib.Path)
 	total := len(*nlris)
 	last := total - 1
 	idx := 0
@@ -620,7 +694,20 @@ func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI, path *bg
 		}
 
 		ip := nlri.GetCIDR()
-		if route, ok = p.ribIn[protoFamily][ip]; !ok {
+		var refreshedRoute *bgprib.AdjRIBRoute
+		route, ok = p.ribIn[protoFamily][ip]
+		if p.clearStaleRoute(protoFamily, ip) && ok {
+			// Route is re-advertised after a route refresh, evaluate it against the current RIB-In policy
+			p.logger.Infof("Neighbor %s: nlri %s protocol family %d refreshed",
+				p.NeighborConf.RunningConf.NeighborAddress, ip, protoFamily)
+			refreshedRoute = route
+			route = bgprib.NewAdjRIBRoute(p.NeighborConf.Neighbor.NeighborAddress, protoFamily, nlri)
+			for pathId, routePath := range refreshedRoute.GetPathMap() {
+				route.AddPolicyPath(pathId, refreshedRoute.GetPrePolicyPath(pathId), routePath)
+			}
+			p.ribIn[protoFamily][ip] = route
+			ok = false
+		} else if !ok {
 			route = bgprib.NewAdjRIBRoute(p.NeighborConf.Neighbor.NeighborAddress, protoFamily, nlri)
 			p.ribIn[protoFamily][ip] = route
 			p.logger.Infof("Neighbor %s: add nlri %s protocol family %d",
@@ -638,10 +725,25 @@ func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI, path *bg
 			continue
 		}
 
-		accept := p.checkRIBInFilter(nlri, route, true)
+		accept := p.checkRIBInFilter(nlri, route, path, true)
 		route.Accept = accept
 		if !accept {
 			p.logger.Infof("Neighbor %s: filter nlri %s", p.NeighborConf.RunningConf.NeighborAddress, ip)
+			if refreshedRoute != nil && refreshedRoute.Accept {
+				rejected = append(rejected, nlri)
+			}
+			(*nlris)[idx] = (*nlris)[last]
+			(*nlris)[last] = nil
+			last--
+			continue
+		}
+
+		if len(route.CommunityActions) > 0 {
+			newPath := p.applyCommunityActions(path, route.CommunityActions, pathCache)
+			p.logger.Infof("Neighbor %s: applied community actions %v to nlri %s",
+				p.NeighborConf.RunningConf.NeighborAddress, route.CommunityActions, ip)
+			route.AddPolicyPath(nlri.GetPathId(), path, newPath)
+			modifiedPaths[newPath] = append(modifiedPaths[newPath], nlri)
 			(*nlris)[idx] = (*nlris)[last]
 			(*nlris)[last] = nil
 			last--
@@ -650,6 +752,34 @@ func (p *Peer) processUpdates(protoFamily uint32, nlris *[]packet.NLRI, path *bg
 		idx++
 	}
 	(*nlris) = (*nlris)[:idx]
+	return modifiedPaths, rejected
+}
+
+func (p *Peer) processModifiedPaths(protoFamily uint32, modifiedPaths map[*bgprib.Path][]packet.NLRI,
+	updated map[uint32]map[*bgprib.Path][]*bgprib.Destination, withdrawn, updatedAddPaths []*bgprib.Destination) (
+	map[uint32]map[*bgprib.Path][]*bgprib.Destination, []*bgprib.Destination, []*bgprib.Destination) {
+	var addedAllPrefixes bool
+	for path, nlris := range modifiedPaths {
+		updated, withdrawn, updatedAddPaths, addedAllPrefixes = p.locRib.ProcessUpdate(p.NeighborConf, path, nlris,
+			make([]packet.NLRI, 0), protoFamily, p.server.AddPathCount, updated, withdrawn, updatedAddPaths)
+		if !addedAllPrefixes {
+			p.MaxPrefixesExceeded()
+		}
+	}
+	return updated, withdrawn, updatedAddPaths
+}
+
+// processRejectedRoutes withdraws the refreshed NLRIs that are rejected by the RIB-In policy from the Loc-RIB.
+func (p *Peer) processRejectedRoutes(protoFamily uint32, path *bgprib.Path, rejected []packet.NLRI,
+	updated map[uint32]map[*bgprib.Path][]*bgprib.Destination, withdrawn, updatedAddPaths []*bgprib.Destination) (
+	map[uint32]map[*bgprib.Path][]*bgprib.Destination, []*bgprib.Destination, []*bgprib.Destination) {
+	if len(rejected) == 0 {
+		return updated, withdrawn, updatedAddPaths
+	}
+
+	updated, withdrawn, updatedAddPaths, _ = p.locRib.ProcessUpdate(p.NeighborConf, path, make([]packet.NLRI, 0),
+		rejected, protoFamily, p.server.AddPathCount, updated, withdrawn, updatedAddPaths)
+	return updated, withdrawn, updatedAddPaths
 }
 
 func (p *Peer) AddRouteNLRIs(route *bgprib.AdjRIBRoute, pathNLRIs map[*bgprib.Path]map[uint32]*bgprib.FilteredRoutes,
@@ -733,8 +863,14 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 	atomic.AddUint32(&p.NeighborConf.Neighbor.State.Queues.Input, ^uint32(0))
 	p.NeighborConf.Neighbor.State.Messages.Received.Update++
 
-	asLoop := false
 	updateMsg := pktInfo.Msg.Body.(*packet.BGPUpdate)
+	p.bmpMonitorPrePolicy(pktInfo.Msg)
+	if eorProtoFamily, ok := packet.IsEndOfRIB(updateMsg); ok {
+		p.bmpMonitorEndOfRIB(eorProtoFamily)
+		return p.receiveEndOfRIB(eorProtoFamily)
+	}
+
+	asLoop := false
 	if packet.HasASLoop(updateMsg.PathAttributes, p.NeighborConf.RunningConf.LocalAS) {
 		p.logger.Infof("Neighbor %s: Recived Update message has AS loop", p.NeighborConf.Neighbor.NeighborAddress)
 		asLoop = true
@@ -748,11 +884,13 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 	//remPath := bgprib.NewPath(p.locRib, p.neighborConf, updateMsg.PathAttributes, mpReach, RouteTypeEGP)
 	path := bgprib.NewPath(p.locRib, p.NeighborConf, updateMsg.PathAttributes, mpReach, bgprib.RouteTypeEGP)
 
+	var modifiedPaths, mpModifiedPaths map[*bgprib.Path][]packet.NLRI
+	var rejected, mpRejected []packet.NLRI
 	p.processWithdraws(protoFamily, &updateMsg.WithdrawnRoutes)
 	if asLoop {
 		updateMsg.NLRI = make([]packet.NLRI, 0)
 	} else {
-		p.processUpdates(protoFamily, &updateMsg.NLRI, path)
+		modifiedPaths, rejected = p.processUpdates(protoFamily, &updateMsg.NLRI, path)
 	}
 
 	if len(updateMsg.WithdrawnRoutes) > 0 || len(updateMsg.NLRI) > 0 {
@@ -763,6 +901,10 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 			p.MaxPrefixesExceeded()
 		}
 	}
+	updated, withdrawn, updatedAddPaths = p.processModifiedPaths(protoFamily, modifiedPaths, updated, withdrawn,
+		updatedAddPaths)
+	updated, withdrawn, updatedAddPaths = p.processRejectedRoutes(protoFamily, path, rejected, updated, withdrawn,
+		updatedAddPaths)
 
 	if mpUnreach != nil {
 		mpUnreachProtoFamily := packet.GetProtocolFamily(mpUnreach.AFI, mpUnreach.SAFI)
@@ -775,7 +917,7 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 			mpReach.NLRI = make([]packet.NLRI, 0)
 		} else {
 			mpReachProtoFamily = packet.GetProtocolFamily(mpReach.AFI, mpReach.SAFI)
-			p.processUpdates(mpReachProtoFamily, &(mpReach.NLRI), path)
+			mpModifiedPaths, mpRejected = p.processUpdates(mpReachProtoFamily, &(mpReach.NLRI), path)
 			if mpReachProtoFamily == mpUnreachProtoFamily {
 				mpProtoFamilySame = true
 				mpReachNLRI = mpReach.NLRI
@@ -800,6 +942,19 @@ func (p *Peer) ReceiveUpdate(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.
 			p.MaxPrefixesExceeded()
 		}
 	}
+	updated, withdrawn, updatedAddPaths = p.processModifiedPaths(mpReachProtoFamily, mpModifiedPaths, updated,
+		withdrawn, updatedAddPaths)
+	updated, withdrawn, updatedAddPaths = p.processRejectedRoutes(mpReachProtoFamily, path, mpRejected, updated,
+		withdrawn, updatedAddPaths)
+
+	p.bmpMonitorPostPolicy(protoFamily, path, nil, updateMsg.NLRI, updateMsg.WithdrawnRoutes, modifiedPaths, rejected)
+	if mpUnreach != nil {
+		p.bmpMonitorPostPolicy(packet.GetProtocolFamily(mpUnreach.AFI, mpUnreach.SAFI), path, nil, nil,
+			mpUnreach.NLRI, nil, nil)
+	}
+	if mpReachProtoFamily != 0 {
+		p.bmpMonitorPostPolicy(mpReachProtoFamily, path, mpReach, mpReach.NLRI, nil, mpModifiedPaths, mpRejected)
+	}
 
 	return updated, withdrawn, updatedAddPaths
 }
@@ -892,6 +1047,18 @@ func (p *Peer) sendUpdateMsg(msg *packet.BGPMessage, path *bgprib.Path) {
 }
 
 func (p *Peer) isAdvertisable(path *bgprib.Path) bool {
+	if path != nil {
+		if path.HasCommunity(packet.BGPCommunityNoAdvertise) {
+			return false
+		}
+
+		// Confederations are not supported, NO_EXPORT_SUBCONFED is treated the same as NO_EXPORT
+		if p.NeighborConf.IsExternal() && (path.HasCommunity(packet.BGPCommunityNoExport) ||
+			path.HasCommunity(packet.BGPCommunityNoExportSubconfed)) {
+			return false
+		}
+	}
+
 	if path != nil && path.NeighborConf != nil {
 		if path.NeighborConf.IsInternal() {
 			if p.NeighborConf.IsInternal() && !path.NeighborConf.IsRouteReflectorClient() &&
@@ -943,7 +1110,7 @@ func (p *Peer) calculateAddPathsAdvertisements(dest *bgprib.Destination, path *b
 	}
 
 	ribOutRoute := p.ribOut[protoFamily][ip]
-	canAdvertise := p.checkRIBOutFilter(dest.NLRI, ribOutRoute, true)
+	canAdvertise := p.checkRIBOutFilter(dest.NLRI, ribOutRoute, dest.LocRibPath, true)
 	canWithdraw := p.checkRIBOutWithdraw(ribOutRoute)
 
 	pathAdded := false
@@ -1027,6 +1194,59 @@ func (p *Peer) checkRIBOutWithdraw(route *bgprib.AdjRIBRoute) bool {
 	return false
 }
 
+// applyRIBOutCommunityActions moves the NLRIs that have community actions set by the RIB-Out policy to a copy
+// of the path with the actions applied.
+func (p *Peer) applyRIBOutCommunityActions(
+	newUpdated map[*bgprib.Path]map[uint32][]packet.NLRI) map[*bgprib.Path]map[uint32][]packet.NLRI {
+	updated := make(map[*bgprib.Path]map[uint32][]packet.NLRI)
+	for path, pfNLRIMap := range newUpdated {
+		pathCache := make(map[string]*bgprib.Path)
+		for protoFamily, nlris := range pfNLRIMap {
+			for _, nlri := range nlris {
+				advPath := path
+				if route := p.ribOut[protoFamily][nlri.GetCIDR()]; route != nil && len(route.CommunityActions) > 0 {
+					advPath = p.applyCommunityActions(path, route.CommunityActions, pathCache)
+				}
+				if _, ok := updated[advPath]; !ok {
+					updated[advPath] = make(map[uint32][]packet.NLRI)
+				}
+				updated[advPath][protoFamily] = append(updated[advPath][protoFamily], nlri)
+			}
+		}
+	}
+	return updated
+}
+
+func (p *Peer) sendWithdraws(withdrawList map[uint32][]packet.NLRI) {
+	if withdrawList == nil {
+		return
+	}
+
+	p.logger.Infof("Neighbor %s: Send update message withdraw routes:%+v",
+		p.NeighborConf.Neighbor.NeighborAddress, withdrawList)
+	var updateMsg *packet.BGPMessage
+	var ipv4List []packet.NLRI
+	protoFamily := packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast)
+	if nlriList, ok := withdrawList[protoFamily]; ok && len(nlriList) > 0 {
+		ipv4List = nlriList
+		delete(withdrawList, protoFamily)
+	}
+	for protoFamily, nlriList := range withdrawList {
+		if len(nlriList) > 0 {
+			mpUnreachNLRI := packet.ConstructMPUnreachNLRIFromProtoFamily(protoFamily, nlriList)
+			pathAtts := make([]packet.BGPPathAttr, 0)
+			pathAtts = append(pathAtts, mpUnreachNLRI)
+			updateMsg = packet.NewBGPUpdateMessage(ipv4List, pathAtts, nil)
+			p.sendUpdateMsg(updateMsg.Clone(), nil)
+			ipv4List = nil
+		}
+	}
+	if ipv4List != nil {
+		updateMsg = packet.NewBGPUpdateMessage(ipv4List, nil, nil)
+		p.sendUpdateMsg(updateMsg.Clone(), nil)
+	}
+}
+
 func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destination, withdrawn,
 	updatedAddPaths []*bgprib.Destination) {
 	p.logger.Infof("Neighbor %s: Send update message valid routes:%v, withdraw routes:%v",
@@ -1064,7 +1284,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 						continue
 					}
 
-					if addPathsTx > 0 {
+					if addPathsTx > 0 && packet.IsUnicastFamily(protoFamily) {
 						for pathId, _ := range route.GetPathMap() {
 							nlri := packet.NewExtNLRI(pathId, dest.NLRI.GetIPPrefix())
 							withdrawList[protoFamily] = append(withdrawList[protoFamily], nlri)
@@ -1094,7 +1314,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 					continue
 				}
 				ip := dest.NLRI.GetCIDR()
-				if addPathsTx > 0 {
+				if addPathsTx > 0 && packet.IsUnicastFamily(protoFamily) {
 					newUpdated, withdrawList = p.calculateAddPathsAdvertisements(dest, path, newUpdated,
 						withdrawList, addPathsTx)
 				} else {
@@ -1119,7 +1339,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 							}
 						}
 						if ribOutPath := ribOutRoute.GetPath(pathId); ribOutPath == nil || ribOutPath != path {
-							if p.checkRIBOutFilter(dest.NLRI, ribOutRoute, true) {
+							if p.checkRIBOutFilter(dest.NLRI, ribOutRoute, path, true) {
 								if _, ok := newUpdated[path]; !ok {
 									newUpdated[path] = make(map[uint32][]packet.NLRI)
 								}
@@ -1127,7 +1347,7 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 									newUpdated[path][protoFamily] = make([]packet.NLRI, 0)
 								}
 								newUpdated[path][protoFamily] = append(newUpdated[path][protoFamily],
-									dest.NLRI.GetIPPrefix())
+									packet.StripPathId(dest.NLRI))
 							}
 						}
 						ribOutRoute.AddPath(pathId, path)
@@ -1139,37 +1359,17 @@ func (p *Peer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destinat
 
 	if addPathsTx > 0 {
 		for _, dest := range updatedAddPaths {
+			if !packet.IsUnicastFamily(dest.GetProtocolFamily()) {
+				continue
+			}
 			newUpdated, withdrawList = p.calculateAddPathsAdvertisements(dest, nil, newUpdated, withdrawList,
 				addPathsTx)
 		}
 	}
 
-	if withdrawList != nil {
-		p.logger.Infof("Neighbor %s: Send update message withdraw routes:%+v",
-			p.NeighborConf.Neighbor.NeighborAddress, withdrawList)
-		var updateMsg *packet.BGPMessage
-		var ipv4List []packet.NLRI
-		protoFamily := packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast)
-		if nlriList, ok := withdrawList[protoFamily]; ok && len(nlriList) > 0 {
-			ipv4List = nlriList
-			delete(withdrawList, protoFamily)
-		}
-		for protoFamily, nlriList := range withdrawList {
-			if len(nlriList) > 0 {
-				mpUnreachNLRI := packet.ConstructMPUnreachNLRIFromProtoFamily(protoFamily, nlriList)
-				pathAtts := make([]packet.BGPPathAttr, 0)
-				pathAtts = append(pathAtts, mpUnreachNLRI)
-				updateMsg = packet.NewBGPUpdateMessage(ipv4List, pathAtts, nil)
-				p.sendUpdateMsg(updateMsg.Clone(), nil)
-				ipv4List = nil
-			}
-		}
-		if ipv4List != nil {
-			updateMsg = packet.NewBGPUpdateMessage(ipv4List, nil, nil)
-			p.sendUpdateMsg(updateMsg.Clone(), nil)
-		}
-	}
+	p.sendWithdraws(withdrawList)
 
+	newUpdated = p.applyRIBOutCommunityActions(newUpdated)
 	localAddress := p.NeighborConf.Neighbor.Transport.Config.LocalAddress
 	p.logger.Infof("Neighbor %s: new updated routes:%+v", p.NeighborConf.Neighbor.NeighborAddress, newUpdated)
 	for path, pfNLRIMap := range newUpdated {
@@ -1290,3 +1490,208 @@ func (p *Peer) AdjRIBOutPolicyUpdated(data interface{}, updateFunc utilspolicy.P
 	}
 
 }
+
+func (p *Peer) markRIBInStale(protoFamily uint32) {
+	p.logger.Infof("Neighbor %s: mark RIB-In routes for protocol family %d as stale",
+		p.NeighborConf.Neighbor.NeighborAddress, protoFamily)
+	p.ribInStale[protoFamily] = make(map[string]bool)
+	for ip, _ := range p.ribIn[protoFamily] {
+		p.ribInStale[protoFamily][ip] = true
+	}
+}
+
+func (p *Peer) clearStaleRoute(protoFamily uint32, ip string) bool {
+	if _, ok := p.ribInStale[protoFamily][ip]; !ok {
+		return false
+	}
+
+	delete(p.ribInStale[protoFamily], ip)
+	return true
+}
+
+// removeStaleRoutes removes the RIB-In routes that were not refreshed by the peer between the BoRR and EoRR
+// messages.
+func (p *Peer) removeStaleRoutes(protoFamily uint32) (map[uint32]map[*bgprib.Path][]*bgprib.Destination,
+	[]*bgprib.Destination, []*bgprib.Destination) {
+	filteredRoutes := make(map[*bgprib.Path]map[uint32]*bgprib.FilteredRoutes)
+	for ip, _ := range p.ribInStale[protoFamily] {
+		route, ok := p.ribIn[protoFamily][ip]
+		if !ok {
+			continue
+		}
+
+		p.logger.Infof("Neighbor %s: remove stale nlri %s protocol family %d from RIB-In",
+			p.NeighborConf.Neighbor.NeighborAddress, ip, protoFamily)
+		if route.Accept {
+			filteredRoutes = p.AddRouteNLRIs(route, filteredRoutes, false)
+		}
+		p.checkRIBInFilter(route.NLRI, route, nil, false)
+		delete(p.ribIn[protoFamily], ip)
+	}
+	delete(p.ribInStale, protoFamily)
+
+	updated, withdrawn, updatedAddPaths, _ := p.locRib.ProcessFilteredRoutes(p.NeighborConf, filteredRoutes,
+		p.server.AddPathCount)
+	return updated, withdrawn, updatedAddPaths
+}
+
+// startRefreshStaleTimer starts the timer to clear the stale marks of a route refresh sent to a peer that does
+// not support enhanced route refresh. Without the EoRR the end of the refresh is not known, the routes that
+// were not sent again are kept and only the marks are cleared.
+func (p *Peer) startRefreshStaleTimer() {
+	p.stopRefreshStaleTimer()
+	peerIP := p.NeighborConf.Neighbor.NeighborAddress.String()
+	p.refreshStaleTimer = time.AfterFunc(time.Duration(config.BGPRouteRefreshStaleTime)*time.Second, func() {
+		p.server.RefreshStaleCh <- peerIP
+	})
+}
+
+func (p *Peer) stopRefreshStaleTimer() {
+	if p.refreshStaleTimer != nil {
+		p.refreshStaleTimer.Stop()
+		p.refreshStaleTimer = nil
+	}
+}
+
+// clearRIBInStale clears the stale marks left by a route refresh. The marks of the protocol families retained
+// during a graceful restart of the peer are kept.
+func (p *Peer) clearRIBInStale() {
+	p.logger.Infof("Neighbor %s: clear stale marks of the route refresh", p.NeighborConf.Neighbor.NeighborAddress)
+	p.refreshStaleTimer = nil
+	for protoFamily, _ := range p.ribInStale {
+		if !p.grStaleFamilies[protoFamily] {
+			delete(p.ribInStale, protoFamily)
+		}
+	}
+}
+
+// resendAdjRIBOut sends the Loc-RIB routes of the protocol family to the peer again and withdraws the routes
+// that were advertised before, but are not advertised anymore.
+func (p *Peer) resendAdjRIBOut(protoFamily uint32) {
+	afi, safi := packet.GetAfiSafi(protoFamily)
+	p.logger.Infof("Neighbor %s: resend Adj-RIB-Out for afi %d safi %d", p.NeighborConf.Neighbor.NeighborAddress,
+		afi, safi)
+	if p.NeighborConf.EnhancedRouteRefresh {
+		p.fsmManager.SendRouteRefreshMsg(afi, safi, packet.BGPRouteRefreshBoRR)
+	}
+
+	oldRibOut := p.ribOut[protoFamily]
+	p.ribOut[protoFamily] = make(map[string]*bgprib.AdjRIBRoute)
+	updated := make(map[uint32]map[*bgprib.Path][]*bgprib.Destination)
+	if pathDestMap, ok := p.locRib.GetLocRib()[protoFamily]; ok {
+		updated[protoFamily] = pathDestMap
+	}
+	p.SendUpdate(updated, make([]*bgprib.Destination, 0), make([]*bgprib.Destination, 0))
+
+	addPathsTx := p.getAddPathsMaxTx()
+	withdrawList := make(map[uint32][]packet.NLRI)
+	for ip, route := range oldRibOut {
+		if !p.checkRIBOutWithdraw(route) {
+			continue
+		}
+
+		newRoute := p.ribOut[protoFamily][ip]
+		if newRoute != nil && !p.checkRIBOutWithdraw(newRoute) {
+			newRoute = nil
+		}
+		for pathId, _ := range route.GetPathMap() {
+			if newRoute != nil && newRoute.GetPath(pathId) != nil {
+				continue
+			}
+
+			if addPathsTx > 0 && packet.IsUnicastFamily(protoFamily) {
+				withdrawList[protoFamily] = append(withdrawList[protoFamily],
+					packet.NewExtNLRI(pathId, route.NLRI.GetIPPrefix()))
+			} else {
+				withdrawList[protoFamily] = append(withdrawList[protoFamily], route.NLRI)
+				break
+			}
+		}
+	}
+	p.sendWithdraws(withdrawList)
+
+	if p.NeighborConf.EnhancedRouteRefresh {
+		p.fsmManager.SendRouteRefreshMsg(afi, safi, packet.BGPRouteRefreshEoRR)
+	}
+}
+
+func (p *Peer) canRefresh() bool {
+	if p.fsmManager == nil {
+		p.logger.Errf("FSM Manager is not instantiated yet for neighbor %s", p.NeighborConf.Neighbor.NeighborAddress)
+		return false
+	}
+
+	if p.NeighborConf.Neighbor.Transport.Config.LocalAddress == nil {
+		p.logger.Errf("Neighbor %s: Can't refresh routes, FSM is not in Established state",
+			p.NeighborConf.Neighbor.NeighborAddress)
+		return false
+	}
+	return true
+}
+
+// SoftClearIn asks the peer to send all its routes again so that they are evaluated against the current
+// RIB-In policy.
+func (p *Peer) SoftClearIn() {
+	if !p.canRefresh() {
+		return
+	}
+
+	if !p.NeighborConf.RouteRefresh {
+		p.logger.Errf("Neighbor %s: Can't soft clear in, peer does not support route refresh",
+			p.NeighborConf.Neighbor.NeighborAddress)
+		return
+	}
+
+	for protoFamily, ok := range p.NeighborConf.AfiSafiMap {
+		if !ok {
+			continue
+		}
+
+		afi, safi := packet.GetAfiSafi(protoFamily)
+		p.markRIBInStale(protoFamily)
+		p.fsmManager.SendRouteRefreshMsg(afi, safi, packet.BGPRouteRefreshNormal)
+	}
+
+	// The peer does not send the BoRR and EoRR messages that end the refresh
+	if !p.NeighborConf.EnhancedRouteRefresh {
+		p.startRefreshStaleTimer()
+	}
+}
+
+// SoftClearOut sends the Adj-RIB-Out to the peer again after applying the current RIB-Out policy.
+func (p *Peer) SoftClearOut() {
+	if !p.canRefresh() {
+		return
+	}
+
+	for protoFamily, ok := range p.NeighborConf.AfiSafiMap {
+		if ok {
+			p.resendAdjRIBOut(protoFamily)
+		}
+	}
+}
+
+func (p *Peer) ReceiveRouteRefresh(pktInfo *packet.BGPPktSrc) (map[uint32]map[*bgprib.Path][]*bgprib.Destination,
+	[]*bgprib.Destination, []*bgprib.Destination) {
+	refreshMsg := pktInfo.Msg.Body.(*packet.BGPRouteRefresh)
+	protoFamily := packet.GetProtocolFamily(refreshMsg.AFI, refreshMsg.SAFI)
+	p.logger.Infof("Neighbor %s: Received route refresh %s for afi %d safi %d",
+		p.NeighborConf.Neighbor.NeighborAddress, packet.BGPRouteRefreshSubtypeToStr[refreshMsg.Subtype],
+		refreshMsg.AFI, refreshMsg.SAFI)
+
+	switch refreshMsg.Subtype {
+	case packet.BGPRouteRefreshNormal:
+		if p.canRefresh() {
+			p.resendAdjRIBOut(protoFamily)
+		}
+
+	case packet.BGPRouteRefreshBoRR:
+		p.markRIBInStale(protoFamily)
+
+	case packet.BGPRouteRefreshEoRR:
+		return p.removeStaleRoutes(protoFamily)
+	}
+
+	return make(map[uint32]map[*bgprib.Path][]*bgprib.Destination), make([]*bgprib.Destination, 0),
+		make([]*bgprib.Destination, 0)
+}
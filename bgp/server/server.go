@@ -29,8 +29,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"l3/bgp/bmp"
 	"l3/bgp/config"
 	"l3/bgp/fsm"
+	"l3/bgp/mrt"
 	"l3/bgp/packet"
 	bgppolicy "l3/bgp/policy"
 	bgprib "l3/bgp/rib"
@@ -83,6 +85,21 @@ type AggUpdate struct {
 	AttrSet []bool
 }
 
+type BMPStationUpdate struct {
+	OldStation config.BMPStationConfig
+	NewStation config.BMPStationConfig
+}
+
+type VRFUpdate struct {
+	OldVRF config.VRFConfig
+	NewVRF config.VRFConfig
+}
+
+type EVIUpdate struct {
+	OldEVI config.EVIConfig
+	NewEVI config.EVIConfig
+}
+
 type PolicyParams struct {
 	CreateType      int
 	DeleteType      int
@@ -114,10 +131,20 @@ type BGPServer struct {
 	RemPeerGroupCh   chan config.PeerGroupConfig
 	AddAggCh         chan AggUpdate
 	RemAggCh         chan config.BGPAggregate
+	AddBMPStationCh  chan BMPStationUpdate
+	RemBMPStationCh  chan config.BMPStationConfig
+	AddVRFCh         chan VRFUpdate
+	RemVRFCh         chan config.VRFConfig
+	AddEVICh         chan EVIUpdate
+	RemEVICh         chan config.EVIConfig
+	EVPNMacCh        chan config.EVPNMacInfo
 	PeerFSMConnCh    chan fsm.PeerFSMConn
 	PeerConnEstCh    chan string
 	PeerConnBrokenCh chan string
 	PeerCommandCh    chan config.PeerCommand
+	PeerSoftClearCh  chan config.PeerSoftClear
+	GREventCh        chan GracefulRestartEvent
+	RefreshStaleCh   chan string
 	ReachabilityCh   chan config.ReachabilityInfo
 	BGPPktSrcCh      chan *packet.BGPPktSrc
 	BfdCh            chan config.BfdInfo
@@ -140,16 +167,31 @@ type BGPServer struct {
 	RedistributionMap map[string]string
 	ifaceIP           net.IP
 	AddPathCount      int
+	grRestarting      bool
+	grDeferralTimer   *time.Timer
+	bmpStations       map[string]*bmp.Station
+	bmpStationsUp     map[string]bool
+	bmpEventCh        chan bmp.StationEvent
+	mrtRIBWriter      *mrt.FileWriter
+	mrtUpdatesWriter  *mrt.FileWriter
+	mrtRIBDumpTimer   *time.Timer
+	mrtRIBDumpCh      chan bool
+	vrfs              map[string]*VRF
+	vrfConnRoutes     map[string]map[string]*config.RouteInfo
+	evis              map[uint32]*EVI
+	evpnLocalMacs     map[int32]map[string]config.EVPNMacInfo
 	// all managers
 	IntfMgr    config.IntfStateMgrIntf
 	routeMgr   config.RouteMgrIntf
 	bfdMgr     config.BfdMgrIntf
+	evpnMgr    config.EVPNMgrIntf
 	stateDBMgr statedbclient.StateDBClient
 	eventDbHdl *dbutils.DBUtil
 }
 
 func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyManager, iMgr config.IntfStateMgrIntf,
-	rMgr config.RouteMgrIntf, bMgr config.BfdMgrIntf, sDBMgr statedbclient.StateDBClient) *BGPServer {
+	rMgr config.RouteMgrIntf, bMgr config.BfdMgrIntf, eMgr config.EVPNMgrIntf,
+	sDBMgr statedbclient.StateDBClient) *BGPServer {
 	bgpServer := &BGPServer{}
 	bgpServer.logger = logger
 	bgpServer.policyManager = policyManager
@@ -162,10 +204,20 @@ func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyMana
 	bgpServer.RemPeerGroupCh = make(chan config.PeerGroupConfig)
 	bgpServer.AddAggCh = make(chan AggUpdate)
 	bgpServer.RemAggCh = make(chan config.BGPAggregate)
+	bgpServer.AddBMPStationCh = make(chan BMPStationUpdate)
+	bgpServer.RemBMPStationCh = make(chan config.BMPStationConfig)
+	bgpServer.AddVRFCh = make(chan VRFUpdate)
+	bgpServer.RemVRFCh = make(chan config.VRFConfig)
+	bgpServer.AddEVICh = make(chan EVIUpdate)
+	bgpServer.RemEVICh = make(chan config.EVIConfig)
+	bgpServer.EVPNMacCh = make(chan config.EVPNMacInfo)
 	bgpServer.PeerFSMConnCh = make(chan fsm.PeerFSMConn, 50)
 	bgpServer.PeerConnEstCh = make(chan string)
 	bgpServer.PeerConnBrokenCh = make(chan string)
 	bgpServer.PeerCommandCh = make(chan config.PeerCommand)
+	bgpServer.PeerSoftClearCh = make(chan config.PeerSoftClear)
+	bgpServer.GREventCh = make(chan GracefulRestartEvent)
+	bgpServer.RefreshStaleCh = make(chan string)
 	bgpServer.ReachabilityCh = make(chan config.ReachabilityInfo)
 	bgpServer.BGPPktSrcCh = make(chan *packet.BGPPktSrc)
 	bgpServer.BfdCh = make(chan config.BfdInfo)
@@ -184,6 +236,7 @@ func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyMana
 	bgpServer.IntfMgr = iMgr
 	bgpServer.routeMgr = rMgr
 	bgpServer.bfdMgr = bMgr
+	bgpServer.evpnMgr = eMgr
 	bgpServer.stateDBMgr = sDBMgr
 	bgpServer.LocRib = bgprib.NewLocRib(logger, rMgr, sDBMgr, &bgpServer.BgpConfig.Global.Config)
 	bgpServer.IfNameToIfIndex = make(map[string]int32)
@@ -192,6 +245,16 @@ func NewBGPServer(logger *logging.Writer, policyManager *bgppolicy.BGPPolicyMana
 	bgpServer.RedistributionMap = make(map[string]string)
 	bgpServer.ifaceIP = nil
 	bgpServer.AddPathCount = 0
+	bgpServer.bmpStations = make(map[string]*bmp.Station)
+	bgpServer.bmpStationsUp = make(map[string]bool)
+	bgpServer.bmpEventCh = make(chan bmp.StationEvent)
+	bgpServer.mrtRIBWriter = mrt.NewFileWriter(logger)
+	bgpServer.mrtUpdatesWriter = mrt.NewFileWriter(logger)
+	bgpServer.mrtRIBDumpCh = make(chan bool, 1)
+	bgpServer.vrfs = make(map[string]*VRF)
+	bgpServer.vrfConnRoutes = make(map[string]map[string]*config.RouteInfo)
+	bgpServer.evis = make(map[uint32]*EVI)
+	bgpServer.evpnLocalMacs = make(map[int32]map[string]config.EVPNMacInfo)
 	bgpServer.initGlobalConfig()
 	bgpServer.initPolicyEngines()
 	return bgpServer
@@ -234,6 +297,7 @@ func (s *BGPServer) initPolicyEngines() {
 			s.logger.Crit("BGPServer: traverse funcs not found for address family", afi)
 		}
 		locRibPE.SetTraverseFuncs(traverseFuncs.ApplyFunc, traverseFuncs.ReverseFunc)
+		locRibPE.SetCommunityPolicyDB(s.policyManager.CommunityDB)
 		s.locRibPE[pfNumber] = locRibPE
 		//s.policyManager.AddPolicyEngine(locRibPE)
 	}
@@ -322,6 +386,13 @@ func (s *BGPServer) listenForPeers(listener *net.TCPListener, proto string, acce
 
 func (s *BGPServer) SendUpdate(updated map[uint32]map[*bgprib.Path][]*bgprib.Destination, withdrawn,
 	updatedAddPaths []*bgprib.Destination) {
+	s.processVRFImports(updated, withdrawn)
+	s.processEVPNImports(updated, withdrawn)
+	if s.grRestarting {
+		// Routes are advertised after the graceful restart completes
+		return
+	}
+
 	for _, peer := range s.PeerMap {
 		peer.SendUpdate(updated, withdrawn, updatedAddPaths)
 	}
@@ -558,9 +629,18 @@ func (s *BGPServer) UndoAggregateAction(actionInfo interface{},
 func (s *BGPServer) ApplyAggregateAction(actionInfo interface{}, conditionInfo []interface{}, params interface{},
 	policyStmt utilspolicy.PolicyStmt) {
 	policyParams := params.(PolicyParams)
+	protoFamily := policyParams.route.Dest.GetProtocolFamily()
+	communityDB := s.locRibPE[protoFamily].GetCommunityPolicyDB()
+	if path := policyParams.route.GetPath(); policyParams.DeleteType != utilspolicy.Valid && path != nil &&
+		!communityDB.MatchStatement(policyStmt, conditionInfo, path.PathAttrs) {
+		s.logger.Infof("ApplyAggregateAction: community conditions %v did not match for route %s/%d",
+			policyStmt.Conditions, policyParams.route.Dest.BGPRouteState.GetNetwork(),
+			policyParams.route.Dest.BGPRouteState.GetCIDRLen())
+		return
+	}
+
 	ipPrefix := packet.NewIPPrefix(net.ParseIP(policyParams.route.Dest.BGPRouteState.GetNetwork()),
 		uint8(policyParams.route.Dest.BGPRouteState.GetCIDRLen()))
-	protoFamily := policyParams.route.Dest.GetProtocolFamily()
 	aggPrefix := s.getAggPrefix(conditionInfo)
 	aggActions := actionInfo.(utilspolicy.PolicyAggregateActionInfo)
 	bgpAgg := config.BGPAggregate{
@@ -585,6 +665,7 @@ func (s *BGPServer) ApplyAggregateAction(actionInfo interface{}, conditionInfo [
 	}
 
 	s.logger.Infof("ApplyAggregateAction: aggregate result update=%+v, withdrawn=%+v", updated, withdrawn)
+	s.applyCommunityActionsToAggPaths(communityDB, policyStmt.Actions, updated)
 	s.setUpdatedWithAggPaths(&policyParams, updated, aggActions.SendSummaryOnly, ipPrefix, protoFamily,
 		updatedAddPaths)
 	s.logger.Infof("ApplyAggregateAction: after updating agg paths, update=%+v, withdrawn=%+v, "+
@@ -593,6 +674,22 @@ func (s *BGPServer) ApplyAggregateAction(actionInfo interface{}, conditionInfo [
 	return
 }
 
+func (s *BGPServer) applyCommunityActionsToAggPaths(communityDB *bgppolicy.CommunityPolicyDB, actions []string,
+	updated map[uint32]map[*bgprib.Path][]*bgprib.Destination) {
+	commActions := communityDB.GetActions(actions)
+	if len(commActions) == 0 {
+		return
+	}
+
+	for _, pathDestMap := range updated {
+		for path, _ := range pathDestMap {
+			if path.IsAggregate() {
+				path.PathAttrs = communityDB.ApplyActions(commActions, path.PathAttrs)
+			}
+		}
+	}
+}
+
 func (s *BGPServer) CheckForAggregation(updated map[uint32]map[*bgprib.Path][]*bgprib.Destination, withdrawn,
 	updatedAddPaths []*bgprib.Destination) (map[uint32]map[*bgprib.Path][]*bgprib.Destination, []*bgprib.Destination,
 	[]*bgprib.Destination) {
@@ -854,6 +951,15 @@ func (s *BGPServer) ApplyAdjRIBAction(actionInfo interface{}, conditionInfo []in
 	policyStmt utilspolicy.PolicyStmt) {
 	policyParams := params.(*AdjRIBPolicyParams)
 	s.logger.Infof("BGPServer:ApplyAdjRIBAction - policyParams=%+v, policyStmt=%+v\n", policyParams, policyStmt)
+	communityDB := s.ribInPE.GetCommunityPolicyDB()
+	if path := policyParams.getPath(); path != nil &&
+		!communityDB.MatchStatement(policyStmt, conditionInfo, path.PathAttrs) {
+		s.logger.Infof("BGPServer:ApplyAdjRIBAction - community conditions %v did not match for nlri %s",
+			policyStmt.Conditions, policyParams.Route.NLRI.GetCIDR())
+		return
+	}
+	policyParams.CommunityActions = append(policyParams.CommunityActions, communityDB.GetActions(policyStmt.Actions)...)
+
 	if len(policyStmt.Actions) > 0 {
 		for _, action := range policyStmt.Actions {
 			if action == "permit" {
@@ -865,7 +971,7 @@ func (s *BGPServer) ApplyAdjRIBAction(actionInfo interface{}, conditionInfo []in
 				s.logger.Info("BGPServer:ApplyAdjRIBAction - policyParams=%+v, policyStmt=%+v, action deny\n",
 					policyParams, policyStmt, action)
 				policyParams.Accept = Reject
-			} else {
+			} else if !communityDB.IsAction(action) {
 				s.logger.Err("BGPServer:ApplyAdjRIBAction - policyParams=%+v, policyStmt=%+v, unknown action=%s\n",
 					policyParams, policyStmt, action)
 			}
@@ -888,7 +994,7 @@ func (s *BGPServer) UndoAdjRIBAction(actionInfo interface{}, conditionInfo []int
 				s.logger.Info("BGPServer:UndoAdjRIBAction - policyParams=%+v, policyStmt=%+v, action deny\n",
 					policyParams, policyStmt, action)
 				policyParams.Accept = Reject
-			} else {
+			} else if !s.ribInPE.GetCommunityPolicyDB().IsAction(action) {
 				s.logger.Err("BGPServer:UndoAdjRIBAction - policyParams=%+v, policyStmt=%+v, unknown action=%s\n",
 					policyParams, policyStmt, action)
 			}
@@ -1064,6 +1170,47 @@ func (s *BGPServer) ProcessUpdate(pktInfo *packet.BGPPktSrc) {
 	updated, withdrawn, updatedAddPaths := peer.ReceiveUpdate(pktInfo)
 	updated, withdrawn, updatedAddPaths = s.CheckForAggregation(updated, withdrawn, updatedAddPaths)
 	s.SendUpdate(updated, withdrawn, updatedAddPaths)
+	s.checkGracefulRestartComplete()
+}
+
+func (s *BGPServer) ProcessRouteRefresh(pktInfo *packet.BGPPktSrc) {
+	peer, ok := s.PeerMap[pktInfo.Src]
+	if !ok {
+		s.logger.Err("BgpServer:ProcessRouteRefresh - Peer not found, address:", pktInfo.Src)
+		return
+	}
+
+	updated, withdrawn, updatedAddPaths := peer.ReceiveRouteRefresh(pktInfo)
+	updated, withdrawn, updatedAddPaths = s.CheckForAggregation(updated, withdrawn, updatedAddPaths)
+	s.SendUpdate(updated, withdrawn, updatedAddPaths)
+}
+
+func (s *BGPServer) ProcessRefreshStaleTimerExp(peerIP string) {
+	peer, ok := s.PeerMap[peerIP]
+	if !ok {
+		s.logger.Infof("Route refresh stale timer expired, Peer %s does not exist", peerIP)
+		return
+	}
+
+	if peer.refreshStaleTimer == nil {
+		return
+	}
+	peer.clearRIBInStale()
+}
+
+func (s *BGPServer) ProcessPeerSoftClear(softClear config.PeerSoftClear) {
+	peer, ok := s.PeerMap[softClear.IP.String()]
+	if !ok {
+		s.logger.Infof("Failed to soft clear peer. Peer at address %s does not exist", softClear.IP)
+		return
+	}
+
+	if softClear.In {
+		peer.SoftClearIn()
+	}
+	if softClear.Out {
+		peer.SoftClearOut()
+	}
 }
 
 func (s *BGPServer) convertDestIPToIPPrefix(routes []*config.RouteInfo) map[uint32][]packet.NLRI {
@@ -1097,6 +1244,7 @@ func (s *BGPServer) convertDestIPToIPPrefix(routes []*config.RouteInfo) map[uint
 
 func (s *BGPServer) ProcessConnectedRoutes(installedRoutes, withdrawnRoutes []*config.RouteInfo) {
 	s.logger.Info("valid routes:", installedRoutes, "invalid routes:", withdrawnRoutes)
+	installedRoutes, withdrawnRoutes = s.processVRFConnectedRoutes(installedRoutes, withdrawnRoutes)
 	valid := s.convertDestIPToIPPrefix(installedRoutes)
 	invalid := s.convertDestIPToIPPrefix(withdrawnRoutes)
 	s.logger.Info("pfNLRI valid:", valid, "invalid:", invalid)
@@ -1349,6 +1497,15 @@ func (s *BGPServer) copyGlobalConf(gConf config.GlobalConfig) {
 	s.BgpConfig.Global.Config.EBGPMaxPaths = gConf.EBGPMaxPaths
 	s.BgpConfig.Global.Config.EBGPAllowMultipleAS = gConf.EBGPAllowMultipleAS
 	s.BgpConfig.Global.Config.IBGPMaxPaths = gConf.IBGPMaxPaths
+	s.BgpConfig.Global.Config.GracefulRestart = gConf.GracefulRestart
+	s.BgpConfig.Global.Config.RestartTime = gConf.RestartTime
+	s.BgpConfig.Global.Config.StalePathTime = gConf.StalePathTime
+	s.BgpConfig.Global.Config.AlwaysCompareMED = gConf.AlwaysCompareMED
+	s.BgpConfig.Global.Config.DeterministicMED = gConf.DeterministicMED
+	s.BgpConfig.Global.Config.MRTRIBDumpFile = gConf.MRTRIBDumpFile
+	s.BgpConfig.Global.Config.MRTRIBDumpInterval = gConf.MRTRIBDumpInterval
+	s.BgpConfig.Global.Config.MRTUpdatesFile = gConf.MRTUpdatesFile
+	s.BgpConfig.Global.Config.MRTUpdatesInterval = gConf.MRTUpdatesInterval
 }
 
 func (s *BGPServer) handleBfdNotifications(oper config.Operation, DestIp string,
@@ -1412,6 +1569,15 @@ func (s *BGPServer) constructBGPGlobalState(gConf *config.GlobalConfig) {
 	s.BgpConfig.Global.State.EBGPMaxPaths = gConf.EBGPMaxPaths
 	s.BgpConfig.Global.State.EBGPAllowMultipleAS = gConf.EBGPAllowMultipleAS
 	s.BgpConfig.Global.State.IBGPMaxPaths = gConf.IBGPMaxPaths
+	s.BgpConfig.Global.State.GracefulRestart = gConf.GracefulRestart
+	s.BgpConfig.Global.State.RestartTime = gConf.RestartTime
+	s.BgpConfig.Global.State.StalePathTime = gConf.StalePathTime
+	s.BgpConfig.Global.State.AlwaysCompareMED = gConf.AlwaysCompareMED
+	s.BgpConfig.Global.State.DeterministicMED = gConf.DeterministicMED
+	s.BgpConfig.Global.State.MRTRIBDumpFile = gConf.MRTRIBDumpFile
+	s.BgpConfig.Global.State.MRTRIBDumpInterval = gConf.MRTRIBDumpInterval
+	s.BgpConfig.Global.State.MRTUpdatesFile = gConf.MRTUpdatesFile
+	s.BgpConfig.Global.State.MRTUpdatesInterval = gConf.MRTUpdatesInterval
 }
 
 func (s *BGPServer) SetupRedistribution(gConf config.GlobalConfig) {
@@ -1607,6 +1773,7 @@ func (s *BGPServer) UpdateGlobal(bgpGlobal *bgpd.BGPGlobal, oldConfig, newConfig
 	if attrSet != nil {
 		objTyp := reflect.TypeOf(*bgpGlobal)
 		restart := false
+		mrtUpdate := false
 		for i := 0; i < objTyp.NumField(); i++ {
 			objName := objTyp.Field(i).Name
 			if attrSet[i] {
@@ -1617,6 +1784,8 @@ func (s *BGPServer) UpdateGlobal(bgpGlobal *bgpd.BGPGlobal, oldConfig, newConfig
 						return
 					}
 					s.SetupRedistribution(newConfig)
+				} else if strings.HasPrefix(objName, "MRT") {
+					mrtUpdate = true
 				} else {
 					restart = true
 				}
@@ -1625,6 +1794,10 @@ func (s *BGPServer) UpdateGlobal(bgpGlobal *bgpd.BGPGlobal, oldConfig, newConfig
 
 		if restart {
 			s.Restart(newConfig)
+		} else if mrtUpdate {
+			s.copyGlobalConf(newConfig)
+			s.constructBGPGlobalState(&newConfig)
+			s.setupMRT()
 		}
 	}
 }
@@ -1643,11 +1816,21 @@ func (s *BGPServer) Restart(cfg config.GlobalConfig) {
 	runtime.Gosched()
 
 	s.RemoveRoutesFromAllNeighbor()
+	s.RemoveVRFImportedRoutes()
+	s.RemoveEVPNRemoteRoutes()
 
 	gConf := cfg
 	packet.SetNextHopPathAttrs(s.ConnRoutesPath.PathAttrs, gConf.RouterId)
+	for _, vrf := range s.vrfs {
+		packet.SetNextHopPathAttrs(vrf.exportPath.PathAttrs, gConf.RouterId)
+		if vrf.evpnExportPath != nil {
+			packet.SetNextHopPathAttrs(vrf.evpnExportPath.PathAttrs, gConf.RouterId)
+		}
+	}
 	s.copyGlobalConf(gConf)
 	s.constructBGPGlobalState(&gConf)
+	s.setupMRT()
+	s.restartEVIs()
 
 	for _, peer := range s.PeerMap {
 		peer.UpdateGlobal(&s.BgpConfig.Global.Config)
@@ -2174,6 +2357,33 @@ func (s *BGPServer) listenChannelUpdates() {
 		case aggConf := <-s.RemAggCh:
 			s.DeleteAgg(aggConf)
 
+		case stationUpdate := <-s.AddBMPStationCh:
+			s.AddOrUpdateBMPStation(stationUpdate.OldStation, stationUpdate.NewStation)
+
+		case stationConf := <-s.RemBMPStationCh:
+			s.DeleteBMPStation(stationConf)
+
+		case stationEvent := <-s.bmpEventCh:
+			s.ProcessBMPStationEvent(stationEvent)
+
+		case vrfUpdate := <-s.AddVRFCh:
+			s.AddOrUpdateVRF(vrfUpdate.OldVRF, vrfUpdate.NewVRF)
+
+		case vrfConf := <-s.RemVRFCh:
+			s.DeleteVRF(vrfConf)
+
+		case eviUpdate := <-s.AddEVICh:
+			s.AddOrUpdateEVI(eviUpdate.OldEVI, eviUpdate.NewEVI)
+
+		case eviConf := <-s.RemEVICh:
+			s.DeleteEVI(eviConf)
+
+		case macInfo := <-s.EVPNMacCh:
+			s.ProcessEVPNMac(macInfo)
+
+		case <-s.mrtRIBDumpCh:
+			s.ProcessMRTRIBDumpTimer()
+
 		case tcpConn := <-s.acceptCh:
 			s.logger.Info("Connected to", tcpConn.RemoteAddr().String())
 			host, _, _ := net.SplitHostPort(tcpConn.RemoteAddr().String())
@@ -2197,6 +2407,10 @@ func (s *BGPServer) listenChannelUpdates() {
 			}
 			peer.Command(peerCommand.Command, fsm.BGPCmdReasonNone)
 
+		case softClear := <-s.PeerSoftClearCh:
+			s.logger.Info("Peer soft clear received", softClear)
+			s.ProcessPeerSoftClear(softClear)
+
 		case peerFSMConn := <-s.PeerFSMConnCh:
 			s.logger.Infof("Server: Peer %s FSM established/broken channel", peerFSMConn.PeerIP)
 			peer, ok := s.PeerMap[peerFSMConn.PeerIP]
@@ -2208,13 +2422,16 @@ func (s *BGPServer) listenChannelUpdates() {
 
 			if peerFSMConn.Established {
 				peer.PeerConnEstablished(peerFSMConn.Conn)
+				peer.bmpPeerConnUp(peerFSMConn)
 				addPathsMaxTx := peer.getAddPathsMaxTx()
 				if addPathsMaxTx > s.AddPathCount {
 					s.AddPathCount = addPathsMaxTx
 				}
 				s.setInterfaceMapForPeer(peerFSMConn.PeerIP, peer)
-				s.SendAllRoutesToPeer(peer)
+				s.peerGracefulRestartUp(peer)
 			} else {
+				gracefulRestart := s.peerGracefulRestart(peer, peerFSMConn.GracefulRestart)
+				peer.bmpPeerConnDown(peerFSMConn)
 				peer.PeerConnBroken(true)
 				addPathsMaxTx := peer.getAddPathsMaxTx()
 				if addPathsMaxTx < s.AddPathCount {
@@ -2227,7 +2444,9 @@ func (s *BGPServer) listenChannelUpdates() {
 					}
 				}
 				s.clearInterfaceMapForPeer(peerFSMConn.PeerIP, peer)
-				s.ProcessRemoveNeighbor(peerFSMConn.PeerIP, peer)
+				if !gracefulRestart {
+					s.ProcessRemoveNeighbor(peerFSMConn.PeerIP, peer)
+				}
 			}
 
 		case peerIP := <-s.PeerConnEstCh:
@@ -2282,9 +2501,19 @@ func (s *BGPServer) listenChannelUpdates() {
 			peer.setIfIdx(-1)
 			s.ProcessRemoveNeighbor(peerIP, peer)
 
+		case grEvent := <-s.GREventCh:
+			s.ProcessGracefulRestartEvent(grEvent)
+
+		case peerIP := <-s.RefreshStaleCh:
+			s.ProcessRefreshStaleTimerExp(peerIP)
+
 		case pktInfo := <-s.BGPPktSrcCh:
 			s.logger.Info("Received BGP message from peer %s", pktInfo.Src)
-			s.ProcessUpdate(pktInfo)
+			if pktInfo.Msg.Header.Type == packet.BGPMsgTypeRouteRefresh {
+				s.ProcessRouteRefresh(pktInfo)
+			} else {
+				s.ProcessUpdate(pktInfo)
+			}
 
 		case reachabilityInfo := <-s.ReachabilityCh:
 			s.logger.Info("Server: Get reachability info for ip", reachabilityInfo.IP)
@@ -2408,6 +2637,7 @@ func (s *BGPServer) StartServer() {
 	s.BgpConfig.Global.Config = gConf
 	s.constructBGPGlobalState(&gConf)
 	s.BgpConfig.PeerGroups = make(map[uint32]map[string]*config.PeerGroup)
+	s.startGracefulRestart(gConf)
 
 	pathAttrs := packet.ConstructPathAttrForConnRoutes(gConf.AS)
 	protoFamily := packet.GetProtocolFamily(packet.AfiIP6, packet.SafiUnicast)
@@ -2428,6 +2658,7 @@ func (s *BGPServer) StartServer() {
 	s.IntfMgr.Start()
 	s.routeMgr.Start()
 	s.bfdMgr.Start()
+	s.evpnMgr.Start()
 	s.SetupRedistribution(gConf)
 
 	/*  ALERT: StartServer is a go routine and hence do not have any other go routine where
@@ -2447,6 +2678,8 @@ func (s *BGPServer) GetBGPGlobalState() config.GlobalState {
 	routesCount := s.LocRib.GetRoutesCount()
 	s.BgpConfig.Global.State.Totalv4Prefixes = 0
 	s.BgpConfig.Global.State.Totalv6Prefixes = 0
+	s.BgpConfig.Global.State.TotalVPNv4Prefixes = 0
+	s.BgpConfig.Global.State.TotalVPNv6Prefixes = 0
 	for protoFamily, count := range routesCount {
 		switch protoFamily {
 		case packet.ProtocolFamilyMap["ipv4-unicast"]:
@@ -2455,6 +2688,12 @@ func (s *BGPServer) GetBGPGlobalState() config.GlobalState {
 		case packet.ProtocolFamilyMap["ipv6-unicast"]:
 			s.BgpConfig.Global.State.Totalv6Prefixes = count
 
+		case packet.ProtocolFamilyMap["l3vpn-ipv4-unicast"]:
+			s.BgpConfig.Global.State.TotalVPNv4Prefixes = count
+
+		case packet.ProtocolFamilyMap["l3vpn-ipv6-unicast"]:
+			s.BgpConfig.Global.State.TotalVPNv6Prefixes = count
+
 		default:
 			s.logger.Err("Unknown protocol family type", protoFamily)
 		}
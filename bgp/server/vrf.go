@@ -0,0 +1,426 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// vrf.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/bgp/config"
+	"l3/bgp/packet"
+	bgprib "l3/bgp/rib"
+)
+
+// VRF is a VPN routing and forwarding instance of a PE. The VPN routes with a matching import route target are
+// imported to the VRF Loc-RIB and installed in the VRF, the connected routes of the VRF are exported to the VPN
+// table with the route distinguisher, the label and the export route targets of the VRF. The connected routes are also
+// exported as EVPN IP prefix routes with the L3 VNI of the VRF, if it is set.
+type VRF struct {
+	Config         config.VRFConfig
+	RD             packet.RouteDistinguisher
+	ImportRTs      map[packet.BGPExtCommunity]bool
+	ExportRTs      []packet.BGPExtCommunity
+	LocRib         *bgprib.LocRib
+	connRoutesPath *bgprib.Path
+	exportPath     *bgprib.Path
+	evpnExportPath *bgprib.Path
+	imported       map[string]*vrfImportedRoute
+	importPathId   uint32
+}
+
+type vrfImportedRoute struct {
+	peerIP      string
+	protoFamily uint32
+	nlri        packet.NLRI
+	vpnPath     *bgprib.Path
+	path        *bgprib.Path
+}
+
+func (v *VRF) String() string {
+	return fmt.Sprintf("VRF %s rd %s", v.Config.Name, v.RD)
+}
+
+func (v *VRF) isImported(path *bgprib.Path) bool {
+	for _, rt := range packet.GetRouteTargets(path.PathAttrs) {
+		if v.ImportRTs[rt] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BGPServer) newVRF(vrfConf config.VRFConfig) (*VRF, error) {
+	rd, err := packet.ParseRouteDistinguisher(vrfConf.RouteDistinguisher)
+	if err != nil {
+		return nil, err
+	}
+
+	if vrfConf.Label > packet.MPLSLabelMax {
+		return nil, errors.New(fmt.Sprintf("VRF %s label %d is not valid", vrfConf.Name, vrfConf.Label))
+	}
+
+	if vrfConf.L3VNI > packet.VXLANVNIMax {
+		return nil, errors.New(fmt.Sprintf("VRF %s L3 VNI %d is not valid", vrfConf.Name, vrfConf.L3VNI))
+	}
+
+	vrf := &VRF{
+		Config:    vrfConf,
+		RD:        rd,
+		ImportRTs: make(map[packet.BGPExtCommunity]bool),
+		ExportRTs: make([]packet.BGPExtCommunity, 0),
+		imported:  make(map[string]*vrfImportedRoute),
+	}
+
+	for _, rtStr := range vrfConf.ImportRouteTargets {
+		rt, err := packet.ParseRouteTarget(rtStr)
+		if err != nil {
+			return nil, err
+		}
+		vrf.ImportRTs[rt] = true
+	}
+
+	for _, rtStr := range vrfConf.ExportRouteTargets {
+		rt, err := packet.ParseRouteTarget(rtStr)
+		if err != nil {
+			return nil, err
+		}
+		vrf.ExportRTs = append(vrf.ExportRTs, rt)
+	}
+
+	gConf := &s.BgpConfig.Global.Config
+	vrf.LocRib = bgprib.NewVRFLocRib(s.logger, s.routeMgr, s.stateDBMgr, gConf, vrfConf.Name)
+	protoFamily := packet.GetProtocolFamily(packet.AfiIP6, packet.SafiUnicast)
+	vrf.connRoutesPath = bgprib.NewPath(vrf.LocRib, nil, packet.ConstructPathAttrForConnRoutes(gConf.AS),
+		packet.ConstructIPv6MPReachNLRIForConnRoutes(protoFamily), bgprib.RouteTypeConnected)
+
+	pathAttrs := packet.ConstructPathAttrForConnRoutes(gConf.AS)
+	packet.SetNextHopPathAttrs(pathAttrs, gConf.RouterId)
+	pathAttrs = packet.SetRouteTargets(pathAttrs, vrf.ExportRTs)
+	vrf.exportPath = bgprib.NewPath(s.LocRib, nil, pathAttrs, nil, bgprib.RouteTypeConnected)
+
+	if vrfConf.L3VNI != 0 {
+		comms := append([]packet.BGPExtCommunity{packet.NewEncapsulationExtCommunity(
+			packet.BGPEncapTunnelTypeVXLAN)}, vrf.ExportRTs...)
+		pathAttrs = packet.SetExtCommunities(packet.CopyPathAttrs(pathAttrs), comms)
+		vrf.evpnExportPath = bgprib.NewPath(s.LocRib, nil, pathAttrs, nil, bgprib.RouteTypeConnected)
+	}
+	return vrf, nil
+}
+
+func (s *BGPServer) AddOrUpdateVRF(oldConf config.VRFConfig, newConf config.VRFConfig) {
+	s.logger.Infof("AddOrUpdateVRF - old %+v new %+v", oldConf, newConf)
+	if oldConf.Name != "" {
+		s.removeVRF(oldConf.Name)
+	}
+	s.removeVRF(newConf.Name)
+
+	vrf, err := s.newVRF(newConf)
+	if err != nil {
+		s.logger.Errf("AddOrUpdateVRF - failed to create VRF %s with error %s", newConf.Name, err)
+		return
+	}
+
+	s.vrfs[newConf.Name] = vrf
+	s.logger.Infof("Created %s", vrf)
+	for protoFamily := range s.BgpConfig.Afs {
+		if !packet.IsVPNFamily(protoFamily) && !packet.IsEVPNFamily(protoFamily) {
+			continue
+		}
+		for _, dest := range s.LocRib.GetDestinations(protoFamily) {
+			s.importVPNRoute(vrf, dest)
+		}
+	}
+
+	routes := make([]*config.RouteInfo, 0)
+	for _, route := range s.vrfConnRoutes[newConf.Name] {
+		routes = append(routes, route)
+	}
+	s.exportVRFRoutes(vrf, routes, make([]*config.RouteInfo, 0))
+}
+
+func (s *BGPServer) DeleteVRF(vrfConf config.VRFConfig) {
+	s.logger.Infof("DeleteVRF - %+v", vrfConf)
+	s.removeVRF(vrfConf.Name)
+}
+
+func (s *BGPServer) removeVRF(name string) {
+	vrf, ok := s.vrfs[name]
+	if !ok {
+		return
+	}
+
+	s.logger.Infof("Remove %s", vrf)
+	s.removeVRFImportedRoutes(vrf)
+	routes := make([]*config.RouteInfo, 0)
+	for _, route := range s.vrfConnRoutes[name] {
+		routes = append(routes, route)
+	}
+	s.exportVRFRoutes(vrf, make([]*config.RouteInfo, 0), routes)
+	delete(s.vrfs, name)
+}
+
+// getVRFImportFamily returns the unicast family of the routes carried in the VPN destination. The EVPN IP prefix
+// routes are only imported to the VRFs with a L3 VNI.
+func getVRFImportFamily(vrf *VRF, dest *bgprib.Destination) (uint32, bool) {
+	vpnFamily := dest.GetProtocolFamily()
+	if !packet.IsEVPNFamily(vpnFamily) {
+		return packet.GetUnicastFamily(vpnFamily), true
+	}
+
+	evpnPrefix, ok := dest.NLRI.(*packet.EVPNPrefix)
+	if !ok || evpnPrefix.RouteType != packet.EVPNRouteTypeIPPrefix || vrf.Config.L3VNI == 0 {
+		return 0, false
+	}
+	if evpnPrefix.Prefix.To4() != nil {
+		return packet.GetProtocolFamily(packet.AfiIP, packet.SafiUnicast), true
+	}
+	return packet.GetProtocolFamily(packet.AfiIP6, packet.SafiUnicast), true
+}
+
+// getVPNLabels returns the label stack the egress PE advertised with the VPN destination
+func getVPNLabels(dest *bgprib.Destination) []uint32 {
+	vpnPrefix, ok := dest.NLRI.(*packet.VPNPrefix)
+	if !ok {
+		return nil
+	}
+	labels := make([]uint32, len(vpnPrefix.Labels))
+	copy(labels, vpnPrefix.Labels)
+	return labels
+}
+
+// importVPNRoute imports the best path of the VPN or EVPN IP prefix destination to the VRF if the path has one
+// of the import route targets of the VRF. The route is removed from the VRF if the destination is withdrawn or
+// the path is no longer imported. The VPN routes originated by this PE are not imported. Each imported
+// destination is added to the VRF Loc-RIB with a path id of its own, so the same prefix imported with different
+// route distinguishers from one PE is kept as separate paths. The path carries the VPN labels of the
+// destination, which are installed with the route in RIBd.
+func (s *BGPServer) importVPNRoute(vrf *VRF, dest *bgprib.Destination) {
+	key := dest.NLRI.GetCIDR()
+	imported, ok := vrf.imported[key]
+	vpnPath := dest.LocRibPath
+	protoFamily, importable := getVRFImportFamily(vrf, dest)
+	if !importable || vpnPath == nil || vpnPath.IsLocal() || !vrf.isImported(vpnPath) {
+		if ok {
+			s.removeVRFImportedRoute(vrf, key, imported)
+		}
+		return
+	}
+
+	if ok && imported.vpnPath == vpnPath {
+		return
+	}
+
+	vpnFamily := dest.GetProtocolFamily()
+	nextHop := vpnPath.GetNextHop(vpnFamily)
+	if nextHop == nil {
+		s.logger.Errf("%s - can't import %s, next hop not found", vrf, key)
+		return
+	}
+
+	peerIP := vpnPath.GetPeerIP()
+	var pathId uint32
+	if ok && imported.peerIP == peerIP {
+		pathId = imported.nlri.GetPathId()
+	} else {
+		if ok {
+			s.removeVRFImportedRoute(vrf, key, imported)
+		}
+		vrf.importPathId++
+		pathId = vrf.importPathId
+	}
+
+	mpReach := packet.ConstructMPReachNLRIForNextHop(protoFamily, nextHop)
+	path := bgprib.NewPath(vrf.LocRib, vpnPath.NeighborConf, packet.CopyPathAttrs(vpnPath.PathAttrs), mpReach,
+		bgprib.RouteTypeEGP)
+	labels := getVPNLabels(dest)
+	path.SetLabels(labels)
+	nlri := packet.NewExtNLRI(pathId, dest.NLRI.GetIPPrefix().Clone().(*packet.IPPrefix))
+	s.logger.Infof("%s - import %s from %s next hop %s labels %v path id %d", vrf, key, peerIP, nextHop, labels,
+		pathId)
+	updated := make(map[uint32]map[*bgprib.Path][]*bgprib.Destination)
+	withdrawn := make([]*bgprib.Destination, 0)
+	updatedAddPaths := make([]*bgprib.Destination, 0)
+	vrf.LocRib.TestNHAndProcessRoutes(peerIP, []packet.NLRI{nlri}, nil, path, path, 0, protoFamily, updated,
+		withdrawn, updatedAddPaths)
+	vrf.imported[key] = &vrfImportedRoute{
+		peerIP:      peerIP,
+		protoFamily: protoFamily,
+		nlri:        nlri,
+		vpnPath:     vpnPath,
+		path:        path,
+	}
+}
+
+func (s *BGPServer) removeVRFImportedRoute(vrf *VRF, key string, imported *vrfImportedRoute) {
+	s.logger.Infof("%s - remove imported route %s from %s", vrf, key, imported.peerIP)
+	updated := make(map[uint32]map[*bgprib.Path][]*bgprib.Destination)
+	withdrawn := make([]*bgprib.Destination, 0)
+	updatedAddPaths := make([]*bgprib.Destination, 0)
+	vrf.LocRib.ProcessRoutes(imported.peerIP, nil, []packet.NLRI{imported.nlri}, imported.path, imported.path, 0,
+		imported.protoFamily, updated, withdrawn, updatedAddPaths)
+	delete(vrf.imported, key)
+}
+
+func (s *BGPServer) removeVRFImportedRoutes(vrf *VRF) {
+	for key, imported := range vrf.imported {
+		s.removeVRFImportedRoute(vrf, key, imported)
+	}
+}
+
+// RemoveVRFImportedRoutes removes the imported routes from all the VRFs. It is called when the routes from the
+// neighbors are removed from the Loc-RIB without the withdraws being sent.
+func (s *BGPServer) RemoveVRFImportedRoutes() {
+	for _, vrf := range s.vrfs {
+		s.removeVRFImportedRoutes(vrf)
+	}
+}
+
+// processVRFImports imports the updated VPN and EVPN destinations to the VRFs and removes the withdrawn ones
+func (s *BGPServer) processVRFImports(updated map[uint32]map[*bgprib.Path][]*bgprib.Destination,
+	withdrawn []*bgprib.Destination) {
+	if len(s.vrfs) == 0 {
+		return
+	}
+
+	for protoFamily, pathDestMap := range updated {
+		if !packet.IsVPNFamily(protoFamily) && !packet.IsEVPNFamily(protoFamily) {
+			continue
+		}
+		for _, destinations := range pathDestMap {
+			for _, dest := range destinations {
+				if dest == nil {
+					continue
+				}
+				for _, vrf := range s.vrfs {
+					s.importVPNRoute(vrf, dest)
+				}
+			}
+		}
+	}
+
+	for _, dest := range withdrawn {
+		if dest == nil || (!packet.IsVPNFamily(dest.GetProtocolFamily()) &&
+			!packet.IsEVPNFamily(dest.GetProtocolFamily())) {
+			continue
+		}
+		for _, vrf := range s.vrfs {
+			s.importVPNRoute(vrf, dest)
+		}
+	}
+}
+
+func (s *BGPServer) constructVPNPrefixes(vrf *VRF, pfNLRI map[uint32][]packet.NLRI) map[uint32][]packet.NLRI {
+	vpnNLRI := make(map[uint32][]packet.NLRI)
+	for protoFamily, nlris := range pfNLRI {
+		vpnFamily := packet.GetVPNFamily(protoFamily)
+		vpnNLRI[vpnFamily] = make([]packet.NLRI, 0, len(nlris))
+		for _, nlri := range nlris {
+			ipPrefix := nlri.GetIPPrefix().Clone().(*packet.IPPrefix)
+			vpnNLRI[vpnFamily] = append(vpnNLRI[vpnFamily], packet.NewVPNPrefix(vrf.RD,
+				[]uint32{vrf.Config.Label}, ipPrefix))
+		}
+	}
+	return vpnNLRI
+}
+
+func (s *BGPServer) constructEVPNIPPrefixes(vrf *VRF, pfNLRI map[uint32][]packet.NLRI) map[uint32][]packet.NLRI {
+	evpnFamily := getEVPNFamily()
+	evpnNLRI := map[uint32][]packet.NLRI{evpnFamily: make([]packet.NLRI, 0)}
+	for _, nlris := range pfNLRI {
+		for _, nlri := range nlris {
+			ipPrefix := nlri.GetIPPrefix().Clone().(*packet.IPPrefix)
+			evpnNLRI[evpnFamily] = append(evpnNLRI[evpnFamily], packet.NewEVPNIPPrefix(vrf.RD, 0, ipPrefix, nil,
+				vrf.Config.L3VNI))
+		}
+	}
+	return evpnNLRI
+}
+
+// exportVRFRoutes adds the connected routes to the VRF Loc-RIB and advertises them as VPN routes and as EVPN IP
+// prefix routes
+func (s *BGPServer) exportVRFRoutes(vrf *VRF, installedRoutes, withdrawnRoutes []*config.RouteInfo) {
+	if len(installedRoutes) == 0 && len(withdrawnRoutes) == 0 {
+		return
+	}
+
+	valid := s.convertDestIPToIPPrefix(installedRoutes)
+	invalid := s.convertDestIPToIPPrefix(withdrawnRoutes)
+	vpnValid := s.constructVPNPrefixes(vrf, valid)
+	vpnInvalid := s.constructVPNPrefixes(vrf, invalid)
+	evpnValid := s.constructEVPNIPPrefixes(vrf, valid)
+	evpnInvalid := s.constructEVPNIPPrefixes(vrf, invalid)
+	s.logger.Infof("%s - export valid routes %v, invalid routes %v", vrf, vpnValid, vpnInvalid)
+
+	routerId := s.BgpConfig.Global.Config.RouterId.String()
+	vrf.LocRib.ProcessConnectedRoutes(routerId, vrf.connRoutesPath, valid, invalid, 0)
+	updated, withdrawn, updatedAddPaths := s.LocRib.ProcessConnectedRoutes(routerId, vrf.exportPath, vpnValid,
+		vpnInvalid, s.AddPathCount)
+	s.SendUpdate(updated, withdrawn, updatedAddPaths)
+
+	if vrf.evpnExportPath != nil {
+		updated, withdrawn, updatedAddPaths = s.LocRib.ProcessConnectedRoutes(routerId, vrf.evpnExportPath,
+			evpnValid, evpnInvalid, s.AddPathCount)
+		s.SendUpdate(updated, withdrawn, updatedAddPaths)
+	}
+}
+
+// processVRFConnectedRoutes exports the connected routes of the VRFs and returns the routes of the global table
+func (s *BGPServer) processVRFConnectedRoutes(installedRoutes, withdrawnRoutes []*config.RouteInfo) (
+	[]*config.RouteInfo, []*config.RouteInfo) {
+	vrfInstalled := make(map[string][]*config.RouteInfo)
+	vrfWithdrawn := make(map[string][]*config.RouteInfo)
+	installed := make([]*config.RouteInfo, 0, len(installedRoutes))
+	withdrawn := make([]*config.RouteInfo, 0, len(withdrawnRoutes))
+
+	for _, route := range installedRoutes {
+		if route.Vrf == "" {
+			installed = append(installed, route)
+			continue
+		}
+		if _, ok := s.vrfConnRoutes[route.Vrf]; !ok {
+			s.vrfConnRoutes[route.Vrf] = make(map[string]*config.RouteInfo)
+		}
+		s.vrfConnRoutes[route.Vrf][route.IPAddr+"/"+route.Mask] = route
+		vrfInstalled[route.Vrf] = append(vrfInstalled[route.Vrf], route)
+	}
+
+	for _, route := range withdrawnRoutes {
+		if route.Vrf == "" {
+			withdrawn = append(withdrawn, route)
+			continue
+		}
+		if _, ok := s.vrfConnRoutes[route.Vrf]; ok {
+			delete(s.vrfConnRoutes[route.Vrf], route.IPAddr+"/"+route.Mask)
+			if len(s.vrfConnRoutes[route.Vrf]) == 0 {
+				delete(s.vrfConnRoutes, route.Vrf)
+			}
+		}
+		vrfWithdrawn[route.Vrf] = append(vrfWithdrawn[route.Vrf], route)
+	}
+
+	for name, vrf := range s.vrfs {
+		s.exportVRFRoutes(vrf, vrfInstalled[name], vrfWithdrawn[name])
+	}
+	return installed, withdrawn
+}
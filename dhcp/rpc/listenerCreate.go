@@ -28,8 +28,78 @@ import (
 	"errors"
 	"fmt"
 	"l3/dhcp/server"
+	"net"
+	"strings"
 )
 
+func convertDhcpReservations(subnet, subnetMask uint32, resvList []*dhcpd.DhcpReservation) ([]server.DhcpReservation, error) {
+	var reservations []server.DhcpReservation
+	ipMap := make(map[uint32]bool)
+	for _, resv := range resvList {
+		if resv.MacAddr == "" && resv.ClientId == "" {
+			err := errors.New("Invalid Reservation: either MacAddr or ClientId is required")
+			return nil, err
+		}
+		ipAddr, ret := convertIPStrToUint32(resv.IpAddr)
+		if !ret || ipAddr&subnetMask != subnet {
+			err := errors.New(fmt.Sprintln("Invalid Reservation IP Address:", resv.IpAddr))
+			return nil, err
+		}
+		if ipMap[ipAddr] {
+			err := errors.New(fmt.Sprintln("Duplicate Reservation for IP Address:", resv.IpAddr))
+			return nil, err
+		}
+		ipMap[ipAddr] = true
+		var macAddr string
+		if resv.MacAddr != "" {
+			mac, err := net.ParseMAC(resv.MacAddr)
+			if err != nil {
+				err := errors.New(fmt.Sprintln("Invalid Reservation Mac Address:", resv.MacAddr))
+				return nil, err
+			}
+			macAddr = mac.String()
+		}
+		reservations = append(reservations, server.DhcpReservation{
+			MacAddr:  macAddr,
+			ClientId: strings.ToLower(resv.ClientId),
+			IpAddr:   ipAddr,
+		})
+	}
+	return reservations, nil
+}
+
+func convertDhcpOptions(optList []*dhcpd.DhcpOption) ([]server.DhcpOptionConfig, error) {
+	var options []server.DhcpOptionConfig
+	for _, opt := range optList {
+		if opt.Code <= 0 || opt.Code >= 255 {
+			err := errors.New(fmt.Sprintln("Invalid Dhcp Option Code:", opt.Code))
+			return nil, err
+		}
+		options = append(options, server.DhcpOptionConfig{
+			Code:  uint8(opt.Code),
+			Value: opt.Value,
+		})
+	}
+	return options, nil
+}
+
+func convertDhcpClientClasses(classList []*dhcpd.DhcpClientClass) ([]server.DhcpClientClass, error) {
+	var clientClasses []server.DhcpClientClass
+	for _, class := range classList {
+		options, err := convertDhcpOptions(class.Options)
+		if err != nil {
+			return nil, err
+		}
+		clientClasses = append(clientClasses, server.DhcpClientClass{
+			Name:          class.Name,
+			VendorClassId: class.VendorClassId,
+			UserClass:     class.UserClass,
+			Options:       options,
+		})
+	}
+	return clientClasses, nil
+}
+
 func (h *DHCPHandler) SendSetDhcpGlobalConfig(conf *dhcpd.DhcpGlobalConfig) error {
 	if conf.DefaultLeaseTime > conf.MaxLeaseTime {
 		err := errors.New("Invalid Config: Default Lease Time cannot be more than Max Lease Time")
@@ -101,6 +171,21 @@ func (h *DHCPHandler) SendSetDhcpIntfConfig(conf *dhcpd.DhcpIntfConfig) error {
 	}
 	h.logger.Info(fmt.Sprintln("DNSServerAddr:", dnsAddr))
 
+	reservations, err := convertDhcpReservations(subnet, subnetMask, conf.Reservations)
+	if err != nil {
+		return err
+	}
+
+	options, err := convertDhcpOptions(conf.Options)
+	if err != nil {
+		return err
+	}
+
+	clientClasses, err := convertDhcpClientClasses(conf.ClientClasses)
+	if err != nil {
+		return err
+	}
+
 	dhcpIntfConf := server.DhcpIntfConfig{
 		IntfRef:       conf.IntfRef,
 		Subnet:        subnet,
@@ -112,6 +197,10 @@ func (h *DHCPHandler) SendSetDhcpIntfConfig(conf *dhcpd.DhcpIntfConfig) error {
 		DnsAddr:       dnsAddr,
 		DomainName:    conf.DomainName,
 		Enable:        conf.Enable,
+		PingCheck:     conf.PingCheck,
+		Reservations:  reservations,
+		Options:       options,
+		ClientClasses: clientClasses,
 	}
 	h.server.DhcpIntfConfCh <- dhcpIntfConf
 	retMsg := <-h.server.DhcpIntfConfRetCh
@@ -0,0 +1,59 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package rpc
+
+import (
+	"dhcpd"
+	"fmt"
+	"l3/dhcp/server"
+)
+
+func (h *DHCPHandler) convertDhcpLeaseStateToThrift(leaseState server.DhcpLeaseState) *dhcpd.DhcpLeaseState {
+	leaseEnt := dhcpd.NewDhcpLeaseState()
+	leaseEnt.IpAddr = leaseState.IpAddr
+	leaseEnt.MacAddr = leaseState.MacAddr
+	leaseEnt.ClientId = leaseState.ClientId
+	leaseEnt.IntfRef = leaseState.IntfRef
+	leaseEnt.State = leaseState.State
+	leaseEnt.Reserved = leaseState.Reserved
+	leaseEnt.LeaseTime = int32(leaseState.LeaseTime)
+	leaseEnt.ExpiryTimeLeft = leaseState.ExpiryTimeLeft
+	return leaseEnt
+}
+
+func (h *DHCPHandler) GetBulkDhcpLeaseState(fromIdx dhcpd.Int, count dhcpd.Int) (*dhcpd.DhcpLeaseStateGetInfo, error) {
+	h.logger.Info(fmt.Sprintln("GetBulk call for DhcpLeaseState..."))
+	nextIdx, currCount, leaseEntries := h.server.GetBulkDhcpLeaseState(int(fromIdx), int(count))
+	leaseResponse := make([]*dhcpd.DhcpLeaseState, len(leaseEntries))
+	for idx, item := range leaseEntries {
+		leaseResponse[idx] = h.convertDhcpLeaseStateToThrift(item)
+	}
+	leaseBulk := dhcpd.NewDhcpLeaseStateGetInfo()
+	leaseBulk.Count = dhcpd.Int(currCount)
+	leaseBulk.StartIdx = dhcpd.Int(fromIdx)
+	leaseBulk.EndIdx = dhcpd.Int(nextIdx)
+	leaseBulk.More = (nextIdx != 0)
+	leaseBulk.DhcpLeaseStateList = leaseResponse
+	return leaseBulk, nil
+}
@@ -72,7 +72,23 @@ func (server *DHCPServer) StartLeaseEntryHandler(port int32, ipAddr uint32, macA
 	l3IfIdx := portEnt.L3IfIndex
 	l3Ent, _ := server.l3IntfPropMap[l3IfIdx]
 	dhcpIntfKey := l3Ent.DhcpIfKey
-	removeLeaseExpireFunc := func() {
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
+	if uIPEnt.RefreshTimer != nil {
+		// Lease restored from DB is being re-acked by the client
+		uIPEnt.RefreshTimer.Stop()
+	}
+	uIPEnt.RefreshTimer = time.AfterFunc(time.Duration(uIPEnt.LeaseTime)*time.Second, server.leaseExpiryFunc(dhcpIntfKey, ipAddr, macAddr))
+	uIPEnt.Expiry = time.Now().Add(time.Duration(uIPEnt.LeaseTime) * time.Second)
+	uIPEnt.State = BOUND
+	//server.logger.Info(fmt.Sprintln("3 uIPEnt: ", uIPEnt))
+	dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	server.storeLeaseInDB(ipAddr, uIPEnt)
+}
+
+func (server *DHCPServer) leaseExpiryFunc(dhcpIntfKey DhcpIntfKey, ipAddr uint32, macAddr string) func() {
+	return func() {
 		server.logger.Info(fmt.Sprintln("Removing the lease expiry entry ", ipAddr, macAddr))
 		dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
 		uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
@@ -80,17 +96,12 @@ func (server *DHCPServer) StartLeaseEntryHandler(port int32, ipAddr uint32, macA
 		if uIPEnt.StaleTimer != nil {
 			uIPEnt.StaleTimer.Stop()
 		}
-		uIPEnt.RefreshTimer.Stop()
+		if uIPEnt.RefreshTimer != nil {
+			uIPEnt.RefreshTimer.Stop()
+		}
 		delete(dhcpIntfEnt.usedIpPool, ipAddr)
 		delete(dhcpIntfEnt.usedIpToMac, macAddr)
 		server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+		server.deleteLeaseInDB(ipAddr)
 	}
-	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
-	uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
-	uIPEnt.RefreshTimer = time.AfterFunc(time.Duration(uIPEnt.LeaseTime)*time.Second, removeLeaseExpireFunc)
-	uIPEnt.State = OFFERED
-	//server.logger.Info(fmt.Sprintln("3 uIPEnt: ", uIPEnt))
-	dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
-	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
-
 }
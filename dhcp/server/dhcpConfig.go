@@ -24,7 +24,6 @@
 package server
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"strconv"
@@ -68,6 +67,11 @@ func (server *DHCPServer) processDhcpIntfConf(conf DhcpIntfConfig) (error, int32
 		}
 	*/
 
+	options, clientClasses, err := buildDhcpPoolOptions(conf)
+	if err != nil {
+		return err, l3IfIdx
+	}
+
 	l3Ent.DhcpConfig = true
 	l3Ent.DhcpIfKey = dhcpIntfKey
 	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
@@ -78,10 +82,15 @@ func (server *DHCPServer) processDhcpIntfConf(conf DhcpIntfConfig) (error, int32
 	dhcpIntfEnt.rtrAddr = conf.RtrAddr
 	dhcpIntfEnt.dnsAddr = conf.DnsAddr
 	dhcpIntfEnt.domainName = conf.DomainName
+	dhcpIntfEnt.pingCheck = conf.PingCheck
+	dhcpIntfEnt.options = options
+	dhcpIntfEnt.clientClasses = clientClasses
+	server.buildReservations(&dhcpIntfEnt, conf.Reservations)
 	dhcpIntfEnt.usedIpPool = make(map[uint32]DhcpOfferedData)
 	dhcpIntfEnt.usedIpToMac = make(map[string]uint32)
 	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
 	server.l3IntfPropMap[l3IfIdx] = l3Ent
+	server.restoreLeasesFromDB(dhcpIntfKey)
 	return nil, l3IfIdx
 }
 
@@ -114,24 +123,9 @@ func (server *DHCPServer) constructDhcpMsg(l3IfIdx int32) {
 	*/
 	dhcpKey := l3Ent.DhcpIfKey
 	dhcpDataEnt, _ := server.DhcpIntfConfMap[dhcpKey]
-	dhcpDataEnt.dhcpMsg = make([]byte, 36)
-	binary.BigEndian.PutUint32(dhcpDataEnt.dhcpMsg[0:4], 0x63825363)
-	dhcpDataEnt.dhcpMsg[4] = DhcpMsgTypeOptCode
-	dhcpDataEnt.dhcpMsg[5] = uint8(1)
-	dhcpDataEnt.dhcpMsg[6] = uint8(0)
-	dhcpDataEnt.dhcpMsg[7] = ServerIdOptCode
-	dhcpDataEnt.dhcpMsg[8] = uint8(4)
-	binary.BigEndian.PutUint32(dhcpDataEnt.dhcpMsg[9:13], l3Ent.IpAddr)
-	dhcpDataEnt.dhcpMsg[13] = IPAddrLeaseTimeOptCode
-	dhcpDataEnt.dhcpMsg[14] = uint8(4)
-	binary.BigEndian.PutUint32(dhcpDataEnt.dhcpMsg[15:19], server.DhcpGlobalConf.DefaultLeaseTime)
-	dhcpDataEnt.dhcpMsg[19] = SubnetMaskOptCode
-	dhcpDataEnt.dhcpMsg[20] = uint8(4)
-	binary.BigEndian.PutUint32(dhcpDataEnt.dhcpMsg[21:25], dhcpKey.subnetMask)
-	dhcpDataEnt.dhcpMsg[25] = RouterOptCode
-	dhcpDataEnt.dhcpMsg[26] = uint8(4)
-	binary.BigEndian.PutUint32(dhcpDataEnt.dhcpMsg[27:31], dhcpDataEnt.rtrAddr)
-	dhcpDataEnt.dhcpMsg[31] = EndOptCode
+	// Options common to every reply, the rest are added per client by
+	// buildDhcpOptions
+	dhcpDataEnt.dhcpMsg = buildDhcpCommonOptions(l3Ent.IpAddr, server.DhcpGlobalConf.DefaultLeaseTime, dhcpKey.subnetMask)
 	server.DhcpIntfConfMap[dhcpKey] = dhcpDataEnt
 	//server.logger.Info(fmt.Sprintln("====Hello=======", dhcpDataEnt))
 }
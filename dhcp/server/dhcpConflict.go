@@ -0,0 +1,216 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	DHCP_PING_TIMEOUT       int    = 1 // In seconds
+	DHCP_MAX_CONFLICT_RETRY int    = 3
+	DHCP_PING_ID            uint16 = 0x4448
+	ICMP_ECHO_REQUEST       uint8  = 8
+	ICMP_ECHO_REPLY         uint8  = 0
+	ICMP_ECHO_HDR_LEN       int    = 8
+	ICMP_ECHO_PAYLOAD_LEN   int    = 8
+)
+
+var dhcpPingSeq uint32
+
+func buildIcmpEchoReq(id uint16, seq uint16) []byte {
+	pkt := make([]byte, ICMP_ECHO_HDR_LEN+ICMP_ECHO_PAYLOAD_LEN)
+	pkt[0] = ICMP_ECHO_REQUEST
+	binary.BigEndian.PutUint16(pkt[4:6], id)
+	binary.BigEndian.PutUint16(pkt[6:8], seq)
+	binary.BigEndian.PutUint64(pkt[ICMP_ECHO_HDR_LEN:], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint16(pkt[2:4], computeChkSum(pkt))
+	return pkt
+}
+
+// isIcmpEchoReply checks whether an IPv4 packet received on the raw socket is
+// the reply to our echo request
+func isIcmpEchoReply(pkt []byte, ipAddr uint32, id uint16, seq uint16) bool {
+	if len(pkt) < 20 {
+		return false
+	}
+	ihl := int(pkt[0]&0x0f) * 4
+	if len(pkt) < ihl+ICMP_ECHO_HDR_LEN {
+		return false
+	}
+	if binary.BigEndian.Uint32(pkt[12:16]) != ipAddr {
+		return false
+	}
+	icmp := pkt[ihl:]
+	return icmp[0] == ICMP_ECHO_REPLY &&
+		binary.BigEndian.Uint16(icmp[4:6]) == id &&
+		binary.BigEndian.Uint16(icmp[6:8]) == seq
+}
+
+// isIPAddrInUse sends an ICMP echo request to ipAddr over a raw socket bound
+// to ifName and waits up to DHCP_PING_TIMEOUT for a reply
+func (server *DHCPServer) isIPAddrInUse(ifName string, ipAddr uint32) bool {
+	ipStr := convertUint32ToIPv4(ipAddr)
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_RAW, syscall.IPPROTO_ICMP)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("Unable to open ICMP socket for conflict check of", ipStr, "error:", err))
+		return false
+	}
+	defer syscall.Close(fd)
+	err = syscall.SetsockoptString(fd, syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, ifName)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("Unable to bind ICMP socket to", ifName, "error:", err))
+		return false
+	}
+
+	id := DHCP_PING_ID ^ uint16(os.Getpid())
+	seq := uint16(atomic.AddUint32(&dhcpPingSeq, 1))
+	dst := &syscall.SockaddrInet4{}
+	copy(dst.Addr[:], net.ParseIP(ipStr).To4())
+	if err = syscall.Sendto(fd, buildIcmpEchoReq(id, seq), 0, dst); err != nil {
+		server.logger.Err(fmt.Sprintln("Unable to send echo request to", ipStr, "on", ifName, "error:", err))
+		return false
+	}
+
+	buf := make([]byte, 1500)
+	deadline := time.Now().Add(time.Duration(DHCP_PING_TIMEOUT) * time.Second)
+	for {
+		timeLeft := deadline.Sub(time.Now())
+		if timeLeft <= 0 {
+			return false
+		}
+		tv := syscall.NsecToTimeval(timeLeft.Nanoseconds())
+		syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return false
+		}
+		if isIcmpEchoReply(buf[:n], ipAddr, id, seq) {
+			server.logger.Info(fmt.Sprintln("Received echo reply from", ipStr, "on", ifName, "address is already in use"))
+			return true
+		}
+	}
+}
+
+// startConflictCheck holds ipAddr for the client while it is being probed.
+// The probe runs in its own goroutine so that the offer is deferred rather
+// than blocking packet processing. The probe goes out of the port on which
+// the discover was received, which is the interface serving the pool
+func (server *DHCPServer) startConflictCheck(port int32, bootPMsgData *BootPMsgStruct, data []byte, ipAddr uint32, retry int) {
+	clientMac := (net.HardwareAddr(bootPMsgData.ClientHWAddr)).String()
+	portEnt, _ := server.portPropertyMap[port]
+	l3Ent, _ := server.l3IntfPropMap[portEnt.L3IfIndex]
+	dhcpIntfKey := l3Ent.DhcpIfKey
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.usedIpToMac[clientMac] = ipAddr
+	dhcpIntfEnt.usedIpPool[ipAddr] = DhcpOfferedData{
+		MacAddr:       clientMac,
+		ClientId:      getClientId(bootPMsgData),
+		TransactionId: bootPMsgData.TransactionId,
+		State:         PROBING,
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	server.logger.Info(fmt.Sprintln("Starting conflict check of", convertUint32ToIPv4(ipAddr), "for", clientMac))
+	go func() {
+		inUse := server.conflictCheckFunc(portEnt.IfName, ipAddr)
+		server.conflictCheckDone(port, bootPMsgData, data, ipAddr, retry, inUse)
+	}()
+}
+
+// conflictCheckDone offers the probed address if nobody answered, otherwise
+// the address is marked as conflicting and the next free address is probed
+func (server *DHCPServer) conflictCheckDone(port int32, bootPMsgData *BootPMsgStruct, data []byte, ipAddr uint32, retry int, inUse bool) {
+	clientMac := (net.HardwareAddr(bootPMsgData.ClientHWAddr)).String()
+	portEnt, _ := server.portPropertyMap[port]
+	l3Ent, _ := server.l3IntfPropMap[portEnt.L3IfIndex]
+	dhcpIntfKey := l3Ent.DhcpIfKey
+	dhcpIntfEnt, exist := server.DhcpIntfConfMap[dhcpIntfKey]
+	if !exist {
+		return
+	}
+	uIPEnt, exist := dhcpIntfEnt.usedIpPool[ipAddr]
+	if !exist || uIPEnt.State != PROBING || uIPEnt.MacAddr != clientMac {
+		server.logger.Info(fmt.Sprintln("Conflict check of", convertUint32ToIPv4(ipAddr), "for", clientMac, "is no longer valid"))
+		return
+	}
+	if !inUse {
+		server.offerIP(port, bootPMsgData, data, ipAddr)
+		return
+	}
+
+	delete(dhcpIntfEnt.usedIpToMac, clientMac)
+	if dhcpIntfEnt.reservedIps[ipAddr] {
+		delete(dhcpIntfEnt.usedIpPool, ipAddr)
+		server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+		server.logger.Err(fmt.Sprintln("Reserved IP", convertUint32ToIPv4(ipAddr), "for", clientMac, "is already in use"))
+		return
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	server.markIPConflict(dhcpIntfKey, ipAddr)
+
+	retry++
+	if retry >= DHCP_MAX_CONFLICT_RETRY {
+		server.logger.Err(fmt.Sprintln("Giving up on offering an IP Addr to", clientMac, "after", retry, "conflicts"))
+		return
+	}
+	ip, ret := server.allocateIP(dhcpIntfKey, clientMac, getClientId(bootPMsgData))
+	if ret == false {
+		server.logger.Err("No available IP Addr")
+		return
+	}
+	server.startConflictCheck(port, bootPMsgData, data, ip, retry)
+}
+
+// markIPConflict keeps an address which answered our ping out of the pool
+// for one default lease time
+func (server *DHCPServer) markIPConflict(dhcpIntfKey DhcpIntfKey, ipAddr uint32) {
+	removeConflictFunc := func() {
+		server.logger.Info(fmt.Sprintln("Releasing the conflict entry ", ipAddr))
+		dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+		uIPEnt, exist := dhcpIntfEnt.usedIpPool[ipAddr]
+		if !exist || uIPEnt.State != CONFLICT {
+			return
+		}
+		delete(dhcpIntfEnt.usedIpPool, ipAddr)
+		server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	}
+
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	uIPEnt := DhcpOfferedData{
+		State: CONFLICT,
+	}
+	uIPEnt.Expiry = time.Now().Add(time.Duration(server.DhcpGlobalConf.DefaultLeaseTime) * time.Second)
+	uIPEnt.StaleTimer = time.AfterFunc(time.Duration(server.DhcpGlobalConf.DefaultLeaseTime)*time.Second, removeConflictFunc)
+	dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+}
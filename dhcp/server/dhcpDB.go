@@ -0,0 +1,166 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package server
+
+import (
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"time"
+	"utils/dbutils"
+)
+
+type dhcpLeaseDbEntry struct {
+	IpAddr    string
+	MacAddr   string
+	ClientId  string
+	LeaseTime uint32
+	Expiry    int64
+	State     uint8
+}
+
+// DhcpDbHdl is the part of the DB handle used for lease persistence
+type DhcpDbHdl interface {
+	Do(cmd string, args ...interface{}) (interface{}, error)
+}
+
+func (server *DHCPServer) initiateDB() error {
+	dbHdl := dbutils.NewDBUtil(server.logger)
+	err := dbHdl.Connect()
+	if err != nil {
+		server.logger.Err("Failed to create the DB handle")
+		return err
+	}
+	server.dbHdl = dbHdl
+	return nil
+}
+
+func getLeaseDbKey(ipAddr uint32) string {
+	return "DhcpLeaseEntry#" + convertUint32ToIPv4(ipAddr)
+}
+
+func (server *DHCPServer) storeLeaseInDB(ipAddr uint32, uIPEnt DhcpOfferedData) {
+	if server.dbHdl == nil {
+		server.logger.Err("DB handler is nil")
+		return
+	}
+	key := getLeaseDbKey(ipAddr)
+	obj := dhcpLeaseDbEntry{
+		IpAddr:    convertUint32ToIPv4(ipAddr),
+		MacAddr:   uIPEnt.MacAddr,
+		ClientId:  uIPEnt.ClientId,
+		LeaseTime: uIPEnt.LeaseTime,
+		Expiry:    uIPEnt.Expiry.Unix(),
+		State:     uIPEnt.State,
+	}
+	_, err := server.dbHdl.Do("HMSET", redis.Args{}.Add(key).AddFlat(&obj)...)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("Failed to add lease entry to db:", obj, err))
+	}
+}
+
+func (server *DHCPServer) deleteLeaseInDB(ipAddr uint32) {
+	if server.dbHdl == nil {
+		server.logger.Err("DB handler is nil")
+		return
+	}
+	key := getLeaseDbKey(ipAddr)
+	_, err := server.dbHdl.Do("DEL", key)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("Failed to delete lease entry from db for:", convertUint32ToIPv4(ipAddr), err))
+	}
+}
+
+// restoreLeasesFromDB repopulates the lease pool of the given interface with
+// the unexpired leases stored before the last restart. Expired leases and
+// leases which no longer fit the pool configuration are removed from the DB.
+func (server *DHCPServer) restoreLeasesFromDB(dhcpIntfKey DhcpIntfKey) {
+	if server.dbHdl == nil {
+		server.logger.Err("DB handler is nil")
+		return
+	}
+	keys, err := redis.Strings(redis.Values(server.dbHdl.Do("KEYS", "DhcpLeaseEntry#*")))
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("Failed to get all lease keys from DB", err))
+		return
+	}
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	curTime := time.Now()
+	for idx := 0; idx < len(keys); idx++ {
+		var obj dhcpLeaseDbEntry
+		val, err := redis.Values(server.dbHdl.Do("HGETALL", keys[idx]))
+		if err != nil {
+			server.logger.Err(fmt.Sprintln("Failed to get lease entry for key:", keys[idx]))
+			continue
+		}
+		err = redis.ScanStruct(val, &obj)
+		if err != nil {
+			server.logger.Err(fmt.Sprintln("Failed to get values corresponding to lease entry key:", keys[idx]))
+			continue
+		}
+		ipAddr, ret := convertIPStrToUint32(obj.IpAddr)
+		if !ret || ipAddr&dhcpIntfKey.subnetMask != dhcpIntfKey.subnet {
+			continue
+		}
+		expiry := time.Unix(obj.Expiry, 0)
+		if !expiry.After(curTime) {
+			server.logger.Debug(fmt.Sprintln("Lease expired while server was down:", obj))
+			server.deleteLeaseInDB(ipAddr)
+			continue
+		}
+		if _, exist := dhcpIntfEnt.usedIpToMac[obj.MacAddr]; exist {
+			continue
+		}
+		if !server.isLeaseValidForPool(dhcpIntfEnt, ipAddr, obj) {
+			server.logger.Debug(fmt.Sprintln("Lease no longer valid for the pool configuration:", obj))
+			server.deleteLeaseInDB(ipAddr)
+			continue
+		}
+		server.logger.Debug(fmt.Sprintln("Restoring lease from DB:", obj))
+		uIPEnt := DhcpOfferedData{
+			LeaseTime: obj.LeaseTime,
+			MacAddr:   obj.MacAddr,
+			ClientId:  obj.ClientId,
+			Expiry:    expiry,
+			State:     BOUND,
+		}
+		uIPEnt.RefreshTimer = time.AfterFunc(expiry.Sub(curTime), server.leaseExpiryFunc(dhcpIntfKey, ipAddr, obj.MacAddr))
+		dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
+		dhcpIntfEnt.usedIpToMac[obj.MacAddr] = ipAddr
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+}
+
+// isLeaseValidForPool checks a persisted lease against the current pool
+// configuration. A client with a static reservation may only hold its
+// reserved address, reserved addresses are only valid for their owner and
+// any other lease has to be within the dynamic range of the pool.
+func (server *DHCPServer) isLeaseValidForPool(dhcpIntfEnt DhcpIntfData, ipAddr uint32, obj dhcpLeaseDbEntry) bool {
+	if ip, exist := server.findReservedIP(dhcpIntfEnt, obj.MacAddr, obj.ClientId); exist {
+		return ip == ipAddr
+	}
+	if dhcpIntfEnt.reservedIps[ipAddr] {
+		return false
+	}
+	return ipAddr >= dhcpIntfEnt.lowerIPBound && ipAddr <= dhcpIntfEnt.higherIPBound
+}
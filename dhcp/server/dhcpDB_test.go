@@ -0,0 +1,259 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDbHdl is an in memory stand in for the redis commands used to persist
+// leases
+type testDbHdl struct {
+	entries map[string]map[string][]byte
+}
+
+func newTestDbHdl() *testDbHdl {
+	return &testDbHdl{
+		entries: make(map[string]map[string][]byte),
+	}
+}
+
+func (db *testDbHdl) Do(cmd string, args ...interface{}) (interface{}, error) {
+	switch cmd {
+	case "HMSET":
+		key := fmt.Sprint(args[0])
+		ent := make(map[string][]byte)
+		for idx := 1; idx+1 < len(args); idx += 2 {
+			ent[fmt.Sprint(args[idx])] = []byte(fmt.Sprint(args[idx+1]))
+		}
+		db.entries[key] = ent
+		return "OK", nil
+	case "DEL":
+		delete(db.entries, fmt.Sprint(args[0]))
+		return int64(1), nil
+	case "KEYS":
+		prefix := strings.TrimSuffix(fmt.Sprint(args[0]), "*")
+		var keys []interface{}
+		for key := range db.entries {
+			if strings.HasPrefix(key, prefix) {
+				keys = append(keys, []byte(key))
+			}
+		}
+		return keys, nil
+	case "HGETALL":
+		var vals []interface{}
+		for field, val := range db.entries[fmt.Sprint(args[0])] {
+			vals = append(vals, []byte(field), val)
+		}
+		return vals, nil
+	}
+	return nil, errors.New("Unsupported command " + cmd)
+}
+
+func getTestLeaseServer(t *testing.T) (*DHCPServer, DhcpIntfKey) {
+	server := getTestServer(t)
+	server.DhcpGlobalConf.DefaultLeaseTime = 3600
+	dhcpIntfKey := DhcpIntfKey{
+		subnet:     0x0a010100,
+		subnetMask: 0xffffff00,
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = DhcpIntfData{
+		enable:        true,
+		l3IfIdx:       1,
+		lowerIPBound:  0x0a010164,
+		higherIPBound: 0x0a0101c8,
+		usedIpPool:    make(map[uint32]DhcpOfferedData),
+		usedIpToMac:   make(map[string]uint32),
+	}
+	server.portPropertyMap[1] = PortProperty{
+		IfName:    "fpPort1",
+		L3IfIndex: 1,
+	}
+	server.l3IntfPropMap[1] = L3Property{
+		IpAddr:    0x0a010101,
+		Mask:      0xffffff00,
+		DhcpIfKey: dhcpIntfKey,
+	}
+	return server, dhcpIntfKey
+}
+
+func stopLeaseTimers(server *DHCPServer) {
+	for _, dhcpIntfEnt := range server.DhcpIntfConfMap {
+		for _, uIPEnt := range dhcpIntfEnt.usedIpPool {
+			if uIPEnt.RefreshTimer != nil {
+				uIPEnt.RefreshTimer.Stop()
+			}
+			if uIPEnt.StaleTimer != nil {
+				uIPEnt.StaleTimer.Stop()
+			}
+		}
+	}
+}
+
+func TestLeasePersistRestore(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	db := newTestDbHdl()
+	server.dbHdl = db
+
+	now := time.Now()
+	leases := map[uint32]DhcpOfferedData{
+		// Unexpired lease
+		0x0a010164: DhcpOfferedData{
+			LeaseTime: 3600,
+			MacAddr:   "00:11:22:33:44:01",
+			ClientId:  "01:00:11:22:33:44:01",
+			Expiry:    now.Add(time.Duration(1800) * time.Second),
+			State:     BOUND,
+		},
+		// Expired while the server was down
+		0x0a010165: DhcpOfferedData{
+			LeaseTime: 3600,
+			MacAddr:   "00:11:22:33:44:02",
+			Expiry:    now.Add(time.Duration(-60) * time.Second),
+			State:     BOUND,
+		},
+		// Lease of another pool
+		0x0a020164: DhcpOfferedData{
+			LeaseTime: 3600,
+			MacAddr:   "00:11:22:33:44:03",
+			Expiry:    now.Add(time.Duration(1800) * time.Second),
+			State:     BOUND,
+		},
+	}
+	for ip, uIPEnt := range leases {
+		server.storeLeaseInDB(ip, uIPEnt)
+	}
+	if len(db.entries) != len(leases) {
+		t.Fatal("Expected", len(leases), "entries in DB, got", len(db.entries))
+	}
+
+	server.restoreLeasesFromDB(dhcpIntfKey)
+	defer stopLeaseTimers(server)
+
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	if len(dhcpIntfEnt.usedIpPool) != 1 {
+		t.Fatal("Expected only the unexpired lease to be restored, got", dhcpIntfEnt.usedIpPool)
+	}
+	uIPEnt, exist := dhcpIntfEnt.usedIpPool[0x0a010164]
+	if !exist {
+		t.Fatal("Unexpired lease was not restored")
+	}
+	if uIPEnt.State != BOUND || uIPEnt.MacAddr != "00:11:22:33:44:01" ||
+		uIPEnt.ClientId != "01:00:11:22:33:44:01" || uIPEnt.LeaseTime != 3600 {
+		t.Error("Restored lease does not match the stored one:", uIPEnt)
+	}
+	if uIPEnt.Expiry.Unix() != leases[0x0a010164].Expiry.Unix() {
+		t.Error("Restored expiry", uIPEnt.Expiry, "expected", leases[0x0a010164].Expiry)
+	}
+	if uIPEnt.RefreshTimer == nil {
+		t.Error("No expiry timer started for the restored lease")
+	}
+	if ip, exist := dhcpIntfEnt.usedIpToMac["00:11:22:33:44:01"]; !exist || ip != 0x0a010164 {
+		t.Error("Restored lease is not indexed by MAC")
+	}
+
+	if _, exist := db.entries[getLeaseDbKey(0x0a010165)]; exist {
+		t.Error("Expired lease was not removed from DB")
+	}
+	if _, exist := db.entries[getLeaseDbKey(0x0a010164)]; !exist {
+		t.Error("Unexpired lease was removed from DB")
+	}
+	if _, exist := db.entries[getLeaseDbKey(0x0a020164)]; !exist {
+		t.Error("Lease of another pool was removed from DB")
+	}
+
+	server.deleteLeaseInDB(0x0a010164)
+	if _, exist := db.entries[getLeaseDbKey(0x0a010164)]; exist {
+		t.Error("Released lease was not removed from DB")
+	}
+}
+
+func TestLeaseRestoreSkipsKnownClient(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	server.dbHdl = newTestDbHdl()
+
+	server.storeLeaseInDB(0x0a010164, DhcpOfferedData{
+		LeaseTime: 3600,
+		MacAddr:   "00:11:22:33:44:01",
+		Expiry:    time.Now().Add(time.Duration(1800) * time.Second),
+		State:     BOUND,
+	})
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.usedIpToMac["00:11:22:33:44:01"] = 0x0a010170
+	dhcpIntfEnt.usedIpPool[0x0a010170] = DhcpOfferedData{
+		MacAddr: "00:11:22:33:44:01",
+		State:   OFFERED,
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+
+	server.restoreLeasesFromDB(dhcpIntfKey)
+	defer stopLeaseTimers(server)
+
+	dhcpIntfEnt = server.DhcpIntfConfMap[dhcpIntfKey]
+	if _, exist := dhcpIntfEnt.usedIpPool[0x0a010164]; exist {
+		t.Error("Lease restored for a client which already has an address")
+	}
+	if dhcpIntfEnt.usedIpToMac["00:11:22:33:44:01"] != 0x0a010170 {
+		t.Error("Current address of the client was overwritten")
+	}
+}
+
+func TestLeaseRestoreValidatesPool(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	db := newTestDbHdl()
+	server.dbHdl = db
+
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	server.buildReservations(&dhcpIntfEnt, []DhcpReservation{
+		DhcpReservation{MacAddr: "00:11:22:33:44:05", IpAddr: 0x0a010110},
+		DhcpReservation{MacAddr: "00:11:22:33:44:06", IpAddr: 0x0a010170},
+		DhcpReservation{ClientId: "01:00:11:22:33:44:07", IpAddr: 0x0a010171},
+	})
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+
+	expiry := time.Now().Add(time.Duration(1800) * time.Second)
+	leases := map[uint32]DhcpOfferedData{
+		// Outside of the dynamic range
+		0x0a010120: DhcpOfferedData{MacAddr: "00:11:22:33:44:01"},
+		// Reserved for another client
+		0x0a010170: DhcpOfferedData{MacAddr: "00:11:22:33:44:02"},
+		// Client has a reservation for a different address
+		0x0a010180: DhcpOfferedData{MacAddr: "00:11:22:33:44:06"},
+		// Reserved address outside of the dynamic range held by its owner
+		0x0a010110: DhcpOfferedData{MacAddr: "00:11:22:33:44:05"},
+		// Reserved by client-id and held by its owner
+		0x0a010171: DhcpOfferedData{MacAddr: "00:11:22:33:44:07", ClientId: "01:00:11:22:33:44:07"},
+		// Dynamic lease within the range
+		0x0a010190: DhcpOfferedData{MacAddr: "00:11:22:33:44:08"},
+	}
+	for ip, uIPEnt := range leases {
+		uIPEnt.LeaseTime = 3600
+		uIPEnt.Expiry = expiry
+		uIPEnt.State = BOUND
+		server.storeLeaseInDB(ip, uIPEnt)
+	}
+
+	server.restoreLeasesFromDB(dhcpIntfKey)
+	defer stopLeaseTimers(server)
+
+	dhcpIntfEnt = server.DhcpIntfConfMap[dhcpIntfKey]
+	for _, ip := range []uint32{0x0a010120, 0x0a010170, 0x0a010180} {
+		if _, exist := dhcpIntfEnt.usedIpPool[ip]; exist {
+			t.Error("Invalid lease restored for", convertUint32ToIPv4(ip))
+		}
+		if _, exist := db.entries[getLeaseDbKey(ip)]; exist {
+			t.Error("Invalid lease for", convertUint32ToIPv4(ip), "was not removed from DB")
+		}
+	}
+	for _, ip := range []uint32{0x0a010110, 0x0a010171, 0x0a010190} {
+		uIPEnt, exist := dhcpIntfEnt.usedIpPool[ip]
+		if !exist || uIPEnt.MacAddr != leases[ip].MacAddr {
+			t.Error("Valid lease was not restored for", convertUint32ToIPv4(ip))
+		}
+	}
+	if len(dhcpIntfEnt.usedIpPool) != 3 {
+		t.Error("Expected 3 restored leases, got", dhcpIntfEnt.usedIpPool)
+	}
+}
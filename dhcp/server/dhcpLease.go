@@ -0,0 +1,184 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DhcpLeaseState struct {
+	IpAddr         string
+	MacAddr        string
+	ClientId       string
+	IntfRef        string
+	State          string
+	Reserved       bool
+	LeaseTime      uint32
+	ExpiryTimeLeft string
+}
+
+func getLeaseStateStr(state uint8) string {
+	switch state {
+	case OFFERED:
+		return "Offered"
+	case BOUND:
+		return "Bound"
+	case CONFLICT:
+		return "Conflict"
+	case PROBING:
+		return "Probing"
+	}
+	return "Unknown"
+}
+
+// getClientId returns the client identifier option (61) as colon separated
+// hex, the same format used to configure client-id based reservations
+func getClientId(bootPMsgData *BootPMsgStruct) string {
+	ent, exist := bootPMsgData.DhcpOptionMap[ClientIdOptCode]
+	if !exist || ent.Length == 0 {
+		return ""
+	}
+	return net.HardwareAddr(ent.Data).String()
+}
+
+func (server *DHCPServer) buildReservations(dhcpIntfEnt *DhcpIntfData, reservations []DhcpReservation) {
+	dhcpIntfEnt.reservations = make(map[string]uint32)
+	dhcpIntfEnt.reservedIps = make(map[uint32]bool)
+	for _, resv := range reservations {
+		if resv.ClientId != "" {
+			dhcpIntfEnt.reservations[strings.ToLower(resv.ClientId)] = resv.IpAddr
+		}
+		if resv.MacAddr != "" {
+			dhcpIntfEnt.reservations[strings.ToLower(resv.MacAddr)] = resv.IpAddr
+		}
+		dhcpIntfEnt.reservedIps[resv.IpAddr] = true
+	}
+}
+
+// findReservedIP looks up a static reservation by client-id first and then
+// by client MAC address
+func (server *DHCPServer) findReservedIP(dhcpIntfEnt DhcpIntfData, clientMac string, clientId string) (uint32, bool) {
+	if clientId != "" {
+		if ip, exist := dhcpIntfEnt.reservations[clientId]; exist {
+			return ip, true
+		}
+	}
+	ip, exist := dhcpIntfEnt.reservations[clientMac]
+	return ip, exist
+}
+
+// allocateIP picks the address to be offered to a new client, honouring
+// static reservations. Conflict detection, when enabled, is done by the
+// caller before the address is offered
+func (server *DHCPServer) allocateIP(dhcpIntfKey DhcpIntfKey, clientMac string, clientId string) (uint32, bool) {
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	ip, exist := server.findReservedIP(dhcpIntfEnt, clientMac, clientId)
+	if exist {
+		if uIPEnt, used := dhcpIntfEnt.usedIpPool[ip]; used {
+			server.logger.Err(fmt.Sprintln("Reserved IP", convertUint32ToIPv4(ip), "for", clientMac, "is held by", uIPEnt.MacAddr))
+			return 0, false
+		}
+		return ip, true
+	}
+	return server.findUnusedIP(dhcpIntfEnt)
+}
+
+func (server *DHCPServer) getLeaseState(dhcpIntfEnt DhcpIntfData, ipAddr uint32, uIPEnt DhcpOfferedData) DhcpLeaseState {
+	leaseState := DhcpLeaseState{
+		IpAddr:    convertUint32ToIPv4(ipAddr),
+		MacAddr:   uIPEnt.MacAddr,
+		ClientId:  uIPEnt.ClientId,
+		IntfRef:   strconv.Itoa(int(dhcpIntfEnt.l3IfIdx)),
+		State:     getLeaseStateStr(uIPEnt.State),
+		Reserved:  dhcpIntfEnt.reservedIps[ipAddr],
+		LeaseTime: uIPEnt.LeaseTime,
+	}
+	if uIPEnt.MacAddr == "" {
+		leaseState.MacAddr = "N/A"
+	}
+	if uIPEnt.Expiry.IsZero() {
+		leaseState.ExpiryTimeLeft = "N/A"
+	} else {
+		leaseState.ExpiryTimeLeft = uIPEnt.Expiry.Sub(time.Now()).String()
+	}
+	return leaseState
+}
+
+func (server *DHCPServer) GetDhcpLeaseState(ipAddrStr string) (DhcpLeaseState, error) {
+	var leaseState DhcpLeaseState
+	ipAddr, ret := convertIPStrToUint32(ipAddrStr)
+	if !ret {
+		return leaseState, errors.New(fmt.Sprintln("Invalid IP Address:", ipAddrStr))
+	}
+	for _, dhcpIntfEnt := range server.DhcpIntfConfMap {
+		uIPEnt, exist := dhcpIntfEnt.usedIpPool[ipAddr]
+		if exist {
+			return server.getLeaseState(dhcpIntfEnt, ipAddr, uIPEnt), nil
+		}
+	}
+	return leaseState, errors.New(fmt.Sprintln("No lease for IP Address:", ipAddrStr))
+}
+
+type leaseSlice []DhcpLeaseState
+
+func (s leaseSlice) Len() int      { return len(s) }
+func (s leaseSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s leaseSlice) Less(i, j int) bool {
+	ipI, _ := convertIPStrToUint32(s[i].IpAddr)
+	ipJ, _ := convertIPStrToUint32(s[j].IpAddr)
+	return ipI < ipJ
+}
+
+func (server *DHCPServer) GetBulkDhcpLeaseState(idx int, cnt int) (int, int, []DhcpLeaseState) {
+	var nextIdx int
+	var count int
+
+	var leases leaseSlice
+	for _, dhcpIntfEnt := range server.DhcpIntfConfMap {
+		for ipAddr, uIPEnt := range dhcpIntfEnt.usedIpPool {
+			leases = append(leases, server.getLeaseState(dhcpIntfEnt, ipAddr, uIPEnt))
+		}
+	}
+	sort.Sort(leases)
+
+	length := len(leases)
+	if idx >= length {
+		return nextIdx, count, nil
+	}
+	end := idx + cnt
+	if end >= length {
+		end = length
+	} else {
+		nextIdx = end
+	}
+	result := leases[idx:end]
+	count = len(result)
+	return nextIdx, count, result
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestReservationLookup(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	server.buildReservations(&dhcpIntfEnt, []DhcpReservation{
+		DhcpReservation{MacAddr: "00:11:22:33:44:01", IpAddr: 0x0a010110},
+		DhcpReservation{ClientId: "01:AA:BB:CC:DD:EE:FF", IpAddr: 0x0a010111},
+		DhcpReservation{MacAddr: "00:11:22:33:44:03", ClientId: "ff:00:00:00:03", IpAddr: 0x0a010112},
+	})
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+
+	tests := []struct {
+		name     string
+		mac      string
+		clientId string
+		ip       uint32
+		found    bool
+	}{
+		{"by mac", "00:11:22:33:44:01", "", 0x0a010110, true},
+		{"by mac with unknown client-id", "00:11:22:33:44:01", "01:00:11:22:33:44:01", 0x0a010110, true},
+		{"by client-id", "00:11:22:33:44:02", "01:aa:bb:cc:dd:ee:ff", 0x0a010111, true},
+		{"client-id takes precedence", "00:11:22:33:44:01", "01:aa:bb:cc:dd:ee:ff", 0x0a010111, true},
+		{"mac and client-id", "00:11:22:33:44:03", "ff:00:00:00:03", 0x0a010112, true},
+		{"no reservation", "00:11:22:33:44:04", "01:00:11:22:33:44:04", 0, false},
+	}
+	for _, test := range tests {
+		ip, found := server.findReservedIP(dhcpIntfEnt, test.mac, test.clientId)
+		if found != test.found || ip != test.ip {
+			t.Error(test.name, ": expected", test.ip, test.found, "got", ip, found)
+		}
+		if !test.found {
+			continue
+		}
+		ip, ret := server.allocateIP(dhcpIntfKey, test.mac, test.clientId)
+		if !ret || ip != test.ip {
+			t.Error(test.name, ": allocateIP returned", ip, ret, "expected", test.ip)
+		}
+	}
+
+	// A reserved address held by someone else is not handed out
+	dhcpIntfEnt.usedIpPool[0x0a010110] = DhcpOfferedData{
+		MacAddr: "00:11:22:33:44:09",
+		State:   BOUND,
+	}
+	if _, ret := server.allocateIP(dhcpIntfKey, "00:11:22:33:44:01", ""); ret {
+		t.Error("Reserved address in use by another client was allocated")
+	}
+}
+
+func TestFindUnusedIPSkipsReserved(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.lowerIPBound = 0x0a010164
+	dhcpIntfEnt.higherIPBound = 0x0a010166
+	server.buildReservations(&dhcpIntfEnt, []DhcpReservation{
+		DhcpReservation{MacAddr: "00:11:22:33:44:01", IpAddr: 0x0a010164},
+	})
+	dhcpIntfEnt.usedIpPool[0x0a010165] = DhcpOfferedData{State: BOUND}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+
+	for idx := 0; idx < 10; idx++ {
+		ip, ret := server.findUnusedIP(dhcpIntfEnt)
+		if !ret || ip != 0x0a010166 {
+			t.Fatal("Expected the only free address, got", ip, ret)
+		}
+	}
+	dhcpIntfEnt.usedIpPool[0x0a010166] = DhcpOfferedData{State: BOUND}
+	if ip, ret := server.findUnusedIP(dhcpIntfEnt); ret {
+		t.Error("Allocated", ip, "from an exhausted pool")
+	}
+}
+
+func TestGetBulkDhcpLeaseState(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	// Insert out of order, the leases are returned sorted by address
+	for _, ip := range []uint32{0x0a010168, 0x0a010164, 0x0a010166, 0x0a010165, 0x0a010167} {
+		dhcpIntfEnt.usedIpPool[ip] = DhcpOfferedData{
+			LeaseTime: 3600,
+			MacAddr:   "00:11:22:33:44:01",
+			State:     BOUND,
+		}
+	}
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+
+	tests := []struct {
+		idx     int
+		cnt     int
+		nextIdx int
+		ips     []string
+	}{
+		{0, 2, 2, []string{"10.1.1.100", "10.1.1.101"}},
+		{2, 2, 4, []string{"10.1.1.102", "10.1.1.103"}},
+		{4, 2, 0, []string{"10.1.1.104"}},
+		{0, 5, 0, []string{"10.1.1.100", "10.1.1.101", "10.1.1.102", "10.1.1.103", "10.1.1.104"}},
+		{3, 10, 0, []string{"10.1.1.103", "10.1.1.104"}},
+		{5, 2, 0, nil},
+	}
+	for _, test := range tests {
+		nextIdx, count, leases := server.GetBulkDhcpLeaseState(test.idx, test.cnt)
+		if nextIdx != test.nextIdx || count != len(test.ips) || len(leases) != len(test.ips) {
+			t.Error("GetBulk(", test.idx, test.cnt, ") returned nextIdx", nextIdx, "count", count, "leases", leases)
+			continue
+		}
+		for i, lease := range leases {
+			if lease.IpAddr != test.ips[i] || lease.State != "Bound" {
+				t.Error("GetBulk(", test.idx, test.cnt, ") entry", i, "is", lease, "expected", test.ips[i])
+			}
+		}
+	}
+
+	// Walk the table the way the client does
+	var ips []string
+	for idx, more := 0, true; more; {
+		nextIdx, _, leases := server.GetBulkDhcpLeaseState(idx, 2)
+		for _, lease := range leases {
+			ips = append(ips, lease.IpAddr)
+		}
+		idx, more = nextIdx, nextIdx != 0
+	}
+	if len(ips) != 5 {
+		t.Error("Walking the lease table returned", ips)
+	}
+}
+
+func TestConflictCheckDefersOffer(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	defer stopLeaseTimers(server)
+
+	probed := make(chan uint32)
+	result := make(chan bool)
+	server.conflictCheckFunc = func(ifName string, ipAddr uint32) bool {
+		if ifName != "fpPort1" {
+			t.Error("Conflict check sent on", ifName)
+		}
+		probed <- ipAddr
+		return <-result
+	}
+	clientMac := "00:11:22:33:44:01"
+	bootPMsgData := &BootPMsgStruct{
+		ClientHWAddr:  []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x01},
+		TransactionId: 0x1234,
+		DhcpOptionMap: make(map[uint8]DhcpOptionData),
+	}
+
+	ip, _ := server.allocateIP(dhcpIntfKey, clientMac, "")
+	server.startConflictCheck(1, bootPMsgData, nil, ip, DHCP_MAX_CONFLICT_RETRY-2)
+	firstIp := <-probed
+	if firstIp != ip {
+		t.Fatal("Probed", firstIp, "instead of", ip)
+	}
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	uIPEnt := dhcpIntfEnt.usedIpPool[firstIp]
+	if uIPEnt.State != PROBING || uIPEnt.MacAddr != clientMac || dhcpIntfEnt.usedIpToMac[clientMac] != firstIp {
+		t.Fatal("Address is not held while being probed:", uIPEnt)
+	}
+
+	// Address answers the ping, the next address gets probed
+	result <- true
+	secondIp := <-probed
+	if secondIp == firstIp {
+		t.Fatal("Conflicting address probed again")
+	}
+	dhcpIntfEnt = server.DhcpIntfConfMap[dhcpIntfKey]
+	if dhcpIntfEnt.usedIpPool[firstIp].State != CONFLICT {
+		t.Error("Conflicting address not marked:", dhcpIntfEnt.usedIpPool[firstIp])
+	}
+	if dhcpIntfEnt.usedIpPool[secondIp].State != PROBING || dhcpIntfEnt.usedIpToMac[clientMac] != secondIp {
+		t.Error("Next address is not held while being probed:", dhcpIntfEnt.usedIpPool[secondIp])
+	}
+	leaseState, err := server.GetDhcpLeaseState(convertUint32ToIPv4(secondIp))
+	if err != nil || leaseState.State != "Probing" {
+		t.Error("Lease state of probed address:", leaseState, err)
+	}
+}
+
+func TestConflictCheckGiveUp(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	defer stopLeaseTimers(server)
+	server.conflictCheckFunc = func(ifName string, ipAddr uint32) bool {
+		t.Error("Unexpected conflict check of", ipAddr)
+		return false
+	}
+	clientMac := "00:11:22:33:44:01"
+	bootPMsgData := &BootPMsgStruct{
+		ClientHWAddr:  []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x01},
+		DhcpOptionMap: make(map[uint8]DhcpOptionData),
+	}
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.usedIpToMac[clientMac] = 0x0a010164
+	dhcpIntfEnt.usedIpPool[0x0a010164] = DhcpOfferedData{
+		MacAddr: clientMac,
+		State:   PROBING,
+	}
+
+	// Last retry answered as well, no further address is probed
+	server.conflictCheckDone(1, bootPMsgData, nil, 0x0a010164, DHCP_MAX_CONFLICT_RETRY-1, true)
+	dhcpIntfEnt = server.DhcpIntfConfMap[dhcpIntfKey]
+	if dhcpIntfEnt.usedIpPool[0x0a010164].State != CONFLICT {
+		t.Error("Conflicting address not marked:", dhcpIntfEnt.usedIpPool[0x0a010164])
+	}
+	if _, exist := dhcpIntfEnt.usedIpToMac[clientMac]; exist {
+		t.Error("Client still holds an address after giving up")
+	}
+	if len(dhcpIntfEnt.usedIpPool) != 1 {
+		t.Error("Unexpected addresses in pool:", dhcpIntfEnt.usedIpPool)
+	}
+}
+
+func TestConflictCheckDoneStale(t *testing.T) {
+	server, dhcpIntfKey := getTestLeaseServer(t)
+	defer stopLeaseTimers(server)
+	server.conflictCheckFunc = func(ifName string, ipAddr uint32) bool {
+		t.Error("Unexpected conflict check of", ipAddr)
+		return false
+	}
+	bootPMsgData := &BootPMsgStruct{
+		ClientHWAddr:  []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x01},
+		DhcpOptionMap: make(map[uint8]DhcpOptionData),
+	}
+
+	// Client released the address while it was being probed
+	server.conflictCheckDone(1, bootPMsgData, nil, 0x0a010164, 0, true)
+	// Address got bound to another client in the meantime
+	dhcpIntfEnt := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.usedIpPool[0x0a010164] = DhcpOfferedData{
+		MacAddr: "00:11:22:33:44:02",
+		State:   BOUND,
+	}
+	server.conflictCheckDone(1, bootPMsgData, nil, 0x0a010164, 0, true)
+	dhcpIntfEnt = server.DhcpIntfConfMap[dhcpIntfKey]
+	if dhcpIntfEnt.usedIpPool[0x0a010164].State != BOUND {
+		t.Error("Stale conflict check changed the lease:", dhcpIntfEnt.usedIpPool[0x0a010164])
+	}
+}
+
+func TestIcmpEcho(t *testing.T) {
+	req := buildIcmpEchoReq(0x1122, 7)
+	if req[0] != ICMP_ECHO_REQUEST || computeChkSum(req) != 0 {
+		t.Fatal("Invalid echo request:", req)
+	}
+
+	reply := make([]byte, 20+len(req))
+	reply[0] = 0x45
+	copy(reply[12:16], []byte{10, 1, 1, 100})
+	copy(reply[20:], req)
+	reply[20] = ICMP_ECHO_REPLY
+	tests := []struct {
+		name  string
+		ip    uint32
+		seq   uint16
+		pkt   []byte
+		reply bool
+	}{
+		{"reply", 0x0a010164, 7, reply, true},
+		{"other source", 0x0a010165, 7, reply, false},
+		{"other sequence", 0x0a010164, 8, reply, false},
+		{"echo request", 0x0a010164, 7, append(append([]byte{}, reply[:20]...), req...), false},
+		{"truncated", 0x0a010164, 7, reply[:24], false},
+	}
+	for _, test := range tests {
+		if isIcmpEchoReply(test.pkt, test.ip, 0x1122, test.seq) != test.reply {
+			t.Error(test.name, ": expected", test.reply)
+		}
+	}
+}
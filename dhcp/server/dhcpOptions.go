@@ -0,0 +1,581 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package server
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type DhcpOptionType uint8
+
+const (
+	DhcpOptTypeIPv4 DhcpOptionType = iota
+	DhcpOptTypeIPv4List
+	DhcpOptTypeIPv4Pairs
+	DhcpOptTypeUint8
+	DhcpOptTypeUint16
+	DhcpOptTypeUint32
+	DhcpOptTypeInt32
+	DhcpOptTypeBool
+	DhcpOptTypeString
+	DhcpOptTypeBinary
+	DhcpOptTypeDomainList
+	DhcpOptTypeClasslessRoute
+	DhcpOptTypeVendorSubOpt
+)
+
+const (
+	DHCP_MAX_OPTION_LEN      int = 255
+	DHCP_MIN_OPTIONS_LEN     int = 64  // BOOTP vendor area
+	DHCP_DEFAULT_MAX_MSG_LEN int = 576 // RFC 2131 minimum every client accepts
+	IP_UDP_HDR_LEN           int = 28
+)
+
+// Layout of the options common to every reply, built by constructDhcpMsg:
+// magic cookie, message type, server id, lease time and subnet mask
+const (
+	DHCP_MAGIC_COOKIE_LEN    int = 4
+	DHCP_MSG_TYPE_OPT_OFFSET int = DHCP_MAGIC_COOKIE_LEN
+	DHCP_MSG_TYPE_OFFSET     int = DHCP_MSG_TYPE_OPT_OFFSET + 2
+	DHCP_SERVER_ID_OFFSET    int = DHCP_MSG_TYPE_OFFSET + 1
+	DHCP_LEASE_TIME_OFFSET   int = DHCP_SERVER_ID_OFFSET + 6
+	DHCP_SUBNET_MASK_OFFSET  int = DHCP_LEASE_TIME_OFFSET + 6
+	DHCP_COMMON_OPTIONS_LEN  int = DHCP_SUBNET_MASK_OFFSET + 6
+)
+
+type DhcpOptionDef struct {
+	Code uint8
+	Name string
+	Type DhcpOptionType
+	// Options managed by the server itself or only sent by clients
+	// cannot be configured
+	Reserved bool
+}
+
+type DhcpOptionConfig struct {
+	Code  uint8
+	Value string
+}
+
+type DhcpClientClass struct {
+	Name          string
+	VendorClassId string
+	UserClass     string
+	Options       []DhcpOptionConfig
+}
+
+type dhcpClientClassData struct {
+	name          string
+	vendorClassId string
+	userClass     string
+	options       map[uint8][]byte
+}
+
+// DHCPv4 options from RFC 2132 and its extensions, options not listed here
+// can still be configured as binary data
+var dhcpOptionRegistry = map[uint8]DhcpOptionDef{
+	PadOptCode:                  {PadOptCode, "pad", DhcpOptTypeBinary, true},
+	SubnetMaskOptCode:           {SubnetMaskOptCode, "subnet-mask", DhcpOptTypeIPv4, true},
+	2:                           {2, "time-offset", DhcpOptTypeInt32, false},
+	RouterOptCode:               {RouterOptCode, "routers", DhcpOptTypeIPv4List, false},
+	4:                           {4, "time-servers", DhcpOptTypeIPv4List, false},
+	5:                           {5, "ien116-name-servers", DhcpOptTypeIPv4List, false},
+	DNSOptCode:                  {DNSOptCode, "domain-name-servers", DhcpOptTypeIPv4List, false},
+	7:                           {7, "log-servers", DhcpOptTypeIPv4List, false},
+	8:                           {8, "cookie-servers", DhcpOptTypeIPv4List, false},
+	9:                           {9, "lpr-servers", DhcpOptTypeIPv4List, false},
+	10:                          {10, "impress-servers", DhcpOptTypeIPv4List, false},
+	11:                          {11, "resource-location-servers", DhcpOptTypeIPv4List, false},
+	HostNameOptCode:             {HostNameOptCode, "host-name", DhcpOptTypeString, false},
+	13:                          {13, "boot-size", DhcpOptTypeUint16, false},
+	14:                          {14, "merit-dump", DhcpOptTypeString, false},
+	DomainNameOptCode:           {DomainNameOptCode, "domain-name", DhcpOptTypeString, false},
+	16:                          {16, "swap-server", DhcpOptTypeIPv4, false},
+	17:                          {17, "root-path", DhcpOptTypeString, false},
+	18:                          {18, "extensions-path", DhcpOptTypeString, false},
+	19:                          {19, "ip-forwarding", DhcpOptTypeBool, false},
+	20:                          {20, "non-local-source-routing", DhcpOptTypeBool, false},
+	22:                          {22, "max-dgram-reassembly", DhcpOptTypeUint16, false},
+	23:                          {23, "default-ip-ttl", DhcpOptTypeUint8, false},
+	24:                          {24, "path-mtu-aging-timeout", DhcpOptTypeUint32, false},
+	26:                          {26, "interface-mtu", DhcpOptTypeUint16, false},
+	27:                          {27, "all-subnets-local", DhcpOptTypeBool, false},
+	28:                          {28, "broadcast-address", DhcpOptTypeIPv4, false},
+	29:                          {29, "perform-mask-discovery", DhcpOptTypeBool, false},
+	30:                          {30, "mask-supplier", DhcpOptTypeBool, false},
+	31:                          {31, "router-discovery", DhcpOptTypeBool, false},
+	32:                          {32, "router-solicitation-address", DhcpOptTypeIPv4, false},
+	StaticRouteOptCode:          {StaticRouteOptCode, "static-routes", DhcpOptTypeIPv4Pairs, false},
+	34:                          {34, "trailer-encapsulation", DhcpOptTypeBool, false},
+	35:                          {35, "arp-cache-timeout", DhcpOptTypeUint32, false},
+	36:                          {36, "ieee802-3-encapsulation", DhcpOptTypeBool, false},
+	37:                          {37, "default-tcp-ttl", DhcpOptTypeUint8, false},
+	38:                          {38, "tcp-keepalive-interval", DhcpOptTypeUint32, false},
+	39:                          {39, "tcp-keepalive-garbage", DhcpOptTypeBool, false},
+	40:                          {40, "nis-domain", DhcpOptTypeString, false},
+	41:                          {41, "nis-servers", DhcpOptTypeIPv4List, false},
+	NTPServerOptCode:            {NTPServerOptCode, "ntp-servers", DhcpOptTypeIPv4List, false},
+	VendorSpecificInfoOptCode:   {VendorSpecificInfoOptCode, "vendor-encapsulated-options", DhcpOptTypeVendorSubOpt, false},
+	NetBIOSServerOptCode:        {NetBIOSServerOptCode, "netbios-name-servers", DhcpOptTypeIPv4List, false},
+	45:                          {45, "netbios-dd-server", DhcpOptTypeIPv4List, false},
+	46:                          {46, "netbios-node-type", DhcpOptTypeUint8, false},
+	47:                          {47, "netbios-scope", DhcpOptTypeString, false},
+	48:                          {48, "font-servers", DhcpOptTypeIPv4List, false},
+	49:                          {49, "x-display-manager", DhcpOptTypeIPv4List, false},
+	ReqIPAddrOptCode:            {ReqIPAddrOptCode, "requested-address", DhcpOptTypeIPv4, true},
+	IPAddrLeaseTimeOptCode:      {IPAddrLeaseTimeOptCode, "lease-time", DhcpOptTypeUint32, true},
+	OptionOverloadOptCode:       {OptionOverloadOptCode, "option-overload", DhcpOptTypeUint8, true},
+	DhcpMsgTypeOptCode:          {DhcpMsgTypeOptCode, "message-type", DhcpOptTypeUint8, true},
+	ServerIdOptCode:             {ServerIdOptCode, "server-identifier", DhcpOptTypeIPv4, true},
+	ParamReqListOptCode:         {ParamReqListOptCode, "parameter-request-list", DhcpOptTypeBinary, true},
+	56:                          {56, "message", DhcpOptTypeString, true},
+	MaxDhcpMsgSizeOptCode:       {MaxDhcpMsgSizeOptCode, "max-message-size", DhcpOptTypeUint16, true},
+	RenewalTimeOptCode:          {RenewalTimeOptCode, "renewal-time", DhcpOptTypeUint32, false},
+	RebindingTimeOptCode:        {RebindingTimeOptCode, "rebinding-time", DhcpOptTypeUint32, false},
+	VendorClassIdOptCode:        {VendorClassIdOptCode, "vendor-class-identifier", DhcpOptTypeString, true},
+	ClientIdOptCode:             {ClientIdOptCode, "client-identifier", DhcpOptTypeBinary, true},
+	64:                          {64, "nisplus-domain", DhcpOptTypeString, false},
+	65:                          {65, "nisplus-servers", DhcpOptTypeIPv4List, false},
+	TFTPServerNameOptCode:       {TFTPServerNameOptCode, "tftp-server-name", DhcpOptTypeString, false},
+	BootFileNameOptCode:         {BootFileNameOptCode, "bootfile-name", DhcpOptTypeString, false},
+	68:                          {68, "mobile-ip-home-agent", DhcpOptTypeIPv4List, false},
+	69:                          {69, "smtp-servers", DhcpOptTypeIPv4List, false},
+	70:                          {70, "pop-servers", DhcpOptTypeIPv4List, false},
+	71:                          {71, "nntp-servers", DhcpOptTypeIPv4List, false},
+	72:                          {72, "www-servers", DhcpOptTypeIPv4List, false},
+	73:                          {73, "finger-servers", DhcpOptTypeIPv4List, false},
+	74:                          {74, "irc-servers", DhcpOptTypeIPv4List, false},
+	75:                          {75, "streettalk-servers", DhcpOptTypeIPv4List, false},
+	76:                          {76, "streettalk-directory-assistance-servers", DhcpOptTypeIPv4List, false},
+	UserClassOptCode:            {UserClassOptCode, "user-class", DhcpOptTypeBinary, true},
+	DomainSearchOptCode:         {DomainSearchOptCode, "domain-search", DhcpOptTypeDomainList, false},
+	ClasslessStaticRouteOptCode: {ClasslessStaticRouteOptCode, "classless-static-routes", DhcpOptTypeClasslessRoute, false},
+	TFTPServerAddrOptCode:       {TFTPServerAddrOptCode, "tftp-server-address", DhcpOptTypeIPv4List, false},
+	EndOptCode:                  {EndOptCode, "end", DhcpOptTypeBinary, true},
+}
+
+func buildDhcpCommonOptions(serverId uint32, leaseTime uint32, subnetMask uint32) []byte {
+	dhcpMsg := make([]byte, DHCP_COMMON_OPTIONS_LEN)
+	binary.BigEndian.PutUint32(dhcpMsg[0:DHCP_MAGIC_COOKIE_LEN], 0x63825363)
+	dhcpMsg[DHCP_MSG_TYPE_OPT_OFFSET] = DhcpMsgTypeOptCode
+	dhcpMsg[DHCP_MSG_TYPE_OPT_OFFSET+1] = uint8(1)
+	dhcpMsg[DHCP_MSG_TYPE_OFFSET] = uint8(0)
+	dhcpMsg[DHCP_SERVER_ID_OFFSET] = ServerIdOptCode
+	dhcpMsg[DHCP_SERVER_ID_OFFSET+1] = uint8(4)
+	binary.BigEndian.PutUint32(dhcpMsg[DHCP_SERVER_ID_OFFSET+2:DHCP_LEASE_TIME_OFFSET], serverId)
+	dhcpMsg[DHCP_LEASE_TIME_OFFSET] = IPAddrLeaseTimeOptCode
+	dhcpMsg[DHCP_LEASE_TIME_OFFSET+1] = uint8(4)
+	binary.BigEndian.PutUint32(dhcpMsg[DHCP_LEASE_TIME_OFFSET+2:DHCP_SUBNET_MASK_OFFSET], leaseTime)
+	dhcpMsg[DHCP_SUBNET_MASK_OFFSET] = SubnetMaskOptCode
+	dhcpMsg[DHCP_SUBNET_MASK_OFFSET+1] = uint8(4)
+	binary.BigEndian.PutUint32(dhcpMsg[DHCP_SUBNET_MASK_OFFSET+2:DHCP_COMMON_OPTIONS_LEN], subnetMask)
+	return dhcpMsg
+}
+
+func getDhcpOptionDef(code uint8) DhcpOptionDef {
+	def, exist := dhcpOptionRegistry[code]
+	if !exist {
+		def = DhcpOptionDef{
+			Code: code,
+			Name: "option-" + strconv.Itoa(int(code)),
+			Type: DhcpOptTypeBinary,
+		}
+	}
+	return def
+}
+
+func splitOptionValue(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func encodeIPv4(ipStr string) ([]byte, error) {
+	ip := net.ParseIP(ipStr)
+	if ip == nil || ip.To4() == nil {
+		return nil, errors.New(fmt.Sprintln("Invalid IPv4 Address:", ipStr))
+	}
+	return []byte(ip.To4()), nil
+}
+
+// encodeBinary accepts "0x" prefixed or colon separated hex
+func encodeBinary(value string) ([]byte, error) {
+	hexStr := strings.Replace(strings.TrimPrefix(value, "0x"), ":", "", -1)
+	data, err := hex.DecodeString(hexStr)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New(fmt.Sprintln("Invalid hex value:", value))
+	}
+	return data, nil
+}
+
+// encodeDomainList encodes domain names in DNS wire format (RFC 3397),
+// without compression
+func encodeDomainList(value string) ([]byte, error) {
+	var data []byte
+	for _, domain := range splitOptionValue(value) {
+		for _, label := range strings.Split(strings.TrimSuffix(domain, "."), ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, errors.New(fmt.Sprintln("Invalid domain name:", domain))
+			}
+			data = append(data, uint8(len(label)))
+			data = append(data, label...)
+		}
+		data = append(data, 0)
+	}
+	return data, nil
+}
+
+// encodeClasslessRoutes encodes "<prefix>/<len> <gateway>" entries as per
+// RFC 3442, only the significant octets of the destination are sent
+func encodeClasslessRoutes(value string) ([]byte, error) {
+	var data []byte
+	for _, route := range splitOptionValue(value) {
+		fields := strings.Fields(route)
+		if len(fields) != 2 {
+			return nil, errors.New(fmt.Sprintln("Invalid classless route, expected '<prefix>/<len> <gateway>':", route))
+		}
+		_, ipNet, err := net.ParseCIDR(fields[0])
+		if err != nil || ipNet.IP.To4() == nil {
+			return nil, errors.New(fmt.Sprintln("Invalid classless route prefix:", fields[0]))
+		}
+		gw, err := encodeIPv4(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		data = append(data, uint8(prefixLen))
+		data = append(data, ipNet.IP.To4()[:(prefixLen+7)/8]...)
+		data = append(data, gw...)
+	}
+	return data, nil
+}
+
+// encodeVendorSubOpts encodes "<code>=<value>" sub-options of option 43,
+// values starting with "0x" are hex and everything else is sent as text
+func encodeVendorSubOpts(value string) ([]byte, error) {
+	var data []byte
+	for _, subOpt := range splitOptionValue(value) {
+		kv := strings.SplitN(subOpt, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New(fmt.Sprintln("Invalid vendor sub-option, expected '<code>=<value>':", subOpt))
+		}
+		code, err := strconv.ParseUint(strings.TrimSpace(kv[0]), 10, 8)
+		if err != nil || code == uint64(PadOptCode) || code == uint64(EndOptCode) {
+			return nil, errors.New(fmt.Sprintln("Invalid vendor sub-option code:", kv[0]))
+		}
+		subData := []byte(kv[1])
+		if strings.HasPrefix(kv[1], "0x") {
+			subData, err = encodeBinary(kv[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(subData) > DHCP_MAX_OPTION_LEN {
+			return nil, errors.New(fmt.Sprintln("Vendor sub-option too long:", code))
+		}
+		data = append(data, uint8(code), uint8(len(subData)))
+		data = append(data, subData...)
+	}
+	return data, nil
+}
+
+func encodeDhcpOption(code uint8, value string) ([]byte, error) {
+	var data []byte
+	var err error
+
+	def := getDhcpOptionDef(code)
+	if def.Reserved {
+		return nil, errors.New(fmt.Sprintln("Dhcp option", code, def.Name, "cannot be configured"))
+	}
+	switch def.Type {
+	case DhcpOptTypeIPv4:
+		data, err = encodeIPv4(strings.TrimSpace(value))
+	case DhcpOptTypeIPv4List, DhcpOptTypeIPv4Pairs:
+		list := splitOptionValue(value)
+		if def.Type == DhcpOptTypeIPv4Pairs && len(list)%2 != 0 {
+			err = errors.New("Expected destination and router pairs")
+			break
+		}
+		for _, ipStr := range list {
+			ip, ipErr := encodeIPv4(ipStr)
+			if ipErr != nil {
+				err = ipErr
+				break
+			}
+			data = append(data, ip...)
+		}
+	case DhcpOptTypeUint8, DhcpOptTypeUint16, DhcpOptTypeUint32:
+		var bitSize int
+		switch def.Type {
+		case DhcpOptTypeUint8:
+			bitSize = 8
+		case DhcpOptTypeUint16:
+			bitSize = 16
+		default:
+			bitSize = 32
+		}
+		val, parseErr := strconv.ParseUint(strings.TrimSpace(value), 10, bitSize)
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+		data = make([]byte, bitSize/8)
+		switch bitSize {
+		case 8:
+			data[0] = uint8(val)
+		case 16:
+			binary.BigEndian.PutUint16(data, uint16(val))
+		default:
+			binary.BigEndian.PutUint32(data, uint32(val))
+		}
+	case DhcpOptTypeInt32:
+		val, parseErr := strconv.ParseInt(strings.TrimSpace(value), 10, 32)
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+		data = make([]byte, 4)
+		binary.BigEndian.PutUint32(data, uint32(int32(val)))
+	case DhcpOptTypeBool:
+		val, parseErr := strconv.ParseBool(strings.TrimSpace(value))
+		if parseErr != nil {
+			err = parseErr
+			break
+		}
+		data = []byte{0}
+		if val {
+			data[0] = 1
+		}
+	case DhcpOptTypeString:
+		data = []byte(value)
+	case DhcpOptTypeBinary:
+		data, err = encodeBinary(strings.TrimSpace(value))
+	case DhcpOptTypeDomainList:
+		data, err = encodeDomainList(value)
+	case DhcpOptTypeClasslessRoute:
+		data, err = encodeClasslessRoutes(value)
+	case DhcpOptTypeVendorSubOpt:
+		data, err = encodeVendorSubOpts(value)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintln("Invalid value for dhcp option", code, def.Name, ":", err))
+	}
+	if len(data) == 0 || len(data) > DHCP_MAX_OPTION_LEN {
+		return nil, errors.New(fmt.Sprintln("Invalid length", len(data), "for dhcp option", code, def.Name))
+	}
+	return data, nil
+}
+
+func buildDhcpOptionSet(optList []DhcpOptionConfig) (map[uint8][]byte, error) {
+	options := make(map[uint8][]byte)
+	for _, opt := range optList {
+		if _, exist := options[opt.Code]; exist {
+			return nil, errors.New(fmt.Sprintln("Dhcp option", opt.Code, "configured more than once"))
+		}
+		data, err := encodeDhcpOption(opt.Code, opt.Value)
+		if err != nil {
+			return nil, err
+		}
+		options[opt.Code] = data
+	}
+	return options, nil
+}
+
+// buildDhcpPoolOptions compiles the pool option set, the legacy router, dns
+// and domain name attributes are used unless overridden by an explicit option
+func buildDhcpPoolOptions(conf DhcpIntfConfig) (map[uint8][]byte, []dhcpClientClassData, error) {
+	options, err := buildDhcpOptionSet(conf.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, exist := options[RouterOptCode]; !exist && conf.RtrAddr != 0 {
+		options[RouterOptCode] = make([]byte, 4)
+		binary.BigEndian.PutUint32(options[RouterOptCode], conf.RtrAddr)
+	}
+	if _, exist := options[DNSOptCode]; !exist && conf.DnsAddr != 0 {
+		options[DNSOptCode] = make([]byte, 4)
+		binary.BigEndian.PutUint32(options[DNSOptCode], conf.DnsAddr)
+	}
+	if _, exist := options[DomainNameOptCode]; !exist && conf.DomainName != "" {
+		options[DomainNameOptCode] = []byte(conf.DomainName)
+	}
+
+	var clientClasses []dhcpClientClassData
+	for _, class := range conf.ClientClasses {
+		if class.VendorClassId == "" && class.UserClass == "" {
+			err := errors.New(fmt.Sprintln("Client class", class.Name, "needs a vendor class or user class to match"))
+			return nil, nil, err
+		}
+		classOptions, err := buildDhcpOptionSet(class.Options)
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintln("Client class", class.Name, ":", err))
+		}
+		clientClasses = append(clientClasses, dhcpClientClassData{
+			name:          class.Name,
+			vendorClassId: class.VendorClassId,
+			userClass:     class.UserClass,
+			options:       classOptions,
+		})
+	}
+	return options, clientClasses, nil
+}
+
+// matchClassPattern does an exact match, or a prefix match when the pattern
+// ends with '*'
+func matchClassPattern(pattern string, value string) bool {
+	if strings.HasSuffix(pattern, "*") {
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == value
+}
+
+// getUserClasses returns the user classes carried in option 77, which is a
+// list of length prefixed entries as per RFC 3004. Some clients send a plain
+// string instead, which is returned as is.
+func getUserClasses(data []byte) []string {
+	var classes []string
+	for idx := 0; idx < len(data); {
+		length := int(data[idx])
+		if length == 0 || idx+1+length > len(data) {
+			return []string{string(data)}
+		}
+		classes = append(classes, string(data[idx+1:idx+1+length]))
+		idx += 1 + length
+	}
+	return classes
+}
+
+func (class dhcpClientClassData) match(bootPMsgData *BootPMsgStruct) bool {
+	if class.vendorClassId != "" {
+		ent, exist := bootPMsgData.DhcpOptionMap[VendorClassIdOptCode]
+		if !exist || !matchClassPattern(class.vendorClassId, string(ent.Data)) {
+			return false
+		}
+	}
+	if class.userClass != "" {
+		ent, exist := bootPMsgData.DhcpOptionMap[UserClassOptCode]
+		if !exist {
+			return false
+		}
+		matched := false
+		for _, userClass := range getUserClasses(ent.Data) {
+			if matchClassPattern(class.userClass, userClass) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// getClientOptions merges the pool options with the options of the first
+// client class matching the request
+func (server *DHCPServer) getClientOptions(dhcpIntfEnt DhcpIntfData, bootPMsgData *BootPMsgStruct) map[uint8][]byte {
+	for _, class := range dhcpIntfEnt.clientClasses {
+		if !class.match(bootPMsgData) {
+			continue
+		}
+		server.logger.Debug(fmt.Sprintln("Client matched class:", class.name))
+		options := make(map[uint8][]byte)
+		for code, data := range dhcpIntfEnt.options {
+			options[code] = data
+		}
+		for code, data := range class.options {
+			options[code] = data
+		}
+		return options
+	}
+	return dhcpIntfEnt.options
+}
+
+// buildDhcpOptions builds the options field of an offer or ack. Options
+// requested by the client come first in the order of its parameter request
+// list, and options that do not fit in the client's maximum message size
+// are left out.
+func (server *DHCPServer) buildDhcpOptions(dhcpIntfEnt DhcpIntfData, bootPMsgData *BootPMsgStruct, msgType uint8) []byte {
+	maxLen := DHCP_DEFAULT_MAX_MSG_LEN - IP_UDP_HDR_LEN - int(BOOTP_MSG_SIZE)
+	maxMsgSize, exist := bootPMsgData.DhcpOptionMap[MaxDhcpMsgSizeOptCode]
+	if exist && maxMsgSize.Length == 2 {
+		clientMaxLen := int(binary.BigEndian.Uint16(maxMsgSize.Data)) - IP_UDP_HDR_LEN - int(BOOTP_MSG_SIZE)
+		if clientMaxLen > maxLen {
+			maxLen = clientMaxLen
+		}
+	}
+
+	dhcpOpts := make([]byte, len(dhcpIntfEnt.dhcpMsg))
+	copy(dhcpOpts, dhcpIntfEnt.dhcpMsg)
+	dhcpOpts[DHCP_MSG_TYPE_OFFSET] = msgType
+
+	options := server.getClientOptions(dhcpIntfEnt, bootPMsgData)
+	var codeList []int
+	added := make(map[uint8]bool)
+	paramReqList, exist := bootPMsgData.DhcpOptionMap[ParamReqListOptCode]
+	if exist {
+		for _, code := range paramReqList.Data {
+			if _, ok := options[code]; ok && !added[code] {
+				codeList = append(codeList, int(code))
+				added[code] = true
+			}
+		}
+	}
+	var restList []int
+	for code, _ := range options {
+		if !added[code] {
+			restList = append(restList, int(code))
+		}
+	}
+	sort.Ints(restList)
+	codeList = append(codeList, restList...)
+
+	for _, code := range codeList {
+		data := options[uint8(code)]
+		// Leave room for End option
+		if len(dhcpOpts)+2+len(data)+1 > maxLen {
+			server.logger.Info(fmt.Sprintln("No space left for dhcp option", code, "in message of max length", maxLen))
+			continue
+		}
+		dhcpOpts = append(dhcpOpts, uint8(code), uint8(len(data)))
+		dhcpOpts = append(dhcpOpts, data...)
+	}
+	dhcpOpts = append(dhcpOpts, EndOptCode)
+	for len(dhcpOpts) < DHCP_MIN_OPTIONS_LEN {
+		dhcpOpts = append(dhcpOpts, PadOptCode)
+	}
+	return dhcpOpts
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestEncodeClasslessRoutes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []byte
+		fail  bool
+	}{
+		{"10.0.0.0/8 10.1.1.254", []byte{8, 10, 10, 1, 1, 254}, false},
+		{"0.0.0.0/0 10.1.1.1", []byte{0, 10, 1, 1, 1}, false},
+		{"192.168.5.0/24 10.1.1.2", []byte{24, 192, 168, 5, 10, 1, 1, 2}, false},
+		{"172.16.0.0/12 10.1.1.3", []byte{12, 172, 16, 10, 1, 1, 3}, false},
+		{"10.1.1.1/32 10.1.1.4", []byte{32, 10, 1, 1, 1, 10, 1, 1, 4}, false},
+		{"10.0.0.0/8 10.1.1.254, 0.0.0.0/0 10.1.1.1", []byte{8, 10, 10, 1, 1, 254, 0, 10, 1, 1, 1}, false},
+		{"10.0.0.0/8", nil, true},
+		{"10.0.0.0 10.1.1.1", nil, true},
+		{"2001::/64 10.1.1.1", nil, true},
+		{"10.0.0.0/8 2001::1", nil, true},
+	}
+	for _, test := range tests {
+		data, err := encodeClasslessRoutes(test.value)
+		if test.fail {
+			if err == nil {
+				t.Error("Expected failure for classless route:", test.value)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, test.want) {
+			t.Error("Classless route:", test.value, "want:", test.want, "got:", data, err)
+		}
+	}
+}
+
+func TestEncodeDomainList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []byte
+		fail  bool
+	}{
+		{"example.com", []byte{7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}, false},
+		{"example.com.", []byte{7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}, false},
+		{"a.b, c", []byte{1, 'a', 1, 'b', 0, 1, 'c', 0}, false},
+		{"a..b", nil, true},
+		{"x" + string(make([]byte, 64)), nil, true},
+	}
+	for _, test := range tests {
+		data, err := encodeDomainList(test.value)
+		if test.fail {
+			if err == nil {
+				t.Error("Expected failure for domain list:", test.value)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, test.want) {
+			t.Error("Domain list:", test.value, "want:", test.want, "got:", data, err)
+		}
+	}
+}
+
+func TestEncodeVendorSubOpts(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []byte
+		fail  bool
+	}{
+		{"1=0x0a0b", []byte{1, 2, 0x0a, 0x0b}, false},
+		{"2=hello", []byte{2, 5, 'h', 'e', 'l', 'l', 'o'}, false},
+		{"1=0x0a0b, 2=hi", []byte{1, 2, 0x0a, 0x0b, 2, 2, 'h', 'i'}, false},
+		{"3=a=b", []byte{3, 3, 'a', '=', 'b'}, false},
+		{"1", nil, true},
+		{"0=abc", nil, true},
+		{"255=abc", nil, true},
+		{"256=abc", nil, true},
+		{"1=0xzz", nil, true},
+	}
+	for _, test := range tests {
+		data, err := encodeVendorSubOpts(test.value)
+		if test.fail {
+			if err == nil {
+				t.Error("Expected failure for vendor sub-options:", test.value)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, test.want) {
+			t.Error("Vendor sub-options:", test.value, "want:", test.want, "got:", data, err)
+		}
+	}
+}
+
+func TestEncodeDhcpOption(t *testing.T) {
+	tests := []struct {
+		code  uint8
+		value string
+		want  []byte
+		fail  bool
+	}{
+		{DNSOptCode, "8.8.8.8, 1.1.1.1", []byte{8, 8, 8, 8, 1, 1, 1, 1}, false},
+		{NTPServerOptCode, "10.1.1.1", []byte{10, 1, 1, 1}, false},
+		{2, "-3600", []byte{0xff, 0xff, 0xf1, 0xf0}, false},
+		{19, "true", []byte{1}, false},
+		{26, "1500", []byte{0x05, 0xdc}, false},
+		{TFTPServerNameOptCode, "tftp.example.com", []byte("tftp.example.com"), false},
+		{BootFileNameOptCode, "ztp.py", []byte("ztp.py"), false},
+		{200, "0xdead", []byte{0xde, 0xad}, false},
+		{200, "de:ad", []byte{0xde, 0xad}, false},
+		{StaticRouteOptCode, "1.1.1.1", nil, true},
+		{DhcpMsgTypeOptCode, "1", nil, true},
+		{ServerIdOptCode, "10.1.1.1", nil, true},
+		{26, "70000", nil, true},
+		{DNSOptCode, "8.8.8", nil, true},
+		{HostNameOptCode, "", nil, true},
+	}
+	for _, test := range tests {
+		data, err := encodeDhcpOption(test.code, test.value)
+		if test.fail {
+			if err == nil {
+				t.Error("Expected failure for option", test.code, "value:", test.value)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(data, test.want) {
+			t.Error("Option", test.code, "value:", test.value, "want:", test.want, "got:", data, err)
+		}
+	}
+}
+
+func TestGetUserClasses(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want []string
+	}{
+		{[]byte{3, 'a', 'b', 'c', 1, 'x'}, []string{"abc", "x"}},
+		{[]byte("iPXE"), []string{"iPXE"}},
+		{[]byte{5, 'a'}, []string{string([]byte{5, 'a'})}},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		classes := getUserClasses(test.data)
+		if len(classes) != len(test.want) {
+			t.Error("User classes for", test.data, "want:", test.want, "got:", classes)
+			continue
+		}
+		for idx := range classes {
+			if classes[idx] != test.want[idx] {
+				t.Error("User classes for", test.data, "want:", test.want, "got:", classes)
+			}
+		}
+	}
+}
+
+func TestMatchClassPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"Cisco", "Cisco", true},
+		{"Cisco", "CiscoSystems", false},
+		{"Cisco*", "CiscoSystems", true},
+		{"Cisco*", "Juniper", false},
+		{"*", "anything", true},
+		{"MSFT 5.0", "MSFT 5.0", true},
+	}
+	for _, test := range tests {
+		if got := matchClassPattern(test.pattern, test.value); got != test.want {
+			t.Error("Pattern:", test.pattern, "value:", test.value, "want:", test.want, "got:", got)
+		}
+	}
+}
+
+func getTestIntfData(t *testing.T, conf DhcpIntfConfig) DhcpIntfData {
+	options, clientClasses, err := buildDhcpPoolOptions(conf)
+	if err != nil {
+		t.Fatal("Failed to build pool options", err)
+	}
+	return DhcpIntfData{
+		options:       options,
+		clientClasses: clientClasses,
+		dhcpMsg:       buildDhcpCommonOptions(0x0a010101, 3600, 0xffffff00),
+	}
+}
+
+// parseDhcpOptions returns the option codes in the order they are encoded
+func parseDhcpOptions(data []byte) ([]uint8, map[uint8][]byte) {
+	var codes []uint8
+	options := make(map[uint8][]byte)
+	for idx := DHCP_MAGIC_COOKIE_LEN; idx < len(data); {
+		code := data[idx]
+		if code == EndOptCode {
+			break
+		}
+		if code == PadOptCode {
+			idx++
+			continue
+		}
+		length := int(data[idx+1])
+		codes = append(codes, code)
+		options[code] = data[idx+2 : idx+2+length]
+		idx += 2 + length
+	}
+	return codes, options
+}
+
+func TestBuildDhcpOptions(t *testing.T) {
+	server := getTestServer(t)
+	conf := DhcpIntfConfig{
+		RtrAddr:    0x0a0101fe,
+		DomainName: "example.com",
+		Options: []DhcpOptionConfig{
+			{TFTPServerNameOptCode, "tftp"},
+			{BootFileNameOptCode, "boot.bin"},
+			{NTPServerOptCode, "10.1.1.2"},
+		},
+		ClientClasses: []DhcpClientClass{
+			{
+				Name:          "ztp",
+				VendorClassId: "Cisco*",
+				Options:       []DhcpOptionConfig{{BootFileNameOptCode, "ztp.py"}},
+			},
+		},
+	}
+	dhcpIntfEnt := getTestIntfData(t, conf)
+
+	// Requested options first in request order, then the rest by code
+	bootPMsgData := &BootPMsgStruct{
+		DhcpOptionMap: map[uint8]DhcpOptionData{
+			ParamReqListOptCode: {2, []byte{BootFileNameOptCode, RouterOptCode}},
+		},
+	}
+	dhcpOpts := server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPOFFER)
+	if dhcpOpts[DHCP_MSG_TYPE_OFFSET] != DHCPOFFER {
+		t.Error("Invalid message type", dhcpOpts[DHCP_MSG_TYPE_OFFSET])
+	}
+	if len(dhcpOpts) < DHCP_MIN_OPTIONS_LEN {
+		t.Error("Options must be padded to", DHCP_MIN_OPTIONS_LEN, "got:", len(dhcpOpts))
+	}
+	codes, options := parseDhcpOptions(dhcpOpts)
+	wantCodes := []uint8{DhcpMsgTypeOptCode, ServerIdOptCode, IPAddrLeaseTimeOptCode, SubnetMaskOptCode,
+		BootFileNameOptCode, RouterOptCode, DomainNameOptCode, NTPServerOptCode, TFTPServerNameOptCode}
+	if !bytes.Equal(codes, wantCodes) {
+		t.Error("Option order want:", wantCodes, "got:", codes)
+	}
+	if string(options[BootFileNameOptCode]) != "boot.bin" {
+		t.Error("Pool bootfile expected, got:", string(options[BootFileNameOptCode]))
+	}
+
+	// Matching client class overrides the pool option
+	bootPMsgData.DhcpOptionMap[VendorClassIdOptCode] = DhcpOptionData{12, []byte("CiscoSystems")}
+	dhcpOpts = server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPACK)
+	if dhcpOpts[DHCP_MSG_TYPE_OFFSET] != DHCPACK {
+		t.Error("Invalid message type", dhcpOpts[DHCP_MSG_TYPE_OFFSET])
+	}
+	_, options = parseDhcpOptions(dhcpOpts)
+	if string(options[BootFileNameOptCode]) != "ztp.py" {
+		t.Error("Class bootfile expected, got:", string(options[BootFileNameOptCode]))
+	}
+	if string(dhcpIntfEnt.options[BootFileNameOptCode]) != "boot.bin" {
+		t.Error("Client class must not modify pool options")
+	}
+}
+
+func TestBuildDhcpOptionsTruncation(t *testing.T) {
+	server := getTestServer(t)
+	var dnsList string
+	for idx := 0; idx < 60; idx++ {
+		if idx != 0 {
+			dnsList += ","
+		}
+		dnsList += "10.1.1.1"
+	}
+	conf := DhcpIntfConfig{
+		Options: []DhcpOptionConfig{
+			{DNSOptCode, dnsList}, // 240 bytes
+			{NTPServerOptCode, dnsList},
+			{HostNameOptCode, "host"},
+		},
+	}
+	dhcpIntfEnt := getTestIntfData(t, conf)
+	maxLen := DHCP_DEFAULT_MAX_MSG_LEN - IP_UDP_HDR_LEN - int(BOOTP_MSG_SIZE)
+
+	bootPMsgData := &BootPMsgStruct{DhcpOptionMap: map[uint8]DhcpOptionData{}}
+	dhcpOpts := server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPOFFER)
+	if len(dhcpOpts) > maxLen {
+		t.Error("Options exceed max length", maxLen, "got:", len(dhcpOpts))
+	}
+	codes, _ := parseDhcpOptions(dhcpOpts)
+	wantCodes := []uint8{DhcpMsgTypeOptCode, ServerIdOptCode, IPAddrLeaseTimeOptCode, SubnetMaskOptCode,
+		DNSOptCode, HostNameOptCode}
+	if !bytes.Equal(codes, wantCodes) {
+		t.Error("Options want:", wantCodes, "got:", codes)
+	}
+
+	// Larger max message size from the client fits everything
+	maxMsgSize := make([]byte, 2)
+	binary.BigEndian.PutUint16(maxMsgSize, 1500)
+	bootPMsgData.DhcpOptionMap[MaxDhcpMsgSizeOptCode] = DhcpOptionData{2, maxMsgSize}
+	dhcpOpts = server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPOFFER)
+	codes, _ = parseDhcpOptions(dhcpOpts)
+	wantCodes = []uint8{DhcpMsgTypeOptCode, ServerIdOptCode, IPAddrLeaseTimeOptCode, SubnetMaskOptCode,
+		DNSOptCode, HostNameOptCode, NTPServerOptCode}
+	if !bytes.Equal(codes, wantCodes) {
+		t.Error("Options want:", wantCodes, "got:", codes)
+	}
+}
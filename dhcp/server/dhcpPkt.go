@@ -172,161 +172,36 @@ func (server *DHCPServer) processUDPLayer(udpLayer gopacket.Layer, udpHdrMd *UDP
 */
 
 const (
-	SubnetMaskOptCode         uint8 = 1 //ParamReqItemCode = 1
-	RouterOptCode             uint8 = 3 //ParamReqItemCode = 3
-	DNSOptCode                uint8 = 6 //ParamReqItemCode = 6
-	HostNameOptCode           uint8 = 12
-	DomainNameOptCode         uint8 = 15 //ParamReqItemCode = 15
-	StaticRouteOptCode        uint8 = 33 //ParamReqItemCode = 33
-	VendorSpecificInfoOptCode uint8 = 43 //ParamReqItemCode = 43
-	NetBIOSServerOptCode      uint8 = 44 //ParamReqItemCode = 44
-	ReqIPAddrOptCode          uint8 = 50
-	IPAddrLeaseTimeOptCode    uint8 = 51
-	DhcpMsgTypeOptCode        uint8 = 53
-	ServerIdOptCode           uint8 = 54
-	ParamReqListOptCode       uint8 = 55
-	MaxDhcpMsgSizeOptCode     uint8 = 57
-	RenewalTimeOptCode        uint8 = 58
-	RebindingTimeOptCode      uint8 = 59
-	ClientIdOptCode           uint8 = 61
-	TFTPServerAddrOptCode     uint8 = 150 //ParamReqItemCode = 150
-	EndOptCode                uint8 = 255
+	PadOptCode                  uint8 = 0
+	SubnetMaskOptCode           uint8 = 1 //ParamReqItemCode = 1
+	RouterOptCode               uint8 = 3 //ParamReqItemCode = 3
+	DNSOptCode                  uint8 = 6 //ParamReqItemCode = 6
+	HostNameOptCode             uint8 = 12
+	DomainNameOptCode           uint8 = 15 //ParamReqItemCode = 15
+	StaticRouteOptCode          uint8 = 33 //ParamReqItemCode = 33
+	NTPServerOptCode            uint8 = 42
+	VendorSpecificInfoOptCode   uint8 = 43 //ParamReqItemCode = 43
+	NetBIOSServerOptCode        uint8 = 44 //ParamReqItemCode = 44
+	ReqIPAddrOptCode            uint8 = 50
+	IPAddrLeaseTimeOptCode      uint8 = 51
+	OptionOverloadOptCode       uint8 = 52
+	DhcpMsgTypeOptCode          uint8 = 53
+	ServerIdOptCode             uint8 = 54
+	ParamReqListOptCode         uint8 = 55
+	MaxDhcpMsgSizeOptCode       uint8 = 57
+	RenewalTimeOptCode          uint8 = 58
+	RebindingTimeOptCode        uint8 = 59
+	VendorClassIdOptCode        uint8 = 60
+	ClientIdOptCode             uint8 = 61
+	TFTPServerNameOptCode       uint8 = 66
+	BootFileNameOptCode         uint8 = 67
+	UserClassOptCode            uint8 = 77
+	DomainSearchOptCode         uint8 = 119
+	ClasslessStaticRouteOptCode uint8 = 121
+	TFTPServerAddrOptCode       uint8 = 150 //ParamReqItemCode = 150
+	EndOptCode                  uint8 = 255
 )
 
-type OptionMetadata struct {
-	OPCode uint8
-	Length uint8
-}
-
-type SubnetMaskStruct struct {
-	OptMd      OptionMetadata
-	SubnetMask []byte
-}
-
-type DNSStruct struct {
-	OptMd OptionMetadata
-	DNS   []byte
-}
-
-type DomainNameStruct struct {
-	OptMd      OptionMetadata
-	DomainName []byte
-}
-
-type NetBIOSServerStruct struct {
-	OptMd         OptionMetadata
-	NetBIOSServer []byte
-}
-
-type RouterStruct struct {
-	OptMd  OptionMetadata
-	Router []byte
-}
-
-type StaticRouteStruct struct {
-	OptMd       OptionMetadata
-	StaticRoute []byte
-}
-
-type TFTPServerAddrStruct struct {
-	OptMd          OptionMetadata
-	TFTPServerAddr []byte
-}
-
-type VendorSpecificInfoStruct struct {
-	OptMd              OptionMetadata
-	VendorSpecificInfo []byte
-}
-
-type HostNameStruct struct {
-	OptMd    OptionMetadata
-	HostName []byte
-}
-
-type DhcpMsgTypeStruct struct {
-	OptMd   OptionMetadata
-	MsgType []byte
-}
-
-type ParamReqListStruct struct {
-	OptMd        OptionMetadata
-	ParamReqList []byte
-}
-
-type MaxDhcpMsgSizeStruct struct {
-	OptMd          OptionMetadata
-	MaxDhcpMsgSize []byte
-}
-
-type ClientIdStruct struct {
-	OptMd    OptionMetadata
-	ClientId []byte
-}
-
-type ServerIdStruct struct {
-	OptMd    OptionMetadata
-	ServerId []byte
-}
-
-type IPAddrLeaseTimeStruct struct {
-	OptMd           OptionMetadata
-	IPAddrLeaseTime []byte
-}
-
-type RenewalTimeStruct struct {
-	OptMd       OptionMetadata
-	RenewalTime []byte
-}
-
-type RebindingTimeStruct struct {
-	OptMd         OptionMetadata
-	RebindingTime []byte
-}
-
-type ReqIPAddrStruct struct {
-	OptMd     OptionMetadata
-	ReqIPAddr []byte
-}
-
-type OptionFields struct {
-	SubnetMaskOpt         bool
-	SubnetMaskVar         SubnetMaskStruct
-	RouterOpt             bool
-	RouterVar             RouterStruct
-	DNSOpt                bool
-	DNSVar                DNSStruct
-	HostNameOpt           bool
-	HostNameVar           HostNameStruct
-	DomainNameOpt         bool
-	DomainNameVar         DomainNameStruct
-	StaticRouteOpt        bool
-	StaticRouteVar        StaticRouteStruct
-	VendorSpecificInfoOpt bool
-	VendorSpecificInfoVar VendorSpecificInfoStruct
-	NetBIOSServerOpt      bool
-	NetBIOSServerVar      NetBIOSServerStruct
-	ReqIPAddrOpt          bool
-	ReqIPAddrVar          ReqIPAddrStruct
-	IPAddrLeaseTimeOpt    bool
-	IPAddrLeaseTimeVar    IPAddrLeaseTimeStruct
-	DhcpMsgTypeOpt        bool
-	DhcpMsgTypeVar        DhcpMsgTypeStruct
-	ServerIdOpt           bool
-	ServerIdVar           ServerIdStruct
-	ParamReqListOpt       bool
-	ParamReqListVar       ParamReqListStruct
-	MaxDhcpMsgSizeOpt     bool
-	MaxDhcpMsgSizeVar     MaxDhcpMsgSizeStruct
-	RenewalTimeOpt        bool
-	RenewalTimeVar        RenewalTimeStruct
-	RebindingTimeOpt      bool
-	RebindingTimeVar      RebindingTimeStruct
-	ClientIdOpt           bool
-	ClientIdVar           ClientIdStruct
-	TFTPServerAddrOpt     bool
-	TFTPServerAddrVar     TFTPServerAddrStruct
-}
-
 type DhcpOptionData struct {
 	Length uint8
 	Data   []byte
@@ -348,8 +223,7 @@ type BootPMsgStruct struct {
 	ServerName       []byte
 	BootFilename     []byte
 	MagicCookie      uint32
-	//DhcpOptFields    OptionFields
-	DhcpOptionMap map[uint8]DhcpOptionData
+	DhcpOptionMap    map[uint8]DhcpOptionData
 }
 
 func NewBootPMsgStruct() *BootPMsgStruct {
@@ -424,12 +298,22 @@ func decodeDhcpOptions(bootPMsgData *BootPMsgStruct, data []byte) {
 		if opCode == EndOptCode {
 			break
 		}
+		if opCode == PadOptCode {
+			start++
+			continue
+		}
+		if start+1 >= length {
+			break
+		}
 		optLen := uint8(data[start+1])
+		start = start + 2
+		end = start + int(optLen)
+		if end > length {
+			break
+		}
 		ent := bootPMsgData.DhcpOptionMap[opCode]
 		ent.Length = optLen
 		ent.Data = make([]byte, optLen)
-		start = start + 2
-		end = start + int(optLen)
 		copy(ent.Data, data[start:end])
 		bootPMsgData.DhcpOptionMap[opCode] = ent
 		start = end
@@ -534,168 +418,3 @@ func (server *DHCPServer) buildDhcpOfferPkt(portEnt PortProperty, dhcpOffer []by
 	dhcpOfferPkt := buffer.Bytes()
 	return dhcpOfferPkt
 }
-
-/*
-func decodeDhcpOptionFields(bootPMsgData *BootPMsgStruct, data []byte) {
-	start := 0
-	end := 0
-	length := len(data)
-	fmt.Println("Data:", data)
-	for start < length {
-		opCode := uint8(data[start])
-		optLen := uint8(data[start+1])
-
-		fmt.Println("OpCode:", opCode, "optLen:", optLen)
-		start = start + 2
-		switch opCode {
-		case SubnetMaskOptCode:
-			bootPMsgData.DhcpOptFields.SubnetMaskOpt = true
-			bootPMsgData.DhcpOptFields.SubnetMaskVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.SubnetMaskVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.SubnetMaskVar.SubnetMask = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.SubnetMaskVar.SubnetMask, data[start:end])
-			start = end
-		case RouterOptCode:
-			bootPMsgData.DhcpOptFields.RouterOpt = true
-			bootPMsgData.DhcpOptFields.RouterVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.RouterVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.RouterVar.Router = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.RouterVar.Router, data[start:end])
-			start = end
-		case DNSOptCode:
-			bootPMsgData.DhcpOptFields.DNSOpt = true
-			bootPMsgData.DhcpOptFields.DNSVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.DNSVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.DNSVar.DNS = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.DNSVar.DNS, data[start:end])
-			start = end
-		case HostNameOptCode:
-			bootPMsgData.DhcpOptFields.HostNameOpt = true
-			bootPMsgData.DhcpOptFields.HostNameVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.HostNameVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.HostNameVar.HostName = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.HostNameVar.HostName, data[start:end])
-			start = end
-		case DomainNameOptCode:
-			bootPMsgData.DhcpOptFields.DomainNameOpt = true
-			bootPMsgData.DhcpOptFields.DomainNameVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.DomainNameVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.DomainNameVar.DomainName = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.DomainNameVar.DomainName, data[start:end])
-			start = end
-		case StaticRouteOptCode:
-			bootPMsgData.DhcpOptFields.StaticRouteOpt = true
-			bootPMsgData.DhcpOptFields.StaticRouteVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.StaticRouteVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.StaticRouteVar.StaticRoute = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.StaticRouteVar.StaticRoute, data[start:end])
-			start = end
-		case VendorSpecificInfoOptCode:
-			bootPMsgData.DhcpOptFields.VendorSpecificInfoOpt = true
-			bootPMsgData.DhcpOptFields.VendorSpecificInfoVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.VendorSpecificInfoVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.VendorSpecificInfoVar.VendorSpecificInfo = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.VendorSpecificInfoVar.VendorSpecificInfo, data[start:end])
-			start = end
-		case NetBIOSServerOptCode:
-			bootPMsgData.DhcpOptFields.NetBIOSServerOpt = true
-			bootPMsgData.DhcpOptFields.NetBIOSServerVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.NetBIOSServerVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.NetBIOSServerVar.NetBIOSServer = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.NetBIOSServerVar.NetBIOSServer, data[start:end])
-			start = end
-		case ReqIPAddrOptCode:
-			bootPMsgData.DhcpOptFields.ReqIPAddrOpt = true
-			bootPMsgData.DhcpOptFields.ReqIPAddrVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.ReqIPAddrVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.ReqIPAddrVar.ReqIPAddr = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.ReqIPAddrVar.ReqIPAddr, data[start:end])
-			start = end
-		case IPAddrLeaseTimeOptCode:
-			bootPMsgData.DhcpOptFields.IPAddrLeaseTimeOpt = true
-			bootPMsgData.DhcpOptFields.IPAddrLeaseTimeVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.IPAddrLeaseTimeVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.IPAddrLeaseTimeVar.IPAddrLeaseTime = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.IPAddrLeaseTimeVar.IPAddrLeaseTime, data[start:end])
-			start = end
-		case DhcpMsgTypeOptCode:
-			bootPMsgData.DhcpOptFields.DhcpMsgTypeOpt = true
-			bootPMsgData.DhcpOptFields.DhcpMsgTypeVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.DhcpMsgTypeVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.DhcpMsgTypeVar.MsgType = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.DhcpMsgTypeVar.MsgType, data[start:end])
-			start = end
-		case ServerIdOptCode:
-			bootPMsgData.DhcpOptFields.ServerIdOpt = true
-			bootPMsgData.DhcpOptFields.ServerIdVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.ServerIdVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.ServerIdVar.ServerId = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.ServerIdVar.ServerId, data[start:end])
-			start = end
-		case ParamReqListOptCode:
-			bootPMsgData.DhcpOptFields.ParamReqListOpt = true
-			bootPMsgData.DhcpOptFields.ParamReqListVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.ParamReqListVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.ParamReqListVar.ParamReqList = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.ParamReqListVar.ParamReqList, data[start:end])
-			start = end
-		case MaxDhcpMsgSizeOptCode:
-			bootPMsgData.DhcpOptFields.MaxDhcpMsgSizeOpt = true
-			bootPMsgData.DhcpOptFields.MaxDhcpMsgSizeVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.MaxDhcpMsgSizeVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.MaxDhcpMsgSizeVar.MaxDhcpMsgSize = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.MaxDhcpMsgSizeVar.MaxDhcpMsgSize, data[start:end])
-			start = end
-		case RenewalTimeOptCode:
-			bootPMsgData.DhcpOptFields.RenewalTimeOpt = true
-			bootPMsgData.DhcpOptFields.RenewalTimeVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.RenewalTimeVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.RenewalTimeVar.RenewalTime = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.RenewalTimeVar.RenewalTime, data[start:end])
-			start = end
-		case RebindingTimeOptCode:
-			bootPMsgData.DhcpOptFields.RebindingTimeOpt = true
-			bootPMsgData.DhcpOptFields.RebindingTimeVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.RebindingTimeVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.RebindingTimeVar.RebindingTime = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.RebindingTimeVar.RebindingTime, data[start:end])
-			start = end
-		case ClientIdOptCode:
-			bootPMsgData.DhcpOptFields.ClientIdOpt = true
-			bootPMsgData.DhcpOptFields.ClientIdVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.ClientIdVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.ClientIdVar.ClientId = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.ClientIdVar.ClientId, data[start:end])
-			start = end
-		case TFTPServerAddrOptCode:
-			bootPMsgData.DhcpOptFields.TFTPServerAddrOpt = true
-			bootPMsgData.DhcpOptFields.TFTPServerAddrVar.OptMd.Length = optLen
-			bootPMsgData.DhcpOptFields.TFTPServerAddrVar.OptMd.OPCode = opCode
-			bootPMsgData.DhcpOptFields.TFTPServerAddrVar.TFTPServerAddr = make([]byte, optLen)
-			end = start + int(optLen)
-			copy(bootPMsgData.DhcpOptFields.TFTPServerAddrVar.TFTPServerAddr, data[start:end])
-			start = end
-		default:
-			fmt.Println("Invalid Op Code...", opCode)
-		}
-	}
-	fmt.Println("Hello...", length, start)
-}
-*/
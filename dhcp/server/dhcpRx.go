@@ -52,8 +52,12 @@ const (
 	BOOTP_MSG_SIZE        uint16 = 236
 )
 
+// Lease states
 const (
-	OFFERED uint8 = 1
+	OFFERED  uint8 = 1
+	BOUND    uint8 = 2
+	CONFLICT uint8 = 3
+	PROBING  uint8 = 4
 )
 
 func (server *DHCPServer) StartRxDhcpPkt(port int32) {
@@ -294,6 +298,7 @@ func (server *DHCPServer) processDhcpRelease(port int32, pktMd *PktMetadata, boo
 	delete(dhcpIntfEnt.usedIpPool, ipAddr)
 	delete(dhcpIntfEnt.usedIpToMac, clientMac)
 	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	server.deleteLeaseInDB(ipAddr)
 }
 
 func (server *DHCPServer) processDhcpRequest(port int32, pktMd *PktMetadata, bootPMsgData *BootPMsgStruct, data []byte) {
@@ -318,6 +323,10 @@ func (server *DHCPServer) processDhcpRequest(port int32, pktMd *PktMetadata, boo
 	}
 
 	uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
+	if uIPEnt.State == PROBING {
+		server.logger.Info(fmt.Sprintln("Conflict check in progress, no offer sent to", clientMac))
+		return
+	}
 	// Check server ID
 	// Check Transaction ID
 	// Check Requested IP Address
@@ -336,7 +345,10 @@ func (server *DHCPServer) processDhcpRequest(port int32, pktMd *PktMetadata, boo
 		}
 	}
 
-	if bootPMsgData.TransactionId != uIPEnt.TransactionId {
+	// Transaction Id only has to match our offer, a bound client
+	// renewing or rebooting uses a new one
+	if uIPEnt.State == OFFERED &&
+		bootPMsgData.TransactionId != uIPEnt.TransactionId {
 		// DHCP NACK
 		server.logger.Info(fmt.Sprintln("TransactionId are not equal"))
 		return
@@ -406,9 +418,11 @@ func (server *DHCPServer) processDhcpRequest(port int32, pktMd *PktMetadata, boo
 
 	uIPEnt, _ = dhcpIntfEnt.usedIpPool[ipAddr]
 	//server.logger.Info(fmt.Sprintln("1 uIPEnt: ", uIPEnt))
-	if uIPEnt.State == OFFERED {
+	if uIPEnt.State == BOUND {
 		server.logger.Info(fmt.Sprintln("Reseting refresh timer...."))
 		uIPEnt.RefreshTimer.Reset(time.Duration(uIPEnt.LeaseTime) * time.Second)
+		uIPEnt.Expiry = time.Now().Add(time.Duration(uIPEnt.LeaseTime) * time.Second)
+		server.storeLeaseInDB(ipAddr, uIPEnt)
 	}
 	if uIPEnt.StaleTimer != nil {
 		server.logger.Info(fmt.Sprintln("Stopping stale timer...."))
@@ -618,14 +632,17 @@ func (server *DHCPServer) buildDhcpOfferPkt(portEnt PortProperty, dhcpOffer []by
 
 func (server *DHCPServer) findUnusedIP(dhcpIntfData DhcpIntfData) (uint32, bool) {
 	diff := int(dhcpIntfData.higherIPBound - dhcpIntfData.lowerIPBound + 1)
-	if len(dhcpIntfData.usedIpPool) == diff {
-		return 0, false
-	}
-	for {
-		offset := rand.Intn(diff)
-		_, exist := dhcpIntfData.usedIpPool[dhcpIntfData.lowerIPBound+uint32(offset)]
-		if !exist {
-			return dhcpIntfData.lowerIPBound + uint32(offset), true
+	start := rand.Intn(diff)
+	for idx := 0; idx < diff; idx++ {
+		ip := dhcpIntfData.lowerIPBound + uint32((start+idx)%diff)
+		if _, exist := dhcpIntfData.usedIpPool[ip]; exist {
+			continue
+		}
+		// Reserved addresses are only handed out to their owner
+		if _, exist := dhcpIntfData.reservedIps[ip]; exist {
+			continue
 		}
+		return ip, true
 	}
+	return 0, false
 }
@@ -44,10 +44,10 @@ func (server *DHCPServer) sendDhcpAck(port int32, bootPMsgData *BootPMsgStruct,
 		}
 	*/
 	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
-	dhcpAck := make([]byte, BOOTP_MSG_SIZE+36)
+	dhcpOpts := server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPACK)
+	dhcpAck := make([]byte, int(BOOTP_MSG_SIZE)+len(dhcpOpts))
 	copy(dhcpAck, data[0:BOOTP_MSG_SIZE])
-	dhcpIntfEnt.dhcpMsg[6] = DHCPACK
-	copy(dhcpAck[BOOTP_MSG_SIZE:], dhcpIntfEnt.dhcpMsg[0:])
+	copy(dhcpAck[BOOTP_MSG_SIZE:], dhcpOpts)
 	binary.BigEndian.PutUint32(dhcpAck[16:20], ipAddr)
 	dhcpAckPkt := server.buildDhcpAckPkt(portEnt, dhcpAck, bootPMsgData)
 
@@ -85,33 +85,66 @@ func (server *DHCPServer) sendDhcpOffer(port int32, pktMd *PktMetadata, bootPMsg
 		}
 	*/
 	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
-	dhcpOffer := make([]byte, BOOTP_MSG_SIZE+36)
-	copy(dhcpOffer, data[0:BOOTP_MSG_SIZE])
-	//Set DHCP Offer Message
-	//Set yiaddr
-	dhcpIntfEnt.dhcpMsg[6] = DHCPOFFER
-	copy(dhcpOffer[BOOTP_MSG_SIZE:], dhcpIntfEnt.dhcpMsg[0:])
 	ipAddr, exist := dhcpIntfEnt.usedIpToMac[clientMac]
-	if !exist {
-		ip, ret := server.findUnusedIP(dhcpIntfEnt)
-		if ret == false {
-			server.logger.Err("No available IP Addr")
-			return
-		}
-		dhcpIntfEnt.usedIpToMac[clientMac] = ip
-		ipAddr = ip
-		uIPEnt, _ := dhcpIntfEnt.usedIpPool[ip]
-		uIPEnt.LeaseTime = server.DhcpGlobalConf.DefaultLeaseTime
-		uIPEnt.MacAddr = clientMac
+	if exist {
+		uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
 		uIPEnt.TransactionId = bootPMsgData.TransactionId
-		dhcpIntfEnt.usedIpPool[ip] = uIPEnt
+		dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
 		server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
-		server.logger.Info("Starting Stale Entry Handler")
-		go server.StartStaleEntryHandler(port, ipAddr, clientMac)
-	} else {
+		if uIPEnt.State == PROBING {
+			server.logger.Info(fmt.Sprintln("Conflict check in progress for client", clientMac))
+			return
+		}
 		server.logger.Info(fmt.Sprintln("Already offered and IP Address to this client", clientMac))
+		server.transmitDhcpOffer(port, bootPMsgData, data, ipAddr)
+		return
+	}
+
+	clientId := getClientId(bootPMsgData)
+	ip, ret := server.allocateIP(dhcpIntfKey, clientMac, clientId)
+	if ret == false {
+		server.logger.Err("No available IP Addr")
+		return
 	}
+	if dhcpIntfEnt.pingCheck {
+		// Offer is sent once the conflict check completes
+		server.startConflictCheck(port, bootPMsgData, data, ip, 0)
+		return
+	}
+	server.offerIP(port, bootPMsgData, data, ip)
+}
 
+// offerIP records the offered address against the client and sends the offer
+func (server *DHCPServer) offerIP(port int32, bootPMsgData *BootPMsgStruct, data []byte, ipAddr uint32) {
+	clientMac := (net.HardwareAddr(bootPMsgData.ClientHWAddr)).String()
+	portEnt, _ := server.portPropertyMap[port]
+	l3Ent, _ := server.l3IntfPropMap[portEnt.L3IfIndex]
+	dhcpIntfKey := l3Ent.DhcpIfKey
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[dhcpIntfKey]
+	dhcpIntfEnt.usedIpToMac[clientMac] = ipAddr
+	uIPEnt, _ := dhcpIntfEnt.usedIpPool[ipAddr]
+	uIPEnt.LeaseTime = server.DhcpGlobalConf.DefaultLeaseTime
+	uIPEnt.MacAddr = clientMac
+	uIPEnt.ClientId = getClientId(bootPMsgData)
+	uIPEnt.TransactionId = bootPMsgData.TransactionId
+	uIPEnt.State = OFFERED
+	dhcpIntfEnt.usedIpPool[ipAddr] = uIPEnt
+	server.DhcpIntfConfMap[dhcpIntfKey] = dhcpIntfEnt
+	server.logger.Info("Starting Stale Entry Handler")
+	go server.StartStaleEntryHandler(port, ipAddr, clientMac)
+	server.transmitDhcpOffer(port, bootPMsgData, data, ipAddr)
+}
+
+func (server *DHCPServer) transmitDhcpOffer(port int32, bootPMsgData *BootPMsgStruct, data []byte, ipAddr uint32) {
+	portEnt, _ := server.portPropertyMap[port]
+	l3Ent, _ := server.l3IntfPropMap[portEnt.L3IfIndex]
+	dhcpIntfEnt, _ := server.DhcpIntfConfMap[l3Ent.DhcpIfKey]
+	dhcpOpts := server.buildDhcpOptions(dhcpIntfEnt, bootPMsgData, DHCPOFFER)
+	dhcpOffer := make([]byte, int(BOOTP_MSG_SIZE)+len(dhcpOpts))
+	copy(dhcpOffer, data[0:BOOTP_MSG_SIZE])
+	//Set DHCP Offer Message
+	//Set yiaddr
+	copy(dhcpOffer[BOOTP_MSG_SIZE:], dhcpOpts)
 	binary.BigEndian.PutUint32(dhcpOffer[16:20], ipAddr)
 	dhcpOfferPkt := server.buildDhcpOfferPkt(portEnt, dhcpOffer)
 
@@ -130,5 +163,4 @@ func (server *DHCPServer) sendDhcpOffer(port int32, pktMd *PktMetadata, bootPMsg
 		return
 	}
 	return
-
 }
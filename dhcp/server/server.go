@@ -58,6 +58,12 @@ type DhcpGlobalConfig struct {
 	MaxLeaseTime     uint32
 }
 
+type DhcpReservation struct {
+	MacAddr  string
+	ClientId string
+	IpAddr   uint32
+}
+
 type DhcpIntfConfig struct {
 	Enable        bool
 	IntfRef       string
@@ -69,6 +75,10 @@ type DhcpIntfConfig struct {
 	RtrAddr       uint32
 	DnsAddr       uint32
 	DomainName    string
+	PingCheck     bool
+	Reservations  []DhcpReservation
+	Options       []DhcpOptionConfig
+	ClientClasses []DhcpClientClass
 }
 
 type DhcpIntfKey struct {
@@ -79,7 +89,9 @@ type DhcpIntfKey struct {
 type DhcpOfferedData struct {
 	LeaseTime     uint32
 	MacAddr       string
+	ClientId      string
 	TransactionId uint32
+	Expiry        time.Time
 	RefreshTimer  *time.Timer
 	StaleTimer    *time.Timer
 	State         uint8
@@ -93,8 +105,13 @@ type DhcpIntfData struct {
 	rtrAddr       uint32
 	dnsAddr       uint32
 	domainName    string
+	pingCheck     bool
+	reservations  map[string]uint32 // MAC or client-id to reserved IP
+	reservedIps   map[uint32]bool
 	usedIpPool    map[uint32]DhcpOfferedData
 	usedIpToMac   map[string]uint32
+	options       map[uint8][]byte
+	clientClasses []dhcpClientClassData
 	dhcpMsg       []byte
 }
 
@@ -118,6 +135,9 @@ type DHCPServer struct {
 	pcapTimeout     time.Duration
 	promiscuous     bool
 	snapshotLen     int32
+	dbHdl           DhcpDbHdl
+	// conflictCheckFunc probes an address before it is offered
+	conflictCheckFunc func(ifName string, ipAddr uint32) bool
 }
 
 func NewDHCPServer(logger *logging.Writer) *DHCPServer {
@@ -135,6 +155,7 @@ func NewDHCPServer(logger *logging.Writer) *DHCPServer {
 	dhcpServer.vlanPropertyMap = make(map[int32]VlanProperty)
 	dhcpServer.lagPropertyMap = make(map[int32]LagProperty)
 	dhcpServer.InitDone = make(chan bool)
+	dhcpServer.conflictCheckFunc = dhcpServer.isIPAddrInUse
 	return dhcpServer
 }
 
@@ -210,6 +231,10 @@ func (server *DHCPServer) InitServer(paramDir string) {
 	}
 	fileName = fileName + "clients.json"
 	server.connectToServers(fileName)
+	err := server.initiateDB()
+	if err != nil {
+		server.logger.Err("Dhcp leases will not be persisted across restart")
+	}
 	server.buildDhcpInfra()
 	server.logger.Debug("Listen for ASICd updates")
 	server.listenForASICdUpdates(asicdCommonDefs.PUB_SOCKET_ADDR)
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fmt"
+	"infra/sysd/sysdCommonDefs"
+	"log/syslog"
+	"testing"
+	"utils/logging"
+)
+
+func NewLogger(name string, tag string, listenToConfig bool) (*logging.Writer, error) {
+	var err error
+	srLogger := new(logging.Writer)
+	srLogger.MyComponentName = name
+
+	srLogger.SysLogger, err = syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		fmt.Println("Failed to initialize syslog - ", err)
+		return srLogger, err
+	}
+
+	srLogger.MyLogLevel = sysdCommonDefs.INFO
+	fmt.Println("Logging level ", srLogger.MyLogLevel, " set for ", srLogger.MyComponentName)
+	return srLogger, err
+}
+
+func getTestServer(t *testing.T) *DHCPServer {
+	logger, err := NewLogger("dhcpdTest", "DHCPTest", true)
+	if err != nil {
+		t.Fatal("Unable to initialize logger")
+	}
+	return NewDHCPServer(logger)
+}
@@ -28,6 +28,7 @@ import (
     "l3/isis/server"
     "strconv"
     "strings"
+    "utils/commonDefs"
     "utils/dmnBase"
 )
 
@@ -57,6 +58,14 @@ func main() {
                 Logger:    dmn.FSBaseDmn.Logger,
         }
         dmn.daemonServer = server.NewISISDServer(serverInitParams)
+
+        // create handler and map for recieving notifications from switch/asicd
+        asicHdl := commonDefs.AsicdClientStruct{
+                NHdl: server.NewAsicNotificationHdl(dmn.daemonServer),
+                NMap: server.InitAsicdNotification(),
+        }
+        asicHdl.Logger = dmn.GetLogger()
+        dmn.daemonServer.SwitchPlugin = dmn.InitSwitch("Flexswitch", DMN_NAME, "ISIS", asicHdl)
         go dmn.daemonServer.Serve()
 
         var rpcServerAddr string
@@ -69,7 +78,7 @@ func main() {
         if rpcServerAddr == "" {
                 panic("Daemon isisd is not part of the system profile")
         }
-        dmn.rpcServer = rpc.NewRPCServer(rpcServerAddr, dmn.FSBaseDmn.Logger)
+        dmn.rpcServer = rpc.NewRPCServer(rpcServerAddr, dmn.FSBaseDmn.Logger, dmn.daemonServer)
 
         dmn.StartKeepAlive()
 
@@ -0,0 +1,144 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+type LanHello struct {
+	Level       uint8 // PDU level. Level1 or Level2
+	CircuitType uint8
+	SourceId    SystemId
+	HoldingTime uint16
+	Priority    uint8
+	LanId       NodeId
+	Tlvs
+}
+
+type P2PHello struct {
+	CircuitType    uint8
+	SourceId       SystemId
+	HoldingTime    uint16
+	LocalCircuitId uint8
+	Tlvs
+}
+
+/*@fn EncodeLanHello
+Encode LAN IIH. The PDU is padded upto padLen when padLen is non zero.
+*/
+func EncodeLanHello(hello *LanHello, padLen int) []byte {
+	pduType := L1LanIIH
+	if hello.Level == Level2 {
+		pduType = L2LanIIH
+	}
+	data := make([]byte, ISIS_LAN_IIH_HDR_LEN)
+	encodeCommonHdr(data, ISIS_LAN_IIH_HDR_LEN, pduType)
+	data[8] = hello.CircuitType & 0x03
+	copy(data[9:15], hello.SourceId[:])
+	binary.BigEndian.PutUint16(data[15:17], hello.HoldingTime)
+	data[19] = hello.Priority & 0x7F
+	copy(data[20:27], hello.LanId[:])
+	data = append(data, encodeTlvs(&hello.Tlvs)...)
+	if padLen > 0 {
+		data = appendPadding(data, padLen)
+	}
+	binary.BigEndian.PutUint16(data[17:19], uint16(len(data)))
+	return data
+}
+
+func DecodeLanHello(data []byte) (*LanHello, error) {
+	hdr, err := DecodeCommonHdr(data)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.PduType != L1LanIIH && hdr.PduType != L2LanIIH {
+		return nil, errors.New(fmt.Sprintln("Not a LAN hello", hdr.PduType))
+	}
+	pduLen, err := getPduLen(data, 17)
+	if err != nil {
+		return nil, err
+	}
+	hello := &LanHello{}
+	hello.Level = Level1
+	if hdr.PduType == L2LanIIH {
+		hello.Level = Level2
+	}
+	hello.CircuitType = data[8] & 0x03
+	copy(hello.SourceId[:], data[9:15])
+	hello.HoldingTime = binary.BigEndian.Uint16(data[15:17])
+	hello.Priority = data[19] & 0x7F
+	copy(hello.LanId[:], data[20:27])
+	tlvs, err := decodeTlvs(data[ISIS_LAN_IIH_HDR_LEN:pduLen])
+	if err != nil {
+		return nil, err
+	}
+	hello.Tlvs = *tlvs
+	return hello, nil
+}
+
+/*@fn EncodeP2PHello
+Encode point to point IIH. The PDU is padded upto padLen when padLen is non zero.
+*/
+func EncodeP2PHello(hello *P2PHello, padLen int) []byte {
+	data := make([]byte, ISIS_P2P_IIH_HDR_LEN)
+	encodeCommonHdr(data, ISIS_P2P_IIH_HDR_LEN, P2PIIH)
+	data[8] = hello.CircuitType & 0x03
+	copy(data[9:15], hello.SourceId[:])
+	binary.BigEndian.PutUint16(data[15:17], hello.HoldingTime)
+	data[19] = hello.LocalCircuitId
+	data = append(data, encodeTlvs(&hello.Tlvs)...)
+	if padLen > 0 {
+		data = appendPadding(data, padLen)
+	}
+	binary.BigEndian.PutUint16(data[17:19], uint16(len(data)))
+	return data
+}
+
+func DecodeP2PHello(data []byte) (*P2PHello, error) {
+	hdr, err := DecodeCommonHdr(data)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.PduType != P2PIIH {
+		return nil, errors.New(fmt.Sprintln("Not a P2P hello", hdr.PduType))
+	}
+	pduLen, err := getPduLen(data, 17)
+	if err != nil {
+		return nil, err
+	}
+	hello := &P2PHello{}
+	hello.CircuitType = data[8] & 0x03
+	copy(hello.SourceId[:], data[9:15])
+	hello.HoldingTime = binary.BigEndian.Uint16(data[15:17])
+	hello.LocalCircuitId = data[19]
+	tlvs, err := decodeTlvs(data[ISIS_P2P_IIH_HDR_LEN:pduLen])
+	if err != nil {
+		return nil, err
+	}
+	hello.Tlvs = *tlvs
+	return hello, nil
+}
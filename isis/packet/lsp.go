@@ -0,0 +1,278 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	LSP_LIFETIME_OFFSET = 10
+	LSP_ID_OFFSET       = 12
+	LSP_CHECKSUM_OFFSET = 24
+	LSP_FLAGS_OFFSET    = 26
+)
+
+/* LSP flags */
+const (
+	LSP_FLAG_PARTITION = 0x80
+	LSP_FLAG_ATT       = 0x08 // Default metric attached
+	LSP_FLAG_OVERLOAD  = 0x04
+)
+
+type Lsp struct {
+	Level             uint8 // PDU level. Level1 or Level2
+	RemainingLifetime uint16
+	LspId             LspId
+	SeqNum            uint32
+	Checksum          uint16
+	Attached          bool
+	Overload          bool
+	IsType            uint8
+	Tlvs
+}
+
+/*@fn fletcherChecksum
+ISO 8473 checksum of data with the checksum field at offset
+*/
+func fletcherChecksum(data []byte, offset int) uint16 {
+	data[offset] = 0
+	data[offset+1] = 0
+	c0 := 0
+	c1 := 0
+	for _, b := range data {
+		c0 = (c0 + int(b)) % 255
+		c1 = (c1 + c0) % 255
+	}
+	x := ((len(data)-offset-1)*c0 - c1) % 255
+	if x <= 0 {
+		x += 255
+	}
+	y := 510 - c0 - x
+	if y > 255 {
+		y -= 255
+	}
+	return uint16(x<<8 | y)
+}
+
+func verifyFletcherChecksum(data []byte) bool {
+	c0 := 0
+	c1 := 0
+	for _, b := range data {
+		c0 = (c0 + int(b)) % 255
+		c1 = (c1 + c0) % 255
+	}
+	return c0 == 0 && c1 == 0
+}
+
+/*@fn EncodeLsp
+Encode LSP and fill in the checksum. Purged LSPs are
+sent without TLVs and with zero checksum.
+*/
+func EncodeLsp(lsp *Lsp) []byte {
+	pduType := L1Lsp
+	if lsp.Level == Level2 {
+		pduType = L2Lsp
+	}
+	data := make([]byte, ISIS_LSP_HDR_LEN)
+	encodeCommonHdr(data, ISIS_LSP_HDR_LEN, pduType)
+	binary.BigEndian.PutUint16(data[LSP_LIFETIME_OFFSET:], lsp.RemainingLifetime)
+	copy(data[LSP_ID_OFFSET:LSP_ID_OFFSET+8], lsp.LspId[:])
+	binary.BigEndian.PutUint32(data[20:24], lsp.SeqNum)
+	flags := lsp.IsType & 0x03
+	if lsp.Attached {
+		flags |= LSP_FLAG_ATT
+	}
+	if lsp.Overload {
+		flags |= LSP_FLAG_OVERLOAD
+	}
+	data[LSP_FLAGS_OFFSET] = flags
+	if lsp.RemainingLifetime != 0 {
+		data = append(data, encodeTlvs(&lsp.Tlvs)...)
+	}
+	binary.BigEndian.PutUint16(data[8:10], uint16(len(data)))
+	lsp.Checksum = 0
+	if lsp.RemainingLifetime != 0 {
+		lsp.Checksum = fletcherChecksum(data[LSP_ID_OFFSET:], LSP_CHECKSUM_OFFSET-LSP_ID_OFFSET)
+	}
+	binary.BigEndian.PutUint16(data[LSP_CHECKSUM_OFFSET:], lsp.Checksum)
+	return data
+}
+
+/*@fn DecodeLsp
+Decode LSP and validate the checksum. Checksum of
+purged LSPs is not validated.
+*/
+func DecodeLsp(data []byte) (*Lsp, error) {
+	hdr, err := DecodeCommonHdr(data)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.PduType != L1Lsp && hdr.PduType != L2Lsp {
+		return nil, errors.New(fmt.Sprintln("Not an LSP", hdr.PduType))
+	}
+	pduLen, err := getPduLen(data, 8)
+	if err != nil {
+		return nil, err
+	}
+	lsp := &Lsp{}
+	lsp.Level = Level1
+	if hdr.PduType == L2Lsp {
+		lsp.Level = Level2
+	}
+	lsp.RemainingLifetime = binary.BigEndian.Uint16(data[LSP_LIFETIME_OFFSET:])
+	copy(lsp.LspId[:], data[LSP_ID_OFFSET:LSP_ID_OFFSET+8])
+	lsp.SeqNum = binary.BigEndian.Uint32(data[20:24])
+	lsp.Checksum = binary.BigEndian.Uint16(data[LSP_CHECKSUM_OFFSET:])
+	flags := data[LSP_FLAGS_OFFSET]
+	lsp.Attached = flags&LSP_FLAG_ATT != 0
+	lsp.Overload = flags&LSP_FLAG_OVERLOAD != 0
+	lsp.IsType = flags & 0x03
+	if lsp.RemainingLifetime == 0 {
+		return lsp, nil
+	}
+	if lsp.Checksum == 0 || !verifyFletcherChecksum(data[LSP_ID_OFFSET:pduLen]) {
+		return nil, errors.New(fmt.Sprintln("Invalid checksum for LSP", lsp.LspId.String()))
+	}
+	tlvs, err := decodeTlvs(data[ISIS_LSP_HDR_LEN:pduLen])
+	if err != nil {
+		return nil, err
+	}
+	lsp.Tlvs = *tlvs
+	return lsp, nil
+}
+
+/*@fn SetLspLifetime
+Remaining lifetime is not covered by the checksum and is
+updated in place before flooding the stored LSP.
+*/
+func SetLspLifetime(data []byte, lifetime uint16) {
+	binary.BigEndian.PutUint16(data[LSP_LIFETIME_OFFSET:], lifetime)
+}
+
+type Csnp struct {
+	Level      uint8
+	SourceId   NodeId
+	StartLspId LspId
+	EndLspId   LspId
+	Tlvs
+}
+
+type Psnp struct {
+	Level    uint8
+	SourceId NodeId
+	Tlvs
+}
+
+func EncodeCsnp(csnp *Csnp) []byte {
+	pduType := L1Csnp
+	if csnp.Level == Level2 {
+		pduType = L2Csnp
+	}
+	data := make([]byte, ISIS_CSNP_HDR_LEN)
+	encodeCommonHdr(data, ISIS_CSNP_HDR_LEN, pduType)
+	copy(data[10:17], csnp.SourceId[:])
+	copy(data[17:25], csnp.StartLspId[:])
+	copy(data[25:33], csnp.EndLspId[:])
+	data = append(data, encodeTlvs(&csnp.Tlvs)...)
+	binary.BigEndian.PutUint16(data[8:10], uint16(len(data)))
+	return data
+}
+
+func DecodeCsnp(data []byte) (*Csnp, error) {
+	hdr, err := DecodeCommonHdr(data)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.PduType != L1Csnp && hdr.PduType != L2Csnp {
+		return nil, errors.New(fmt.Sprintln("Not a CSNP", hdr.PduType))
+	}
+	pduLen, err := getPduLen(data, 8)
+	if err != nil {
+		return nil, err
+	}
+	csnp := &Csnp{}
+	csnp.Level = Level1
+	if hdr.PduType == L2Csnp {
+		csnp.Level = Level2
+	}
+	copy(csnp.SourceId[:], data[10:17])
+	copy(csnp.StartLspId[:], data[17:25])
+	copy(csnp.EndLspId[:], data[25:33])
+	tlvs, err := decodeTlvs(data[ISIS_CSNP_HDR_LEN:pduLen])
+	if err != nil {
+		return nil, err
+	}
+	csnp.Tlvs = *tlvs
+	return csnp, nil
+}
+
+func EncodePsnp(psnp *Psnp) []byte {
+	pduType := L1Psnp
+	if psnp.Level == Level2 {
+		pduType = L2Psnp
+	}
+	data := make([]byte, ISIS_PSNP_HDR_LEN)
+	encodeCommonHdr(data, ISIS_PSNP_HDR_LEN, pduType)
+	copy(data[10:17], psnp.SourceId[:])
+	data = append(data, encodeTlvs(&psnp.Tlvs)...)
+	binary.BigEndian.PutUint16(data[8:10], uint16(len(data)))
+	return data
+}
+
+func DecodePsnp(data []byte) (*Psnp, error) {
+	hdr, err := DecodeCommonHdr(data)
+	if err != nil {
+		return nil, err
+	}
+	if hdr.PduType != L1Psnp && hdr.PduType != L2Psnp {
+		return nil, errors.New(fmt.Sprintln("Not a PSNP", hdr.PduType))
+	}
+	pduLen, err := getPduLen(data, 8)
+	if err != nil {
+		return nil, err
+	}
+	psnp := &Psnp{}
+	psnp.Level = Level1
+	if hdr.PduType == L2Psnp {
+		psnp.Level = Level2
+	}
+	copy(psnp.SourceId[:], data[10:17])
+	tlvs, err := decodeTlvs(data[ISIS_PSNP_HDR_LEN:pduLen])
+	if err != nil {
+		return nil, err
+	}
+	psnp.Tlvs = *tlvs
+	return psnp, nil
+}
+
+/* Number of LSP entries that fit in one SNP */
+func MaxSnpEntries(hdrLen int) int {
+	perTlv := TLV_MAX_LEN / LSP_ENTRY_LEN
+	tlvLen := TLV_HDR_LEN + perTlv*LSP_ENTRY_LEN
+	space := ISIS_MAX_PDU_LEN - hdrLen
+	return (space / tlvLen) * perTlv
+}
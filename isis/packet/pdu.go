@@ -0,0 +1,294 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+/*
+IS-IS PDUs (ISO 10589) carried in 802.3 frames with LLC header.
+Only 6 byte system id and wide metrics are supported.
+*/
+
+const (
+	ISIS_PROTO_DISCRIMINATOR = 0x83
+	ISIS_VERSION             = 1
+	ISIS_ID_LEN              = 6
+	ISIS_COMMON_HDR_LEN      = 8
+	ISIS_LAN_IIH_HDR_LEN     = 27
+	ISIS_P2P_IIH_HDR_LEN     = 20
+	ISIS_LSP_HDR_LEN         = 27
+	ISIS_CSNP_HDR_LEN        = 33
+	ISIS_PSNP_HDR_LEN        = 17
+	ISIS_MAX_PDU_LEN         = 1492
+	ISIS_LLC_SAP             = 0xFE
+	ISIS_LLC_CTRL            = 0x03
+	ETH_HDR_LEN              = 14
+	LLC_HDR_LEN              = 3
+)
+
+type PduType uint8
+
+const (
+	L1LanIIH PduType = 15
+	L2LanIIH PduType = 16
+	P2PIIH   PduType = 17
+	L1Lsp    PduType = 18
+	L2Lsp    PduType = 20
+	L1Csnp   PduType = 24
+	L2Csnp   PduType = 25
+	L1Psnp   PduType = 26
+	L2Psnp   PduType = 27
+)
+
+/* Circuit type and IS type */
+const (
+	Level1   uint8 = 1
+	Level2   uint8 = 2
+	Level1_2 uint8 = 3
+)
+
+/* Network layer protocol ids */
+const (
+	NLPID_IPV4 = 0xCC
+	NLPID_IPV6 = 0x8E
+)
+
+var (
+	AllL1ISs = net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x14}
+	AllL2ISs = net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x15}
+	AllISs   = net.HardwareAddr{0x09, 0x00, 0x2B, 0x00, 0x00, 0x05}
+)
+
+type SystemId [ISIS_ID_LEN]byte
+
+/* System id followed by pseudonode id */
+type NodeId [ISIS_ID_LEN + 1]byte
+
+/* Node id followed by fragment number */
+type LspId [ISIS_ID_LEN + 2]byte
+
+func (id SystemId) String() string {
+	return fmt.Sprintf("%02x%02x.%02x%02x.%02x%02x", id[0], id[1], id[2], id[3], id[4], id[5])
+}
+
+func (id NodeId) String() string {
+	return fmt.Sprintf("%s.%02x", id.SystemId().String(), id[ISIS_ID_LEN])
+}
+
+func (id NodeId) SystemId() (sysId SystemId) {
+	copy(sysId[:], id[:ISIS_ID_LEN])
+	return sysId
+}
+
+func (id NodeId) IsPseudonode() bool {
+	return id[ISIS_ID_LEN] != 0
+}
+
+func (id LspId) String() string {
+	return fmt.Sprintf("%s-%02x", id.NodeId().String(), id[ISIS_ID_LEN+1])
+}
+
+func (id LspId) NodeId() (nodeId NodeId) {
+	copy(nodeId[:], id[:ISIS_ID_LEN+1])
+	return nodeId
+}
+
+func (id LspId) SystemId() SystemId {
+	return id.NodeId().SystemId()
+}
+
+func MakeNodeId(sysId SystemId, pnId uint8) (nodeId NodeId) {
+	copy(nodeId[:], sysId[:])
+	nodeId[ISIS_ID_LEN] = pnId
+	return nodeId
+}
+
+func MakeLspId(nodeId NodeId, fragment uint8) (lspId LspId) {
+	copy(lspId[:], nodeId[:])
+	lspId[ISIS_ID_LEN+1] = fragment
+	return lspId
+}
+
+/*@fn ParseSystemId
+Parse system id in "xxxx.xxxx.xxxx" format
+*/
+func ParseSystemId(str string) (sysId SystemId, err error) {
+	str = strings.Replace(str, ".", "", -1)
+	if len(str) != 2*ISIS_ID_LEN {
+		return sysId, errors.New(fmt.Sprintln("Invalid system id", str))
+	}
+	for i := 0; i < ISIS_ID_LEN; i++ {
+		val, err := strconv.ParseUint(str[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return sysId, errors.New(fmt.Sprintln("Invalid system id", str))
+		}
+		sysId[i] = byte(val)
+	}
+	return sysId, nil
+}
+
+/*@fn ParseAreaAddress
+Parse area address in "49.0001" format
+*/
+func ParseAreaAddress(str string) ([]byte, error) {
+	str = strings.Replace(str, ".", "", -1)
+	if len(str) == 0 || len(str)%2 != 0 || len(str) > 26 {
+		return nil, errors.New(fmt.Sprintln("Invalid area address", str))
+	}
+	area := make([]byte, len(str)/2)
+	for i := 0; i < len(area); i++ {
+		val, err := strconv.ParseUint(str[2*i:2*i+2], 16, 8)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintln("Invalid area address", str))
+		}
+		area[i] = byte(val)
+	}
+	return area, nil
+}
+
+func AreaAddressString(area []byte) string {
+	if len(area) == 0 {
+		return ""
+	}
+	str := fmt.Sprintf("%02x", area[0])
+	for i := 1; i < len(area); i += 2 {
+		str += "."
+		for j := i; j < i+2 && j < len(area); j++ {
+			str += fmt.Sprintf("%02x", area[j])
+		}
+	}
+	return str
+}
+
+type CommonHdr struct {
+	HdrLen  uint8
+	PduType PduType
+	MaxArea uint8
+}
+
+func encodeCommonHdr(data []byte, hdrLen uint8, pduType PduType) {
+	data[0] = ISIS_PROTO_DISCRIMINATOR
+	data[1] = hdrLen
+	data[2] = ISIS_VERSION
+	data[3] = 0 // 6 byte system id
+	data[4] = uint8(pduType)
+	data[5] = ISIS_VERSION
+	data[6] = 0
+	data[7] = 0 // 3 area addresses
+}
+
+/*@fn DecodeCommonHdr
+Validate the common header and return the PDU type
+*/
+func DecodeCommonHdr(data []byte) (hdr CommonHdr, err error) {
+	if len(data) < ISIS_COMMON_HDR_LEN {
+		return hdr, errors.New("Short IS-IS PDU")
+	}
+	if data[0] != ISIS_PROTO_DISCRIMINATOR {
+		return hdr, errors.New(fmt.Sprintln("Invalid protocol discriminator", data[0]))
+	}
+	if data[2] != ISIS_VERSION || data[5] != ISIS_VERSION {
+		return hdr, errors.New(fmt.Sprintln("Unsupported version", data[2], data[5]))
+	}
+	if data[3] != 0 && data[3] != ISIS_ID_LEN {
+		return hdr, errors.New(fmt.Sprintln("Unsupported id length", data[3]))
+	}
+	hdr.HdrLen = data[1]
+	hdr.PduType = PduType(data[4] & 0x1F)
+	hdr.MaxArea = data[7]
+	if hdr.MaxArea == 0 {
+		hdr.MaxArea = 3
+	}
+	var expLen uint8
+	switch hdr.PduType {
+	case L1LanIIH, L2LanIIH:
+		expLen = ISIS_LAN_IIH_HDR_LEN
+	case P2PIIH:
+		expLen = ISIS_P2P_IIH_HDR_LEN
+	case L1Lsp, L2Lsp:
+		expLen = ISIS_LSP_HDR_LEN
+	case L1Csnp, L2Csnp:
+		expLen = ISIS_CSNP_HDR_LEN
+	case L1Psnp, L2Psnp:
+		expLen = ISIS_PSNP_HDR_LEN
+	default:
+		return hdr, errors.New(fmt.Sprintln("Unknown PDU type", hdr.PduType))
+	}
+	if hdr.HdrLen != expLen || len(data) < int(expLen) {
+		return hdr, errors.New(fmt.Sprintln("Invalid header length", hdr.HdrLen, "for PDU type", hdr.PduType))
+	}
+	return hdr, nil
+}
+
+/* PDU length field follows the fixed part of each PDU type */
+func getPduLen(data []byte, offset int) (int, error) {
+	pduLen := int(binary.BigEndian.Uint16(data[offset : offset+2]))
+	if pduLen > len(data) || pduLen < offset+2 {
+		return 0, errors.New(fmt.Sprintln("Invalid PDU length", pduLen, "received", len(data)))
+	}
+	return pduLen, nil
+}
+
+/*@fn EncodeFrame
+Add 802.3 and LLC headers to the PDU
+*/
+func EncodeFrame(dstMac net.HardwareAddr, srcMac net.HardwareAddr, pdu []byte) []byte {
+	frame := make([]byte, ETH_HDR_LEN+LLC_HDR_LEN+len(pdu))
+	copy(frame[0:6], dstMac)
+	copy(frame[6:12], srcMac)
+	binary.BigEndian.PutUint16(frame[12:14], uint16(LLC_HDR_LEN+len(pdu)))
+	frame[14] = ISIS_LLC_SAP
+	frame[15] = ISIS_LLC_SAP
+	frame[16] = ISIS_LLC_CTRL
+	copy(frame[ETH_HDR_LEN+LLC_HDR_LEN:], pdu)
+	return frame
+}
+
+/*@fn DecodeFrame
+Strip the 802.3 and LLC headers. Returns the source mac and the PDU
+*/
+func DecodeFrame(frame []byte) (dstMac net.HardwareAddr, srcMac net.HardwareAddr, pdu []byte, err error) {
+	if len(frame) < ETH_HDR_LEN+LLC_HDR_LEN+ISIS_COMMON_HDR_LEN {
+		return nil, nil, nil, errors.New("Short frame")
+	}
+	length := int(binary.BigEndian.Uint16(frame[12:14]))
+	if length > 1500 || length < LLC_HDR_LEN+ISIS_COMMON_HDR_LEN ||
+		ETH_HDR_LEN+length > len(frame) {
+		return nil, nil, nil, errors.New(fmt.Sprintln("Not an 802.3 frame or invalid length", length))
+	}
+	if frame[14] != ISIS_LLC_SAP || frame[15] != ISIS_LLC_SAP || frame[16] != ISIS_LLC_CTRL {
+		return nil, nil, nil, errors.New("Not an IS-IS LLC frame")
+	}
+	dstMac = net.HardwareAddr(frame[0:6])
+	srcMac = net.HardwareAddr(frame[6:12])
+	pdu = frame[ETH_HDR_LEN+LLC_HDR_LEN : ETH_HDR_LEN+length]
+	return dstMac, srcMac, pdu, nil
+}
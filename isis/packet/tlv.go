@@ -0,0 +1,443 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package packet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+const (
+	TLV_AREA_ADDRESSES      = 1
+	TLV_IS_NEIGHBORS        = 6
+	TLV_PADDING             = 8
+	TLV_LSP_ENTRIES         = 9
+	TLV_EXT_IS_REACH        = 22
+	TLV_PROTOCOLS_SUPPORTED = 129
+	TLV_IPV4_INTF_ADDR      = 132
+	TLV_EXT_IP_REACH        = 135
+	TLV_HOSTNAME            = 137
+	TLV_IPV6_INTF_ADDR      = 232
+	TLV_IPV6_REACH          = 236
+	TLV_P2P_ADJ_STATE       = 240
+)
+
+const (
+	TLV_HDR_LEN       = 2
+	TLV_MAX_LEN       = 255
+	LSP_ENTRY_LEN     = 16
+	EXT_IS_REACH_LEN  = 11
+	MAX_WIDE_METRIC   = 0xFE000000 // Max path metric (RFC 5305)
+	MAX_LINK_METRIC   = 0xFFFFFF
+	MAX_PREFIX_METRIC = 0xFE000000
+)
+
+/* Three way adjacency states (RFC 5303) */
+const (
+	P2PAdjUp   uint8 = 0
+	P2PAdjInit uint8 = 1
+	P2PAdjDown uint8 = 2
+)
+
+type LspEntry struct {
+	RemainingLifetime uint16
+	LspId             LspId
+	SeqNum            uint32
+	Checksum          uint16
+}
+
+type ExtIsReach struct {
+	NbrId  NodeId
+	Metric uint32 // 24 bit metric
+}
+
+type ExtIPReach struct {
+	Metric uint32
+	Down   bool
+	Prefix net.IPNet
+}
+
+type IPv6Reach struct {
+	Metric   uint32
+	Down     bool
+	External bool
+	Prefix   net.IPNet
+}
+
+type P2PAdjState struct {
+	State          uint8
+	LocalCircuitId uint32
+	HasNbr         bool
+	NbrSysId       SystemId
+	NbrCircuitId   uint32
+}
+
+type Tlvs struct {
+	AreaAddresses      [][]byte
+	IsNeighbors        []net.HardwareAddr
+	LspEntries         []LspEntry
+	ExtIsReach         []ExtIsReach
+	ProtocolsSupported []uint8
+	IPv4IntfAddrs      []net.IP
+	ExtIPReach         []ExtIPReach
+	Hostname           string
+	IPv6IntfAddrs      []net.IP
+	IPv6Reach          []IPv6Reach
+	P2PAdj             *P2PAdjState
+}
+
+/*
+Items of a list TLV are packed into as many TLVs
+as needed to keep each TLV within 255 bytes.
+*/
+func appendTlvItems(data []byte, tlvType uint8, items [][]byte) []byte {
+	var value []byte
+	for _, item := range items {
+		if len(value)+len(item) > TLV_MAX_LEN {
+			data = append(data, tlvType, uint8(len(value)))
+			data = append(data, value...)
+			value = nil
+		}
+		value = append(value, item...)
+	}
+	if len(value) > 0 {
+		data = append(data, tlvType, uint8(len(value)))
+		data = append(data, value...)
+	}
+	return data
+}
+
+func prefixBytes(prefix net.IPNet) (ip net.IP, prefixLen int) {
+	prefixLen, _ = prefix.Mask.Size()
+	if ip4 := prefix.IP.To4(); ip4 != nil && len(prefix.Mask) == net.IPv4len {
+		ip = ip4
+	} else {
+		ip = prefix.IP.To16()
+	}
+	return ip[:(prefixLen+7)/8], prefixLen
+}
+
+func encodeTlvs(tlvs *Tlvs) []byte {
+	var data []byte
+	var items [][]byte
+
+	items = nil
+	for _, area := range tlvs.AreaAddresses {
+		item := append([]byte{uint8(len(area))}, area...)
+		items = append(items, item)
+	}
+	data = appendTlvItems(data, TLV_AREA_ADDRESSES, items)
+
+	if len(tlvs.ProtocolsSupported) > 0 {
+		data = append(data, TLV_PROTOCOLS_SUPPORTED, uint8(len(tlvs.ProtocolsSupported)))
+		data = append(data, tlvs.ProtocolsSupported...)
+	}
+
+	if len(tlvs.Hostname) > 0 {
+		hostname := tlvs.Hostname
+		if len(hostname) > TLV_MAX_LEN {
+			hostname = hostname[:TLV_MAX_LEN]
+		}
+		data = append(data, TLV_HOSTNAME, uint8(len(hostname)))
+		data = append(data, []byte(hostname)...)
+	}
+
+	if tlvs.P2PAdj != nil {
+		adj := tlvs.P2PAdj
+		value := []byte{adj.State, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(value[1:5], adj.LocalCircuitId)
+		if adj.HasNbr {
+			value = append(value, adj.NbrSysId[:]...)
+			value = append(value, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(value[11:15], adj.NbrCircuitId)
+		}
+		data = append(data, TLV_P2P_ADJ_STATE, uint8(len(value)))
+		data = append(data, value...)
+	}
+
+	items = nil
+	for _, mac := range tlvs.IsNeighbors {
+		items = append(items, []byte(mac))
+	}
+	data = appendTlvItems(data, TLV_IS_NEIGHBORS, items)
+
+	items = nil
+	for _, ip := range tlvs.IPv4IntfAddrs {
+		if ip4 := ip.To4(); ip4 != nil {
+			items = append(items, []byte(ip4))
+		}
+	}
+	data = appendTlvItems(data, TLV_IPV4_INTF_ADDR, items)
+
+	items = nil
+	for _, ip := range tlvs.IPv6IntfAddrs {
+		items = append(items, []byte(ip.To16()))
+	}
+	data = appendTlvItems(data, TLV_IPV6_INTF_ADDR, items)
+
+	items = nil
+	for _, ent := range tlvs.LspEntries {
+		item := make([]byte, LSP_ENTRY_LEN)
+		binary.BigEndian.PutUint16(item[0:2], ent.RemainingLifetime)
+		copy(item[2:10], ent.LspId[:])
+		binary.BigEndian.PutUint32(item[10:14], ent.SeqNum)
+		binary.BigEndian.PutUint16(item[14:16], ent.Checksum)
+		items = append(items, item)
+	}
+	data = appendTlvItems(data, TLV_LSP_ENTRIES, items)
+
+	items = nil
+	for _, ent := range tlvs.ExtIsReach {
+		item := make([]byte, EXT_IS_REACH_LEN)
+		copy(item[0:7], ent.NbrId[:])
+		item[7] = uint8(ent.Metric >> 16)
+		item[8] = uint8(ent.Metric >> 8)
+		item[9] = uint8(ent.Metric)
+		item[10] = 0 // No sub TLVs
+		items = append(items, item)
+	}
+	data = appendTlvItems(data, TLV_EXT_IS_REACH, items)
+
+	items = nil
+	for _, ent := range tlvs.ExtIPReach {
+		ip, prefixLen := prefixBytes(ent.Prefix)
+		item := make([]byte, 5)
+		binary.BigEndian.PutUint32(item[0:4], ent.Metric)
+		item[4] = uint8(prefixLen)
+		if ent.Down {
+			item[4] |= 0x80
+		}
+		item = append(item, ip...)
+		items = append(items, item)
+	}
+	data = appendTlvItems(data, TLV_EXT_IP_REACH, items)
+
+	items = nil
+	for _, ent := range tlvs.IPv6Reach {
+		ip, prefixLen := prefixBytes(ent.Prefix)
+		item := make([]byte, 6)
+		binary.BigEndian.PutUint32(item[0:4], ent.Metric)
+		if ent.Down {
+			item[4] |= 0x80
+		}
+		if ent.External {
+			item[4] |= 0x40
+		}
+		item[5] = uint8(prefixLen)
+		item = append(item, ip...)
+		items = append(items, item)
+	}
+	data = appendTlvItems(data, TLV_IPV6_REACH, items)
+
+	return data
+}
+
+func decodePrefix(value []byte, prefixLen int, ipLen int) (prefix net.IPNet, n int, err error) {
+	if prefixLen > ipLen*8 {
+		return prefix, 0, errors.New(fmt.Sprintln("Invalid prefix length", prefixLen))
+	}
+	n = (prefixLen + 7) / 8
+	if n > len(value) {
+		return prefix, 0, errors.New("Truncated prefix")
+	}
+	ip := make(net.IP, ipLen)
+	copy(ip, value[:n])
+	prefix.Mask = net.CIDRMask(prefixLen, ipLen*8)
+	prefix.IP = ip.Mask(prefix.Mask)
+	return prefix, n, nil
+}
+
+func decodeTlv(tlvs *Tlvs, tlvType uint8, value []byte) error {
+	switch tlvType {
+	case TLV_AREA_ADDRESSES:
+		for len(value) > 0 {
+			areaLen := int(value[0])
+			if areaLen == 0 || 1+areaLen > len(value) {
+				return errors.New("Invalid area address TLV")
+			}
+			area := make([]byte, areaLen)
+			copy(area, value[1:1+areaLen])
+			tlvs.AreaAddresses = append(tlvs.AreaAddresses, area)
+			value = value[1+areaLen:]
+		}
+	case TLV_IS_NEIGHBORS:
+		if len(value)%6 != 0 {
+			return errors.New("Invalid IS neighbors TLV")
+		}
+		for i := 0; i < len(value); i += 6 {
+			mac := make(net.HardwareAddr, 6)
+			copy(mac, value[i:i+6])
+			tlvs.IsNeighbors = append(tlvs.IsNeighbors, mac)
+		}
+	case TLV_LSP_ENTRIES:
+		if len(value)%LSP_ENTRY_LEN != 0 {
+			return errors.New("Invalid LSP entries TLV")
+		}
+		for i := 0; i < len(value); i += LSP_ENTRY_LEN {
+			var ent LspEntry
+			ent.RemainingLifetime = binary.BigEndian.Uint16(value[i : i+2])
+			copy(ent.LspId[:], value[i+2:i+10])
+			ent.SeqNum = binary.BigEndian.Uint32(value[i+10 : i+14])
+			ent.Checksum = binary.BigEndian.Uint16(value[i+14 : i+16])
+			tlvs.LspEntries = append(tlvs.LspEntries, ent)
+		}
+	case TLV_EXT_IS_REACH:
+		for len(value) > 0 {
+			if len(value) < EXT_IS_REACH_LEN {
+				return errors.New("Invalid extended IS reachability TLV")
+			}
+			var ent ExtIsReach
+			copy(ent.NbrId[:], value[0:7])
+			ent.Metric = uint32(value[7])<<16 | uint32(value[8])<<8 | uint32(value[9])
+			subLen := int(value[10])
+			if EXT_IS_REACH_LEN+subLen > len(value) {
+				return errors.New("Invalid extended IS reachability sub TLV")
+			}
+			tlvs.ExtIsReach = append(tlvs.ExtIsReach, ent)
+			value = value[EXT_IS_REACH_LEN+subLen:]
+		}
+	case TLV_PROTOCOLS_SUPPORTED:
+		tlvs.ProtocolsSupported = append(tlvs.ProtocolsSupported, value...)
+	case TLV_IPV4_INTF_ADDR:
+		if len(value)%net.IPv4len != 0 {
+			return errors.New("Invalid IPv4 interface address TLV")
+		}
+		for i := 0; i < len(value); i += net.IPv4len {
+			tlvs.IPv4IntfAddrs = append(tlvs.IPv4IntfAddrs, net.IPv4(value[i], value[i+1], value[i+2], value[i+3]))
+		}
+	case TLV_EXT_IP_REACH:
+		for len(value) > 0 {
+			if len(value) < 5 {
+				return errors.New("Invalid extended IP reachability TLV")
+			}
+			var ent ExtIPReach
+			ent.Metric = binary.BigEndian.Uint32(value[0:4])
+			ent.Down = value[4]&0x80 != 0
+			subTlv := value[4]&0x40 != 0
+			prefix, n, err := decodePrefix(value[5:], int(value[4]&0x3F), net.IPv4len)
+			if err != nil {
+				return err
+			}
+			ent.Prefix = prefix
+			value = value[5+n:]
+			if subTlv {
+				if len(value) < 1 || 1+int(value[0]) > len(value) {
+					return errors.New("Invalid extended IP reachability sub TLV")
+				}
+				value = value[1+int(value[0]):]
+			}
+			tlvs.ExtIPReach = append(tlvs.ExtIPReach, ent)
+		}
+	case TLV_HOSTNAME:
+		tlvs.Hostname = string(value)
+	case TLV_IPV6_INTF_ADDR:
+		if len(value)%net.IPv6len != 0 {
+			return errors.New("Invalid IPv6 interface address TLV")
+		}
+		for i := 0; i < len(value); i += net.IPv6len {
+			ip := make(net.IP, net.IPv6len)
+			copy(ip, value[i:i+net.IPv6len])
+			tlvs.IPv6IntfAddrs = append(tlvs.IPv6IntfAddrs, ip)
+		}
+	case TLV_IPV6_REACH:
+		for len(value) > 0 {
+			if len(value) < 6 {
+				return errors.New("Invalid IPv6 reachability TLV")
+			}
+			var ent IPv6Reach
+			ent.Metric = binary.BigEndian.Uint32(value[0:4])
+			ent.Down = value[4]&0x80 != 0
+			ent.External = value[4]&0x40 != 0
+			subTlv := value[4]&0x20 != 0
+			prefix, n, err := decodePrefix(value[6:], int(value[5]), net.IPv6len)
+			if err != nil {
+				return err
+			}
+			ent.Prefix = prefix
+			value = value[6+n:]
+			if subTlv {
+				if len(value) < 1 || 1+int(value[0]) > len(value) {
+					return errors.New("Invalid IPv6 reachability sub TLV")
+				}
+				value = value[1+int(value[0]):]
+			}
+			tlvs.IPv6Reach = append(tlvs.IPv6Reach, ent)
+		}
+	case TLV_P2P_ADJ_STATE:
+		if len(value) != 1 && len(value) != 5 && len(value) != 11 && len(value) != 15 {
+			return errors.New("Invalid P2P adjacency state TLV")
+		}
+		adj := &P2PAdjState{
+			State: value[0],
+		}
+		if len(value) >= 5 {
+			adj.LocalCircuitId = binary.BigEndian.Uint32(value[1:5])
+		}
+		if len(value) >= 11 {
+			adj.HasNbr = true
+			copy(adj.NbrSysId[:], value[5:11])
+		}
+		if len(value) == 15 {
+			adj.NbrCircuitId = binary.BigEndian.Uint32(value[11:15])
+		}
+		tlvs.P2PAdj = adj
+	}
+	// Unknown TLVs are ignored
+	return nil
+}
+
+func decodeTlvs(data []byte) (*Tlvs, error) {
+	tlvs := &Tlvs{}
+	for len(data) > 0 {
+		if len(data) < TLV_HDR_LEN {
+			return nil, errors.New("Truncated TLV header")
+		}
+		tlvType := data[0]
+		tlvLen := int(data[1])
+		if TLV_HDR_LEN+tlvLen > len(data) {
+			return nil, errors.New(fmt.Sprintln("Truncated TLV", tlvType, "length", tlvLen))
+		}
+		err := decodeTlv(tlvs, tlvType, data[TLV_HDR_LEN:TLV_HDR_LEN+tlvLen])
+		if err != nil {
+			return nil, err
+		}
+		data = data[TLV_HDR_LEN+tlvLen:]
+	}
+	return tlvs, nil
+}
+
+/* Padding TLVs to fill the hello upto pduLen */
+func appendPadding(data []byte, pduLen int) []byte {
+	for len(data)+TLV_HDR_LEN <= pduLen {
+		padLen := pduLen - len(data) - TLV_HDR_LEN
+		if padLen > TLV_MAX_LEN {
+			padLen = TLV_MAX_LEN
+		}
+		data = append(data, TLV_PADDING, uint8(padLen))
+		data = append(data, make([]byte, padLen)...)
+	}
+	return data
+}
@@ -26,16 +26,19 @@ package rpc
 import (
     "isisd"
     "git.apache.org/thrift.git/lib/go/thrift"
+    "l3/isis/server"
     "utils/logging"
 )
 
 type rpcServiceHandler struct {
     logger logging.LoggerIntf
+    server *server.DmnServer
 }
 
-func newRPCServiceHandler(logger logging.LoggerIntf) *rpcServiceHandler {
+func newRPCServiceHandler(logger logging.LoggerIntf, srvr *server.DmnServer) *rpcServiceHandler {
     return &rpcServiceHandler{
         logger: logger,
+        server: srvr,
     }
 }
 
@@ -43,12 +46,12 @@ type RPCServer struct {
     *thrift.TSimpleServer
 }
 
-func NewRPCServer(rpcAddr string, logger logging.LoggerIntf) *RPCServer {
+func NewRPCServer(rpcAddr string, logger logging.LoggerIntf, srvr *server.DmnServer) *RPCServer {
         transport, err := thrift.NewTServerSocket(rpcAddr)
         if err != nil {
                 panic(err)
         }
-        handler := newRPCServiceHandler(logger)
+        handler := newRPCServiceHandler(logger, srvr)
         processor := isisd.NewISISDServicesProcessor(handler)
         transportFactory := thrift.NewTBufferedTransportFactory(8192)
         protocolFactory := thrift.NewTBinaryProtocolFactoryDefault()
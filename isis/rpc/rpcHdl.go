@@ -24,38 +24,289 @@
 package rpc
 
 import (
-        "isisd"
+	"errors"
+	"fmt"
+	"isisd"
+	"l3/isis/server"
 )
 
+func (rpcHdl *rpcServiceHandler) sendReq(op server.ServerOp, data interface{}) interface{} {
+	replyCh := make(chan interface{})
+	rpcHdl.server.ReqChan <- &server.ServerRequest{
+		Op:      op,
+		Data:    data,
+		ReplyCh: replyCh,
+	}
+	return <-replyCh
+}
+
+func (rpcHdl *rpcServiceHandler) sendCfgReq(op server.ServerOp, data interface{}) (bool, error) {
+	err, _ := rpcHdl.sendReq(op, data).(error)
+	if err != nil {
+		rpcHdl.logger.Err("IS-IS config failed", err)
+		return false, err
+	}
+	return true, nil
+}
+
+func convertGlobalConfig(cfg *isisd.IsisGlobal) (server.GlobalConfig, error) {
+	isType, err := server.ConvertIsType(cfg.IsType)
+	if err != nil {
+		return server.GlobalConfig{}, err
+	}
+	return server.GlobalConfig{
+		Vrf:                cfg.Vrf,
+		Enable:             cfg.Enable,
+		SystemId:           cfg.SystemId,
+		AreaId:             cfg.AreaId,
+		IsType:             isType,
+		Hostname:           cfg.Hostname,
+		LspLifetime:        uint16(cfg.LspLifetime),
+		LspRefreshInterval: uint16(cfg.LspRefreshInterval),
+		Overload:           cfg.Overload,
+	}, nil
+}
+
+func convertIntfConfig(cfg *isisd.IsisIntf) (server.IntfConfig, error) {
+	circuitType, err := server.ConvertIsType(cfg.CircuitType)
+	if err != nil {
+		return server.IntfConfig{}, err
+	}
+	networkType, err := server.ConvertNetworkType(cfg.NetworkType)
+	if err != nil {
+		return server.IntfConfig{}, err
+	}
+	return server.IntfConfig{
+		IntfRef:         cfg.IntfRef,
+		Enable:          cfg.Enable,
+		CircuitType:     circuitType,
+		NetworkType:     networkType,
+		HelloInterval:   uint16(cfg.HelloInterval),
+		HelloMultiplier: uint16(cfg.HelloMultiplier),
+		Priority:        uint8(cfg.Priority),
+		Metric:          uint32(cfg.Metric),
+		Passive:         cfg.Passive,
+	}, nil
+}
+
 func (rpcHdl *rpcServiceHandler) CreateIsisGlobal(cfg *isisd.IsisGlobal) (bool, error) {
-        rpcHdl.logger.Info("Calling CreateIsisGlobal", cfg)
-        return true, nil
+	rpcHdl.logger.Info("Calling CreateIsisGlobal", cfg)
+	globalCfg, err := convertGlobalConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+	return rpcHdl.sendCfgReq(server.CREATE_GLOBAL, globalCfg)
 }
 
 func (rpcHdl *rpcServiceHandler) UpdateIsisGlobal(oldCfg, newCfg *isisd.IsisGlobal, attrset []bool, op []*isisd.PatchOpInfo) (bool, error) {
-        rpcHdl.logger.Info("Calling UpdateIsisGlobal", oldCfg, newCfg)
-        return true, nil
+	rpcHdl.logger.Info("Calling UpdateIsisGlobal", oldCfg, newCfg)
+	globalCfg, err := convertGlobalConfig(newCfg)
+	if err != nil {
+		return false, err
+	}
+	return rpcHdl.sendCfgReq(server.UPDATE_GLOBAL, globalCfg)
+}
+
+func (rpcHdl *rpcServiceHandler) DeleteIsisGlobal(cfg *isisd.IsisGlobal) (bool, error) {
+	rpcHdl.logger.Info("Calling DeleteIsisGlobal", cfg)
+	return rpcHdl.sendCfgReq(server.DELETE_GLOBAL, server.GlobalConfig{Vrf: cfg.Vrf})
 }
 
-func (rpcHdl rpcServiceHandler) DeleteIsisGlobal(cfg *isisd.IsisGlobal) (bool, error) {
-        rpcHdl.logger.Info("Calling DeleteIsisGlobal", cfg)
-        return true, nil
+func (rpcHdl *rpcServiceHandler) CreateIsisIntf(cfg *isisd.IsisIntf) (bool, error) {
+	rpcHdl.logger.Info("Calling CreateIsisIntf", cfg)
+	intfCfg, err := convertIntfConfig(cfg)
+	if err != nil {
+		return false, err
+	}
+	return rpcHdl.sendCfgReq(server.CREATE_INTF, intfCfg)
 }
 
-func (rpcHdl *rpcServiceHandler) GetIsisGlobalState(key string) (obj *isisd.IsisGlobalState, err error) {
-        rpcHdl.logger.Info("Calling GetIsisGlobalState", key)
-        return obj, err
+func (rpcHdl *rpcServiceHandler) UpdateIsisIntf(oldCfg, newCfg *isisd.IsisIntf, attrset []bool, op []*isisd.PatchOpInfo) (bool, error) {
+	rpcHdl.logger.Info("Calling UpdateIsisIntf", oldCfg, newCfg)
+	intfCfg, err := convertIntfConfig(newCfg)
+	if err != nil {
+		return false, err
+	}
+	return rpcHdl.sendCfgReq(server.UPDATE_INTF, intfCfg)
+}
+
+func (rpcHdl *rpcServiceHandler) DeleteIsisIntf(cfg *isisd.IsisIntf) (bool, error) {
+	rpcHdl.logger.Info("Calling DeleteIsisIntf", cfg)
+	return rpcHdl.sendCfgReq(server.DELETE_INTF, server.IntfConfig{IntfRef: cfg.IntfRef})
+}
+
+func (rpcHdl *rpcServiceHandler) GetIsisGlobalState(vrf string) (*isisd.IsisGlobalState, error) {
+	rpcHdl.logger.Info("Calling GetIsisGlobalState", vrf)
+	state := rpcHdl.sendReq(server.GET_GLOBAL_STATE, nil).(server.GlobalState)
+	obj := isisd.NewIsisGlobalState()
+	obj.Vrf = state.Vrf
+	obj.SystemId = state.SystemId
+	obj.AreaId = state.AreaId
+	obj.IsType = state.IsType
+	obj.Hostname = state.Hostname
+	obj.Attached = state.Attached
+	obj.NumAdjacencies = int32(state.NumAdjacencies)
+	obj.NumL1Lsps = int32(state.NumL1Lsps)
+	obj.NumL2Lsps = int32(state.NumL2Lsps)
+	obj.SpfRuns = int32(state.SpfRuns)
+	return obj, nil
 }
 
 func (rpcHdl *rpcServiceHandler) GetBulkIsisGlobalState(fromIdx, count isisd.Int) (*isisd.IsisGlobalStateGetInfo, error) {
-        var getBulkInfo isisd.IsisGlobalStateGetInfo
-        var err error
-        //info, err := api.GetBulkIsisGlobalState(int(fromIdx), int(count))
-        //getBulkInfo.StartIdx = fromIdx
-        //getBulkInfo.EndIdx = isisd.Int(info.EndIdx)
-        //getBulkInfo.More = info.More
-        //getBulkInfo.Count = isisd.Int(len(info.List))
-        // Fill in data, remember to convert back to thrift format
-        return &getBulkInfo, err
+	var getBulkInfo isisd.IsisGlobalStateGetInfo
+	obj, err := rpcHdl.GetIsisGlobalState("default")
+	if err != nil {
+		return nil, err
+	}
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = isisd.Int(1)
+	getBulkInfo.More = false
+	getBulkInfo.Count = isisd.Int(1)
+	getBulkInfo.IsisGlobalStateList = append(getBulkInfo.IsisGlobalStateList, obj)
+	return &getBulkInfo, nil
 }
 
+func convertIntfState(state server.IntfState) *isisd.IsisIntfState {
+	obj := isisd.NewIsisIntfState()
+	obj.IntfRef = state.IntfRef
+	obj.IfIndex = state.IfIndex
+	obj.State = state.State
+	obj.NetworkType = state.NetworkType
+	obj.CircuitType = state.CircuitType
+	obj.CircuitId = int32(state.CircuitId)
+	obj.Metric = int32(state.Metric)
+	obj.L1Dis = state.L1Dis
+	obj.L2Dis = state.L2Dis
+	obj.NumAdjacencies = int32(state.NumAdjacencies)
+	return obj
+}
+
+func (rpcHdl *rpcServiceHandler) GetBulkIsisIntfState(fromIdx, count isisd.Int) (*isisd.IsisIntfStateGetInfo, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_INTF_STATE, server.BulkReq{FromIdx: int(fromIdx), Count: int(count)}).(server.IntfStateGetInfo)
+	var getBulkInfo isisd.IsisIntfStateGetInfo
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = isisd.Int(info.EndIdx)
+	getBulkInfo.More = info.More
+	getBulkInfo.Count = isisd.Int(len(info.List))
+	for _, state := range info.List {
+		getBulkInfo.IsisIntfStateList = append(getBulkInfo.IsisIntfStateList, convertIntfState(state))
+	}
+	return &getBulkInfo, nil
+}
+
+func (rpcHdl *rpcServiceHandler) GetIsisIntfState(intfRef string) (*isisd.IsisIntfState, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_INTF_STATE, server.BulkReq{}).(server.IntfStateGetInfo)
+	for _, state := range info.List {
+		if state.IntfRef == intfRef {
+			return convertIntfState(state), nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("No IS-IS interface", intfRef))
+}
+
+func convertAdjState(state server.AdjState) *isisd.IsisAdjacencyState {
+	obj := isisd.NewIsisAdjacencyState()
+	obj.IntfRef = state.IntfRef
+	obj.SystemId = state.SystemId
+	obj.Hostname = state.Hostname
+	obj.Level = int32(state.Level)
+	obj.State = state.State
+	obj.Snpa = state.Snpa
+	obj.Priority = int32(state.Priority)
+	obj.HoldTimeRemaining = int32(state.HoldTimeRemaining)
+	obj.Ipv4Addr = state.IPv4Addr
+	obj.Ipv6Addr = state.IPv6Addr
+	obj.UpTime = state.UpTime
+	return obj
+}
+
+func (rpcHdl *rpcServiceHandler) GetBulkIsisAdjacencyState(fromIdx, count isisd.Int) (*isisd.IsisAdjacencyStateGetInfo, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_ADJ_STATE, server.BulkReq{FromIdx: int(fromIdx), Count: int(count)}).(server.AdjStateGetInfo)
+	var getBulkInfo isisd.IsisAdjacencyStateGetInfo
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = isisd.Int(info.EndIdx)
+	getBulkInfo.More = info.More
+	getBulkInfo.Count = isisd.Int(len(info.List))
+	for _, state := range info.List {
+		getBulkInfo.IsisAdjacencyStateList = append(getBulkInfo.IsisAdjacencyStateList, convertAdjState(state))
+	}
+	return &getBulkInfo, nil
+}
+
+func (rpcHdl *rpcServiceHandler) GetIsisAdjacencyState(intfRef string, systemId string) (*isisd.IsisAdjacencyState, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_ADJ_STATE, server.BulkReq{}).(server.AdjStateGetInfo)
+	for _, state := range info.List {
+		if state.IntfRef == intfRef && state.SystemId == systemId {
+			return convertAdjState(state), nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("No IS-IS adjacency", systemId, "on", intfRef))
+}
+
+func convertLspState(state server.LspState) *isisd.IsisLspState {
+	obj := isisd.NewIsisLspState()
+	obj.Level = int32(state.Level)
+	obj.LspId = state.LspId
+	obj.Hostname = state.Hostname
+	obj.SeqNum = int64(state.SeqNum)
+	obj.Checksum = int32(state.Checksum)
+	obj.RemainingLifetime = int32(state.RemainingLifetime)
+	obj.Attached = state.Attached
+	obj.Overload = state.Overload
+	return obj
+}
+
+func (rpcHdl *rpcServiceHandler) GetBulkIsisLspState(fromIdx, count isisd.Int) (*isisd.IsisLspStateGetInfo, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_LSP_STATE, server.BulkReq{FromIdx: int(fromIdx), Count: int(count)}).(server.LspStateGetInfo)
+	var getBulkInfo isisd.IsisLspStateGetInfo
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = isisd.Int(info.EndIdx)
+	getBulkInfo.More = info.More
+	getBulkInfo.Count = isisd.Int(len(info.List))
+	for _, state := range info.List {
+		getBulkInfo.IsisLspStateList = append(getBulkInfo.IsisLspStateList, convertLspState(state))
+	}
+	return &getBulkInfo, nil
+}
+
+func (rpcHdl *rpcServiceHandler) GetIsisLspState(level int32, lspId string) (*isisd.IsisLspState, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_LSP_STATE, server.BulkReq{}).(server.LspStateGetInfo)
+	for _, state := range info.List {
+		if int32(state.Level) == level && state.LspId == lspId {
+			return convertLspState(state), nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("No level", level, "LSP", lspId))
+}
+
+func convertRouteState(state server.RouteState) *isisd.IsisRouteState {
+	obj := isisd.NewIsisRouteState()
+	obj.Prefix = state.Prefix
+	obj.Level = int32(state.Level)
+	obj.Metric = int32(state.Metric)
+	obj.NextHops = state.NextHops
+	return obj
+}
+
+func (rpcHdl *rpcServiceHandler) GetBulkIsisRouteState(fromIdx, count isisd.Int) (*isisd.IsisRouteStateGetInfo, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_ROUTE_STATE, server.BulkReq{FromIdx: int(fromIdx), Count: int(count)}).(server.RouteStateGetInfo)
+	var getBulkInfo isisd.IsisRouteStateGetInfo
+	getBulkInfo.StartIdx = fromIdx
+	getBulkInfo.EndIdx = isisd.Int(info.EndIdx)
+	getBulkInfo.More = info.More
+	getBulkInfo.Count = isisd.Int(len(info.List))
+	for _, state := range info.List {
+		getBulkInfo.IsisRouteStateList = append(getBulkInfo.IsisRouteStateList, convertRouteState(state))
+	}
+	return &getBulkInfo, nil
+}
+
+func (rpcHdl *rpcServiceHandler) GetIsisRouteState(prefix string) (*isisd.IsisRouteState, error) {
+	info := rpcHdl.sendReq(server.GET_BULK_ROUTE_STATE, server.BulkReq{}).(server.RouteStateGetInfo)
+	for _, state := range info.List {
+		if state.Prefix == prefix {
+			return convertRouteState(state), nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintln("No IS-IS route", prefix))
+}
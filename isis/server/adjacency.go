@@ -0,0 +1,492 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"l3/isis/packet"
+	"net"
+	"sort"
+	"time"
+)
+
+const (
+	ADJ_STATE_DOWN uint8 = iota
+	ADJ_STATE_INIT
+	ADJ_STATE_UP
+)
+
+type Adjacency struct {
+	SystemId     packet.SystemId
+	Snpa         net.HardwareAddr
+	Usage        uint8 // Levels the adjacency is used for
+	State        uint8
+	CircuitType  uint8
+	Priority     uint8
+	LanId        packet.NodeId
+	NbrCircuitId uint32
+	HoldingTime  uint16
+	holdTimer    uint16
+	IPv4Addrs    []net.IP
+	IPv6Addrs    []net.IP
+	AreaAddrs    [][]byte
+	UpTime       time.Time
+}
+
+func AdjStateString(state uint8) string {
+	switch state {
+	case ADJ_STATE_INIT:
+		return "Init"
+	case ADJ_STATE_UP:
+		return "Up"
+	}
+	return "Down"
+}
+
+type adjSlice []*Adjacency
+
+func (adjs adjSlice) Len() int {
+	return len(adjs)
+}
+func (adjs adjSlice) Less(i, j int) bool {
+	return bytes.Compare(adjs[i].Snpa, adjs[j].Snpa) < 0
+}
+func (adjs adjSlice) Swap(i, j int) {
+	adjs[i], adjs[j] = adjs[j], adjs[i]
+}
+
+/* LAN adjacencies ordered by SNPA */
+func sortedAdjs(intfLevel *IntfLevel) []*Adjacency {
+	adjs := make([]*Adjacency, 0, len(intfLevel.adjs))
+	for _, adj := range intfLevel.adjs {
+		adjs = append(adjs, adj)
+	}
+	sort.Sort(adjSlice(adjs))
+	return adjs
+}
+
+func (srvr *DmnServer) areaMatch(areas [][]byte) bool {
+	for _, area := range areas {
+		for _, ownArea := range srvr.AreaAddrs {
+			if bytes.Equal(area, ownArea) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (srvr *DmnServer) helloInterval(intf *Intf, intfLevel *IntfLevel) uint16 {
+	interval := intf.Cfg.HelloInterval
+	if intfLevel != nil && intfLevel.isDis {
+		// DIS sends hellos three times faster to detect its failure quickly
+		interval = interval / 3
+		if interval == 0 {
+			interval = 1
+		}
+	}
+	return interval
+}
+
+func (srvr *DmnServer) helloTlvs(intf *Intf) packet.Tlvs {
+	tlvs := packet.Tlvs{
+		AreaAddresses:      srvr.AreaAddrs,
+		ProtocolsSupported: []uint8{packet.NLPID_IPV4, packet.NLPID_IPV6},
+	}
+	for _, ipNet := range intf.IPv4Addrs {
+		tlvs.IPv4IntfAddrs = append(tlvs.IPv4IntfAddrs, ipNet.IP)
+	}
+	for _, ipNet := range intf.IPv6Addrs {
+		// Hellos carry link local addresses which are used as next hops
+		if ipNet.IP.IsLinkLocalUnicast() {
+			tlvs.IPv6IntfAddrs = append(tlvs.IPv6IntfAddrs, ipNet.IP)
+		}
+	}
+	return tlvs
+}
+
+func (srvr *DmnServer) sendHellos(intf *Intf) {
+	if intf.isP2P() {
+		srvr.sendP2PHello(intf)
+		return
+	}
+	for _, intfLevel := range intf.levels {
+		if intfLevel != nil {
+			srvr.sendLanHello(intf, intfLevel)
+		}
+	}
+}
+
+func (srvr *DmnServer) sendLanHello(intf *Intf, intfLevel *IntfLevel) {
+	interval := srvr.helloInterval(intf, intfLevel)
+	hello := &packet.LanHello{
+		Level:       intfLevel.level,
+		CircuitType: intf.levelMask,
+		SourceId:    srvr.SystemId,
+		HoldingTime: interval * intf.Cfg.HelloMultiplier,
+		Priority:    intf.Cfg.Priority,
+		LanId:       intfLevel.lanId,
+		Tlvs:        srvr.helloTlvs(intf),
+	}
+	for _, adj := range sortedAdjs(intfLevel) {
+		hello.IsNeighbors = append(hello.IsNeighbors, adj.Snpa)
+	}
+	pdu := packet.EncodeLanHello(hello, packet.ISIS_MAX_PDU_LEN)
+	srvr.sendPdu(intf, levelMulticastMac(intf, intfLevel.level), pdu)
+	intfLevel.helloTimer = interval
+}
+
+func (srvr *DmnServer) sendP2PHello(intf *Intf) {
+	hello := &packet.P2PHello{
+		CircuitType:    intf.levelMask,
+		SourceId:       srvr.SystemId,
+		HoldingTime:    intf.Cfg.HelloInterval * intf.Cfg.HelloMultiplier,
+		LocalCircuitId: intf.CircuitId,
+		Tlvs:           srvr.helloTlvs(intf),
+	}
+	adjState := &packet.P2PAdjState{
+		State:          packet.P2PAdjDown,
+		LocalCircuitId: uint32(intf.IfIndex),
+	}
+	if adj := intf.p2pAdj; adj != nil {
+		adjState.HasNbr = true
+		adjState.NbrSysId = adj.SystemId
+		adjState.NbrCircuitId = adj.NbrCircuitId
+		if adj.State == ADJ_STATE_UP {
+			adjState.State = packet.P2PAdjUp
+		} else if adj.State == ADJ_STATE_INIT {
+			adjState.State = packet.P2PAdjInit
+		}
+	}
+	hello.P2PAdj = adjState
+	pdu := packet.EncodeP2PHello(hello, packet.ISIS_MAX_PDU_LEN)
+	srvr.sendPdu(intf, packet.AllISs, pdu)
+	intf.p2pHello = intf.Cfg.HelloInterval
+}
+
+func (adj *Adjacency) updateFromTlvs(tlvs *packet.Tlvs) {
+	adj.IPv4Addrs = tlvs.IPv4IntfAddrs
+	adj.IPv6Addrs = tlvs.IPv6IntfAddrs
+	adj.AreaAddrs = tlvs.AreaAddresses
+}
+
+func (srvr *DmnServer) processLanHello(intf *Intf, hello *packet.LanHello, srcMac net.HardwareAddr) {
+	if intf.isP2P() {
+		srvr.Logger.Debug("LAN hello received on point to point interface", intf.IntfRef)
+		return
+	}
+	intfLevel := intf.getLevel(hello.Level)
+	if intfLevel == nil || hello.SourceId == srvr.SystemId || hello.CircuitType&hello.Level == 0 {
+		return
+	}
+	key := srcMac.String()
+	adj, exist := intfLevel.adjs[key]
+	if hello.Level == packet.Level1 && !srvr.areaMatch(hello.AreaAddresses) {
+		srvr.Logger.Debug("Area mismatch for L1 hello from", hello.SourceId.String(), "on", intf.IntfRef)
+		if exist {
+			srvr.deleteLanAdjacency(intf, intfLevel, key)
+		}
+		return
+	}
+	if exist && adj.SystemId != hello.SourceId {
+		srvr.deleteLanAdjacency(intf, intfLevel, key)
+		exist = false
+	}
+	if !exist {
+		adj = &Adjacency{
+			SystemId: hello.SourceId,
+			Snpa:     srcMac,
+			Usage:    hello.Level,
+			State:    ADJ_STATE_DOWN,
+		}
+		intfLevel.adjs[key] = adj
+		srvr.Logger.Info("New level", hello.Level, "adjacency", hello.SourceId.String(), "on", intf.IntfRef)
+	}
+	oldState := adj.State
+	oldPriority := adj.Priority
+	oldLanId := adj.LanId
+	adj.CircuitType = hello.CircuitType
+	adj.Priority = hello.Priority
+	adj.LanId = hello.LanId
+	adj.HoldingTime = hello.HoldingTime
+	adj.holdTimer = hello.HoldingTime
+	adj.updateFromTlvs(&hello.Tlvs)
+	adj.State = ADJ_STATE_INIT
+	for _, mac := range hello.IsNeighbors {
+		if bytes.Equal(mac, intf.MacAddr) {
+			adj.State = ADJ_STATE_UP
+			break
+		}
+	}
+	if adj.State != oldState {
+		srvr.Logger.Info("Level", hello.Level, "adjacency", hello.SourceId.String(), "on", intf.IntfRef,
+			AdjStateString(oldState), "->", AdjStateString(adj.State))
+		if adj.State == ADJ_STATE_UP {
+			adj.UpTime = time.Now()
+		}
+		if !exist {
+			// Let the new neighbor see us without waiting for the hello timer
+			srvr.sendLanHello(intf, intfLevel)
+		}
+	}
+	if adj.State != oldState || (adj.State == ADJ_STATE_UP &&
+		(adj.Priority != oldPriority || adj.LanId != oldLanId)) {
+		srvr.runDisElection(intf, intfLevel)
+		srvr.scheduleLspGen(hello.Level)
+		srvr.scheduleSpf(hello.Level)
+	}
+}
+
+func (srvr *DmnServer) deleteLanAdjacency(intf *Intf, intfLevel *IntfLevel, key string) {
+	adj, exist := intfLevel.adjs[key]
+	if !exist {
+		return
+	}
+	srvr.Logger.Info("Level", intfLevel.level, "adjacency", adj.SystemId.String(), "on", intf.IntfRef, "deleted")
+	delete(intfLevel.adjs, key)
+	if adj.State == ADJ_STATE_UP {
+		srvr.runDisElection(intf, intfLevel)
+		srvr.scheduleLspGen(intfLevel.level)
+		srvr.scheduleSpf(intfLevel.level)
+	}
+}
+
+/*@fn runDisElection
+Designated IS is the one with highest priority, ties are broken by the
+highest SNPA. Only adjacencies in Up state take part in the election.
+*/
+func (srvr *DmnServer) runDisElection(intf *Intf, intfLevel *IntfLevel) {
+	var dis *Adjacency
+	for _, adj := range intfLevel.adjs {
+		if adj.State != ADJ_STATE_UP {
+			continue
+		}
+		if dis == nil || adj.Priority > dis.Priority ||
+			(adj.Priority == dis.Priority && bytes.Compare(adj.Snpa, dis.Snpa) > 0) {
+			dis = adj
+		}
+	}
+	isDis := false
+	lanId := packet.MakeNodeId(srvr.SystemId, intf.CircuitId)
+	if dis != nil {
+		if intf.Cfg.Priority > dis.Priority ||
+			(intf.Cfg.Priority == dis.Priority && bytes.Compare(intf.MacAddr, dis.Snpa) > 0) {
+			isDis = true
+		} else {
+			lanId = dis.LanId
+		}
+	}
+	if isDis == intfLevel.isDis && lanId == intfLevel.lanId {
+		return
+	}
+	srvr.Logger.Info("Level", intfLevel.level, "DIS on", intf.IntfRef, "changed to", lanId.String())
+	if intfLevel.isDis && !isDis {
+		srvr.purgePseudonodeLsp(intfLevel.level, intf.CircuitId)
+	}
+	intfLevel.isDis = isDis
+	intfLevel.lanId = lanId
+	if isDis {
+		intfLevel.csnpTimer = 0
+	}
+	srvr.sendLanHello(intf, intfLevel)
+	srvr.scheduleLspGen(intfLevel.level)
+	srvr.scheduleSpf(intfLevel.level)
+}
+
+func (srvr *DmnServer) processP2PHello(intf *Intf, hello *packet.P2PHello, srcMac net.HardwareAddr) {
+	if !intf.isP2P() {
+		srvr.Logger.Debug("Point to point hello received on broadcast interface", intf.IntfRef)
+		return
+	}
+	if hello.SourceId == srvr.SystemId {
+		return
+	}
+	usage := hello.CircuitType & intf.levelMask
+	if usage&packet.Level1 != 0 && !srvr.areaMatch(hello.AreaAddresses) {
+		usage &^= packet.Level1
+	}
+	adj := intf.p2pAdj
+	if adj != nil && (adj.SystemId != hello.SourceId || adj.Usage != usage) {
+		srvr.deleteP2PAdjacency(intf)
+		adj = nil
+	}
+	if usage == 0 {
+		srvr.Logger.Debug("No common level with", hello.SourceId.String(), "on", intf.IntfRef)
+		return
+	}
+	if adj == nil {
+		adj = &Adjacency{
+			SystemId: hello.SourceId,
+			Snpa:     srcMac,
+			Usage:    usage,
+			State:    ADJ_STATE_DOWN,
+		}
+		intf.p2pAdj = adj
+		srvr.Logger.Info("New point to point adjacency", hello.SourceId.String(), "on", intf.IntfRef)
+	}
+	oldState := adj.State
+	adj.CircuitType = hello.CircuitType
+	adj.HoldingTime = hello.HoldingTime
+	adj.holdTimer = hello.HoldingTime
+	adj.updateFromTlvs(&hello.Tlvs)
+	newState := ADJ_STATE_UP
+	if p2pAdj := hello.P2PAdj; p2pAdj != nil {
+		// Three way handshake (RFC 5303)
+		adj.NbrCircuitId = p2pAdj.LocalCircuitId
+		if p2pAdj.HasNbr && (p2pAdj.NbrSysId != srvr.SystemId ||
+			p2pAdj.NbrCircuitId != uint32(intf.IfIndex)) {
+			srvr.Logger.Info("Neighbor", hello.SourceId.String(), "on", intf.IntfRef, "sees another system")
+			srvr.deleteP2PAdjacency(intf)
+			return
+		}
+		switch p2pAdj.State {
+		case packet.P2PAdjDown:
+			newState = ADJ_STATE_INIT
+		case packet.P2PAdjInit:
+			newState = ADJ_STATE_UP
+		case packet.P2PAdjUp:
+			if oldState == ADJ_STATE_DOWN {
+				newState = ADJ_STATE_DOWN
+			}
+		}
+	}
+	adj.State = newState
+	if newState == oldState {
+		return
+	}
+	srvr.Logger.Info("Point to point adjacency", hello.SourceId.String(), "on", intf.IntfRef,
+		AdjStateString(oldState), "->", AdjStateString(newState))
+	if newState == ADJ_STATE_UP {
+		adj.UpTime = time.Now()
+		// Synchronize the database with the new neighbor
+		for _, level := range []uint8{packet.Level1, packet.Level2} {
+			if usage&level != 0 {
+				srvr.setAllSrm(intf, level)
+			}
+		}
+	}
+	srvr.sendP2PHello(intf)
+	if newState == ADJ_STATE_UP || oldState == ADJ_STATE_UP {
+		srvr.scheduleLspGen(usage)
+		srvr.scheduleSpf(usage)
+	}
+}
+
+func (srvr *DmnServer) deleteP2PAdjacency(intf *Intf) {
+	adj := intf.p2pAdj
+	if adj == nil {
+		return
+	}
+	srvr.Logger.Info("Point to point adjacency", adj.SystemId.String(), "on", intf.IntfRef, "deleted")
+	intf.p2pAdj = nil
+	for _, intfLevel := range intf.levels {
+		if intfLevel == nil {
+			continue
+		}
+		for lspId, _ := range intfLevel.srm {
+			delete(intfLevel.srm, lspId)
+		}
+	}
+	if adj.State == ADJ_STATE_UP {
+		srvr.scheduleLspGen(adj.Usage)
+		srvr.scheduleSpf(adj.Usage)
+	}
+}
+
+/*@fn getUpAdjacency
+Adjacency in Up state on the circuit for the level. For LAN, snpa identifies
+the neighbor.
+*/
+func (srvr *DmnServer) getUpAdjacency(intf *Intf, level uint8, snpa net.HardwareAddr) *Adjacency {
+	if intf.isP2P() {
+		adj := intf.p2pAdj
+		if adj != nil && adj.State == ADJ_STATE_UP && adj.Usage&level != 0 {
+			return adj
+		}
+		return nil
+	}
+	intfLevel := intf.getLevel(level)
+	if intfLevel == nil {
+		return nil
+	}
+	adj, exist := intfLevel.adjs[snpa.String()]
+	if !exist || adj.State != ADJ_STATE_UP {
+		return nil
+	}
+	return adj
+}
+
+func (srvr *DmnServer) hasUpAdjacency(intf *Intf, level uint8) bool {
+	if intf.isP2P() {
+		return srvr.getUpAdjacency(intf, level, nil) != nil
+	}
+	intfLevel := intf.getLevel(level)
+	if intfLevel == nil {
+		return false
+	}
+	for _, adj := range intfLevel.adjs {
+		if adj.State == ADJ_STATE_UP {
+			return true
+		}
+	}
+	return false
+}
+
+/* Hello and holding timers, called every second */
+func (srvr *DmnServer) processHelloTimers(intf *Intf) {
+	if intf.isP2P() {
+		if intf.p2pHello <= 1 {
+			srvr.sendP2PHello(intf)
+		} else {
+			intf.p2pHello--
+		}
+		if adj := intf.p2pAdj; adj != nil {
+			if adj.holdTimer <= 1 {
+				srvr.Logger.Info("Hold timer expired for", adj.SystemId.String(), "on", intf.IntfRef)
+				srvr.deleteP2PAdjacency(intf)
+			} else {
+				adj.holdTimer--
+			}
+		}
+		return
+	}
+	for _, intfLevel := range intf.levels {
+		if intfLevel == nil {
+			continue
+		}
+		if intfLevel.helloTimer <= 1 {
+			srvr.sendLanHello(intf, intfLevel)
+		} else {
+			intfLevel.helloTimer--
+		}
+		for key, adj := range intfLevel.adjs {
+			if adj.holdTimer <= 1 {
+				srvr.Logger.Info("Hold timer expired for", adj.SystemId.String(), "on", intf.IntfRef)
+				srvr.deleteLanAdjacency(intf, intfLevel, key)
+			} else {
+				adj.holdTimer--
+			}
+		}
+	}
+}
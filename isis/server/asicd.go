@@ -0,0 +1,181 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"asicd/asicdCommonDefs"
+	"net"
+	"utils/commonDefs"
+)
+
+type AsicNotificationHdl struct {
+	srvr *DmnServer
+}
+
+func NewAsicNotificationHdl(srvr *DmnServer) *AsicNotificationHdl {
+	return &AsicNotificationHdl{srvr}
+}
+
+func InitAsicdNotification() commonDefs.AsicdNotification {
+	nMap := commonDefs.AsicdNotification{
+		commonDefs.NOTIFY_L2INTF_STATE_CHANGE:       false,
+		commonDefs.NOTIFY_IPV4_L3INTF_STATE_CHANGE:  true,
+		commonDefs.NOTIFY_IPV6_L3INTF_STATE_CHANGE:  true,
+		commonDefs.NOTIFY_VLAN_CREATE:               false,
+		commonDefs.NOTIFY_VLAN_DELETE:               false,
+		commonDefs.NOTIFY_VLAN_UPDATE:               false,
+		commonDefs.NOTIFY_LOGICAL_INTF_CREATE:       false,
+		commonDefs.NOTIFY_LOGICAL_INTF_DELETE:       false,
+		commonDefs.NOTIFY_LOGICAL_INTF_UPDATE:       false,
+		commonDefs.NOTIFY_IPV4INTF_CREATE:           true,
+		commonDefs.NOTIFY_IPV4INTF_DELETE:           true,
+		commonDefs.NOTIFY_IPV6INTF_CREATE:           true,
+		commonDefs.NOTIFY_IPV6INTF_DELETE:           true,
+		commonDefs.NOTIFY_LAG_CREATE:                false,
+		commonDefs.NOTIFY_LAG_DELETE:                false,
+		commonDefs.NOTIFY_LAG_UPDATE:                false,
+		commonDefs.NOTIFY_IPV4NBR_MAC_MOVE:          false,
+		commonDefs.NOTIFY_IPV6NBR_MAC_MOVE:          false,
+		commonDefs.NOTIFY_IPV4_ROUTE_CREATE_FAILURE: false,
+		commonDefs.NOTIFY_IPV4_ROUTE_DELETE_FAILURE: false,
+		commonDefs.NOTIFY_IPV6_ROUTE_CREATE_FAILURE: false,
+		commonDefs.NOTIFY_IPV6_ROUTE_DELETE_FAILURE: false,
+		commonDefs.NOTIFY_VTEP_CREATE:               false,
+		commonDefs.NOTIFY_VTEP_DELETE:               false,
+		commonDefs.NOTIFY_MPLSINTF_STATE_CHANGE:     false,
+		commonDefs.NOTIFY_MPLSINTF_CREATE:           false,
+		commonDefs.NOTIFY_MPLSINTF_DELETE:           false,
+		commonDefs.NOTIFY_PORT_CONFIG_MODE_CHANGE:   false,
+	}
+	return nMap
+}
+
+func (notifyHdl *AsicNotificationHdl) ProcessNotification(msg commonDefs.AsicdNotifyMsg) {
+	notifyHdl.srvr.AsicdNotifyCh <- msg
+}
+
+func (srvr *DmnServer) getIntfInfo(intfRef string, ifIndex int32) *IntfInfo {
+	info, exist := srvr.intfInfoMap[intfRef]
+	if !exist {
+		info = &IntfInfo{
+			IntfRef: intfRef,
+			IfIndex: ifIndex,
+		}
+		if netIntf, err := net.InterfaceByName(intfRef); err == nil {
+			info.MacAddr = netIntf.HardwareAddr.String()
+		}
+		if srvr.SwitchPlugin != nil {
+			info.IsLoopback = srvr.SwitchPlugin.IsLoopbackType(ifIndex)
+		}
+		srvr.intfInfoMap[intfRef] = info
+		srvr.ifIndexMap[ifIndex] = intfRef
+	}
+	return info
+}
+
+func addAddr(addrs []string, addr string) []string {
+	for _, ipAddr := range addrs {
+		if ipAddr == addr {
+			return addrs
+		}
+	}
+	return append(addrs, addr)
+}
+
+func delAddr(addrs []string, addr string) []string {
+	for idx, ipAddr := range addrs {
+		if ipAddr == addr {
+			return append(addrs[:idx], addrs[idx+1:]...)
+		}
+	}
+	return addrs
+}
+
+func (srvr *DmnServer) buildIntfInfo() {
+	if srvr.SwitchPlugin == nil {
+		return
+	}
+	v4Intfs, err := srvr.SwitchPlugin.GetAllIPv4IntfState()
+	if err != nil {
+		srvr.Logger.Err("Failed to get IPv4 interfaces", err)
+	}
+	for _, obj := range v4Intfs {
+		info := srvr.getIntfInfo(obj.IntfRef, obj.IfIndex)
+		info.IPv4Addrs = addAddr(info.IPv4Addrs, obj.IpAddr)
+		info.OperState = info.OperState || obj.OperState == "UP"
+	}
+	v6Intfs, err := srvr.SwitchPlugin.GetAllIPv6IntfState()
+	if err != nil {
+		srvr.Logger.Err("Failed to get IPv6 interfaces", err)
+	}
+	for _, obj := range v6Intfs {
+		info := srvr.getIntfInfo(obj.IntfRef, obj.IfIndex)
+		info.IPv6Addrs = addAddr(info.IPv6Addrs, obj.IpAddr)
+		info.OperState = info.OperState || obj.OperState == "UP"
+	}
+	for _, info := range srvr.intfInfoMap {
+		srvr.UpdateIntfInfo(*info)
+	}
+}
+
+func (srvr *DmnServer) processAsicdNotification(msg commonDefs.AsicdNotifyMsg) {
+	var info *IntfInfo
+	switch msg.(type) {
+	case commonDefs.IPv4IntfNotifyMsg:
+		ipv4Msg := msg.(commonDefs.IPv4IntfNotifyMsg)
+		info = srvr.getIntfInfo(ipv4Msg.IntfRef, ipv4Msg.IfIndex)
+		if ipv4Msg.MsgType == commonDefs.NOTIFY_IPV4INTF_CREATE {
+			info.IPv4Addrs = addAddr(info.IPv4Addrs, ipv4Msg.IpAddr)
+		} else {
+			info.IPv4Addrs = delAddr(info.IPv4Addrs, ipv4Msg.IpAddr)
+		}
+	case commonDefs.IPv6IntfNotifyMsg:
+		ipv6Msg := msg.(commonDefs.IPv6IntfNotifyMsg)
+		info = srvr.getIntfInfo(ipv6Msg.IntfRef, ipv6Msg.IfIndex)
+		if ipv6Msg.MsgType == commonDefs.NOTIFY_IPV6INTF_CREATE {
+			info.IPv6Addrs = addAddr(info.IPv6Addrs, ipv6Msg.IpAddr)
+		} else {
+			info.IPv6Addrs = delAddr(info.IPv6Addrs, ipv6Msg.IpAddr)
+		}
+	case commonDefs.IPv4L3IntfStateNotifyMsg:
+		l3Msg := msg.(commonDefs.IPv4L3IntfStateNotifyMsg)
+		intfRef, exist := srvr.ifIndexMap[l3Msg.IfIndex]
+		if !exist {
+			return
+		}
+		info = srvr.intfInfoMap[intfRef]
+		info.OperState = l3Msg.IfState == asicdCommonDefs.INTF_STATE_UP
+	case commonDefs.IPv6L3IntfStateNotifyMsg:
+		l3Msg := msg.(commonDefs.IPv6L3IntfStateNotifyMsg)
+		intfRef, exist := srvr.ifIndexMap[l3Msg.IfIndex]
+		if !exist {
+			return
+		}
+		info = srvr.intfInfoMap[intfRef]
+		info.OperState = l3Msg.IfState == asicdCommonDefs.INTF_STATE_UP
+	default:
+		return
+	}
+	srvr.UpdateIntfInfo(*info)
+}
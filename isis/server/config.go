@@ -0,0 +1,296 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/isis/packet"
+	"strings"
+)
+
+/* Interface network types */
+const (
+	NETWORK_BROADCAST uint8 = 1
+	NETWORK_P2P       uint8 = 2
+)
+
+const (
+	DEFAULT_HELLO_INTERVAL     = 10
+	DEFAULT_HELLO_MULTIPLIER   = 3
+	DEFAULT_PRIORITY           = 64
+	DEFAULT_METRIC             = 10
+	DEFAULT_LSP_LIFETIME       = 1200
+	DEFAULT_LSP_REFRESH        = 900
+	CSNP_INTERVAL              = 10
+	LSP_RETRANSMIT_INTERVAL    = 5
+	ZERO_AGE_LIFETIME          = 60
+	SPF_HOLD_INTERVAL          = 1
+	MAX_PRIORITY               = 127
+	MAX_AREA_ADDRESSES         = 3
+	MIN_LSP_REFRESH_DIFFERENCE = 300
+)
+
+type GlobalConfig struct {
+	Vrf                string
+	Enable             bool
+	SystemId           string
+	AreaId             string
+	IsType             uint8
+	Hostname           string
+	LspLifetime        uint16
+	LspRefreshInterval uint16
+	Overload           bool
+}
+
+type IntfConfig struct {
+	IntfRef         string
+	Enable          bool
+	CircuitType     uint8
+	NetworkType     uint8
+	HelloInterval   uint16
+	HelloMultiplier uint16
+	Priority        uint8
+	Metric          uint32
+	Passive         bool
+}
+
+/* Interface information learnt from asicd */
+type IntfInfo struct {
+	IntfRef    string
+	IfIndex    int32
+	MacAddr    string
+	OperState  bool
+	IsLoopback bool
+	IPv4Addrs  []string
+	IPv6Addrs  []string
+}
+
+type ServerOp int
+
+const (
+	CREATE_GLOBAL ServerOp = iota
+	UPDATE_GLOBAL
+	DELETE_GLOBAL
+	CREATE_INTF
+	UPDATE_INTF
+	DELETE_INTF
+	GET_GLOBAL_STATE
+	GET_BULK_INTF_STATE
+	GET_BULK_ADJ_STATE
+	GET_BULK_LSP_STATE
+	GET_BULK_ROUTE_STATE
+)
+
+type BulkReq struct {
+	FromIdx int
+	Count   int
+}
+
+type ServerRequest struct {
+	Op      ServerOp
+	Data    interface{}
+	ReplyCh chan interface{}
+}
+
+func ConvertIsType(str string) (uint8, error) {
+	switch strings.ToLower(str) {
+	case "level-1":
+		return packet.Level1, nil
+	case "level-2":
+		return packet.Level2, nil
+	case "level-1-2", "":
+		return packet.Level1_2, nil
+	}
+	return 0, errors.New(fmt.Sprintln("Invalid level", str))
+}
+
+func IsTypeString(isType uint8) string {
+	switch isType {
+	case packet.Level1:
+		return "level-1"
+	case packet.Level2:
+		return "level-2"
+	case packet.Level1_2:
+		return "level-1-2"
+	}
+	return "none"
+}
+
+func ConvertNetworkType(str string) (uint8, error) {
+	switch strings.ToLower(str) {
+	case "broadcast", "":
+		return NETWORK_BROADCAST, nil
+	case "point-to-point":
+		return NETWORK_P2P, nil
+	}
+	return 0, errors.New(fmt.Sprintln("Invalid network type", str))
+}
+
+func NetworkTypeString(netType uint8) string {
+	if netType == NETWORK_P2P {
+		return "point-to-point"
+	}
+	return "broadcast"
+}
+
+func (cfg *GlobalConfig) setDefaults() {
+	if cfg.LspLifetime == 0 {
+		cfg.LspLifetime = DEFAULT_LSP_LIFETIME
+	}
+	if cfg.LspRefreshInterval == 0 {
+		cfg.LspRefreshInterval = DEFAULT_LSP_REFRESH
+	}
+	if cfg.IsType == 0 {
+		cfg.IsType = packet.Level1_2
+	}
+}
+
+func (cfg *IntfConfig) setDefaults() {
+	if cfg.CircuitType == 0 {
+		cfg.CircuitType = packet.Level1_2
+	}
+	if cfg.NetworkType == 0 {
+		cfg.NetworkType = NETWORK_BROADCAST
+	}
+	if cfg.HelloInterval == 0 {
+		cfg.HelloInterval = DEFAULT_HELLO_INTERVAL
+	}
+	if cfg.HelloMultiplier == 0 {
+		cfg.HelloMultiplier = DEFAULT_HELLO_MULTIPLIER
+	}
+	if cfg.Metric == 0 {
+		cfg.Metric = DEFAULT_METRIC
+	}
+}
+
+func (srvr *DmnServer) validateGlobalConfig(cfg *GlobalConfig) error {
+	if _, err := packet.ParseSystemId(cfg.SystemId); err != nil {
+		return err
+	}
+	areas := strings.Split(cfg.AreaId, ",")
+	if len(areas) > MAX_AREA_ADDRESSES {
+		return errors.New(fmt.Sprintln("Maximum", MAX_AREA_ADDRESSES, "area addresses are supported"))
+	}
+	for _, area := range areas {
+		if _, err := packet.ParseAreaAddress(strings.TrimSpace(area)); err != nil {
+			return err
+		}
+	}
+	if cfg.LspRefreshInterval+MIN_LSP_REFRESH_DIFFERENCE > cfg.LspLifetime {
+		return errors.New(fmt.Sprintln("Lsp lifetime", cfg.LspLifetime, "should be atleast",
+			MIN_LSP_REFRESH_DIFFERENCE, "seconds more than refresh interval", cfg.LspRefreshInterval))
+	}
+	return nil
+}
+
+func (srvr *DmnServer) validateIntfConfig(cfg *IntfConfig) error {
+	if cfg.IntfRef == "" {
+		return errors.New("Interface is not specified")
+	}
+	if cfg.Priority > MAX_PRIORITY {
+		return errors.New(fmt.Sprintln("Invalid priority", cfg.Priority))
+	}
+	if cfg.Metric > packet.MAX_LINK_METRIC {
+		return errors.New(fmt.Sprintln("Invalid metric", cfg.Metric))
+	}
+	return nil
+}
+
+func (srvr *DmnServer) processGlobalConfig(cfg GlobalConfig, op ServerOp) error {
+	switch op {
+	case CREATE_GLOBAL, UPDATE_GLOBAL:
+		cfg.setDefaults()
+		err := srvr.validateGlobalConfig(&cfg)
+		if err != nil {
+			return err
+		}
+		sysId, _ := packet.ParseSystemId(cfg.SystemId)
+		restart := srvr.globalCfg.Enable != cfg.Enable || srvr.SystemId != sysId
+		if restart && srvr.globalCfg.Enable {
+			srvr.stopInstance()
+		}
+		srvr.globalCfg = cfg
+		srvr.SystemId = sysId
+		srvr.AreaAddrs = nil
+		for _, area := range strings.Split(cfg.AreaId, ",") {
+			areaAddr, _ := packet.ParseAreaAddress(strings.TrimSpace(area))
+			srvr.AreaAddrs = append(srvr.AreaAddrs, areaAddr)
+		}
+		if restart && cfg.Enable {
+			srvr.startInstance()
+		} else if cfg.Enable {
+			for _, intf := range srvr.intfMap {
+				srvr.updateIntfLevels(intf)
+			}
+			srvr.scheduleLspGen(packet.Level1_2)
+		}
+	case DELETE_GLOBAL:
+		if srvr.globalCfg.Enable {
+			srvr.stopInstance()
+		}
+		srvr.globalCfg = GlobalConfig{}
+	}
+	return nil
+}
+
+func (srvr *DmnServer) processIntfConfig(cfg IntfConfig, op ServerOp) error {
+	switch op {
+	case CREATE_INTF, UPDATE_INTF:
+		cfg.setDefaults()
+		err := srvr.validateIntfConfig(&cfg)
+		if err != nil {
+			return err
+		}
+		intf, exist := srvr.intfMap[cfg.IntfRef]
+		if !exist {
+			intf = newIntf(cfg.IntfRef)
+			srvr.intfMap[cfg.IntfRef] = intf
+		}
+		reset := !exist || intf.Cfg.NetworkType != cfg.NetworkType ||
+			intf.Cfg.CircuitType != cfg.CircuitType || intf.Cfg.Enable != cfg.Enable ||
+			intf.Cfg.Passive != cfg.Passive
+		if reset && exist {
+			srvr.stopIntf(intf)
+		}
+		intf.Cfg = cfg
+		intf.configured = true
+		if reset {
+			srvr.startIntf(intf)
+		} else {
+			srvr.updateIntfLevels(intf)
+		}
+		srvr.scheduleLspGen(packet.Level1_2)
+	case DELETE_INTF:
+		intf, exist := srvr.intfMap[cfg.IntfRef]
+		if !exist || !intf.configured {
+			return errors.New(fmt.Sprintln("No IS-IS config for interface", cfg.IntfRef))
+		}
+		srvr.stopIntf(intf)
+		intf.Cfg = IntfConfig{IntfRef: cfg.IntfRef}
+		intf.configured = false
+		srvr.scheduleLspGen(packet.Level1_2)
+	}
+	return nil
+}
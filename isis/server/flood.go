@@ -0,0 +1,309 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"l3/isis/packet"
+	"net"
+)
+
+/* Set SRM on all circuits of the level except the one LSP is received on */
+func (srvr *DmnServer) floodLsp(level uint8, lspId packet.LspId, rxIntf *Intf) {
+	for _, intf := range srvr.intfMap {
+		if intf == rxIntf || !intf.running || intf.isPassive() {
+			continue
+		}
+		if intfLevel := intf.getLevel(level); intfLevel != nil {
+			intfLevel.srm[lspId] = 0
+		}
+	}
+}
+
+func (srvr *DmnServer) setAllSrm(intf *Intf, level uint8) {
+	intfLevel := intf.getLevel(level)
+	if intfLevel == nil {
+		return
+	}
+	for lspId, _ := range srvr.getLsdb(level) {
+		intfLevel.srm[lspId] = 0
+	}
+}
+
+func (srvr *DmnServer) processLsp(intf *Intf, pdu []byte, srcMac net.HardwareAddr) {
+	lsp, err := packet.DecodeLsp(pdu)
+	if err != nil {
+		srvr.Logger.Err("Failed to decode LSP on", intf.IntfRef, err)
+		return
+	}
+	intfLevel := intf.getLevel(lsp.Level)
+	if intfLevel == nil || srvr.getUpAdjacency(intf, lsp.Level, srcMac) == nil {
+		return
+	}
+	lsdb := srvr.getLsdb(lsp.Level)
+	entry, exist := lsdb[lsp.LspId]
+	if !exist && lsp.RemainingLifetime == 0 {
+		// Purge of an unknown LSP is only acknowledged
+		if intf.isP2P() {
+			intfLevel.ssn[lsp.LspId] = true
+		}
+		return
+	}
+	cmp := 1
+	if exist {
+		cmp = compareLsp(lsp.SeqNum, lsp.RemainingLifetime, entry.Lsp.SeqNum, entry.Remaining)
+	}
+	if lsp.LspId.SystemId() == srvr.SystemId && cmp > 0 {
+		srvr.processOwnLsp(lsp, entry)
+		return
+	}
+	switch {
+	case cmp > 0:
+		srvr.Logger.Debug("Installing level", lsp.Level, "LSP", lsp.LspId.String(), "seq", lsp.SeqNum)
+		raw := make([]byte, len(pdu))
+		copy(raw, pdu)
+		srvr.installLsp(lsp.Level, lsp, raw, false)
+		srvr.floodLsp(lsp.Level, lsp.LspId, intf)
+		delete(intfLevel.srm, lsp.LspId)
+		if intf.isP2P() {
+			intfLevel.ssn[lsp.LspId] = true
+		}
+	case cmp == 0:
+		delete(intfLevel.srm, lsp.LspId)
+		if intf.isP2P() {
+			intfLevel.ssn[lsp.LspId] = true
+		}
+	default:
+		intfLevel.srm[lsp.LspId] = 0
+		delete(intfLevel.ssn, lsp.LspId)
+	}
+}
+
+/*@fn processOwnLsp
+A newer copy of an LSP with our system id is received. If we still originate
+the LSP it is reissued with a higher sequence number, otherwise it is purged.
+*/
+func (srvr *DmnServer) processOwnLsp(lsp *packet.Lsp, entry *LsdbEntry) {
+	if entry != nil && entry.self && entry.Remaining != 0 {
+		srvr.Logger.Info("Reissuing own LSP", lsp.LspId.String(), "with seq", lsp.SeqNum+1)
+		newLsp := *entry.Lsp
+		newLsp.SeqNum = lsp.SeqNum + 1
+		newLsp.RemainingLifetime = srvr.globalCfg.LspLifetime
+		srvr.installLsp(lsp.Level, &newLsp, packet.EncodeLsp(&newLsp), true)
+		srvr.floodLsp(lsp.Level, lsp.LspId, nil)
+		return
+	}
+	if lsp.RemainingLifetime == 0 {
+		srvr.installLsp(lsp.Level, lsp, packet.EncodeLsp(lsp), false)
+		srvr.floodLsp(lsp.Level, lsp.LspId, nil)
+		return
+	}
+	srvr.purgeLsp(lsp.Level, &LsdbEntry{Lsp: lsp})
+}
+
+/*@fn processSnpEntries
+Compare the entries of a received CSNP/PSNP with the database
+*/
+func (srvr *DmnServer) processSnpEntries(intf *Intf, intfLevel *IntfLevel, entries []packet.LspEntry) {
+	lsdb := srvr.getLsdb(intfLevel.level)
+	for _, snpEntry := range entries {
+		entry, exist := lsdb[snpEntry.LspId]
+		if !exist {
+			if snpEntry.RemainingLifetime != 0 && snpEntry.SeqNum != 0 {
+				intfLevel.ssn[snpEntry.LspId] = true
+			}
+			continue
+		}
+		cmp := compareLsp(entry.Lsp.SeqNum, entry.Remaining, snpEntry.SeqNum, snpEntry.RemainingLifetime)
+		switch {
+		case cmp > 0:
+			intfLevel.srm[snpEntry.LspId] = 0
+			delete(intfLevel.ssn, snpEntry.LspId)
+		case cmp == 0:
+			delete(intfLevel.srm, snpEntry.LspId)
+		default:
+			intfLevel.ssn[snpEntry.LspId] = true
+			delete(intfLevel.srm, snpEntry.LspId)
+		}
+	}
+}
+
+func (srvr *DmnServer) processCsnp(intf *Intf, pdu []byte, srcMac net.HardwareAddr) {
+	csnp, err := packet.DecodeCsnp(pdu)
+	if err != nil {
+		srvr.Logger.Err("Failed to decode CSNP on", intf.IntfRef, err)
+		return
+	}
+	intfLevel := intf.getLevel(csnp.Level)
+	if intfLevel == nil || srvr.getUpAdjacency(intf, csnp.Level, srcMac) == nil {
+		return
+	}
+	srvr.processSnpEntries(intf, intfLevel, csnp.LspEntries)
+	listed := make(map[packet.LspId]bool)
+	for _, snpEntry := range csnp.LspEntries {
+		listed[snpEntry.LspId] = true
+	}
+	// LSPs in the range missing from the CSNP are sent to the neighbor
+	for lspId, entry := range srvr.getLsdb(csnp.Level) {
+		if listed[lspId] || entry.Remaining == 0 {
+			continue
+		}
+		if bytes.Compare(lspId[:], csnp.StartLspId[:]) >= 0 && bytes.Compare(lspId[:], csnp.EndLspId[:]) <= 0 {
+			intfLevel.srm[lspId] = 0
+		}
+	}
+}
+
+func (srvr *DmnServer) processPsnp(intf *Intf, pdu []byte, srcMac net.HardwareAddr) {
+	psnp, err := packet.DecodePsnp(pdu)
+	if err != nil {
+		srvr.Logger.Err("Failed to decode PSNP on", intf.IntfRef, err)
+		return
+	}
+	intfLevel := intf.getLevel(psnp.Level)
+	if intfLevel == nil || srvr.getUpAdjacency(intf, psnp.Level, srcMac) == nil {
+		return
+	}
+	if !intf.isP2P() && !intfLevel.isDis {
+		return
+	}
+	srvr.processSnpEntries(intf, intfLevel, psnp.LspEntries)
+}
+
+func (srvr *DmnServer) sendCsnps(intf *Intf, intfLevel *IntfLevel) {
+	lsdb := srvr.getLsdb(intfLevel.level)
+	ids := srvr.sortedLspIds(intfLevel.level)
+	maxEntries := packet.MaxSnpEntries(packet.ISIS_CSNP_HDR_LEN)
+	startId := packet.LspId{}
+	for {
+		count := len(ids)
+		if count > maxEntries {
+			count = maxEntries
+		}
+		csnp := &packet.Csnp{
+			Level:      intfLevel.level,
+			SourceId:   packet.MakeNodeId(srvr.SystemId, 0),
+			StartLspId: startId,
+		}
+		for _, lspId := range ids[:count] {
+			csnp.LspEntries = append(csnp.LspEntries, lsdb[lspId].lspEntry())
+		}
+		ids = ids[count:]
+		if len(ids) == 0 {
+			for idx, _ := range csnp.EndLspId {
+				csnp.EndLspId[idx] = 0xFF
+			}
+		} else {
+			csnp.EndLspId = csnp.LspEntries[count-1].LspId
+			startId = ids[0]
+		}
+		srvr.sendPdu(intf, levelMulticastMac(intf, intfLevel.level), packet.EncodeCsnp(csnp))
+		if len(ids) == 0 {
+			return
+		}
+	}
+}
+
+func (srvr *DmnServer) sendPsnps(intf *Intf, intfLevel *IntfLevel) {
+	lsdb := srvr.getLsdb(intfLevel.level)
+	maxEntries := packet.MaxSnpEntries(packet.ISIS_PSNP_HDR_LEN)
+	psnp := &packet.Psnp{
+		Level:    intfLevel.level,
+		SourceId: packet.MakeNodeId(srvr.SystemId, 0),
+	}
+	for lspId, _ := range intfLevel.ssn {
+		snpEntry := packet.LspEntry{LspId: lspId}
+		if entry, exist := lsdb[lspId]; exist {
+			snpEntry = entry.lspEntry()
+		}
+		psnp.LspEntries = append(psnp.LspEntries, snpEntry)
+		delete(intfLevel.ssn, lspId)
+		if len(psnp.LspEntries) == maxEntries {
+			srvr.sendPdu(intf, levelMulticastMac(intf, intfLevel.level), packet.EncodePsnp(psnp))
+			psnp.LspEntries = nil
+		}
+	}
+	if len(psnp.LspEntries) != 0 {
+		srvr.sendPdu(intf, levelMulticastMac(intf, intfLevel.level), packet.EncodePsnp(psnp))
+	}
+}
+
+/*@fn processFloodTimers
+Called every second. Sends LSPs with SRM set and PSNPs for SSN. On point to
+point circuits SRM is cleared only on acknowledgement, LSPs are retransmitted
+every LSP_RETRANSMIT_INTERVAL till then. DIS sends periodic CSNPs.
+*/
+func (srvr *DmnServer) processFloodTimers(intf *Intf) {
+	for _, intfLevel := range intf.levels {
+		if intfLevel == nil {
+			continue
+		}
+		if !srvr.hasUpAdjacency(intf, intfLevel.level) {
+			for lspId, _ := range intfLevel.srm {
+				delete(intfLevel.srm, lspId)
+			}
+			for lspId, _ := range intfLevel.ssn {
+				delete(intfLevel.ssn, lspId)
+			}
+			continue
+		}
+		lsdb := srvr.getLsdb(intfLevel.level)
+		for _, lspId := range srvr.sortedLspIds(intfLevel.level) {
+			timer, exist := intfLevel.srm[lspId]
+			if !exist {
+				continue
+			}
+			if timer > 0 {
+				intfLevel.srm[lspId] = timer - 1
+				continue
+			}
+			entry := lsdb[lspId]
+			pdu := make([]byte, len(entry.Raw))
+			copy(pdu, entry.Raw)
+			packet.SetLspLifetime(pdu, entry.Remaining)
+			srvr.sendPdu(intf, levelMulticastMac(intf, intfLevel.level), pdu)
+			if intf.isP2P() {
+				intfLevel.srm[lspId] = LSP_RETRANSMIT_INTERVAL
+			} else {
+				delete(intfLevel.srm, lspId)
+			}
+		}
+		for lspId, _ := range intfLevel.srm {
+			if _, exist := lsdb[lspId]; !exist {
+				delete(intfLevel.srm, lspId)
+			}
+		}
+		if len(intfLevel.ssn) != 0 {
+			srvr.sendPsnps(intf, intfLevel)
+		}
+		if !intf.isP2P() && intfLevel.isDis {
+			if intfLevel.csnpTimer <= 1 {
+				srvr.sendCsnps(intf, intfLevel)
+				intfLevel.csnpTimer = CSNP_INTERVAL
+			} else {
+				intfLevel.csnpTimer--
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+	"l3/isis/packet"
+	"net"
+	"time"
+)
+
+const (
+	ISIS_PCAP_FILTER      = "ether dst 01:80:c2:00:00:14 or ether dst 01:80:c2:00:00:15 or ether dst 09:00:2b:00:00:05"
+	ISIS_PCAP_TIMEOUT     = 1 * time.Second
+	ISIS_PCAP_SNAPSHOTLEN = 1600
+	ISIS_PCAP_PROMISCUOUS = true
+)
+
+type RxPkt struct {
+	IntfRef string
+	Frame   []byte
+}
+
+/* Per level state of a circuit */
+type IntfLevel struct {
+	level      uint8
+	adjs       map[string]*Adjacency // LAN adjacencies, key is neighbor SNPA
+	lanId      packet.NodeId
+	isDis      bool
+	helloTimer uint16
+	csnpTimer  uint16
+	srm        map[packet.LspId]uint16 // LSPs to be sent, value is seconds till (re)transmission
+	ssn        map[packet.LspId]bool   // LSPs to be acked or requested through PSNP
+}
+
+type Intf struct {
+	IntfRef    string
+	Cfg        IntfConfig
+	configured bool
+	IfIndex    int32
+	MacAddr    net.HardwareAddr
+	OperState  bool
+	IsLoopback bool
+	IPv4Addrs  []net.IPNet // IP is the interface address
+	IPv6Addrs  []net.IPNet
+	CircuitId  uint8
+	running    bool
+	levelMask  uint8
+	levels     [2]*IntfLevel
+	p2pAdj     *Adjacency
+	p2pHello   uint16
+	pcapHdl    *pcap.Handle
+}
+
+func newIntf(intfRef string) *Intf {
+	return &Intf{
+		IntfRef: intfRef,
+		IfIndex: -1,
+	}
+}
+
+func newIntfLevel(level uint8) *IntfLevel {
+	return &IntfLevel{
+		level: level,
+		adjs:  make(map[string]*Adjacency),
+		srm:   make(map[packet.LspId]uint16),
+		ssn:   make(map[packet.LspId]bool),
+	}
+}
+
+func (intf *Intf) getLevel(level uint8) *IntfLevel {
+	if level != packet.Level1 && level != packet.Level2 {
+		return nil
+	}
+	return intf.levels[level-1]
+}
+
+func (intf *Intf) isP2P() bool {
+	return intf.Cfg.NetworkType == NETWORK_P2P
+}
+
+/* Passive circuits advertise their prefixes but never form adjacencies */
+func (intf *Intf) isPassive() bool {
+	return intf.Cfg.Passive || intf.IsLoopback
+}
+
+/* Address on the interface which is in the same subnet as ip */
+func (intf *Intf) matchIPv4Subnet(ip net.IP) bool {
+	for _, ipNet := range intf.IPv4Addrs {
+		subnet := net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (srvr *DmnServer) intfRunnable(intf *Intf) bool {
+	return srvr.globalCfg.Enable && intf.configured && intf.Cfg.Enable &&
+		intf.OperState && intf.IfIndex != -1
+}
+
+func (srvr *DmnServer) intfLevels(intf *Intf) uint8 {
+	return intf.Cfg.CircuitType & srvr.globalCfg.IsType
+}
+
+func (srvr *DmnServer) allocCircuitId() uint8 {
+	used := make(map[uint8]bool)
+	for _, intf := range srvr.intfMap {
+		if intf.running {
+			used[intf.CircuitId] = true
+		}
+	}
+	for id := 1; id <= 255; id++ {
+		if !used[uint8(id)] {
+			return uint8(id)
+		}
+	}
+	return 0
+}
+
+func (srvr *DmnServer) startIntf(intf *Intf) {
+	if intf.running || !srvr.intfRunnable(intf) {
+		return
+	}
+	srvr.Logger.Info("Starting IS-IS on interface", intf.IntfRef)
+	intf.running = true
+	intf.CircuitId = srvr.allocCircuitId()
+	intf.levelMask = srvr.intfLevels(intf)
+	for _, level := range []uint8{packet.Level1, packet.Level2} {
+		if intf.levelMask&level == 0 {
+			continue
+		}
+		intfLevel := newIntfLevel(level)
+		intfLevel.lanId = packet.MakeNodeId(srvr.SystemId, intf.CircuitId)
+		intf.levels[level-1] = intfLevel
+	}
+	if intf.isPassive() {
+		return
+	}
+	if srvr.pcapEnabled {
+		err := srvr.openPcap(intf)
+		if err != nil {
+			srvr.Logger.Err("Failed to open pcap for", intf.IntfRef, err)
+		}
+	}
+	srvr.sendHellos(intf)
+}
+
+func (srvr *DmnServer) stopIntf(intf *Intf) {
+	if !intf.running {
+		return
+	}
+	srvr.Logger.Info("Stopping IS-IS on interface", intf.IntfRef)
+	if intf.p2pAdj != nil {
+		srvr.deleteP2PAdjacency(intf)
+	}
+	for _, intfLevel := range intf.levels {
+		if intfLevel == nil {
+			continue
+		}
+		for key, _ := range intfLevel.adjs {
+			delete(intfLevel.adjs, key)
+		}
+		if intfLevel.isDis {
+			srvr.purgePseudonodeLsp(intfLevel.level, intf.CircuitId)
+		}
+		srvr.scheduleSpf(intfLevel.level)
+	}
+	srvr.closePcap(intf)
+	intf.levels = [2]*IntfLevel{}
+	intf.running = false
+	intf.levelMask = 0
+}
+
+/*@fn updateIntfLevels
+Restart the circuit when its operational levels or runnable state changed
+*/
+func (srvr *DmnServer) updateIntfLevels(intf *Intf) {
+	runnable := srvr.intfRunnable(intf)
+	if intf.running == runnable && (!runnable || intf.levelMask == srvr.intfLevels(intf)) {
+		return
+	}
+	srvr.stopIntf(intf)
+	srvr.startIntf(intf)
+}
+
+/*@fn UpdateIntfInfo
+Update interface properties learnt from the switch
+*/
+func (srvr *DmnServer) UpdateIntfInfo(info IntfInfo) {
+	intf, exist := srvr.intfMap[info.IntfRef]
+	if !exist {
+		intf = newIntf(info.IntfRef)
+		srvr.intfMap[info.IntfRef] = intf
+	}
+	intf.IfIndex = info.IfIndex
+	intf.OperState = info.OperState
+	intf.IsLoopback = info.IsLoopback
+	intf.MacAddr, _ = net.ParseMAC(info.MacAddr)
+	intf.IPv4Addrs = nil
+	intf.IPv6Addrs = nil
+	for _, addr := range info.IPv4Addrs {
+		ip, ipNet, err := net.ParseCIDR(addr)
+		if err != nil {
+			continue
+		}
+		intf.IPv4Addrs = append(intf.IPv4Addrs, net.IPNet{IP: ip.To4(), Mask: ipNet.Mask})
+	}
+	for _, addr := range info.IPv6Addrs {
+		ip, ipNet, err := net.ParseCIDR(addr)
+		if err != nil {
+			continue
+		}
+		intf.IPv6Addrs = append(intf.IPv6Addrs, net.IPNet{IP: ip, Mask: ipNet.Mask})
+	}
+	srvr.updateIntfLevels(intf)
+	if intf.running {
+		srvr.scheduleLspGen(packet.Level1_2)
+	}
+}
+
+func (srvr *DmnServer) openPcap(intf *Intf) error {
+	hdl, err := pcap.OpenLive(intf.IntfRef, ISIS_PCAP_SNAPSHOTLEN, ISIS_PCAP_PROMISCUOUS, ISIS_PCAP_TIMEOUT)
+	if err != nil {
+		return err
+	}
+	err = hdl.SetBPFFilter(ISIS_PCAP_FILTER)
+	if err != nil {
+		hdl.Close()
+		return err
+	}
+	intf.pcapHdl = hdl
+	go srvr.receivePkts(intf.IntfRef, hdl)
+	return nil
+}
+
+func (srvr *DmnServer) closePcap(intf *Intf) {
+	if intf.pcapHdl != nil {
+		// Closing the handle terminates the rx go routine
+		intf.pcapHdl.Close()
+		intf.pcapHdl = nil
+	}
+}
+
+func (srvr *DmnServer) receivePkts(intfRef string, hdl *pcap.Handle) {
+	src := gopacket.NewPacketSource(hdl, layers.LayerTypeEthernet)
+	in := src.Packets()
+	for {
+		pkt, ok := <-in
+		if !ok {
+			srvr.Logger.Info("Pcap closed for", intfRef, "exiting rx routine")
+			return
+		}
+		srvr.RxPktCh <- RxPkt{
+			IntfRef: intfRef,
+			Frame:   pkt.Data(),
+		}
+	}
+}
+
+func (srvr *DmnServer) pcapTx(intfRef string, frame []byte) error {
+	intf, exist := srvr.intfMap[intfRef]
+	if !exist || intf.pcapHdl == nil {
+		return errors.New(fmt.Sprintln("No pcap handle for", intfRef))
+	}
+	return intf.pcapHdl.WritePacketData(frame)
+}
+
+func (srvr *DmnServer) sendPdu(intf *Intf, dstMac net.HardwareAddr, pdu []byte) {
+	frame := packet.EncodeFrame(dstMac, intf.MacAddr, pdu)
+	err := srvr.txPkt(intf.IntfRef, frame)
+	if err != nil {
+		srvr.Logger.Err("Failed to send IS-IS PDU on", intf.IntfRef, err)
+	}
+}
+
+func levelMulticastMac(intf *Intf, level uint8) net.HardwareAddr {
+	if intf.isP2P() {
+		return packet.AllISs
+	}
+	if level == packet.Level2 {
+		return packet.AllL2ISs
+	}
+	return packet.AllL1ISs
+}
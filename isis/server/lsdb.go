@@ -0,0 +1,204 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"l3/isis/packet"
+	"sort"
+)
+
+type LsdbEntry struct {
+	Lsp       *packet.Lsp
+	Raw       []byte // Encoded PDU as originated
+	Remaining uint16
+	self      bool
+	zeroAge   uint16 // Seconds a purged LSP is held before removal
+	refresh   uint16 // Seconds till a self originated LSP is refreshed
+}
+
+func (srvr *DmnServer) getLsdb(level uint8) map[packet.LspId]*LsdbEntry {
+	if level != packet.Level1 && level != packet.Level2 {
+		return nil
+	}
+	return srvr.lsdb[level-1]
+}
+
+func (entry *LsdbEntry) lspEntry() packet.LspEntry {
+	return packet.LspEntry{
+		RemainingLifetime: entry.Remaining,
+		LspId:             entry.Lsp.LspId,
+		SeqNum:            entry.Lsp.SeqNum,
+		Checksum:          entry.Lsp.Checksum,
+	}
+}
+
+/*@fn compareLsp
+Returns 1 if LSP a is newer than b, -1 if older and 0 if both are same.
+With equal sequence numbers a purged LSP is newer.
+*/
+func compareLsp(seqA uint32, lifetimeA uint16, seqB uint32, lifetimeB uint16) int {
+	if seqA > seqB {
+		return 1
+	}
+	if seqA < seqB {
+		return -1
+	}
+	if lifetimeA == 0 && lifetimeB != 0 {
+		return 1
+	}
+	if lifetimeA != 0 && lifetimeB == 0 {
+		return -1
+	}
+	return 0
+}
+
+func lspContentEqual(a, b *packet.Lsp) bool {
+	lspA := *a
+	lspB := *b
+	lspA.SeqNum, lspB.SeqNum = 0, 0
+	lspA.RemainingLifetime, lspB.RemainingLifetime = 1, 1
+	return bytes.Equal(packet.EncodeLsp(&lspA), packet.EncodeLsp(&lspB))
+}
+
+/*@fn installLsp
+Install the LSP in the database. Purged LSPs are stored without TLVs.
+*/
+func (srvr *DmnServer) installLsp(level uint8, lsp *packet.Lsp, raw []byte, self bool) *LsdbEntry {
+	lsdb := srvr.getLsdb(level)
+	if lsp.RemainingLifetime == 0 && len(lsp.Tlvs.AreaAddresses)+len(lsp.Tlvs.ExtIsReach) != 0 {
+		purged := *lsp
+		purged.Tlvs = packet.Tlvs{}
+		lsp = &purged
+		raw = packet.EncodeLsp(lsp)
+	}
+	entry := &LsdbEntry{
+		Lsp:       lsp,
+		Raw:       raw,
+		Remaining: lsp.RemainingLifetime,
+		self:      self,
+	}
+	if entry.Remaining == 0 {
+		entry.zeroAge = ZERO_AGE_LIFETIME
+	}
+	if self {
+		entry.refresh = srvr.globalCfg.LspRefreshInterval
+	}
+	lsdb[lsp.LspId] = entry
+	srvr.scheduleSpf(level)
+	return entry
+}
+
+/*@fn purgeLsp
+Purge the LSP with its current sequence number and flood the purge
+*/
+func (srvr *DmnServer) purgeLsp(level uint8, entry *LsdbEntry) {
+	srvr.Logger.Info("Purging level", level, "LSP", entry.Lsp.LspId.String())
+	lsp := &packet.Lsp{
+		Level:  level,
+		LspId:  entry.Lsp.LspId,
+		SeqNum: entry.Lsp.SeqNum,
+	}
+	srvr.installLsp(level, lsp, packet.EncodeLsp(lsp), false)
+	srvr.floodLsp(level, lsp.LspId, nil)
+}
+
+func (srvr *DmnServer) purgePseudonodeLsp(level uint8, circuitId uint8) {
+	lspId := packet.MakeLspId(packet.MakeNodeId(srvr.SystemId, circuitId), 0)
+	entry, exist := srvr.getLsdb(level)[lspId]
+	if !exist || !entry.self || entry.Remaining == 0 {
+		return
+	}
+	srvr.purgeLsp(level, entry)
+}
+
+type lspIdSlice []packet.LspId
+
+func (ids lspIdSlice) Len() int {
+	return len(ids)
+}
+func (ids lspIdSlice) Less(i, j int) bool {
+	return bytes.Compare(ids[i][:], ids[j][:]) < 0
+}
+func (ids lspIdSlice) Swap(i, j int) {
+	ids[i], ids[j] = ids[j], ids[i]
+}
+
+/* Sorted LSP ids of the level database */
+func (srvr *DmnServer) sortedLspIds(level uint8) []packet.LspId {
+	lsdb := srvr.getLsdb(level)
+	ids := make([]packet.LspId, 0, len(lsdb))
+	for lspId, _ := range lsdb {
+		ids = append(ids, lspId)
+	}
+	sort.Sort(lspIdSlice(ids))
+	return ids
+}
+
+/*@fn processLsdbAging
+Age the database every second. Expired LSPs are purged and held for
+ZeroAgeLifetime, self originated LSPs are refreshed.
+*/
+func (srvr *DmnServer) processLsdbAging(level uint8) {
+	lsdb := srvr.getLsdb(level)
+	for lspId, entry := range lsdb {
+		if entry.Remaining == 0 {
+			if entry.zeroAge <= 1 {
+				delete(lsdb, lspId)
+				srvr.clearSrm(level, lspId)
+			} else {
+				entry.zeroAge--
+			}
+			continue
+		}
+		entry.Remaining--
+		if entry.self {
+			if entry.refresh <= 1 {
+				srvr.refreshOwnLsp(level, entry)
+			} else {
+				entry.refresh--
+			}
+			continue
+		}
+		if entry.Remaining == 0 {
+			srvr.Logger.Info("Level", level, "LSP", lspId.String(), "expired")
+			srvr.purgeLsp(level, entry)
+		}
+	}
+}
+
+func (srvr *DmnServer) clearSrm(level uint8, lspId packet.LspId) {
+	for _, intf := range srvr.intfMap {
+		if intfLevel := intf.getLevel(level); intfLevel != nil {
+			delete(intfLevel.srm, lspId)
+			delete(intfLevel.ssn, lspId)
+		}
+	}
+}
+
+func (srvr *DmnServer) flushLsdb() {
+	for idx, _ := range srvr.lsdb {
+		srvr.lsdb[idx] = make(map[packet.LspId]*LsdbEntry)
+	}
+}
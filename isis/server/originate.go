@@ -0,0 +1,218 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/isis/packet"
+	"net"
+)
+
+func (srvr *DmnServer) levelEnabled(level uint8) bool {
+	return srvr.globalCfg.Enable && srvr.globalCfg.IsType&level != 0
+}
+
+func (srvr *DmnServer) scheduleLspGen(levels uint8) {
+	srvr.lspGenPending |= levels
+}
+
+func maskedPrefix(ipNet net.IPNet) net.IPNet {
+	return net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask}
+}
+
+/*@fn buildOwnLspTlvs
+TLVs of the non pseudonode LSP. Reachability to LAN neighbors is advertised
+through the pseudonode of the DIS.
+*/
+func (srvr *DmnServer) buildOwnLspTlvs(level uint8) packet.Tlvs {
+	tlvs := packet.Tlvs{
+		AreaAddresses:      srvr.AreaAddrs,
+		ProtocolsSupported: []uint8{packet.NLPID_IPV4, packet.NLPID_IPV6},
+		Hostname:           srvr.globalCfg.Hostname,
+	}
+	prefixes := make(map[string]bool)
+	for _, intfRef := range srvr.sortedIntfRefs() {
+		intf := srvr.intfMap[intfRef]
+		if !intf.running || intf.getLevel(level) == nil {
+			continue
+		}
+		for _, ipNet := range intf.IPv4Addrs {
+			tlvs.IPv4IntfAddrs = append(tlvs.IPv4IntfAddrs, ipNet.IP)
+			prefix := maskedPrefix(ipNet)
+			if !prefixes[prefix.String()] {
+				prefixes[prefix.String()] = true
+				tlvs.ExtIPReach = append(tlvs.ExtIPReach, packet.ExtIPReach{
+					Metric: intf.Cfg.Metric,
+					Prefix: prefix,
+				})
+			}
+		}
+		for _, ipNet := range intf.IPv6Addrs {
+			if ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			tlvs.IPv6IntfAddrs = append(tlvs.IPv6IntfAddrs, ipNet.IP)
+			prefix := maskedPrefix(ipNet)
+			if !prefixes[prefix.String()] {
+				prefixes[prefix.String()] = true
+				tlvs.IPv6Reach = append(tlvs.IPv6Reach, packet.IPv6Reach{
+					Metric: intf.Cfg.Metric,
+					Prefix: prefix,
+				})
+			}
+		}
+		if intf.isPassive() {
+			continue
+		}
+		if intf.isP2P() {
+			if adj := srvr.getUpAdjacency(intf, level, nil); adj != nil {
+				tlvs.ExtIsReach = append(tlvs.ExtIsReach, packet.ExtIsReach{
+					NbrId:  packet.MakeNodeId(adj.SystemId, 0),
+					Metric: intf.Cfg.Metric,
+				})
+			}
+			continue
+		}
+		intfLevel := intf.getLevel(level)
+		if intfLevel.isDis || srvr.disAdjacencyUp(intfLevel) {
+			tlvs.ExtIsReach = append(tlvs.ExtIsReach, packet.ExtIsReach{
+				NbrId:  intfLevel.lanId,
+				Metric: intf.Cfg.Metric,
+			})
+		}
+	}
+	if level == packet.Level2 && srvr.levelEnabled(packet.Level1) {
+		// L1 routes are leaked into level 2
+		for _, key := range sortedRouteKeys(srvr.spfRoutes[packet.Level1-1]) {
+			route := srvr.spfRoutes[packet.Level1-1][key]
+			if prefixes[key] || route.Prefix.String() == defaultV4Prefix || route.Prefix.String() == defaultV6Prefix {
+				continue
+			}
+			if route.Prefix.IP.To4() != nil {
+				tlvs.ExtIPReach = append(tlvs.ExtIPReach, packet.ExtIPReach{
+					Metric: route.Metric,
+					Prefix: route.Prefix,
+				})
+			} else {
+				tlvs.IPv6Reach = append(tlvs.IPv6Reach, packet.IPv6Reach{
+					Metric: route.Metric,
+					Prefix: route.Prefix,
+				})
+			}
+		}
+	}
+	return tlvs
+}
+
+/* Adjacency with the DIS of a LAN circuit is Up */
+func (srvr *DmnServer) disAdjacencyUp(intfLevel *IntfLevel) bool {
+	for _, adj := range intfLevel.adjs {
+		if adj.State == ADJ_STATE_UP && adj.SystemId == intfLevel.lanId.SystemId() {
+			return true
+		}
+	}
+	return false
+}
+
+func (srvr *DmnServer) buildPseudonodeTlvs(intfLevel *IntfLevel) packet.Tlvs {
+	tlvs := packet.Tlvs{}
+	tlvs.ExtIsReach = append(tlvs.ExtIsReach, packet.ExtIsReach{
+		NbrId: packet.MakeNodeId(srvr.SystemId, 0),
+	})
+	for _, adj := range sortedAdjs(intfLevel) {
+		if adj.State == ADJ_STATE_UP {
+			tlvs.ExtIsReach = append(tlvs.ExtIsReach, packet.ExtIsReach{
+				NbrId: packet.MakeNodeId(adj.SystemId, 0),
+			})
+		}
+	}
+	return tlvs
+}
+
+/*@fn updateOwnLsp
+Install and flood the self originated LSP if its contents changed
+*/
+func (srvr *DmnServer) updateOwnLsp(level uint8, lspId packet.LspId, tlvs packet.Tlvs, attached bool) {
+	lsp := &packet.Lsp{
+		Level:             level,
+		RemainingLifetime: srvr.globalCfg.LspLifetime,
+		LspId:             lspId,
+		SeqNum:            1,
+		Attached:          attached,
+		Overload:          srvr.globalCfg.Overload && !lspId.NodeId().IsPseudonode(),
+		IsType:            srvr.globalCfg.IsType,
+		Tlvs:              tlvs,
+	}
+	entry, exist := srvr.getLsdb(level)[lspId]
+	if exist {
+		if entry.self && entry.Remaining != 0 && lspContentEqual(entry.Lsp, lsp) {
+			return
+		}
+		lsp.SeqNum = entry.Lsp.SeqNum + 1
+	}
+	raw := packet.EncodeLsp(lsp)
+	if len(raw) > packet.ISIS_MAX_PDU_LEN {
+		srvr.Logger.Err("Level", level, "LSP", lspId.String(), "exceeds maximum PDU size", len(raw))
+	}
+	srvr.Logger.Info("Originating level", level, "LSP", lspId.String(), "seq", lsp.SeqNum)
+	srvr.installLsp(level, lsp, raw, true)
+	srvr.floodLsp(level, lspId, nil)
+}
+
+func (srvr *DmnServer) refreshOwnLsp(level uint8, entry *LsdbEntry) {
+	lsp := *entry.Lsp
+	lsp.SeqNum++
+	lsp.RemainingLifetime = srvr.globalCfg.LspLifetime
+	srvr.installLsp(level, &lsp, packet.EncodeLsp(&lsp), true)
+	srvr.floodLsp(level, lsp.LspId, nil)
+}
+
+func (srvr *DmnServer) originateLsps(level uint8) {
+	if !srvr.levelEnabled(level) {
+		return
+	}
+	attached := level == packet.Level1 && srvr.globalCfg.IsType == packet.Level1_2 && srvr.attached
+	lspId := packet.MakeLspId(packet.MakeNodeId(srvr.SystemId, 0), 0)
+	srvr.updateOwnLsp(level, lspId, srvr.buildOwnLspTlvs(level), attached)
+	for _, intf := range srvr.intfMap {
+		if !intf.running || intf.isP2P() || intf.isPassive() {
+			continue
+		}
+		intfLevel := intf.getLevel(level)
+		if intfLevel == nil || !intfLevel.isDis {
+			continue
+		}
+		lspId := packet.MakeLspId(intfLevel.lanId, 0)
+		srvr.updateOwnLsp(level, lspId, srvr.buildPseudonodeTlvs(intfLevel), false)
+	}
+}
+
+func (srvr *DmnServer) processLspGen() {
+	levels := srvr.lspGenPending
+	srvr.lspGenPending = 0
+	for _, level := range []uint8{packet.Level1, packet.Level2} {
+		if levels&level != 0 {
+			srvr.originateLsps(level)
+		}
+	}
+}
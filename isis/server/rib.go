@@ -0,0 +1,229 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/json"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"io/ioutil"
+	"net"
+	"ribd"
+	"sort"
+	"strconv"
+	"time"
+	"utils/ipcutils"
+)
+
+const ROUTE_PROTOCOL = "ISIS"
+
+type ClientJson struct {
+	Name string `json:"Name"`
+	Port int    `json:"Port"`
+}
+
+type RibdClient struct {
+	Address            string
+	Transport          thrift.TTransport
+	PtrProtocolFactory *thrift.TBinaryProtocolFactory
+	IsConnected        bool
+	ClientHdl          *ribd.RIBDServicesClient
+}
+
+type Route struct {
+	Prefix   net.IPNet
+	Level    uint8
+	Metric   uint32
+	NextHops map[NextHop]bool
+}
+
+func sortedRouteKeys(routes map[string]*Route) []string {
+	keys := make([]string, 0, len(routes))
+	for key, _ := range routes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func routeEqual(a, b *Route) bool {
+	if a.Level != b.Level || a.Metric != b.Metric || len(a.NextHops) != len(b.NextHops) {
+		return false
+	}
+	for nextHop, _ := range a.NextHops {
+		if !b.NextHops[nextHop] {
+			return false
+		}
+	}
+	return true
+}
+
+func routeTblEqual(a, b map[string]*Route) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, route := range a {
+		other, exist := b[key]
+		if !exist || !routeEqual(route, other) {
+			return false
+		}
+	}
+	return true
+}
+
+func (srvr *DmnServer) ConnectToClients(paramsFile string) {
+	var clientsList []ClientJson
+
+	bytes, err := ioutil.ReadFile(paramsFile)
+	if err != nil {
+		srvr.Logger.Err("Error in reading configuration file", paramsFile)
+		return
+	}
+	err = json.Unmarshal(bytes, &clientsList)
+	if err != nil {
+		srvr.Logger.Err("Error in Unmarshalling Json")
+		return
+	}
+	for _, client := range clientsList {
+		if client.Name != "ribd" {
+			continue
+		}
+		srvr.Logger.Info("found ribd at port", client.Port)
+		srvr.ribdClient.Address = "localhost:" + strconv.Itoa(client.Port)
+		srvr.ribdClient.Transport, srvr.ribdClient.PtrProtocolFactory, err = ipcutils.CreateIPCHandles(srvr.ribdClient.Address)
+		if err != nil {
+			srvr.Logger.Info("Failed to connect to Ribd, retrying until connection is successful")
+			count := 0
+			ticker := time.NewTicker(time.Duration(1000) * time.Millisecond)
+			for _ = range ticker.C {
+				srvr.ribdClient.Transport, srvr.ribdClient.PtrProtocolFactory, err = ipcutils.CreateIPCHandles(srvr.ribdClient.Address)
+				if err == nil {
+					ticker.Stop()
+					break
+				}
+				count++
+				if (count % 10) == 0 {
+					srvr.Logger.Info("Still can't connect to Ribd, retrying..")
+				}
+			}
+		}
+		srvr.Logger.Info("Isisd is connected to Ribd")
+		srvr.ribdClient.ClientHdl = ribd.NewRIBDServicesClientFactory(srvr.ribdClient.Transport, srvr.ribdClient.PtrProtocolFactory)
+		srvr.ribdClient.IsConnected = true
+	}
+}
+
+func (srvr *DmnServer) installRoute(route *Route) {
+	if srvr.ribdClient.ClientHdl == nil {
+		return
+	}
+	destNw := route.Prefix.IP.String()
+	mask := net.IP(route.Prefix.Mask).String()
+	for nextHop, _ := range route.NextHops {
+		nextHopInfo := &ribd.NextHopInfo{
+			NextHopIntRef: strconv.Itoa(int(nextHop.IfIndex)),
+		}
+		var err error
+		if route.Prefix.IP.To4() != nil {
+			nextHopInfo.NextHopIp = nextHop.IPv4Addr
+			cfg := ribd.IPv4Route{
+				DestinationNw: destNw,
+				NetworkMask:   mask,
+				Protocol:      ROUTE_PROTOCOL,
+				Cost:          int32(route.Metric),
+				NextHop:       []*ribd.NextHopInfo{nextHopInfo},
+			}
+			_, err = srvr.ribdClient.ClientHdl.CreateIPv4Route(&cfg)
+		} else {
+			nextHopInfo.NextHopIp = nextHop.IPv6Addr
+			cfg := ribd.IPv6Route{
+				DestinationNw: destNw,
+				NetworkMask:   mask,
+				Protocol:      ROUTE_PROTOCOL,
+				Cost:          int32(route.Metric),
+				NextHop:       []*ribd.NextHopInfo{nextHopInfo},
+			}
+			_, err = srvr.ribdClient.ClientHdl.CreateIPv6Route(&cfg)
+		}
+		if err != nil {
+			srvr.Logger.Err("Error installing route", route.Prefix.String(), "via", nextHopInfo.NextHopIp, err)
+		}
+	}
+}
+
+func (srvr *DmnServer) deleteRoute(route *Route) {
+	if srvr.ribdClient.ClientHdl == nil {
+		return
+	}
+	destNw := route.Prefix.IP.String()
+	mask := net.IP(route.Prefix.Mask).String()
+	for nextHop, _ := range route.NextHops {
+		nextHopInfo := &ribd.NextHopInfo{}
+		var err error
+		if route.Prefix.IP.To4() != nil {
+			nextHopInfo.NextHopIp = nextHop.IPv4Addr
+			cfg := ribd.IPv4Route{
+				DestinationNw: destNw,
+				NetworkMask:   mask,
+				Protocol:      ROUTE_PROTOCOL,
+				NextHop:       []*ribd.NextHopInfo{nextHopInfo},
+			}
+			_, err = srvr.ribdClient.ClientHdl.DeleteIPv4Route(&cfg)
+		} else {
+			nextHopInfo.NextHopIp = nextHop.IPv6Addr
+			cfg := ribd.IPv6Route{
+				DestinationNw: destNw,
+				NetworkMask:   mask,
+				Protocol:      ROUTE_PROTOCOL,
+				NextHop:       []*ribd.NextHopInfo{nextHopInfo},
+			}
+			_, err = srvr.ribdClient.ClientHdl.DeleteIPv6Route(&cfg)
+		}
+		if err != nil {
+			srvr.Logger.Err("Error deleting route", route.Prefix.String(), "via", nextHopInfo.NextHopIp, err)
+		}
+	}
+}
+
+/*@fn updateRib
+Install the difference between the current and the new route table
+*/
+func (srvr *DmnServer) updateRib(routeTbl map[string]*Route) {
+	for _, key := range sortedRouteKeys(srvr.routeTbl) {
+		route := srvr.routeTbl[key]
+		newRoute, exist := routeTbl[key]
+		if !exist || !routeEqual(route, newRoute) {
+			srvr.Logger.Info("Deleting route", key)
+			srvr.deleteRoute(route)
+		}
+	}
+	for _, key := range sortedRouteKeys(routeTbl) {
+		route := routeTbl[key]
+		oldRoute, exist := srvr.routeTbl[key]
+		if !exist || !routeEqual(route, oldRoute) {
+			srvr.Logger.Info("Installing route", key, "metric", route.Metric)
+			srvr.installRoute(route)
+		}
+	}
+	srvr.routeTbl = routeTbl
+}
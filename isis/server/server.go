@@ -24,61 +24,243 @@
 package server
 
 import (
-        "utils/dbutils"
-        "utils/keepalive"
-        "utils/logging"
+	"infra/sysd/sysdCommonDefs"
+	"l3/isis/packet"
+	"time"
+	"utils/asicdClient"
+	"utils/commonDefs"
+	"utils/dbutils"
+	"utils/keepalive"
+	"utils/logging"
 )
 
+type TxPktFunc func(intfRef string, frame []byte) error
+
 type DmnServer struct {
-        // store info related to server
-        DbHdl          dbutils.DBIntf
-        Logger         logging.LoggerIntf
-        InitCompleteCh chan bool
+	// store info related to server
+	DbHdl          dbutils.DBIntf
+	Logger         logging.LoggerIntf
+	InitCompleteCh chan bool
+	ReqChan        chan *ServerRequest
+	AsicdNotifyCh  chan commonDefs.AsicdNotifyMsg
+	RxPktCh        chan RxPkt
+	SwitchPlugin   asicdClient.AsicdClientIntf
+	paramsDir      string
+
+	globalCfg   GlobalConfig
+	SystemId    packet.SystemId
+	AreaAddrs   [][]byte
+	intfMap     map[string]*Intf
+	intfInfoMap map[string]*IntfInfo
+	ifIndexMap  map[int32]string
+
+	lsdb          [2]map[packet.LspId]*LsdbEntry
+	lspGenPending uint8
+	spfPending    uint8
+	spfHoldTimer  uint16
+	spfRuns       uint32
+	spfRoutes     [2]map[string]*Route
+	routeTbl      map[string]*Route
+	attached      bool // Level 2 reaches other areas
+
+	ribdClient  RibdClient
+	pcapEnabled bool
+	txPkt       TxPktFunc
 }
 
 type ServerInitParams struct {
-        DmnName     string
-        ParamsDir   string
-        CfgFileName string
-        DbHdl       dbutils.DBIntf
-        Logger      logging.LoggerIntf
+	DmnName     string
+	ParamsDir   string
+	CfgFileName string
+	DbHdl       dbutils.DBIntf
+	Logger      logging.LoggerIntf
 }
 
 func NewISISDServer(initParams *ServerInitParams) *DmnServer {
-        srvr := DmnServer{}
-        srvr.DbHdl = initParams.DbHdl
-        srvr.Logger = initParams.Logger
-        srvr.InitCompleteCh = make(chan bool)
-
-        // setup whatever you need for your server
+	srvr := DmnServer{}
+	srvr.DbHdl = initParams.DbHdl
+	srvr.Logger = initParams.Logger
+	srvr.paramsDir = initParams.ParamsDir
+	srvr.InitCompleteCh = make(chan bool)
+	srvr.ReqChan = make(chan *ServerRequest)
+	srvr.AsicdNotifyCh = make(chan commonDefs.AsicdNotifyMsg)
+	srvr.RxPktCh = make(chan RxPkt, 100)
+	srvr.intfMap = make(map[string]*Intf)
+	srvr.intfInfoMap = make(map[string]*IntfInfo)
+	srvr.ifIndexMap = make(map[int32]string)
+	srvr.flushLsdb()
+	srvr.routeTbl = make(map[string]*Route)
+	srvr.pcapEnabled = true
+	srvr.txPkt = srvr.pcapTx
+	return &srvr
+}
 
-        return &srvr
+/*@fn SetTxPktFunc
+Send IS-IS frames through fn instead of pcap
+*/
+func (srvr *DmnServer) SetTxPktFunc(fn TxPktFunc) {
+	srvr.pcapEnabled = false
+	srvr.txPkt = fn
 }
 
 func (srvr *DmnServer) initServer() error {
-        // initize the daemon server here
-        return nil
+	srvr.ConnectToClients(srvr.paramsDir + "/clients.json")
+	srvr.buildIntfInfo()
+	return nil
+}
+
+func (srvr *DmnServer) startInstance() {
+	srvr.Logger.Info("Starting IS-IS instance, system id", srvr.SystemId.String())
+	for _, intf := range srvr.intfMap {
+		srvr.startIntf(intf)
+	}
+	srvr.scheduleLspGen(packet.Level1_2)
+}
+
+func (srvr *DmnServer) stopInstance() {
+	srvr.Logger.Info("Stopping IS-IS instance")
+	for _, intf := range srvr.intfMap {
+		srvr.stopIntf(intf)
+	}
+	srvr.flushLsdb()
+	srvr.lspGenPending = 0
+	srvr.spfPending = 0
+	srvr.spfRoutes = [2]map[string]*Route{}
+	srvr.attached = false
+	srvr.updateRib(make(map[string]*Route))
+}
+
+/*@fn HandleRequest
+Process config and state requests. Config requests return error,
+state requests return the state.
+*/
+func (srvr *DmnServer) HandleRequest(req *ServerRequest) interface{} {
+	switch req.Op {
+	case CREATE_GLOBAL, UPDATE_GLOBAL, DELETE_GLOBAL:
+		return srvr.processGlobalConfig(req.Data.(GlobalConfig), req.Op)
+	case CREATE_INTF, UPDATE_INTF, DELETE_INTF:
+		return srvr.processIntfConfig(req.Data.(IntfConfig), req.Op)
+	case GET_GLOBAL_STATE:
+		return srvr.getGlobalState()
+	case GET_BULK_INTF_STATE:
+		bulkReq := req.Data.(BulkReq)
+		return srvr.getBulkIntfState(bulkReq.FromIdx, bulkReq.Count)
+	case GET_BULK_ADJ_STATE:
+		bulkReq := req.Data.(BulkReq)
+		return srvr.getBulkAdjState(bulkReq.FromIdx, bulkReq.Count)
+	case GET_BULK_LSP_STATE:
+		bulkReq := req.Data.(BulkReq)
+		return srvr.getBulkLspState(bulkReq.FromIdx, bulkReq.Count)
+	case GET_BULK_ROUTE_STATE:
+		bulkReq := req.Data.(BulkReq)
+		return srvr.getBulkRouteState(bulkReq.FromIdx, bulkReq.Count)
+	}
+	return nil
+}
+
+/*@fn ProcessRxFrame
+Dispatch a received IS-IS frame to the PDU handlers
+*/
+func (srvr *DmnServer) ProcessRxFrame(intfRef string, frame []byte) {
+	intf, exist := srvr.intfMap[intfRef]
+	if !exist || !intf.running || intf.isPassive() {
+		return
+	}
+	_, srcMac, pdu, err := packet.DecodeFrame(frame)
+	if err != nil {
+		return
+	}
+	hdr, err := packet.DecodeCommonHdr(pdu)
+	if err != nil {
+		srvr.Logger.Debug("Invalid IS-IS PDU on", intfRef, err)
+		return
+	}
+	switch hdr.PduType {
+	case packet.L1LanIIH, packet.L2LanIIH:
+		hello, err := packet.DecodeLanHello(pdu)
+		if err != nil {
+			srvr.Logger.Err("Failed to decode LAN hello on", intfRef, err)
+			return
+		}
+		srvr.processLanHello(intf, hello, srcMac)
+	case packet.P2PIIH:
+		hello, err := packet.DecodeP2PHello(pdu)
+		if err != nil {
+			srvr.Logger.Err("Failed to decode point to point hello on", intfRef, err)
+			return
+		}
+		srvr.processP2PHello(intf, hello, srcMac)
+	case packet.L1Lsp, packet.L2Lsp:
+		srvr.processLsp(intf, pdu, srcMac)
+	case packet.L1Csnp, packet.L2Csnp:
+		srvr.processCsnp(intf, pdu, srcMac)
+	case packet.L1Psnp, packet.L2Psnp:
+		srvr.processPsnp(intf, pdu, srcMac)
+	}
+}
+
+/*@fn ProcessTimerTick
+One second timer driving hellos, holding timers, flooding, LSP
+aging, LSP generation and SPF.
+*/
+func (srvr *DmnServer) ProcessTimerTick() {
+	if !srvr.globalCfg.Enable {
+		return
+	}
+	for _, intfRef := range srvr.sortedIntfRefs() {
+		intf := srvr.intfMap[intfRef]
+		if intf.running && !intf.isPassive() {
+			srvr.processHelloTimers(intf)
+		}
+	}
+	for _, level := range []uint8{packet.Level1, packet.Level2} {
+		srvr.processLsdbAging(level)
+	}
+	if srvr.spfHoldTimer > 0 {
+		srvr.spfHoldTimer--
+	} else if srvr.spfPending != 0 {
+		srvr.processSpf()
+		srvr.spfHoldTimer = SPF_HOLD_INTERVAL
+	}
+	if srvr.lspGenPending != 0 {
+		srvr.processLspGen()
+	}
+	for _, intfRef := range srvr.sortedIntfRefs() {
+		intf := srvr.intfMap[intfRef]
+		if intf.running && !intf.isPassive() {
+			srvr.processFloodTimers(intf)
+		}
+	}
 }
 
 func (srvr *DmnServer) Serve() {
-        srvr.Logger.Info("Server initialization started")
-        err := srvr.initServer()
-        if err != nil {
-              panic(err)
-        }
-        daemonStatusListener := keepalive.InitDaemonStatusListener()
-        if daemonStatusListener != nil {
-                go daemonStatusListener.StartDaemonStatusListner()
-        }
-        srvr.InitCompleteCh <- true
-        srvr.Logger.Info("Server initialization complete, starting cfg/state listerner")
-        for {
-                select {
-                //case req := <-srvr.ReqChan:
-                //      srvr.Logger.Info("Server request received - ", *req)
-                //      srvr.handleRPCRequest(req)
-                case daemonStatus := <-daemonStatusListener.DaemonStatusCh:
-                        srvr.Logger.Info("Received daemon status: ", daemonStatus.Name, daemonStatus.Status)
-                }
-        }
+	srvr.Logger.Info("Server initialization started")
+	err := srvr.initServer()
+	if err != nil {
+		panic(err)
+	}
+	var daemonStatusCh chan sysdCommonDefs.DaemonStatus
+	daemonStatusListener := keepalive.InitDaemonStatusListener()
+	if daemonStatusListener != nil {
+		go daemonStatusListener.StartDaemonStatusListner()
+		daemonStatusCh = daemonStatusListener.DaemonStatusCh
+	}
+	srvr.InitCompleteCh <- true
+	srvr.Logger.Info("Server initialization complete, starting cfg/state listerner")
+	ticker := time.NewTicker(time.Second)
+	for {
+		select {
+		case req := <-srvr.ReqChan:
+			srvr.Logger.Debug("Server request received - ", *req)
+			req.ReplyCh <- srvr.HandleRequest(req)
+		case msg := <-srvr.AsicdNotifyCh:
+			srvr.processAsicdNotification(msg)
+		case pkt := <-srvr.RxPktCh:
+			srvr.ProcessRxFrame(pkt.IntfRef, pkt.Frame)
+		case <-ticker.C:
+			srvr.ProcessTimerTick()
+		case daemonStatus := <-daemonStatusCh:
+			srvr.Logger.Info("Received daemon status: ", daemonStatus.Name, daemonStatus.Status)
+		}
+	}
 }
@@ -0,0 +1,380 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/isis/packet"
+	"net"
+	"sort"
+)
+
+const (
+	defaultV4Prefix = "0.0.0.0/0"
+	defaultV6Prefix = "::/0"
+)
+
+/* Node of the SPF graph built from all fragments of an LSP */
+type spfNode struct {
+	nodeId    packet.NodeId
+	isReach   []packet.ExtIsReach
+	ipReach   []packet.ExtIPReach
+	ipv6Reach []packet.IPv6Reach
+	areaAddrs [][]byte
+	attached  bool
+	overload  bool
+}
+
+type NextHop struct {
+	IntfRef  string
+	IfIndex  int32
+	SystemId packet.SystemId
+	IPv4Addr string
+	IPv6Addr string
+}
+
+type spfVertex struct {
+	dist     uint32
+	nextHops map[NextHop]bool
+	viaIntf  *Intf // LAN circuit for pseudonodes directly attached to root
+	done     bool
+}
+
+func (srvr *DmnServer) scheduleSpf(levels uint8) {
+	srvr.spfPending |= levels
+}
+
+func (srvr *DmnServer) sortedIntfRefs() []string {
+	refs := make([]string, 0, len(srvr.intfMap))
+	for intfRef, _ := range srvr.intfMap {
+		refs = append(refs, intfRef)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func (srvr *DmnServer) buildSpfGraph(level uint8) map[packet.NodeId]*spfNode {
+	graph := make(map[packet.NodeId]*spfNode)
+	for _, lspId := range srvr.sortedLspIds(level) {
+		entry := srvr.getLsdb(level)[lspId]
+		if entry.Remaining == 0 {
+			continue
+		}
+		nodeId := lspId.NodeId()
+		node, exist := graph[nodeId]
+		if !exist {
+			node = &spfNode{nodeId: nodeId}
+			graph[nodeId] = node
+		}
+		lsp := entry.Lsp
+		if lspId[packet.ISIS_ID_LEN+1] == 0 {
+			node.attached = lsp.Attached
+			node.overload = lsp.Overload
+			node.areaAddrs = lsp.AreaAddresses
+		}
+		node.isReach = append(node.isReach, lsp.ExtIsReach...)
+		node.ipReach = append(node.ipReach, lsp.ExtIPReach...)
+		node.ipv6Reach = append(node.ipv6Reach, lsp.IPv6Reach...)
+	}
+	return graph
+}
+
+func (node *spfNode) hasNbr(nodeId packet.NodeId) bool {
+	for _, reach := range node.isReach {
+		if reach.NbrId == nodeId {
+			return true
+		}
+	}
+	return false
+}
+
+func (srvr *DmnServer) makeNextHop(intf *Intf, adj *Adjacency) NextHop {
+	nextHop := NextHop{
+		IntfRef:  intf.IntfRef,
+		IfIndex:  intf.IfIndex,
+		SystemId: adj.SystemId,
+	}
+	for _, ip := range adj.IPv4Addrs {
+		if intf.matchIPv4Subnet(ip) || nextHop.IPv4Addr == "" {
+			nextHop.IPv4Addr = ip.String()
+		}
+	}
+	for _, ip := range adj.IPv6Addrs {
+		if ip.IsLinkLocalUnicast() {
+			nextHop.IPv6Addr = ip.String()
+			break
+		}
+	}
+	return nextHop
+}
+
+/*@fn rootNextHops
+Next hops to a neighbor advertised in our own LSP. Pseudonodes resolve their
+next hops when their neighbors are reached.
+*/
+func (srvr *DmnServer) rootNextHops(level uint8, reach packet.ExtIsReach, vertex *spfVertex) map[NextHop]bool {
+	nextHops := make(map[NextHop]bool)
+	for _, intf := range srvr.intfMap {
+		if !intf.running || intf.isPassive() || intf.Cfg.Metric != reach.Metric {
+			continue
+		}
+		if reach.NbrId.IsPseudonode() {
+			intfLevel := intf.getLevel(level)
+			if !intf.isP2P() && intfLevel != nil && intfLevel.lanId == reach.NbrId {
+				vertex.viaIntf = intf
+			}
+			continue
+		}
+		if !intf.isP2P() {
+			continue
+		}
+		adj := srvr.getUpAdjacency(intf, level, nil)
+		if adj != nil && adj.SystemId == reach.NbrId.SystemId() {
+			nextHops[srvr.makeNextHop(intf, adj)] = true
+		}
+	}
+	return nextHops
+}
+
+func (srvr *DmnServer) lanNextHops(level uint8, intf *Intf, sysId packet.SystemId) map[NextHop]bool {
+	nextHops := make(map[NextHop]bool)
+	intfLevel := intf.getLevel(level)
+	if intfLevel == nil {
+		return nextHops
+	}
+	for _, adj := range intfLevel.adjs {
+		if adj.State == ADJ_STATE_UP && adj.SystemId == sysId {
+			nextHops[srvr.makeNextHop(intf, adj)] = true
+		}
+	}
+	return nextHops
+}
+
+/*@fn runSpf
+Dijkstra over the level database. An edge is used only when both ends
+advertise each other. Overloaded systems are not used for transit.
+*/
+func (srvr *DmnServer) runSpf(level uint8, graph map[packet.NodeId]*spfNode) map[packet.NodeId]*spfVertex {
+	root := packet.MakeNodeId(srvr.SystemId, 0)
+	vertices := make(map[packet.NodeId]*spfVertex)
+	if _, exist := graph[root]; !exist {
+		return vertices
+	}
+	vertices[root] = &spfVertex{nextHops: make(map[NextHop]bool)}
+	for {
+		var uId packet.NodeId
+		var u *spfVertex
+		for nodeId, vertex := range vertices {
+			if vertex.done {
+				continue
+			}
+			if u == nil || vertex.dist < u.dist {
+				uId = nodeId
+				u = vertex
+			}
+		}
+		if u == nil {
+			break
+		}
+		u.done = true
+		uNode := graph[uId]
+		if uId != root && uNode.overload && !uId.IsPseudonode() {
+			continue
+		}
+		for _, reach := range uNode.isReach {
+			vId := reach.NbrId
+			vNode, exist := graph[vId]
+			if !exist || vId == root || !vNode.hasNbr(uId) {
+				continue
+			}
+			dist := u.dist + reach.Metric
+			if dist > packet.MAX_WIDE_METRIC {
+				continue
+			}
+			v, exist := vertices[vId]
+			if exist && (v.done || dist > v.dist) {
+				continue
+			}
+			candidate := &spfVertex{dist: dist}
+			switch {
+			case uId == root:
+				candidate.nextHops = srvr.rootNextHops(level, reach, candidate)
+				if candidate.viaIntf == nil && len(candidate.nextHops) == 0 {
+					continue
+				}
+			case u.viaIntf != nil:
+				candidate.nextHops = srvr.lanNextHops(level, u.viaIntf, vId.SystemId())
+				for nextHop, _ := range u.nextHops {
+					candidate.nextHops[nextHop] = true
+				}
+			default:
+				candidate.nextHops = u.nextHops
+			}
+			if !exist || dist < v.dist {
+				nextHops := make(map[NextHop]bool)
+				for nextHop, _ := range candidate.nextHops {
+					nextHops[nextHop] = true
+				}
+				candidate.nextHops = nextHops
+				vertices[vId] = candidate
+				continue
+			}
+			for nextHop, _ := range candidate.nextHops {
+				v.nextHops[nextHop] = true
+			}
+			if v.viaIntf == nil {
+				v.viaIntf = candidate.viaIntf
+			}
+		}
+	}
+	return vertices
+}
+
+func addSpfRoute(routes map[string]*Route, level uint8, prefix net.IPNet, metric uint32, nextHops map[NextHop]bool, v6 bool) {
+	if len(nextHops) == 0 {
+		return
+	}
+	if metric > packet.MAX_PREFIX_METRIC {
+		metric = packet.MAX_PREFIX_METRIC
+	}
+	key := prefix.String()
+	route, exist := routes[key]
+	if exist && route.Metric < metric {
+		return
+	}
+	if !exist || metric < route.Metric {
+		route = &Route{
+			Prefix:   prefix,
+			Level:    level,
+			Metric:   metric,
+			NextHops: make(map[NextHop]bool),
+		}
+		routes[key] = route
+	}
+	for nextHop, _ := range nextHops {
+		if (v6 && nextHop.IPv6Addr != "") || (!v6 && nextHop.IPv4Addr != "") {
+			route.NextHops[nextHop] = true
+		}
+	}
+	if len(route.NextHops) == 0 {
+		delete(routes, key)
+	}
+}
+
+/*@fn calcLevelRoutes
+Routes of a level from the shortest path tree. Prefixes of directly
+connected interfaces are not installed. A level 1 only system installs a
+default route towards the nearest attached L1/L2 system.
+*/
+func (srvr *DmnServer) calcLevelRoutes(level uint8) map[string]*Route {
+	routes := make(map[string]*Route)
+	graph := srvr.buildSpfGraph(level)
+	vertices := srvr.runSpf(level, graph)
+	root := packet.MakeNodeId(srvr.SystemId, 0)
+	connected := make(map[string]bool)
+	if rootNode, exist := graph[root]; exist {
+		for _, reach := range rootNode.ipReach {
+			connected[reach.Prefix.String()] = true
+		}
+		for _, reach := range rootNode.ipv6Reach {
+			connected[reach.Prefix.String()] = true
+		}
+	}
+	var attachedDist uint32
+	attachedNextHops := make(map[NextHop]bool)
+	otherArea := false
+	for nodeId, vertex := range vertices {
+		if nodeId == root || nodeId.IsPseudonode() {
+			continue
+		}
+		node := graph[nodeId]
+		for _, reach := range node.ipReach {
+			if connected[reach.Prefix.String()] {
+				continue
+			}
+			addSpfRoute(routes, level, reach.Prefix, vertex.dist+reach.Metric, vertex.nextHops, false)
+		}
+		for _, reach := range node.ipv6Reach {
+			if connected[reach.Prefix.String()] {
+				continue
+			}
+			addSpfRoute(routes, level, reach.Prefix, vertex.dist+reach.Metric, vertex.nextHops, true)
+		}
+		if node.attached && len(vertex.nextHops) != 0 {
+			if len(attachedNextHops) == 0 || vertex.dist < attachedDist {
+				attachedDist = vertex.dist
+				attachedNextHops = make(map[NextHop]bool)
+			}
+			if vertex.dist == attachedDist {
+				for nextHop, _ := range vertex.nextHops {
+					attachedNextHops[nextHop] = true
+				}
+			}
+		}
+		if len(node.areaAddrs) != 0 && !srvr.areaMatch(node.areaAddrs) {
+			otherArea = true
+		}
+	}
+	if level == packet.Level1 && srvr.globalCfg.IsType == packet.Level1 && len(attachedNextHops) != 0 {
+		_, v4Default, _ := net.ParseCIDR(defaultV4Prefix)
+		_, v6Default, _ := net.ParseCIDR(defaultV6Prefix)
+		addSpfRoute(routes, level, *v4Default, attachedDist, attachedNextHops, false)
+		addSpfRoute(routes, level, *v6Default, attachedDist, attachedNextHops, true)
+	}
+	if level == packet.Level2 && srvr.attached != otherArea {
+		srvr.attached = otherArea
+		srvr.scheduleLspGen(packet.Level1)
+	}
+	return routes
+}
+
+/*@fn processSpf
+Run SPF for the pending levels and install the merged routes. Level 1 routes
+are preferred over level 2 routes for the same prefix.
+*/
+func (srvr *DmnServer) processSpf() {
+	levels := srvr.spfPending
+	srvr.spfPending = 0
+	for _, level := range []uint8{packet.Level1, packet.Level2} {
+		if levels&level == 0 {
+			continue
+		}
+		routes := make(map[string]*Route)
+		if srvr.levelEnabled(level) {
+			routes = srvr.calcLevelRoutes(level)
+		}
+		if level == packet.Level1 && !routeTblEqual(srvr.spfRoutes[level-1], routes) {
+			srvr.scheduleLspGen(packet.Level2)
+		}
+		srvr.spfRoutes[level-1] = routes
+		srvr.spfRuns++
+	}
+	routeTbl := make(map[string]*Route)
+	for key, route := range srvr.spfRoutes[packet.Level2-1] {
+		routeTbl[key] = route
+	}
+	for key, route := range srvr.spfRoutes[packet.Level1-1] {
+		routeTbl[key] = route
+	}
+	srvr.updateRib(routeTbl)
+}
@@ -0,0 +1,298 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/isis/packet"
+	"sort"
+	"strings"
+)
+
+type GlobalState struct {
+	Vrf            string
+	SystemId       string
+	AreaId         string
+	IsType         string
+	Hostname       string
+	Attached       bool
+	NumAdjacencies int
+	NumL1Lsps      int
+	NumL2Lsps      int
+	SpfRuns        uint32
+}
+
+type IntfState struct {
+	IntfRef        string
+	IfIndex        int32
+	State          string
+	NetworkType    string
+	CircuitType    string
+	CircuitId      uint8
+	Metric         uint32
+	L1Dis          string
+	L2Dis          string
+	NumAdjacencies int
+}
+
+type AdjState struct {
+	IntfRef           string
+	SystemId          string
+	Hostname          string
+	Level             uint8
+	State             string
+	Snpa              string
+	Priority          uint8
+	HoldTimeRemaining uint16
+	IPv4Addr          string
+	IPv6Addr          string
+	UpTime            string
+}
+
+type LspState struct {
+	Level             uint8
+	LspId             string
+	Hostname          string
+	SeqNum            uint32
+	Checksum          uint16
+	RemainingLifetime uint16
+	Attached          bool
+	Overload          bool
+}
+
+type RouteState struct {
+	Prefix   string
+	Level    uint8
+	Metric   uint32
+	NextHops []string
+}
+
+type IntfStateGetInfo struct {
+	EndIdx int
+	More   bool
+	List   []IntfState
+}
+
+type AdjStateGetInfo struct {
+	EndIdx int
+	More   bool
+	List   []AdjState
+}
+
+type LspStateGetInfo struct {
+	EndIdx int
+	More   bool
+	List   []LspState
+}
+
+type RouteStateGetInfo struct {
+	EndIdx int
+	More   bool
+	List   []RouteState
+}
+
+/* Start and end index of a getbulk request over length entries */
+func getBulkRange(fromIdx, count, length int) (int, int, bool) {
+	if fromIdx >= length || fromIdx < 0 {
+		return 0, 0, false
+	}
+	endIdx := fromIdx + count
+	if endIdx >= length || count <= 0 {
+		return fromIdx, length, false
+	}
+	return fromIdx, endIdx, true
+}
+
+/* Hostname from the dynamic hostname TLV of the system's LSP */
+func (srvr *DmnServer) getHostname(sysId packet.SystemId) string {
+	lspId := packet.MakeLspId(packet.MakeNodeId(sysId, 0), 0)
+	for _, level := range []uint8{packet.Level2, packet.Level1} {
+		if entry, exist := srvr.getLsdb(level)[lspId]; exist && entry.Lsp.Hostname != "" {
+			return entry.Lsp.Hostname
+		}
+	}
+	return ""
+}
+
+func (srvr *DmnServer) getGlobalState() GlobalState {
+	state := GlobalState{
+		Vrf:       srvr.globalCfg.Vrf,
+		SystemId:  srvr.SystemId.String(),
+		IsType:    IsTypeString(srvr.globalCfg.IsType),
+		Hostname:  srvr.globalCfg.Hostname,
+		Attached:  srvr.attached,
+		NumL1Lsps: len(srvr.getLsdb(packet.Level1)),
+		NumL2Lsps: len(srvr.getLsdb(packet.Level2)),
+		SpfRuns:   srvr.spfRuns,
+	}
+	areas := make([]string, 0, len(srvr.AreaAddrs))
+	for _, area := range srvr.AreaAddrs {
+		areas = append(areas, packet.AreaAddressString(area))
+	}
+	state.AreaId = strings.Join(areas, ",")
+	state.NumAdjacencies = len(srvr.getAdjStates())
+	return state
+}
+
+func (srvr *DmnServer) getIntfState(intf *Intf) IntfState {
+	state := IntfState{
+		IntfRef:     intf.IntfRef,
+		IfIndex:     intf.IfIndex,
+		State:       "Down",
+		NetworkType: NetworkTypeString(intf.Cfg.NetworkType),
+		CircuitType: IsTypeString(intf.levelMask),
+		CircuitId:   intf.CircuitId,
+		Metric:      intf.Cfg.Metric,
+	}
+	if intf.running {
+		state.State = "Up"
+	}
+	if intf.p2pAdj != nil && intf.p2pAdj.State == ADJ_STATE_UP {
+		state.NumAdjacencies++
+	}
+	for _, intfLevel := range intf.levels {
+		if intfLevel == nil {
+			continue
+		}
+		for _, adj := range intfLevel.adjs {
+			if adj.State == ADJ_STATE_UP {
+				state.NumAdjacencies++
+			}
+		}
+		if intf.isP2P() {
+			continue
+		}
+		if intfLevel.level == packet.Level1 {
+			state.L1Dis = intfLevel.lanId.String()
+		} else {
+			state.L2Dis = intfLevel.lanId.String()
+		}
+	}
+	return state
+}
+
+func (srvr *DmnServer) makeAdjState(intf *Intf, level uint8, adj *Adjacency) AdjState {
+	state := AdjState{
+		IntfRef:           intf.IntfRef,
+		SystemId:          adj.SystemId.String(),
+		Hostname:          srvr.getHostname(adj.SystemId),
+		Level:             level,
+		State:             AdjStateString(adj.State),
+		Snpa:              adj.Snpa.String(),
+		Priority:          adj.Priority,
+		HoldTimeRemaining: adj.holdTimer,
+	}
+	nextHop := srvr.makeNextHop(intf, adj)
+	state.IPv4Addr = nextHop.IPv4Addr
+	state.IPv6Addr = nextHop.IPv6Addr
+	if adj.State == ADJ_STATE_UP {
+		state.UpTime = adj.UpTime.String()
+	}
+	return state
+}
+
+func (srvr *DmnServer) getAdjStates() []AdjState {
+	states := make([]AdjState, 0)
+	for _, intfRef := range srvr.sortedIntfRefs() {
+		intf := srvr.intfMap[intfRef]
+		if intf.p2pAdj != nil {
+			states = append(states, srvr.makeAdjState(intf, intf.p2pAdj.Usage, intf.p2pAdj))
+		}
+		for _, intfLevel := range intf.levels {
+			if intfLevel == nil {
+				continue
+			}
+			for _, adj := range sortedAdjs(intfLevel) {
+				states = append(states, srvr.makeAdjState(intf, intfLevel.level, adj))
+			}
+		}
+	}
+	return states
+}
+
+func (srvr *DmnServer) getBulkIntfState(fromIdx, count int) IntfStateGetInfo {
+	refs := make([]string, 0)
+	for _, intfRef := range srvr.sortedIntfRefs() {
+		if srvr.intfMap[intfRef].configured {
+			refs = append(refs, intfRef)
+		}
+	}
+	start, end, more := getBulkRange(fromIdx, count, len(refs))
+	info := IntfStateGetInfo{EndIdx: end, More: more}
+	for _, intfRef := range refs[start:end] {
+		info.List = append(info.List, srvr.getIntfState(srvr.intfMap[intfRef]))
+	}
+	return info
+}
+
+func (srvr *DmnServer) getBulkAdjState(fromIdx, count int) AdjStateGetInfo {
+	states := srvr.getAdjStates()
+	start, end, more := getBulkRange(fromIdx, count, len(states))
+	return AdjStateGetInfo{EndIdx: end, More: more, List: states[start:end]}
+}
+
+func (srvr *DmnServer) getBulkLspState(fromIdx, count int) LspStateGetInfo {
+	states := make([]LspState, 0)
+	for _, level := range []uint8{packet.Level1, packet.Level2} {
+		lsdb := srvr.getLsdb(level)
+		for _, lspId := range srvr.sortedLspIds(level) {
+			entry := lsdb[lspId]
+			states = append(states, LspState{
+				Level:             level,
+				LspId:             lspId.String(),
+				Hostname:          srvr.getHostname(lspId.SystemId()),
+				SeqNum:            entry.Lsp.SeqNum,
+				Checksum:          entry.Lsp.Checksum,
+				RemainingLifetime: entry.Remaining,
+				Attached:          entry.Lsp.Attached,
+				Overload:          entry.Lsp.Overload,
+			})
+		}
+	}
+	start, end, more := getBulkRange(fromIdx, count, len(states))
+	return LspStateGetInfo{EndIdx: end, More: more, List: states[start:end]}
+}
+
+func (srvr *DmnServer) getBulkRouteState(fromIdx, count int) RouteStateGetInfo {
+	states := make([]RouteState, 0)
+	for _, key := range sortedRouteKeys(srvr.routeTbl) {
+		route := srvr.routeTbl[key]
+		state := RouteState{
+			Prefix: key,
+			Level:  route.Level,
+			Metric: route.Metric,
+		}
+		for nextHop, _ := range route.NextHops {
+			nhIp := nextHop.IPv4Addr
+			if route.Prefix.IP.To4() == nil {
+				nhIp = nextHop.IPv6Addr
+			}
+			state.NextHops = append(state.NextHops, fmt.Sprintf("%s%%%s", nhIp, nextHop.IntfRef))
+		}
+		sort.Strings(state.NextHops)
+		states = append(states, state)
+	}
+	start, end, more := getBulkRange(fromIdx, count, len(states))
+	return RouteStateGetInfo{EndIdx: end, More: more, List: states[start:end]}
+}
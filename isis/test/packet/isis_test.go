@@ -0,0 +1,477 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __  
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  | 
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  | 
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   | 
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  | 
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__| 
+//                                                                                                           
+
+package packettest
+
+import (
+	"l3/isis/packet"
+	"l3/isis/server"
+	"net"
+	"testing"
+	"utils/logging"
+)
+
+type linkEnd struct {
+	srvr    *server.DmnServer
+	intfRef string
+}
+
+type txFrame struct {
+	from  linkEnd
+	frame []byte
+}
+
+/* Routers connected back to back, frames are delivered after every tick */
+type testNet struct {
+	links   map[linkEnd][]linkEnd
+	pending []txFrame
+	routers []*server.DmnServer
+}
+
+func newTestNet() *testNet {
+	return &testNet{
+		links: make(map[linkEnd][]linkEnd),
+	}
+}
+
+func (tn *testNet) newRouter(t *testing.T, sysId string, area string, isType string) *server.DmnServer {
+	logger, _ := logging.NewLogger("isisd", "ISIS", true)
+	srvr := server.NewISISDServer(&server.ServerInitParams{
+		DmnName: "isisd",
+		Logger:  logger,
+	})
+	srvr.SetTxPktFunc(func(intfRef string, frame []byte) error {
+		data := make([]byte, len(frame))
+		copy(data, frame)
+		tn.pending = append(tn.pending, txFrame{linkEnd{srvr, intfRef}, data})
+		return nil
+	})
+	level, _ := server.ConvertIsType(isType)
+	err := srvr.HandleRequest(&server.ServerRequest{
+		Op: server.CREATE_GLOBAL,
+		Data: server.GlobalConfig{
+			Vrf:      "default",
+			Enable:   true,
+			SystemId: sysId,
+			AreaId:   area,
+			IsType:   level,
+			Hostname: "rtr-" + sysId,
+		},
+	})
+	if err != nil {
+		t.Fatal("Failed to configure IS-IS global", err)
+	}
+	tn.routers = append(tn.routers, srvr)
+	return srvr
+}
+
+func (tn *testNet) addIntf(t *testing.T, srvr *server.DmnServer, cfg server.IntfConfig, ifIndex int32, mac string, addrs ...string) {
+	info := server.IntfInfo{
+		IntfRef:   cfg.IntfRef,
+		IfIndex:   ifIndex,
+		MacAddr:   mac,
+		OperState: true,
+	}
+	for _, addr := range addrs {
+		ip, _, _ := net.ParseCIDR(addr)
+		if ip.To4() != nil {
+			info.IPv4Addrs = append(info.IPv4Addrs, addr)
+		} else {
+			info.IPv6Addrs = append(info.IPv6Addrs, addr)
+		}
+	}
+	srvr.UpdateIntfInfo(info)
+	cfg.Enable = true
+	err := srvr.HandleRequest(&server.ServerRequest{
+		Op:   server.CREATE_INTF,
+		Data: cfg,
+	})
+	if err != nil {
+		t.Fatal("Failed to configure IS-IS interface", cfg.IntfRef, err)
+	}
+}
+
+func (tn *testNet) connect(ends ...linkEnd) {
+	for _, end := range ends {
+		for _, peer := range ends {
+			if peer != end {
+				tn.links[end] = append(tn.links[end], peer)
+			}
+		}
+	}
+}
+
+func (tn *testNet) deliver() {
+	for len(tn.pending) != 0 {
+		frames := tn.pending
+		tn.pending = nil
+		for _, txFrame := range frames {
+			for _, peer := range tn.links[txFrame.from] {
+				peer.srvr.ProcessRxFrame(peer.intfRef, txFrame.frame)
+			}
+		}
+	}
+}
+
+func (tn *testNet) run(seconds int) {
+	tn.deliver()
+	for idx := 0; idx < seconds; idx++ {
+		for _, srvr := range tn.routers {
+			srvr.ProcessTimerTick()
+		}
+		tn.deliver()
+	}
+}
+
+func getRoutes(srvr *server.DmnServer) map[string]server.RouteState {
+	info := srvr.HandleRequest(&server.ServerRequest{
+		Op:   server.GET_BULK_ROUTE_STATE,
+		Data: server.BulkReq{},
+	}).(server.RouteStateGetInfo)
+	routes := make(map[string]server.RouteState)
+	for _, route := range info.List {
+		routes[route.Prefix] = route
+	}
+	return routes
+}
+
+func getAdjacencies(srvr *server.DmnServer) []server.AdjState {
+	info := srvr.HandleRequest(&server.ServerRequest{
+		Op:   server.GET_BULK_ADJ_STATE,
+		Data: server.BulkReq{},
+	}).(server.AdjStateGetInfo)
+	return info.List
+}
+
+func getLsps(srvr *server.DmnServer) map[string]server.LspState {
+	info := srvr.HandleRequest(&server.ServerRequest{
+		Op:   server.GET_BULK_LSP_STATE,
+		Data: server.BulkReq{},
+	}).(server.LspStateGetInfo)
+	lsps := make(map[string]server.LspState)
+	for _, lsp := range info.List {
+		lsps[lsp.LspId] = lsp
+	}
+	return lsps
+}
+
+func checkRoute(t *testing.T, srvr *server.DmnServer, prefix string, metric uint32, nextHops ...string) {
+	route, exist := getRoutes(srvr)[prefix]
+	if !exist {
+		t.Fatal("No route for", prefix, "routes:", getRoutes(srvr))
+	}
+	if route.Metric != metric {
+		t.Error("Route", prefix, "metric", route.Metric, "expected", metric)
+	}
+	if len(route.NextHops) != len(nextHops) {
+		t.Fatal("Route", prefix, "next hops", route.NextHops, "expected", nextHops)
+	}
+	for idx, nextHop := range nextHops {
+		if route.NextHops[idx] != nextHop {
+			t.Error("Route", prefix, "next hops", route.NextHops, "expected", nextHops)
+		}
+	}
+}
+
+func TestIsisLanHelloCodec(t *testing.T) {
+	sysId, _ := packet.ParseSystemId("1921.6800.1001")
+	area, _ := packet.ParseAreaAddress("49.0001")
+	hello := &packet.LanHello{
+		Level:       packet.Level2,
+		CircuitType: packet.Level1_2,
+		SourceId:    sysId,
+		HoldingTime: 30,
+		Priority:    100,
+		LanId:       packet.MakeNodeId(sysId, 3),
+	}
+	hello.AreaAddresses = [][]byte{area}
+	hello.IsNeighbors = []net.HardwareAddr{{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}}
+	hello.IPv4IntfAddrs = []net.IP{net.ParseIP("10.0.0.1").To4()}
+	hello.IPv6IntfAddrs = []net.IP{net.ParseIP("fe80::1")}
+	pdu := packet.EncodeLanHello(hello, packet.ISIS_MAX_PDU_LEN)
+	if len(pdu) != packet.ISIS_MAX_PDU_LEN {
+		t.Error("Hello not padded, length", len(pdu))
+	}
+	frame := packet.EncodeFrame(packet.AllL2ISs, net.HardwareAddr{0, 1, 2, 3, 4, 5}, pdu)
+	dstMac, srcMac, rxPdu, err := packet.DecodeFrame(frame)
+	if err != nil || dstMac.String() != packet.AllL2ISs.String() || srcMac.String() != "00:01:02:03:04:05" {
+		t.Fatal("Frame decode failed", err, dstMac, srcMac)
+	}
+	rxHello, err := packet.DecodeLanHello(rxPdu)
+	if err != nil {
+		t.Fatal("Hello decode failed", err)
+	}
+	if rxHello.Level != packet.Level2 || rxHello.Priority != 100 || rxHello.LanId.String() != "1921.6800.1001.03" ||
+		rxHello.HoldingTime != 30 || packet.AreaAddressString(rxHello.AreaAddresses[0]) != "49.0001" {
+		t.Error("Hello header mismatch", rxHello)
+	}
+	if len(rxHello.IsNeighbors) != 1 || rxHello.IsNeighbors[0].String() != "00:11:22:33:44:55" ||
+		!rxHello.IPv4IntfAddrs[0].Equal(net.ParseIP("10.0.0.1")) || !rxHello.IPv6IntfAddrs[0].Equal(net.ParseIP("fe80::1")) {
+		t.Error("Hello TLV mismatch", rxHello.Tlvs)
+	}
+}
+
+func TestIsisP2PHelloCodec(t *testing.T) {
+	sysId, _ := packet.ParseSystemId("0000.0000.0001")
+	nbrId, _ := packet.ParseSystemId("0000.0000.0002")
+	hello := &packet.P2PHello{
+		CircuitType:    packet.Level1,
+		SourceId:       sysId,
+		HoldingTime:    30,
+		LocalCircuitId: 1,
+	}
+	hello.P2PAdj = &packet.P2PAdjState{
+		State:          packet.P2PAdjInit,
+		LocalCircuitId: 7,
+		HasNbr:         true,
+		NbrSysId:       nbrId,
+		NbrCircuitId:   9,
+	}
+	rxHello, err := packet.DecodeP2PHello(packet.EncodeP2PHello(hello, 0))
+	if err != nil {
+		t.Fatal("Hello decode failed", err)
+	}
+	if rxHello.P2PAdj == nil || *rxHello.P2PAdj != *hello.P2PAdj {
+		t.Error("Three way adjacency TLV mismatch", rxHello.P2PAdj)
+	}
+}
+
+func TestIsisLspCodec(t *testing.T) {
+	sysId, _ := packet.ParseSystemId("0000.0000.0001")
+	area, _ := packet.ParseAreaAddress("49.0001")
+	_, v4Prefix, _ := net.ParseCIDR("10.1.1.0/24")
+	_, v6Prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	lsp := &packet.Lsp{
+		Level:             packet.Level1,
+		RemainingLifetime: 1200,
+		LspId:             packet.MakeLspId(packet.MakeNodeId(sysId, 0), 0),
+		SeqNum:            0x10,
+		Attached:          true,
+		IsType:            packet.Level1_2,
+	}
+	lsp.AreaAddresses = [][]byte{area}
+	lsp.Hostname = "rtr1"
+	lsp.ExtIsReach = []packet.ExtIsReach{{NbrId: packet.MakeNodeId(sysId, 2), Metric: 10}}
+	lsp.ExtIPReach = []packet.ExtIPReach{{Metric: 20, Prefix: *v4Prefix}}
+	lsp.IPv6Reach = []packet.IPv6Reach{{Metric: 30, Prefix: *v6Prefix}}
+	pdu := packet.EncodeLsp(lsp)
+	rxLsp, err := packet.DecodeLsp(pdu)
+	if err != nil {
+		t.Fatal("LSP decode failed", err)
+	}
+	if rxLsp.Checksum != lsp.Checksum || rxLsp.Checksum == 0 || !rxLsp.Attached || rxLsp.Overload ||
+		rxLsp.LspId.String() != "0000.0000.0001.00-00" || rxLsp.SeqNum != 0x10 {
+		t.Error("LSP header mismatch", rxLsp)
+	}
+	if rxLsp.Hostname != "rtr1" || rxLsp.ExtIsReach[0] != lsp.ExtIsReach[0] ||
+		rxLsp.ExtIPReach[0].Prefix.String() != "10.1.1.0/24" || rxLsp.ExtIPReach[0].Metric != 20 ||
+		rxLsp.IPv6Reach[0].Prefix.String() != "2001:db8:1::/64" || rxLsp.IPv6Reach[0].Metric != 30 {
+		t.Error("LSP TLV mismatch", rxLsp.Tlvs)
+	}
+	// Remaining lifetime is not covered by checksum
+	packet.SetLspLifetime(pdu, 100)
+	if _, err := packet.DecodeLsp(pdu); err != nil {
+		t.Error("LSP with updated lifetime rejected", err)
+	}
+	pdu[len(pdu)-1] ^= 0x01
+	if _, err := packet.DecodeLsp(pdu); err == nil {
+		t.Error("Corrupted LSP accepted")
+	}
+	purge := &packet.Lsp{
+		Level:  packet.Level1,
+		LspId:  lsp.LspId,
+		SeqNum: 0x11,
+	}
+	rxPurge, err := packet.DecodeLsp(packet.EncodeLsp(purge))
+	if err != nil || rxPurge.RemainingLifetime != 0 || rxPurge.SeqNum != 0x11 {
+		t.Error("Purge decode failed", err, rxPurge)
+	}
+}
+
+func TestIsisSnpCodec(t *testing.T) {
+	sysId, _ := packet.ParseSystemId("0000.0000.0001")
+	csnp := &packet.Csnp{
+		Level:    packet.Level2,
+		SourceId: packet.MakeNodeId(sysId, 0),
+		EndLspId: packet.LspId{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+	}
+	for idx := 0; idx < 20; idx++ {
+		nbrId := sysId
+		nbrId[5] = uint8(idx)
+		csnp.LspEntries = append(csnp.LspEntries, packet.LspEntry{
+			RemainingLifetime: 1000,
+			LspId:             packet.MakeLspId(packet.MakeNodeId(nbrId, 0), 0),
+			SeqNum:            uint32(idx),
+			Checksum:          0x1234,
+		})
+	}
+	rxCsnp, err := packet.DecodeCsnp(packet.EncodeCsnp(csnp))
+	if err != nil {
+		t.Fatal("CSNP decode failed", err)
+	}
+	if rxCsnp.Level != packet.Level2 || rxCsnp.EndLspId != csnp.EndLspId || len(rxCsnp.LspEntries) != 20 ||
+		rxCsnp.LspEntries[19] != csnp.LspEntries[19] {
+		t.Error("CSNP mismatch", rxCsnp)
+	}
+	psnp := &packet.Psnp{
+		Level:    packet.Level1,
+		SourceId: packet.MakeNodeId(sysId, 0),
+	}
+	psnp.LspEntries = csnp.LspEntries[:1]
+	rxPsnp, err := packet.DecodePsnp(packet.EncodePsnp(psnp))
+	if err != nil || rxPsnp.Level != packet.Level1 || len(rxPsnp.LspEntries) != 1 || rxPsnp.LspEntries[0] != psnp.LspEntries[0] {
+		t.Error("PSNP mismatch", err, rxPsnp)
+	}
+}
+
+/*
+   r1 ----- p2p ----- r2
+   10.0.12.1/30       10.0.12.2/30, loopback 2.2.2.2/32, 2001:db8:2::/64
+*/
+func TestIsisP2PAdjacencyAndRoutes(t *testing.T) {
+	tn := newTestNet()
+	r1 := tn.newRouter(t, "0000.0000.0001", "49.0001", "level-2")
+	r2 := tn.newRouter(t, "0000.0000.0002", "49.0002", "level-2")
+	p2p := server.IntfConfig{IntfRef: "eth1", NetworkType: server.NETWORK_P2P}
+	tn.addIntf(t, r1, p2p, 1, "00:00:00:00:01:01", "10.0.12.1/30", "fe80::1/64", "2001:db8:12::1/64")
+	tn.addIntf(t, r2, p2p, 1, "00:00:00:00:02:01", "10.0.12.2/30", "fe80::2/64", "2001:db8:12::2/64")
+	tn.addIntf(t, r2, server.IntfConfig{IntfRef: "lo", Passive: true, Metric: 5}, 2, "", "2.2.2.2/32", "2001:db8:2::1/64")
+	tn.connect(linkEnd{r1, "eth1"}, linkEnd{r2, "eth1"})
+	tn.run(5)
+
+	adjs := getAdjacencies(r1)
+	if len(adjs) != 1 || adjs[0].State != "Up" || adjs[0].SystemId != "0000.0000.0002" ||
+		adjs[0].IPv4Addr != "10.0.12.2" || adjs[0].IPv6Addr != "fe80::2" {
+		t.Fatal("Point to point adjacency not up", adjs)
+	}
+	lsps := getLsps(r1)
+	if lsp, exist := lsps["0000.0000.0002.00-00"]; !exist || lsp.Hostname != "rtr-0000.0000.0002" {
+		t.Fatal("LSP of r2 not received", lsps)
+	}
+	checkRoute(t, r1, "2.2.2.2/32", 15, "10.0.12.2%eth1")
+	checkRoute(t, r1, "2001:db8:2::/64", 15, "fe80::2%eth1")
+	if _, exist := getRoutes(r1)["10.0.12.0/30"]; exist {
+		t.Error("Route installed for connected subnet")
+	}
+
+	// Neighbor goes silent, adjacency and routes are removed on hold timer expiry
+	tn.links = make(map[linkEnd][]linkEnd)
+	tn.run(35)
+	if len(getAdjacencies(r1)) != 0 {
+		t.Error("Adjacency not removed on hold timer expiry", getAdjacencies(r1))
+	}
+	if len(getRoutes(r1)) != 0 {
+		t.Error("Routes not withdrawn", getRoutes(r1))
+	}
+}
+
+/*
+   r1, r2 and r3 on a LAN 10.0.0.0/24, r3 has highest priority and is DIS.
+   r2 has a stub network 20.0.0.0/24.
+*/
+func TestIsisLanDisElection(t *testing.T) {
+	tn := newTestNet()
+	r1 := tn.newRouter(t, "0000.0000.0001", "49.0001", "level-1")
+	r2 := tn.newRouter(t, "0000.0000.0002", "49.0001", "level-1")
+	r3 := tn.newRouter(t, "0000.0000.0003", "49.0001", "level-1")
+	lan := server.IntfConfig{IntfRef: "eth0", NetworkType: server.NETWORK_BROADCAST, Priority: 64}
+	tn.addIntf(t, r1, lan, 1, "00:00:00:00:00:01", "10.0.0.1/24", "fe80::1/64")
+	tn.addIntf(t, r2, lan, 1, "00:00:00:00:00:02", "10.0.0.2/24", "fe80::2/64")
+	lan.Priority = 100
+	tn.addIntf(t, r3, lan, 1, "00:00:00:00:00:03", "10.0.0.3/24", "fe80::3/64")
+	tn.addIntf(t, r2, server.IntfConfig{IntfRef: "eth1", Passive: true}, 2, "00:00:00:00:00:12", "20.0.0.1/24")
+	tn.connect(linkEnd{r1, "eth0"}, linkEnd{r2, "eth0"}, linkEnd{r3, "eth0"})
+	tn.run(15)
+
+	if adjs := getAdjacencies(r1); len(adjs) != 2 || adjs[0].State != "Up" || adjs[1].State != "Up" {
+		t.Fatal("LAN adjacencies not up", adjs)
+	}
+	for _, srvr := range tn.routers {
+		info := srvr.HandleRequest(&server.ServerRequest{
+			Op:   server.GET_BULK_INTF_STATE,
+			Data: server.BulkReq{},
+		}).(server.IntfStateGetInfo)
+		if info.List[0].L1Dis != "0000.0000.0003.01" {
+			t.Error("Unexpected DIS", info.List[0].L1Dis)
+		}
+	}
+	lsps := getLsps(r1)
+	if _, exist := lsps["0000.0000.0003.01-00"]; !exist {
+		t.Fatal("Pseudonode LSP not received", lsps)
+	}
+	checkRoute(t, r1, "20.0.0.0/24", 20, "10.0.0.2%eth0")
+
+	// Higher priority router joins, DIS moves and old pseudonode LSP is purged
+	r4 := tn.newRouter(t, "0000.0000.0004", "49.0001", "level-1")
+	lan.Priority = 120
+	tn.addIntf(t, r4, lan, 1, "00:00:00:00:00:04", "10.0.0.4/24", "fe80::4/64")
+	tn.links = make(map[linkEnd][]linkEnd)
+	tn.connect(linkEnd{r1, "eth0"}, linkEnd{r2, "eth0"}, linkEnd{r3, "eth0"}, linkEnd{r4, "eth0"})
+	tn.run(15)
+	lsps = getLsps(r1)
+	if lsp, exist := lsps["0000.0000.0003.01-00"]; exist && lsp.RemainingLifetime != 0 {
+		t.Error("Old pseudonode LSP not purged", lsp)
+	}
+	if _, exist := lsps["0000.0000.0004.01-00"]; !exist {
+		t.Error("New pseudonode LSP not received", lsps)
+	}
+	checkRoute(t, r4, "20.0.0.0/24", 20, "10.0.0.2%eth0")
+}
+
+/*
+   r1 (L1, 49.0001) --- r2 (L1/L2, 49.0001) --- r3 (L2, 49.0002)
+   L1 prefixes of r1 are leaked into level 2 by r2, r1 uses r2 as the
+   default exit since r2 sets attached bit.
+*/
+func TestIsisMultiLevel(t *testing.T) {
+	tn := newTestNet()
+	r1 := tn.newRouter(t, "0000.0000.0001", "49.0001", "level-1")
+	r2 := tn.newRouter(t, "0000.0000.0002", "49.0001", "level-1-2")
+	r3 := tn.newRouter(t, "0000.0000.0003", "49.0002", "level-2")
+	p2p := server.IntfConfig{IntfRef: "eth1", NetworkType: server.NETWORK_P2P}
+	tn.addIntf(t, r1, p2p, 1, "00:00:00:00:01:01", "10.0.12.1/30", "fe80::1/64")
+	tn.addIntf(t, r2, p2p, 1, "00:00:00:00:02:01", "10.0.12.2/30", "fe80::2/64")
+	p2p.IntfRef = "eth2"
+	tn.addIntf(t, r2, p2p, 2, "00:00:00:00:02:02", "10.0.23.2/30", "fe80::2:2/64")
+	tn.addIntf(t, r3, p2p, 1, "00:00:00:00:03:02", "10.0.23.3/30", "fe80::3/64")
+	tn.addIntf(t, r1, server.IntfConfig{IntfRef: "lo", Passive: true, Metric: 1}, 3, "", "1.1.1.1/32")
+	tn.addIntf(t, r3, server.IntfConfig{IntfRef: "lo", Passive: true, Metric: 1}, 3, "", "3.3.3.3/32")
+	tn.connect(linkEnd{r1, "eth1"}, linkEnd{r2, "eth1"})
+	tn.connect(linkEnd{r2, "eth2"}, linkEnd{r3, "eth2"})
+	tn.run(10)
+
+	checkRoute(t, r3, "1.1.1.1/32", 21, "10.0.23.2%eth2")
+	checkRoute(t, r3, "10.0.12.0/30", 20, "10.0.23.2%eth2")
+	checkRoute(t, r1, "0.0.0.0/0", 10, "10.0.12.2%eth1")
+	checkRoute(t, r1, "::/0", 10, "fe80::2%eth1")
+	if _, exist := getRoutes(r1)["3.3.3.3/32"]; exist {
+		t.Error("Level 2 route leaked into level 1")
+	}
+	if route := getRoutes(r2)["1.1.1.1/32"]; route.Level != packet.Level1 {
+		t.Error("Level 1 route not preferred", route)
+	}
+	lsps := getLsps(r1)
+	if lsp := lsps["0000.0000.0002.00-00"]; !lsp.Attached {
+		t.Error("Attached bit not set by L1/L2 router", lsp)
+	}
+}
@@ -101,7 +101,8 @@ func GetNeighborEntry(ipAddr string) *config.NeighborConfig {
 	return ndpApi.server.GetNeighborEntry(ipAddr)
 }
 
-func CreateGlobalConfig(vrf string, retransmit uint32, reachableTime uint32, raTime uint8) (bool, error) {
+func CreateGlobalConfig(vrf string, retransmit uint32, reachableTime uint32, raTime uint8, dadTransmits uint8,
+	optimisticDad bool) (bool, error) {
 	if ndpApi.server == nil {
 		return false, errors.New("Server is not initialized")
 	}
@@ -109,12 +110,85 @@ func CreateGlobalConfig(vrf string, retransmit uint32, reachableTime uint32, raT
 	if err != nil {
 		return rv, err
 	}
-	ndpApi.server.GlobalCfg <- server.NdpConfig{vrf, reachableTime, retransmit, raTime}
+	rv, err = ndpApi.server.NdpConfig.ValidateDad(dadTransmits)
+	if err != nil {
+		return rv, err
+	}
+	ndpApi.server.GlobalCfg <- server.NdpConfig{vrf, reachableTime, retransmit, raTime, dadTransmits, optimisticDad}
 	return true, nil
 }
 
-func UpdateGlobalConfig(vrf string, retransmit uint32, reachableTime uint32, raTime uint8) (bool, error) {
-	return CreateGlobalConfig(vrf, retransmit, reachableTime, raTime)
+func UpdateGlobalConfig(vrf string, retransmit uint32, reachableTime uint32, raTime uint8, dadTransmits uint8,
+	optimisticDad bool) (bool, error) {
+	return CreateGlobalConfig(vrf, retransmit, reachableTime, raTime, dadTransmits, optimisticDad)
+}
+
+func sendRAIntfConfig(oper string, cfg config.RAIntfConfig) (bool, error) {
+	if ndpApi.server == nil {
+		return false, errors.New("Server is not initialized")
+	}
+	rv, err := server.ValidateRAIntfConfig(&cfg)
+	if err != nil {
+		return rv, err
+	}
+	ndpApi.server.RaIntfCfgCh <- &config.RAIntfNotification{
+		Operation: oper,
+		Cfg:       cfg,
+	}
+	return true, nil
+}
+
+func CreateRAIntfConfig(cfg config.RAIntfConfig) (bool, error) {
+	return sendRAIntfConfig(config.CONFIG_CREATE, cfg)
+}
+
+func UpdateRAIntfConfig(cfg config.RAIntfConfig) (bool, error) {
+	return sendRAIntfConfig(config.CONFIG_UPDATE, cfg)
+}
+
+func DeleteRAIntfConfig(intfRef string) (bool, error) {
+	if ndpApi.server == nil {
+		return false, errors.New("Server is not initialized")
+	}
+	ndpApi.server.RaIntfCfgCh <- &config.RAIntfNotification{
+		Operation: config.CONFIG_DELETE,
+		Cfg:       config.RAIntfConfig{IntfRef: intfRef},
+	}
+	return true, nil
+}
+
+func sendNdpIntfConfig(oper string, cfg config.NdpIntfConfig) (bool, error) {
+	if ndpApi.server == nil {
+		return false, errors.New("Server is not initialized")
+	}
+	rv, err := server.ValidateNdpIntfConfig(&cfg)
+	if err != nil {
+		return rv, err
+	}
+	ndpApi.server.NdpIntfCfgCh <- &config.NdpIntfNotification{
+		Operation: oper,
+		Cfg:       cfg,
+	}
+	return true, nil
+}
+
+func CreateNdpIntfConfig(cfg config.NdpIntfConfig) (bool, error) {
+	return sendNdpIntfConfig(config.CONFIG_CREATE, cfg)
+}
+
+func UpdateNdpIntfConfig(cfg config.NdpIntfConfig) (bool, error) {
+	return sendNdpIntfConfig(config.CONFIG_UPDATE, cfg)
+}
+
+func DeleteNdpIntfConfig(intfRef string) (bool, error) {
+	if ndpApi.server == nil {
+		return false, errors.New("Server is not initialized")
+	}
+	ndpApi.server.NdpIntfCfgCh <- &config.NdpIntfNotification{
+		Operation: config.CONFIG_DELETE,
+		Cfg:       config.NdpIntfConfig{IntfRef: intfRef},
+	}
+	return true, nil
 }
 
 func GetNDPGlobalState(vrf string) (*config.GlobalState, error) {
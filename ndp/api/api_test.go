@@ -117,8 +117,9 @@ func TestNdpGlobalConfigState(t *testing.T) {
 	raTime := uint8(5)
 	reachableTime := uint32(30000)
 	retransmit := uint32(1)
+	dadTransmits := uint8(1)
 	vrf := "default"
-	rv, err := CreateGlobalConfig("", retransmit, reachableTime, raTime)
+	rv, err := CreateGlobalConfig("", retransmit, reachableTime, raTime, dadTransmits, false)
 	if err == nil {
 		t.Error("Create Global NDP Config should fail for \"\" as vrf")
 		return
@@ -127,7 +128,7 @@ func TestNdpGlobalConfigState(t *testing.T) {
 		t.Error("Create Global NDP Config should fail for \"\" as vrf")
 		return
 	}
-	rv, err = CreateGlobalConfig(vrf, retransmit, reachableTime, raTime)
+	rv, err = CreateGlobalConfig(vrf, retransmit, reachableTime, raTime, dadTransmits, false)
 	if err != nil {
 		t.Error("Create Global NDP Config should not fail for vrf:", vrf)
 		return
@@ -137,7 +138,7 @@ func TestNdpGlobalConfigState(t *testing.T) {
 		return
 	}
 
-	rv, err = UpdateGlobalConfig(vrf, retransmit, reachableTime, raTime)
+	rv, err = UpdateGlobalConfig(vrf, retransmit, reachableTime, raTime, dadTransmits, false)
 	if err != nil {
 		t.Error("Update Global NDP Config should not fail for vrf:", vrf)
 		return
@@ -153,7 +154,14 @@ func TestNdpGlobalConfigState(t *testing.T) {
 		return
 	}
 	//t.Log(*result)
-	wantGblState := &config.GlobalState{vrf, int32(retransmit), int32(reachableTime), int32(raTime), 0, 0, 0}
+	wantGblState := &config.GlobalState{
+		Vrf:                         vrf,
+		RetransmitInterval:          int32(retransmit),
+		ReachableTime:               int32(reachableTime),
+		RouterAdvertisementInterval: int32(raTime),
+		DupAddrDetectTransmits:      int32(dadTransmits),
+		OptimisticDad:               false,
+	}
 	if !reflect.DeepEqual(result, wantGblState) {
 		t.Error("Failure in getting ndp global state, want:", *wantGblState, "got:", *result)
 		return
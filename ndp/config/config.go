@@ -57,6 +57,7 @@ type PortInfo struct {
 	OperState   string
 	MacAddr     string
 	Description string
+	Mtu         int32
 }
 
 type PortState struct {
@@ -85,6 +86,8 @@ type GlobalState struct {
 	RetransmitInterval          int32
 	ReachableTime               int32
 	RouterAdvertisementInterval int32
+	DupAddrDetectTransmits      int32
+	OptimisticDad               bool
 	Neighbors                   int32
 	TotalTxPackets              int64
 	TotalRxPackets              int64
@@ -108,13 +111,15 @@ type NeighborEntry struct {
 }
 
 type InterfaceEntries struct {
-	IntfRef         string
-	IfIndex         int32
-	LinkScopeIp     string
-	GlobalScopeIp   string
-	SendPackets     int64
-	ReceivedPackets int64
-	Neighbor        []NeighborEntry
+	IntfRef            string
+	IfIndex            int32
+	LinkScopeIp        string
+	LinkScopeIpState   string // Tentative, Optimistic, Preferred or Duplicate
+	GlobalScopeIp      string
+	GlobalScopeIpState string
+	SendPackets        int64
+	ReceivedPackets    int64
+	Neighbor           []NeighborEntry
 }
 
 type VlanInfo struct {
@@ -147,6 +152,7 @@ type PacketData struct {
 	NeighborMac string
 	IfIndex     int32
 	FastProbe   bool
+	DadProbe    bool // Duplicate Address Detection probe for NeighborIp which is our own address
 }
 
 type ActionData struct {
@@ -154,3 +160,50 @@ type ActionData struct {
 	NbrIp   string
 	IntfRef string
 }
+
+type RAPrefixConfig struct {
+	Prefix            string // CIDR Format
+	OnLink            bool
+	Autonomous        bool
+	ValidLifetime     uint32
+	PreferredLifetime uint32
+}
+
+type RARouteConfig struct {
+	Prefix     string // CIDR Format
+	Preference string // high, medium, low
+	Lifetime   uint32
+}
+
+type RAIntfConfig struct {
+	IntfRef             string
+	CurHopLimit         uint8
+	ManagedFlag         bool
+	OtherConfigFlag     bool
+	RouterPreference    string // high, medium, low
+	RouterLifetime      uint16
+	ReachableTime       uint32
+	RetransTime         uint32
+	AdvertiseIntfPrefix bool // advertise global scope prefix of the interface
+	Prefixes            []RAPrefixConfig
+	RdnssServers        []string
+	RdnssLifetime       uint32
+	DnsslDomains        []string
+	DnsslLifetime       uint32
+	Routes              []RARouteConfig
+}
+
+type RAIntfNotification struct {
+	Operation string
+	Cfg       RAIntfConfig
+}
+
+type NdpIntfConfig struct {
+	IntfRef       string
+	SendRedirects bool // generate ICMPv6 Redirect for packets forwarded back on the same interface
+}
+
+type NdpIntfNotification struct {
+	Operation string
+	Cfg       NdpIntfConfig
+}
@@ -33,7 +33,7 @@ import (
 
 func (h *ConfigHandler) CreateNDPGlobal(config *ndpd.NDPGlobal) (bool, error) {
 	return api.CreateGlobalConfig(config.Vrf, uint32(config.RetransmitInterval), uint32(config.ReachableTime),
-		uint8(config.RouterAdvertisementInterval))
+		uint8(config.RouterAdvertisementInterval), uint8(config.DupAddrDetectTransmits), config.OptimisticDad)
 }
 
 func (h *ConfigHandler) UpdateNDPGlobal(orgCfg *ndpd.NDPGlobal, newCfg *ndpd.NDPGlobal, attrset []bool, op []*ndpd.PatchOpInfo) (bool, error) {
@@ -44,6 +44,72 @@ func (h *ConfigHandler) DeleteNDPGlobal(config *ndpd.NDPGlobal) (bool, error) {
 	return false, errors.New("Delete of Global Object is not supported")
 }
 
+func convertNDPRouterAdvertisementToConfig(cfg *ndpd.NDPRouterAdvertisement) config.RAIntfConfig {
+	raCfg := config.RAIntfConfig{
+		IntfRef:             cfg.IntfRef,
+		CurHopLimit:         uint8(cfg.CurHopLimit),
+		ManagedFlag:         cfg.ManagedFlag,
+		OtherConfigFlag:     cfg.OtherConfigFlag,
+		RouterPreference:    cfg.RouterPreference,
+		RouterLifetime:      uint16(cfg.RouterLifetime),
+		ReachableTime:       uint32(cfg.ReachableTime),
+		RetransTime:         uint32(cfg.RetransTime),
+		AdvertiseIntfPrefix: cfg.AdvertiseIntfPrefix,
+		RdnssServers:        cfg.RdnssServers,
+		RdnssLifetime:       uint32(cfg.RdnssLifetime),
+		DnsslDomains:        cfg.DnsslDomains,
+		DnsslLifetime:       uint32(cfg.DnsslLifetime),
+	}
+	for _, prefix := range cfg.Prefixes {
+		raCfg.Prefixes = append(raCfg.Prefixes, config.RAPrefixConfig{
+			Prefix:            prefix.Prefix,
+			OnLink:            prefix.OnLink,
+			Autonomous:        prefix.Autonomous,
+			ValidLifetime:     uint32(prefix.ValidLifetime),
+			PreferredLifetime: uint32(prefix.PreferredLifetime),
+		})
+	}
+	for _, route := range cfg.Routes {
+		raCfg.Routes = append(raCfg.Routes, config.RARouteConfig{
+			Prefix:     route.Prefix,
+			Preference: route.Preference,
+			Lifetime:   uint32(route.Lifetime),
+		})
+	}
+	return raCfg
+}
+
+func (h *ConfigHandler) CreateNDPRouterAdvertisement(config *ndpd.NDPRouterAdvertisement) (bool, error) {
+	return api.CreateRAIntfConfig(convertNDPRouterAdvertisementToConfig(config))
+}
+
+func (h *ConfigHandler) UpdateNDPRouterAdvertisement(orgCfg *ndpd.NDPRouterAdvertisement, newCfg *ndpd.NDPRouterAdvertisement, attrset []bool, op []*ndpd.PatchOpInfo) (bool, error) {
+	return api.UpdateRAIntfConfig(convertNDPRouterAdvertisementToConfig(newCfg))
+}
+
+func (h *ConfigHandler) DeleteNDPRouterAdvertisement(config *ndpd.NDPRouterAdvertisement) (bool, error) {
+	return api.DeleteRAIntfConfig(config.IntfRef)
+}
+
+func convertNDPInterfaceToConfig(cfg *ndpd.NDPInterface) config.NdpIntfConfig {
+	return config.NdpIntfConfig{
+		IntfRef:       cfg.IntfRef,
+		SendRedirects: cfg.SendRedirects,
+	}
+}
+
+func (h *ConfigHandler) CreateNDPInterface(config *ndpd.NDPInterface) (bool, error) {
+	return api.CreateNdpIntfConfig(convertNDPInterfaceToConfig(config))
+}
+
+func (h *ConfigHandler) UpdateNDPInterface(orgCfg *ndpd.NDPInterface, newCfg *ndpd.NDPInterface, attrset []bool, op []*ndpd.PatchOpInfo) (bool, error) {
+	return api.UpdateNdpIntfConfig(convertNDPInterfaceToConfig(newCfg))
+}
+
+func (h *ConfigHandler) DeleteNDPInterface(config *ndpd.NDPInterface) (bool, error) {
+	return api.DeleteNdpIntfConfig(config.IntfRef)
+}
+
 func convertNDPEntryStateToThriftEntry(state config.NeighborConfig) *ndpd.NDPEntryState {
 	entry := ndpd.NewNDPEntryState()
 	entry.IpAddr = state.IpAddr
@@ -94,6 +160,8 @@ func (h *ConfigHandler) convertNDPGlobalStateEntryToThriftEntry(gblState *config
 	entry.RetransmitInterval = gblState.RetransmitInterval
 	entry.ReachableTime = gblState.ReachableTime
 	entry.RouterAdvertisementInterval = gblState.RouterAdvertisementInterval
+	entry.DupAddrDetectTransmits = gblState.DupAddrDetectTransmits
+	entry.OptimisticDad = gblState.OptimisticDad
 	return entry
 }
 
@@ -120,6 +188,8 @@ func (h *ConfigHandler) convertIPV6AdjStateEntryToThriftEntry(state config.Inter
 	entry.IfIndex = state.IfIndex
 	entry.LinkScopeIp = state.LinkScopeIp
 	entry.GlobalScopeIp = state.GlobalScopeIp
+	entry.LinkScopeIpState = state.LinkScopeIpState
+	entry.GlobalScopeIpState = state.GlobalScopeIpState
 	entry.ReceivedPackets = state.ReceivedPackets
 	entry.SendPackets = state.SendPackets
 	for _, nbrEntry := range state.Neighbor {
@@ -0,0 +1,84 @@
+// Copyright [2016] [SnapRoute Inc]
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	   http://www.apache.org/licenses/LICENSE-2.0
+//
+//		 Unless required by applicable law or agreed to in writing, software
+//		 distributed under the License is distributed on an "AS IS" BASIS,
+//		 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//		 See the License for the specific language governing permissions and
+//		 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+package flexswitch
+
+import (
+	"errors"
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"l3/ndp/debug"
+	"ribd"
+	"ribdInt"
+	"strconv"
+	"time"
+	"utils/ipcutils"
+)
+
+/*
+ *  RibdPlugin resolves the next hop of a destination from ribd, used by redirect to find the better first hop
+ */
+type RibdPlugin struct {
+	Address            string
+	Transport          thrift.TTransport
+	PtrProtocolFactory *thrift.TBinaryProtocolFactory
+	ClientHdl          *ribd.RIBDServicesClient
+}
+
+/*
+ *  Connect to ribd, retries until ribd is up
+ */
+func NewRibdPlugin(paramsDir string) *RibdPlugin {
+	plugin := &RibdPlugin{}
+	clientJson, err := getClient(paramsDir+"clients.json", "ribd")
+	if err != nil || clientJson == nil {
+		debug.Logger.Err("Couldn't find ribd port info, redirect will only use on-link destinations", err)
+		return plugin
+	}
+	plugin.Address = "localhost:" + strconv.Itoa(clientJson.Port)
+	for {
+		plugin.Transport, plugin.PtrProtocolFactory, err = ipcutils.CreateIPCHandles(plugin.Address)
+		if err == nil {
+			break
+		}
+		debug.Logger.Info("Failed to connect to ribd, retrying")
+		time.Sleep(time.Second)
+	}
+	plugin.ClientHdl = ribd.NewRIBDServicesClientFactory(plugin.Transport, plugin.PtrProtocolFactory)
+	debug.Logger.Info("Connected to ribd at", plugin.Address)
+	return plugin
+}
+
+/*
+ *  GetNextHop returns the next hop ip and l3 ifIndex used to forward packets to ipAddr, for connected
+ *  destinations the next hop ip is unspecified
+ */
+func (plugin *RibdPlugin) GetNextHop(ipAddr string) (string, int32, error) {
+	if plugin.ClientHdl == nil {
+		return "", -1, errors.New("not connected to ribd")
+	}
+	nhInfo, err := plugin.ClientHdl.GetRouteReachabilityInfo(ipAddr, ribdInt.Int(-1))
+	if err != nil {
+		return "", -1, err
+	}
+	if nhInfo == nil || !nhInfo.IsReachable {
+		return "", -1, errors.New(ipAddr + " is not reachable")
+	}
+	return nhInfo.NextHopIp, int32(nhInfo.NextHopIfIndex), nil
+}
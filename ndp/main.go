@@ -67,6 +67,9 @@ func main() {
 		// create new ndp server and cache the information for switch/asicd plugin
 		debug.Logger.Info("Creating NDP Server")
 		ndpServer := server.NDPNewServer(switchPlugin, ndpBase)
+		// ribd plugin is used to find the next hop of destinations for redirect
+		debug.Logger.Info("Connecting to RIBd")
+		ndpServer.RoutePlugin = flexswitch.NewRibdPlugin(ndpBase.ParamsDir)
 		// Init API layer after server is created
 		debug.Logger.Info("Starting API Layer for NDP server")
 		api.Init(ndpServer)
@@ -115,6 +115,28 @@ func (p *Packet) decodeRA(hdr *layers.ICMPv6, srcIP, dstIP net.IP) (*NDInfo, err
 	return ndInfo, nil
 }
 
+func (p *Packet) decodeRS(hdr *layers.ICMPv6, srcIP, dstIP net.IP) (*NDInfo, error) {
+	ndInfo := &NDInfo{}
+	ndInfo.PktType = layers.ICMPv6TypeRouterSolicitation
+	ndInfo.DecodeRSInfo(hdr.LayerPayload())
+	err := ndInfo.ValidateRSInfo(srcIP)
+	if err != nil {
+		return nil, err
+	}
+	return ndInfo, nil
+}
+
+func (p *Packet) decodeRedirect(hdr *layers.ICMPv6, srcIP, dstIP net.IP) (*NDInfo, error) {
+	ndInfo := &NDInfo{}
+	ndInfo.PktType = layers.ICMPv6TypeRedirect
+	ndInfo.DecodeRedirectInfo(hdr.LayerPayload())
+	err := ndInfo.ValidateRedirectInfo(srcIP)
+	if err != nil {
+		return nil, err
+	}
+	return ndInfo, nil
+}
+
 func (p *Packet) decodeICMPv6Hdr(hdr *layers.ICMPv6, srcIP net.IP, dstIP net.IP) (*NDInfo, error) {
 	ndInfo := &NDInfo{}
 	var err error
@@ -135,10 +157,13 @@ func (p *Packet) decodeICMPv6Hdr(hdr *layers.ICMPv6, srcIP net.IP, dstIP net.IP)
 		ndInfo, err = p.decodeNA(hdr, srcIP, dstIP)
 
 	case layers.ICMPv6TypeRouterSolicitation:
-		return nil, errors.New("Router Solicitation is not yet supported")
+		ndInfo, err = p.decodeRS(hdr, srcIP, dstIP)
 
 	case layers.ICMPv6TypeRouterAdvertisement:
 		ndInfo, err = p.decodeRA(hdr, srcIP, dstIP)
+
+	case layers.ICMPv6TypeRedirect:
+		ndInfo, err = p.decodeRedirect(hdr, srcIP, dstIP)
 	default:
 		return nil, errors.New(fmt.Sprintln("Not Supported ICMPv6 Type:", typeCode.Type()))
 	}
@@ -209,3 +234,22 @@ func (p *Packet) DecodeND(pkt gopacket.Packet) (*NDInfo, error) {
 
 	return ndInfo, nil
 }
+
+/*
+ * API: returns true if the packet is a neighbor discovery message, everything else received on the interface is
+ *	data packet punted to cpu
+ */
+func IsNDPacket(pkt gopacket.Packet) bool {
+	icmpv6Layer := pkt.Layer(layers.LayerTypeICMPv6)
+	if icmpv6Layer == nil {
+		return false
+	}
+	icmpv6Hdr := icmpv6Layer.(*layers.ICMPv6)
+	switch icmpv6Hdr.TypeCode.Type() {
+	case layers.ICMPv6TypeRouterSolicitation, layers.ICMPv6TypeRouterAdvertisement,
+		layers.ICMPv6TypeNeighborSolicitation, layers.ICMPv6TypeNeighborAdvertisement,
+		layers.ICMPv6TypeRedirect:
+		return true
+	}
+	return false
+}
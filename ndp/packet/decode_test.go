@@ -244,7 +244,7 @@ func TestUnSupportedICMPv6(t *testing.T) {
 	pkt := &Packet{}
 	csum := []byte{0x9a, 0xbb}
 	flags := []byte{0xa0, 00, 00, 00}
-	icmpv6Hdr.TypeCode = layers.CreateICMPv6TypeCode(137, 0)
+	icmpv6Hdr.TypeCode = layers.CreateICMPv6TypeCode(138, 0)
 	icmpv6Hdr.Checksum = binary.BigEndian.Uint16(csum[:])
 	icmpv6Hdr.TypeBytes = append(icmpv6Hdr.TypeBytes, flags...)
 	var ip net.IP
@@ -258,3 +258,139 @@ func TestUnSupportedICMPv6(t *testing.T) {
 		t.Error("Validating ICMPv6 Header should have failed:", err)
 	}
 }
+
+const (
+	testRedirectSrcMac  = "88:1d:fc:cf:15:fc"
+	testRedirectDstMac  = "00:1f:16:25:33:ce"
+	testRedirectSrcIp   = "fe80::8a1d:fcff:fecf:15fc"
+	testRedirectDstIp   = "2001:db8:0:f101::2"
+	testRedirectTarget  = "2001:db8:0:f101::3"
+	testRedirectNbrMac  = "00:1f:16:25:34:31"
+	testRedirectDataLen = 64
+)
+
+func constructTestRedirectPkt(srcIp string, redirect *RedirectInfo) gopacket.Packet {
+	pkt := &Packet{
+		SrcMac:   testRedirectSrcMac,
+		DstMac:   testRedirectDstMac,
+		SrcIp:    srcIp,
+		DstIp:    testRedirectDstIp,
+		PType:    layers.ICMPv6TypeRedirect,
+		Redirect: redirect,
+	}
+	return gopacket.NewPacket(pkt.Encode(), layers.LinkTypeEthernet, gopacket.Default)
+}
+
+func TestDecodeNDUsingRedirectPkt(t *testing.T) {
+	initPacketTestBasics()
+	targetMac, _ := net.ParseMAC(testRedirectNbrMac)
+	redirectedPkt := make([]byte, testRedirectDataLen+1)
+	for idx, _ := range redirectedPkt {
+		redirectedPkt[idx] = byte(idx)
+	}
+	redirect := &RedirectInfo{
+		Target:        net.ParseIP(testRedirectTarget),
+		Destination:   net.ParseIP(testRedirectTarget),
+		TargetMac:     targetMac,
+		RedirectedPkt: redirectedPkt,
+	}
+	p := constructTestRedirectPkt(testRedirectSrcIp, redirect)
+	if p.ErrorLayer() != nil {
+		t.Error("Failed to decode packet:", p.ErrorLayer().Error())
+		return
+	}
+	pkt := &Packet{}
+	ndInfo, err := pkt.DecodeND(p)
+	if err != nil {
+		t.Error("Failed to Decode Redirect Packet, Error:", err)
+		return
+	}
+	if ndInfo.PktType != layers.ICMPv6TypeRedirect || !ndInfo.TargetAddress.Equal(redirect.Target) ||
+		!ndInfo.DestinationAddress.Equal(redirect.Destination) {
+		t.Error("Invalid redirect information decoded:", ndInfo)
+		return
+	}
+	if ndInfo.SrcIp != testRedirectSrcIp || ndInfo.DstIp != testRedirectDstIp {
+		t.Error("Invalid redirect ip header decoded src:", ndInfo.SrcIp, "dst:", ndInfo.DstIp)
+		return
+	}
+	if len(ndInfo.Options) != 2 {
+		t.Error("Redirect should have Target Link-Layer & Redirected Header options, got:", len(ndInfo.Options))
+		return
+	}
+	tllaOpt := ndInfo.Options[0]
+	if tllaOpt.Type != NDOptionTypeTargetLinkLayerAddress || !reflect.DeepEqual(tllaOpt.Value, []byte(targetMac)) {
+		t.Error("Invalid Target Link-Layer Address option:", tllaOpt)
+		return
+	}
+	rhOpt := ndInfo.Options[1]
+	// 65 bytes of data + 8 bytes header padded to 80 bytes
+	if rhOpt.Type != NDOptionTypeRedirectHeader || rhOpt.Length != 10 {
+		t.Error("Invalid Redirected Header option type:", rhOpt.Type, "length:", rhOpt.Length)
+		return
+	}
+	if !reflect.DeepEqual(rhOpt.Value[6:6+len(redirectedPkt)], redirectedPkt) {
+		t.Error("Redirected Header option doesn't carry the redirected packet")
+		return
+	}
+}
+
+func TestRedirectTruncatedToMinMtu(t *testing.T) {
+	initPacketTestBasics()
+	redirect := &RedirectInfo{
+		Target:        net.ParseIP(testRedirectTarget),
+		Destination:   net.ParseIP(testRedirectTarget),
+		RedirectedPkt: make([]byte, 2*IPV6_MIN_MTU),
+	}
+	p := constructTestRedirectPkt(testRedirectSrcIp, redirect)
+	ipLayer := p.Layer(layers.LayerTypeIPv6)
+	if ipLayer == nil {
+		t.Error("Failed decoding ipv6 layer for redirect")
+		return
+	}
+	ipv6 := ipLayer.(*layers.IPv6)
+	if int(ipv6.Length)+IPV6_HDR_LENGTH > IPV6_MIN_MTU {
+		t.Error("Redirect exceeds minimum ipv6 mtu, length:", int(ipv6.Length)+IPV6_HDR_LENGTH)
+		return
+	}
+	pkt := &Packet{}
+	if _, err := pkt.DecodeND(p); err != nil {
+		t.Error("Failed to Decode truncated Redirect Packet, Error:", err)
+	}
+}
+
+func TestRedirectValidation(t *testing.T) {
+	initPacketTestBasics()
+	pkt := &Packet{}
+	redirect := &RedirectInfo{
+		Target:      net.ParseIP(testRedirectTarget),
+		Destination: net.ParseIP(testRedirectTarget),
+	}
+	// source address must be link-local
+	_, err := pkt.DecodeND(constructTestRedirectPkt(testNaSrcIp, redirect))
+	if err == nil {
+		t.Error("Redirect from global address should have failed validation")
+		return
+	}
+	// destination can't be multicast
+	redirect.Destination = net.ParseIP(TEST_ALL_NODES_MULTICAST_IPV6_ADDRESS)
+	_, err = pkt.DecodeND(constructTestRedirectPkt(testRedirectSrcIp, redirect))
+	if err == nil {
+		t.Error("Redirect for multicast destination should have failed validation")
+		return
+	}
+	// target must be link-local or same as destination
+	redirect.Destination = net.ParseIP(testRedirectDstIp)
+	_, err = pkt.DecodeND(constructTestRedirectPkt(testRedirectSrcIp, redirect))
+	if err == nil {
+		t.Error("Redirect with global target different than destination should have failed validation")
+		return
+	}
+	// redirect to a router on the link
+	redirect.Target = net.ParseIP(testNsSrcIp)
+	_, err = pkt.DecodeND(constructTestRedirectPkt(testRedirectSrcIp, redirect))
+	if err != nil {
+		t.Error("Redirect with link-local target should pass validation, Error:", err)
+		return
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"l3/ndp/debug"
 	"net"
+	"strings"
 )
 
 func (pkt *Packet) constructEthLayer() *layers.Ethernet {
@@ -61,60 +62,289 @@ func (pkt *Packet) constructIPv6Layer() *layers.IPv6 {
 	return ipv6
 }
 
-func constructICMPv6NS(srcMac net.HardwareAddr, ipv6 *layers.IPv6) []byte {
+func constructICMPv6NS(srcMac net.HardwareAddr, ipv6 *layers.IPv6, targetIp net.IP) []byte {
 	// ICMPV6 Layer Information
 	payload := make([]byte, ICMPV6_MIN_LENGTH)
 	payload[0] = byte(layers.ICMPv6TypeNeighborSolicitation)
 	payload[1] = byte(0)
 	binary.BigEndian.PutUint16(payload[2:4], 0) // Putting zero for checksum before calculating checksum
 	binary.BigEndian.PutUint32(payload[4:], 0)  // RESERVED FLAG...
-	copy(payload[8:], ipv6.DstIP.To16())
+	if targetIp != nil {
+		copy(payload[8:], targetIp.To16())
+	} else {
+		copy(payload[8:], ipv6.DstIP.To16())
+	}
 
-	// Append Source Link Layer Option here
-	srcOption := NDOption{
-		Type:   NDOptionTypeSourceLinkLayerAddress,
-		Length: 1,
-		Value:  srcMac,
+	// Append Source Link Layer Option here, RFC 4861 do not send source link layer option when source ip is
+	// unspecified address i.e during Duplicate Address Detection
+	if !ipv6.SrcIP.IsUnspecified() {
+		srcOption := NDOption{
+			Type:   NDOptionTypeSourceLinkLayerAddress,
+			Length: 1,
+			Value:  srcMac,
+		}
+		payload = append(payload, byte(srcOption.Type))
+		payload = append(payload, srcOption.Length)
+		payload = append(payload, srcOption.Value...)
 	}
-	payload = append(payload, byte(srcOption.Type))
-	payload = append(payload, srcOption.Length)
-	payload = append(payload, srcOption.Value...)
 	binary.BigEndian.PutUint16(payload[2:4], getCheckSum(ipv6, payload))
 	return payload
 }
 
-func constructICMPv6RA(srcMac net.HardwareAddr, ipv6 *layers.IPv6) []byte {
+/*
+ *  helper function to append option in TLV format, value should already be padded to 8 octet boundary
+ */
+func appendNDOption(payload []byte, option NDOption) []byte {
+	payload = append(payload, byte(option.Type))
+	payload = append(payload, option.Length)
+	payload = append(payload, option.Value...)
+	return payload
+}
+
+/*
+ *   0                   1                   2                   3
+ *   0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |    Length     | Prefix Length |L|A| Reserved1 |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                         Valid Lifetime                        |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                       Preferred Lifetime                      |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                           Reserved2                           |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                            Prefix                             |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ */
+func constructPrefixInfoOption(prefix PrefixInfo) NDOption {
+	value := make([]byte, 30)
+	value[0] = prefix.PrefixLength
+	if prefix.OnLink {
+		value[1] |= PREFIX_INFO_FLAG_ON_LINK
+	}
+	if prefix.Autonomous {
+		value[1] |= PREFIX_INFO_FLAG_AUTONOMOUS
+	}
+	binary.BigEndian.PutUint32(value[2:6], prefix.ValidLifetime)
+	binary.BigEndian.PutUint32(value[6:10], prefix.PreferredLifetime)
+	// value[10:14] is reserved2
+	copy(value[14:30], prefix.Prefix.Mask(net.CIDRMask(int(prefix.PrefixLength), 128)).To16())
+	return NDOption{
+		Type:   NDOptionTypePrefixInfo,
+		Length: ND_OPTION_PREFIX_INFO_LENGTH,
+		Value:  value,
+	}
+}
+
+/*
+ *   0                   1                   2                   3
+ *   0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |    Length     | Prefix Length |Resvd|Prf|Resvd|
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                        Route Lifetime                         |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                   Prefix (Variable Length)                    |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *
+ *  Length is 1, 2 or 3 depending on the prefix length (RFC 4191 section 2.3)
+ */
+func constructRouteInfoOption(route RouteInfo) NDOption {
+	length := ND_OPTION_ROUTE_INFO_MIN_UNIT
+	switch {
+	case route.PrefixLength > 64:
+		length += IPV6_ADDRESS_OPTION_UNITS
+	case route.PrefixLength > 0:
+		length += IPV6_ADDRESS_OPTION_UNITS / 2
+	}
+	value := make([]byte, int(length)*ND_OPTION_LENGTH_UNIT-2)
+	value[0] = route.PrefixLength
+	value[1] = (route.Preference & 0x03) << 3
+	binary.BigEndian.PutUint32(value[2:6], route.Lifetime)
+	prefix := route.Prefix.Mask(net.CIDRMask(int(route.PrefixLength), 128)).To16()
+	copy(value[6:], prefix[:len(value)-6])
+	return NDOption{
+		Type:   NDOptionTypeRouteInfo,
+		Length: length,
+		Value:  value,
+	}
+}
+
+/*
+ *   0                   1                   2                   3
+ *   0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |     Length    |           Reserved            |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                           Lifetime                            |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  :            Addresses of IPv6 Recursive DNS Servers            :
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ */
+func constructRDNSSOption(servers []net.IP, lifetime uint32) NDOption {
+	value := make([]byte, 6)
+	binary.BigEndian.PutUint32(value[2:6], lifetime)
+	for _, server := range servers {
+		value = append(value, server.To16()...)
+	}
+	return NDOption{
+		Type:   NDOptionTypeRDNSS,
+		Length: ND_OPTION_DNS_HDR_LENGTH + byte(len(servers))*IPV6_ADDRESS_OPTION_UNITS,
+		Value:  value,
+	}
+}
+
+/*
+ *   0                   1                   2                   3
+ *   0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |     Length    |           Reserved            |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                           Lifetime                            |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  :                Domain Names of DNS Search List                :
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *
+ *  Domain names are encoded as per RFC 1035 section 3.1 and padded with zeros to 8 octet boundary
+ */
+func constructDNSSLOption(domains []string, lifetime uint32) NDOption {
+	value := make([]byte, 6)
+	binary.BigEndian.PutUint32(value[2:6], lifetime)
+	for _, domain := range domains {
+		for _, label := range strings.Split(strings.Trim(domain, "."), ".") {
+			if label == "" {
+				continue
+			}
+			value = append(value, byte(len(label)))
+			value = append(value, label...)
+		}
+		value = append(value, 0)
+	}
+	// pad to 8 octet boundary, 2 bytes of type & length are not part of value
+	for (len(value)+2)%ND_OPTION_LENGTH_UNIT != 0 {
+		value = append(value, 0)
+	}
+	return NDOption{
+		Type:   NDOptionTypeDNSSL,
+		Length: byte((len(value) + 2) / ND_OPTION_LENGTH_UNIT),
+		Value:  value,
+	}
+}
+
+func constructMTUOption(mtu uint32) NDOption {
+	// Reserved is added as first 2 bytes in value
+	value := make([]byte, 6)
+	binary.BigEndian.PutUint32(value[2:6], mtu)
+	return NDOption{
+		Type:   NDOptionTypeMTU,
+		Length: ND_OPTION_MTU_LENGTH,
+		Value:  value,
+	}
+}
+
+func constructICMPv6RA(srcMac net.HardwareAddr, ipv6 *layers.IPv6, raInfo *RAInfo) []byte {
+	if raInfo == nil {
+		raInfo = DefaultRAInfo()
+	}
 	// ICMPV6 Layer Information
 	payload := make([]byte, ICMPV6_MIN_LENGTH_RA)
 	payload[0] = byte(layers.ICMPv6TypeRouterAdvertisement)
 	payload[1] = byte(0)
 	binary.BigEndian.PutUint16(payload[2:4], 0) // Putting zero for checksum before calculating checksum
-	payload[4] = byte(64)
-	payload[5] = byte(0)
-	binary.BigEndian.PutUint16(payload[6:8], 1800) // Router Lifetime
-	binary.BigEndian.PutUint32(payload[8:12], 0)   // reachable time
-	binary.BigEndian.PutUint32(payload[12:16], 0)  // retrans time
+	payload[4] = raInfo.CurHopLimit
+	if raInfo.ManagedFlag {
+		payload[5] |= RA_FLAG_MANAGED_ADDRESS
+	}
+	if raInfo.OtherConfigFlag {
+		payload[5] |= RA_FLAG_OTHER_CONFIG
+	}
+	// RFC 4191: if router lifetime is zero then preference must be set to medium
+	if raInfo.RouterLifetime != 0 {
+		payload[5] |= (raInfo.RouterPreference & 0x03) << 3
+	}
+	binary.BigEndian.PutUint16(payload[6:8], raInfo.RouterLifetime)
+	binary.BigEndian.PutUint32(payload[8:12], raInfo.ReachableTime)
+	binary.BigEndian.PutUint32(payload[12:16], raInfo.RetransTime)
 
 	// Append Source Link Layer Option here
 	srcOption := NDOption{
 		Type:   NDOptionTypeSourceLinkLayerAddress,
-		Length: 1,
+		Length: ND_OPTION_SOURCE_LINK_LENGTH,
 		Value:  srcMac,
 	}
+	payload = appendNDOption(payload, srcOption)
 
-	mtuOption := NDOption{
-		Type:   NDOptionTypeMTU,
-		Length: 1,
-		// Reserved is added as first 2 bytes in value
-		Value: []byte{0x00, 0x00, 0x00, 0x00, 0x05, 0xdc}, // 1500 hardcoded need to change this
+	if raInfo.Mtu != 0 {
+		payload = appendNDOption(payload, constructMTUOption(raInfo.Mtu))
 	}
-	payload = append(payload, byte(srcOption.Type))
-	payload = append(payload, srcOption.Length)
-	payload = append(payload, srcOption.Value...)
+	for _, prefix := range raInfo.Prefixes {
+		payload = appendNDOption(payload, constructPrefixInfoOption(prefix))
+	}
+	for _, route := range raInfo.Routes {
+		payload = appendNDOption(payload, constructRouteInfoOption(route))
+	}
+	if len(raInfo.RdnssServers) > 0 {
+		payload = appendNDOption(payload, constructRDNSSOption(raInfo.RdnssServers, raInfo.RdnssLifetime))
+	}
+	if len(raInfo.DnsslDomains) > 0 {
+		payload = appendNDOption(payload, constructDNSSLOption(raInfo.DnsslDomains, raInfo.DnsslLifetime))
+	}
+	binary.BigEndian.PutUint16(payload[2:4], getCheckSum(ipv6, payload))
+	return payload
+}
 
-	payload = append(payload, byte(mtuOption.Type))
-	payload = append(payload, mtuOption.Length)
-	payload = append(payload, mtuOption.Value...)
+/*
+ *  0                   1                   2                   3
+ *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |     Code      |          Checksum             |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                           Reserved                            |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                       Target Address (16 bytes)                |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                    Destination Address (16 bytes)              |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |   Options ...
+ *  +-+-+-+-+-+-+-+-+-+-+-+-
+ *  Redirected Header option carries as much of the original packet as possible without redirect exceeding
+ *  the minimum ipv6 mtu
+ */
+func constructICMPv6Redirect(ipv6 *layers.IPv6, redirect *RedirectInfo) []byte {
+	payload := make([]byte, ICMPV6_MIN_LENGTH_REDIRECT)
+	payload[0] = byte(layers.ICMPv6TypeRedirect)
+	payload[1] = byte(0)
+	binary.BigEndian.PutUint16(payload[2:4], 0) // Putting zero for checksum before calculating checksum
+	binary.BigEndian.PutUint32(payload[4:8], 0) // RESERVED FLAG...
+	copy(payload[8:24], redirect.Target.To16())
+	copy(payload[24:40], redirect.Destination.To16())
+	if redirect.TargetMac != nil {
+		payload = appendNDOption(payload, NDOption{
+			Type:   NDOptionTypeTargetLinkLayerAddress,
+			Length: ND_OPTION_SOURCE_LINK_LENGTH,
+			Value:  redirect.TargetMac,
+		})
+	}
+	if len(redirect.RedirectedPkt) > 0 {
+		maxLen := IPV6_MIN_MTU - IPV6_HDR_LENGTH - len(payload) - ND_OPTION_REDIRECT_HDR_LENGTH
+		maxLen -= maxLen % ND_OPTION_LENGTH_UNIT
+		redirectedPkt := redirect.RedirectedPkt
+		if len(redirectedPkt) > maxLen {
+			redirectedPkt = redirectedPkt[:maxLen]
+		}
+		// option length has to be multiple of 8 octets and hence pad the redirected packet with zero's
+		optLen := ND_OPTION_REDIRECT_HDR_LENGTH + len(redirectedPkt)
+		if optLen%ND_OPTION_LENGTH_UNIT != 0 {
+			optLen += ND_OPTION_LENGTH_UNIT - optLen%ND_OPTION_LENGTH_UNIT
+		}
+		value := make([]byte, optLen-2)
+		copy(value[ND_OPTION_REDIRECT_HDR_LENGTH-2:], redirectedPkt)
+		payload = appendNDOption(payload, NDOption{
+			Type:   NDOptionTypeRedirectHeader,
+			Length: byte(optLen / ND_OPTION_LENGTH_UNIT),
+			Value:  value,
+		})
+	}
 	binary.BigEndian.PutUint16(payload[2:4], getCheckSum(ipv6, payload))
 	return payload
 }
@@ -126,9 +356,11 @@ func (pkt *Packet) Encode() []byte {
 	var icmpv6Payload []byte
 	switch pkt.PType {
 	case layers.ICMPv6TypeNeighborSolicitation:
-		icmpv6Payload = constructICMPv6NS(eth.SrcMAC, ipv6)
+		icmpv6Payload = constructICMPv6NS(eth.SrcMAC, ipv6, net.ParseIP(pkt.TargetIp))
 	case layers.ICMPv6TypeRouterAdvertisement:
-		icmpv6Payload = constructICMPv6RA(eth.SrcMAC, ipv6)
+		icmpv6Payload = constructICMPv6RA(eth.SrcMAC, ipv6, pkt.RA)
+	case layers.ICMPv6TypeRedirect:
+		icmpv6Payload = constructICMPv6Redirect(ipv6, pkt.Redirect)
 	}
 
 	ipv6.Length = uint16(len(icmpv6Payload))
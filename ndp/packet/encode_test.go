@@ -23,11 +23,14 @@
 package packet
 
 import (
+	"encoding/binary"
 	"fmt"
+	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 	"infra/sysd/sysdCommonDefs"
 	"l3/ndp/debug"
 	"log/syslog"
+	"net"
 	"reflect"
 	"testing"
 	"utils/logging"
@@ -150,3 +153,176 @@ func TestNSUnicastEncode(t *testing.T) {
 		return
 	}
 }
+
+func TestRAEncodeWithOptions(t *testing.T) {
+	initPacketTestBasics()
+	raInfo := &RAInfo{
+		CurHopLimit:      64,
+		ManagedFlag:      true,
+		OtherConfigFlag:  true,
+		RouterPreference: ROUTER_PREFERENCE_HIGH,
+		RouterLifetime:   1800,
+		ReachableTime:    30000,
+		RetransTime:      1000,
+		Mtu:              9000,
+		Prefixes: []PrefixInfo{
+			PrefixInfo{
+				Prefix:            net.ParseIP("2001:db8:1::1"),
+				PrefixLength:      64,
+				OnLink:            true,
+				Autonomous:        true,
+				ValidLifetime:     2592000,
+				PreferredLifetime: 604800,
+			},
+		},
+		Routes: []RouteInfo{
+			RouteInfo{
+				Prefix:       net.ParseIP("2001:db8:2::"),
+				PrefixLength: 48,
+				Preference:   ROUTER_PREFERENCE_LOW,
+				Lifetime:     1800,
+			},
+		},
+		RdnssServers:  []net.IP{net.ParseIP("2001:db8::53"), net.ParseIP("2001:db8::54")},
+		RdnssLifetime: 600,
+		DnsslDomains:  []string{"example.com"},
+		DnsslLifetime: 600,
+	}
+	pkt := &Packet{
+		SrcMac: testRASrcMac,
+		DstMac: TEST_ALL_NODES_MULTICAST_LINK_LAYER_ADDRESS,
+		SrcIp:  testRALinkScopeIp,
+		DstIp:  TEST_ALL_NODES_MULTICAST_IPV6_ADDRESS,
+		PType:  layers.ICMPv6TypeRouterAdvertisement,
+		RA:     raInfo,
+	}
+	pktToSend := pkt.Encode()
+	p := gopacket.NewPacket(pktToSend, layers.LinkTypeEthernet, gopacket.Default)
+	if p.ErrorLayer() != nil {
+		t.Error("Failed to decode packet:", p.ErrorLayer().Error())
+		return
+	}
+	ndInfo, err := pkt.DecodeND(p)
+	if err != nil {
+		t.Error("Failed to Decode encoded RA Packet, Error:", err)
+		return
+	}
+	if ndInfo.CurHopLimit != 64 || ndInfo.RouterLifetime != 1800 || ndInfo.ReachableTime != 30000 ||
+		ndInfo.RetransTime != 1000 {
+		t.Error("Invalid RA header information:", ndInfo)
+		return
+	}
+	// M & O flags with high preference
+	if ndInfo.ReservedFlags != 0xc8 {
+		t.Errorf("Invalid RA flags 0x%x", ndInfo.ReservedFlags)
+		return
+	}
+	wantOptions := []struct {
+		Type   NDOptionType
+		Length byte
+	}{
+		{NDOptionTypeSourceLinkLayerAddress, 1},
+		{NDOptionTypeMTU, 1},
+		{NDOptionTypePrefixInfo, 4},
+		{NDOptionTypeRouteInfo, 2},
+		{NDOptionTypeRDNSS, 5},
+		{NDOptionTypeDNSSL, 3},
+	}
+	if len(ndInfo.Options) != len(wantOptions) {
+		t.Error("Want", len(wantOptions), "options but received", len(ndInfo.Options))
+		return
+	}
+	for idx, wantOpt := range wantOptions {
+		option := ndInfo.Options[idx]
+		if option.Type != wantOpt.Type || option.Length != wantOpt.Length {
+			t.Error("Want option", wantOpt, "but received type:", option.Type, "length:", option.Length)
+			return
+		}
+	}
+	mtuOpt := ndInfo.Options[1]
+	if binary.BigEndian.Uint32(mtuOpt.Value[2:6]) != 9000 {
+		t.Error("Invalid MTU option value:", mtuOpt.Value)
+	}
+	prefixOpt := ndInfo.Options[2]
+	if prefixOpt.Value[0] != 64 || prefixOpt.Value[1] != (PREFIX_INFO_FLAG_ON_LINK|PREFIX_INFO_FLAG_AUTONOMOUS) {
+		t.Error("Invalid Prefix Information option:", prefixOpt.Value)
+	}
+	if !net.IP(prefixOpt.Value[14:30]).Equal(net.ParseIP("2001:db8:1::")) {
+		t.Error("Prefix Information option should carry masked prefix:", net.IP(prefixOpt.Value[14:30]))
+	}
+	routeOpt := ndInfo.Options[3]
+	if routeOpt.Value[0] != 48 || routeOpt.Value[1] != ROUTER_PREFERENCE_LOW<<3 {
+		t.Error("Invalid Route Information option:", routeOpt.Value)
+	}
+	rdnssOpt := ndInfo.Options[4]
+	if !net.IP(rdnssOpt.Value[6:22]).Equal(raInfo.RdnssServers[0]) ||
+		!net.IP(rdnssOpt.Value[22:38]).Equal(raInfo.RdnssServers[1]) {
+		t.Error("Invalid RDNSS option:", rdnssOpt.Value)
+	}
+	wantDomain := []byte{7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0}
+	dnsslOpt := ndInfo.Options[5]
+	if !reflect.DeepEqual(dnsslOpt.Value[6:6+len(wantDomain)], wantDomain) {
+		t.Error("Invalid DNSSL option:", dnsslOpt.Value)
+	}
+}
+
+func TestRAEncodeZeroLifetimePreference(t *testing.T) {
+	initPacketTestBasics()
+	raInfo := DefaultRAInfo()
+	raInfo.RouterLifetime = 0
+	raInfo.RouterPreference = ROUTER_PREFERENCE_HIGH
+	ipv6 := &layers.IPv6{
+		SrcIP: net.ParseIP(testRALinkScopeIp),
+		DstIP: net.ParseIP(TEST_ALL_NODES_MULTICAST_IPV6_ADDRESS),
+	}
+	srcMac, _ := net.ParseMAC(testRASrcMac)
+	payload := constructICMPv6RA(srcMac, ipv6, raInfo)
+	if payload[5] != 0 {
+		t.Errorf("Router preference must be medium when router lifetime is zero, flags: 0x%x", payload[5])
+	}
+}
+
+func TestNSDadEncode(t *testing.T) {
+	initPacketTestBasics()
+	targetIp := net.ParseIP(testNsDstIp)
+	snmAddr := SolicitedNodeMulticastAddr(targetIp)
+	if snmAddr.String() != "ff02::1:ff00:1" {
+		t.Error("Invalid solicited-node multicast address", snmAddr.String(), "for", testNsDstIp)
+		return
+	}
+	snmMac := MulticastMacAddr(snmAddr)
+	if snmMac.String() != "33:33:ff:00:00:01" {
+		t.Error("Invalid multicast mac address", snmMac.String(), "for", snmAddr.String())
+		return
+	}
+	pkt := &Packet{
+		SrcMac:   testNsSrcMac,
+		DstMac:   snmMac.String(),
+		SrcIp:    UNSPECIFIED_IP_ADDRESS,
+		DstIp:    snmAddr.String(),
+		TargetIp: testNsDstIp,
+		PType:    layers.ICMPv6TypeNeighborSolicitation,
+	}
+	pktToSend := pkt.Encode()
+	p := gopacket.NewPacket(pktToSend, layers.LinkTypeEthernet, gopacket.Default)
+	ipv6Layer := p.Layer(layers.LayerTypeIPv6)
+	if ipv6Layer == nil {
+		t.Error("Failed decoding ipv6 layer for DAD NS")
+		return
+	}
+	ipv6 := ipv6Layer.(*layers.IPv6)
+	if !ipv6.SrcIP.IsUnspecified() || !ipv6.DstIP.Equal(snmAddr) {
+		t.Error("Invalid DAD NS ip header src:", ipv6.SrcIP, "dst:", ipv6.DstIP)
+		return
+	}
+	payload := ipv6.LayerPayload()
+	// no source link layer option when source is unspecified address
+	if len(payload) != ICMPV6_MIN_LENGTH {
+		t.Error("DAD NS should not have any options, payload length:", len(payload))
+		return
+	}
+	if !net.IP(payload[8:24]).Equal(targetIp) {
+		t.Error("Invalid DAD NS target address", net.IP(payload[8:24]))
+		return
+	}
+}
@@ -30,6 +30,9 @@ const (
 	NDOptionTypePrefixInfo             NDOptionType = 3
 	NDOptionTypeRedirectHeader         NDOptionType = 4
 	NDOptionTypeMTU                    NDOptionType = 5
+	NDOptionTypeRouteInfo              NDOptionType = 24 // RFC 4191
+	NDOptionTypeRDNSS                  NDOptionType = 25 // RFC 8106
+	NDOptionTypeDNSSL                  NDOptionType = 31 // RFC 8106
 )
 
 const (
@@ -50,4 +53,41 @@ const (
 	// Router Advertisement Specific Constants
 	ICMPV6_MIN_LENGTH_RA         uint16 = 16
 	ICMPV6_MIN_PAYLOAD_LENGTH_RA        = 8
+
+	// Router Solicitation Specific Constants
+	ICMPV6_MIN_LENGTH_RS uint16 = 8
+
+	// Redirect Specific Constants
+	ICMPV6_MIN_LENGTH_REDIRECT         uint16 = 40
+	ICMPV6_MIN_PAYLOAD_LENGTH_REDIRECT        = 2 * IPV6_ADDRESS_BYTES // target + destination address
+	IPV6_HDR_LENGTH                           = 40
+	IPV6_MIN_MTU                              = 1280 // RFC: 2460, redirect must not exceed minimum mtu
+	ND_OPTION_REDIRECT_HDR_LENGTH             = 8    // type, length & 6 bytes reserved
+
+	// Router Advertisement Default Values, used when no RA Info is provided
+	RA_DEFAULT_CUR_HOP_LIMIT   uint8  = 64
+	RA_DEFAULT_ROUTER_LIFETIME uint16 = 1800
+	RA_DEFAULT_MTU             uint32 = 1500
+
+	// Router Advertisement Flags
+	RA_FLAG_MANAGED_ADDRESS byte = 0x80
+	RA_FLAG_OTHER_CONFIG    byte = 0x40
+
+	// Default Router Preference & Route Information Preference values RFC 4191
+	ROUTER_PREFERENCE_MEDIUM uint8 = 0x00
+	ROUTER_PREFERENCE_HIGH   uint8 = 0x01
+	ROUTER_PREFERENCE_LOW    uint8 = 0x03
+
+	// Prefix Information Option Flags
+	PREFIX_INFO_FLAG_ON_LINK    byte = 0x80
+	PREFIX_INFO_FLAG_AUTONOMOUS byte = 0x40
+
+	// Option length in units of 8 octets
+	ND_OPTION_LENGTH_UNIT              = 8
+	ND_OPTION_PREFIX_INFO_LENGTH  byte = 4
+	ND_OPTION_MTU_LENGTH          byte = 1
+	ND_OPTION_SOURCE_LINK_LENGTH  byte = 1
+	IPV6_ADDRESS_OPTION_UNITS     byte = 2
+	ND_OPTION_DNS_HDR_LENGTH      byte = 1
+	ND_OPTION_ROUTE_INFO_MIN_UNIT byte = 1
 )
@@ -54,6 +54,8 @@ type NDInfo struct {
 	RouterLifetime uint16
 	ReachableTime  uint32
 	RetransTime    uint32
+	// Redirect Information, Target Address is stored in TargetAddress
+	DestinationAddress net.IP
 
 	// For All Types
 	Options []*NDOption
@@ -109,7 +111,7 @@ func (nd *NDInfo) DecodeNDInfo(payload []byte) {
  *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
  *  |   Options ...
  *  +-+-+-+-+-+-+-+-+-+-+-+-
- *  @TODO: Handle Prefix Information Option Type
+ *  Options are variable length, so walk them using the option length in units of 8 octets
  */
 func (nd *NDInfo) DecodeRAInfo(typeByte, payload []byte) {
 	nd.CurHopLimit = typeByte[0]
@@ -119,13 +121,69 @@ func (nd *NDInfo) DecodeRAInfo(typeByte, payload []byte) {
 	nd.RetransTime = binary.BigEndian.Uint32(payload[4:8])
 	// if more than min payload length then it means that we have got options
 	if len(payload) > ICMPV6_MIN_PAYLOAD_LENGTH_RA {
-		for base := ICMPV6_MIN_PAYLOAD_LENGTH_RA; base < len(payload); base = base + 8 {
-			if base+8 > len(payload) {
-				break
-			}
-			ndOpt := DecodeOptionLayer(payload[base:(base + 8)])
-			nd.Options = append(nd.Options, ndOpt)
+		nd.decodeOptions(payload[ICMPV6_MIN_PAYLOAD_LENGTH_RA:])
+	}
+}
+
+/*
+ *  0                   1                   2                   3
+ *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |     Code      |          Checksum             |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                            Reserved                           |     <------ ICMPV6 Ends here
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |   Options ...
+ *  +-+-+-+-+-+-+-+-+-+-+-+-
+ */
+func (nd *NDInfo) DecodeRSInfo(payload []byte) {
+	if len(payload) > 0 {
+		nd.decodeOptions(payload)
+	}
+}
+
+/*
+ *  0                   1                   2                   3
+ *  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |     Type      |     Code      |          Checksum             |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                           Reserved                            |     <------ ICMPV6 Ends here
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                       Target Address (16 bytes)                |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |                    Destination Address (16 bytes)              |
+ *  +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+ *  |   Options ...
+ *  +-+-+-+-+-+-+-+-+-+-+-+-
+ */
+func (nd *NDInfo) DecodeRedirectInfo(payload []byte) {
+	nd.TargetAddress = make(net.IP, IPV6_ADDRESS_BYTES, IPV6_ADDRESS_BYTES)
+	nd.DestinationAddress = make(net.IP, IPV6_ADDRESS_BYTES, IPV6_ADDRESS_BYTES)
+	copy(nd.TargetAddress, payload[0:IPV6_ADDRESS_BYTES])
+	copy(nd.DestinationAddress, payload[IPV6_ADDRESS_BYTES:ICMPV6_MIN_PAYLOAD_LENGTH_REDIRECT])
+	if len(payload) > ICMPV6_MIN_PAYLOAD_LENGTH_REDIRECT {
+		nd.decodeOptions(payload[ICMPV6_MIN_PAYLOAD_LENGTH_REDIRECT:])
+	}
+}
+
+/*
+ *  helper function to decode all options, an option with zero length is kept as is so that validation can
+ *  discard the packet
+ */
+func (nd *NDInfo) decodeOptions(payload []byte) {
+	for base := 0; base+2 <= len(payload); {
+		length := int(payload[base+1]) * ND_OPTION_LENGTH_UNIT
+		if length == 0 {
+			nd.Options = append(nd.Options, DecodeOptionLayer(payload[base:base+2]))
+			break
 		}
+		if base+length > len(payload) {
+			break
+		}
+		ndOpt := DecodeOptionLayer(payload[base:(base + length)])
+		nd.Options = append(nd.Options, ndOpt)
+		base += length
 	}
 }
 
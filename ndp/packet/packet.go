@@ -21,17 +21,102 @@ package packet
 
 import (
 	"github.com/google/gopacket/layers"
+	"net"
 )
 
+/*
+ *  Prefix Information Option (RFC 4861, section 4.6.2) advertised in Router Advertisement
+ */
+type PrefixInfo struct {
+	Prefix            net.IP
+	PrefixLength      uint8
+	OnLink            bool
+	Autonomous        bool
+	ValidLifetime     uint32
+	PreferredLifetime uint32
+}
+
+/*
+ *  Route Information Option (RFC 4191, section 2.3) advertised in Router Advertisement
+ */
+type RouteInfo struct {
+	Prefix       net.IP
+	PrefixLength uint8
+	Preference   uint8
+	Lifetime     uint32
+}
+
+/*
+ *  Router Advertisement information used during encoding, if Packet does not carry any RA information
+ *  then default values are used
+ */
+type RAInfo struct {
+	CurHopLimit      uint8
+	ManagedFlag      bool
+	OtherConfigFlag  bool
+	RouterPreference uint8
+	RouterLifetime   uint16
+	ReachableTime    uint32
+	RetransTime      uint32
+	Mtu              uint32
+	Prefixes         []PrefixInfo
+	RdnssServers     []net.IP
+	RdnssLifetime    uint32
+	DnsslDomains     []string
+	DnsslLifetime    uint32
+	Routes           []RouteInfo
+}
+
+type RedirectInfo struct {
+	Target        net.IP           // better first hop, same as Destination when destination is on-link
+	Destination   net.IP           // destination of the redirected packet
+	TargetMac     net.HardwareAddr // Target Link-Layer Address, not included when nil
+	RedirectedPkt []byte           // ipv6 header + data of the packet which triggered the redirect
+}
+
 type Packet struct {
 	SrcMac string
 	DstMac string
 	SrcIp  string
 	DstIp  string
 	PType  layers.ICMPv6TypeCode
+	RA     *RAInfo
+	// Target Address for Neighbor Solicitation, if not set then DstIp is used as target
+	TargetIp string
+	Redirect *RedirectInfo
+}
+
+func DefaultRAInfo() *RAInfo {
+	return &RAInfo{
+		CurHopLimit:      RA_DEFAULT_CUR_HOP_LIMIT,
+		RouterPreference: ROUTER_PREFERENCE_MEDIUM,
+		RouterLifetime:   RA_DEFAULT_ROUTER_LIFETIME,
+		Mtu:              RA_DEFAULT_MTU,
+	}
 }
 
 func Init() *Packet {
 	pkt := &Packet{}
 	return pkt
 }
+
+/*
+ *  Solicited-Node multicast address is formed by taking the low-order 24 bits of an address and appending those
+ *  bits to the prefix FF02:0:0:0:0:1:FF00::/104 (RFC 4291 section 2.7.1)
+ */
+func SolicitedNodeMulticastAddr(ip net.IP) net.IP {
+	snmAddr := net.ParseIP(SOLICITATED_NODE_ADDRESS)
+	copy(snmAddr[13:], ip.To16()[13:])
+	return snmAddr
+}
+
+/*
+ *  IPv6 multicast mac address is 33:33 followed by the low-order 32 bits of the multicast address (RFC 2464)
+ */
+func MulticastMacAddr(ip net.IP) net.HardwareAddr {
+	mac := make(net.HardwareAddr, 6)
+	mac[0] = 0x33
+	mac[1] = 0x33
+	copy(mac[2:], ip.To16()[12:])
+	return mac
+}
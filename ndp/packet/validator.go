@@ -85,6 +85,14 @@ func validateIPv6Hdr(hdr *layers.IPv6, layerType uint8) error {
 		if hdr.Length < ICMPV6_MIN_LENGTH_RA {
 			return errors.New(fmt.Sprintf("Invalid ICMP length %d", hdr.Length))
 		}
+	case layers.ICMPv6TypeRouterSolicitation:
+		if hdr.Length < ICMPV6_MIN_LENGTH_RS {
+			return errors.New(fmt.Sprintf("Invalid ICMP length %d", hdr.Length))
+		}
+	case layers.ICMPv6TypeRedirect:
+		if hdr.Length < ICMPV6_MIN_LENGTH_REDIRECT {
+			return errors.New(fmt.Sprintf("Invalid ICMP length %d", hdr.Length))
+		}
 	}
 	return nil
 }
@@ -113,8 +121,68 @@ func (nd *NDInfo) ValidateRAInfo() error {
 					return errors.New(fmt.Sprintln("During Router Advertisement",
 						"MTU Option has length as zero"))
 				}
+			case NDOptionTypePrefixInfo:
+				if option.Length != ND_OPTION_PREFIX_INFO_LENGTH {
+					return errors.New(fmt.Sprintln("During Router Advertisement",
+						"Prefix Information Option has invalid length", option.Length))
+				}
+			default:
+				if option.Length == 0 {
+					return errors.New(fmt.Sprintln("During Router Advertisement",
+						"Option", option.Type, "has length as zero"))
+				}
 			}
 		}
 	}
 	return nil
 }
+
+/*
+ * Validate RFC 4861 section 6.1.1
+ *	- All included options have a length that is greater than zero.
+ *	- If the IP source address is the unspecified address, there is no
+ *	  source link-layer address option in the message.
+ */
+func (nd *NDInfo) ValidateRSInfo(srcIP net.IP) error {
+	for _, option := range nd.Options {
+		if option.Length == 0 {
+			return errors.New(fmt.Sprintln("During Router Solicitation",
+				"Option", option.Type, "has length as zero"))
+		}
+		if option.Type == NDOptionTypeSourceLinkLayerAddress && srcIP.IsUnspecified() {
+			return errors.New(fmt.Sprintln("During Router Solicitation with Unspecified",
+				"address Source Link Layer Option should not be set"))
+		}
+	}
+	return nil
+}
+
+/*
+ * Validate RFC 4861 section 8.1
+ *	- IP Source Address is a link-local address.
+ *	- The ICMP Destination Address field in the redirect message does not contain a multicast address.
+ *	- The ICMP Target Address is either a link-local address (when redirected to a router) or the same as
+ *	  the ICMP Destination Address (when redirected to the on-link destination).
+ *	- All included options have a length that is greater than zero.
+ */
+func (nd *NDInfo) ValidateRedirectInfo(srcIP net.IP) error {
+	if !srcIP.IsLinkLocalUnicast() {
+		return errors.New(fmt.Sprintln("During Redirect Source Address", srcIP.String(),
+			"is not a link-local address"))
+	}
+	if nd.DestinationAddress.IsMulticast() {
+		return errors.New(fmt.Sprintln("During Redirect Destination Address",
+			nd.DestinationAddress.String(), "is a multicast address"))
+	}
+	if !nd.TargetAddress.IsLinkLocalUnicast() && !nd.TargetAddress.Equal(nd.DestinationAddress) {
+		return errors.New(fmt.Sprintln("During Redirect Target Address", nd.TargetAddress.String(),
+			"is neither link-local nor same as Destination Address", nd.DestinationAddress.String()))
+	}
+	for _, option := range nd.Options {
+		if option.Length == 0 {
+			return errors.New(fmt.Sprintln("During Redirect",
+				"Option", option.Type, "has length as zero"))
+		}
+	}
+	return nil
+}
@@ -25,7 +25,20 @@ package server
 import (
 	"errors"
 	"fmt"
+	"l3/ndp/config"
 	"l3/ndp/debug"
+	"l3/ndp/packet"
+	"net"
+	"strings"
+)
+
+const (
+	RA_PREFERENCE_HIGH   = "high"
+	RA_PREFERENCE_MEDIUM = "medium"
+	RA_PREFERENCE_LOW    = "low"
+
+	RA_MAX_ROUTER_LIFETIME uint16 = 9000 // RFC: 4861
+	DNS_MAX_LABEL_LENGTH          = 63
 )
 
 func (cfg *NdpConfig) Validate(vrf string, retransmit uint32, reachableTime uint32, raTime uint8) (bool, error) {
@@ -59,5 +72,92 @@ func (cfg *NdpConfig) Create(gCfg NdpConfig) bool {
 	cfg.RetransTime = gCfg.RetransTime
 	cfg.RaRestransmitTime = gCfg.RaRestransmitTime
 	cfg.ReachableTime = gCfg.ReachableTime
+	cfg.DadTransmits = gCfg.DadTransmits
+	cfg.OptimisticDad = gCfg.OptimisticDad
 	return update
 }
+
+func (cfg *NdpConfig) ValidateDad(dadTransmits uint8) (bool, error) {
+	if dadTransmits > NDP_MAX_DAD_TRANSMITS {
+		return false, errors.New(fmt.Sprintln("Invalid Duplicate Address Detection Transmits", dadTransmits))
+	}
+	return true, nil
+}
+
+func convertRAPreference(pref string) (uint8, error) {
+	switch strings.ToLower(pref) {
+	case RA_PREFERENCE_HIGH:
+		return packet.ROUTER_PREFERENCE_HIGH, nil
+	case RA_PREFERENCE_MEDIUM, "":
+		return packet.ROUTER_PREFERENCE_MEDIUM, nil
+	case RA_PREFERENCE_LOW:
+		return packet.ROUTER_PREFERENCE_LOW, nil
+	}
+	return packet.ROUTER_PREFERENCE_MEDIUM, errors.New(fmt.Sprintln("Invalid Preference", pref))
+}
+
+func validateIPv6Prefix(prefix string) error {
+	ip, _, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return errors.New(fmt.Sprintln("Invalid Prefix", prefix, err))
+	}
+	if ip.To4() != nil {
+		return errors.New(fmt.Sprintln("Prefix", prefix, "is not an IPv6 prefix"))
+	}
+	return nil
+}
+
+func ValidateRAIntfConfig(cfg *config.RAIntfConfig) (bool, error) {
+	if cfg.IntfRef == "" {
+		return false, errors.New("Invalid Interface for Router Advertisement Config")
+	}
+	if _, err := convertRAPreference(cfg.RouterPreference); err != nil {
+		return false, errors.New(fmt.Sprintln("Invalid Router Preference", cfg.RouterPreference))
+	}
+	if cfg.RouterLifetime > RA_MAX_ROUTER_LIFETIME {
+		return false, errors.New(fmt.Sprintln("Invalid Router Lifetime", cfg.RouterLifetime))
+	}
+	for _, prefix := range cfg.Prefixes {
+		if err := validateIPv6Prefix(prefix.Prefix); err != nil {
+			return false, err
+		}
+		if prefix.PreferredLifetime > prefix.ValidLifetime {
+			return false, errors.New(fmt.Sprintln("Preferred Lifetime", prefix.PreferredLifetime,
+				"is greater than Valid Lifetime", prefix.ValidLifetime, "for prefix", prefix.Prefix))
+		}
+	}
+	for _, route := range cfg.Routes {
+		if err := validateIPv6Prefix(route.Prefix); err != nil {
+			return false, err
+		}
+		if _, err := convertRAPreference(route.Preference); err != nil {
+			return false, errors.New(fmt.Sprintln("Invalid Route Preference", route.Preference,
+				"for route", route.Prefix))
+		}
+	}
+	for _, server := range cfg.RdnssServers {
+		ip := net.ParseIP(server)
+		if ip == nil || ip.To4() != nil {
+			return false, errors.New(fmt.Sprintln("Invalid Recursive DNS Server", server))
+		}
+	}
+	for _, domain := range cfg.DnsslDomains {
+		domain = strings.Trim(domain, ".")
+		if domain == "" {
+			return false, errors.New("Invalid empty DNS Search List domain")
+		}
+		for _, label := range strings.Split(domain, ".") {
+			if label == "" || len(label) > DNS_MAX_LABEL_LENGTH {
+				return false, errors.New(fmt.Sprintln("Invalid DNS Search List domain", domain))
+			}
+		}
+	}
+	return true, nil
+}
+
+func ValidateNdpIntfConfig(cfg *config.NdpIntfConfig) (bool, error) {
+	if cfg.IntfRef == "" {
+		return false, errors.New("Invalid Interface for NDP Interface Config")
+	}
+	return true, nil
+}
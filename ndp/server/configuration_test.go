@@ -23,6 +23,7 @@
 package server
 
 import (
+	"l3/ndp/config"
 	"reflect"
 	"testing"
 )
@@ -75,3 +76,56 @@ func TestNdpGlobal(t *testing.T) {
 	}
 	testGlobalConfigNdpOperations(gblCfg, t)
 }
+
+func TestRAIntfConfigValidation(t *testing.T) {
+	initServerBasic()
+	raCfg := config.RAIntfConfig{
+		IntfRef:          testIntfRef,
+		CurHopLimit:      64,
+		RouterPreference: RA_PREFERENCE_HIGH,
+		RouterLifetime:   1800,
+		Prefixes: []config.RAPrefixConfig{
+			config.RAPrefixConfig{"2001:db8:1::/64", true, true, 2592000, 604800},
+		},
+		Routes: []config.RARouteConfig{
+			config.RARouteConfig{"2001:db8:2::/48", RA_PREFERENCE_LOW, 1800},
+		},
+		RdnssServers: []string{"2001:db8::53"},
+		DnsslDomains: []string{"example.com"},
+	}
+	rv, err := ValidateRAIntfConfig(&raCfg)
+	if err != nil || rv == false {
+		t.Error("Valid router advertisement config failed validation:", err)
+		return
+	}
+	invalidCfg := raCfg
+	invalidCfg.RouterPreference = "invalid"
+	if rv, err = ValidateRAIntfConfig(&invalidCfg); err == nil || rv == true {
+		t.Error("Invalid router preference should have failed")
+		return
+	}
+	invalidCfg = raCfg
+	invalidCfg.Prefixes = []config.RAPrefixConfig{config.RAPrefixConfig{"10.1.1.0/24", true, true, 100, 10}}
+	if rv, err = ValidateRAIntfConfig(&invalidCfg); err == nil || rv == true {
+		t.Error("IPv4 prefix in router advertisement should have failed")
+		return
+	}
+	invalidCfg = raCfg
+	invalidCfg.Prefixes = []config.RAPrefixConfig{config.RAPrefixConfig{"2001:db8:1::/64", true, true, 10, 100}}
+	if rv, err = ValidateRAIntfConfig(&invalidCfg); err == nil || rv == true {
+		t.Error("Preferred lifetime greater than valid lifetime should have failed")
+		return
+	}
+	invalidCfg = raCfg
+	invalidCfg.RdnssServers = []string{"10.1.1.1"}
+	if rv, err = ValidateRAIntfConfig(&invalidCfg); err == nil || rv == true {
+		t.Error("IPv4 recursive dns server should have failed")
+		return
+	}
+	invalidCfg = raCfg
+	invalidCfg.DnsslDomains = []string{"example..com"}
+	if rv, err = ValidateRAIntfConfig(&invalidCfg); err == nil || rv == true {
+		t.Error("Invalid dns search list domain should have failed")
+		return
+	}
+}
@@ -0,0 +1,257 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"github.com/google/gopacket/layers"
+	"l3/ndp/config"
+	"l3/ndp/debug"
+	"l3/ndp/packet"
+	"math/rand"
+	"net"
+	"time"
+)
+
+type DAD_STATE byte
+
+const (
+	DAD_TENTATIVE DAD_STATE = iota + 1
+	DAD_OPTIMISTIC
+	DAD_PREFERRED
+	DAD_DUPLICATE
+)
+
+const (
+	NDP_DEFAULT_DAD_TRANSMITS  uint8 = 1    // RFC: 4862
+	NDP_MAX_DAD_TRANSMITS      uint8 = 10   // upper bound for user configuration
+	NDP_DAD_RETRANS_TIMER      int64 = 1000 // RFC: 4861 RETRANS_TIMER in ms
+	MAX_RTR_SOLICITATION_DELAY int64 = 1000 // RFC: 4861 in ms, random delay before first DAD probe
+)
+
+type DadInfo struct {
+	IpAddr     string
+	State      DAD_STATE
+	ProbesSent uint8
+	Timer      *time.Timer
+}
+
+func (state DAD_STATE) String() string {
+	switch state {
+	case DAD_TENTATIVE:
+		return "Tentative"
+	case DAD_OPTIMISTIC:
+		return "Optimistic"
+	case DAD_PREFERRED:
+		return "Preferred"
+	case DAD_DUPLICATE:
+		return "Duplicate"
+	}
+	return ""
+}
+
+/*
+ *  Start Duplicate Address Detection (RFC 4862 section 5.4) for link scope & global scope ip of the
+ *  interface. Address which are already verified are not probed again.
+ *	1) DupAddrDetectTransmits is zero then address is marked preferred right away
+ *	2) Optimistic DAD is enabled then address is usable while probes are going on (RFC 4429)
+ *	3) First probe is delayed by random time between 0 and MAX_RTR_SOLICITATION_DELAY
+ */
+func (intf *Interface) StartDad() {
+	if intf.dad == nil {
+		intf.dad = make(map[string]DadInfo, 2)
+	}
+	for _, ipAddr := range []string{intf.linkScope, intf.globalScope} {
+		if ipAddr == "" {
+			continue
+		}
+		if _, exists := intf.dad[ipAddr]; exists {
+			continue
+		}
+		dadInfo := DadInfo{
+			IpAddr: ipAddr,
+		}
+		if intf.dadTransmits == 0 {
+			dadInfo.State = DAD_PREFERRED
+			intf.dad[ipAddr] = dadInfo
+			continue
+		}
+		if intf.optimisticDad {
+			dadInfo.State = DAD_OPTIMISTIC
+		} else {
+			dadInfo.State = DAD_TENTATIVE
+		}
+		delay := time.Duration(rand.Int63n(MAX_RTR_SOLICITATION_DELAY)) * time.Millisecond
+		dadInfo.Timer = time.AfterFunc(delay, intf.dadProbeFunc(ipAddr))
+		debug.Logger.Debug("Starting DAD for ip:", ipAddr, "intf:", intf.IntfRef, "state:", dadInfo.State.String(),
+			"after:", delay)
+		intf.dad[ipAddr] = dadInfo
+	}
+}
+
+/*
+ *  timer function which requests server to send DAD probe for ipAddr
+ */
+func (intf *Interface) dadProbeFunc(ipAddr string) func() {
+	pktCh := intf.PktDataCh
+	ifIndex := intf.IfIndex
+	return func() {
+		pktCh <- config.PacketData{
+			SendPktType: layers.ICMPv6TypeNeighborSolicitation,
+			NeighborIp:  ipAddr,
+			IfIndex:     ifIndex,
+			DadProbe:    true,
+		}
+	}
+}
+
+/*
+ *  Send Duplicate Address Detection Neighbor Solicitation from unspecified address to solicited-node multicast
+ *  address of the tentative address. Once DupAddrDetectTransmits probes are sent and RetransTimer is elapsed
+ *  without any conflict then address is moved to preferred state
+ */
+func (intf *Interface) SendDadNS(srcMac, ipAddr string) NDP_OPERATION {
+	dadInfo, exists := intf.dad[ipAddr]
+	if !exists || dadInfo.State == DAD_PREFERRED || dadInfo.State == DAD_DUPLICATE {
+		return IGNORE
+	}
+	if dadInfo.ProbesSent >= intf.dadTransmits {
+		debug.Logger.Info("DAD completed for ip:", ipAddr, "intf:", intf.IntfRef, "moving to preferred state")
+		dadInfo.State = DAD_PREFERRED
+		dadInfo.Timer = nil
+		intf.dad[ipAddr] = dadInfo
+		return IGNORE
+	}
+	snmAddr := packet.SolicitedNodeMulticastAddr(net.ParseIP(ipAddr))
+	pkt := &packet.Packet{
+		SrcMac:   srcMac,
+		DstMac:   packet.MulticastMacAddr(snmAddr).String(),
+		SrcIp:    packet.UNSPECIFIED_IP_ADDRESS,
+		DstIp:    snmAddr.String(),
+		TargetIp: ipAddr,
+		PType:    layers.ICMPv6TypeNeighborSolicitation,
+	}
+	pktToSend := pkt.Encode()
+	err := intf.writePkt(pktToSend)
+	if err != nil {
+		return IGNORE
+	}
+	intf.counter.Send++
+	dadInfo.ProbesSent++
+	if dadInfo.Timer != nil {
+		dadInfo.Timer.Reset(time.Duration(NDP_DAD_RETRANS_TIMER) * time.Millisecond)
+	} else {
+		dadInfo.Timer = time.AfterFunc(time.Duration(NDP_DAD_RETRANS_TIMER)*time.Millisecond,
+			intf.dadProbeFunc(ipAddr))
+	}
+	intf.dad[ipAddr] = dadInfo
+	return IGNORE
+}
+
+/*
+ *  Process received NS/NA for Duplicate Address Detection, RFC 4862 section 5.4.3 & 5.4.4
+ *	1) NS from unspecified address with target as our tentative address means other node is also
+ *	   doing DAD for the same address
+ *	2) NA with target as our tentative address means other node already owns the address
+ *  Packets send by us and looped back are ignored. Returns the duplicate address if any
+ */
+func (intf *Interface) ProcessDad(ndInfo *packet.NDInfo, ownPkt bool) (string, bool) {
+	if intf.dad == nil || ownPkt || ndInfo.TargetAddress == nil {
+		return "", false
+	}
+	ipAddr := ndInfo.TargetAddress.String()
+	dadInfo, exists := intf.dad[ipAddr]
+	if !exists {
+		return "", false
+	}
+	switch ndInfo.PktType {
+	case layers.ICMPv6TypeNeighborSolicitation:
+		if ndInfo.SrcIp != packet.UNSPECIFIED_IP_ADDRESS {
+			return "", false
+		}
+	case layers.ICMPv6TypeNeighborAdvertisement:
+		if dadInfo.State == DAD_PREFERRED {
+			debug.Logger.Warning("Received NA for our preferred ip:", ipAddr, "from:", ndInfo.SrcMac,
+				"intf:", intf.IntfRef)
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if dadInfo.State != DAD_TENTATIVE && dadInfo.State != DAD_OPTIMISTIC {
+		return "", false
+	}
+	debug.Logger.Alert("Duplicate Address Detected for ip:", ipAddr, "intf:", intf.IntfRef, "by:", ndInfo.SrcMac)
+	if dadInfo.Timer != nil {
+		dadInfo.Timer.Stop()
+		dadInfo.Timer = nil
+	}
+	dadInfo.State = DAD_DUPLICATE
+	intf.dad[ipAddr] = dadInfo
+	return ipAddr, true
+}
+
+/*
+ *  stop duplicate address detection for ip address and remove its state
+ */
+func (intf *Interface) stopDad(ipAddr string) {
+	dadInfo, exists := intf.dad[ipAddr]
+	if !exists {
+		return
+	}
+	if dadInfo.Timer != nil {
+		dadInfo.Timer.Stop()
+	}
+	delete(intf.dad, ipAddr)
+}
+
+/*
+ *  stop duplicate address detection for all ip address, address will be verified again on port up
+ */
+func (intf *Interface) StopAllDad() {
+	for ipAddr, _ := range intf.dad {
+		intf.stopDad(ipAddr)
+	}
+}
+
+/*
+ *  Get Duplicate Address Detection state for ip address
+ */
+func (intf *Interface) getDadState(ipAddr string) string {
+	dadInfo, exists := intf.dad[ipAddr]
+	if !exists {
+		return ""
+	}
+	return dadInfo.State.String()
+}
+
+/*
+ *  Address can be used as source address only when it is preferred or optimistic. Address on which DAD was never
+ *  started are considered usable
+ */
+func (intf *Interface) isUsableAddr(ipAddr string) bool {
+	dadInfo, exists := intf.dad[ipAddr]
+	if !exists {
+		return true
+	}
+	return dadInfo.State == DAD_PREFERRED || dadInfo.State == DAD_OPTIMISTIC
+}
@@ -0,0 +1,157 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"github.com/google/gopacket/layers"
+	"l3/ndp/config"
+	"l3/ndp/packet"
+	"net"
+	"testing"
+	"time"
+)
+
+func initTestDadInterface(dadTransmits uint8, optimisticDad bool) {
+	initTestInterface()
+	testIntf.IfIndex = testIfIndex
+	testIntf.PktDataCh = make(chan config.PacketData, 2)
+	testIntf.dadTransmits = dadTransmits
+	testIntf.optimisticDad = optimisticDad
+	testIntf.addIP(testMyLinkScopeIP)
+	testIntf.addIP(testMyGSIp)
+}
+
+func TestDadDisabled(t *testing.T) {
+	initTestDadInterface(0, false)
+	testIntf.StartDad()
+	for _, ipAddr := range []string{testMyAbsLinkScopeIP, testMyAbsGSIP} {
+		if testIntf.getDadState(ipAddr) != DAD_PREFERRED.String() {
+			t.Error("When DupAddrDetectTransmits is zero ip:", ipAddr, "should be preferred, got:",
+				testIntf.getDadState(ipAddr))
+			return
+		}
+	}
+	testIntf.StopAllDad()
+}
+
+func TestDadTentative(t *testing.T) {
+	initTestDadInterface(1, false)
+	testIntf.StartDad()
+	if testIntf.getDadState(testMyAbsGSIP) != DAD_TENTATIVE.String() {
+		t.Error("ip:", testMyAbsGSIP, "should be tentative, got:", testIntf.getDadState(testMyAbsGSIP))
+		return
+	}
+	if testIntf.isUsableAddr(testMyAbsGSIP) {
+		t.Error("Tentative address should not be usable")
+		return
+	}
+	select {
+	case pktData := <-testIntf.PktDataCh:
+		if !pktData.DadProbe || pktData.SendPktType != layers.ICMPv6TypeNeighborSolicitation ||
+			pktData.IfIndex != testIfIndex {
+			t.Error("Invalid DAD probe packet data:", pktData)
+			return
+		}
+	case <-time.After(time.Duration(MAX_RTR_SOLICITATION_DELAY)*time.Millisecond + time.Second):
+		t.Error("DAD probe was not requested within MAX_RTR_SOLICITATION_DELAY")
+		return
+	}
+	testIntf.StopAllDad()
+	if len(testIntf.dad) != 0 {
+		t.Error("Stopping DAD should remove all entries, got:", testIntf.dad)
+	}
+}
+
+func TestDadOptimistic(t *testing.T) {
+	initTestDadInterface(1, true)
+	testIntf.StartDad()
+	if testIntf.getDadState(testMyAbsLinkScopeIP) != DAD_OPTIMISTIC.String() {
+		t.Error("ip:", testMyAbsLinkScopeIP, "should be optimistic, got:",
+			testIntf.getDadState(testMyAbsLinkScopeIP))
+		return
+	}
+	if !testIntf.isUsableAddr(testMyAbsLinkScopeIP) {
+		t.Error("Optimistic address should be usable")
+		return
+	}
+	testIntf.StopAllDad()
+}
+
+func TestDadComplete(t *testing.T) {
+	initTestDadInterface(1, false)
+	testIntf.StartDad()
+	dadInfo := testIntf.dad[testMyAbsGSIP]
+	dadInfo.ProbesSent = 1
+	testIntf.dad[testMyAbsGSIP] = dadInfo
+	testIntf.SendDadNS(testSwitchMac, testMyAbsGSIP)
+	if testIntf.getDadState(testMyAbsGSIP) != DAD_PREFERRED.String() {
+		t.Error("ip:", testMyAbsGSIP, "should be preferred after all probes, got:",
+			testIntf.getDadState(testMyAbsGSIP))
+		return
+	}
+	testIntf.StopAllDad()
+}
+
+func TestProcessDadDuplicate(t *testing.T) {
+	initTestDadInterface(1, false)
+	testIntf.StartDad()
+	// NS from other node with source ip which is not unspecified is not a DAD probe
+	ndInfo := &packet.NDInfo{
+		PktType:       layers.ICMPv6TypeNeighborSolicitation,
+		SrcIp:         testServerNSSrcIp,
+		SrcMac:        testServerNSSrcMac,
+		TargetAddress: net.ParseIP(testMyAbsGSIP),
+	}
+	if _, duplicate := testIntf.ProcessDad(ndInfo, false); duplicate {
+		t.Error("NS with specified source address should not be treated as duplicate")
+		return
+	}
+	// looped back DAD probe send by us
+	ndInfo.SrcIp = packet.UNSPECIFIED_IP_ADDRESS
+	if _, duplicate := testIntf.ProcessDad(ndInfo, true); duplicate {
+		t.Error("Own DAD probe should not be treated as duplicate")
+		return
+	}
+	ipAddr, duplicate := testIntf.ProcessDad(ndInfo, false)
+	if !duplicate || ipAddr != testMyAbsGSIP {
+		t.Error("DAD probe from other node should mark", testMyAbsGSIP, "as duplicate")
+		return
+	}
+	if testIntf.getDadState(testMyAbsGSIP) != DAD_DUPLICATE.String() || testIntf.isUsableAddr(testMyAbsGSIP) {
+		t.Error("ip:", testMyAbsGSIP, "should be duplicate, got:", testIntf.getDadState(testMyAbsGSIP))
+		return
+	}
+	// NA for tentative link scope ip
+	ndInfo = &packet.NDInfo{
+		PktType:       layers.ICMPv6TypeNeighborAdvertisement,
+		SrcIp:         testMyAbsLinkScopeIP,
+		SrcMac:        testServerNSSrcMac,
+		TargetAddress: net.ParseIP(testMyAbsLinkScopeIP),
+	}
+	ipAddr, duplicate = testIntf.ProcessDad(ndInfo, false)
+	if !duplicate || ipAddr != testMyAbsLinkScopeIP {
+		t.Error("NA from other node should mark", testMyAbsLinkScopeIP, "as duplicate")
+		return
+	}
+	testIntf.StopAllDad()
+}
@@ -23,6 +23,7 @@
 package server
 
 import (
+	"l3/ndp/config"
 	"l3/ndp/debug"
 	"models/objects"
 	"utils/dbutils"
@@ -64,10 +65,95 @@ func (svr *NDPServer) readNdpGblCfg(dbHdl *dbutils.DBUtil) {
 		} else {
 			svr.NdpConfig.RetransTime = uint32(dbEntry.RetransmitInterval)
 		}
+		if dbEntry.DupAddrDetectTransmits < 0 || dbEntry.DupAddrDetectTransmits > int32(NDP_MAX_DAD_TRANSMITS) {
+			debug.Logger.Warning("Invalid Duplicate Address Detection Transmits and hence setting default value",
+				NDP_DEFAULT_DAD_TRANSMITS)
+			svr.NdpConfig.DadTransmits = NDP_DEFAULT_DAD_TRANSMITS
+		} else {
+			svr.NdpConfig.DadTransmits = uint8(dbEntry.DupAddrDetectTransmits)
+		}
+		svr.NdpConfig.OptimisticDad = dbEntry.OptimisticDad
 		debug.Logger.Info("Done with reading NDPGlobal config from DB")
 	}
 }
 
+func (svr *NDPServer) readNdpRAIntfCfg(dbHdl *dbutils.DBUtil) {
+	var dbRAObj objects.NDPRouterAdvertisement
+	objList, err := dbHdl.GetAllObjFromDb(dbRAObj)
+	if err != nil {
+		debug.Logger.Err("DB Querry failed for NDPRouterAdvertisement Config", err)
+		return
+	}
+	if svr.RaIntfCfg == nil {
+		svr.RaIntfCfg = make(map[string]config.RAIntfConfig, NDP_SERVER_MAP_INITIAL_CAP)
+	}
+	for _, obj := range objList {
+		dbEntry := obj.(objects.NDPRouterAdvertisement)
+		raCfg := config.RAIntfConfig{
+			IntfRef:             dbEntry.IntfRef,
+			CurHopLimit:         uint8(dbEntry.CurHopLimit),
+			ManagedFlag:         dbEntry.ManagedFlag,
+			OtherConfigFlag:     dbEntry.OtherConfigFlag,
+			RouterPreference:    dbEntry.RouterPreference,
+			RouterLifetime:      uint16(dbEntry.RouterLifetime),
+			ReachableTime:       uint32(dbEntry.ReachableTime),
+			RetransTime:         uint32(dbEntry.RetransTime),
+			AdvertiseIntfPrefix: dbEntry.AdvertiseIntfPrefix,
+			RdnssServers:        dbEntry.RdnssServers,
+			RdnssLifetime:       uint32(dbEntry.RdnssLifetime),
+			DnsslDomains:        dbEntry.DnsslDomains,
+			DnsslLifetime:       uint32(dbEntry.DnsslLifetime),
+		}
+		for _, prefix := range dbEntry.Prefixes {
+			raCfg.Prefixes = append(raCfg.Prefixes, config.RAPrefixConfig{
+				Prefix:            prefix.Prefix,
+				OnLink:            prefix.OnLink,
+				Autonomous:        prefix.Autonomous,
+				ValidLifetime:     uint32(prefix.ValidLifetime),
+				PreferredLifetime: uint32(prefix.PreferredLifetime),
+			})
+		}
+		for _, route := range dbEntry.Routes {
+			raCfg.Routes = append(raCfg.Routes, config.RARouteConfig{
+				Prefix:     route.Prefix,
+				Preference: route.Preference,
+				Lifetime:   uint32(route.Lifetime),
+			})
+		}
+		if _, err := ValidateRAIntfConfig(&raCfg); err != nil {
+			debug.Logger.Err("Invalid Router Advertisement config in DB for", dbEntry.IntfRef, err)
+			continue
+		}
+		svr.RaIntfCfg[raCfg.IntfRef] = raCfg
+	}
+	debug.Logger.Info("Done with reading NDPRouterAdvertisement config from DB")
+}
+
+func (svr *NDPServer) readNdpIntfCfg(dbHdl *dbutils.DBUtil) {
+	var dbIntfObj objects.NDPInterface
+	objList, err := dbHdl.GetAllObjFromDb(dbIntfObj)
+	if err != nil {
+		debug.Logger.Err("DB Querry failed for NDPInterface Config", err)
+		return
+	}
+	if svr.NdpIntfCfg == nil {
+		svr.NdpIntfCfg = make(map[string]config.NdpIntfConfig, NDP_SERVER_MAP_INITIAL_CAP)
+	}
+	for _, obj := range objList {
+		dbEntry := obj.(objects.NDPInterface)
+		intfCfg := config.NdpIntfConfig{
+			IntfRef:       dbEntry.IntfRef,
+			SendRedirects: dbEntry.SendRedirects,
+		}
+		if _, err := ValidateNdpIntfConfig(&intfCfg); err != nil {
+			debug.Logger.Err("Invalid NDP Interface config in DB for", dbEntry.IntfRef, err)
+			continue
+		}
+		svr.NdpIntfCfg[intfCfg.IntfRef] = intfCfg
+	}
+	debug.Logger.Info("Done with reading NDPInterface config from DB")
+}
+
 func (svr *NDPServer) ReadDB() {
 	if svr.dmnBase == nil {
 		return
@@ -79,4 +165,6 @@ func (svr *NDPServer) ReadDB() {
 	}
 	debug.Logger.Info("Reading Config from DB")
 	svr.readNdpGblCfg(dbHdl)
+	svr.readNdpRAIntfCfg(dbHdl)
+	svr.readNdpIntfCfg(dbHdl)
 }
@@ -43,6 +43,8 @@ type NdpConfig struct {
 	ReachableTime     uint32
 	RetransTime       uint32
 	RaRestransmitTime uint8
+	DadTransmits      uint8 // DupAddrDetectTransmits, 0 means DAD is disabled
+	OptimisticDad     bool  // RFC 4429
 }
 
 type L3Info struct {
@@ -57,10 +59,18 @@ type PhyPort struct {
 	L3   L3Info
 }
 
+/*
+ *  Route plugin resolves the next hop ip and l3 ifIndex used to forward packets to an ip address
+ */
+type RoutePluginIntf interface {
+	GetNextHop(ipAddr string) (string, int32, error)
+}
+
 type NDPServer struct {
 	NdpConfig                                // base config
 	dmnBase      *dmnBase.FSBaseDmn          // base Daemon
 	SwitchPlugin asicdClient.AsicdClientIntf // asicd plugin
+	RoutePlugin  RoutePluginIntf             // ribd plugin, used to find the better first hop for redirect
 
 	// System Ports information, key is IntfRef
 	L2Port              map[int32]PhyPort                //config.PortInfo        // key is l2 ifIndex
@@ -74,6 +84,14 @@ type NDPServer struct {
 
 	//Configuration Channels
 	GlobalCfg chan NdpConfig
+	// Router Advertisement per interface configuration channel
+	RaIntfCfgCh chan *config.RAIntfNotification
+	// Router Advertisement per interface configuration, key is IntfRef
+	RaIntfCfg map[string]config.RAIntfConfig
+	// NDP per interface configuration channel
+	NdpIntfCfgCh chan *config.NdpIntfNotification
+	// NDP per interface configuration, key is IntfRef
+	NdpIntfCfg map[string]config.NdpIntfConfig
 	// Lock for reading/writing NeighorInfo
 	// We need this lock because getbulk/getentry is not requested on the main entry point channel, rather it's a
 	// direct call to server. So to avoid updating the Neighbor Runtime Info during read
@@ -25,6 +25,7 @@ package server
 import (
 	"l3/ndp/config"
 	"l3/ndp/debug"
+	"l3/ndp/packet"
 	"strings"
 	"utils/commonDefs"
 )
@@ -56,6 +57,7 @@ func (svr *NDPServer) GetPorts() {
 		} else {
 			port.MacAddr = pObj.MacAddr
 			port.Description = pObj.Description
+			port.Mtu = pObj.Mtu
 		}
 		l2Port := svr.L2Port[port.IfIndex]
 		l2Port.Info = port
@@ -176,6 +178,10 @@ func (svr *NDPServer) HandleIPIntfCreateDelete(obj *config.IPIntfNotification) {
 		// Done during Init
 		if exists {
 			ipInfo.UpdateIntf(obj.IpAddr)
+			if ipInfo.PcapBase.Tx != nil {
+				// interface is already up and hence verify newly added ip address
+				ipInfo.StartDad()
+			}
 			svr.L3Port[obj.IfIndex] = ipInfo
 			return
 		}
@@ -331,6 +337,128 @@ func (svr *NDPServer) HandleAction(action *config.ActionData) {
 	}
 }
 
+/*
+ *    API: It will store router advertisement config for the interface and if the l3 port exists then it will
+ *	   update the l3 port so that next router advertisement carries new information
+ */
+func (svr *NDPServer) HandleRAIntfConfig(msg *config.RAIntfNotification) {
+	debug.Logger.Info("Handling Router Advertisement Config:", msg.Operation, "for port:", msg.Cfg.IntfRef)
+	var raCfg *config.RAIntfConfig
+	switch msg.Operation {
+	case config.CONFIG_CREATE, config.CONFIG_UPDATE:
+		svr.RaIntfCfg[msg.Cfg.IntfRef] = msg.Cfg
+		cfg := msg.Cfg
+		raCfg = &cfg
+	case config.CONFIG_DELETE:
+		delete(svr.RaIntfCfg, msg.Cfg.IntfRef)
+	}
+	ifIndex, exists := svr.L3IfIntfRefToIfIndex[msg.Cfg.IntfRef]
+	if !exists {
+		return
+	}
+	l3Port, exists := svr.L3Port[ifIndex]
+	if !exists {
+		return
+	}
+	l3Port.UpdateRAConfig(raCfg)
+	svr.L3Port[ifIndex] = l3Port
+}
+
+/*
+ *    API: It will store ndp interface config and if the l3 port exists then it will update the l3 port and
+ *	   its rx pcap filter so that punted data packets are received only when redirects are enabled
+ */
+func (svr *NDPServer) HandleNdpIntfConfig(msg *config.NdpIntfNotification) {
+	debug.Logger.Info("Handling NDP Interface Config:", msg.Operation, "for port:", msg.Cfg.IntfRef)
+	switch msg.Operation {
+	case config.CONFIG_CREATE, config.CONFIG_UPDATE:
+		svr.NdpIntfCfg[msg.Cfg.IntfRef] = msg.Cfg
+	case config.CONFIG_DELETE:
+		delete(svr.NdpIntfCfg, msg.Cfg.IntfRef)
+	}
+	ifIndex, exists := svr.L3IfIntfRefToIfIndex[msg.Cfg.IntfRef]
+	if !exists {
+		return
+	}
+	l3Port, exists := svr.L3Port[ifIndex]
+	if !exists {
+		return
+	}
+	l3Port.UpdateRedirectConfig(svr.NdpIntfCfg[msg.Cfg.IntfRef].SendRedirects)
+	svr.updatePcapFilter(&l3Port)
+	svr.L3Port[ifIndex] = l3Port
+}
+
+/*
+ *    API: It will update bpf filter of rx pcap handlers for l3 port, for vlan it is all member ports
+ */
+func (svr *NDPServer) updatePcapFilter(l3Port *Interface) {
+	filter := getPcapFilter(l3Port.redirectEnable)
+	if l3Port.PcapBase.PcapHandle != nil {
+		err := l3Port.PcapBase.PcapHandle.SetBPFFilter(filter)
+		if err != nil {
+			debug.Logger.Err("Updating BPF Filter failed for", l3Port.IntfRef, "Error", err)
+		}
+	}
+	for _, l2Port := range svr.L2Port {
+		if l2Port.L3.IfIndex != l3Port.IfIndex || l2Port.RX == nil {
+			continue
+		}
+		err := l2Port.RX.SetBPFFilter(filter)
+		if err != nil {
+			debug.Logger.Err("Updating BPF Filter failed for", l2Port.Info.Name, "Error", err)
+		}
+	}
+}
+
+/*
+ *    API: It will return the rx pcap filter for l3 port
+ */
+func (svr *NDPServer) getPcapFilter(ifIndex int32) string {
+	l3Port, exists := svr.L3Port[ifIndex]
+	if !exists {
+		return NDP_PCAP_FILTER
+	}
+	return getPcapFilter(svr.NdpIntfCfg[l3Port.IntfRef].SendRedirects)
+}
+
+/*
+ *    API: It will return the router advertisement config for the interface, nil if not configured
+ */
+func (svr *NDPServer) getRAIntfConfig(intfRef string) *config.RAIntfConfig {
+	cfg, exists := svr.RaIntfCfg[intfRef]
+	if !exists {
+		return nil
+	}
+	return &cfg
+}
+
+/*
+ *    API: It will return link mtu for l3 port, for vlan it is the lowest mtu of all member ports
+ */
+func (svr *NDPServer) getL3IntfMtu(ifIndex int32) uint32 {
+	var mtu int32
+	if l2Port, exists := svr.L2Port[ifIndex]; exists {
+		mtu = l2Port.Info.Mtu
+	} else if vlan, exists := svr.VlanInfo[ifIndex]; exists {
+		for _, portsMap := range []map[int32]bool{vlan.UntagPortsMap, vlan.TagPortsMap} {
+			for portIfIndex, _ := range portsMap {
+				l2Port, exists := svr.L2Port[portIfIndex]
+				if !exists || l2Port.Info.Mtu == 0 {
+					continue
+				}
+				if mtu == 0 || l2Port.Info.Mtu < mtu {
+					mtu = l2Port.Info.Mtu
+				}
+			}
+		}
+	}
+	if mtu <= 0 {
+		return packet.RA_DEFAULT_MTU
+	}
+	return uint32(mtu)
+}
+
 /*
  *    API: It will remove any deleted ip port from the up state slice list
  */
@@ -395,6 +523,23 @@ func (svr *NDPServer) SendIPv6DeleteNotification(ipAddr string, ifIndex int32) {
 	svr.pushNotification(notification)
 }
 
+/*
+ *    API: send ipv6 duplicate address notification when DAD fails for our own address
+ */
+func (svr *NDPServer) SendIPv6DuplicateAddrNotification(ipAddr string, ifIndex int32) {
+	msgBuf, err := createNotificationMsg(ipAddr, ifIndex)
+	if err != nil {
+		return
+	}
+
+	notification := commonDefs.NdpNotification{
+		MsgType: commonDefs.NOTIFY_IPV6_DUPLICATE_ADDRESS,
+		Msg:     msgBuf,
+	}
+	debug.Logger.Info("Sending Duplicate Address notification for ip address:", ipAddr, "and ifIndex:", ifIndex)
+	svr.pushNotification(notification)
+}
+
 func createNeighborKey(mac, ip, intfName string) string {
 	return mac + "_" + ip + "_" + intfName
 }
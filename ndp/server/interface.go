@@ -38,11 +38,13 @@ import (
 )
 
 const (
-	NDP_PCAP_FILTER                              = "(ip6[6] == 0x3a) and (ip6[40] >= 133 && ip6[40] <= 136)"
+	NDP_PCAP_FILTER                              = "(ip6[6] == 0x3a) and (ip6[40] >= 133 && ip6[40] <= 137)"
+	NDP_PCAP_REDIRECT_FILTER                     = "(" + NDP_PCAP_FILTER + ") or (ip6 and not ip6 multicast)" // punted data pkts
 	NDP_PCAP_TIMEOUT                             = 1 * time.Second
 	NDP_PCAP_SNAPSHOTlEN                         = 1024
 	NDP_PCAP_PROMISCUOUS                         = false
-	MIN_DELAY_BETWEEN_RAS                  uint8 = 3 // RFC: 4861
+	MIN_DELAY_BETWEEN_RAS                  uint8 = 3   // RFC: 4861
+	MAX_RA_DELAY_TIME                      int64 = 500 // RFC: 4861 in ms
 	MAX_INITIAL_RTR_ADVERTISEMENTS         uint8 = 3
 	MAX_INITIAL_RTR_ADVERT_INTERVAL        uint8 = 16
 	ALL_NODES_MULTICAST_IPV6_ADDRESS             = "ff02::1"
@@ -51,6 +53,10 @@ const (
 	NDP_DEFAULT_RTR_ADVERTISEMENT_INTERVAL uint8  = 5
 	NDP_DEFAULT_RETRANSMIT_INTERVAL        uint32 = 1
 	NDP_DEFAULT_REACHABLE_INTERVAL         uint32 = 30000
+
+	NDP_DEFAULT_PREFIX_VALID_LIFETIME     uint32 = 2592000 // RFC: 4861 30 days
+	NDP_DEFAULT_PREFIX_PREFERRED_LIFETIME uint32 = 604800  // RFC: 4861 7 days
+	NDP_SLAAC_PREFIX_LENGTH                      = 64
 )
 
 /* https://tools.ietf.org/html/rfc7346
@@ -78,6 +84,13 @@ const (
 var IPV6_MULTICAST_PREFIXES = []string{"ff00", "ff01", "ff02", "ff03", "ff04", "ff05", "ff06", "ff07",
 	"ff08", "ff09", "ff0a", "ff0b", "ff0c", "ff0d", "ff0e", "ff0f"}
 
+func getPcapFilter(redirectEnable bool) string {
+	if redirectEnable {
+		return NDP_PCAP_REDIRECT_FILTER
+	}
+	return NDP_PCAP_FILTER
+}
+
 type PcapBase struct {
 	// TX Pcap handler
 	Tx *pcap.Handle
@@ -110,7 +123,17 @@ type Interface struct {
 	routerLifeTime    uint16
 	raRestransmitTime uint8 // @TODO: get it from user
 	raTimer           *time.Timer
-	initialRASend     uint8                   // on port up we have to send 3 RA before kicking in config timer
+	initialRASend     uint8                // on port up we have to send 3 RA before kicking in config timer
+	raCfg             *config.RAIntfConfig // user configured router advertisement information
+	mtu               uint32               // link mtu advertised in router advertisement
+	lastRASend        time.Time            // used for rate limiting solicited router advertisement
+	solicitedRATimer  *time.Timer
+	solicitedRAPend   bool                    // solicited router advertisement is already scheduled
+	dadTransmits      uint8                   // DupAddrDetectTransmits
+	optimisticDad     bool                    // RFC 4429 optimistic duplicate address detection
+	dad               map[string]DadInfo      // key is absolute ip address
+	redirectEnable    bool                    // send ICMPv6 Redirect
+	redirectLastSent  map[string]time.Time    // rate limit for redirect, key is destination ip
 	Neighbor          map[string]NeighborInfo // key is NbrIp_NbrMac to handle move scenario's
 	PktDataCh         chan config.PacketData
 	counter           PktCounter
@@ -138,9 +161,11 @@ func (intf *Interface) addIP(ipAddr string) {
 
 func (intf *Interface) removeIP(ipAddr string) {
 	if isLinkLocal(ipAddr) {
+		intf.stopDad(intf.linkScope)
 		intf.LinkLocalIp = ""
 		intf.linkScope = ""
 	} else {
+		intf.stopDad(intf.globalScope)
 		intf.IpAddr = ""
 		intf.globalScope = ""
 	}
@@ -161,6 +186,10 @@ func (intf *Interface) commonInit(ipAddr string, pktCh chan config.PacketData, g
 	intf.routerLifeTime = 1800                      // config value s
 	intf.initialRASend = 0
 	intf.raTimer = nil
+	// Duplicate Address Detection Init
+	intf.dadTransmits = gCfg.DadTransmits
+	intf.optimisticDad = gCfg.OptimisticDad
+	intf.dad = make(map[string]DadInfo, 2)
 	// Neighbor Init
 	intf.PktDataCh = pktCh
 	intf.Neighbor = make(map[string]NeighborInfo, 10)
@@ -226,6 +255,8 @@ func (intf *Interface) SetIfType(ifType int) {
 func (intf *Interface) deleteNbrList() ([]string, error) {
 	if intf.PcapBase.PcapHandle == nil && intf.PcapBase.PcapUsers == 0 {
 		intf.StopRATimer()
+		intf.StopSolicitedRATimer()
+		intf.StopAllDad()
 		deleteEntries, err := intf.FlushNeighbors()
 		return deleteEntries, err
 	}
@@ -265,7 +296,7 @@ func (intf *Interface) CreatePcap() (err error) {
 			debug.Logger.Err("Creating Pcap Handler failed for interface:", name, "Error:", err)
 			return err
 		}
-		err = intf.PcapBase.PcapHandle.SetBPFFilter(NDP_PCAP_FILTER)
+		err = intf.PcapBase.PcapHandle.SetBPFFilter(getPcapFilter(intf.redirectEnable))
 		if err != nil {
 			debug.Logger.Err("Creating BPF Filter failed Error", err)
 			intf.PcapBase.PcapHandle = nil
@@ -459,7 +490,9 @@ func (intf *Interface) ProcessND(ndInfo *packet.NDInfo) (*config.NeighborConfig,
 	case layers.ICMPv6TypeRouterAdvertisement:
 		return intf.processRA(ndInfo)
 	case layers.ICMPv6TypeRouterSolicitation:
-		// @TODO: not supported
+		return intf.processRS(ndInfo)
+	case layers.ICMPv6TypeRedirect:
+		return intf.processRedirect(ndInfo)
 	}
 
 	return nil, IGNORE
@@ -471,6 +504,9 @@ func (intf *Interface) ProcessND(ndInfo *packet.NDInfo) (*config.NeighborConfig,
 func (intf *Interface) SendND(pktData config.PacketData, mac string) NDP_OPERATION {
 	switch pktData.SendPktType {
 	case layers.ICMPv6TypeNeighborSolicitation:
+		if pktData.DadProbe {
+			return intf.SendDadNS(mac, pktData.NeighborIp)
+		}
 		return intf.SendNS(mac, pktData.NeighborMac, pktData.NeighborIp, pktData.FastProbe)
 	case layers.ICMPv6TypeNeighborAdvertisement:
 		// @TODO: implement this
@@ -489,6 +525,8 @@ func (intf *Interface) UpdateTimer(gCfg NdpConfig) {
 	intf.reachableTime = gCfg.ReachableTime
 	intf.raRestransmitTime = gCfg.RaRestransmitTime
 	intf.retransTime = gCfg.RetransTime
+	intf.dadTransmits = gCfg.DadTransmits
+	intf.optimisticDad = gCfg.OptimisticDad
 }
 
 /*
@@ -94,7 +94,7 @@ func TestTimerUpdate(t *testing.T) {
 		t.Error("Initializing interface object failed")
 		return
 	}
-	gCfg := NdpConfig{"default", 200, 100, 245}
+	gCfg := NdpConfig{"default", 200, 100, 245, 1, false}
 	intf.UpdateTimer(gCfg)
 	validateTimerUpdate(t, gCfg, intf)
 }
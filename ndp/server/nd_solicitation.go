@@ -55,6 +55,10 @@ func (intf *Interface) sendUnicastNS(srcMac, nbrMac, nbrIp string, isFastProbe b
 	} else {
 		pkt.SrcIp = intf.globalScope
 	}
+	if !intf.isUsableAddr(pkt.SrcIp) {
+		// RFC 4862: tentative or duplicate address cannot be used as source address
+		return IGNORE
+	}
 
 	pktToSend := pkt.Encode()
 	err := intf.writePkt(pktToSend)
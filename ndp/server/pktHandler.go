@@ -29,6 +29,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"l3/ndp/config"
 	"l3/ndp/debug"
+	"l3/ndp/packet"
 	"net"
 	"reflect"
 	"utils/commonDefs"
@@ -49,6 +50,8 @@ func (svr *NDPServer) StartRxTx(ifIndex int32) {
 			ifIndex, "is not allowed")
 		return
 	}
+	// redirect configuration decides rx pcap filter and hence it should be updated before creating pcap
+	l3Port.UpdateRedirectConfig(svr.NdpIntfCfg[l3Port.IntfRef].SendRedirects)
 	var err error
 	switch l3Port.IfType {
 	case commonDefs.IfTypePort:
@@ -92,7 +95,11 @@ func (svr *NDPServer) StartRxTx(ifIndex int32) {
 		}
 		svr.ndpUpL3IntfStateSlice = append(svr.ndpUpL3IntfStateSlice, ifIndex)
 	}
-	// On Port Up Send RA packets
+	// On Port Up Send RA packets with latest link mtu and router advertisement configuration
+	l3Port.mtu = svr.getL3IntfMtu(ifIndex)
+	l3Port.UpdateRAConfig(svr.getRAIntfConfig(l3Port.IntfRef))
+	// verify link scope & global scope ip are unique on the link before using them
+	l3Port.StartDad()
 	pktData := config.PacketData{
 		SendPktType: layers.ICMPv6TypeRouterAdvertisement,
 	}
@@ -294,6 +301,8 @@ func (svr *NDPServer) ProcessRxPkt(ifIndex int32, pkt gopacket.Packet) error {
 	var l2Port PhyPort
 	var exists bool
 	var nbrKey string
+	var dupIp string
+	var duplicate bool
 	// if we receive packet on L2 Physical interface then the we need get l3 port via cross referencing PhyPortToL3PortMap
 	l3IfIndex, l3exists := svr.PhyPortToL3PortMap[ifIndex]
 	if l3exists {
@@ -310,6 +319,16 @@ func (svr *NDPServer) ProcessRxPkt(ifIndex int32, pkt gopacket.Packet) error {
 			return errors.New(fmt.Sprintln("Entry for ifIndex:", ifIndex, "doesn't exists"))
 		}
 	}
+	// Step1 : data packet punted to cpu, check if host needs to be redirected
+	if !packet.IsNDPacket(pkt) {
+		svr.ProcessPuntedPkt(&l3Port, pkt)
+		if l3exists {
+			svr.L3Port[l3IfIndex] = l3Port
+		} else {
+			svr.L3Port[ifIndex] = l3Port
+		}
+		return nil
+	}
 	// Step1 : decode packet
 	ndInfo, err := svr.Packet.DecodeND(pkt)
 	if err != nil || ndInfo == nil {
@@ -326,6 +345,12 @@ func (svr *NDPServer) ProcessRxPkt(ifIndex int32, pkt gopacket.Packet) error {
 		ndInfo.LearnedIfIndex = l3Port.IfIndex
 		ndInfo.LearnedIntfRef = l3Port.IntfRef
 	}
+	// Step2: check if packet is conflicting with our tentative address, packets looped back from our
+	// own mac are ignored
+	dupIp, duplicate = l3Port.ProcessDad(ndInfo, svr.CheckSrcMac(ndInfo.SrcMac))
+	if duplicate {
+		svr.SendIPv6DuplicateAddrNotification(dupIp, l3Port.IfIndex)
+	}
 	// Step2: process decoded packet
 	nbrInfo, operation := l3Port.ProcessND(ndInfo)
 	if nbrInfo == nil && operation == IGNORE { //|| (operation != CREATE && operation != DELETE) {
@@ -373,6 +398,48 @@ early_exit:
 	return nil
 }
 
+/*
+ *	ProcessPuntedPkt: packet destined to our mac but not to our ip needs to be forwarded, if it goes back out of
+ *			  the same interface then redirect the host to better first hop
+ */
+func (svr *NDPServer) ProcessPuntedPkt(l3Port *Interface, pkt gopacket.Packet) {
+	if !l3Port.redirectEnable {
+		return
+	}
+	ethLayer := pkt.Layer(layers.LayerTypeEthernet)
+	ipLayer := pkt.Layer(layers.LayerTypeIPv6)
+	if ethLayer == nil || ipLayer == nil {
+		return
+	}
+	eth := ethLayer.(*layers.Ethernet)
+	// packet send by us or packet which is not routed via us
+	if svr.CheckSrcMac(eth.SrcMAC.String()) || !svr.CheckSrcMac(eth.DstMAC.String()) {
+		return
+	}
+	ipv6 := ipLayer.(*layers.IPv6)
+	l3Port.SendRedirect(svr.SwitchMac, eth.SrcMAC.String(), ipv6, svr.getRedirectNextHop(l3Port, ipv6.DstIP))
+}
+
+/*
+ *	getRedirectNextHop: next hop used to forward the packet if it goes back out of the same interface, nil if
+ *			    the next hop is not known or is on another interface. Destination itself is returned for
+ *			    connected destinations
+ */
+func (svr *NDPServer) getRedirectNextHop(l3Port *Interface, dstIp net.IP) net.IP {
+	if svr.RoutePlugin == nil {
+		return nil
+	}
+	nextHopIp, ifIndex, err := svr.RoutePlugin.GetNextHop(dstIp.String())
+	if err != nil || ifIndex != l3Port.IfIndex {
+		return nil
+	}
+	nextHop := net.ParseIP(nextHopIp)
+	if nextHop == nil || nextHop.IsUnspecified() {
+		return dstIp
+	}
+	return nextHop
+}
+
 func (svr *NDPServer) ProcessTimerExpiry(pktData config.PacketData) error {
 	var l3Port Interface
 	var exists bool
@@ -0,0 +1,212 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"github.com/google/gopacket/layers"
+	"l3/ndp/config"
+	"l3/ndp/debug"
+	"l3/ndp/packet"
+	"net"
+	"time"
+)
+
+const (
+	MIN_DELAY_BETWEEN_REDIRECTS int64 = 1000 // in ms, per destination rate limit RFC: 4861 section 8.2
+	NDP_REDIRECT_CACHE_MAX            = 1024 // destinations tracked for rate limiting before stale purge
+)
+
+/*
+ *  Update redirect configuration for the interface
+ */
+func (intf *Interface) UpdateRedirectConfig(enable bool) {
+	intf.redirectEnable = enable
+	intf.redirectLastSent = nil
+}
+
+/*
+ *  A router MUST NOT update its routing tables upon receipt of a Redirect (RFC 4861 section 8.3), so received
+ *  redirect is only validated and counted
+ */
+func (intf *Interface) processRedirect(ndInfo *packet.NDInfo) (*config.NeighborConfig, NDP_OPERATION) {
+	debug.Logger.Debug("Received Redirect on intf:", intf.IntfRef, "from:", ndInfo.SrcIp, "target:",
+		ndInfo.TargetAddress, "destination:", ndInfo.DestinationAddress, "ignoring it")
+	return nil, IGNORE
+}
+
+/*
+ *  ip address is on-link if it is link-local or it is part of global scope prefix of the interface
+ */
+func (intf *Interface) isOnLink(ip net.IP) bool {
+	if ip.IsLinkLocalUnicast() {
+		return true
+	}
+	if intf.IpAddr == "" {
+		return false
+	}
+	_, ipNet, err := net.ParseCIDR(intf.IpAddr)
+	if err != nil {
+		return false
+	}
+	return ipNet.Contains(ip)
+}
+
+/*
+ *  get link layer address of the neighbor from neighbor cache, empty string if neighbor is not learned yet
+ */
+func (intf *Interface) getNeighborMac(ipAddr string) string {
+	for _, nbr := range intf.Neighbor {
+		if nbr.IpAddr == ipAddr {
+			return nbr.LinkLayerAddress
+		}
+	}
+	return ""
+}
+
+/*
+ *  RFC 4861 section 8.2 requires the target router to be identified by its link-local address, find it from the
+ *  neighbor cache entry with the same link layer address as the next hop. nil if it is not learned yet
+ */
+func (intf *Interface) getLinkLocalNeighbor(ip net.IP) net.IP {
+	nbrMac := intf.getNeighborMac(ip.String())
+	if nbrMac == "" {
+		return nil
+	}
+	for _, nbr := range intf.Neighbor {
+		if nbr.LinkLayerAddress != nbrMac {
+			continue
+		}
+		if nbrIp := net.ParseIP(nbr.IpAddr); nbrIp != nil && nbrIp.IsLinkLocalUnicast() {
+			return nbrIp
+		}
+	}
+	return nil
+}
+
+/*
+ *  Redirect is sent at most once per MIN_DELAY_BETWEEN_REDIRECTS for a destination, returns true if redirect
+ *  for the destination needs to be suppressed
+ */
+func (intf *Interface) redirectRateLimited(dstIp string, now time.Time) bool {
+	if intf.redirectLastSent == nil {
+		intf.redirectLastSent = make(map[string]time.Time, 10)
+	}
+	limit := time.Duration(MIN_DELAY_BETWEEN_REDIRECTS) * time.Millisecond
+	lastSent, exists := intf.redirectLastSent[dstIp]
+	if exists && now.Sub(lastSent) < limit {
+		return true
+	}
+	if len(intf.redirectLastSent) >= NDP_REDIRECT_CACHE_MAX {
+		for ip, sent := range intf.redirectLastSent {
+			if now.Sub(sent) >= limit {
+				delete(intf.redirectLastSent, ip)
+			}
+		}
+	}
+	intf.redirectLastSent[dstIp] = now
+	return false
+}
+
+/*
+ *  Build redirect for packet punted to cpu which needs to be forwarded back on the same interface
+ *  RFC 4861 section 8.2:
+ *	1) Source address of the packet identifies a neighbor
+ *	2) Destination is not multicast and packet is not addressed to the router
+ *	3) Next hop is on the same interface. Target Address is the next hop, which is the Destination Address
+ *	   for on-link destinations and link-local address of the router otherwise. When the next hop is not
+ *	   known only on-link destinations are redirected
+ *  Target Link-Layer Address is included if target is present in neighbor cache
+ */
+func (intf *Interface) buildRedirectInfo(ipv6 *layers.IPv6, nextHop net.IP) *packet.RedirectInfo {
+	srcIp := ipv6.SrcIP
+	dstIp := ipv6.DstIP
+	if srcIp.IsUnspecified() || srcIp.IsMulticast() || dstIp.IsUnspecified() || dstIp.IsMulticast() {
+		return nil
+	}
+	if dstIp.String() == intf.linkScope || dstIp.String() == intf.globalScope {
+		return nil
+	}
+	if !intf.isOnLink(srcIp) {
+		return nil
+	}
+	target := nextHop
+	if target == nil {
+		if !intf.isOnLink(dstIp) {
+			return nil
+		}
+		target = dstIp
+	}
+	if !target.Equal(dstIp) && !target.IsLinkLocalUnicast() {
+		target = intf.getLinkLocalNeighbor(target)
+		if target == nil {
+			return nil
+		}
+	}
+	if target.Equal(srcIp) {
+		return nil
+	}
+	redirect := &packet.RedirectInfo{
+		Target:      target,
+		Destination: dstIp,
+	}
+	redirect.RedirectedPkt = append(redirect.RedirectedPkt, ipv6.Contents...)
+	redirect.RedirectedPkt = append(redirect.RedirectedPkt, ipv6.Payload...)
+	if nbrMac := intf.getNeighborMac(target.String()); nbrMac != "" {
+		redirect.TargetMac, _ = net.ParseMAC(nbrMac)
+	}
+	return redirect
+}
+
+/*
+ *  Send Redirect to the host which sent the punted packet, redirect is always sent from link scope ip. nextHop
+ *  is the next hop on this interface the packet is forwarded to, nil if it is not known
+ */
+func (intf *Interface) SendRedirect(srcMac, hostMac string, ipv6 *layers.IPv6, nextHop net.IP) NDP_OPERATION {
+	if !intf.redirectEnable || intf.linkScope == "" || !intf.isUsableAddr(intf.linkScope) {
+		return IGNORE
+	}
+	redirect := intf.buildRedirectInfo(ipv6, nextHop)
+	if redirect == nil {
+		return IGNORE
+	}
+	if intf.redirectRateLimited(redirect.Destination.String(), time.Now()) {
+		return IGNORE
+	}
+	pkt := &packet.Packet{
+		SrcMac:   srcMac,
+		DstMac:   hostMac,
+		SrcIp:    intf.linkScope,
+		DstIp:    ipv6.SrcIP.String(),
+		PType:    layers.ICMPv6TypeRedirect,
+		Redirect: redirect,
+	}
+	pktToSend := pkt.Encode()
+	err := intf.writePkt(pktToSend)
+	if err != nil {
+		return IGNORE
+	}
+	debug.Logger.Debug("Sent Redirect on intf:", intf.IntfRef, "to host:", ipv6.SrcIP, "for destination:",
+		redirect.Destination, "target:", redirect.Target, "target mac:", redirect.TargetMac)
+	intf.counter.Send++
+	return IGNORE
+}
@@ -0,0 +1,205 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"github.com/google/gopacket/layers"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	testRedirectHostIp = "2192::168:1:10"
+	testRedirectDstIp  = "2192::168:1:20"
+	testRedirectNbrMac = "00:1f:16:25:34:31"
+	testRemoteDstIp    = "2001:db8::1"
+	testRouterGSIp     = "2192::168:1:2"
+	testRouterLLIp     = "fe80::21f:16ff:fe25:3432"
+	testRouterMac      = "00:1f:16:25:34:32"
+)
+
+type testRoutePlugin struct {
+	nextHopIp string
+	ifIndex   int32
+}
+
+func (p *testRoutePlugin) GetNextHop(ipAddr string) (string, int32, error) {
+	return p.nextHopIp, p.ifIndex, nil
+}
+
+func constructTestRedirectIPv6(srcIp, dstIp string) *layers.IPv6 {
+	ipv6 := &layers.IPv6{
+		Version:    6,
+		NextHeader: layers.IPProtocolUDP,
+		HopLimit:   64,
+		SrcIP:      net.ParseIP(srcIp),
+		DstIP:      net.ParseIP(dstIp),
+	}
+	ipv6.Contents = make([]byte, 40)
+	ipv6.Payload = []byte{0x01, 0x02, 0x03, 0x04}
+	return ipv6
+}
+
+func TestBuildRedirectInfo(t *testing.T) {
+	initTestInterface()
+	testIntf.addIP(testMyLinkScopeIP)
+	testIntf.addIP(testMyGSIp)
+	testIntf.Neighbor = make(map[string]NeighborInfo, 1)
+	testIntf.Neighbor[testRedirectNbrMac+"_"+testRedirectDstIp+"_"+testIntfRef] = NeighborInfo{
+		IpAddr:           testRedirectDstIp,
+		LinkLayerAddress: testRedirectNbrMac,
+	}
+	redirect := testIntf.buildRedirectInfo(constructTestRedirectIPv6(testRedirectHostIp, testRedirectDstIp), nil)
+	if redirect == nil {
+		t.Error("Redirect should be generated for on-link destination", testRedirectDstIp)
+		return
+	}
+	if !redirect.Target.Equal(redirect.Destination) || redirect.Destination.String() != testRedirectDstIp {
+		t.Error("Target should be same as on-link destination, got target:", redirect.Target, "destination:",
+			redirect.Destination)
+		return
+	}
+	if redirect.TargetMac.String() != testRedirectNbrMac {
+		t.Error("Target Link-Layer Address should be neighbor mac", testRedirectNbrMac, "got:", redirect.TargetMac)
+		return
+	}
+	if len(redirect.RedirectedPkt) != 44 {
+		t.Error("Redirected packet should carry ipv6 header and data, got length:", len(redirect.RedirectedPkt))
+		return
+	}
+	// next hop is not on the same link
+	if testIntf.buildRedirectInfo(constructTestRedirectIPv6(testRedirectHostIp, testRemoteDstIp), nil) != nil {
+		t.Error("Redirect should not be generated for off-link destination", testRemoteDstIp)
+		return
+	}
+	// source is not a neighbor
+	if testIntf.buildRedirectInfo(constructTestRedirectIPv6(testRemoteDstIp, testRedirectDstIp), nil) != nil {
+		t.Error("Redirect should not be generated when source", testRemoteDstIp, "is not a neighbor")
+		return
+	}
+	// packet destined to router
+	if testIntf.buildRedirectInfo(constructTestRedirectIPv6(testRedirectHostIp, testMyAbsGSIP), nil) != nil {
+		t.Error("Redirect should not be generated for packet destined to router")
+		return
+	}
+}
+
+func TestBuildRedirectInfoOffLink(t *testing.T) {
+	initTestInterface()
+	testIntf.addIP(testMyLinkScopeIP)
+	testIntf.addIP(testMyGSIp)
+	testIntf.Neighbor = make(map[string]NeighborInfo, 2)
+	testIntf.Neighbor[testRouterMac+"_"+testRouterGSIp+"_"+testIntfRef] = NeighborInfo{
+		IpAddr:           testRouterGSIp,
+		LinkLayerAddress: testRouterMac,
+	}
+	// router link-local address is not learned yet
+	ipv6 := constructTestRedirectIPv6(testRedirectHostIp, testRemoteDstIp)
+	if testIntf.buildRedirectInfo(ipv6, net.ParseIP(testRouterGSIp)) != nil {
+		t.Error("Redirect should not be generated when link-local address of router", testRouterGSIp, "is unknown")
+		return
+	}
+	testIntf.Neighbor[testRouterMac+"_"+testRouterLLIp+"_"+testIntfRef] = NeighborInfo{
+		IpAddr:           testRouterLLIp,
+		LinkLayerAddress: testRouterMac,
+	}
+	redirect := testIntf.buildRedirectInfo(ipv6, net.ParseIP(testRouterGSIp))
+	if redirect == nil {
+		t.Error("Redirect should be generated for off-link destination", testRemoteDstIp, "via router on same link")
+		return
+	}
+	if redirect.Target.String() != testRouterLLIp || redirect.Destination.String() != testRemoteDstIp {
+		t.Error("Target should be link-local address of router", testRouterLLIp, "got target:", redirect.Target,
+			"destination:", redirect.Destination)
+		return
+	}
+	if redirect.TargetMac.String() != testRouterMac {
+		t.Error("Target Link-Layer Address should be router mac", testRouterMac, "got:", redirect.TargetMac)
+		return
+	}
+}
+
+func TestGetRedirectNextHop(t *testing.T) {
+	initTestInterface()
+	svr := &NDPServer{}
+	dstIp := net.ParseIP(testRemoteDstIp)
+	if svr.getRedirectNextHop(testIntf, dstIp) != nil {
+		t.Error("Next hop should be unknown without route plugin")
+		return
+	}
+	svr.RoutePlugin = &testRoutePlugin{testRouterGSIp, testIntf.IfIndex + 1}
+	if svr.getRedirectNextHop(testIntf, dstIp) != nil {
+		t.Error("Next hop on another interface should not be used for redirect")
+		return
+	}
+	svr.RoutePlugin = &testRoutePlugin{testRouterGSIp, testIntf.IfIndex}
+	if nextHop := svr.getRedirectNextHop(testIntf, dstIp); nextHop.String() != testRouterGSIp {
+		t.Error("Next hop should be", testRouterGSIp, "got:", nextHop)
+		return
+	}
+	svr.RoutePlugin = &testRoutePlugin{"::", testIntf.IfIndex}
+	if nextHop := svr.getRedirectNextHop(testIntf, dstIp); !nextHop.Equal(dstIp) {
+		t.Error("Next hop of connected destination should be destination itself, got:", nextHop)
+		return
+	}
+}
+
+func TestRedirectRateLimit(t *testing.T) {
+	initTestInterface()
+	now := time.Now()
+	if testIntf.redirectRateLimited(testRedirectDstIp, now) {
+		t.Error("First redirect for", testRedirectDstIp, "should not be rate limited")
+		return
+	}
+	if !testIntf.redirectRateLimited(testRedirectDstIp, now.Add(100*time.Millisecond)) {
+		t.Error("Second redirect for", testRedirectDstIp, "within MIN_DELAY_BETWEEN_REDIRECTS should be rate limited")
+		return
+	}
+	if testIntf.redirectRateLimited(testRedirectHostIp, now.Add(100*time.Millisecond)) {
+		t.Error("Rate limit should be per destination")
+		return
+	}
+	later := now.Add(time.Duration(MIN_DELAY_BETWEEN_REDIRECTS) * time.Millisecond)
+	if testIntf.redirectRateLimited(testRedirectDstIp, later) {
+		t.Error("Redirect for", testRedirectDstIp, "after MIN_DELAY_BETWEEN_REDIRECTS should not be rate limited")
+		return
+	}
+}
+
+func TestSendRedirectDisabled(t *testing.T) {
+	initTestInterface()
+	testIntf.addIP(testMyLinkScopeIP)
+	testIntf.addIP(testMyGSIp)
+	testIntf.SendRedirect(testSwitchMac, testRedirectNbrMac,
+		constructTestRedirectIPv6(testRedirectHostIp, testRedirectDstIp), nil)
+	if testIntf.redirectLastSent != nil {
+		t.Error("Redirect should not be processed when it is not enabled on the interface")
+		return
+	}
+	testIntf.UpdateRedirectConfig(true)
+	if !testIntf.redirectEnable || getPcapFilter(testIntf.redirectEnable) != NDP_PCAP_REDIRECT_FILTER {
+		t.Error("Enabling redirect should receive punted data packets")
+		return
+	}
+}
@@ -25,7 +25,10 @@ package server
 import (
 	"github.com/google/gopacket/layers"
 	"l3/ndp/config"
+	"l3/ndp/debug"
 	"l3/ndp/packet"
+	"net"
+	"time"
 )
 
 /*
@@ -74,8 +77,91 @@ func (intf *Interface) processRA(ndInfo *packet.NDInfo) (nbrInfo *config.Neighbo
 }
 
 /*
- *  Router Advertisement Packet is send out for both link scope ip and global scope ip on timer expiry & port
- *  up notification
+ *  Build router advertisement information using user configuration, if there is no configuration then global
+ *  scope prefix of the interface is advertised with on-link & autonomous flags
+ */
+func (intf *Interface) buildRAInfo() *packet.RAInfo {
+	raInfo := packet.DefaultRAInfo()
+	raInfo.RouterLifetime = intf.routerLifeTime
+	if intf.mtu != 0 {
+		raInfo.Mtu = intf.mtu
+	}
+	advertiseIntfPrefix := true
+	cfg := intf.raCfg
+	if cfg != nil {
+		raInfo.CurHopLimit = cfg.CurHopLimit
+		raInfo.ManagedFlag = cfg.ManagedFlag
+		raInfo.OtherConfigFlag = cfg.OtherConfigFlag
+		raInfo.RouterPreference, _ = convertRAPreference(cfg.RouterPreference)
+		raInfo.RouterLifetime = cfg.RouterLifetime
+		raInfo.ReachableTime = cfg.ReachableTime
+		raInfo.RetransTime = cfg.RetransTime
+		advertiseIntfPrefix = cfg.AdvertiseIntfPrefix
+	}
+	if advertiseIntfPrefix && intf.IpAddr != "" {
+		ip, ipNet, err := net.ParseCIDR(intf.IpAddr)
+		if err == nil {
+			prefixLen, _ := ipNet.Mask.Size()
+			raInfo.Prefixes = append(raInfo.Prefixes, packet.PrefixInfo{
+				Prefix:            ip,
+				PrefixLength:      uint8(prefixLen),
+				OnLink:            true,
+				Autonomous:        prefixLen == NDP_SLAAC_PREFIX_LENGTH,
+				ValidLifetime:     NDP_DEFAULT_PREFIX_VALID_LIFETIME,
+				PreferredLifetime: NDP_DEFAULT_PREFIX_PREFERRED_LIFETIME,
+			})
+		}
+	}
+	if cfg == nil {
+		return raInfo
+	}
+	for _, prefixCfg := range cfg.Prefixes {
+		ip, ipNet, err := net.ParseCIDR(prefixCfg.Prefix)
+		if err != nil {
+			debug.Logger.Err("Invalid ra prefix:", prefixCfg.Prefix, "for intf:", intf.IntfRef)
+			continue
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		raInfo.Prefixes = append(raInfo.Prefixes, packet.PrefixInfo{
+			Prefix:            ip,
+			PrefixLength:      uint8(prefixLen),
+			OnLink:            prefixCfg.OnLink,
+			Autonomous:        prefixCfg.Autonomous,
+			ValidLifetime:     prefixCfg.ValidLifetime,
+			PreferredLifetime: prefixCfg.PreferredLifetime,
+		})
+	}
+	for _, routeCfg := range cfg.Routes {
+		ip, ipNet, err := net.ParseCIDR(routeCfg.Prefix)
+		if err != nil {
+			debug.Logger.Err("Invalid ra route prefix:", routeCfg.Prefix, "for intf:", intf.IntfRef)
+			continue
+		}
+		prefixLen, _ := ipNet.Mask.Size()
+		preference, _ := convertRAPreference(routeCfg.Preference)
+		raInfo.Routes = append(raInfo.Routes, packet.RouteInfo{
+			Prefix:       ip,
+			PrefixLength: uint8(prefixLen),
+			Preference:   preference,
+			Lifetime:     routeCfg.Lifetime,
+		})
+	}
+	for _, server := range cfg.RdnssServers {
+		ip := net.ParseIP(server)
+		if ip == nil {
+			continue
+		}
+		raInfo.RdnssServers = append(raInfo.RdnssServers, ip)
+	}
+	raInfo.RdnssLifetime = cfg.RdnssLifetime
+	raInfo.DnsslDomains = cfg.DnsslDomains
+	raInfo.DnsslLifetime = cfg.DnsslLifetime
+	return raInfo
+}
+
+/*
+ *  Router Advertisement Packet is send out for both link scope ip and global scope ip on timer expiry, port
+ *  up notification & router solicitation
  */
 func (intf *Interface) SendRA(srcMac string) {
 	pkt := &packet.Packet{
@@ -83,19 +169,29 @@ func (intf *Interface) SendRA(srcMac string) {
 		DstMac: ALL_NODES_MULTICAST_LINK_LAYER_ADDRESS,
 		DstIp:  ALL_NODES_MULTICAST_IPV6_ADDRESS,
 		PType:  layers.ICMPv6TypeRouterAdvertisement,
+		RA:     intf.buildRAInfo(),
 	}
-	if intf.linkScope != "" {
+	if intf.linkScope != "" && intf.isUsableAddr(intf.linkScope) {
 		pkt.SrcIp = intf.linkScope
 		pktToSend := pkt.Encode()
 		intf.writePkt(pktToSend)
 		intf.counter.Send++
 	}
-	if intf.globalScope != "" {
+	if intf.globalScope != "" && intf.isUsableAddr(intf.globalScope) {
 		pkt.SrcIp = intf.globalScope
 		pktToSend := pkt.Encode()
 		intf.writePkt(pktToSend)
 		intf.counter.Send++
 	}
+	intf.lastRASend = time.Now()
+	intf.solicitedRAPend = false
 
 	intf.RAResTransmitTimer()
 }
+
+/*
+ *  Update router advertisement configuration for the interface
+ */
+func (intf *Interface) UpdateRAConfig(raCfg *config.RAIntfConfig) {
+	intf.raCfg = raCfg
+}
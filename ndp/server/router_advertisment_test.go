@@ -214,3 +214,65 @@ func TestInvalidProcessRA(t *testing.T) {
 		return
 	}
 }
+
+func TestBuildRAInfo(t *testing.T) {
+	initServerBasic()
+	intf := Interface{
+		IpAddr:         testMyGSIp,
+		routerLifeTime: 1800,
+		mtu:            9000,
+	}
+	raInfo := intf.buildRAInfo()
+	if raInfo.Mtu != 9000 || raInfo.RouterLifetime != 1800 {
+		t.Error("Router advertisement should use interface mtu & router lifetime:", raInfo)
+		return
+	}
+	if len(raInfo.Prefixes) != 1 {
+		t.Error("Global scope prefix should be advertised by default, prefixes:", raInfo.Prefixes)
+		return
+	}
+	prefix := raInfo.Prefixes[0]
+	if prefix.PrefixLength != 64 || !prefix.OnLink || !prefix.Autonomous {
+		t.Error("Invalid default prefix information:", prefix)
+		return
+	}
+
+	intf.UpdateRAConfig(&config.RAIntfConfig{
+		IntfRef:          testIntfRef,
+		CurHopLimit:      255,
+		ManagedFlag:      true,
+		RouterPreference: RA_PREFERENCE_LOW,
+		RouterLifetime:   600,
+		Prefixes: []config.RAPrefixConfig{
+			config.RAPrefixConfig{"2001:db8:1::/64", true, false, 3600, 1800},
+		},
+		Routes: []config.RARouteConfig{
+			config.RARouteConfig{"::/0", RA_PREFERENCE_HIGH, 600},
+		},
+		RdnssServers:  []string{"2001:db8::53"},
+		RdnssLifetime: 60,
+		DnsslDomains:  []string{"example.com"},
+		DnsslLifetime: 60,
+	})
+	raInfo = intf.buildRAInfo()
+	if raInfo.CurHopLimit != 255 || !raInfo.ManagedFlag || raInfo.OtherConfigFlag ||
+		raInfo.RouterPreference != packet.ROUTER_PREFERENCE_LOW || raInfo.RouterLifetime != 600 {
+		t.Error("Router advertisement should use configured values:", raInfo)
+		return
+	}
+	if len(raInfo.Prefixes) != 1 || raInfo.Prefixes[0].Autonomous || raInfo.Prefixes[0].ValidLifetime != 3600 {
+		t.Error("Only configured prefixes should be advertised:", raInfo.Prefixes)
+		return
+	}
+	if len(raInfo.Routes) != 1 || raInfo.Routes[0].PrefixLength != 0 ||
+		raInfo.Routes[0].Preference != packet.ROUTER_PREFERENCE_HIGH {
+		t.Error("Invalid route information:", raInfo.Routes)
+		return
+	}
+	if len(raInfo.RdnssServers) != 1 || len(raInfo.DnsslDomains) != 1 {
+		t.Error("Invalid dns information:", raInfo.RdnssServers, raInfo.DnsslDomains)
+		return
+	}
+	// encode should not fail for configured options
+	intf.SendRA(testSrcMac)
+}
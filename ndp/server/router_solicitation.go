@@ -21,3 +21,30 @@
 // |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
 //
 package server
+
+import (
+	"l3/ndp/config"
+	"l3/ndp/debug"
+	"l3/ndp/packet"
+)
+
+/*
+ * When we get router solicitation packet we need to send solicited router advertisement. As per RFC 4861
+ * section 6.2.6 the response is rate limited and only one response is scheduled at any given time, all the
+ * router solicitation received while a response is pending are served by the same advertisement
+ *
+ * Router solicitation does not create any neighbor entry and hence we always return IGNORE
+ */
+func (intf *Interface) processRS(ndInfo *packet.NDInfo) (nbrInfo *config.NeighborConfig, oper NDP_OPERATION) {
+	if intf.PcapBase.Tx == nil {
+		debug.Logger.Debug("Ignoring router solicitation on intf:", intf.IntfRef, "as tx is not started")
+		return nil, IGNORE
+	}
+	if intf.solicitedRAPend {
+		debug.Logger.Debug("Solicited ra is already scheduled for intf:", intf.IntfRef, "ignoring rs from:",
+			ndInfo.SrcIp)
+		return nil, IGNORE
+	}
+	intf.SolicitedRATimer()
+	return nil, IGNORE
+}
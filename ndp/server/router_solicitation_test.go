@@ -0,0 +1,79 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"github.com/google/gopacket/layers"
+	"l3/ndp/config"
+	"l3/ndp/packet"
+	"testing"
+	"time"
+)
+
+func TestProcessRSWithoutTx(t *testing.T) {
+	initTestInterface()
+	ndInfo := &packet.NDInfo{
+		PktType: layers.ICMPv6TypeRouterSolicitation,
+		SrcIp:   testServerNSSrcIp,
+		SrcMac:  testServerNSSrcMac,
+	}
+	nbrInfo, oper := testIntf.processRS(ndInfo)
+	if nbrInfo != nil || oper != IGNORE {
+		t.Error("Router Solicitation should not create any neighbor")
+		return
+	}
+	if testIntf.solicitedRAPend {
+		t.Error("Solicited RA should not be scheduled when tx is not started")
+		return
+	}
+}
+
+func TestSolicitedRATimer(t *testing.T) {
+	initTestInterface()
+	testIntf.IfIndex = testIfIndex
+	testIntf.PktDataCh = make(chan config.PacketData, 1)
+	testIntf.SolicitedRATimer()
+	if !testIntf.solicitedRAPend {
+		t.Error("Solicited RA should be marked as pending")
+		return
+	}
+	select {
+	case pktData := <-testIntf.PktDataCh:
+		if pktData.SendPktType != layers.ICMPv6TypeRouterAdvertisement || pktData.IfIndex != testIfIndex {
+			t.Error("Invalid solicited RA packet data:", pktData)
+		}
+	case <-time.After(time.Duration(MAX_RA_DELAY_TIME)*time.Millisecond + time.Second):
+		t.Error("Solicited RA was not send within MAX_RA_DELAY_TIME")
+	}
+	testIntf.StopSolicitedRATimer()
+
+	// rate limit: RA was just send and hence solicited RA should wait for MIN_DELAY_BETWEEN_RAS
+	testIntf.lastRASend = time.Now()
+	testIntf.SolicitedRATimer()
+	select {
+	case <-testIntf.PktDataCh:
+		t.Error("Solicited RA should be rate limited by MIN_DELAY_BETWEEN_RAS")
+	case <-time.After(time.Duration(MAX_RA_DELAY_TIME)*time.Millisecond + 100*time.Millisecond):
+	}
+	testIntf.StopSolicitedRATimer()
+}
@@ -99,6 +99,16 @@ func (svr *NDPServer) InitGlobalDS() {
 
 	//configuration channels
 	svr.GlobalCfg = make(chan NdpConfig)
+	svr.RaIntfCfgCh = make(chan *config.RAIntfNotification)
+	if svr.RaIntfCfg == nil {
+		// router advertisement config might have been read from DB already
+		svr.RaIntfCfg = make(map[string]config.RAIntfConfig, NDP_SERVER_MAP_INITIAL_CAP)
+	}
+	svr.NdpIntfCfgCh = make(chan *config.NdpIntfNotification)
+	if svr.NdpIntfCfg == nil {
+		// interface config might have been read from DB already
+		svr.NdpIntfCfg = make(map[string]config.NdpIntfConfig, NDP_SERVER_MAP_INITIAL_CAP)
+	}
 
 	// init publisher
 	pub := publisher.NewPublisher()
@@ -156,6 +166,18 @@ func (svr *NDPServer) EventsListener() {
 			if update {
 				svr.UpdateInterfaceTimers()
 			}
+		// router advertisement interface configuration channel
+		case raCfg, ok := <-svr.RaIntfCfgCh:
+			if !ok {
+				continue
+			}
+			svr.HandleRAIntfConfig(raCfg)
+		// ndp interface configuration channel
+		case intfCfg, ok := <-svr.NdpIntfCfgCh:
+			if !ok {
+				continue
+			}
+			svr.HandleNdpIntfConfig(intfCfg)
 		case vlanInfo, ok := <-svr.VlanCh:
 			if !ok {
 				continue
@@ -231,7 +231,7 @@ func TestNdpDeInit(t *testing.T) {
 
 func TestGlobalUpdateTimer(t *testing.T) {
 	TestIPv6IntfCreate(t)
-	gCfg := NdpConfig{"default", 200, 100, 245}
+	gCfg := NdpConfig{"default", 200, 100, 245, 1, false}
 	testGlobalConfigNdpOperations(gCfg, t)
 	gCfg.RaRestransmitTime = 5
 	update := testNdpServer.NdpConfig.Create(gCfg)
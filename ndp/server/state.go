@@ -78,6 +78,8 @@ func (svr *NDPServer) GetGlobalState(vrf string) *config.GlobalState {
 	gblState.RetransmitInterval = int32(svr.NdpConfig.RetransTime)
 	gblState.ReachableTime = int32(svr.NdpConfig.ReachableTime)
 	gblState.RouterAdvertisementInterval = int32(svr.NdpConfig.RaRestransmitTime)
+	gblState.DupAddrDetectTransmits = int32(svr.NdpConfig.DadTransmits)
+	gblState.OptimisticDad = svr.NdpConfig.OptimisticDad
 	return &gblState
 }
 
@@ -90,6 +92,8 @@ func (svr *NDPServer) PopulateInterfaceInfo(ifIndex int32, entry *config.Interfa
 	entry.IfIndex = intf.IfIndex
 	entry.LinkScopeIp = intf.LinkLocalIp
 	entry.GlobalScopeIp = intf.IpAddr
+	entry.LinkScopeIpState = intf.getDadState(intf.linkScope)
+	entry.GlobalScopeIpState = intf.getDadState(intf.globalScope)
 	entry.SendPackets = intf.counter.Send
 	entry.ReceivedPackets = intf.counter.Rcvd
 	for _, nbrInfo := range intf.Neighbor {
@@ -27,6 +27,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"l3/ndp/config"
 	"l3/ndp/debug"
+	"math/rand"
 	"time"
 )
 
@@ -85,6 +86,18 @@ func (intf *Interface) StopRATimer() {
 	}
 }
 
+/*
+ *  stop Solicited Router Advertisement Timer
+ */
+func (intf *Interface) StopSolicitedRATimer() {
+	if intf.solicitedRATimer != nil {
+		debug.Logger.Debug("Stopping Solicited RA Timer for interface:", intf.IntfRef)
+		intf.solicitedRATimer.Stop()
+		intf.solicitedRATimer = nil
+	}
+	intf.solicitedRAPend = false
+}
+
 /*
  *  stop Invalid Timer
  */
@@ -300,6 +313,33 @@ func (intf *Interface) RAResTransmitTimer() {
 	}
 }
 
+/*
+ * Solicited Router Advertisment Timer: RFC 4861 section 6.2.6, response to RS is delayed by a random time
+ * between 0 and MAX_RA_DELAY_TIME and if a multicast RA was sent within last MIN_DELAY_BETWEEN_RAS then
+ * response is send only after MIN_DELAY_BETWEEN_RAS is elapsed
+ */
+func (intf *Interface) SolicitedRATimer() {
+	delay := time.Duration(rand.Int63n(MAX_RA_DELAY_TIME)) * time.Millisecond
+	nextRA := intf.lastRASend.Add(time.Duration(MIN_DELAY_BETWEEN_RAS) * time.Second)
+	if wait := nextRA.Sub(time.Now()); wait > delay {
+		delay = wait
+	}
+	if intf.solicitedRATimer != nil {
+		intf.solicitedRATimer.Reset(delay)
+	} else {
+		var solicitedRA_func func()
+		solicitedRA_func = func() {
+			intf.PktDataCh <- config.PacketData{
+				SendPktType: layers.ICMPv6TypeRouterAdvertisement,
+				IfIndex:     intf.IfIndex,
+			}
+		}
+		intf.solicitedRATimer = time.AfterFunc(delay, solicitedRA_func)
+	}
+	debug.Logger.Debug("Scheduled solicited ra for intf:", intf.IntfRef, "after:", delay)
+	intf.solicitedRAPend = true
+}
+
 /*
  *  invalidation timer received during RA
  */
@@ -100,7 +100,7 @@ func (svr *NDPServer) updateL2Operstate(ifIndex int32, state string) {
 	case config.STATE_UP:
 		// if l2 port rx is set to nil and l3 ifIndex is not invalid then create pcap
 		if l2Port.RX == nil && l2Port.L3.IfIndex != config.L3_INVALID_IFINDEX {
-			l2Port.createPortPcap(svr.RxPktCh, l2Port.L3.Name)
+			l2Port.createPortPcap(svr.RxPktCh, l2Port.L3.Name, svr.getPcapFilter(l2Port.L3.IfIndex))
 			// reverse map updated
 			svr.PhyPortToL3PortMap[ifIndex] = l2Port.L3.IfIndex
 		}
@@ -114,7 +114,7 @@ func (svr *NDPServer) updateL2Operstate(ifIndex int32, state string) {
 /*
  * internal api for creating pcap handler for l2 untagged/tagged physical port for RX
  */
-func (l2Port *PhyPort) createPortPcap(pktCh chan *RxPktInfo, name string) (err error) {
+func (l2Port *PhyPort) createPortPcap(pktCh chan *RxPktInfo, name, filter string) (err error) {
 	if l2Port.RX == nil {
 		debug.Logger.Debug("creating l2 rx pcap for", name, l2Port.Info.IfIndex)
 		l2Port.RX, err = pcap.OpenLive(name, NDP_PCAP_SNAPSHOTlEN, NDP_PCAP_PROMISCUOUS, NDP_PCAP_TIMEOUT)
@@ -122,7 +122,7 @@ func (l2Port *PhyPort) createPortPcap(pktCh chan *RxPktInfo, name string) (err e
 			debug.Logger.Err("Creating Pcap Handler failed for l2 interface:", name, "Error:", err)
 			return err
 		}
-		err = l2Port.RX.SetBPFFilter(NDP_PCAP_FILTER)
+		err = l2Port.RX.SetBPFFilter(filter)
 		if err != nil {
 			debug.Logger.Err("Creating BPF Filter failed Error", err)
 			l2Port.RX = nil
@@ -185,7 +185,7 @@ func (svr *NDPServer) CreatePcap(ifIndex int32) error {
 		if exists {
 			name := l2Port.Info.Name + "." + vlan.Name
 			if l2Port.Info.OperState == config.STATE_UP {
-				l2Port.createPortPcap(svr.RxPktCh, name)
+				l2Port.createPortPcap(svr.RxPktCh, name, svr.getPcapFilter(ifIndex))
 			} else {
 				l2Port.RX = nil
 			}
@@ -203,7 +203,7 @@ func (svr *NDPServer) CreatePcap(ifIndex int32) error {
 		l2Port, exists := svr.L2Port[pIfIndex]
 		if exists {
 			if l2Port.Info.OperState == config.STATE_UP {
-				l2Port.createPortPcap(svr.RxPktCh, l2Port.Info.Name)
+				l2Port.createPortPcap(svr.RxPktCh, l2Port.Info.Name, svr.getPcapFilter(ifIndex))
 			} else {
 				l2Port.RX = nil
 			}
@@ -71,6 +71,7 @@ const (
 	SimplePassword AuthType = 1
 	Md5            AuthType = 2
 	Reserved       AuthType = 3
+	HmacSha256     AuthType = 4 // Cryptographic authentication using HMAC-SHA-256 (RFC 5709)
 )
 
 type RestartSupport int
@@ -134,6 +135,9 @@ const (
 	UnnumberedP2P     IfType = 4
 	PointToMultipoint IfType = 5
 	Stub              IfType = 6
+	// Virtual links are never configured on a physical interface, the
+	// interface is created for an up virtual link (RFC 2328 15)
+	VirtualLink IfType = 7
 )
 
 var IfTypeList = []string{
@@ -143,7 +147,8 @@ var IfTypeList = []string{
 	"NumberedP2P",
 	"UnnumberedP2P",
 	"PointToMultipoint",
-	"Stub"}
+	"Stub",
+	"VirtualLink"}
 
 type MulticastForwarding int
 
@@ -262,6 +267,10 @@ type GlobalConf struct {
 	RestartSupport     RestartSupport
 	RestartInterval    int32
 	ReferenceBandwidth uint32
+	OpaqueLsaSupport   bool
+	SpfInitialDelay    int32 // msec
+	SpfHoldTime        int32 // msec
+	SpfMaxWaitTime     int32 // msec
 }
 
 type GlobalState struct {
@@ -302,6 +311,10 @@ type AreaState struct {
 	AreaLsaCksumSum          int32
 	AreaNssaTranslatorState  NssaTranslatorState
 	AreaNssaTranslatorEvents int32
+	SpfFullRuns              int32
+	SpfIncrementalRuns       int32
+	SpfPartialRuns           int32
+	SpfLastDuration          int32 // usec
 }
 
 // Indexed by StubAreaId and StubTOS
@@ -377,6 +390,12 @@ type InterfaceConf struct {
 	IfPollInterval    PositiveInteger
 	IfAuthKey         string
 	IfAuthType        AuthType
+	IfAuthKeyId       uint8
+	// Traffic engineering (RFC 3630). Bandwidth in kbps.
+	IfTeMetric               uint32
+	IfMaxBandwidth           uint32
+	IfMaxReservableBandwidth uint32
+	IfAdminGroup             uint32
 }
 
 type InterfaceState struct {
@@ -390,6 +409,8 @@ type InterfaceState struct {
 	IfLsaCksumSum              int32
 	IfDesignatedRouterId       RouterId
 	IfBackupDesignatedRouterId RouterId
+	IfAuthFailures             int32
+	IfAuthSeqFailures          int32
 }
 
 // Indexed By  IfMetricIpAddress, IfMetricAddressLessIf, IfMetricTOS
@@ -550,6 +571,25 @@ type AsLsdbState struct {
 	AsLsdbAdvertisement string
 }
 
+// OSPF-TE link database (RFC 3630)
+// Indexed By TeAreaId, TeAdvRouterId, TeLsid
+// Bandwidth is in kbps.
+type TeLinkState struct {
+	TeAreaId                 AreaId
+	TeAdvRouterId            RouterId
+	TeLsid                   IpAddress
+	TeRouterAddress          IpAddress
+	TeLinkType               int32
+	TeLinkId                 IpAddress
+	TeLocalIpAddr            IpAddress
+	TeRemoteIpAddr           IpAddress
+	TeMetric                 uint32
+	TeMaxBandwidth           uint32
+	TeMaxReservableBandwidth uint32
+	TeUnreservedBandwidth    uint32
+	TeAdminGroup             uint32
+}
+
 // Area LSA Counter Table
 // Indexed By AreaLsaCountAreaId, AreaLsaCountLsaType
 type OspfAreaLsaCountState struct {
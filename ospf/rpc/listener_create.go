@@ -40,6 +40,10 @@ func (h *OSPFHandler) SendOspfGlobal(ospfGlobalConf *ospfd.OspfGlobal) error {
 		RestartSupport:     config.RestartSupport(ospfGlobalConf.RestartSupport),
 		RestartInterval:    ospfGlobalConf.RestartInterval,
 		ReferenceBandwidth: uint32(ospfGlobalConf.ReferenceBandwidth),
+		OpaqueLsaSupport:   ospfGlobalConf.OpaqueLsaSupport,
+		SpfInitialDelay:    ospfGlobalConf.SpfInitialDelay,
+		SpfHoldTime:        ospfGlobalConf.SpfHoldTime,
+		SpfMaxWaitTime:     ospfGlobalConf.SpfMaxWaitTime,
 	}
 	h.server.GlobalConfigCh <- gConf
 	//	retMsg := <-h.server.GlobalConfigRetCh
@@ -49,18 +53,23 @@ func (h *OSPFHandler) SendOspfGlobal(ospfGlobalConf *ospfd.OspfGlobal) error {
 
 func (h *OSPFHandler) SendOspfIfConf(ospfIfConf *ospfd.OspfIfEntry) error {
 	ifConf := config.InterfaceConf{
-		IfIpAddress:       config.IpAddress(ospfIfConf.IfIpAddress),
-		AddressLessIf:     config.InterfaceIndexOrZero(ospfIfConf.AddressLessIf),
-		IfAreaId:          config.AreaId(ospfIfConf.IfAreaId),
-		IfAdminStat:       config.Status(ospfIfConf.IfAdminStat),
-		IfRtrPriority:     config.DesignatedRouterPriority(ospfIfConf.IfRtrPriority),
-		IfTransitDelay:    config.UpToMaxAge(ospfIfConf.IfTransitDelay),
-		IfRetransInterval: config.UpToMaxAge(ospfIfConf.IfRetransInterval),
-		IfHelloInterval:   config.HelloRange(ospfIfConf.IfHelloInterval),
-		IfRtrDeadInterval: config.PositiveInteger(ospfIfConf.IfRtrDeadInterval),
-		IfPollInterval:    config.PositiveInteger(ospfIfConf.IfPollInterval),
-		IfAuthKey:         ospfIfConf.IfAuthKey,
-		IfAuthType:        config.AuthType(ospfIfConf.IfAuthType),
+		IfIpAddress:              config.IpAddress(ospfIfConf.IfIpAddress),
+		AddressLessIf:            config.InterfaceIndexOrZero(ospfIfConf.AddressLessIf),
+		IfAreaId:                 config.AreaId(ospfIfConf.IfAreaId),
+		IfAdminStat:              config.Status(ospfIfConf.IfAdminStat),
+		IfRtrPriority:            config.DesignatedRouterPriority(ospfIfConf.IfRtrPriority),
+		IfTransitDelay:           config.UpToMaxAge(ospfIfConf.IfTransitDelay),
+		IfRetransInterval:        config.UpToMaxAge(ospfIfConf.IfRetransInterval),
+		IfHelloInterval:          config.HelloRange(ospfIfConf.IfHelloInterval),
+		IfRtrDeadInterval:        config.PositiveInteger(ospfIfConf.IfRtrDeadInterval),
+		IfPollInterval:           config.PositiveInteger(ospfIfConf.IfPollInterval),
+		IfAuthKey:                ospfIfConf.IfAuthKey,
+		IfAuthType:               config.AuthType(ospfIfConf.IfAuthType),
+		IfAuthKeyId:              uint8(ospfIfConf.IfAuthKeyId),
+		IfTeMetric:               uint32(ospfIfConf.IfTeMetric),
+		IfMaxBandwidth:           uint32(ospfIfConf.IfMaxBandwidth),
+		IfMaxReservableBandwidth: uint32(ospfIfConf.IfMaxReservableBandwidth),
+		IfAdminGroup:             uint32(ospfIfConf.IfAdminGroup),
 	}
 
 	for index, ifName := range config.IfTypeList {
@@ -158,7 +167,31 @@ func (h *OSPFHandler) CreateOspfIfMetricEntry(ospfIfMetricConf *ospfd.OspfIfMetr
 	return true, nil
 }
 
+func (h *OSPFHandler) SendOspfVirtIfConf(ospfVirtIfConf *ospfd.OspfVirtIfEntry) error {
+	virtIfConf := config.VirtIfConf{
+		VirtIfAreaId:          config.AreaId(ospfVirtIfConf.VirtIfAreaId),
+		VirtIfNeighbor:        config.RouterId(ospfVirtIfConf.VirtIfNeighbor),
+		VirtIfTransitDelay:    config.UpToMaxAge(ospfVirtIfConf.VirtIfTransitDelay),
+		VirtIfRetransInterval: config.UpToMaxAge(ospfVirtIfConf.VirtIfRetransInterval),
+		VirtIfHelloInterval:   config.HelloRange(ospfVirtIfConf.VirtIfHelloInterval),
+		VirtIfRtrDeadInterval: config.PositiveInteger(ospfVirtIfConf.VirtIfRtrDeadInterval),
+		VirtIfAuthKey:         ospfVirtIfConf.VirtIfAuthKey,
+		VirtIfAuthType:        config.AuthType(ospfVirtIfConf.VirtIfAuthType),
+	}
+
+	h.server.VirtIfConfigCh <- virtIfConf
+	return nil
+}
+
 func (h *OSPFHandler) CreateOspfVirtIfEntry(ospfVirtIfConf *ospfd.OspfVirtIfEntry) (bool, error) {
+	if ospfVirtIfConf == nil {
+		err := errors.New("Invalid Virtual Interface Configuration")
+		return false, err
+	}
 	h.logger.Info(fmt.Sprintln("Create virtual interface config attrs:", ospfVirtIfConf))
+	err := h.SendOspfVirtIfConf(ospfVirtIfConf)
+	if err != nil {
+		return false, err
+	}
 	return true, nil
 }
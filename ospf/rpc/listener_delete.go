@@ -25,8 +25,8 @@ package rpc
 
 import (
 	"fmt"
+	"l3/ospf/config"
 	"ospfd"
-	//    "l3/ospf/config"
 	//    "l3/ospf/server"
 	//    "utils/logging"
 	//    "net"
@@ -55,5 +55,10 @@ func (h *OSPFHandler) DeleteOspfIfMetricEntry(ospfIfMetricConf *ospfd.OspfIfMetr
 
 func (h *OSPFHandler) DeleteOspfVirtIfEntry(ospfVirtIfConf *ospfd.OspfVirtIfEntry) (bool, error) {
 	h.logger.Info(fmt.Sprintln("Delete virtual interface config attrs:", ospfVirtIfConf))
+	virtIfConf := config.VirtIfConf{
+		VirtIfAreaId:   config.AreaId(ospfVirtIfConf.VirtIfAreaId),
+		VirtIfNeighbor: config.RouterId(ospfVirtIfConf.VirtIfNeighbor),
+	}
+	h.server.VirtIfDeleteCh <- virtIfConf
 	return true, nil
 }
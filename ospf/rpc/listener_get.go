@@ -101,3 +101,9 @@ func (h *OSPFHandler) GetOspfIPv4RouteState(destId string, addrMask string, dest
 func (h *OSPFHandler) GetOspfEventState(Index int32) (*ospfd.OspfEventState, error) {
 	return nil, nil
 }
+
+func (h *OSPFHandler) GetOspfTeLinkState(teAreaId string, teAdvRouterId string, teLsid string) (*ospfd.OspfTeLinkState, error) {
+	h.logger.Info(fmt.Sprintln("Get TE link attrs"))
+	ospfTeLinkResponse := ospfd.NewOspfTeLinkState()
+	return ospfTeLinkResponse, nil
+}
@@ -40,6 +40,10 @@ func (h *OSPFHandler) convertAreaEntryStateToThrift(ent config.AreaState) *ospfd
 	areaEntry.AreaBdrRtrCount = ent.AreaBdrRtrCount
 	areaEntry.AsBdrRtrCount = ent.AsBdrRtrCount
 	areaEntry.AreaLsaCount = ent.AreaLsaCount
+	areaEntry.SpfFullRuns = ent.SpfFullRuns
+	areaEntry.SpfIncrementalRuns = ent.SpfIncrementalRuns
+	areaEntry.SpfPartialRuns = ent.SpfPartialRuns
+	areaEntry.SpfLastDuration = ent.SpfLastDuration
 
 	return areaEntry
 }
@@ -70,6 +74,8 @@ func (h *OSPFHandler) convertIfEntryStateToThrift(ent config.InterfaceState) *os
 	ifEntry.IfLsaCount = int32(ent.IfLsaCount)
 	ifEntry.IfDesignatedRouterId = string(ent.IfDesignatedRouterId)
 	ifEntry.IfBackupDesignatedRouterId = string(ent.IfBackupDesignatedRouter)
+	ifEntry.IfAuthFailures = int32(ent.IfAuthFailures)
+	ifEntry.IfAuthSeqFailures = int32(ent.IfAuthSeqFailures)
 
 	return ifEntry
 }
@@ -218,3 +224,37 @@ func (h *OSPFHandler) GetBulkOspfEventState(fromIdx ospfd.Int, count ospfd.Int)
        /* This is template API. Events are stored in redis-db */
 	return nil, nil
 }
+
+func (h *OSPFHandler) convertTeLinkStateToThrift(ent config.TeLinkState) *ospfd.OspfTeLinkState {
+	teLink := ospfd.NewOspfTeLinkState()
+	teLink.TeAreaId = string(ent.TeAreaId)
+	teLink.TeAdvRouterId = string(ent.TeAdvRouterId)
+	teLink.TeLsid = string(ent.TeLsid)
+	teLink.TeRouterAddress = string(ent.TeRouterAddress)
+	teLink.TeLinkType = ent.TeLinkType
+	teLink.TeLinkId = string(ent.TeLinkId)
+	teLink.TeLocalIpAddr = string(ent.TeLocalIpAddr)
+	teLink.TeRemoteIpAddr = string(ent.TeRemoteIpAddr)
+	teLink.TeMetric = int32(ent.TeMetric)
+	teLink.TeMaxBandwidth = int32(ent.TeMaxBandwidth)
+	teLink.TeMaxReservableBandwidth = int32(ent.TeMaxReservableBandwidth)
+	teLink.TeUnreservedBandwidth = int32(ent.TeUnreservedBandwidth)
+	teLink.TeAdminGroup = int32(ent.TeAdminGroup)
+	return teLink
+}
+
+func (h *OSPFHandler) GetBulkOspfTeLinkState(fromIdx ospfd.Int, count ospfd.Int) (*ospfd.OspfTeLinkStateGetInfo, error) {
+	h.logger.Info(fmt.Sprintln("Get TE link attrs"))
+	nextIdx, currCount, ospfTeLinkStates := h.server.GetBulkOspfTeLinkState(int(fromIdx), int(count))
+	ospfTeLinkResponse := make([]*ospfd.OspfTeLinkState, len(ospfTeLinkStates))
+	for idx, item := range ospfTeLinkStates {
+		ospfTeLinkResponse[idx] = h.convertTeLinkStateToThrift(item)
+	}
+	ospfTeLinkStateGetInfo := ospfd.NewOspfTeLinkStateGetInfo()
+	ospfTeLinkStateGetInfo.Count = ospfd.Int(currCount)
+	ospfTeLinkStateGetInfo.StartIdx = ospfd.Int(fromIdx)
+	ospfTeLinkStateGetInfo.EndIdx = ospfd.Int(nextIdx)
+	ospfTeLinkStateGetInfo.More = (nextIdx != 0)
+	ospfTeLinkStateGetInfo.OspfTeLinkStateList = ospfTeLinkResponse
+	return ospfTeLinkStateGetInfo, nil
+}
@@ -59,6 +59,10 @@ func (h *OSPFHandler) UpdateOspfIfMetricEntry(origConf *ospfd.OspfIfMetricEntry,
 func (h *OSPFHandler) UpdateOspfVirtIfEntry(origConf *ospfd.OspfVirtIfEntry, newConf *ospfd.OspfVirtIfEntry, attrset []bool, op []*ospfd.PatchOpInfo) (bool, error) {
 	h.logger.Info(fmt.Sprintln("Original virtual interface config attrs:", origConf))
 	h.logger.Info(fmt.Sprintln("New virtual interface config attrs:", newConf))
+	err := h.SendOspfVirtIfConf(newConf)
+	if err != nil {
+		return false, err
+	}
 	return true, nil
 }
 
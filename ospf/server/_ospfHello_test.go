@@ -133,7 +133,7 @@ func checkHeaderAPIs() {
 	pkt := encodeOspfHdr(*ospfHdr)
 	fmt.Println("Encoded header pkt : ", pkt)
 
-	ospf.processOspfHeader(hello, key, &ospfHdrMd)
+	ospf.processOspfHeader(hello, key, &ospfHdrMd, &ipHdrMd)
 	ospf.processOspfData(hello, &ethHdrMd, &ipHdrMd, &ospfHdrMd, key)
 	ospf.processOspfData(lsaupd, &ethHdrMd, &ipHdrMd, &ospfHdrMd, key)
 	ospf.processOspfData(lsaack, &ethHdrMd, &ipHdrMd, &ospfHdrMd, key)
@@ -52,6 +52,11 @@ type AreaState struct {
 	AreaLsaCksumSum          int32
 	AreaNssaTranslatorState  config.NssaTranslatorState
 	AreaNssaTranslatorEvents int32
+	SpfFullRuns              int32
+	SpfIncrementalRuns       int32
+	SpfPartialRuns           int32
+	SpfLastDuration          int32 // usec
+	nssaStabilityExpiry      time.Time
 }
 
 func (server *OSPFServer) processAreaConfig(areaConf config.AreaConf) error {
@@ -104,6 +109,10 @@ func (server *OSPFServer) initAreaStateSlice(key AreaConfKey) {
 	ent.AreaLsaCksumSum = 0
 	ent.AreaNssaTranslatorState = config.NssaTranslatorDisabled
 	ent.AreaNssaTranslatorEvents = 0
+	ent.SpfFullRuns = 0
+	ent.SpfIncrementalRuns = 0
+	ent.SpfPartialRuns = 0
+	ent.SpfLastDuration = 0
 	server.AreaStateMap[key] = ent
 	if !exist {
 		server.AreaStateSlice = append(server.AreaStateSlice, key)
@@ -221,3 +230,19 @@ func (server *OSPFServer) isStubArea(areaid config.AreaId) bool {
 	}
 	return false
 }
+
+func (server *OSPFServer) isNssaArea(areaid config.AreaId) bool {
+
+	areaConfKey := AreaConfKey{
+		AreaId: areaid,
+	}
+
+	conf, exist := server.AreaConfMap[areaConfKey]
+	if !exist {
+		return false
+	}
+	if conf.ImportAsExtern == config.ImportNssa {
+		return true
+	}
+	return false
+}
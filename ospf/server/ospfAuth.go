@@ -0,0 +1,255 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"l3/ospf/config"
+	"sync"
+	"time"
+)
+
+type CryptoAlgo uint8
+
+const (
+	CryptoAlgoMd5        CryptoAlgo = 0 // RFC 2328 Appendix D.3
+	CryptoAlgoHmacSha256 CryptoAlgo = 1 // RFC 5709
+)
+
+const (
+	OSPF_AUTH_SIMPLE_LEN     = 8
+	OSPF_MD5_DIGEST_LEN      = md5.Size
+	OSPF_SHA256_DIGEST_LEN   = sha256.Size
+	OSPF_HMAC_SHA_APAD_CONST = 0x878FE1F3
+)
+
+/*
+Per interface authentication state. It is shared by all the copies
+of the IntfConf and updated from the packet receive threads.
+*/
+type IntfAuthState struct {
+	lock            sync.Mutex
+	nbrCryptoSeqMap map[string]uint32 // Key: Neighbor IP, Value: last received crypto sequence number
+	authFailures    int32             // All the packets dropped by authentication
+	authSeqFailures int32             // Packets dropped because of decreasing crypto sequence number
+}
+
+func newIntfAuthState() *IntfAuthState {
+	return &IntfAuthState{
+		nbrCryptoSeqMap: make(map[string]uint32),
+	}
+}
+
+func (authState *IntfAuthState) getAuthFailures() (int32, int32) {
+	if authState == nil {
+		return 0, 0
+	}
+	authState.lock.Lock()
+	defer authState.lock.Unlock()
+	return authState.authFailures, authState.authSeqFailures
+}
+
+/*
+Hmac-Sha256 and Md5 are both sent with the cryptographic auth type
+*/
+func getIntfAuthType(authType config.AuthType) (uint16, CryptoAlgo) {
+	switch authType {
+	case config.Md5:
+		return uint16(config.Md5), CryptoAlgoMd5
+	case config.HmacSha256:
+		return uint16(config.Md5), CryptoAlgoHmacSha256
+	}
+	return uint16(authType), CryptoAlgoMd5
+}
+
+/*
+Simple password can be given in the dotted format used for the default key,
+otherwise the key is used as it is.
+*/
+func getIntfAuthKey(authType config.AuthType, authKey string) []byte {
+	if authType == config.SimplePassword || authType == config.NoAuth {
+		if key := convertAuthKey(authKey); key != nil {
+			return key
+		}
+		key := make([]byte, OSPF_AUTH_SIMPLE_LEN)
+		copy(key, []byte(authKey))
+		return key
+	}
+	return []byte(authKey)
+}
+
+func getCryptoDigestLen(algo CryptoAlgo) int {
+	if algo == CryptoAlgoHmacSha256 {
+		return OSPF_SHA256_DIGEST_LEN
+	}
+	return OSPF_MD5_DIGEST_LEN
+}
+
+/*
+RFC 2328 D.4.3: Md5 digest is calculated over the packet followed by
+the key padded to 16 bytes.
+RFC 5709 3.3: Hmac-Sha256 is calculated over the packet followed by
+Apad, which is 0x878FE1F3 repeated to the length of the digest. A key
+longer than the digest is replaced by its hash (Ks = H(K)); hmac only
+does that for keys longer than the hash block size.
+*/
+func computeCryptoDigest(algo CryptoAlgo, authKey []byte, ospfPkt []byte) []byte {
+	switch algo {
+	case CryptoAlgoHmacSha256:
+		apad := make([]byte, OSPF_SHA256_DIGEST_LEN)
+		for i := 0; i < OSPF_SHA256_DIGEST_LEN; i += 4 {
+			binary.BigEndian.PutUint32(apad[i:i+4], OSPF_HMAC_SHA_APAD_CONST)
+		}
+		if len(authKey) > OSPF_SHA256_DIGEST_LEN {
+			keyHash := sha256.Sum256(authKey)
+			authKey = keyHash[:]
+		}
+		mac := hmac.New(sha256.New, authKey)
+		mac.Write(ospfPkt)
+		mac.Write(apad)
+		return mac.Sum(nil)
+	default:
+		key := make([]byte, OSPF_MD5_DIGEST_LEN)
+		copy(key, authKey)
+		hash := md5.New()
+		hash.Write(ospfPkt)
+		hash.Write(key)
+		return hash.Sum(nil)
+	}
+}
+
+/*
+Crypto sequence number has to be non decreasing. Seconds since epoch
+keep it non decreasing across restarts as well.
+*/
+func getCryptoSeqNum() uint32 {
+	return uint32(time.Now().Unix())
+}
+
+/*
+Fill checksum and authentication field of the encoded ospf packet.
+With cryptographic authentication the checksum is not calculated and
+the message digest is appended at the end of the packet (not included
+in the ospf packet length).
+*/
+func (server *OSPFServer) encodeOspfAuth(ent IntfConf, ospf []byte) []byte {
+	switch config.AuthType(ent.IfAuthType) {
+	case config.Md5:
+		binary.BigEndian.PutUint16(ospf[12:14], 0)
+		binary.BigEndian.PutUint16(ospf[16:18], 0)
+		ospf[18] = ent.IfAuthKeyId
+		ospf[19] = uint8(getCryptoDigestLen(ent.IfCryptoAlgo))
+		binary.BigEndian.PutUint32(ospf[20:24], getCryptoSeqNum())
+		digest := computeCryptoDigest(ent.IfCryptoAlgo, ent.IfAuthKey, ospf)
+		ospf = append(ospf, digest...)
+	case config.SimplePassword:
+		csum := computeCheckSum(ospf)
+		binary.BigEndian.PutUint16(ospf[12:14], csum)
+		copy(ospf[16:24], ent.IfAuthKey)
+	default:
+		csum := computeCheckSum(ospf)
+		binary.BigEndian.PutUint16(ospf[12:14], csum)
+	}
+	return ospf
+}
+
+/*
+RFC 2328 D.5: Authenticate the received packet as per the
+interface authentication type.
+*/
+func (server *OSPFServer) authenticateOspfPkt(ospfPkt []byte, ospfHdr *OSPFHeader, ent IntfConf, ipHdrMd *IpHdrMetadata) error {
+	var err error
+	switch config.AuthType(ent.IfAuthType) {
+	case config.Md5:
+		err = server.authenticateCryptoOspfPkt(ospfPkt, ospfHdr, ent, ipHdrMd)
+	case config.SimplePassword:
+		if !bytesEqual(ospfHdr.authKey, ent.IfAuthKey) {
+			err = errors.New("Dropped because of simple password not matching")
+		}
+		if err == nil {
+			err = verifyOspfChecksum(ospfPkt, ospfHdr)
+		}
+	default:
+		err = verifyOspfChecksum(ospfPkt, ospfHdr)
+	}
+	if err != nil && ent.IfAuthState != nil {
+		ent.IfAuthState.lock.Lock()
+		ent.IfAuthState.authFailures++
+		ent.IfAuthState.lock.Unlock()
+	}
+	return err
+}
+
+func verifyOspfChecksum(ospfPkt []byte, ospfHdr *OSPFHeader) error {
+	cksumPkt := make([]byte, ospfHdr.pktlen)
+	copy(cksumPkt, ospfPkt[:ospfHdr.pktlen])
+	binary.BigEndian.PutUint16(cksumPkt[12:14], 0)
+	copy(cksumPkt[16:OSPF_HEADER_SIZE], []byte{0, 0, 0, 0, 0, 0, 0, 0})
+	csum := computeCheckSum(cksumPkt)
+	if csum != ospfHdr.chksum {
+		return errors.New("Dropped because of invalid checksum")
+	}
+	return nil
+}
+
+func (server *OSPFServer) authenticateCryptoOspfPkt(ospfPkt []byte, ospfHdr *OSPFHeader, ent IntfConf, ipHdrMd *IpHdrMetadata) error {
+	keyId := ospfHdr.authKey[2]
+	digestLen := int(ospfHdr.authKey[3])
+	seqNum := binary.BigEndian.Uint32(ospfHdr.authKey[4:8])
+	if keyId != ent.IfAuthKeyId {
+		return errors.New(fmt.Sprintln("Dropped because of key id", keyId, "not matching"))
+	}
+	if digestLen != getCryptoDigestLen(ent.IfCryptoAlgo) ||
+		len(ospfPkt) < int(ospfHdr.pktlen)+digestLen {
+		return errors.New(fmt.Sprintln("Dropped because of invalid digest length", digestLen))
+	}
+	authState := ent.IfAuthState
+	if authState == nil {
+		return errors.New("Dropped because of missing authentication state")
+	}
+	nbrIP := convertIPInByteToString(ipHdrMd.srcIP)
+	authState.lock.Lock()
+	lastSeqNum, exist := authState.nbrCryptoSeqMap[nbrIP]
+	authState.lock.Unlock()
+	if exist && seqNum < lastSeqNum {
+		authState.lock.Lock()
+		authState.authSeqFailures++
+		authState.lock.Unlock()
+		return errors.New(fmt.Sprintln("Dropped because of crypto sequence number", seqNum, "less than", lastSeqNum))
+	}
+	digest := ospfPkt[ospfHdr.pktlen : int(ospfHdr.pktlen)+digestLen]
+	computedDigest := computeCryptoDigest(ent.IfCryptoAlgo, ent.IfAuthKey, ospfPkt[:ospfHdr.pktlen])
+	if !hmac.Equal(digest, computedDigest) {
+		return errors.New("Dropped because of message digest not matching")
+	}
+	authState.lock.Lock()
+	authState.nbrCryptoSeqMap[nbrIP] = seqNum
+	authState.lock.Unlock()
+	return nil
+}
@@ -0,0 +1,107 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"l3/ospf/config"
+	"net"
+	"testing"
+)
+
+func buildAuthTestPkt(server *OSPFServer, ent IntfConf) []byte {
+	ospfHdr := OSPFHeader{
+		ver:      OSPF_VERSION_2,
+		pktType:  uint8(HelloType),
+		pktlen:   uint16(OSPF_HEADER_SIZE + OSPF_HELLO_MIN_SIZE),
+		routerId: []byte{1, 1, 1, 1},
+		areaId:   []byte{0, 0, 0, 0},
+		authType: ent.IfAuthType,
+	}
+	ospf := append(encodeOspfHdr(ospfHdr), make([]byte, OSPF_HELLO_MIN_SIZE)...)
+	return server.encodeOspfAuth(ent, ospf)
+}
+
+func TestOspfCryptoAuth(t *testing.T) {
+	server := getServerObject()
+	ipHdrMd := NewIpHdrMetadata()
+	ipHdrMd.srcIP = net.IP{10, 0, 0, 1}
+	for _, authType := range []config.AuthType{config.Md5, config.HmacSha256} {
+		ent := IntfConf{
+			IfAuthKey:   getIntfAuthKey(authType, "ospfkey"),
+			IfAuthKeyId: 5,
+			IfAuthState: newIntfAuthState(),
+		}
+		ent.IfAuthType, ent.IfCryptoAlgo = getIntfAuthType(authType)
+		pkt := buildAuthTestPkt(server, ent)
+		if len(pkt) != OSPF_HEADER_SIZE+OSPF_HELLO_MIN_SIZE+getCryptoDigestLen(ent.IfCryptoAlgo) {
+			t.Fatal("Digest is not appended to the packet for auth type", authType)
+		}
+		ospfHdr := NewOSPFHeader()
+		decodeOspfHdr(pkt, ospfHdr)
+		err := server.authenticateOspfPkt(pkt, ospfHdr, ent, ipHdrMd)
+		if err != nil {
+			t.Fatal("Authentication failed for auth type", authType, err)
+		}
+
+		// Replayed packet with older sequence number
+		seqNum := binary.BigEndian.Uint32(pkt[20:24])
+		ent.IfAuthState.nbrCryptoSeqMap[convertIPInByteToString(ipHdrMd.srcIP)] = seqNum + 1
+		err = server.authenticateOspfPkt(pkt, ospfHdr, ent, ipHdrMd)
+		if err == nil {
+			t.Fatal("Packet with decreasing sequence number accepted for auth type", authType)
+		}
+		authFailures, authSeqFailures := ent.IfAuthState.getAuthFailures()
+		if authFailures != 1 || authSeqFailures != 1 {
+			t.Fatal("Invalid auth failure counters", authFailures, authSeqFailures)
+		}
+
+		// Wrong key
+		ent.IfAuthState = newIntfAuthState()
+		ent.IfAuthKey = []byte("wrongkey")
+		err = server.authenticateOspfPkt(pkt, ospfHdr, ent, ipHdrMd)
+		if err == nil {
+			t.Fatal("Packet with wrong key accepted for auth type", authType)
+		}
+	}
+}
+
+func TestOspfHmacSha256LongKey(t *testing.T) {
+	pkt := []byte{2, 1, 0, 44, 1, 1, 1, 1}
+	longKey := []byte("0123456789abcdef0123456789abcdef0123456789")
+	keyHash := sha256.Sum256(longKey)
+	digest := computeCryptoDigest(CryptoAlgoHmacSha256, longKey, pkt)
+	if !bytes.Equal(digest, computeCryptoDigest(CryptoAlgoHmacSha256, keyHash[:], pkt)) {
+		t.Fatal("Key longer than the digest length is not replaced by its hash")
+	}
+
+	shortKey := []byte("ospfkey")
+	shortHash := sha256.Sum256(shortKey)
+	if bytes.Equal(computeCryptoDigest(CryptoAlgoHmacSha256, shortKey, pkt),
+		computeCryptoDigest(CryptoAlgoHmacSha256, shortHash[:], pkt)) {
+		t.Fatal("Key not longer than the digest length should be used as is")
+	}
+}
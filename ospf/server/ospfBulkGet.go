@@ -58,6 +58,10 @@ func (server *OSPFServer) GetBulkOspfAreaEntryState(idx int, cnt int) (int, int,
 			result[i].AreaLsaCksumSum = ent.AreaLsaCksumSum
 			result[i].AreaNssaTranslatorState = ent.AreaNssaTranslatorState
 			result[i].AreaNssaTranslatorEvents = ent.AreaNssaTranslatorEvents
+			result[i].SpfFullRuns = ent.SpfFullRuns
+			result[i].SpfIncrementalRuns = ent.SpfIncrementalRuns
+			result[i].SpfPartialRuns = ent.SpfPartialRuns
+			result[i].SpfLastDuration = ent.SpfLastDuration
 		} else {
 			result[i].SpfRuns = -1
 			result[i].AreaBdrRtrCount = -1
@@ -66,6 +70,10 @@ func (server *OSPFServer) GetBulkOspfAreaEntryState(idx int, cnt int) (int, int,
 			result[i].AreaLsaCksumSum = -1
 			result[i].AreaNssaTranslatorState = -1
 			result[i].AreaNssaTranslatorEvents = -1
+			result[i].SpfFullRuns = -1
+			result[i].SpfIncrementalRuns = -1
+			result[i].SpfPartialRuns = -1
+			result[i].SpfLastDuration = -1
 		}
 
 	}
@@ -144,6 +152,27 @@ func (server *OSPFServer) GetBulkOspfLsdbEntryState(idx int, cnt int) (int, int,
 			}
 			lsaEnc = encodeASExternalLsa(lsa, lsaKey)
 			lsaMd = lsa.LsaMd
+		} else if lsdbSliceEnt.LSType == NSSALSA {
+			lsa, exist := lsDbEnt.NSSALsaMap[lsaKey]
+			if !exist {
+				continue
+			}
+			lsaEnc = encodeASExternalLsa(lsa, lsaKey)
+			lsaMd = lsa.LsaMd
+		} else if lsdbSliceEnt.LSType == AreaOpaqueLSA {
+			lsa, exist := lsDbEnt.AreaOpaqueLsaMap[lsaKey]
+			if !exist {
+				continue
+			}
+			lsaEnc = encodeOpaqueLsa(lsa, lsaKey)
+			lsaMd = lsa.LsaMd
+		} else if lsdbSliceEnt.LSType == ASOpaqueLSA {
+			lsa, exist := lsDbEnt.ASOpaqueLsaMap[lsaKey]
+			if !exist {
+				continue
+			}
+			lsaEnc = encodeOpaqueLsa(lsa, lsaKey)
+			lsaMd = lsa.LsaMd
 		}
 
 		server.logger.Info(fmt.Sprintln(lsaEnc))
@@ -205,6 +234,7 @@ func (server *OSPFServer) GetBulkOspfIfEntryState(idx int, cnt int) (int, int, [
 			result[i].IfLsaCksumSum = ent.IfLsaCksumSum
 			result[i].IfDesignatedRouterId = config.RouterId(convertUint32ToIPv4(ent.IfDRtrId))
 			result[i].IfBackupDesignatedRouterId = config.RouterId(convertUint32ToIPv4(ent.IfBDRtrId))
+			result[i].IfAuthFailures, result[i].IfAuthSeqFailures = ent.IfAuthState.getAuthFailures()
 		} else {
 			result[i].IfState = 0
 			result[i].IfDesignatedRouter = "0.0.0.0"
@@ -214,6 +244,8 @@ func (server *OSPFServer) GetBulkOspfIfEntryState(idx int, cnt int) (int, int, [
 			result[i].IfLsaCksumSum = 0
 			result[i].IfDesignatedRouterId = "0.0.0.0"
 			result[i].IfBackupDesignatedRouterId = "0.0.0.0"
+			result[i].IfAuthFailures = 0
+			result[i].IfAuthSeqFailures = 0
 		}
 	}
 
@@ -236,7 +268,7 @@ func (server *OSPFServer) GetOspfGlobalState() *config.GlobalState {
 	result.RxNewLsas = ent.RxNewLsas
 	result.OpaqueLsaSupport = ent.OpaqueLsaSupport
 	result.RestartStatus = ent.RestartStatus
-	result.RestartAge = ent.RestartAge
+	result.RestartAge = server.getGrRestartAge()
 	result.RestartExitReason = ent.RestartExitReason
 	result.AsLsaCount = ent.AsLsaCount
 	result.AsLsaCksumSum = ent.AsLsaCksumSum
@@ -283,9 +315,10 @@ func (server *OSPFServer) GetBulkOspfNbrEntryState(idx int, cnt int) (int, int,
 			result[i].NbrLsRetransQLen = 0
 			result[i].NbmaNbrPermanence = 0
 			result[i].NbrHelloSuppressed = false
-			result[i].NbrRestartHelperStatus = 0
-			result[i].NbrRestartHelperAge = 0
-			result[i].NbrRestartHelperExitReason = 0
+			helperStatus, helperAge, helperExitReason := server.getGrHelperState(key)
+			result[i].NbrRestartHelperStatus = int(helperStatus)
+			result[i].NbrRestartHelperAge = helperAge
+			result[i].NbrRestartHelperExitReason = int(helperExitReason)
 		}
 
 	}
@@ -294,3 +327,24 @@ func (server *OSPFServer) GetBulkOspfNbrEntryState(idx int, cnt int) (int, int,
 	server.logger.Info(fmt.Sprintln("length:", length, "count:", count, "nextIdx:", nextIdx, "result:", result))
 	return nextIdx, count, result
 }
+
+func (server *OSPFServer) GetBulkOspfTeLinkState(idx int, cnt int) (int, int, []config.TeLinkState) {
+	server.logger.Info(fmt.Sprintln("Getbulk: TE link states called."))
+	var nextIdx int
+	var count int
+	teLinks := server.getTeLinkStateList()
+	length := len(teLinks)
+	if idx >= length {
+		return 0, 0, nil
+	}
+	if idx+cnt >= length {
+		count = length - idx
+		nextIdx = 0
+	} else {
+		count = cnt
+		nextIdx = idx + cnt
+	}
+	result := teLinks[idx : idx+count]
+	server.logger.Info(fmt.Sprintln("length:", length, "count:", count, "nextIdx:", nextIdx, "result:", result))
+	return nextIdx, count, result
+}
@@ -94,6 +94,8 @@ const (
 	OSPF_LSA_ACK_SIZE    = 20
 	OSPF_HEADER_SIZE     = 24
 	IP_HEADER_MIN_LEN    = 20
+	ETH_HEADER_LEN       = 14
+	VIRT_LINK_TTL        = 64
 	OSPF_PROTO_ID        = 89
 	OSPF_VERSION_2       = 2
 	OSPF_NO_OF_LSA_FIELD = 4
@@ -129,8 +131,9 @@ const (
 	EOption  = 0x02
 	MCOption = 0x04
 	NPOption = 0x08
-	EAOption = 0x20
-	DCOption = 0x40
+	EAOption = 0x10
+	DCOption = 0x20
+	OOption  = 0x40 // Opaque LSA capable (RFC 5250)
 )
 
 type IntfTxHandle struct {
@@ -135,11 +135,15 @@ func (server *OSPFServer) readGlobalConfFromDB() {
 
 func (server *OSPFServer) applyOspfGlobalConf(conf *ospfd.OspfGlobal) error {
 	gConf := config.GlobalConf{
-		RouterId:        config.RouterId(conf.RouterId),
-		ASBdrRtrStatus:  conf.ASBdrRtrStatus,
-		TOSSupport:      conf.TOSSupport,
-		RestartSupport:  config.RestartSupport(conf.RestartSupport),
-		RestartInterval: conf.RestartInterval,
+		RouterId:         config.RouterId(conf.RouterId),
+		ASBdrRtrStatus:   conf.ASBdrRtrStatus,
+		TOSSupport:       conf.TOSSupport,
+		RestartSupport:   config.RestartSupport(conf.RestartSupport),
+		RestartInterval:  conf.RestartInterval,
+		OpaqueLsaSupport: conf.OpaqueLsaSupport,
+		SpfInitialDelay:  conf.SpfInitialDelay,
+		SpfHoldTime:      conf.SpfHoldTime,
+		SpfMaxWaitTime:   conf.SpfMaxWaitTime,
 	}
 	err := server.processGlobalConfig(gConf)
 	if err != nil {
@@ -218,17 +222,22 @@ func (server *OSPFServer) readIntfConfFromDB() {
 
 func (server *OSPFServer) applyOspfIntfConf(conf *ospfd.OspfIfEntry) error {
 	ifConf := config.InterfaceConf{
-		IfIpAddress:       config.IpAddress(conf.IfIpAddress),
-		AddressLessIf:     config.InterfaceIndexOrZero(conf.AddressLessIf),
-		IfAreaId:          config.AreaId(conf.IfAreaId),
-		IfRtrPriority:     config.DesignatedRouterPriority(conf.IfRtrPriority),
-		IfTransitDelay:    config.UpToMaxAge(conf.IfTransitDelay),
-		IfRetransInterval: config.UpToMaxAge(conf.IfRetransInterval),
-		IfHelloInterval:   config.HelloRange(conf.IfHelloInterval),
-		IfRtrDeadInterval: config.PositiveInteger(conf.IfRtrDeadInterval),
-		IfPollInterval:    config.PositiveInteger(conf.IfPollInterval),
-		IfAuthKey:         conf.IfAuthKey,
-		IfAuthType:        config.AuthType(conf.IfAuthType),
+		IfIpAddress:              config.IpAddress(conf.IfIpAddress),
+		AddressLessIf:            config.InterfaceIndexOrZero(conf.AddressLessIf),
+		IfAreaId:                 config.AreaId(conf.IfAreaId),
+		IfRtrPriority:            config.DesignatedRouterPriority(conf.IfRtrPriority),
+		IfTransitDelay:           config.UpToMaxAge(conf.IfTransitDelay),
+		IfRetransInterval:        config.UpToMaxAge(conf.IfRetransInterval),
+		IfHelloInterval:          config.HelloRange(conf.IfHelloInterval),
+		IfRtrDeadInterval:        config.PositiveInteger(conf.IfRtrDeadInterval),
+		IfPollInterval:           config.PositiveInteger(conf.IfPollInterval),
+		IfAuthKey:                conf.IfAuthKey,
+		IfAuthType:               config.AuthType(conf.IfAuthType),
+		IfAuthKeyId:              uint8(conf.IfAuthKeyId),
+		IfTeMetric:               uint32(conf.IfTeMetric),
+		IfMaxBandwidth:           uint32(conf.IfMaxBandwidth),
+		IfMaxReservableBandwidth: uint32(conf.IfMaxReservableBandwidth),
+		IfAdminGroup:             uint32(conf.IfAdminGroup),
 	}
 
 	for index, ifName := range config.IfTypeList {
@@ -387,6 +396,27 @@ func (server *OSPFServer) AddLsdbEntry(entry LsdbSliceEnt) error {
 		}
 		lsaEnc = encodeASExternalLsa(lsa, lsaKey)
 		lsaMd = lsa.LsaMd
+	} else if entry.LSType == NSSALSA {
+		lsa, exist := lsDbEnt.NSSALsaMap[lsaKey]
+		if !exist {
+			return nil
+		}
+		lsaEnc = encodeASExternalLsa(lsa, lsaKey)
+		lsaMd = lsa.LsaMd
+	} else if entry.LSType == AreaOpaqueLSA {
+		lsa, exist := lsDbEnt.AreaOpaqueLsaMap[lsaKey]
+		if !exist {
+			return nil
+		}
+		lsaEnc = encodeOpaqueLsa(lsa, lsaKey)
+		lsaMd = lsa.LsaMd
+	} else if entry.LSType == ASOpaqueLSA {
+		lsa, exist := lsDbEnt.ASOpaqueLsaMap[lsaKey]
+		if !exist {
+			return nil
+		}
+		lsaEnc = encodeOpaqueLsa(lsa, lsaKey)
+		lsaMd = lsa.LsaMd
 	}
 	adv := convertByteToOctetString(lsaEnc[OSPF_LSA_HEADER_SIZE:])
 
@@ -286,14 +286,12 @@ func (server *OSPFServer) BuildDBDPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, dbdDataEnc...)
 	//server.logger.Info(fmt.Sprintln("OSPF DBD:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	copy(ospf[16:24], ent.IfAuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
 
 	var DstIP net.IP
 	var DstMAC net.HardwareAddr
 
-	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.pktlen
+	ipPktlen := IP_HEADER_MIN_LEN + len(ospf)
 	SrcIP := ent.IfIpAddr
 
 	if ent.IfType == config.NumberedP2P {
@@ -395,6 +393,9 @@ func (server *OSPFServer) ConstructAndSendDbdPacket(nbrKey NeighborConfKey,
 
 	server.logger.Debug(fmt.Sprintln("DBD: MTU ", ifMtu))
 	dbd_mdata.interface_mtu = uint16(ifMtu)
+	if intf, ok := server.IntfConfMap[nbrCon.intfConfKey]; ok && intf.IfAreaId != nil {
+		options = server.getAreaOptions(config.AreaId(convertIPInByteToString(intf.IfAreaId)), options)
+	}
 	dbd_mdata.options = options
 	dbd_mdata.dd_sequence_number = seq
 
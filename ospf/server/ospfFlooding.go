@@ -194,6 +194,10 @@ func (server *OSPFServer) processFloodMsg(lsa_data ospfFloodMsg) {
 				server.logger.Info(fmt.Sprintln("LSASELFLOOD:Dont flood on rx intf ", rxIntf.IfIpAddr))
 				continue // dont flood the LSA on the interface it is received.
 			}
+			if intf.IfType == config.VirtualLink &&
+				lsa_data.lsType == ASExternalLSA {
+				continue // AS external LSAs are not flooded over virtual links.
+			}
 			send := server.nbrFloodCheck(lsa_data.nbrKey, key, intf, lsa_data.lsType)
 			if send {
 				if lsa_data.pkt != nil {
@@ -262,6 +266,14 @@ func (server *OSPFServer) processFloodMsg(lsa_data ospfFloodMsg) {
 		server.logger.Info(fmt.Sprintln("LSAEXTFLOOD: Flood external routes for lsa key ", lsa_data.lsaKey))
 		server.processAsExternalLSAFlood(lsa_data.lsaKey)
 
+	case LSANSSAFLOOD: //flood NSSA LSA within the area
+		server.logger.Info(fmt.Sprintln("LSANSSAFLOOD: Flood NSSA lsa key ", lsa_data.lsaKey, " area ", lsa_data.areaId))
+		server.processNSSALSAFlood(lsa_data.areaId, lsa_data.lsaKey)
+
+	case LSAOPAQUEFLOOD: //flood opaque LSA on link, area or AS
+		server.logger.Info(fmt.Sprintln("LSAOPAQUEFLOOD: Flood opaque lsa key ", lsa_data.lsaKey, " area ", lsa_data.areaId))
+		server.processOpaqueLSAFlood(lsa_data)
+
 	case LSAAGE: // Flood aged LSAs
 		server.constructAndSendLsaAgeFlood()
 
@@ -476,6 +488,9 @@ func (server *OSPFServer) floodSummaryLsa(pkt []byte, areaid uint32) {
 func (server *OSPFServer) processAsExternalLSAFlood(lsakey LsaKey) {
 	areaId := convertAreaOrRouterIdUint32("0.0.0.0")
 	for ent, _ := range server.AreaConfMap {
+		if server.isStubArea(ent.AreaId) || server.isNssaArea(ent.AreaId) {
+			continue
+		}
 		areaId = convertAreaOrRouterIdUint32(string(ent.AreaId))
 	}
 	var lsaEncPkt []byte
@@ -520,6 +535,13 @@ func (server *OSPFServer) floodASExternalLsa(pkt []byte) {
 			server.logger.Info(fmt.Sprintln("ASBR: Dont flood AS external as area is stub ", areaId))
 			continue
 		}
+		if server.isNssaArea(areaId) {
+			server.logger.Info(fmt.Sprintln("ASBR: Dont flood AS external as area is NSSA ", areaId))
+			continue
+		}
+		if intf.IfType == config.VirtualLink {
+			continue
+		}
 		nbrMdata, ok := ospfIntfToNbrMap[key]
 		if ok && len(nbrMdata.nbrList) > 0 {
 			send_pkt := server.BuildLsaUpdPkt(key, intf, dstMac, dstIp, len(pkt), pkt)
@@ -528,3 +550,36 @@ func (server *OSPFServer) floodASExternalLsa(pkt []byte) {
 		}
 	}
 }
+
+/*
+@fn processNSSALSAFlood
+	Type-7 LSAs are flooded only within the NSSA
+*/
+func (server *OSPFServer) processNSSALSAFlood(areaId uint32, lsakey LsaKey) {
+	var lsaEncPkt []byte
+	LsaEnc := []byte{}
+
+	entry, ret := server.getNSSALsaFromLsdb(areaId, lsakey)
+	if ret == LsdbEntryNotFound {
+		server.logger.Info(fmt.Sprintln("NSSA: Lsa not found . Area",
+			areaId, " LSA key ", lsakey))
+		return
+	}
+	LsaEnc = encodeASExternalLsa(entry, lsakey)
+	pktLen := len(LsaEnc)
+	checksumOffset := uint16(14)
+	checkSum := computeFletcherChecksum(LsaEnc[2:], checksumOffset)
+	binary.BigEndian.PutUint16(LsaEnc[16:18], checkSum)
+	binary.BigEndian.PutUint16(LsaEnc[18:20], uint16(pktLen))
+
+	no_lsas := uint32(1)
+	lsas_enc := make([]byte, 4)
+	binary.BigEndian.PutUint32(lsas_enc, no_lsas)
+	lsaEncPkt = append(lsaEncPkt, lsas_enc...)
+	lsaEncPkt = append(lsaEncPkt, LsaEnc...)
+	lsid := convertUint32ToIPv4(lsakey.LSId)
+	adv_router := convertUint32ToIPv4(lsakey.AdvRouter)
+	server.logger.Info(fmt.Sprintln("NSSA: flood lsid ", lsid, " adv_router ", adv_router))
+	// area scoped flooding is same as for summary LSAs
+	server.floodSummaryLsa(lsaEncPkt, areaId)
+}
@@ -0,0 +1,701 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/garyburd/redigo/redis"
+	"l3/ospf/config"
+	"l3/rib/ribdCommonDefs"
+	"models/objects"
+	"net"
+	"os"
+	"os/signal"
+	"ospfd"
+	"strings"
+	"syscall"
+	"time"
+)
+
+/*
+RFC 3623 Graceful OSPF Restart.
+Before a planned restart the router floods a grace-LSA on every
+interface with neighbors and leaves the grace period in the DB so
+that RIBd keeps the OSPF routes. After the restart it neither
+originates LSAs nor touches RIBd until all of its old neighbors are
+full again or the grace period expires.
+Neighbors which receive the grace-LSA act as helpers and keep
+advertising the restarting router for the grace period.
+Unplanned restarts are not supported as nothing is left in the DB
+for them.
+*/
+
+const (
+	GraceLsaOpaqueType uint8  = 3
+	GraceLsaTlvPeriod  uint16 = 1
+	GraceLsaTlvReason  uint16 = 2
+	GraceLsaTlvIfAddr  uint16 = 3
+)
+
+const (
+	GrReasonUnknown    uint8 = 0
+	GrReasonSwRestart  uint8 = 1
+	GrReasonSwReload   uint8 = 2
+	GrReasonSwitchover uint8 = 3
+)
+
+// Used if RestartInterval is not configured (RFC 3623 B.1)
+const GrDefaultGracePeriod = 120
+
+type GraceLsa struct {
+	LsaMd       LsaMetadata
+	GracePeriod uint32
+	Reason      uint8
+	IfIpAddr    uint32
+}
+
+type GrHelperEntry struct {
+	AdvRouter   uint32
+	AreaId      uint32
+	GracePeriod uint32
+	Reason      uint8
+	StartTime   time.Time
+	GraceTimer  *time.Timer
+}
+
+/*
+    0                   1                   2                   3
+    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |            LS age             |     Options   |       9       |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |       3       |                    Opaque ID                  |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                     Advertising Router                        |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                     LS sequence number                        |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |         LS checksum           |             length            |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                                                               |
+   +                            TLVs                             -+
+   |                             ...                               |
+*/
+
+func encodeGraceLsa(lsa GraceLsa, lsakey LsaKey) []byte {
+	lsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 24)
+	gLsa := make([]byte, lsa.LsaMd.LSLen)
+	lsaHdr := encodeLsaHeader(lsa.LsaMd, lsakey)
+	copy(gLsa[0:20], lsaHdr)
+	binary.BigEndian.PutUint16(gLsa[20:22], GraceLsaTlvPeriod)
+	binary.BigEndian.PutUint16(gLsa[22:24], 4)
+	binary.BigEndian.PutUint32(gLsa[24:28], lsa.GracePeriod)
+	binary.BigEndian.PutUint16(gLsa[28:30], GraceLsaTlvReason)
+	binary.BigEndian.PutUint16(gLsa[30:32], 1)
+	gLsa[32] = lsa.Reason
+	binary.BigEndian.PutUint16(gLsa[36:38], GraceLsaTlvIfAddr)
+	binary.BigEndian.PutUint16(gLsa[38:40], 4)
+	binary.BigEndian.PutUint32(gLsa[40:44], lsa.IfIpAddr)
+	return gLsa
+}
+
+func decodeGraceLsa(data []byte, lsa *GraceLsa, lsakey *LsaKey) {
+	lsa.LsaMd.LSAge = binary.BigEndian.Uint16(data[0:2])
+	lsa.LsaMd.Options = uint8(data[2])
+	lsakey.LSType = uint8(data[3])
+	lsakey.LSId = binary.BigEndian.Uint32(data[4:8])
+	lsakey.AdvRouter = binary.BigEndian.Uint32(data[8:12])
+	lsa.LsaMd.LSSequenceNum = int(binary.BigEndian.Uint32(data[12:16]))
+	lsa.LsaMd.LSChecksum = binary.BigEndian.Uint16(data[16:18])
+	lsa.LsaMd.LSLen = binary.BigEndian.Uint16(data[18:20])
+	end := int(lsa.LsaMd.LSLen)
+	if end > len(data) {
+		end = len(data)
+	}
+	start := OSPF_LSA_HEADER_SIZE
+	for start+4 <= end {
+		tlvType := binary.BigEndian.Uint16(data[start : start+2])
+		tlvLen := int(binary.BigEndian.Uint16(data[start+2 : start+4]))
+		start = start + 4
+		if start+tlvLen > end {
+			return
+		}
+		switch tlvType {
+		case GraceLsaTlvPeriod:
+			if tlvLen == 4 {
+				lsa.GracePeriod = binary.BigEndian.Uint32(data[start : start+4])
+			}
+		case GraceLsaTlvReason:
+			if tlvLen == 1 {
+				lsa.Reason = data[start]
+			}
+		case GraceLsaTlvIfAddr:
+			if tlvLen == 4 {
+				lsa.IfIpAddr = binary.BigEndian.Uint32(data[start : start+4])
+			}
+		}
+		// TLV values are padded to 4 bytes
+		start = start + ((tlvLen+3)/4)*4
+	}
+}
+
+func (server *OSPFServer) getGracePeriod() uint32 {
+	if server.ospfGlobalConf.RestartInterval > 0 {
+		return uint32(server.ospfGlobalConf.RestartInterval)
+	}
+	return GrDefaultGracePeriod
+}
+
+/*@fn sendGraceLsa
+Grace-LSA has link local flooding scope. It is sent
+only on the interface it describes.
+*/
+func (server *OSPFServer) sendGraceLsa(key IntfConfKey, ent IntfConf, lsAge uint16, seqNum int) {
+	lsaKey := LsaKey{
+		LSType:    LocalOpaqueLSA,
+		LSId:      uint32(GraceLsaOpaqueType) << 24,
+		AdvRouter: convertIPv4ToUint32(server.ospfGlobalConf.RouterId),
+	}
+	areaId := config.AreaId(convertIPInByteToString(ent.IfAreaId))
+	lsa := GraceLsa{
+		GracePeriod: server.getGracePeriod(),
+		Reason:      GrReasonSwRestart,
+		IfIpAddr:    convertAreaOrRouterIdUint32(ent.IfIpAddr.String()),
+	}
+	lsa.LsaMd.LSAge = lsAge
+	lsa.LsaMd.Options = server.getAreaOptions(areaId, EOption)
+	lsa.LsaMd.LSSequenceNum = seqNum
+	LsaEnc := encodeGraceLsa(lsa, lsaKey)
+	checkSum := computeFletcherChecksum(LsaEnc[2:], uint16(14))
+	binary.BigEndian.PutUint16(LsaEnc[16:18], checkSum)
+
+	lsas_enc := make([]byte, 4)
+	binary.BigEndian.PutUint32(lsas_enc, uint32(1))
+	lsaEncPkt := append(lsas_enc, LsaEnc...)
+	dstMac := net.HardwareAddr{0x01, 0x00, 0x5e, 0x00, 0x00, 0x05}
+	dstIp := net.IP{224, 0, 0, 5}
+	pkt := server.BuildLsaUpdPkt(key, ent, dstMac, dstIp, len(lsaEncPkt), lsaEncPkt)
+	server.logger.Info(fmt.Sprintln("GR: Send grace LSA on ", ent.IfIpAddr, " age ", lsAge))
+	server.SendOspfPkt(key, pkt)
+}
+
+/*@fn processGraceLsa
+Helper mode (RFC 3623 3.1). Grace-LSAs are never installed in the
+LSDB. They only start or stop helping the advertising neighbor.
+*/
+func (server *OSPFServer) processGraceLsa(nbr OspfNeighborEntry, areaId uint32, data []byte) {
+	lsa := GraceLsa{}
+	lsaKey := LsaKey{}
+	decodeGraceLsa(data, &lsa, &lsaKey)
+	if lsaKey.LSId>>24 != uint32(GraceLsaOpaqueType) {
+		return
+	}
+	nbrKey, exist := server.getGrNbrKey(nbr.intfConfKey, lsaKey.AdvRouter, lsa.IfIpAddr)
+	if !exist {
+		server.logger.Info(fmt.Sprintln("GR: Grace LSA from unknown neighbor ", convertUint32ToIPv4(lsaKey.AdvRouter)))
+		return
+	}
+	if lsa.LsaMd.LSAge >= config.MaxAge {
+		server.exitGrHelper(nbrKey, config.Completed)
+		return
+	}
+	if uint32(lsa.LsaMd.LSAge) >= lsa.GracePeriod {
+		server.exitGrHelper(nbrKey, config.TimeedOut)
+		return
+	}
+	if server.isGrRestarting() {
+		server.logger.Info("GR: Can not help neighbor while restarting")
+		return
+	}
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	ent, helping := server.GrHelperMap[nbrKey]
+	if !helping {
+		nbrConf := server.NeighborConfigMap[nbrKey]
+		if nbrConf.OspfNbrState != config.NbrFull {
+			server.logger.Info(fmt.Sprintln("GR: Neighbor not full. Dont help ", nbrKey))
+			return
+		}
+		if len(ospfNeighborRetx_list[nbrKey]) > 0 {
+			// Topology has changed since the neighbor went down
+			server.logger.Info(fmt.Sprintln("GR: Pending retransmissions. Dont help ", nbrKey))
+			server.GrHelperExitMap[nbrKey] = config.TopologyChanged
+			return
+		}
+		ent.AdvRouter = lsaKey.AdvRouter
+		ent.AreaId = areaId
+		ent.StartTime = time.Now().Add(-time.Duration(lsa.LsaMd.LSAge) * time.Second)
+		server.logger.Info(fmt.Sprintln("GR: Start helping neighbor ", nbrKey, " grace period ", lsa.GracePeriod))
+	} else if ent.GraceTimer != nil {
+		ent.GraceTimer.Stop()
+	}
+	ent.GracePeriod = lsa.GracePeriod
+	ent.Reason = lsa.Reason
+	remaining := time.Duration(lsa.GracePeriod-uint32(lsa.LsaMd.LSAge)) * time.Second
+	ent.GraceTimer = time.AfterFunc(remaining, func() {
+		server.exitGrHelper(nbrKey, config.TimeedOut)
+	})
+	server.GrHelperMap[nbrKey] = ent
+	server.GrHelperExitMap[nbrKey] = config.InProgress
+}
+
+/*@fn getGrNbrKey
+Grace-LSA may be reflooded on the segment by the DR. Find the
+restarting neighbor using router id and interface address.
+*/
+func (server *OSPFServer) getGrNbrKey(intfKey IntfConfKey, rtrId uint32, ifIpAddr uint32) (NeighborConfKey, bool) {
+	for key, ent := range server.NeighborConfigMap {
+		if ent.intfConfKey != intfKey || ent.OspfNbrRtrId != rtrId {
+			continue
+		}
+		if ifIpAddr != 0 && convertAreaOrRouterIdUint32(ent.OspfNbrIPAddr.String()) != ifIpAddr {
+			continue
+		}
+		return key, true
+	}
+	return NeighborConfKey{}, false
+}
+
+func (server *OSPFServer) isGrHelping(nbrKey NeighborConfKey) bool {
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	_, exist := server.GrHelperMap[nbrKey]
+	return exist
+}
+
+/*@fn exitGrHelper
+Stop helping and regenerate router LSA of the area so the real
+state of the adjacency is advertised.
+*/
+func (server *OSPFServer) exitGrHelper(nbrKey NeighborConfKey, reason config.RestartExitReason) {
+	server.grMutex.Lock()
+	ent, exist := server.GrHelperMap[nbrKey]
+	if !exist {
+		server.grMutex.Unlock()
+		return
+	}
+	if ent.GraceTimer != nil {
+		ent.GraceTimer.Stop()
+	}
+	delete(server.GrHelperMap, nbrKey)
+	server.GrHelperExitMap[nbrKey] = reason
+	server.grMutex.Unlock()
+	server.logger.Info(fmt.Sprintln("GR: Stop helping neighbor ", nbrKey, " reason ", reason))
+	go server.sendAreaRouterLsaChange(ent.AreaId)
+}
+
+/*@fn checkGrHelperTopologyChange
+RFC 3623 3.2 : Changed LSA which would be flooded to the restarting
+router terminates the helper mode.
+*/
+func (server *OSPFServer) checkGrHelperTopologyChange(rxNbrKey NeighborConfKey, areaId uint32,
+	lsaKey LsaKey, data []byte) {
+	server.grMutex.Lock()
+	exitList := []NeighborConfKey{}
+	for nbrKey, ent := range server.GrHelperMap {
+		if nbrKey == rxNbrKey || lsaKey.AdvRouter == ent.AdvRouter {
+			continue
+		}
+		if lsaKey.LSType != ASExternalLSA && ent.AreaId != areaId {
+			continue
+		}
+		exitList = append(exitList, nbrKey)
+	}
+	server.grMutex.Unlock()
+	if len(exitList) == 0 || !server.lsaContentChanged(areaId, lsaKey, data) {
+		return
+	}
+	for _, nbrKey := range exitList {
+		server.exitGrHelper(nbrKey, config.TopologyChanged)
+	}
+}
+
+/*@fn lsaContentChanged
+Compare received LSA with the LSDB copy ignoring the header.
+Refreshes of the same LSA are not topology changes.
+*/
+func (server *OSPFServer) lsaContentChanged(areaId uint32, lsaKey LsaKey, data []byte) bool {
+	var lsaEnc []byte
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsaLen := int(binary.BigEndian.Uint16(data[18:20]))
+	if lsaLen < len(data) {
+		data = data[:lsaLen]
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return true
+	}
+	switch lsaKey.LSType {
+	case RouterLSA:
+		lsa, exist := lsDbEnt.RouterLsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeRouterLsa(lsa, lsaKey)
+	case NetworkLSA:
+		lsa, exist := lsDbEnt.NetworkLsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeNetworkLsa(lsa, lsaKey)
+	case Summary3LSA:
+		lsa, exist := lsDbEnt.Summary3LsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeSummaryLsa(lsa, lsaKey)
+	case Summary4LSA:
+		lsa, exist := lsDbEnt.Summary4LsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeSummaryLsa(lsa, lsaKey)
+	case ASExternalLSA:
+		lsa, exist := lsDbEnt.ASExternalLsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeASExternalLsa(lsa, lsaKey)
+	case NSSALSA:
+		lsa, exist := lsDbEnt.NSSALsaMap[lsaKey]
+		if !exist {
+			return true
+		}
+		lsaEnc = encodeASExternalLsa(lsa, lsaKey)
+	default:
+		return false
+	}
+	if len(lsaEnc) != len(data) {
+		return true
+	}
+	for i := OSPF_LSA_HEADER_SIZE; i < len(data); i++ {
+		if lsaEnc[i] != data[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (server *OSPFServer) getGrHelperState(nbrKey NeighborConfKey) (config.NbrRestartHelperStatus,
+	uint32, config.RestartExitReason) {
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	reason, exist := server.GrHelperExitMap[nbrKey]
+	if !exist {
+		reason = config.NoAttempt
+	}
+	ent, exist := server.GrHelperMap[nbrKey]
+	if !exist {
+		return config.NotHelping, 0, reason
+	}
+	age := uint32(time.Since(ent.StartTime).Seconds())
+	if age >= ent.GracePeriod {
+		return config.Helping, 0, reason
+	}
+	return config.Helping, ent.GracePeriod - age, reason
+}
+
+// Caller holds grMutex
+func (server *OSPFServer) grRestartInProgress() bool {
+	return server.ospfGlobalConf.RestartStatus == config.PlannedRestart ||
+		server.ospfGlobalConf.RestartStatus == config.UnplannedRestart
+}
+
+func (server *OSPFServer) isGrRestarting() bool {
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	return server.grRestartInProgress()
+}
+
+func (server *OSPFServer) getGrRestartAge() int32 {
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	if !server.grRestartInProgress() {
+		return 0
+	}
+	remaining := server.GrRestartEnd.Sub(time.Now()).Seconds()
+	if remaining < 0 {
+		return 0
+	}
+	return int32(remaining)
+}
+
+/*@fn grSigHandler
+Planned restart (RFC 3623 2.0). Ospfd is stopped with SIGHUP.
+*/
+func (server *OSPFServer) grSigHandler() {
+	sigChan := make(chan os.Signal, 1)
+	signalList := []os.Signal{syscall.SIGHUP}
+	signal.Notify(sigChan, signalList...)
+
+	for {
+		select {
+		case sig := <-sigChan:
+			switch sig {
+			case syscall.SIGHUP:
+				server.logger.Info("GR: Received SIGHUP signal")
+				if server.ospfGlobalConf.RestartSupport != config.None &&
+					server.ospfGlobalConf.AdminStat == config.Enabled {
+					server.startGrRestart()
+				}
+				if server.dbHdl != nil {
+					server.dbHdl.Disconnect()
+				}
+				server.logger.Info("Exiting!!!")
+				os.Exit(0)
+			default:
+			}
+		}
+	}
+}
+
+/*@fn startGrRestart
+Flood grace-LSA on all interfaces with neighbors and save the
+grace period and the full neighbors in the DB.
+*/
+func (server *OSPFServer) startGrRestart() {
+	nbrList := []string{}
+	for _, nbr := range server.NeighborConfigMap {
+		if nbr.OspfNbrState == config.NbrFull {
+			nbrList = append(nbrList, convertUint32ToIPv4(nbr.OspfNbrRtrId))
+		}
+	}
+	if len(nbrList) == 0 {
+		server.logger.Info("GR: No full neighbors. Graceful restart not needed.")
+		return
+	}
+	for key, ent := range server.IntfConfMap {
+		nbrMdata, ok := ospfIntfToNbrMap[key]
+		if !ok || len(nbrMdata.nbrList) == 0 {
+			continue
+		}
+		server.sendGraceLsa(key, ent, 0, InitialSequenceNumber)
+	}
+	grEnt := ribdCommonDefs.OspfGrDbEntry{
+		GracePeriodEnd: time.Now().Add(time.Duration(server.getGracePeriod()) * time.Second).Unix(),
+		RestartReason:  int(GrReasonSwRestart),
+		Neighbors:      strings.Join(nbrList, ","),
+	}
+	if server.dbHdl == nil {
+		server.logger.Err("GR: Nil db handle. Routes will not be preserved.")
+		return
+	}
+	_, err := server.dbHdl.Do("HMSET", redis.Args{}.Add(ribdCommonDefs.OSPF_GR_DB_KEY).AddFlat(&grEnt)...)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("GR: Failed to store graceful restart state in db ", err))
+		return
+	}
+	server.logger.Info(fmt.Sprintln("GR: Planned restart. Neighbors ", grEnt.Neighbors))
+}
+
+/*@fn initGrRestart
+Check DB for a planned restart in progress. Routes installed before
+the restart are read back from the DB. They are reconciled with the
+routing table when the restart is over.
+*/
+func (server *OSPFServer) initGrRestart() {
+	if server.dbHdl == nil {
+		return
+	}
+	var grEnt ribdCommonDefs.OspfGrDbEntry
+	val, err := redis.Values(server.dbHdl.Do("HGETALL", ribdCommonDefs.OSPF_GR_DB_KEY))
+	if err != nil || len(val) == 0 {
+		return
+	}
+	err = redis.ScanStruct(val, &grEnt)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("GR: Failed to read graceful restart state ", err))
+		server.delGrStateFromDB()
+		return
+	}
+	graceEnd := time.Unix(grEnt.GracePeriodEnd, 0)
+	if !graceEnd.After(time.Now()) || grEnt.Neighbors == "" {
+		server.logger.Info("GR: Grace period expired before restart.")
+		server.delGrStateFromDB()
+		return
+	}
+	server.grMutex.Lock()
+	server.ospfGlobalConf.RestartStatus = config.PlannedRestart
+	server.ospfGlobalConf.RestartExitReason = config.InProgress
+	server.GrRestartEnd = graceEnd
+	server.GrRestartNbrs = make(map[uint32]bool)
+	for _, rtrId := range strings.Split(grEnt.Neighbors, ",") {
+		server.GrRestartNbrs[convertAreaOrRouterIdUint32(rtrId)] = true
+	}
+	server.grMutex.Unlock()
+	server.GrPreservedRoutingTbl = server.readIPv4RoutesStateFromDB()
+	server.GrRestartTimer = time.AfterFunc(graceEnd.Sub(time.Now()), func() {
+		server.GrRestartExitCh <- config.TimeedOut
+	})
+	server.logger.Info(fmt.Sprintln("GR: Graceful restart in progress. Neighbors ", grEnt.Neighbors,
+		" preserved routes ", len(server.GrPreservedRoutingTbl)))
+}
+
+func (server *OSPFServer) delGrStateFromDB() {
+	if server.dbHdl == nil {
+		return
+	}
+	_, err := server.dbHdl.Do("DEL", ribdCommonDefs.OSPF_GR_DB_KEY)
+	if err != nil {
+		server.logger.Err(fmt.Sprintln("GR: Failed to delete graceful restart state from db ", err))
+	}
+}
+
+func (server *OSPFServer) readIPv4RoutesStateFromDB() map[RoutingTblEntryKey]GlobalRoutingTblEntry {
+	routeTbl := make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
+	var dbObj objects.OspfIPv4RouteState
+	objList, err := server.dbHdl.GetAllObjFromDb(dbObj)
+	if err != nil {
+		server.logger.Err("DB query failed for OspfIPv4RouteState")
+		return routeTbl
+	}
+	for idx := 0; idx < len(objList); idx++ {
+		obj := ospfd.NewOspfIPv4RouteState()
+		dbObject := objList[idx].(objects.OspfIPv4RouteState)
+		objects.ConvertospfdOspfIPv4RouteStateObjToThrift(&dbObject, obj)
+		rKey := RoutingTblEntryKey{
+			DestId:   convertAreaOrRouterIdUint32(obj.DestId),
+			AddrMask: convertAreaOrRouterIdUint32(obj.AddrMask),
+			DestType: Network,
+		}
+		var rEnt GlobalRoutingTblEntry
+		rEnt.AreaId = convertAreaOrRouterIdUint32(obj.AreaId)
+		rEnt.RoutingTblEnt.Cost = uint16(obj.Cost)
+		rEnt.RoutingTblEnt.NumOfPaths = int(obj.NumOfPaths)
+		rEnt.RoutingTblEnt.NextHops = make(map[NextHop]bool)
+		for _, nh := range obj.NextHops {
+			nextHop := NextHop{
+				IfIPAddr:  convertAreaOrRouterIdUint32(nh.IfIPAddr),
+				IfIdx:     uint32(nh.IfIdx),
+				NextHopIP: convertAreaOrRouterIdUint32(nh.NextHopIP),
+				AdvRtr:    convertAreaOrRouterIdUint32(nh.AdvRtr),
+			}
+			rEnt.RoutingTblEnt.NextHops[nextHop] = true
+		}
+		routeTbl[rKey] = rEnt
+	}
+	return routeTbl
+}
+
+/*@fn isGrRestartDone
+Restart is complete once all the neighbors from before the
+restart are full again (RFC 3623 2.3).
+*/
+func (server *OSPFServer) isGrRestartDone() bool {
+	server.grMutex.Lock()
+	defer server.grMutex.Unlock()
+	if !server.grRestartInProgress() {
+		return false
+	}
+	for rtrId, _ := range server.GrRestartNbrs {
+		full := false
+		for _, nbr := range server.NeighborConfigMap {
+			if nbr.OspfNbrRtrId == rtrId && nbr.OspfNbrState == config.NbrFull {
+				full = true
+				break
+			}
+		}
+		if !full {
+			return false
+		}
+	}
+	return true
+}
+
+/*@fn checkGrRestartRouterLsa
+Router LSA of a neighbor that no longer lists an adjacency to us
+means the neighbor stopped helping (RFC 3623 2.2).
+*/
+func (server *OSPFServer) checkGrRestartRouterLsa(lsa RouterLsa, lsaKey LsaKey) {
+	server.grMutex.Lock()
+	restarting := server.grRestartInProgress()
+	_, isNbr := server.GrRestartNbrs[lsaKey.AdvRouter]
+	server.grMutex.Unlock()
+	if !restarting || !isNbr {
+		return
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	for _, link := range lsa.LinkDetails {
+		switch link.LinkType {
+		case P2PLink, VirtualLink:
+			if link.LinkId == rtrId {
+				return
+			}
+		case TransitLink:
+			for _, ent := range server.IntfConfMap {
+				if convertIPv4ToUint32(ent.IfDRIp) == link.LinkId {
+					return
+				}
+			}
+		}
+	}
+	server.logger.Info(fmt.Sprintln("GR: Inconsistent router LSA from ", convertUint32ToIPv4(lsaKey.AdvRouter)))
+	go func() {
+		server.GrRestartExitCh <- config.TopologyChanged
+	}()
+}
+
+/*@fn exitGrRestart
+Flush grace-LSAs and originate LSAs for all the areas.
+SPF run after this reconciles the preserved routes with RIBd.
+*/
+func (server *OSPFServer) exitGrRestart(reason config.RestartExitReason) {
+	server.grMutex.Lock()
+	if !server.grRestartInProgress() {
+		server.grMutex.Unlock()
+		return
+	}
+	server.ospfGlobalConf.RestartStatus = config.NotRestarting
+	server.ospfGlobalConf.RestartExitReason = reason
+	server.GrRestartNbrs = nil
+	server.grMutex.Unlock()
+	if server.GrRestartTimer != nil {
+		server.GrRestartTimer.Stop()
+	}
+	server.logger.Info(fmt.Sprintln("GR: Graceful restart done. reason ", reason))
+	server.delGrStateFromDB()
+	doneAreas := make(map[uint32]bool)
+	for key, ent := range server.IntfConfMap {
+		nbrMdata, ok := ospfIntfToNbrMap[key]
+		if !ok || len(nbrMdata.nbrList) == 0 {
+			continue
+		}
+		server.sendGraceLsa(key, ent, config.MaxAge, InitialSequenceNumber+1)
+		msg := ospfNbrMdata{
+			intf:   key,
+			areaId: convertIPv4ToUint32(ent.IfAreaId),
+		}
+		server.processNeighborFullEvent(msg)
+		doneAreas[msg.areaId] = true
+	}
+	for key, _ := range server.AreaConfMap {
+		areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
+		if !doneAreas[areaId] {
+			server.generateRouterLSA(areaId)
+		}
+	}
+}
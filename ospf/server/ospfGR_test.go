@@ -0,0 +1,142 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"l3/ospf/config"
+	"net"
+	"testing"
+)
+
+func getGraceLsaPkt(advRouter uint32, ifIpAddr uint32, lsAge uint16) []byte {
+	lsaKey := LsaKey{
+		LSType:    LocalOpaqueLSA,
+		LSId:      uint32(GraceLsaOpaqueType) << 24,
+		AdvRouter: advRouter,
+	}
+	lsa := GraceLsa{
+		GracePeriod: 60,
+		Reason:      GrReasonSwRestart,
+		IfIpAddr:    ifIpAddr,
+	}
+	lsa.LsaMd.LSAge = lsAge
+	lsa.LsaMd.Options = EOption
+	lsa.LsaMd.LSSequenceNum = InitialSequenceNumber
+	lsaEnc := encodeGraceLsa(lsa, lsaKey)
+	checkSum := computeFletcherChecksum(lsaEnc[2:], uint16(14))
+	binary.BigEndian.PutUint16(lsaEnc[16:18], checkSum)
+	return lsaEnc
+}
+
+func TestOspfGraceLsaCodec(t *testing.T) {
+	advRouter := convertAreaOrRouterIdUint32("2.2.2.2")
+	ifIpAddr := convertAreaOrRouterIdUint32("10.1.1.2")
+	lsaEnc := getGraceLsaPkt(advRouter, ifIpAddr, 5)
+	if !validateChecksum(lsaEnc) {
+		t.Fatal("Grace LSA checksum is not valid")
+	}
+	lsa := GraceLsa{}
+	lsaKey := LsaKey{}
+	decodeGraceLsa(lsaEnc, &lsa, &lsaKey)
+	if lsaKey.LSType != LocalOpaqueLSA || lsaKey.LSId>>24 != uint32(GraceLsaOpaqueType) ||
+		lsaKey.AdvRouter != advRouter {
+		t.Fatal("Grace LSA key mismatch", lsaKey)
+	}
+	if lsa.GracePeriod != 60 || lsa.Reason != GrReasonSwRestart ||
+		lsa.IfIpAddr != ifIpAddr || lsa.LsaMd.LSAge != 5 {
+		t.Fatal("Grace LSA decode mismatch", lsa)
+	}
+}
+
+func TestOspfGrHelper(t *testing.T) {
+	server := getServerObject()
+	server.initOspfGlobalConfDefault()
+	server.ospfGlobalConf.RouterId = []byte{1, 1, 1, 1}
+	areaId := convertAreaOrRouterIdUint32("0.0.0.0")
+	server.initLSDatabase(areaId)
+	intfKey := IntfConfKey{
+		IPAddr:  config.IpAddress("10.1.1.1"),
+		IntfIdx: config.InterfaceIndexOrZero(1),
+	}
+	nbrKey := NeighborConfKey{
+		IPAddr:  config.IpAddress("10.1.1.2"),
+		IntfIdx: intfKey.IntfIdx,
+	}
+	nbrRtrId := convertAreaOrRouterIdUint32("2.2.2.2")
+	nbr := OspfNeighborEntry{
+		OspfNbrRtrId:  nbrRtrId,
+		OspfNbrIPAddr: net.IP{10, 1, 1, 2},
+		intfConfKey:   intfKey,
+		OspfNbrState:  config.NbrFull,
+	}
+	server.NeighborConfigMap[nbrKey] = nbr
+
+	server.processGraceLsa(nbr, areaId, getGraceLsaPkt(nbrRtrId, convertAreaOrRouterIdUint32("10.1.1.2"), 5))
+	if !server.isGrHelping(nbrKey) {
+		t.Fatal("Helper mode is not started for full neighbor")
+	}
+	status, age, reason := server.getGrHelperState(nbrKey)
+	if status != config.Helping || age == 0 || age > 55 || reason != config.InProgress {
+		t.Fatal("Helper state mismatch", status, age, reason)
+	}
+
+	// Refresh of an unchanged LSA is not a topology change
+	peer := convertAreaOrRouterIdUint32("3.3.3.3")
+	rlsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      peer,
+		AdvRouter: peer,
+	}
+	rlsa := RouterLsa{}
+	rlsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4)
+	lsDbEnt := server.AreaLsdb[LsdbKey{AreaId: areaId}]
+	lsDbEnt.RouterLsaMap[rlsaKey] = rlsa
+	otherNbrKey := NeighborConfKey{
+		IPAddr:  config.IpAddress("10.1.1.3"),
+		IntfIdx: intfKey.IntfIdx,
+	}
+	server.checkGrHelperTopologyChange(otherNbrKey, areaId, rlsaKey, encodeRouterLsa(rlsa, rlsaKey))
+	if !server.isGrHelping(nbrKey) {
+		t.Fatal("LSA refresh should not stop helper mode")
+	}
+	rlsa.BitB = true
+	server.checkGrHelperTopologyChange(otherNbrKey, areaId, rlsaKey, encodeRouterLsa(rlsa, rlsaKey))
+	if server.isGrHelping(nbrKey) {
+		t.Fatal("Changed LSA should stop helper mode")
+	}
+	if _, _, reason = server.getGrHelperState(nbrKey); reason != config.TopologyChanged {
+		t.Fatal("Helper exit reason should be topology change", reason)
+	}
+
+	server.processGraceLsa(nbr, areaId, getGraceLsaPkt(nbrRtrId, 0, 5))
+	if !server.isGrHelping(nbrKey) {
+		t.Fatal("Helper mode is not started again")
+	}
+	server.processGraceLsa(nbr, areaId, getGraceLsaPkt(nbrRtrId, 0, config.MaxAge))
+	status, _, reason = server.getGrHelperState(nbrKey)
+	if status != config.NotHelping || reason != config.Completed {
+		t.Fatal("Flushed grace LSA should complete helper mode", status, reason)
+	}
+}
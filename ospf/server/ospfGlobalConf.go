@@ -42,6 +42,9 @@ type GlobalConf struct {
 	RestartSupport           config.RestartSupport
 	RestartInterval          int32
 	RestartStrictLsaChecking bool
+	SpfInitialDelay          int32 // msec
+	SpfHoldTime              int32 // msec
+	SpfMaxWaitTime           int32 // msec
 	StubRouterAdvertisement  config.AdvertiseAction
 	Version                  uint8
 	AreaBdrRtrStatus         bool
@@ -74,6 +77,10 @@ func (server *OSPFServer) updateGlobalConf(gConf config.GlobalConf) {
 	server.ospfGlobalConf.RestartSupport = gConf.RestartSupport
 	server.ospfGlobalConf.RestartInterval = gConf.RestartInterval
 	server.ospfGlobalConf.ReferenceBandwidth = uint32(gConf.ReferenceBandwidth)
+	server.ospfGlobalConf.OpaqueLsaSupport = gConf.OpaqueLsaSupport
+	server.ospfGlobalConf.SpfInitialDelay = gConf.SpfInitialDelay
+	server.ospfGlobalConf.SpfHoldTime = gConf.SpfHoldTime
+	server.ospfGlobalConf.SpfMaxWaitTime = gConf.SpfMaxWaitTime
 	server.logger.Err("Global configuration updated")
 }
 
@@ -95,6 +102,9 @@ func (server *OSPFServer) initOspfGlobalConfDefault() {
 	server.ospfGlobalConf.RestartSupport = config.None
 	server.ospfGlobalConf.RestartInterval = 0
 	server.ospfGlobalConf.RestartStrictLsaChecking = false
+	server.ospfGlobalConf.SpfInitialDelay = SPF_INITIAL_DELAY
+	server.ospfGlobalConf.SpfHoldTime = SPF_HOLD_TIME
+	server.ospfGlobalConf.SpfMaxWaitTime = SPF_MAX_WAIT_TIME
 	server.ospfGlobalConf.StubRouterAdvertisement = config.DoNotAdvertise
 	server.ospfGlobalConf.Version = uint8(OSPF_VERSION_2)
 	server.ospfGlobalConf.AreaBdrRtrStatus = false
@@ -135,6 +145,7 @@ func (server *OSPFServer) processGlobalConfig(gConf config.GlobalConf) error {
 	}
 
 	if server.ospfGlobalConf.AdminStat == config.Enabled {
+		server.stopVirtLinks()
 		server.nbrFSMCtrlCh <- false
 		server.neighborConfStopCh <- true
 		//server.NeighborListMap = nil
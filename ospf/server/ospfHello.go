@@ -102,11 +102,7 @@ func (server *OSPFServer) BuildHelloPkt(ent IntfConf) []byte {
 		return nil
 	}
 	areaId := config.AreaId(convertIPInByteToString(ent.IfAreaId))
-	isStub := server.isStubArea(areaId)
-	option := uint8(2)
-	if isStub {
-		option = uint8(0)
-	}
+	option := server.getAreaOptions(areaId, EOption)
 	helloData := OSPFHelloData{
 		netmask:             ent.IfNetmask,
 		helloInterval:       ent.IfHelloInterval,
@@ -144,11 +140,9 @@ func (server *OSPFServer) BuildHelloPkt(ent IntfConf) []byte {
 
 	ospf := append(ospfEncHdr, helloDataNbrEnc...)
 	//server.logger.Debug(fmt.Sprintln("ospf:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	copy(ospf[16:24], ent.IfAuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
 
-	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.pktlen
+	ipPktlen := IP_HEADER_MIN_LEN + len(ospf)
 	ipLayer := layers.IPv4{
 		Version:  uint8(4),
 		IHL:      uint8(IP_HEADER_MIN_LEN),
@@ -188,8 +182,9 @@ func (server *OSPFServer) processRxHelloPkt(data []byte, ospfHdrMd *OspfHdrMetad
 	}
 	decodeOspfHelloData(data, ospfHelloData)
 
-	//  Todo: Sec 10.5 RFC2328 Need to add check for Virtual links
-	if ent.IfType != config.NumberedP2P || ent.IfType != config.UnnumberedP2P {
+	// Sec 10.5 RFC2328 netmask is not checked on p2p networks and virtual links
+	if ent.IfType != config.NumberedP2P && ent.IfType != config.UnnumberedP2P &&
+		ent.IfType != config.VirtualLink {
 		if bytesEqual(ent.IfNetmask, ospfHelloData.netmask) == false {
 			server.logger.Debug(fmt.Sprintln("HELLO: Netmask mismatch. Int mask", ent.IfNetmask, " Hello mask ", ospfHelloData.netmask, " ip ", ipHdrMd.srcIP))
 			err := errors.New("Netmask mismatch")
@@ -213,6 +208,13 @@ func (server *OSPFServer) processRxHelloPkt(data []byte, ospfHdrMd *OspfHdrMetad
 			err := errors.New("External Routing Capability mismatch")
 			return err
 		}
+	} else if ent.IfAreaId != nil {
+		/* E-bit and N-bit must match area configuration (RFC 3101 2.4) */
+		areaOptions := server.getAreaOptions(config.AreaId(convertIPInByteToString(ent.IfAreaId)), EOption)
+		if (ospfHelloData.options^areaOptions)&(EOption|NPOption) != 0 {
+			err := errors.New("External Routing Capability mismatch")
+			return err
+		}
 	}
 
 	//Todo: Find whether one way or two way
@@ -349,10 +351,11 @@ func (server *OSPFServer) CreateAndSendHelloRecvdMsg(routerId uint32,
 	if ifType == config.Broadcast ||
 		ifType == config.Nbma ||
 		ifType == config.PointToMultipoint ||
-		ifType == config.NumberedP2P {
+		ifType == config.NumberedP2P ||
+		ifType == config.VirtualLink {
 		msg.NeighborIP = net.IPv4(ipHdrMd.srcIP[0], ipHdrMd.srcIP[1], ipHdrMd.srcIP[2], ipHdrMd.srcIP[3])
 		//copy(msg.NeighborIP, ipHdrMd.srcIP)
-	} else { //Check for Virtual Links and unnumbered p2p
+	} else { //Check for unnumbered p2p
 		msg.NeighborIP = net.IPv4(ospfHdrMd.routerId[0], ospfHdrMd.routerId[1], ospfHdrMd.routerId[2], ospfHdrMd.routerId[3])
 		//copy(msg.NeighborIP, ospfHdrMd.routerId)
 	}
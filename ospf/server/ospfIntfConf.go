@@ -93,9 +93,13 @@ type IntfConf struct {
 	IfMulticastForwarding config.MulticastForwarding
 	IfDemand              bool
 	IfAuthType            uint16
+	IfAuthKeyId           uint8
+	IfCryptoAlgo          CryptoAlgo
+	IfAuthState           *IntfAuthState
 	FSMCtrlCh             chan bool
 	FSMCtrlStatusCh       chan bool
 	HelloIntervalTicker   *time.Ticker
+	PollIntervalTicker    *time.Ticker
 	BackupSeenCh          chan BackupSeenMsg
 	NeighborMap           map[NeighborConfKey]NeighborData
 	NeighCreateCh         chan NeighCreateMsg
@@ -120,6 +124,11 @@ type IntfConf struct {
 	IfMtu          int32
 	IfCost         uint32
 	IfMetricTOSMap map[uint8]uint32 // Key: TOS Value, Value: TOS Metric
+	/* Traffic engineering (RFC 3630) */
+	IfTeMetric               uint32
+	IfMaxBandwidth           uint32 // kbps
+	IfMaxReservableBandwidth uint32 // kbps
+	IfAdminGroup             uint32
 }
 
 func (server *OSPFServer) initDefaultIntfConf(key IntfConfKey, ipIntfProp IPIntfProperty, ifType int) {
@@ -168,6 +177,9 @@ func (server *OSPFServer) initDefaultIntfConf(key IntfConfKey, ipIntfProp IPIntf
 		ent.IfMulticastForwarding = config.Blocked
 		ent.IfDemand = false
 		ent.IfAuthType = uint16(config.NoAuth)
+		ent.IfAuthKeyId = 0
+		ent.IfCryptoAlgo = CryptoAlgoMd5
+		ent.IfAuthState = newIntfAuthState()
 		ent.FSMCtrlCh = make(chan bool)
 		ent.FSMCtrlStatusCh = make(chan bool)
 		ent.BackupSeenCh = make(chan BackupSeenMsg)
@@ -178,6 +190,7 @@ func (server *OSPFServer) initDefaultIntfConf(key IntfConfKey, ipIntfProp IPIntf
 		//ent.WaitTimerExpired = make(chan bool)
 		ent.WaitTimer = nil
 		ent.HelloIntervalTicker = nil
+		ent.PollIntervalTicker = nil
 		ent.NeighborMap = make(map[NeighborConfKey]NeighborData)
 		if ifType == broadcast {
 			ent.IfNetmask = ipIntfProp.NetMask
@@ -393,13 +406,16 @@ func (server *OSPFServer) updateIPIntfConfMap(ifConf config.InterfaceConf) {
 		ent.IfHelloInterval = uint16(ifConf.IfHelloInterval)
 		ent.IfRtrDeadInterval = uint32(ifConf.IfRtrDeadInterval)
 		ent.IfPollInterval = ifConf.IfPollInterval
-		//authKey := convertAuthKey(string(ifConf.IfAuthKey))
-		//if authKey == nil {
-		//	server.logger.Err("Invalid authKey")
-		//	return
-		//}
-		//ent.IfAuthKey = authKey
-		ent.IfAuthType = uint16(ifConf.IfAuthType)
+		ent.IfAuthKey = getIntfAuthKey(ifConf.IfAuthType, ifConf.IfAuthKey)
+		ent.IfAuthType, ent.IfCryptoAlgo = getIntfAuthType(ifConf.IfAuthType)
+		ent.IfAuthKeyId = ifConf.IfAuthKeyId
+		ent.IfTeMetric = ifConf.IfTeMetric
+		ent.IfMaxBandwidth = ifConf.IfMaxBandwidth
+		ent.IfMaxReservableBandwidth = ifConf.IfMaxReservableBandwidth
+		ent.IfAdminGroup = ifConf.IfAdminGroup
+		if ent.IfAuthState == nil {
+			ent.IfAuthState = newIntfAuthState()
+		}
 		/* Re initiate the Interface State */
 		ent.IfDRIp = []byte{0, 0, 0, 0}
 		ent.IfBDRIp = []byte{0, 0, 0, 0}
@@ -424,6 +440,11 @@ func (server *OSPFServer) processIntfConfig(ifConf config.InterfaceConf) error {
 		err := errors.New("No such L3 interface exists")
 		return err
 	}
+	if ifConf.IfType == config.VirtualLink {
+		server.logger.Err("Virtual link interfaces are created by the virtual interface configuration")
+		err := errors.New("Invalid Configuration")
+		return err
+	}
 	if intfConfKey.IPAddr == "0.0.0.0" &&
 		ifConf.IfType == config.NumberedP2P || ifConf.IfType == config.UnnumberedP2P {
 		flag := false
@@ -474,16 +495,21 @@ func (server *OSPFServer) StartSendRecvPkts(intfConfKey IntfConfKey) {
 	ent, _ := server.IntfConfMap[intfConfKey]
 	helloInterval := time.Duration(ent.IfHelloInterval) * time.Second
 	ent.HelloIntervalTicker = time.NewTicker(helloInterval)
-	if ent.IfType == config.Broadcast {
+	if ent.IfType == config.Broadcast || ent.IfType == config.Nbma {
 		waitTime := time.Duration(ent.IfRtrDeadInterval) * time.Second
 		ent.WaitTimer = time.NewTimer(waitTime)
 	}
+	if ent.IfType == config.Nbma && ent.IfPollInterval > 0 {
+		pollInterval := time.Duration(ent.IfPollInterval) * time.Second
+		ent.PollIntervalTicker = time.NewTicker(pollInterval)
+	}
 	// rtrDeadInterval := time.Duration(ent.IfRtrDeadInterval * time.Second)
 	ent.NeighborMap = make(map[NeighborConfKey]NeighborData)
 	ent.IfEvents = ent.IfEvents + 1
-	if ent.IfType == config.Broadcast {
+	if ent.IfType == config.Broadcast || ent.IfType == config.Nbma {
 		ent.IfFSMState = config.Waiting
-	} else if ent.IfType == config.NumberedP2P || ent.IfType == config.UnnumberedP2P {
+	} else if ent.IfType == config.NumberedP2P || ent.IfType == config.UnnumberedP2P ||
+		ent.IfType == config.PointToMultipoint {
 		ent.IfFSMState = config.P2P
 	}
 	server.IntfConfMap[intfConfKey] = ent
@@ -45,9 +45,10 @@ func (server *OSPFServer) StartOspfIntfFSM(key IntfConfKey) {
 	server.logger.Info("Sending msg for router LSA generation")
 	server.IntfStateChangeCh <- msg
 
-	if ent.IfType == config.NumberedP2P || ent.IfType == config.UnnumberedP2P {
+	if ent.IfType == config.NumberedP2P || ent.IfType == config.UnnumberedP2P ||
+		ent.IfType == config.PointToMultipoint || ent.IfType == config.VirtualLink {
 		server.StartOspfP2PIntfFSM(key)
-	} else if ent.IfType == config.Broadcast {
+	} else if ent.IfType == config.Broadcast || ent.IfType == config.Nbma {
 		server.StartOspfBroadcastIntfFSM(key)
 	}
 }
@@ -125,9 +126,16 @@ func (server *OSPFServer) StartOspfBroadcastIntfFSM(key IntfConfKey) {
 	server.StartSendHelloPkt(key)
 	for {
 		ent, _ := server.IntfConfMap[key]
+		// Only NBMA interfaces poll the neighbors which are down
+		var pollCh <-chan time.Time
+		if ent.PollIntervalTicker != nil {
+			pollCh = ent.PollIntervalTicker.C
+		}
 		select {
 		case <-ent.HelloIntervalTicker.C:
 			server.StartSendHelloPkt(key)
+		case <-pollCh:
+			server.sendNbmaHelloPkt(key, true)
 		case <-ent.WaitTimer.C:
 			server.logger.Info("Wait timer expired")
 			eventInfo := "Wait time expired for "
@@ -188,11 +188,9 @@ func (server *OSPFServer) EncodeLSAReqPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaDataEnc...)
 	server.logger.Info(fmt.Sprintln("OSPF LSA REQ:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	copy(ospf[16:24], ent.IfAuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
 
-	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.pktlen
+	ipPktlen := IP_HEADER_MIN_LEN + len(ospf)
 	var dstIp net.IP
 	if ent.IfType == config.NumberedP2P {
 		dstIp = net.ParseIP(config.AllSPFRouters)
@@ -340,16 +338,14 @@ func (server *OSPFServer) BuildLsaUpdPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaUpdEnc...)
 	//server.logger.Info(fmt.Sprintln("OSPF LSA UPD:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	copy(ospf[16:24], ent.IfAuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
 
 	if ent.IfType == config.NumberedP2P {
 		dstIp = net.ParseIP(config.AllSPFRouters)
 		dstMAC, _ = net.ParseMAC(config.McastMAC)
 	}
 
-	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.pktlen
+	ipPktlen := IP_HEADER_MIN_LEN + len(ospf)
 	ipLayer := layers.IPv4{
 		Version:  uint8(4),
 		IHL:      uint8(IP_HEADER_MIN_LEN),
@@ -466,6 +462,8 @@ func (server *OSPFServer) DecodeLSAUpd(msg ospfNeighborLSAUpdMsg) {
 			//continue
 		}
 		lsa_key := NewLsaKey()
+		no_flood := false
+		lsop = LSASELFLOOD
 
 		switch lsa_header.LSType {
 		case RouterLSA:
@@ -474,6 +472,9 @@ func (server *OSPFServer) DecodeLSAUpd(msg ospfNeighborLSAUpdMsg) {
 
 			drlsa, ret := server.getRouterLsaFromLsdb(msg.areaId, *lsa_key)
 			discard, op = server.sanityCheckRouterLsa(*rlsa, drlsa, nbr, intf, ret, lsa_max_age)
+			if !discard {
+				server.checkGrRestartRouterLsa(*rlsa, *lsa_key)
+			}
 
 		case NetworkLSA:
 			nlsa := NewNetworkLsa()
@@ -495,6 +496,57 @@ func (server *OSPFServer) DecodeLSAUpd(msg ospfNeighborLSAUpdMsg) {
 			dalsa, ret := server.getASExternalLsaFromLsdb(msg.areaId, *lsa_key)
 			discard, op = server.sanityCheckASExternalLsa(*alsa, dalsa, nbr, intf, intf.IfAreaId, ret, lsa_max_age)
 
+		case NSSALSA:
+			nlsa := NewASExternalLsa()
+			decodeASExternalLsa(lsdb_msg.Data, nlsa, lsa_key)
+			dnlsa, ret := server.getNSSALsaFromLsdb(msg.areaId, *lsa_key)
+			discard, op = server.sanityCheckNSSALsa(*nlsa, dnlsa, nbr, intf, intf.IfAreaId, ret, lsa_max_age)
+
+		case LocalOpaqueLSA:
+			// Link local LSAs are never flooded beyond the link.
+			no_flood = true
+			if getOpaqueType(lsa_header.LinkId) == GraceLsaOpaqueType {
+				// Grace LSA is not added to LSDB.
+				server.processGraceLsa(nbr, msg.areaId, lsdb_msg.Data)
+				discard = true
+				break
+			}
+			if !server.ospfGlobalConf.OpaqueLsaSupport {
+				discard = true
+				break
+			}
+			olsa := NewOpaqueLsa()
+			decodeOpaqueLsa(lsdb_msg.Data, olsa, lsa_key)
+			dolsa, ret := server.getLinkOpaqueLsaFromLsdb(nbr.intfConfKey, *lsa_key)
+			discard, op = server.sanityCheckOpaqueLsa(*lsa_key, *olsa, dolsa, nbr, intf, ret, lsa_max_age)
+			lsdb_msg.IntfKey = nbr.intfConfKey
+
+		case AreaOpaqueLSA:
+			if !server.ospfGlobalConf.OpaqueLsaSupport {
+				// Not opaque capable. Neither installed nor flooded.
+				discard = true
+				no_flood = true
+				break
+			}
+			olsa := NewOpaqueLsa()
+			decodeOpaqueLsa(lsdb_msg.Data, olsa, lsa_key)
+			dolsa, ret := server.getAreaOpaqueLsaFromLsdb(msg.areaId, *lsa_key)
+			discard, op = server.sanityCheckOpaqueLsa(*lsa_key, *olsa, dolsa, nbr, intf, ret, lsa_max_age)
+
+		case ASOpaqueLSA:
+			if !server.ospfGlobalConf.OpaqueLsaSupport {
+				// Not opaque capable. Neither installed nor flooded.
+				discard = true
+				no_flood = true
+				break
+			}
+			olsa := NewOpaqueLsa()
+			decodeOpaqueLsa(lsdb_msg.Data, olsa, lsa_key)
+			dolsa, ret := server.getASOpaqueLsaFromLsdb(msg.areaId, *lsa_key)
+			discard, op = server.sanityCheckOpaqueLsa(*lsa_key, *olsa, dolsa, nbr, intf, ret, lsa_max_age)
+			// AS scope LSAs are flooded into all the non stub areas
+			lsop = LSAOPAQUEFLOOD
+
 		}
 		lsid := convertUint32ToIPv4(lsa_header.LinkId)
 		router_id := convertUint32ToIPv4(lsa_header.Adv_router)
@@ -507,6 +559,7 @@ func (server *OSPFServer) DecodeLSAUpd(msg ospfNeighborLSAUpdMsg) {
 		}
 
 		if !discard && !self_gen && op == FloodLsa {
+			server.checkGrHelperTopologyChange(msg.nbrKey, msg.areaId, *lsa_key, lsdb_msg.Data)
 			server.logger.Info(fmt.Sprintln("LSAUPD: add to lsdb lsid ", lsid, " router_id ", router_id, " lstype ", lsa_header.LSType))
 			lsdb_msg.MsgType = LsdbAdd
 			server.LsdbUpdateCh <- *lsdb_msg
@@ -518,11 +571,12 @@ func (server *OSPFServer) DecodeLSAUpd(msg ospfNeighborLSAUpdMsg) {
 			areaId: msg.areaId,
 			lsType: lsa_header.LSType,
 			linkid: lsa_header.LinkId,
+			lsaKey: *lsa_key,
 			lsOp:   lsop,
 		}
 		flood_pkt.pkt = make([]byte, end_index-index)
 		copy(flood_pkt.pkt, lsdb_msg.Data)
-		if lsop != LSASUMMARYFLOOD && !self_gen { // for ABR summary lsa is flooded after LSDB/SPF changes are done.
+		if lsop != LSASUMMARYFLOOD && !no_flood && !self_gen { // for ABR summary lsa is flooded after LSDB/SPF changes are done.
 			server.ospfNbrLsaUpdSendCh <- flood_pkt
 		}
 
@@ -665,7 +719,14 @@ func (server *OSPFServer) sanityCheckSummaryLsa(slsa SummaryLsa, dslsa SummaryLs
 func (server *OSPFServer) sanityCheckASExternalLsa(alsa ASExternalLsa, dalsa ASExternalLsa, nbr OspfNeighborEntry, intf IntfConf, areaid []byte, exist int, lsa_max_age bool) (discard bool, op uint8) {
 	discard = false
 	op = LsdbAdd
-	// TODO Reject this lsa if area is configured as stub area.
+	/* AS external LSAs are not flooded into stub areas and NSSAs */
+	if areaid != nil {
+		areaId := config.AreaId(convertIPInByteToString(areaid))
+		if server.isStubArea(areaId) || server.isNssaArea(areaId) {
+			server.logger.Info(fmt.Sprintln("LSAUPD: As external LSA Discard. Area doesnt accept external routes ", areaId))
+			return true, LsdbNoAction
+		}
+	}
 	send_ack := server.lsAgeCheck(nbr.intfConfKey, lsa_max_age, exist)
 	if send_ack {
 		op = LsdbNoAction
@@ -685,6 +746,63 @@ func (server *OSPFServer) sanityCheckASExternalLsa(alsa ASExternalLsa, dalsa ASE
 	return discard, op
 }
 
+/*@fn sanityCheckNSSALsa
+Type-7 LSAs are accepted only in NSSA (RFC 3101 2.5)
+*/
+func (server *OSPFServer) sanityCheckNSSALsa(nlsa ASExternalLsa, dnlsa ASExternalLsa, nbr OspfNeighborEntry, intf IntfConf, areaid []byte, exist int, lsa_max_age bool) (discard bool, op uint8) {
+	discard = false
+	op = LsdbAdd
+	if areaid == nil || !server.isNssaArea(config.AreaId(convertIPInByteToString(areaid))) {
+		server.logger.Info(fmt.Sprintln("LSAUPD: NSSA LSA Discard. Area is not NSSA", " nbr ", nbr))
+		return true, LsdbNoAction
+	}
+	send_ack := server.lsAgeCheck(nbr.intfConfKey, lsa_max_age, exist)
+	if send_ack {
+		op = LsdbNoAction
+		discard = true
+		server.logger.Info(fmt.Sprintln("LSAUPD: NSSA LSA Discard.", " nbr ", nbr))
+		return discard, op
+	} else {
+		isNew := server.validateLsaIsNew(nlsa.LsaMd, dnlsa.LsaMd)
+		if isNew {
+			op = FloodLsa
+			discard = false
+		} else {
+			discard = true
+			op = LsdbNoAction
+		}
+	}
+	return discard, op
+}
+
+func (server *OSPFServer) sanityCheckOpaqueLsa(lsaKey LsaKey, olsa OpaqueLsa, dolsa OpaqueLsa, nbr OspfNeighborEntry, intf IntfConf, exist int, lsa_max_age bool) (discard bool, op uint8) {
+	discard = false
+	op = LsdbAdd
+	areaId := config.AreaId(convertIPInByteToString(intf.IfAreaId))
+	if lsaKey.LSType == ASOpaqueLSA &&
+		(server.isStubArea(areaId) || server.isNssaArea(areaId)) {
+		server.logger.Info(fmt.Sprintln("LSAUPD: AS opaque LSA Discard. Area is stub or NSSA", " nbr ", nbr))
+		return true, LsdbNoAction
+	}
+	send_ack := server.lsAgeCheck(nbr.intfConfKey, lsa_max_age, exist)
+	if send_ack {
+		op = LsdbNoAction
+		discard = true
+		server.logger.Info(fmt.Sprintln("LSAUPD: Opaque LSA Discard.", " nbr ", nbr))
+		return discard, op
+	} else {
+		isNew := server.validateLsaIsNew(olsa.LsaMd, dolsa.LsaMd)
+		if isNew {
+			op = FloodLsa
+			discard = false
+		} else {
+			discard = true
+			op = LsdbNoAction
+		}
+	}
+	return discard, op
+}
+
 func validateChecksum(data []byte) bool {
 
 	csum := computeFletcherChecksum(data[2:], FLETCHER_CHECKSUM_VALIDATE)
@@ -770,11 +888,9 @@ func (server *OSPFServer) BuildLSAAckPkt(intfKey IntfConfKey, ent IntfConf,
 
 	ospf := append(ospfEncHdr, lsaAckEnc...)
 	//server.logger.Info(fmt.Sprintln("OSPF LSA ACK:", ospf))
-	csum := computeCheckSum(ospf)
-	binary.BigEndian.PutUint16(ospf[12:14], csum)
-	copy(ospf[16:24], ent.IfAuthKey)
+	ospf = server.encodeOspfAuth(ent, ospf)
 
-	ipPktlen := IP_HEADER_MIN_LEN + ospfHdr.pktlen
+	ipPktlen := IP_HEADER_MIN_LEN + len(ospf)
 	if ent.IfType == config.NumberedP2P {
 		dstIp = net.ParseIP(config.AllSPFRouters)
 		dstMAC, _ = net.ParseMAC(config.McastMAC)
@@ -999,6 +1115,25 @@ func (server *OSPFServer) generateLsaUpdUnicast(req ospfLSAReq, nbrKey NeighborC
 			server.logger.Info(fmt.Sprintln("LSAREQ: AS external lsa not fount. lsaid ",
 				req.link_state_id, " lstype ", lsa_key.LSType, " adv_router ", lsa_key.AdvRouter, " areaid ", areaid))
 		}
+	case NSSALSA:
+		dnlsa, ret := server.getNSSALsaFromLsdb(areaid, *lsa_key)
+		if ret == LsdbEntryFound {
+			lsa_pkt = encodeASExternalLsa(dnlsa, *lsa_key)
+			flood = true
+		} else {
+			server.logger.Info(fmt.Sprintln("LSAREQ: NSSA lsa not found. lsaid ",
+				req.link_state_id, " lstype ", lsa_key.LSType, " adv_router ", lsa_key.AdvRouter, " areaid ", areaid))
+		}
+
+	case LocalOpaqueLSA, AreaOpaqueLSA, ASOpaqueLSA:
+		dolsa, ret := server.getOpaqueLsaFromLsdb(areaid, nbrConf.intfConfKey, *lsa_key)
+		if ret == LsdbEntryFound {
+			lsa_pkt = encodeOpaqueLsa(dolsa, *lsa_key)
+			flood = true
+		} else {
+			server.logger.Info(fmt.Sprintln("LSAREQ: Opaque lsa not found. lsaid ",
+				req.link_state_id, " lstype ", lsa_key.LSType, " adv_router ", lsa_key.AdvRouter, " areaid ", areaid))
+		}
 	}
 	lsid := convertUint32ToIPv4(req.link_state_id)
 	router_id := convertUint32ToIPv4(req.adv_router_id)
@@ -1096,6 +1231,20 @@ func (server *OSPFServer) lsaAddCheck(lsaheader ospfLSAHeader,
 		dalsa, ret := server.getASExternalLsaFromLsdb(areaId, *lsa_key)
 		discard, op = server.sanityCheckASExternalLsa(*alsa, dalsa, nbr, intf, intf.IfAreaId, ret, lsa_max_age)
 
+	case NSSALSA:
+		nlsa := NewASExternalLsa()
+		dnlsa, ret := server.getNSSALsaFromLsdb(areaId, *lsa_key)
+		discard, op = server.sanityCheckNSSALsa(*nlsa, dnlsa, nbr, intf, intf.IfAreaId, ret, lsa_max_age)
+
+
+	case LocalOpaqueLSA, AreaOpaqueLSA, ASOpaqueLSA:
+		if !server.ospfGlobalConf.OpaqueLsaSupport {
+			return false
+		}
+		olsa := NewOpaqueLsa()
+		dolsa, ret := server.getOpaqueLsaFromLsdb(areaId, nbr.intfConfKey, *lsa_key)
+		discard, op = server.sanityCheckOpaqueLsa(*lsa_key, *olsa, dolsa, nbr, intf, ret, lsa_max_age)
+
 	}
 	if discard {
 		server.logger.Info(fmt.Sprintln("DBD: LSA is not added in the request list. Adv router ", adv_router,
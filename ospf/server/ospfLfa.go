@@ -0,0 +1,246 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/ospf/config"
+)
+
+/*
+IP fast reroute using loop free alternates (RFC 5286).
+After the SPF run of an area, SPF is run again rooted at
+each full neighbor. Neighbor N is a loop free alternate
+for destination D if
+	Distance_opt(N, D) < Distance_opt(N, S) + Distance_opt(S, D)
+Only intra area network routes are protected. Alternate is
+installed in RIBd as backup next hop so that forwarding can
+switch to it on link down or BFD down event without waiting
+for the route recalculation.
+*/
+
+const LFA_INFINITY uint32 = 0xffffffff
+
+type LfaNbr struct {
+	VKey    VertexKey
+	NextHop NextHop
+	Cost    uint32 // Cost of the link to the neighbor
+}
+
+type lfaAttach struct {
+	vKey VertexKey
+	cost uint32
+}
+
+/*@fn calcLfaDistance
+Dijkstra on the area graph rooted at root. Returns the
+shortest distance from root to every reachable vertex.
+*/
+func calcLfaDistance(graph map[VertexKey]Vertex, root VertexKey) map[VertexKey]uint32 {
+	dist := make(map[VertexKey]uint32)
+	done := make(map[VertexKey]bool)
+	if _, exist := graph[root]; !exist {
+		return dist
+	}
+	dist[root] = 0
+	for {
+		var vKey VertexKey
+		min := LFA_INFINITY
+		for key, d := range dist {
+			if done[key] || d >= min {
+				continue
+			}
+			vKey = key
+			min = d
+		}
+		if min == LFA_INFINITY {
+			break
+		}
+		done[vKey] = true
+		ent := graph[vKey]
+		for idx, nKey := range ent.NbrVertexKey {
+			if _, exist := graph[nKey]; !exist {
+				continue
+			}
+			d := min + uint32(ent.NbrVertexCost[idx])
+			old, exist := dist[nKey]
+			if !exist || d < old {
+				dist[nKey] = d
+			}
+		}
+	}
+	return dist
+}
+
+/* Vertices to which each network destination is attached */
+func getLfaPrefixAttach(graph map[VertexKey]Vertex, stubs map[VertexKey]StubVertex) map[RoutingTblEntryKey][]lfaAttach {
+	attachMap := make(map[RoutingTblEntryKey][]lfaAttach)
+	for vKey, ent := range graph {
+		if vKey.Type != TNetworkVertex || len(ent.NbrVertexKey) < 1 {
+			continue
+		}
+		addrMask := ent.LinkData[ent.NbrVertexKey[0]]
+		rKey := RoutingTblEntryKey{
+			DestType: Network,
+			AddrMask: addrMask,
+			DestId:   vKey.ID & addrMask,
+		}
+		attachMap[rKey] = append(attachMap[rKey], lfaAttach{vKey, 0})
+	}
+	for vKey, ent := range stubs {
+		rKey := RoutingTblEntryKey{
+			DestType: Network,
+			AddrMask: ent.LinkData,
+			DestId:   vKey.ID,
+		}
+		attachMap[rKey] = append(attachMap[rKey], lfaAttach{ent.NbrVertexKey, uint32(ent.NbrVertexCost)})
+	}
+	return attachMap
+}
+
+func getLfaPrefixDistance(dist map[VertexKey]uint32, attach []lfaAttach) uint32 {
+	min := LFA_INFINITY
+	for _, ent := range attach {
+		d, exist := dist[ent.vKey]
+		if !exist {
+			continue
+		}
+		if d+ent.cost < min {
+			min = d + ent.cost
+		}
+	}
+	return min
+}
+
+/*@fn findLfaNextHops
+Select the loop free alternate for every intra area network
+route. Neighbors which are primary next hops or which are
+reachable over the interface of a primary next hop are not
+considered as they would fail along with the primary path.
+Among the candidates the one with least total cost is chosen.
+*/
+func findLfaNextHops(graph map[VertexKey]Vertex, stubs map[VertexKey]StubVertex, rootVKey VertexKey,
+	nbrs []LfaNbr, routes map[RoutingTblEntryKey]RoutingTblEntry) map[RoutingTblEntryKey]map[NextHop]bool {
+	lfaMap := make(map[RoutingTblEntryKey]map[NextHop]bool)
+	if len(nbrs) < 2 {
+		return lfaMap
+	}
+	attachMap := getLfaPrefixAttach(graph, stubs)
+	rootDist := calcLfaDistance(graph, rootVKey)
+	nbrDist := make([]map[VertexKey]uint32, len(nbrs))
+	for idx, nbr := range nbrs {
+		nbrDist[idx] = calcLfaDistance(graph, nbr.VKey)
+	}
+	for rKey, rEnt := range routes {
+		if rKey.DestType != Network || rEnt.PathType != IntraArea ||
+			len(rEnt.NextHops) == 0 {
+			continue
+		}
+		attach, exist := attachMap[rKey]
+		if !exist {
+			continue
+		}
+		distSD := getLfaPrefixDistance(rootDist, attach)
+		if distSD == LFA_INFINITY {
+			continue
+		}
+		var lfa NextHop
+		lfaCost := LFA_INFINITY
+		for idx, nbr := range nbrs {
+			primary := false
+			for nextHop, _ := range rEnt.NextHops {
+				if nextHop.NextHopIP == nbr.NextHop.NextHopIP ||
+					nextHop.IfIPAddr == nbr.NextHop.IfIPAddr {
+					primary = true
+					break
+				}
+			}
+			if primary {
+				continue
+			}
+			distNS, exist := nbrDist[idx][rootVKey]
+			if !exist {
+				continue
+			}
+			distND := getLfaPrefixDistance(nbrDist[idx], attach)
+			if distND == LFA_INFINITY || distND >= distNS+distSD {
+				continue
+			}
+			if nbr.Cost+distND < lfaCost {
+				lfa = nbr.NextHop
+				lfaCost = nbr.Cost + distND
+			}
+		}
+		if lfaCost != LFA_INFINITY {
+			lfaMap[rKey] = map[NextHop]bool{lfa: true}
+		}
+	}
+	return lfaMap
+}
+
+/* Full neighbors of the area which are present in the area graph */
+func (server *OSPFServer) getLfaNbrs(areaId uint32, graph map[VertexKey]Vertex) []LfaNbr {
+	var nbrs []LfaNbr
+	for _, nbr := range server.NeighborConfigMap {
+		if nbr.OspfNbrState != config.NbrFull {
+			continue
+		}
+		intf, exist := server.IntfConfMap[nbr.intfConfKey]
+		if !exist || intf.IfType == config.VirtualLink ||
+			convertIPv4ToUint32(intf.IfAreaId) != areaId {
+			continue
+		}
+		vKey := VertexKey{
+			Type:   RouterVertex,
+			ID:     nbr.OspfNbrRtrId,
+			AdvRtr: nbr.OspfNbrRtrId,
+		}
+		if _, exist := graph[vKey]; !exist {
+			continue
+		}
+		nextHop := NextHop{
+			IfIPAddr:  convertIPv4ToUint32(intf.IfIpAddr.To4()),
+			IfIdx:     uint32(nbr.intfConfKey.IntfIdx),
+			NextHopIP: convertIPv4ToUint32(nbr.OspfNbrIPAddr.To4()),
+			AdvRtr:    nbr.OspfNbrRtrId,
+		}
+		nbrs = append(nbrs, LfaNbr{
+			VKey:    vKey,
+			NextHop: nextHop,
+			Cost:    intf.IfCost,
+		})
+	}
+	return nbrs
+}
+
+/*@fn calcAreaLfa
+Compute loop free alternates for the intra area routes
+of the last SPF run of the area.
+*/
+func (server *OSPFServer) calcAreaLfa(areaId uint32, cache AreaSpfCache) map[RoutingTblEntryKey]map[NextHop]bool {
+	nbrs := server.getLfaNbrs(areaId, cache.AreaGraph)
+	lfaMap := findLfaNextHops(cache.AreaGraph, cache.AreaStubs, cache.RootVKey, nbrs, cache.IntraRoutes)
+	server.logger.Info(fmt.Sprintln("LFA: Area", convertUint32ToIPv4(areaId), "neighbors", len(nbrs), "protected routes", len(lfaMap)))
+	return lfaMap
+}
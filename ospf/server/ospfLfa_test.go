@@ -0,0 +1,112 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+package server
+
+import (
+	"testing"
+)
+
+func TestOspfLfa(t *testing.T) {
+	rtrKey := func(id string) VertexKey {
+		rtrId := convertAreaOrRouterIdUint32(id)
+		return VertexKey{
+			Type:   RouterVertex,
+			ID:     rtrId,
+			AdvRtr: rtrId,
+		}
+	}
+	nbrHop := func(ifIp string, nbrIp string, rtr VertexKey) NextHop {
+		return NextHop{
+			IfIPAddr:  convertAreaOrRouterIdUint32(ifIp),
+			NextHopIP: convertAreaOrRouterIdUint32(nbrIp),
+			AdvRtr:    rtr.ID,
+		}
+	}
+	/*
+		S -10- A -5- B -10- S, C -10- S
+		Stub 20.1.1.0/24 on A with cost 1
+	*/
+	s := rtrKey("1.1.1.1")
+	a := rtrKey("2.2.2.2")
+	b := rtrKey("3.3.3.3")
+	c := rtrKey("4.4.4.4")
+	graph := map[VertexKey]Vertex{
+		s: Vertex{NbrVertexKey: []VertexKey{a, b, c}, NbrVertexCost: []uint16{10, 10, 10}},
+		a: Vertex{NbrVertexKey: []VertexKey{s, b}, NbrVertexCost: []uint16{10, 5}},
+		b: Vertex{NbrVertexKey: []VertexKey{s, a}, NbrVertexCost: []uint16{10, 5}},
+		c: Vertex{NbrVertexKey: []VertexKey{s}, NbrVertexCost: []uint16{10}},
+	}
+	mask := convertAreaOrRouterIdUint32("255.255.255.0")
+	stubKey := VertexKey{
+		Type:   SNetworkVertex,
+		ID:     convertAreaOrRouterIdUint32("20.1.1.0"),
+		AdvRtr: a.ID,
+	}
+	stubs := map[VertexKey]StubVertex{
+		stubKey: StubVertex{NbrVertexKey: a, NbrVertexCost: 1, LinkData: mask},
+	}
+
+	dist := calcLfaDistance(graph, s)
+	if dist[a] != 10 || dist[b] != 10 || dist[c] != 10 {
+		t.Fatal("Wrong SPF distance", dist)
+	}
+	dist = calcLfaDistance(graph, c)
+	if dist[b] != 20 || dist[a] != 20 {
+		t.Fatal("Wrong SPF distance from neighbor", dist)
+	}
+
+	nbrs := []LfaNbr{
+		LfaNbr{VKey: a, NextHop: nbrHop("10.0.1.1", "10.0.1.2", a), Cost: 10},
+		LfaNbr{VKey: b, NextHop: nbrHop("10.0.2.1", "10.0.2.2", b), Cost: 10},
+		LfaNbr{VKey: c, NextHop: nbrHop("10.0.3.1", "10.0.3.2", c), Cost: 10},
+	}
+	rKey := RoutingTblEntryKey{
+		DestType: Network,
+		AddrMask: mask,
+		DestId:   stubKey.ID,
+	}
+	primary := NextHop{
+		IfIPAddr:  convertAreaOrRouterIdUint32("10.0.1.1"),
+		NextHopIP: convertAreaOrRouterIdUint32("10.0.1.2"),
+	}
+	routes := map[RoutingTblEntryKey]RoutingTblEntry{
+		rKey: RoutingTblEntry{
+			PathType: IntraArea,
+			Cost:     11,
+			NextHops: map[NextHop]bool{primary: true},
+		},
+	}
+
+	// B is loop free, C would loop back through S
+	lfaMap := findLfaNextHops(graph, stubs, s, nbrs, routes)
+	lfa, exist := lfaMap[rKey]
+	if !exist || len(lfa) != 1 || !lfa[nbrs[1].NextHop] {
+		t.Fatal("Expected neighbor B as loop free alternate", lfaMap)
+	}
+
+	// Without B there is no alternate
+	lfaMap = findLfaNextHops(graph, stubs, s, []LfaNbr{nbrs[0], nbrs[2]}, routes)
+	if _, exist := lfaMap[rKey]; exist {
+		t.Fatal("Neighbor C must not be loop free alternate", lfaMap)
+	}
+}
@@ -30,11 +30,15 @@ import (
 )
 
 const (
-	RouterLSA     uint8 = 1
-	NetworkLSA    uint8 = 2
-	Summary3LSA   uint8 = 3
-	Summary4LSA   uint8 = 4
-	ASExternalLSA uint8 = 5
+	RouterLSA      uint8 = 1
+	NetworkLSA     uint8 = 2
+	Summary3LSA    uint8 = 3
+	Summary4LSA    uint8 = 4
+	ASExternalLSA  uint8 = 5
+	NSSALSA        uint8 = 7
+	LocalOpaqueLSA uint8 = 9
+	AreaOpaqueLSA  uint8 = 10
+	ASOpaqueLSA    uint8 = 11
 )
 
 type LsaKey struct {
@@ -90,6 +94,7 @@ type RouterLsa struct {
 	BitV        bool         /* V Bit */
 	BitE        bool         /* Bit E */
 	BitB        bool         /* Bit B */
+	BitNt       bool         /* Bit Nt (RFC 3101) */
 	NumOfLinks  uint16       /* NumOfLinks */
 	LinkDetails []LinkDetail /* List of LinkDetails */
 }
@@ -134,7 +139,7 @@ type ASExtTOSDetail struct {
 	TOSExtRouteTag uint32
 }
 
-/* LS Type 5 */
+/* LS Type 5 or 7 */
 type ASExternalLsa struct {
 	LsaMd           LsaMetadata
 	Netmask         uint32 /* Network Mask */
@@ -149,12 +154,25 @@ func NewASExternalLsa() *ASExternalLsa {
 	return &ASExternalLsa{}
 }
 
+/* LS Type 9, 10 or 11 (RFC 5250) */
+type OpaqueLsa struct {
+	LsaMd LsaMetadata
+	Data  []byte /* Opaque Information */
+}
+
+func NewOpaqueLsa() *OpaqueLsa {
+	return &OpaqueLsa{}
+}
+
 type LSDatabase struct {
 	RouterLsaMap     map[LsaKey]RouterLsa
 	NetworkLsaMap    map[LsaKey]NetworkLsa
 	Summary3LsaMap   map[LsaKey]SummaryLsa
 	Summary4LsaMap   map[LsaKey]SummaryLsa
 	ASExternalLsaMap map[LsaKey]ASExternalLsa
+	NSSALsaMap       map[LsaKey]ASExternalLsa
+	AreaOpaqueLsaMap map[LsaKey]OpaqueLsa
+	ASOpaqueLsaMap   map[LsaKey]OpaqueLsa
 }
 
 type maxAgeLsaMsg struct {
@@ -232,7 +250,7 @@ func decodeLsaHeader(data []byte, header *LsaHeader) {
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |         LS checksum           |             length            |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-   |    0    |V|E|B|        0      |            # links            |
+   |   0   |Nt|V|E|B|       0      |            # links            |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |                          Link ID                              |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
@@ -275,6 +293,11 @@ func decodeRouterLsa(data []byte, lsa *RouterLsa, lsakey *LsaKey) {
 	} else {
 		lsa.BitB = false
 	}
+	if data[20]&0x10 != 0 {
+		lsa.BitNt = true
+	} else {
+		lsa.BitNt = false
+	}
 	lsa.NumOfLinks = binary.BigEndian.Uint16(data[22:24])
 	lsa.LinkDetails = make([]LinkDetail, lsa.NumOfLinks)
 	start := 24
@@ -341,6 +364,9 @@ func encodeRouterLsa(lsa RouterLsa, lsakey LsaKey) []byte {
 	if lsa.BitB == true {
 		val = val | 1
 	}
+	if lsa.BitNt == true {
+		val = val | 1<<4
+	}
 	rtrLsa[20] = val
 	binary.BigEndian.PutUint16(rtrLsa[22:24], lsa.NumOfLinks)
 
@@ -496,7 +522,7 @@ func decodeSummaryLsa(data []byte, lsa *SummaryLsa, lsakey *LsaKey) {
     0                   1                   2                   3
     0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-   |            LS age             |     Options   |      5        |
+   |            LS age             |     Options   |    5 or 7     |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
    |                        Link State ID                          |
    +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
@@ -600,6 +626,70 @@ func decodeASExternalLsa(data []byte, lsa *ASExternalLsa, lsakey *LsaKey) {
 	}
 }
 
+/*
+    0                   1                   2                   3
+    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |            LS age             |     Options   |   9, 10 or 11 |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |  Opaque Type  |               Opaque ID                       |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                      Advertising Router                       |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                      LS Sequence Number                       |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |         LS checksum           |           Length              |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                                                               |
+   +                                                               +
+   |                      Opaque Information                       |
+   +                                                               +
+   |                              ...                              |
+*/
+
+func encodeOpaqueLsa(lsa OpaqueLsa, lsakey LsaKey) []byte {
+	lsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + len(lsa.Data))
+	oLsa := make([]byte, lsa.LsaMd.LSLen)
+	lsaHdr := encodeLsaHeader(lsa.LsaMd, lsakey)
+	copy(oLsa[0:20], lsaHdr)
+	copy(oLsa[20:], lsa.Data)
+	return oLsa
+}
+
+func decodeOpaqueLsa(data []byte, lsa *OpaqueLsa, lsakey *LsaKey) {
+	lsa.LsaMd.LSAge = binary.BigEndian.Uint16(data[0:2])
+	lsa.LsaMd.Options = uint8(data[2])
+	lsakey.LSType = uint8(data[3])
+	lsakey.LSId = binary.BigEndian.Uint32(data[4:8])
+	lsakey.AdvRouter = binary.BigEndian.Uint32(data[8:12])
+	lsa.LsaMd.LSSequenceNum = int(binary.BigEndian.Uint32(data[12:16]))
+	lsa.LsaMd.LSChecksum = binary.BigEndian.Uint16(data[16:18])
+	lsa.LsaMd.LSLen = binary.BigEndian.Uint16(data[18:20])
+	end := int(lsa.LsaMd.LSLen)
+	if end > len(data) || end < OSPF_LSA_HEADER_SIZE {
+		end = len(data)
+	}
+	lsa.Data = make([]byte, end-OSPF_LSA_HEADER_SIZE)
+	copy(lsa.Data, data[OSPF_LSA_HEADER_SIZE:end])
+}
+
+/* Link State Id of opaque LSAs is made of 8 bit type and 24 bit id */
+func getOpaqueType(lsId uint32) uint8 {
+	return uint8(lsId >> 24)
+}
+
+func getOpaqueId(lsId uint32) uint32 {
+	return lsId & 0x00ffffff
+}
+
+func getOpaqueLsId(opaqueType uint8, opaqueId uint32) uint32 {
+	return uint32(opaqueType)<<24 | (opaqueId & 0x00ffffff)
+}
+
+func isOpaqueLsa(lsType uint8) bool {
+	return lsType == LocalOpaqueLSA || lsType == AreaOpaqueLSA || lsType == ASOpaqueLSA
+}
+
 func (server *OSPFServer) getRouterLsaFromLsdb(areaId uint32, lsaKey LsaKey) (lsa RouterLsa, retVal int) {
 	//server.logger.Info(fmt.Sprintln("1. LS DB:", server.AreaLsdb))
 	//server.logger.Info(fmt.Sprintln("1. areaId:", areaId, "lsaKey:", lsaKey))
@@ -668,6 +758,42 @@ func (server *OSPFServer) getASExternalLsaFromLsdb(areaId uint32, lsaKey LsaKey)
 	return lsa, LsdbEntryFound
 }
 
+func (server *OSPFServer) getNSSALsaFromLsdb(areaId uint32, lsaKey LsaKey) (lsa ASExternalLsa, retVal int) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, _ := server.AreaLsdb[lsdbKey]
+	lsa, exist := lsDbEnt.NSSALsaMap[lsaKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	return lsa, LsdbEntryFound
+}
+
+func (server *OSPFServer) getAreaOpaqueLsaFromLsdb(areaId uint32, lsaKey LsaKey) (lsa OpaqueLsa, retVal int) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, _ := server.AreaLsdb[lsdbKey]
+	lsa, exist := lsDbEnt.AreaOpaqueLsaMap[lsaKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	return lsa, LsdbEntryFound
+}
+
+func (server *OSPFServer) getASOpaqueLsaFromLsdb(areaId uint32, lsaKey LsaKey) (lsa OpaqueLsa, retVal int) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, _ := server.AreaLsdb[lsdbKey]
+	lsa, exist := lsDbEnt.ASOpaqueLsaMap[lsaKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	return lsa, LsdbEntryFound
+}
+
 func (server *OSPFServer) processMaxAgeLSA(lsdbKey LsdbKey, lsdbEnt LSDatabase) {
 	flood_lsa := false
 	/* Router LSA */
@@ -727,6 +853,54 @@ func (server *OSPFServer) processMaxAgeLSA(lsdbKey LsdbKey, lsdbEnt LSDatabase)
 			lsdbEnt.ASExternalLsaMap[lsakey] = lsa_ex
 		}
 	}
+	/* NSSA LSA */
+	for lsakey, lsa_nssa := range lsdbEnt.NSSALsaMap {
+		if lsa_nssa.LsaMd.LSAge == config.MaxAge {
+			// add to flood list
+			lsa_pkt := encodeASExternalLsa(lsa_nssa, lsakey)
+			maxAgeLsaMap[lsakey] = lsa_pkt
+			// delete LSA
+			delete(lsdbEnt.NSSALsaMap, lsakey)
+			advRouter := convertUint32ToIPv4(lsakey.AdvRouter)
+			lsid := convertUint32ToIPv4(lsakey.LSId)
+			server.logger.Info(fmt.Sprintln("DELETE: Max age reached. adv_router ",
+				advRouter, " lstype ", lsakey.LSType, " lsid ", lsid))
+			flood_lsa = true
+
+		} else {
+			lsa_nssa.LsaMd.LSAge++
+			lsdbEnt.NSSALsaMap[lsakey] = lsa_nssa
+		}
+	}
+	/* Opaque LSAs */
+	for lsakey, lsa_op := range lsdbEnt.AreaOpaqueLsaMap {
+		if lsa_op.LsaMd.LSAge == config.MaxAge {
+			lsa_pkt := encodeOpaqueLsa(lsa_op, lsakey)
+			maxAgeLsaMap[lsakey] = lsa_pkt
+			delete(lsdbEnt.AreaOpaqueLsaMap, lsakey)
+			server.logger.Info(fmt.Sprintln("DELETE: Max age reached. adv_router ",
+				convertUint32ToIPv4(lsakey.AdvRouter), " lstype ", lsakey.LSType,
+				" lsid ", convertUint32ToIPv4(lsakey.LSId)))
+			flood_lsa = true
+		} else {
+			lsa_op.LsaMd.LSAge++
+			lsdbEnt.AreaOpaqueLsaMap[lsakey] = lsa_op
+		}
+	}
+	for lsakey, lsa_op := range lsdbEnt.ASOpaqueLsaMap {
+		if lsa_op.LsaMd.LSAge == config.MaxAge {
+			lsa_pkt := encodeOpaqueLsa(lsa_op, lsakey)
+			maxAgeLsaMap[lsakey] = lsa_pkt
+			delete(lsdbEnt.ASOpaqueLsaMap, lsakey)
+			server.logger.Info(fmt.Sprintln("DELETE: Max age reached. adv_router ",
+				convertUint32ToIPv4(lsakey.AdvRouter), " lstype ", lsakey.LSType,
+				" lsid ", convertUint32ToIPv4(lsakey.LSId)))
+			flood_lsa = true
+		} else {
+			lsa_op.LsaMd.LSAge++
+			lsdbEnt.ASOpaqueLsaMap[lsakey] = lsa_op
+		}
+	}
 	/* Summary 3 */
 	for lsakey, lsa_sum := range lsdbEnt.Summary3LsaMap {
 		if lsa_sum.LsaMd.LSAge == config.MaxAge {
@@ -33,6 +33,7 @@ import (
 type LsdbUpdateMsg struct {
 	MsgType uint8
 	AreaId  uint32
+	IntfKey IntfConfKey // receiving interface for link local LSAs
 	Data    []byte
 }
 
@@ -85,6 +86,9 @@ func (server *OSPFServer) initLSDatabase(areaId uint32) {
 		lsDbEnt.Summary3LsaMap = make(map[LsaKey]SummaryLsa)
 		lsDbEnt.Summary4LsaMap = make(map[LsaKey]SummaryLsa)
 		lsDbEnt.ASExternalLsaMap = make(map[LsaKey]ASExternalLsa)
+		lsDbEnt.NSSALsaMap = make(map[LsaKey]ASExternalLsa)
+		lsDbEnt.AreaOpaqueLsaMap = make(map[LsaKey]OpaqueLsa)
+		lsDbEnt.ASOpaqueLsaMap = make(map[LsaKey]OpaqueLsa)
 		server.AreaLsdb[lsdbKey] = lsDbEnt
 	}
 	selfOrigLsaEnt, exist := server.AreaSelfOrigLsa[lsdbKey]
@@ -107,6 +111,7 @@ func (server *OSPFServer) StartLSDatabase() {
 	// start LSDB aging ticker
 	lsdbTickerCh = time.NewTimer(time.Second * 1)
 	lsdbRefreshTickerCh = time.NewTimer(time.Second * time.Duration(config.LSRefreshTime))
+	server.initSpfThrottle()
 	go server.processLSDatabaseUpdates()
 	return
 }
@@ -114,6 +119,7 @@ func (server *OSPFServer) StartLSDatabase() {
 func (server *OSPFServer) StopLSDatabase() {
 	lsdbTickerCh.Stop()
 	lsdbRefreshTickerCh.Stop()
+	server.spfThrottle.timer.Stop()
 }
 
 func (server *OSPFServer) compareSummaryLsa(lsdbKey LsdbKey, lsaKey LsaKey, lsaEnt SummaryLsa) bool {
@@ -225,6 +231,9 @@ func (server *OSPFServer) installSummaryLsa() {
 	ifkey := IntfConfKey{}
 	nbr := NeighborConfKey{}
 	server.logger.Info("Installing summary Lsa...")
+	if server.isGrRestarting() {
+		return
+	}
 	for lsdbKey, sLsa := range server.SummaryLsDb {
 		selfOrigLsaEnt, _ := server.AreaSelfOrigLsa[lsdbKey]
 		oldSelfOrigSummaryLsa := make(map[LsaKey]bool)
@@ -317,7 +326,7 @@ func (server *OSPFServer) generateNetworkLSA(areaId uint32, key IntfConfKey, isD
 	AreaId := convertIPv4ToUint32(ent.IfAreaId)
 	nbrmdata := ospfIntfToNbrMap[key]
 
-	if areaId != AreaId {
+	if areaId != AreaId || server.isGrRestarting() {
 		return
 	}
 	if ent.IfFSMState <= config.Waiting {
@@ -355,7 +364,7 @@ func (server *OSPFServer) generateNetworkLSA(areaId uint32, key IntfConfKey, isD
 	}
 	LSType := NetworkLSA
 	LSId := convertAreaOrRouterIdUint32(ent.IfIpAddr.String())
-	Options := uint8(2) // Need to be revisited
+	Options := server.getAreaOptions(config.AreaId(convertUint32ToIPv4(areaId)), EOption)
 	LSAge := 0
 	AdvRouter := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
 	lsaKey := LsaKey{
@@ -451,8 +460,56 @@ func (server *OSPFServer) constructStubLinkP2P(ent IntfConf, likType config.IfTy
 	return linkDetail
 }
 
+/*
+RFC 2328 12.4.1.4
+Point-to-MultiPoint interface advertises a host route to the interface
+address and a Type 1 link to each of the fully adjacent neighbors.
+*/
+func (server *OSPFServer) constructP2MPLinks(key IntfConfKey, ent IntfConf) []LinkDetail {
+	var linkDetails []LinkDetail
+	ipAddr := convertAreaOrRouterIdUint32(ent.IfIpAddr.String())
+
+	var stubLink LinkDetail
+	stubLink.LinkId = ipAddr
+	stubLink.LinkData = 0xffffffff
+	stubLink.LinkType = StubLink
+	stubLink.NumOfTOS = 0
+	stubLink.LinkMetric = 0
+	linkDetails = append(linkDetails, stubLink)
+
+	for _, nbrRtrId := range server.getFullNbrRtrIds(key) {
+		var linkDetail LinkDetail
+		linkDetail.LinkId = nbrRtrId
+		linkDetail.LinkData = ipAddr
+		linkDetail.LinkType = P2PLink
+		linkDetail.NumOfTOS = 0
+		linkDetail.LinkMetric = uint16(ent.IfCost)
+		linkDetails = append(linkDetails, linkDetail)
+	}
+	return linkDetails
+}
+
+func (server *OSPFServer) getFullNbrRtrIds(key IntfConfKey) []uint32 {
+	var rtrIds []uint32
+	nbrData, exist := ospfIntfToNbrMap[key]
+	if !exist {
+		return nil
+	}
+	for _, nbrKey := range nbrData.nbrList {
+		nbrConf, exist := server.NeighborConfigMap[nbrKey]
+		if exist && nbrConf.OspfNbrState == config.NbrFull {
+			rtrIds = append(rtrIds, nbrConf.OspfNbrRtrId)
+		}
+	}
+	return rtrIds
+}
+
 func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 	var linkDetails []LinkDetail = nil
+	if server.isGrRestarting() {
+		server.logger.Info(fmt.Sprintln("LSDB: Graceful restart. Dont generate router LSA ", areaId))
+		return
+	}
 	for key, ent := range server.IntfConfMap {
 		AreaId := convertIPv4ToUint32(ent.IfAreaId)
 		if areaId != AreaId {
@@ -471,7 +528,7 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 		}
 		var linkDetail LinkDetail
 		switch ent.IfType {
-		case config.Broadcast:
+		case config.Broadcast, config.Nbma:
 			if len(ent.NeighborMap) == 0 { // Stub Network
 				server.logger.Info("Stub Network")
 				ipAddr := convertAreaOrRouterIdUint32(ent.IfIpAddr.String())
@@ -553,6 +610,29 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 			linkDetail.LinkType = P2PLink
 			linkDetail.NumOfTOS = 0
 			linkDetail.LinkMetric = uint16(ent.IfCost)
+
+		case config.PointToMultipoint:
+			p2mpLinks := server.constructP2MPLinks(key, ent)
+			linkDetails = append(linkDetails, p2mpLinks...)
+			continue
+
+		case config.VirtualLink:
+			/* RFC 2328 12.4.1.3
+			   Virtual link is added once the neighbor is fully adjacent.
+			   Link ID is the router id of the other end point, Link Data
+			   the interface address and the cost is the intra area cost
+			   through the transit area.
+			*/
+			nbrRtrIds := server.getFullNbrRtrIds(key)
+			if len(nbrRtrIds) == 0 {
+				server.logger.Info(fmt.Sprintln("LSDB: Virtual link neighbor is not full ", key.IPAddr))
+				continue
+			}
+			linkDetail.LinkId = nbrRtrIds[0]
+			linkDetail.LinkData = convertAreaOrRouterIdUint32(ent.IfIpAddr.String())
+			linkDetail.LinkType = VirtualLink
+			linkDetail.NumOfTOS = 0
+			linkDetail.LinkMetric = uint16(ent.IfCost)
 		}
 		linkDetails = append(linkDetails, linkDetail)
 	}
@@ -561,13 +641,23 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 
 	LSType := RouterLSA
 	LSId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
-	Options := uint8(2) // Need to be revisited
+	areaConfKey := AreaConfKey{
+		AreaId: config.AreaId(convertUint32ToIPv4(areaId)),
+	}
+	Options := server.getAreaOptions(areaConfKey.AreaId, EOption)
 	LSAge := 0
 	AdvRouter := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
 	BitE := false //not an AS boundary router (Todo)
 	BitB := false
+	BitNt := false
 	if server.ospfGlobalConf.AreaBdrRtrStatus == true {
 		BitB = true
+		/* NSSA border router configured to always translate */
+		areaConf, _ := server.AreaConfMap[areaConfKey]
+		if server.isNssaArea(areaConfKey.AreaId) &&
+			areaConf.AreaNssaTranslatorRole == config.Always {
+			BitNt = true
+		}
 	}
 	lsaKey := LsaKey{
 		LSType:    LSType,
@@ -590,6 +680,7 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 		delete(selfOrigLsaEnt, lsaKey)
 		server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
 		server.AreaLsdb[lsdbKey] = lsDbEnt
+		server.generateTeLsa(areaId)
 		return
 	}
 	ent, exist := lsDbEnt.RouterLsaMap[lsaKey]
@@ -606,6 +697,8 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 	ent.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + (12 * numOfLinks))
 	ent.BitE = BitE
 	ent.BitB = BitB
+	ent.BitNt = BitNt
+	ent.BitV = server.isVirtLinkTransitArea(areaId)
 	ent.NumOfLinks = uint16(numOfLinks)
 	ent.LinkDetails = make([]LinkDetail, numOfLinks)
 	copy(ent.LinkDetails, linkDetails[0:])
@@ -633,6 +726,7 @@ func (server *OSPFServer) generateRouterLSA(areaId uint32) {
 		}
 		server.DbLsdbOp <- msg
 	}
+	server.generateTeLsa(areaId)
 	return
 }
 
@@ -651,6 +745,10 @@ func (server *OSPFServer) generateASExternalLsa(route RouteMdata) LsaKey {
 
 	BitE := true
 	for lsdbKey, _ := range server.AreaLsdb {
+		if server.isNssaArea(config.AreaId(convertUint32ToIPv4(lsdbKey.AreaId))) {
+			// NSSA carries Type-7 LSAs instead
+			continue
+		}
 		lsDbEnt, _ := server.AreaLsdb[lsdbKey]
 		ent, exist := lsDbEnt.ASExternalLsaMap[lsaKey]
 		LSAge := 0
@@ -737,6 +835,30 @@ func (server *OSPFServer) updateAsExternalLSA(lsdbKey LsdbKey, lsaKey LsaKey) er
 	return nil
 }
 
+/*@fn updateNSSALsa
+Refresh self originated Type-7 LSA.
+*/
+func (server *OSPFServer) updateNSSALsa(lsdbKey LsdbKey, lsaKey LsaKey) error {
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return nil
+	}
+	ent, valid := lsDbEnt.NSSALsaMap[lsaKey]
+	if !valid {
+		server.logger.Warning(fmt.Sprintln("LSDB: NSSA LSA doesnt exist lsdb ", lsdbKey, lsaKey))
+		return nil
+	}
+	ent.LsaMd.LSSequenceNum = ent.LsaMd.LSSequenceNum + 1
+	ent.LsaMd.LSAge = 0
+	ent.LsaMd.LSChecksum = 0
+	LsaEnc := encodeASExternalLsa(ent, lsaKey)
+	checksumOffset := uint16(14)
+	ent.LsaMd.LSChecksum = computeFletcherChecksum(LsaEnc[2:], checksumOffset)
+	lsDbEnt.NSSALsaMap[lsaKey] = ent
+	server.AreaLsdb[lsdbKey] = lsDbEnt
+	return nil
+}
+
 func (server *OSPFServer) processDeleteRouterLsa(data []byte, areaId uint32) bool {
 	lsakey := NewLsaKey()
 	routerLsa := NewRouterLsa()
@@ -1027,6 +1149,80 @@ func (server *OSPFServer) processRecvdASExternalLsa(data []byte, areaId uint32)
 	return true
 }
 
+func (server *OSPFServer) processDeleteNSSALsa(data []byte, areaId uint32) bool {
+	lsakey := NewLsaKey()
+	var val LsdbSliceEnt
+	nssaLsa := NewASExternalLsa()
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	decodeASExternalLsa(data, nssaLsa, lsakey)
+	lsDbEnt, _ := server.AreaLsdb[lsdbKey]
+	delete(lsDbEnt.NSSALsaMap, *lsakey)
+	server.AreaLsdb[lsdbKey] = lsDbEnt
+
+	val.AreaId = lsdbKey.AreaId
+	val.LSType = lsakey.LSType
+	val.LSId = lsakey.LSId
+	val.AdvRtr = lsakey.AdvRouter
+	err := server.DelLsdbEntry(val)
+	if err != nil {
+		server.logger.Info(fmt.Sprintln("DB: Failed to delete entry from db ", lsakey))
+	}
+	return true
+}
+
+func (server *OSPFServer) processRecvdNSSALsa(data []byte, areaId uint32) bool {
+	lsakey := NewLsaKey()
+	nssaLsa := NewASExternalLsa()
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	if !server.isNssaArea(config.AreaId(convertUint32ToIPv4(areaId))) {
+		server.logger.Err(fmt.Sprintln("NSSA LSA received in non NSSA area ", areaId))
+		return false
+	}
+	decodeASExternalLsa(data, nssaLsa, lsakey)
+	selfOrigLsaEnt, _ := server.AreaSelfOrigLsa[lsdbKey]
+	_, exist := selfOrigLsaEnt[*lsakey]
+	if exist {
+		server.logger.Info("Recvd a self generated NSSA LSA")
+		return false
+	}
+
+	//Check Checksum
+	csum := computeFletcherChecksum(data[2:], FLETCHER_CHECKSUM_VALIDATE)
+	if csum != 0 {
+		server.logger.Err("Invalid NSSA LSA Checksum")
+		return false
+	}
+	lsDbEnt, _ := server.AreaLsdb[lsdbKey]
+	ent, exist := lsDbEnt.NSSALsaMap[*lsakey]
+	if exist {
+		if ent.LsaMd.LSSequenceNum >= nssaLsa.LsaMd.LSSequenceNum {
+			server.logger.Err("Old instance of NSSA LSA Recvd")
+			return false
+		}
+	}
+	lsDbEnt.NSSALsaMap[*lsakey] = *nssaLsa
+	server.AreaLsdb[lsdbKey] = lsDbEnt
+	if !exist {
+		var val LsdbSliceEnt
+		val.AreaId = lsdbKey.AreaId
+		val.LSType = lsakey.LSType
+		val.LSId = lsakey.LSId
+		val.AdvRtr = lsakey.AdvRouter
+		server.LsdbSlice = append(server.LsdbSlice, val)
+		msg := DbLsdbMsg{
+			entry: val,
+			op:    true,
+		}
+		server.DbLsdbOp <- msg
+	}
+
+	return true
+}
+
 func (server *OSPFServer) processRecvdLsa(data []byte, areaId uint32) bool {
 	LSType := uint8(data[3])
 	if LSType == RouterLSA {
@@ -1043,6 +1239,9 @@ func (server *OSPFServer) processRecvdLsa(data []byte, areaId uint32) bool {
 		return server.processRecvdSummaryLsa(data, areaId, LSType)
 	} else if LSType == ASExternalLSA {
 		return server.processRecvdASExternalLsa(data, areaId)
+	} else if LSType == NSSALSA {
+		server.logger.Info("LSDB: Received NSSA lsa")
+		return server.processRecvdNSSALsa(data, areaId)
 	} else {
 		server.logger.Info("LSDB: Invalid LSA packet from nbr")
 		return false
@@ -1062,6 +1261,8 @@ func (server *OSPFServer) processDeleteLsa(data []byte, areaId uint32) bool {
 		return server.processDeleteSummaryLsa(data, areaId, LSType)
 	} else if LSType == ASExternalLSA {
 		return server.processDeleteASExternalLsa(data, areaId)
+	} else if LSType == NSSALSA {
+		return server.processDeleteNSSALsa(data, areaId)
 	} else {
 		return false
 	}
@@ -1071,78 +1272,63 @@ func (server *OSPFServer) processLSDatabaseUpdates() {
 	for {
 		select {
 		case msg := <-server.LsdbUpdateCh:
-			if msg.MsgType == LsdbAdd {
+			if isOpaqueLsa(msg.Data[3]) {
+				// Opaque LSAs do not change the topology. No SPF.
+				server.processOpaqueLsdbUpdate(msg)
+			} else if msg.MsgType == LsdbAdd {
 				server.logger.Info("Adding LS in the Lsdb")
 				server.logger.Info("Received New LSA")
+				spfType := server.getLsaSpfType(msg.Data, msg.AreaId, false)
 				ret := server.processRecvdLsa(msg.Data, msg.AreaId)
 				server.logger.Info(fmt.Sprintln("Return Code:", ret))
 				//server.LsaUpdateRetCodeCh <- ret
-				server.StartCalcSPFCh <- true
-				spfStatus := <-server.DoneCalcSPFCh
-				server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
-				if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-					server.installSummaryLsa()
-				}
+				server.scheduleSpf(msg.AreaId, spfType)
 			} else if msg.MsgType == LsdbDel {
 				server.logger.Info("Deleting LS in the Lsdb")
+				spfType := server.getLsaSpfType(msg.Data, msg.AreaId, true)
 				ret := server.processDeleteLsa(msg.Data, msg.AreaId)
 				//server.LsaUpdateRetCodeCh <- ret
 				server.logger.Info(fmt.Sprintln("Return Code:", ret))
-				server.StartCalcSPFCh <- true
-				spfStatus := <-server.DoneCalcSPFCh
-				server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
-				if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-					server.installSummaryLsa()
-				}
+				server.scheduleSpf(msg.AreaId, spfType)
 			} else if msg.MsgType == LsdbUpdate {
 				server.logger.Info("Deleting LS in the Lsdb")
+				spfType := server.getLsaSpfType(msg.Data, msg.AreaId, false)
 				ret := server.processRecvdLsa(msg.Data, msg.AreaId)
 				//server.LsaUpdateRetCodeCh <- ret
 				server.logger.Info(fmt.Sprintln("Return Code:", ret))
-				server.StartCalcSPFCh <- true
-				spfStatus := <-server.DoneCalcSPFCh
-				server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
-				if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-					server.installSummaryLsa()
-				}
+				server.scheduleSpf(msg.AreaId, spfType)
 			}
 		case msg := <-server.IntfStateChangeCh:
 			server.logger.Info(fmt.Sprintf("Interface State change msg", msg))
 			server.generateRouterLSA(msg.areaId)
 			//server.logger.Info(fmt.Sprintln("LS Database", server.AreaLsdb))
-			server.StartCalcSPFCh <- true
-			spfStatus := <-server.DoneCalcSPFCh
-			server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
+			server.scheduleSpf(msg.areaId, FullSpf)
 			server.processInterfaceChangeMsg(msg)
-			if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-				server.installSummaryLsa()
-			}
 		case msg := <-server.NetworkDRChangeCh:
 			server.logger.Info(fmt.Sprintf("Network DR change msg", msg))
 			// Create a new router LSA
 			//server.logger.Info(fmt.Sprintln("LS Database", server.AreaLsdb))
 			server.processDrBdrChangeMsg(msg)
-			server.StartCalcSPFCh <- true
-			spfStatus := <-server.DoneCalcSPFCh
-			server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
-			if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-				server.installSummaryLsa()
-			}
+			server.scheduleSpf(msg.areaId, FullSpf)
 		case msg := <-server.CreateNetworkLSACh:
 			server.logger.Info(fmt.Sprintf("Create Network LSA msg", msg))
-			server.processNeighborFullEvent(msg)
+			if server.isGrRestartDone() {
+				server.exitGrRestart(config.Completed)
+				server.scheduleFullSpf()
+			} else {
+				server.processNeighborFullEvent(msg)
+				server.scheduleSpf(msg.areaId, FullSpf)
+			}
 			//server.generateNetworkLSA(msg.areaId, msg.intf, msg.isDR)
 			// Flush the old Network LSA
 			// Check if link is broadcast or not
 			// If link is broadcast
 			// Create Network LSA
 			//server.logger.Info(fmt.Sprintln("LS Database", server.AreaLsdb))
-			server.StartCalcSPFCh <- true
-			spfStatus := <-server.DoneCalcSPFCh
-			server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
-			if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-				server.installSummaryLsa()
-			}
+
+		case reason := <-server.GrRestartExitCh:
+			server.exitGrRestart(reason)
+			server.scheduleFullSpf()
 
 		case msg := <-server.ExternalRouteNotif: //Generate external LSA
 			server.processExtRouteUpd(msg)
@@ -1150,6 +1336,9 @@ func (server *OSPFServer) processLSDatabaseUpdates() {
 		case msg := <-server.maxAgeLsaCh: //Flood MaxAge LSA
 			server.processMaxAgeLsaMsg(msg)
 
+		case <-server.spfThrottle.timer.C: //Run scheduled SPF
+			server.runScheduledSpf()
+
 		case <-lsdbTickerCh.C: //Increment LSA AGE
 			lsdbTickerCh.Stop()
 			server.processLSDatabaseTicker()
@@ -1164,7 +1353,8 @@ func (server *OSPFServer) processLSDatabaseUpdates() {
 }
 
 /*@fn processExtRouteUpd
-Generate / delete As external LSA.
+Generate / delete As external LSA and
+Type-7 LSAs for the NSSAs.
 Send flood message if new route is added.
 */
 func (server *OSPFServer) processExtRouteUpd(msg RouteMdata) {
@@ -1174,6 +1364,7 @@ func (server *OSPFServer) processExtRouteUpd(msg RouteMdata) {
 	if !msg.isDel {
 		server.sendLsdbToNeighborEvent(ifkey, nbr, 0, 0, 0, lsaKey, LSAEXTFLOOD)
 	}
+	server.processNssaExtRouteUpd(msg)
 }
 
 /*
@@ -1211,7 +1402,8 @@ func (server *OSPFServer) processNeighborFullEvent(msg ospfNbrMdata) {
 	intConf := server.IntfConfMap[msg.intf]
 	server.logger.Info(fmt.Sprintln("LSDB: Nbr full. Generate router and network LSA  area id  ",
 		msg.areaId, " intf ", intConf.IfIpAddr))
-	if intConf.IfDRtrId == rtr_id && intConf.IfType == config.Broadcast {
+	if intConf.IfDRtrId == rtr_id &&
+		(intConf.IfType == config.Broadcast || intConf.IfType == config.Nbma) {
 		server.logger.Info(fmt.Sprintln("Generate network LSA ", msg.intf))
 		server.generateNetworkLSA(msg.areaId, msg.intf, true)
 	}
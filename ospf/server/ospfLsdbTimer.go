@@ -81,6 +81,30 @@ func (server *OSPFServer) lsdbStateRefresh() {
 				val.AdvRtr = lsakey.AdvRouter
 				server.LsdbSlice = append(server.LsdbSlice, val)
 			}
+			for lsakey, _ := range lsdbEnt.NSSALsaMap {
+				var val LsdbSliceEnt
+				val.AreaId = lsdbkey.AreaId
+				val.LSType = lsakey.LSType
+				val.LSId = lsakey.LSId
+				val.AdvRtr = lsakey.AdvRouter
+				server.LsdbSlice = append(server.LsdbSlice, val)
+			}
+			for lsakey, _ := range lsdbEnt.AreaOpaqueLsaMap {
+				var val LsdbSliceEnt
+				val.AreaId = lsdbkey.AreaId
+				val.LSType = lsakey.LSType
+				val.LSId = lsakey.LSId
+				val.AdvRtr = lsakey.AdvRouter
+				server.LsdbSlice = append(server.LsdbSlice, val)
+			}
+			for lsakey, _ := range lsdbEnt.ASOpaqueLsaMap {
+				var val LsdbSliceEnt
+				val.AreaId = lsdbkey.AreaId
+				val.LSType = lsakey.LSType
+				val.LSId = lsakey.LSId
+				val.AdvRtr = lsakey.AdvRouter
+				server.LsdbSlice = append(server.LsdbSlice, val)
+			}
 		}
 		server.logger.Info(fmt.Sprintln("The new Lsdb Slice after refresh", server.LsdbSlice))
 		server.LsdbStateTimer.Reset(server.RefreshDuration)
@@ -113,6 +137,9 @@ func (server *OSPFServer) lsdbSelfLsaRefresh() {
 				if floodAsExt == 0 && lsaKey.LSType == ASExternalLSA {
 					server.sendLsdbToNeighborEvent(ifkey, nbr, 0, 0, 0, lsaKey, LSAEXTFLOOD)
 				}
+				if lsaKey.LSType == NSSALSA {
+					server.sendLsdbToNeighborEvent(ifkey, nbr, lsdbKey.AreaId, 0, 0, lsaKey, LSANSSAFLOOD)
+				}
 				if err != nil {
 					server.logger.Warning(fmt.Sprintln("LSDB: Failed to regenerate LSA ", lsaKey, " Area ", lsdbKey))
 				}
@@ -158,6 +185,12 @@ func (server *OSPFServer) regenerateLsa(lsdbKey LsdbKey, lsaKey LsaKey) error {
 	case ASExternalLSA:
 		server.updateAsExternalLSA(lsdbKey, lsaKey)
 
+	case NSSALSA:
+		server.updateNSSALsa(lsdbKey, lsaKey)
+
+	case AreaOpaqueLSA, ASOpaqueLSA:
+		server.refreshOpaqueLsa(lsdbKey, lsaKey)
+
 	}
 	return nil
 }
@@ -174,6 +207,8 @@ func (server *OSPFServer) processLSDatabaseTicker() {
 		server.processMaxAgeLSA(lsdbKey, lsDbEnt)
 
 	}
+	server.processMaxAgeLinkOpaqueLsa()
+	server.checkNssaTranslatorStability()
 
 }
 
@@ -35,6 +35,9 @@ func (server *OSPFServer) HandleSummaryLsa(areaId uint32) {
 	server.HandleSummaryType3Lsa(areaId)
 	server.HandleSummaryType4Lsa(areaId)
 	server.HandleASExternalLsa(areaId)
+	if server.isNssaArea(config.AreaId(convertUint32ToIPv4(areaId))) {
+		server.HandleNSSALsa(areaId)
+	}
 }
 
 func (server *OSPFServer) HandleSummaryType3Lsa(areaId uint32) {
@@ -42,8 +45,104 @@ func (server *OSPFServer) HandleSummaryType3Lsa(areaId uint32) {
 	server.CalcInterAreaRoutes(areaId)
 }
 
+/*
+RFC 2328 16.3
+Examine summary LSAs of each transit area to find better paths than
+the ones computed through the backbone (including virtual links).
+*/
 func (server *OSPFServer) HandleTransitAreaSummaryLsa() {
+	for key, aEnt := range server.AreaConfMap {
+		areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
+		if areaId == 0 || aEnt.TransitCapability == false {
+			continue
+		}
+		server.logger.Info(fmt.Sprintln("Examining summary LSAs of transit area:", key.AreaId))
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+		if !exist {
+			server.logger.Err(fmt.Sprintln("Unable to find Area Lsdb entry for transit area", key.AreaId))
+			continue
+		}
+		for lsaKey, lsaEnt := range lsDbEnt.Summary3LsaMap {
+			rKey := RoutingTblEntryKey{
+				DestId:   lsaKey.LSId & lsaEnt.Netmask,
+				AddrMask: lsaEnt.Netmask,
+				DestType: Network,
+			}
+			server.examineTransitAreaSummaryLsa(areaId, lsaKey, lsaEnt, rKey)
+		}
+		for lsaKey, lsaEnt := range lsDbEnt.Summary4LsaMap {
+			rKey := RoutingTblEntryKey{
+				DestId:   lsaKey.LSId,
+				AddrMask: 0,
+				DestType: ASBdrRouter,
+			}
+			server.examineTransitAreaSummaryLsa(areaId, lsaKey, lsaEnt, rKey)
+		}
+	}
+}
 
+func (server *OSPFServer) examineTransitAreaSummaryLsa(areaId uint32, lsaKey LsaKey, lsaEnt SummaryLsa, rKey RoutingTblEntryKey) {
+	if lsaEnt.Metric == LSInfinity ||
+		lsaEnt.LsaMd.LSAge == config.MaxAge {
+		return
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	if lsaKey.AdvRouter == rtrId {
+		return
+	}
+
+	bbAreaIdKey := AreaIdKey{
+		AreaId: 0,
+	}
+	bbRoutingTbl, exist := server.TempAreaRoutingTbl[bbAreaIdKey]
+	if !exist || bbRoutingTbl.RoutingTblMap == nil {
+		return
+	}
+	rEnt, exist := bbRoutingTbl.RoutingTblMap[rKey]
+	if !exist ||
+		(rEnt.PathType != IntraArea && rEnt.PathType != InterArea) {
+		return
+	}
+
+	areaIdKey := AreaIdKey{
+		AreaId: areaId,
+	}
+	transitRoutingTbl := server.TempAreaRoutingTbl[areaIdKey]
+	brKey := RoutingTblEntryKey{
+		DestId:   lsaKey.AdvRouter,
+		AddrMask: 0,
+		DestType: AreaBdrRouter,
+	}
+	brEnt, exist := transitRoutingTbl.RoutingTblMap[brKey]
+	if !exist {
+		brKey.DestType = ASAreaBdrRouter
+		brEnt, exist = transitRoutingTbl.RoutingTblMap[brKey]
+		if !exist {
+			return
+		}
+	}
+	if brEnt.NumOfPaths == 0 {
+		return
+	}
+
+	cost := brEnt.Cost + uint16(lsaEnt.Metric)
+	if rEnt.Cost < cost {
+		return
+	} else if rEnt.Cost > cost {
+		server.logger.Info(fmt.Sprintln("Better path found through transit area for", rKey))
+		rEnt.Cost = cost
+		rEnt.NextHops = make(map[NextHop]bool)
+	}
+	for nextHop, _ := range brEnt.NextHops {
+		nextHop.AdvRtr = lsaKey.AdvRouter
+		rEnt.NextHops[nextHop] = true
+	}
+	rEnt.NumOfPaths = len(rEnt.NextHops)
+	bbRoutingTbl.RoutingTblMap[rKey] = rEnt
+	server.TempAreaRoutingTbl[bbAreaIdKey] = bbRoutingTbl
 }
 
 // Handling Summary LSA in case of FS is internal router
@@ -0,0 +1,182 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/ospf/config"
+	"net"
+)
+
+/*
+NBMA networks have no multicast capability (RFC 2328 9.5.1).
+Neighbors are configured statically and hellos are unicast to them,
+neighbors which have not been heard from are polled at PollInterval.
+*/
+type NbmaNbrConf struct {
+	NbrPriority uint8
+}
+
+func (server *OSPFServer) processNbrConfig(nbrConf config.NbrConf) error {
+	if net.ParseIP(string(nbrConf.NbrIpAddress)).To4() == nil {
+		server.logger.Err(fmt.Sprintln("NBMA: Invalid neighbor address ", nbrConf.NbrIpAddress))
+		err := errors.New("Invalid neighbor address")
+		return err
+	}
+	nbrKey := NeighborConfKey{
+		IPAddr:  nbrConf.NbrIpAddress,
+		IntfIdx: nbrConf.NbrAddressLessIndex,
+	}
+	server.NbmaNbrMap[nbrKey] = NbmaNbrConf{
+		NbrPriority: uint8(nbrConf.NbrPriority),
+	}
+	server.logger.Info(fmt.Sprintln("NBMA: Configured neighbor ", nbrKey))
+	return nil
+}
+
+func (server *OSPFServer) processNbrDelete(nbrConf config.NbrConf) {
+	nbrKey := NeighborConfKey{
+		IPAddr:  nbrConf.NbrIpAddress,
+		IntfIdx: nbrConf.NbrAddressLessIndex,
+	}
+	if _, exist := server.NbmaNbrMap[nbrKey]; !exist {
+		server.logger.Err(fmt.Sprintln("NBMA: No such neighbor configured ", nbrKey))
+		return
+	}
+	delete(server.NbmaNbrMap, nbrKey)
+	server.logger.Info(fmt.Sprintln("NBMA: Deleted neighbor ", nbrKey))
+}
+
+/*@fn getNbmaStaticNbrs
+Configured neighbors which are attached to the NBMA interface.
+*/
+func (server *OSPFServer) getNbmaStaticNbrs(key IntfConfKey, ent IntfConf) map[NeighborConfKey]NbmaNbrConf {
+	nbrs := make(map[NeighborConfKey]NbmaNbrConf)
+	for nbrKey, nbrConf := range server.NbmaNbrMap {
+		if nbrKey.IntfIdx != key.IntfIdx {
+			continue
+		}
+		nbrIp := net.ParseIP(string(nbrKey.IPAddr))
+		if key.IntfIdx == 0 &&
+			!isInSubnet(ent.IfIpAddr, nbrIp, net.IPMask(ent.IfNetmask)) {
+			continue
+		}
+		nbrs[nbrKey] = nbrConf
+	}
+	return nbrs
+}
+
+/*@fn nbmaHelloDstList
+RFC 2328 9.5.1
+Neighbors heard from get hellos at HelloInterval. DR eligible routers
+send to all the eligible neighbors, DR and BDR send to everybody and
+the rest only send to the DR and BDR.
+Configured neighbors which are down are polled at PollInterval, except
+for the eligible ones while an eligible router is Waiting.
+*/
+func nbmaHelloDstList(ent IntfConf, staticNbrs map[NeighborConfKey]NbmaNbrConf, poll bool) []NeighborConfKey {
+	var dstList []NeighborConfKey
+	eligible := ent.IfRtrPriority > 0
+	isDROrBDR := ent.IfFSMState == config.DesignatedRouter ||
+		ent.IfFSMState == config.BackupDesignatedRouter
+
+	if !poll {
+		for nbrKey, nbrEntry := range ent.NeighborMap {
+			nbrIp := net.ParseIP(string(nbrKey.IPAddr)).To4()
+			if isDROrBDR ||
+				(eligible && nbrEntry.RtrPrio > 0) ||
+				bytesEqual(nbrIp, ent.IfDRIp) ||
+				bytesEqual(nbrIp, ent.IfBDRIp) {
+				dstList = append(dstList, nbrKey)
+			}
+		}
+	}
+
+	for nbrKey, nbrConf := range staticNbrs {
+		if _, exist := ent.NeighborMap[nbrKey]; exist {
+			continue
+		}
+		helloNbr := eligible && nbrConf.NbrPriority > 0 &&
+			ent.IfFSMState == config.Waiting
+		if helloNbr != poll {
+			dstList = append(dstList, nbrKey)
+		}
+	}
+	return dstList
+}
+
+func (server *OSPFServer) sendNbmaHelloPkt(key IntfConfKey, poll bool) {
+	ent, _ := server.IntfConfMap[key]
+	dstList := nbmaHelloDstList(ent, server.getNbmaStaticNbrs(key, ent), poll)
+	if len(dstList) == 0 {
+		return
+	}
+	ospfHelloPkt := server.BuildHelloPkt(ent)
+	if ospfHelloPkt == nil {
+		return
+	}
+	for _, nbrKey := range dstList {
+		err := server.sendOspfUnicastPkt(key, ospfHelloPkt, nbrKey, 1)
+		if err != nil {
+			server.logger.Err(fmt.Sprintln("NBMA: Unable to send the ospf Hello pkt to ", nbrKey.IPAddr))
+		}
+	}
+}
+
+/*@fn sendNbmaMcastPkt
+Packets built for AllSPFRouters/AllDRouters are replicated to every
+neighbor on the NBMA interface.
+*/
+func (server *OSPFServer) sendNbmaMcastPkt(key IntfConfKey, ent IntfConf, ospfPkt []byte) error {
+	var err error
+	for nbrKey, _ := range ent.NeighborMap {
+		if sendErr := server.sendOspfUnicastPkt(key, ospfPkt, nbrKey, 1); sendErr != nil {
+			err = sendErr
+		}
+	}
+	return err
+}
+
+func (server *OSPFServer) sendOspfUnicastPkt(key IntfConfKey, ospfPkt []byte,
+	nbrKey NeighborConfKey, ttl uint8) error {
+	dstIp := net.ParseIP(string(nbrKey.IPAddr))
+	ucastPkt := getOspfUnicastPkt(ospfPkt, dstIp, getNbrDstMAC(nbrKey), ttl)
+	if ucastPkt == nil {
+		err := errors.New("Invalid ospf pkt")
+		return err
+	}
+	return server.writeOspfPkt(key, ucastPkt)
+}
+
+/*@fn getNbrDstMAC
+MAC learnt from the packets received from the neighbor, frames to
+neighbors never heard from are broadcast.
+*/
+func getNbrDstMAC(nbrKey NeighborConfKey) net.HardwareAddr {
+	if dstMac, exist := ospfNeighborIPToMAC[nbrKey]; exist && dstMac != nil {
+		return dstMac
+	}
+	return net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+}
@@ -479,6 +479,9 @@ func (server *OSPFServer) ProcessNbrStateMachine() {
 							isStateUpdate = true
 						}
 					}
+				} else if server.isGrHelping(nbrKey) {
+					// Restarting neighbor lost us. Keep the adjacency during grace period.
+					server.logger.Debug(fmt.Sprintln("NBRHELLO: GR helper keep nbr state ", nbrKey))
 				} else {
 					nbrConf.OspfNbrState = config.NbrInit
 					isStateUpdate = true
@@ -758,6 +761,16 @@ func (server *OSPFServer) generateDbSummaryList(nbrConfKey NeighborConfKey) {
 		db_list = append(db_list, asExternal_list...)
 	}
 
+	nssa_list := server.generateDbNssaList(areaId)
+	if nssa_list != nil {
+		db_list = append(db_list, nssa_list...)
+	}
+
+	opaque_list := server.generateDbOpaqueList(areaId, nbrConf.intfConfKey)
+	if opaque_list != nil {
+		db_list = append(db_list, opaque_list...)
+	}
+
 	for lsa := range db_list {
 		rtr_id := convertUint32ToIPv4(db_list[lsa].lsa_headers.adv_router_id)
 		server.logger.Info(fmt.Sprintln(lsa, ": ", rtr_id, " lsatype ", db_list[lsa].lsa_headers.ls_type))
@@ -806,6 +819,77 @@ func (server *OSPFServer) generateDbasExternalList(self_areaId uint32) []*ospfNe
 	return db_list
 }
 
+/*@fn generateDbNssaList
+This function generates Type-7 LSA list for NSSA
+*/
+func (server *OSPFServer) generateDbNssaList(self_areaId uint32) []*ospfNeighborDBSummary {
+	db_list := []*ospfNeighborDBSummary{}
+	lsdbKey := LsdbKey{
+		AreaId: self_areaId,
+	}
+
+	area_lsa, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err(fmt.Sprintln("negotiation: NSSA LSA doesnt exist"))
+		return nil
+	}
+	nssa_lsdb := area_lsa.NSSALsaMap
+
+	for lsaKey, drlsa := range nssa_lsdb {
+		db_nssa := newospfNeighborDBSummary()
+		db_nssa.lsa_headers = getLsaHeaderFromLsa(drlsa.LsaMd.LSAge, drlsa.LsaMd.Options,
+			NSSALSA, lsaKey.LSId, lsaKey.AdvRouter,
+			uint32(drlsa.LsaMd.LSSequenceNum), drlsa.LsaMd.LSChecksum,
+			drlsa.LsaMd.LSLen)
+		db_nssa.valid = true
+		db_list = append(db_list, db_nssa)
+		lsid := convertUint32ToIPv4(lsaKey.LSId)
+		server.logger.Info(fmt.Sprintln("negotiation: db_list NSSA append lsid  ", lsid))
+	}
+	return db_list
+}
+
+/*@fn generateDbOpaqueList
+Attach opaque LSAs of the link, the area and the AS
+if opaque LSAs are supported.
+*/
+func (server *OSPFServer) generateDbOpaqueList(self_areaId uint32, intfKey IntfConfKey) []*ospfNeighborDBSummary {
+	if !server.ospfGlobalConf.OpaqueLsaSupport {
+		return nil
+	}
+	db_list := []*ospfNeighborDBSummary{}
+	lsdbKey := LsdbKey{
+		AreaId: self_areaId,
+	}
+
+	area_lsa, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err(fmt.Sprintln("negotiation: Opaque LSA doesnt exist"))
+		return nil
+	}
+	opaque_lsdb := make(map[LsaKey]OpaqueLsa)
+	for lsaKey, lsa := range server.LinkOpaqueLsdb[intfKey] {
+		opaque_lsdb[lsaKey] = lsa
+	}
+	for lsaKey, lsa := range area_lsa.AreaOpaqueLsaMap {
+		opaque_lsdb[lsaKey] = lsa
+	}
+	for lsaKey, lsa := range area_lsa.ASOpaqueLsaMap {
+		opaque_lsdb[lsaKey] = lsa
+	}
+
+	for lsaKey, dolsa := range opaque_lsdb {
+		db_opaque := newospfNeighborDBSummary()
+		db_opaque.lsa_headers = getLsaHeaderFromLsa(dolsa.LsaMd.LSAge, dolsa.LsaMd.Options,
+			lsaKey.LSType, lsaKey.LSId, lsaKey.AdvRouter,
+			uint32(dolsa.LsaMd.LSSequenceNum), dolsa.LsaMd.LSChecksum,
+			dolsa.LsaMd.LSLen)
+		db_opaque.valid = true
+		db_list = append(db_list, db_opaque)
+	}
+	return db_list
+}
+
 /* @fn generateDbsummaryLsaList
 This function will attach summary LSAs if the router is ABR
 */
@@ -921,6 +1005,12 @@ func (server *OSPFServer) neighborDeadTimerEvent(nbrConfKey NeighborConfKey) {
 		server.logger.Info(fmt.Sprintln("NBRSCAN: DEAD ", nbrConfKey.IPAddr))
 
 		_, exists := server.NeighborConfigMap[nbrConfKey]
+		if exists && server.isGrHelping(nbrConfKey) {
+			// Helper keeps the restarting neighbor till grace period is over
+			nbrConf := server.NeighborConfigMap[nbrConfKey]
+			nbrConf.NbrDeadTimer.Reset(nbrConf.OspfNbrDeadTimer)
+			return
+		}
 		if exists {
 			nbrConf := server.NeighborConfigMap[nbrConfKey]
 			msg := DbEventMsg{
@@ -54,6 +54,8 @@ const (
 	LSASUMMARYFLOOD = 4 //flood summary LSAs in different areas.
 	LSAEXTFLOOD     = 5 //flood AS External summary LSA
 	LSAROUTERFLOOD  = 6 //flood only router LSA
+	LSANSSAFLOOD    = 7 //flood NSSA LSA within the area
+	LSAOPAQUEFLOOD  = 8 //flood opaque LSA as per its scope
 )
 
 type NeighborConfKey struct {
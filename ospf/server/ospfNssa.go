@@ -0,0 +1,543 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/ospf/config"
+	"time"
+)
+
+/*
+RFC 3101 Not-So-Stubby Areas.
+External routes redistributed inside an NSSA are carried in Type-7
+LSAs which are flooded only within the area. One of the NSSA border
+routers translates Type-7 LSAs with P-bit set into Type-5 LSAs for
+the rest of the AS.
+*/
+
+// TranslatorStabilityInterval RFC 3101 3.1
+const NssaTranslatorStabilityInterval = 40 * time.Second
+
+/*@fn getAreaOptions
+E-bit is cleared for stub and NSSA areas and
+N-bit is set for NSSA areas (RFC 3101 2.4)
+*/
+func (server *OSPFServer) getAreaOptions(areaId config.AreaId, options uint8) uint8 {
+	if server.isStubArea(areaId) {
+		return options &^ EOption
+	}
+	if server.isNssaArea(areaId) {
+		return (options &^ EOption) | NPOption
+	}
+	return options
+}
+
+/*@fn getNssaFwdAddr
+Type-7 LSAs with P-bit set need a forwarding address.
+Pick the lowest active interface address in the area.
+*/
+func (server *OSPFServer) getNssaFwdAddr(areaId uint32) uint32 {
+	fwdAddr := uint32(0)
+	for _, ent := range server.IntfConfMap {
+		if ent.IfAreaId == nil || ent.IfFSMState <= config.Down {
+			continue
+		}
+		if convertIPv4ToUint32(ent.IfAreaId) != areaId {
+			continue
+		}
+		ipAddr := convertAreaOrRouterIdUint32(ent.IfIpAddr.String())
+		if fwdAddr == 0 || ipAddr < fwdAddr {
+			fwdAddr = ipAddr
+		}
+	}
+	return fwdAddr
+}
+
+/*@fn generateNSSALsa
+Originate / flush Type-7 LSA for the redistributed route.
+Returns true if LSDB is changed.
+*/
+func (server *OSPFServer) generateNSSALsa(areaId uint32, route RouteMdata) (LsaKey, bool) {
+	AdvRouter := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	lsaKey := LsaKey{
+		LSType:    NSSALSA,
+		LSId:      route.ipaddr & route.mask,
+		AdvRouter: AdvRouter,
+	}
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return lsaKey, false
+	}
+	selfOrigLsaEnt, _ := server.AreaSelfOrigLsa[lsdbKey]
+	var val LsdbSliceEnt
+	val.AreaId = lsdbKey.AreaId
+	val.LSType = lsaKey.LSType
+	val.LSId = lsaKey.LSId
+	val.AdvRtr = lsaKey.AdvRouter
+
+	ent, exist := lsDbEnt.NSSALsaMap[lsaKey]
+	if route.isDel {
+		if !exist {
+			return lsaKey, false
+		}
+		server.logger.Info(fmt.Sprintln("NSSA: Flush Type-7 LSA area ", areaId, " lsaKey ", lsaKey))
+		ent.LsaMd.LSAge = config.MaxAge
+		maxAgeLsaMap[lsaKey] = encodeASExternalLsa(ent, lsaKey)
+		delete(lsDbEnt.NSSALsaMap, lsaKey)
+		delete(selfOrigLsaEnt, lsaKey)
+		server.AreaLsdb[lsdbKey] = lsDbEnt
+		server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		server.DelLsdbEntry(val)
+		return lsaKey, true
+	}
+
+	/* P-bit tells the translator to propagate the route
+	   into the rest of the AS. An ABR originates Type-5 itself. */
+	fwdAddr := server.getNssaFwdAddr(areaId)
+	options := uint8(0)
+	if !server.ospfGlobalConf.AreaBdrRtrStatus && fwdAddr != 0 {
+		options = NPOption
+	} else {
+		fwdAddr = 0
+	}
+	if !exist {
+		ent.LsaMd.LSSequenceNum = InitialSequenceNumber
+	} else {
+		ent.LsaMd.LSSequenceNum = ent.LsaMd.LSSequenceNum + 1
+	}
+	ent.LsaMd.LSAge = 0
+	ent.LsaMd.Options = options
+	ent.LsaMd.LSChecksum = 0
+	ent.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
+	ent.BitE = true
+	ent.FwdAddr = fwdAddr
+	ent.Metric = route.metric
+	ent.Netmask = route.mask
+	ent.ExtRouteTag = 0
+	LsaEnc := encodeASExternalLsa(ent, lsaKey)
+	checksumOffset := uint16(14)
+	ent.LsaMd.LSChecksum = computeFletcherChecksum(LsaEnc[2:], checksumOffset)
+	lsDbEnt.NSSALsaMap[lsaKey] = ent
+	server.AreaLsdb[lsdbKey] = lsDbEnt
+	selfOrigLsaEnt[lsaKey] = true
+	server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+	server.logger.Info(fmt.Sprintln("NSSA: Added Type-7 LSA to area ", areaId, " lsaKey ", lsaKey))
+	if !exist {
+		server.LsdbSlice = append(server.LsdbSlice, val)
+		msg := DbLsdbMsg{
+			entry: val,
+			op:    true,
+		}
+		server.DbLsdbOp <- msg
+	}
+	return lsaKey, true
+}
+
+/*@fn processNssaExtRouteUpd
+Redistributed routes are advertised as Type-7 LSAs
+in every NSSA the router is attached to.
+*/
+func (server *OSPFServer) processNssaExtRouteUpd(msg RouteMdata) {
+	ifkey := IntfConfKey{}
+	nbr := NeighborConfKey{}
+	flush := false
+	for key, _ := range server.AreaConfMap {
+		if !server.isNssaArea(key.AreaId) {
+			continue
+		}
+		areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
+		lsaKey, changed := server.generateNSSALsa(areaId, msg)
+		if !changed {
+			continue
+		}
+		if msg.isDel {
+			flush = true
+		} else {
+			server.sendLsdbToNeighborEvent(ifkey, nbr, areaId, 0, 0, lsaKey, LSANSSAFLOOD)
+		}
+	}
+	if flush {
+		server.ospfNbrLsaUpdSendCh <- ospfFloodMsg{
+			lsOp: LSAAGE,
+		}
+	}
+}
+
+/*@fn lookupNssaFwdRoute
+Path to the forwarding address of Type-7 LSA is
+the intra area route which best matches the address.
+Zero forwarding address resolves to the ASBR.
+*/
+func (server *OSPFServer) lookupNssaFwdRoute(areaIdKey AreaIdKey, lsaKey LsaKey, lsaEnt ASExternalLsa) (RoutingTblEntry, bool) {
+	var fwdEnt RoutingTblEntry
+	tempAreaRoutingTbl := server.TempAreaRoutingTbl[areaIdKey]
+	if lsaEnt.FwdAddr == 0 {
+		for _, destType := range []DestType{ASBdrRouter, ASAreaBdrRouter} {
+			rKey := RoutingTblEntryKey{
+				DestId:   lsaKey.AdvRouter,
+				AddrMask: 0,
+				DestType: destType,
+			}
+			rEnt, exist := tempAreaRoutingTbl.RoutingTblMap[rKey]
+			if exist {
+				return rEnt, true
+			}
+		}
+		return fwdEnt, false
+	}
+	found := false
+	var bestMask uint32
+	for rKey, rEnt := range tempAreaRoutingTbl.RoutingTblMap {
+		if rKey.DestType != Network ||
+			rEnt.PathType != IntraArea {
+			continue
+		}
+		if lsaEnt.FwdAddr&rKey.AddrMask != rKey.DestId {
+			continue
+		}
+		if !found || rKey.AddrMask > bestMask {
+			fwdEnt = rEnt
+			bestMask = rKey.AddrMask
+			found = true
+		}
+	}
+	return fwdEnt, found
+}
+
+/*@fn HandleNSSALsa
+Type-7 route calculation RFC 3101 2.5
+*/
+func (server *OSPFServer) HandleNSSALsa(areaId uint32) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		server.logger.Err(fmt.Sprintln("Unable to find Area Lsdb entry"))
+		return
+	}
+	areaIdKey := AreaIdKey{
+		AreaId: areaId,
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	for lsaKey, lsaEnt := range lsDbEnt.NSSALsaMap {
+		if lsaEnt.Metric == LSInfinity ||
+			lsaEnt.LsaMd.LSAge == config.MaxAge {
+			server.logger.Info("Ignoring NSSA LSA...")
+			continue
+		}
+		if lsaKey.AdvRouter == rtrId {
+			server.logger.Info("Self originated NSSA LSA, so no need to process for routing table calc")
+			continue
+		}
+		fwdEnt, exist := server.lookupNssaFwdRoute(areaIdKey, lsaKey, lsaEnt)
+		if !exist || fwdEnt.NumOfPaths == 0 {
+			server.logger.Info(fmt.Sprintln("NSSA: No route to forwarding address for lsaKey", lsaKey))
+			continue
+		}
+		server.updateExtRoutingTblEntry(areaIdKey, lsaKey, lsaEnt, fwdEnt)
+	}
+}
+
+/*@fn isElectedNssaTranslator
+Translator election RFC 3101 3.1.
+NSSA border router with Nt-bit set always translates,
+otherwise the one with highest router id is elected.
+*/
+func (server *OSPFServer) isElectedNssaTranslator(areaId uint32) bool {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return false
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	for lsaKey, lsaEnt := range lsDbEnt.RouterLsaMap {
+		if lsaKey.AdvRouter == rtrId ||
+			!lsaEnt.BitB ||
+			lsaEnt.LsaMd.LSAge == config.MaxAge {
+			continue
+		}
+		if !server.isAreaBdrRtrReachable(lsaKey.AdvRouter) {
+			continue
+		}
+		if lsaEnt.BitNt || lsaKey.AdvRouter > rtrId {
+			return false
+		}
+	}
+	return true
+}
+
+func (server *OSPFServer) isAreaBdrRtrReachable(rtrId uint32) bool {
+	for _, destType := range []DestType{AreaBdrRouter, ASAreaBdrRouter} {
+		rKey := RoutingTblEntryKey{
+			DestId:   rtrId,
+			AddrMask: 0,
+			DestType: destType,
+		}
+		if _, exist := server.GlobalRoutingTbl[rKey]; exist {
+			return true
+		}
+	}
+	return false
+}
+
+/*@fn updateNssaTranslatorState
+Update the translator state of the NSSA.
+Returns true if Type-7 LSAs of the area are to be translated.
+*/
+func (server *OSPFServer) updateNssaTranslatorState(key AreaConfKey, areaId uint32) bool {
+	areaConf, _ := server.AreaConfMap[key]
+	areaState, _ := server.AreaStateMap[key]
+	state := config.NssaTranslatorDisabled
+	if server.ospfGlobalConf.AreaBdrRtrStatus {
+		if areaConf.AreaNssaTranslatorRole == config.Always {
+			state = config.NssaTranslatorEnabled
+		} else if server.isElectedNssaTranslator(areaId) {
+			state = config.NssaTranslatorElected
+		}
+	}
+	if state != areaState.AreaNssaTranslatorState {
+		server.logger.Info(fmt.Sprintln("NSSA: Translator state change area ", key.AreaId,
+			" old ", areaState.AreaNssaTranslatorState, " new ", state))
+		if state == config.NssaTranslatorDisabled &&
+			server.ospfGlobalConf.AreaBdrRtrStatus {
+			// Keep translating till the new translator takes over
+			areaState.nssaStabilityExpiry = time.Now().Add(NssaTranslatorStabilityInterval)
+		} else {
+			areaState.nssaStabilityExpiry = time.Time{}
+		}
+		areaState.AreaNssaTranslatorState = state
+		areaState.AreaNssaTranslatorEvents++
+	}
+	translate := state != config.NssaTranslatorDisabled
+	if !translate && !areaState.nssaStabilityExpiry.IsZero() {
+		if time.Now().Before(areaState.nssaStabilityExpiry) {
+			translate = true
+		} else {
+			areaState.nssaStabilityExpiry = time.Time{}
+		}
+	}
+	server.AreaStateMap[key] = areaState
+	return translate
+}
+
+/*@fn checkNssaTranslatorStability
+Called from LSDB ticker to stop translation once
+TranslatorStabilityInterval expires.
+*/
+func (server *OSPFServer) checkNssaTranslatorStability() {
+	for _, areaState := range server.AreaStateMap {
+		if !areaState.nssaStabilityExpiry.IsZero() &&
+			!time.Now().Before(areaState.nssaStabilityExpiry) {
+			server.processNssaTranslation()
+			return
+		}
+	}
+}
+
+/*@fn isNssaLsaTranslatable
+Only Type-7 LSAs with P-bit and non-zero forwarding address
+which are selected for the routing table are translated.
+*/
+func (server *OSPFServer) isNssaLsaTranslatable(areaId uint32, lsaKey LsaKey, lsaEnt ASExternalLsa) bool {
+	if lsaEnt.LsaMd.Options&NPOption == 0 ||
+		lsaEnt.FwdAddr == 0 {
+		return false
+	}
+	if lsaEnt.Metric == LSInfinity ||
+		lsaEnt.LsaMd.LSAge == config.MaxAge {
+		return false
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	if lsaKey.AdvRouter == rtrId {
+		return false
+	}
+	rKey := RoutingTblEntryKey{
+		DestId:   lsaKey.LSId & lsaEnt.Netmask,
+		AddrMask: lsaEnt.Netmask,
+		DestType: Network,
+	}
+	gEnt, exist := server.GlobalRoutingTbl[rKey]
+	if !exist || gEnt.AreaId != areaId ||
+		gEnt.RoutingTblEnt.LSOrigin != lsaKey {
+		return false
+	}
+	return true
+}
+
+/*@fn translateNssaLsa
+Install the Type-5 LSA translated from Type-7 LSA
+in all the areas which accept AS external LSAs.
+Returns false if the Type-5 LSA is originated locally.
+*/
+func (server *OSPFServer) translateNssaLsa(lsaKey LsaKey, lsaEnt ASExternalLsa) (extKey LsaKey, changed bool, ok bool) {
+	extKey = LsaKey{
+		LSType:    ASExternalLSA,
+		LSId:      lsaKey.LSId,
+		AdvRouter: convertIPv4ToUint32(server.ospfGlobalConf.RouterId),
+	}
+	_, translated := server.NssaTranslatedLsa[extKey]
+	for lsdbKey, lsDbEnt := range server.AreaLsdb {
+		areaId := config.AreaId(convertUint32ToIPv4(lsdbKey.AreaId))
+		if server.isStubArea(areaId) || server.isNssaArea(areaId) {
+			continue
+		}
+		ent, exist := lsDbEnt.ASExternalLsaMap[extKey]
+		if exist && !translated {
+			server.logger.Info(fmt.Sprintln("NSSA: AS external LSA is originated locally, skip translation ", extKey))
+			return extKey, false, false
+		}
+		if exist && ent.Netmask == lsaEnt.Netmask &&
+			ent.Metric == lsaEnt.Metric &&
+			ent.BitE == lsaEnt.BitE &&
+			ent.FwdAddr == lsaEnt.FwdAddr &&
+			ent.ExtRouteTag == lsaEnt.ExtRouteTag {
+			continue
+		}
+		if !exist {
+			ent.LsaMd.LSSequenceNum = InitialSequenceNumber
+		} else {
+			ent.LsaMd.LSSequenceNum = ent.LsaMd.LSSequenceNum + 1
+		}
+		ent.LsaMd.LSAge = 0
+		ent.LsaMd.Options = EOption
+		ent.LsaMd.LSChecksum = 0
+		ent.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
+		ent.Netmask = lsaEnt.Netmask
+		ent.BitE = lsaEnt.BitE
+		ent.Metric = lsaEnt.Metric
+		ent.FwdAddr = lsaEnt.FwdAddr
+		ent.ExtRouteTag = lsaEnt.ExtRouteTag
+		LsaEnc := encodeASExternalLsa(ent, extKey)
+		checksumOffset := uint16(14)
+		ent.LsaMd.LSChecksum = computeFletcherChecksum(LsaEnc[2:], checksumOffset)
+		lsDbEnt.ASExternalLsaMap[extKey] = ent
+		server.AreaLsdb[lsdbKey] = lsDbEnt
+		selfOrigLsaEnt, _ := server.AreaSelfOrigLsa[lsdbKey]
+		selfOrigLsaEnt[extKey] = true
+		server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		server.logger.Info(fmt.Sprintln("NSSA: Translated ", lsaKey, " to ", extKey, " area ", areaId))
+		if !exist {
+			var val LsdbSliceEnt
+			val.AreaId = lsdbKey.AreaId
+			val.LSType = extKey.LSType
+			val.LSId = extKey.LSId
+			val.AdvRtr = extKey.AdvRouter
+			server.LsdbSlice = append(server.LsdbSlice, val)
+			msg := DbLsdbMsg{
+				entry: val,
+				op:    true,
+			}
+			server.DbLsdbOp <- msg
+		}
+		changed = true
+	}
+	return extKey, changed, true
+}
+
+/*@fn flushTranslatedLsa
+Flush the Type-5 LSA when the Type-7 LSA is withdrawn or
+the router is no more the translator.
+*/
+func (server *OSPFServer) flushTranslatedLsa(extKey LsaKey) {
+	for lsdbKey, lsDbEnt := range server.AreaLsdb {
+		ent, exist := lsDbEnt.ASExternalLsaMap[extKey]
+		if !exist {
+			continue
+		}
+		server.logger.Info(fmt.Sprintln("NSSA: Flush translated LSA ", extKey, " area ", lsdbKey.AreaId))
+		ent.LsaMd.LSAge = config.MaxAge
+		maxAgeLsaMap[extKey] = encodeASExternalLsa(ent, extKey)
+		delete(lsDbEnt.ASExternalLsaMap, extKey)
+		server.AreaLsdb[lsdbKey] = lsDbEnt
+		selfOrigLsaEnt, _ := server.AreaSelfOrigLsa[lsdbKey]
+		delete(selfOrigLsaEnt, extKey)
+		server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+		var val LsdbSliceEnt
+		val.AreaId = lsdbKey.AreaId
+		val.LSType = extKey.LSType
+		val.LSId = extKey.LSId
+		val.AdvRtr = extKey.AdvRouter
+		server.DelLsdbEntry(val)
+	}
+}
+
+/*@fn processNssaTranslation
+Run translator election for all NSSAs and translate
+Type-7 LSAs into Type-5 LSAs. Called after SPF
+calculation is done.
+*/
+func (server *OSPFServer) processNssaTranslation() {
+	ifkey := IntfConfKey{}
+	nbr := NeighborConfKey{}
+	translated := make(map[LsaKey]bool)
+	for key, _ := range server.AreaConfMap {
+		if !server.isNssaArea(key.AreaId) {
+			continue
+		}
+		areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
+		if !server.updateNssaTranslatorState(key, areaId) {
+			continue
+		}
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+		if !exist {
+			continue
+		}
+		for lsaKey, lsaEnt := range lsDbEnt.NSSALsaMap {
+			if !server.isNssaLsaTranslatable(areaId, lsaKey, lsaEnt) {
+				continue
+			}
+			extKey, changed, ok := server.translateNssaLsa(lsaKey, lsaEnt)
+			if !ok {
+				continue
+			}
+			translated[extKey] = true
+			if changed {
+				server.sendLsdbToNeighborEvent(ifkey, nbr, 0, 0, 0, extKey, LSAEXTFLOOD)
+			}
+		}
+	}
+	flush := false
+	for extKey, _ := range server.NssaTranslatedLsa {
+		if !translated[extKey] {
+			server.flushTranslatedLsa(extKey)
+			flush = true
+		}
+	}
+	server.NssaTranslatedLsa = translated
+	if flush {
+		server.ospfNbrLsaUpdSendCh <- ospfFloodMsg{
+			lsOp: LSAAGE,
+		}
+	}
+}
@@ -0,0 +1,127 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"l3/ospf/config"
+	"testing"
+)
+
+func TestOspfNssaLsaCodec(t *testing.T) {
+	lsaKey := LsaKey{
+		LSType:    NSSALSA,
+		LSId:      convertAreaOrRouterIdUint32("192.168.10.0"),
+		AdvRouter: convertAreaOrRouterIdUint32("2.2.2.2"),
+	}
+	lsa := ASExternalLsa{
+		BitE:    true,
+		Netmask: convertAreaOrRouterIdUint32("255.255.255.0"),
+		Metric:  20,
+		FwdAddr: convertAreaOrRouterIdUint32("10.1.1.2"),
+	}
+	lsa.LsaMd.Options = NPOption
+	lsa.LsaMd.LSSequenceNum = InitialSequenceNumber
+	lsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 16)
+	dlsa := NewASExternalLsa()
+	dlsaKey := NewLsaKey()
+	decodeASExternalLsa(encodeASExternalLsa(lsa, lsaKey), dlsa, dlsaKey)
+	if *dlsaKey != lsaKey {
+		t.Fatal("NSSA LSA key mismatch", *dlsaKey, lsaKey)
+	}
+	if dlsa.LsaMd.Options&NPOption == 0 || dlsa.FwdAddr != lsa.FwdAddr ||
+		dlsa.Metric != lsa.Metric || !dlsa.BitE {
+		t.Fatal("NSSA LSA decode mismatch", *dlsa)
+	}
+
+	rlsa := RouterLsa{
+		BitB:  true,
+		BitNt: true,
+	}
+	rlsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      lsaKey.AdvRouter,
+		AdvRouter: lsaKey.AdvRouter,
+	}
+	drlsa := NewRouterLsa()
+	decodeRouterLsa(encodeRouterLsa(rlsa, rlsaKey), drlsa, NewLsaKey())
+	if !drlsa.BitB || !drlsa.BitNt || drlsa.BitE {
+		t.Fatal("Router LSA Nt bit mismatch", *drlsa)
+	}
+}
+
+func TestOspfNssaTranslatorElection(t *testing.T) {
+	server := getServerObject()
+	areaId := convertAreaOrRouterIdUint32("0.0.0.1")
+	areaConfKey := AreaConfKey{
+		AreaId: config.AreaId("0.0.0.1"),
+	}
+	server.AreaConfMap[areaConfKey] = AreaConf{
+		ImportAsExtern:         config.ImportNssa,
+		AreaNssaTranslatorRole: config.Candidate,
+	}
+	if server.getAreaOptions(areaConfKey.AreaId, EOption) != NPOption {
+		t.Fatal("NSSA options should have N-bit set and E-bit clear")
+	}
+	server.ospfGlobalConf.RouterId = []byte{2, 2, 2, 2}
+	server.ospfGlobalConf.AreaBdrRtrStatus = true
+	server.GlobalRoutingTbl = make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
+	server.initLSDatabase(areaId)
+
+	if !server.updateNssaTranslatorState(areaConfKey, areaId) {
+		t.Fatal("Only NSSA border router should be elected translator")
+	}
+
+	peer := convertAreaOrRouterIdUint32("3.3.3.3")
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt := server.AreaLsdb[lsdbKey]
+	lsDbEnt.RouterLsaMap[LsaKey{LSType: RouterLSA, LSId: peer, AdvRouter: peer}] = RouterLsa{
+		BitB: true,
+	}
+	server.AreaLsdb[lsdbKey] = lsDbEnt
+	if !server.isElectedNssaTranslator(areaId) {
+		t.Fatal("Unreachable border router should not take part in election")
+	}
+	server.GlobalRoutingTbl[RoutingTblEntryKey{DestId: peer, DestType: AreaBdrRouter}] = GlobalRoutingTblEntry{}
+	if server.isElectedNssaTranslator(areaId) {
+		t.Fatal("Border router with higher router id should be elected translator")
+	}
+	if !server.updateNssaTranslatorState(areaConfKey, areaId) {
+		t.Fatal("Translation should continue for TranslatorStabilityInterval")
+	}
+	areaState := server.AreaStateMap[areaConfKey]
+	if areaState.AreaNssaTranslatorState != config.NssaTranslatorDisabled ||
+		areaState.AreaNssaTranslatorEvents != 2 {
+		t.Fatal("Translator state is not updated", areaState)
+	}
+
+	conf := server.AreaConfMap[areaConfKey]
+	conf.AreaNssaTranslatorRole = config.Always
+	server.AreaConfMap[areaConfKey] = conf
+	server.updateNssaTranslatorState(areaConfKey, areaId)
+	if server.AreaStateMap[areaConfKey].AreaNssaTranslatorState != config.NssaTranslatorEnabled {
+		t.Fatal("Translator role always should enable translation")
+	}
+}
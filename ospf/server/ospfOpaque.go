@@ -0,0 +1,375 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"l3/ospf/config"
+	"net"
+)
+
+/*
+RFC 5250 Opaque LSAs.
+The flooding scope is given by the LS type. Type 9 LSAs stay on
+the link they are received on and are kept per interface, type 10
+LSAs are flooded within the area and type 11 LSAs within the AS
+except stub areas and NSSAs.
+The LSDB does not interpret the opaque information, it is decoded
+by the application owning the opaque type (e.g. TE).
+*/
+
+func (server *OSPFServer) getLinkOpaqueLsaFromLsdb(intfKey IntfConfKey, lsaKey LsaKey) (lsa OpaqueLsa, retVal int) {
+	linkLsdb, exist := server.LinkOpaqueLsdb[intfKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	lsa, exist = linkLsdb[lsaKey]
+	if !exist {
+		return lsa, LsdbEntryNotFound
+	}
+	return lsa, LsdbEntryFound
+}
+
+func (server *OSPFServer) getOpaqueLsaFromLsdb(areaId uint32, intfKey IntfConfKey, lsaKey LsaKey) (lsa OpaqueLsa, retVal int) {
+	switch lsaKey.LSType {
+	case LocalOpaqueLSA:
+		return server.getLinkOpaqueLsaFromLsdb(intfKey, lsaKey)
+	case AreaOpaqueLSA:
+		return server.getAreaOpaqueLsaFromLsdb(areaId, lsaKey)
+	case ASOpaqueLSA:
+		return server.getASOpaqueLsaFromLsdb(areaId, lsaKey)
+	}
+	return lsa, LsdbEntryNotFound
+}
+
+/*@fn getASOpaqueAreas
+Areas in which AS scope opaque LSAs are kept.
+*/
+func (server *OSPFServer) getASOpaqueAreas() []uint32 {
+	var areas []uint32
+	for key, _ := range server.AreaConfMap {
+		if server.isStubArea(key.AreaId) || server.isNssaArea(key.AreaId) {
+			continue
+		}
+		areas = append(areas, convertAreaOrRouterIdUint32(string(key.AreaId)))
+	}
+	return areas
+}
+
+func (server *OSPFServer) addOpaqueLsaToArea(areaId uint32, lsaKey LsaKey, lsa OpaqueLsa) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return
+	}
+	lsaMap := lsDbEnt.AreaOpaqueLsaMap
+	if lsaKey.LSType == ASOpaqueLSA {
+		lsaMap = lsDbEnt.ASOpaqueLsaMap
+	}
+	_, exist = lsaMap[lsaKey]
+	lsaMap[lsaKey] = lsa
+	if !exist {
+		var val LsdbSliceEnt
+		val.AreaId = lsdbKey.AreaId
+		val.LSType = lsaKey.LSType
+		val.LSId = lsaKey.LSId
+		val.AdvRtr = lsaKey.AdvRouter
+		server.LsdbSlice = append(server.LsdbSlice, val)
+		msg := DbLsdbMsg{
+			entry: val,
+			op:    true,
+		}
+		server.DbLsdbOp <- msg
+	}
+}
+
+func (server *OSPFServer) delOpaqueLsaFromArea(areaId uint32, lsaKey LsaKey) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return
+	}
+	lsaMap := lsDbEnt.AreaOpaqueLsaMap
+	if lsaKey.LSType == ASOpaqueLSA {
+		lsaMap = lsDbEnt.ASOpaqueLsaMap
+	}
+	if _, exist = lsaMap[lsaKey]; !exist {
+		return
+	}
+	delete(lsaMap, lsaKey)
+	var val LsdbSliceEnt
+	val.AreaId = lsdbKey.AreaId
+	val.LSType = lsaKey.LSType
+	val.LSId = lsaKey.LSId
+	val.AdvRtr = lsaKey.AdvRouter
+	err := server.DelLsdbEntry(val)
+	if err != nil {
+		server.logger.Info(fmt.Sprintln("DB: Failed to delete entry from db ", lsaKey))
+	}
+}
+
+/*@fn installOpaqueLsa
+Add (or delete if del is set) the opaque LSA as per its scope.
+*/
+func (server *OSPFServer) installOpaqueLsa(areaId uint32, intfKey IntfConfKey,
+	lsaKey LsaKey, lsa OpaqueLsa, del bool) {
+	switch lsaKey.LSType {
+	case LocalOpaqueLSA:
+		linkLsdb, exist := server.LinkOpaqueLsdb[intfKey]
+		if !exist {
+			if del {
+				return
+			}
+			linkLsdb = make(map[LsaKey]OpaqueLsa)
+			server.LinkOpaqueLsdb[intfKey] = linkLsdb
+		}
+		if del {
+			delete(linkLsdb, lsaKey)
+		} else {
+			linkLsdb[lsaKey] = lsa
+		}
+	case AreaOpaqueLSA:
+		if del {
+			server.delOpaqueLsaFromArea(areaId, lsaKey)
+		} else {
+			server.addOpaqueLsaToArea(areaId, lsaKey, lsa)
+		}
+	case ASOpaqueLSA:
+		for _, asArea := range server.getASOpaqueAreas() {
+			if del {
+				server.delOpaqueLsaFromArea(asArea, lsaKey)
+			} else {
+				server.addOpaqueLsaToArea(asArea, lsaKey, lsa)
+			}
+		}
+	}
+}
+
+/*@fn processOpaqueLsdbUpdate
+Received opaque LSAs are installed without SPF run.
+MaxAge instances remove the LSA from the LSDB.
+*/
+func (server *OSPFServer) processOpaqueLsdbUpdate(msg LsdbUpdateMsg) {
+	if !server.ospfGlobalConf.OpaqueLsaSupport {
+		return
+	}
+	lsaKey := NewLsaKey()
+	lsa := NewOpaqueLsa()
+	decodeOpaqueLsa(msg.Data, lsa, lsaKey)
+	if int(lsa.LsaMd.LSLen) > len(msg.Data) {
+		server.logger.Err(fmt.Sprintln("LSDB: Invalid opaque LSA length ", lsaKey))
+		return
+	}
+	csum := computeFletcherChecksum(msg.Data[2:lsa.LsaMd.LSLen], FLETCHER_CHECKSUM_VALIDATE)
+	if csum != 0 {
+		server.logger.Err("LSDB: Invalid opaque LSA Checksum")
+		return
+	}
+	if server.selfGenLsaCheck(*lsaKey) {
+		server.logger.Info("LSDB: Recvd a self generated opaque LSA")
+		return
+	}
+	del := msg.MsgType == LsdbDel || lsa.LsaMd.LSAge == LSA_MAX_AGE
+	server.logger.Info(fmt.Sprintln("LSDB: Opaque lsa ", *lsaKey, " opaque type ",
+		getOpaqueType(lsaKey.LSId), " del ", del))
+	server.installOpaqueLsa(msg.AreaId, msg.IntfKey, *lsaKey, *lsa, del)
+}
+
+/*@fn processMaxAgeLinkOpaqueLsa
+Age link local opaque LSAs. These are removed silently
+as the originator flushes them on the link.
+*/
+func (server *OSPFServer) processMaxAgeLinkOpaqueLsa() {
+	for intfKey, linkLsdb := range server.LinkOpaqueLsdb {
+		if _, exist := server.IntfConfMap[intfKey]; !exist {
+			delete(server.LinkOpaqueLsdb, intfKey)
+			continue
+		}
+		for lsaKey, lsa := range linkLsdb {
+			if lsa.LsaMd.LSAge >= config.MaxAge {
+				delete(linkLsdb, lsaKey)
+				continue
+			}
+			lsa.LsaMd.LSAge++
+			linkLsdb[lsaKey] = lsa
+		}
+	}
+}
+
+func setOpaqueLsaChecksum(lsa *OpaqueLsa, lsaKey LsaKey) {
+	lsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + len(lsa.Data))
+	lsa.LsaMd.LSChecksum = 0
+	LsaEnc := encodeOpaqueLsa(*lsa, lsaKey)
+	checksumOffset := uint16(14)
+	lsa.LsaMd.LSChecksum = computeFletcherChecksum(LsaEnc[2:], checksumOffset)
+}
+
+/*@fn originateOpaqueLsa
+Install a self originated area or AS scope opaque LSA and flood it.
+A new instance is originated only if the opaque information changed.
+*/
+func (server *OSPFServer) originateOpaqueLsa(areaId uint32, lsaKey LsaKey, data []byte) {
+	if !server.ospfGlobalConf.OpaqueLsaSupport {
+		return
+	}
+	areas := []uint32{areaId}
+	if lsaKey.LSType == ASOpaqueLSA {
+		areas = server.getASOpaqueAreas()
+		if len(areas) == 0 {
+			return
+		}
+	}
+	ent, ret := server.getOpaqueLsaFromLsdb(areas[0], IntfConfKey{}, lsaKey)
+	if ret == LsdbEntryFound && bytes.Equal(ent.Data, data) {
+		return
+	}
+	lsa := OpaqueLsa{
+		Data: data,
+	}
+	lsa.LsaMd.LSAge = 0
+	lsa.LsaMd.Options = server.getAreaOptions(config.AreaId(convertUint32ToIPv4(areas[0])), EOption)
+	if ret == LsdbEntryFound {
+		lsa.LsaMd.LSSequenceNum = ent.LsaMd.LSSequenceNum + 1
+	} else {
+		lsa.LsaMd.LSSequenceNum = InitialSequenceNumber
+	}
+	setOpaqueLsaChecksum(&lsa, lsaKey)
+	for _, area := range areas {
+		server.addOpaqueLsaToArea(area, lsaKey, lsa)
+		lsdbKey := LsdbKey{
+			AreaId: area,
+		}
+		selfOrigLsaEnt, exist := server.AreaSelfOrigLsa[lsdbKey]
+		if !exist {
+			continue
+		}
+		selfOrigLsaEnt[lsaKey] = true
+		server.AreaSelfOrigLsa[lsdbKey] = selfOrigLsaEnt
+	}
+	server.logger.Info(fmt.Sprintln("LSDB: Originate opaque lsa ", lsaKey, " seq ", lsa.LsaMd.LSSequenceNum))
+	server.sendLsdbToNeighborEvent(IntfConfKey{}, NeighborConfKey{}, areaId, 0, 0, lsaKey, LSAOPAQUEFLOOD)
+}
+
+/*@fn refreshOpaqueLsa
+Originate a new instance of the self originated opaque LSA
+on LSRefreshTime.
+*/
+func (server *OSPFServer) refreshOpaqueLsa(lsdbKey LsdbKey, lsaKey LsaKey) {
+	lsa, ret := server.getOpaqueLsaFromLsdb(lsdbKey.AreaId, IntfConfKey{}, lsaKey)
+	if ret == LsdbEntryNotFound {
+		return
+	}
+	if lsaKey.LSType == ASOpaqueLSA && lsa.LsaMd.LSAge == 0 {
+		return // already refreshed through another area
+	}
+	lsa.LsaMd.LSAge = 0
+	lsa.LsaMd.LSSequenceNum = lsa.LsaMd.LSSequenceNum + 1
+	setOpaqueLsaChecksum(&lsa, lsaKey)
+	server.installOpaqueLsa(lsdbKey.AreaId, IntfConfKey{}, lsaKey, lsa, false)
+	server.sendLsdbToNeighborEvent(IntfConfKey{}, NeighborConfKey{}, lsdbKey.AreaId, 0, 0, lsaKey, LSAOPAQUEFLOOD)
+}
+
+/*@fn flushOpaqueLsa
+Flush self originated opaque LSA. Caller sends LSAAGE flood message.
+*/
+func (server *OSPFServer) flushOpaqueLsa(areaId uint32, lsaKey LsaKey) {
+	lsa, ret := server.getOpaqueLsaFromLsdb(areaId, IntfConfKey{}, lsaKey)
+	if ret == LsdbEntryNotFound {
+		return
+	}
+	server.logger.Info(fmt.Sprintln("FLUSH: Opaque lsa ", lsaKey, " area ", areaId))
+	lsa.LsaMd.LSAge = config.MaxAge
+	maxAgeLsaMap[lsaKey] = encodeOpaqueLsa(lsa, lsaKey)
+	server.installOpaqueLsa(areaId, IntfConfKey{}, lsaKey, lsa, true)
+	for lsdbKey, selfOrigLsaEnt := range server.AreaSelfOrigLsa {
+		if lsaKey.LSType == AreaOpaqueLSA && lsdbKey.AreaId != areaId {
+			continue
+		}
+		delete(selfOrigLsaEnt, lsaKey)
+	}
+}
+
+/*@fn processOpaqueLSAFlood
+Flood opaque LSA as per its scope. Received LSAs are not sent
+back on the receiving interface.
+*/
+func (server *OSPFServer) processOpaqueLSAFlood(lsa_data ospfFloodMsg) {
+	lsaKey := lsa_data.lsaKey
+	lsaEncPkt := lsa_data.pkt
+	if lsaEncPkt == nil {
+		lsa, ret := server.getOpaqueLsaFromLsdb(lsa_data.areaId, lsa_data.intfKey, lsaKey)
+		if ret == LsdbEntryNotFound {
+			server.logger.Info(fmt.Sprintln("OPAQUE: Lsa not found . Area",
+				lsa_data.areaId, " LSA key ", lsaKey))
+			return
+		}
+		lsaEncPkt = encodeOpaqueLsa(lsa, lsaKey)
+		checksumOffset := uint16(14)
+		checkSum := computeFletcherChecksum(lsaEncPkt[2:], checksumOffset)
+		binary.BigEndian.PutUint16(lsaEncPkt[16:18], checkSum)
+	}
+	lsas_enc := make([]byte, 4)
+	binary.BigEndian.PutUint32(lsas_enc, uint32(1))
+	pkt := append(lsas_enc, lsaEncPkt...)
+
+	rxIntfKey := IntfConfKey{}
+	if nbrConf, exist := server.NeighborConfigMap[lsa_data.nbrKey]; exist {
+		rxIntfKey = nbrConf.intfConfKey
+	}
+	dstMac := net.HardwareAddr{0x01, 0x00, 0x5e, 0x00, 0x00, 0x05}
+	dstIp := net.IP{224, 0, 0, 5}
+	for key, intf := range server.IntfConfMap {
+		if key == rxIntfKey {
+			continue
+		}
+		areaId := config.AreaId(convertIPInByteToString(intf.IfAreaId))
+		switch lsaKey.LSType {
+		case LocalOpaqueLSA:
+			if key != lsa_data.intfKey {
+				continue
+			}
+		case AreaOpaqueLSA:
+			if convertIPv4ToUint32(intf.IfAreaId) != lsa_data.areaId {
+				continue
+			}
+		case ASOpaqueLSA:
+			if server.isStubArea(areaId) || server.isNssaArea(areaId) ||
+				intf.IfType == config.VirtualLink {
+				continue
+			}
+		}
+		nbrMdata, ok := ospfIntfToNbrMap[key]
+		if ok && len(nbrMdata.nbrList) > 0 {
+			send_pkt := server.BuildLsaUpdPkt(key, intf, dstMac, dstIp, len(pkt), pkt)
+			server.logger.Info(fmt.Sprintln("OPAQUE: Send  LSA to interface ", intf.IfIpAddr, " lsa key ", lsaKey))
+			server.SendOspfPkt(key, send_pkt)
+		}
+	}
+}
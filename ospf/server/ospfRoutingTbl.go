@@ -92,6 +92,7 @@ type RoutingTblEntry struct {
 type GlobalRoutingTblEntry struct {
 	AreaId        uint32 // Area
 	RoutingTblEnt RoutingTblEntry
+	LfaNextHops   map[NextHop]bool // Loop free alternates
 }
 
 /*
@@ -216,7 +217,7 @@ func (server *OSPFServer) findP2PNextHopIP(vFirst VertexKey, vSecond VertexKey,
 	}
 	for _, link := range secondLsa.LinkDetails {
 		if link.LinkId == vFirst.AdvRtr &&
-			link.LinkType == P2PLink {
+			(link.LinkType == P2PLink || link.LinkType == VirtualLink) {
 			secondLink = link
 			flag = true
 			break
@@ -635,6 +636,15 @@ func (server *OSPFServer) CompareRoutes(rKey RoutingTblEntryKey) bool {
 			return false
 		}
 	}
+	if len(oldEnt.LfaNextHops) != len(newEnt.LfaNextHops) {
+		return false
+	}
+	for key, _ := range oldEnt.LfaNextHops {
+		_, exist := newEnt.LfaNextHops[key]
+		if !exist {
+			return false
+		}
+	}
 	return true
 }
 
@@ -701,6 +711,19 @@ func (server *OSPFServer) InstallRoute(rKey RoutingTblEntryKey) {
 	metric := ribd.Int(newEnt.RoutingTblEnt.Cost)     //int : 3
 	routeType := "OSPF"                               // 7 : String
 	//routeType := "IBGP" // 7 : String
+	backupNextHop := make([]*ribd.NextHopInfo, 0)
+	for key, _ := range newEnt.LfaNextHops {
+		ipProp, exist := server.ipPropertyMap[key.IfIPAddr]
+		if !exist {
+			server.logger.Err(fmt.Sprintln("Unable to find entry for ip:", key.IfIPAddr, "in ipPropertyMap"))
+			continue
+		}
+		nextHopIfIndex := asicdCommonDefs.GetIfIndexFromIntfIdAndIntfType(int(ipProp.IfId), int(ipProp.IfType))
+		backupNextHop = append(backupNextHop, &ribd.NextHopInfo{
+			NextHopIp:     convertUint32ToIPv4(key.NextHopIP),
+			NextHopIntRef: strconv.Itoa(int(nextHopIfIndex)),
+		})
+	}
 	for key, _ := range newEnt.RoutingTblEnt.NextHops {
 		nextHopIp := convertUint32ToIPv4(key.NextHopIP) //String : 4
 		ipProp, exist := server.ipPropertyMap[key.IfIPAddr]
@@ -722,6 +745,7 @@ func (server *OSPFServer) InstallRoute(rKey RoutingTblEntryKey) {
 		}
 		cfg.NextHop = make([]*ribd.NextHopInfo, 0)
 		cfg.NextHop = append(cfg.NextHop, &nextHopInfo)
+		cfg.BackupNextHop = backupNextHop
 		ret, err := server.ribdClient.ClientHdl.CreateIPv4Route(&cfg) //destNetIp, networkMask, metric, nextHopIp, nextHopIfType, nextHopIfIndex, routeType)
 		if err != nil {
 			server.logger.Err(fmt.Sprintln("Error Installing Route:", err))
@@ -758,6 +782,9 @@ func (server *OSPFServer) ConsolidatingRoutingTbl() {
 			} else {
 				ent.AreaId = areaId
 				ent.RoutingTblEnt = rEnt
+				if rEnt.PathType == IntraArea {
+					ent.LfaNextHops = server.SpfAreaCache[areaId].LfaRoutes[rKey]
+				}
 			}
 			server.TempGlobalRoutingTbl[rKey] = ent
 		}
@@ -82,7 +82,7 @@ func (server *OSPFServer) processIPv4Layer(ipLayer gopacket.Layer, ipAddr net.IP
 	return nil
 }
 
-func (server *OSPFServer) processOspfHeader(ospfPkt []byte, key IntfConfKey, md *OspfHdrMetadata) error {
+func (server *OSPFServer) processOspfHeader(ospfPkt []byte, key IntfConfKey, md *OspfHdrMetadata, ipHdrMd *IpHdrMetadata) error {
 	if len(ospfPkt) < OSPF_HEADER_SIZE {
 		err := errors.New("Invalid length of Ospf Header")
 		return err
@@ -118,18 +118,25 @@ func (server *OSPFServer) processOspfHeader(ospfPkt []byte, key IntfConfKey, md
 		md.backbone = false
 	}
 
+	if int(ospfHdr.pktlen) < OSPF_HEADER_SIZE || int(ospfHdr.pktlen) > len(ospfPkt) {
+		err := errors.New("Dropped because of invalid Ospf packet length")
+		return err
+	}
+
 	//OSPF Auth Type
 	if ent.IfAuthType != ospfHdr.authType {
-		err := errors.New("Dropped because of Router Id not matching")
+		if ent.IfAuthState != nil {
+			ent.IfAuthState.lock.Lock()
+			ent.IfAuthState.authFailures++
+			ent.IfAuthState.lock.Unlock()
+		}
+		err := errors.New("Dropped because of Auth Type not matching")
 		return err
 	}
 
-	//OSPF Header CheckSum
-	binary.BigEndian.PutUint16(ospfPkt[12:14], 0)
-	copy(ospfPkt[16:OSPF_HEADER_SIZE], []byte{0, 0, 0, 0, 0, 0, 0, 0})
-	csum := computeCheckSum(ospfPkt)
-	if csum != ospfHdr.chksum {
-		err := errors.New("Dropped because of invalid checksum")
+	//OSPF Header CheckSum and Authentication
+	err := server.authenticateOspfPkt(ospfPkt, ospfHdr, ent, ipHdrMd)
+	if err != nil {
 		return err
 	}
 
@@ -137,7 +144,6 @@ func (server *OSPFServer) processOspfHeader(ospfPkt []byte, key IntfConfKey, md
 	   ToDo:
 	   RFC 2328 Section 8.2
 	   1. Complete AreaID check
-	   2. Authentication
 	*/
 	md.pktType = OspfType(ospfHdr.pktType)
 	md.pktlen = ospfHdr.pktlen
@@ -171,6 +177,12 @@ func (server *OSPFServer) ProcessOspfRecvPkt(key IntfConfKey, pkt gopacket.Packe
 		return
 	}
 
+	// Backbone packets on a transit area interface belong to a virtual link
+	if vKey, ok := server.getVirtLinkRxIntfKey(ent, ipLayer.LayerPayload()); ok {
+		key = vKey
+		ent, _ = server.IntfConfMap[key]
+	}
+
 	ipHdrMd := NewIpHdrMetadata()
 	err := server.processIPv4Layer(ipLayer, ent.IfIpAddr, ipHdrMd)
 	if err != nil {
@@ -182,7 +194,7 @@ func (server *OSPFServer) ProcessOspfRecvPkt(key IntfConfKey, pkt gopacket.Packe
 
 	ospfHdrMd := NewOspfHdrMetadata()
 	ospfPkt := ipLayer.LayerPayload()
-	err = server.processOspfHeader(ospfPkt, key, ospfHdrMd)
+	err = server.processOspfHeader(ospfPkt, key, ospfHdrMd, ipHdrMd)
 	if err != nil {
 		server.logger.Err(fmt.Sprintln("Dropped because of Ospf Header processing", err))
 		return
@@ -190,7 +202,8 @@ func (server *OSPFServer) ProcessOspfRecvPkt(key IntfConfKey, pkt gopacket.Packe
 		//server.logger.Info("Ospfv2 Header is processed successfully")
 	}
 
-	ospfData := ospfPkt[OSPF_HEADER_SIZE:]
+	// Message digest appended with cryptographic authentication is not part of the packet
+	ospfData := ospfPkt[OSPF_HEADER_SIZE:ospfHdrMd.pktlen]
 	err = server.processOspfData(ospfData, ethHdrMd, ipHdrMd, ospfHdrMd, key)
 	if err != nil {
 		server.logger.Err(fmt.Sprintln("Dropped because of Ospf Header processing", err))
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"l3/ospf/config"
 	"sort"
+	"time"
 )
 
 type VertexKey struct {
@@ -282,6 +283,22 @@ func (server *OSPFServer) findRouterLsa(areaId uint32, LSId uint32) (err error)
 	return nil
 }
 
+func (server *OSPFServer) addAreaStub(areaId uint32, vertexKey VertexKey, lsaKey LsaKey, linkDetail LinkDetail) {
+	vKey := VertexKey{
+		Type:   SNetworkVertex,
+		ID:     linkDetail.LinkId,
+		AdvRtr: lsaKey.AdvRouter,
+	}
+	sentry, _ := server.AreaStubs[vKey]
+	sentry.NbrVertexKey = vertexKey
+	sentry.NbrVertexCost = linkDetail.LinkMetric
+	sentry.LinkData = linkDetail.LinkData
+	sentry.AreaId = areaId
+	sentry.LsaKey = lsaKey
+	sentry.LinkStateId = lsaKey.LSId
+	server.AreaStubs[vKey] = sentry
+}
+
 func (server *OSPFServer) UpdateAreaGraphRouterLsa(lsaEnt RouterLsa, lsaKey LsaKey, areaId uint32) error {
 	server.logger.Info(fmt.Sprintln("1: Using Lsa with key as:", dumpLsaKey(lsaKey), "for SPF calc"))
 	vertexKey := VertexKey{
@@ -339,22 +356,9 @@ func (server *OSPFServer) UpdateAreaGraphRouterLsa(lsaEnt RouterLsa, lsaKey LsaK
 			ent.LinkData[vKey] = lData
 		} else if linkDetail.LinkType == StubLink {
 			server.logger.Info("===It is StubLink===")
-			vKey = VertexKey{
-				Type:   SNetworkVertex,
-				ID:     linkDetail.LinkId,
-				AdvRtr: lsaKey.AdvRouter,
-			}
-			cost = linkDetail.LinkMetric
-			lData = linkDetail.LinkData
-			sentry, _ := server.AreaStubs[vKey]
-			sentry.NbrVertexKey = vertexKey
-			sentry.NbrVertexCost = cost
-			sentry.LinkData = lData
-			sentry.AreaId = areaId
-			sentry.LsaKey = lsaKey
-			sentry.LinkStateId = lsaKey.LSId
-			server.AreaStubs[vKey] = sentry
-		} else if linkDetail.LinkType == P2PLink {
+			server.addAreaStub(areaId, vertexKey, lsaKey, linkDetail)
+		} else if linkDetail.LinkType == P2PLink ||
+			linkDetail.LinkType == VirtualLink {
 			server.logger.Info("===It is P2PLink===")
 			vKey = VertexKey{
 				Type:   RouterVertex,
@@ -795,15 +799,15 @@ func (server *OSPFServer) spfCalculation() {
 		server.OldGlobalRoutingTbl = server.GlobalRoutingTbl
 		server.TempAreaRoutingTbl = nil
 		server.TempAreaRoutingTbl = make(map[AreaIdKey]AreaRoutingTbl)
+		spfStart := time.Now()
+		spfRunMap := make(map[uint32]SpfType)
 		for key, aEnt := range server.AreaConfMap {
 
 			//server.logger.Info(fmt.Sprintln("===========Area Id : ", key.AreaId, "Area Bdr Status:", server.ospfGlobalConf.isABR, "======================================================="))
 			if len(aEnt.IntfListMap) == 0 {
 				continue
 			}
-			aEnt.TransitCapability = false
 			areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
-			server.initialiseSPFStructs()
 			areaIdKey := AreaIdKey{
 				AreaId: areaId,
 			}
@@ -812,43 +816,38 @@ func (server *OSPFServer) spfCalculation() {
 			tempRoutingTbl.RoutingTblMap = make(map[RoutingTblEntryKey]RoutingTblEntry)
 			server.TempAreaRoutingTbl[areaIdKey] = tempRoutingTbl
 
-			vKey, err := server.CreateAreaGraph(areaId)
-			if err != nil {
-				server.logger.Err(fmt.Sprintln("Error while creating graph for areaId:", areaId))
-				//flag = true
-				continue
-			}
-			//server.logger.Info("=========================Start before Dijkstra=================")
-			//server.dumpAreaGraph()
-			//server.dumpAreaStubs()
-			//server.logger.Info("=========================End before Dijkstra=================")
-			//server.printRouterLsa()
-			err = server.ExecuteDijkstra(vKey, areaId)
+			// Summary and external routes are recalculated for all the areas
+			spfType := server.getAreaSpfType(areaId)
+			spfRunMap[areaId] = spfType
+			server.logger.Info(fmt.Sprintln("SPF: area ", key.AreaId, " spf type ", spfType))
+			err := server.calcIntraAreaRoutes(areaId, spfType)
 			if err != nil {
-				server.logger.Err(fmt.Sprintln("Error while executing Dijkstra for areaId:", areaId))
 				//flag = true
 				continue
 			}
-			server.logger.Info("=========================Start after Dijkstra=================")
-			//	server.dumpAreaGraph()
-			//	server.dumpAreaStubs()
-			//	server.dumpSPFTree()
-			server.logger.Info("=========================End after Dijkstra=================")
-			server.UpdateRoutingTbl(vKey, areaId)
-			server.logger.Info("==============Handling Stub links...====================")
-			server.HandleStubs(vKey, areaId)
 			server.HandleSummaryLsa(areaId)
-			server.AreaGraph = nil
-			server.AreaStubs = nil
-			server.SPFTree = nil
 		}
 		/*
 			server.dumpRoutingTbl()
 		*/
+		if server.ospfGlobalConf.AreaBdrRtrStatus == true {
+			server.logger.Info("Examine transit areas, Summary LSA...")
+			server.HandleTransitAreaSummaryLsa()
+		}
+		server.updateVirtLinks()
 		server.TempGlobalRoutingTbl = nil
 		server.TempGlobalRoutingTbl = make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
 		/* Summarize and Install/Delete Routes In Routing Table */
-		server.InstallRoutingTbl()
+		if server.isGrRestarting() {
+			// RIBd keeps the routes from before the restart
+			server.ConsolidatingRoutingTbl()
+		} else {
+			if server.GrPreservedRoutingTbl != nil {
+				server.OldGlobalRoutingTbl = server.GrPreservedRoutingTbl
+				server.GrPreservedRoutingTbl = nil
+			}
+			server.InstallRoutingTbl()
+		}
 		// Copy the Summarize Routing Table in Global Routing Table
 		server.GlobalRoutingTbl = nil
 		server.GlobalRoutingTbl = make(map[RoutingTblEntryKey]GlobalRoutingTblEntry)
@@ -868,12 +867,11 @@ func (server *OSPFServer) spfCalculation() {
 		server.TempGlobalRoutingTbl = nil
 		//server.dumpGlobalRoutingTbl()
 		if server.ospfGlobalConf.AreaBdrRtrStatus == true {
-			server.logger.Info("Examine transit areas, Summary LSA...")
-			server.HandleTransitAreaSummaryLsa()
 			server.logger.Info("Generate Summary LSA...")
 			server.GenerateSummaryLsa()
 			server.logger.Info(fmt.Sprintln("========", server.SummaryLsDb, "=========="))
 		}
+		server.updateSpfStats(spfRunMap, time.Since(spfStart))
 		server.DoneCalcSPFCh <- true
 	}
 }
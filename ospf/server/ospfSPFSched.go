@@ -0,0 +1,399 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"l3/ospf/config"
+	"time"
+)
+
+/*
+SPF scheduling.
+LSDB events only record the kind of route calculation needed
+for the area. The calculation runs when the throttle timer
+expires so that a burst of LSAs is handled by a single run.
+While the network is unstable the wait between two runs is
+doubled upto the max wait time.
+*/
+
+type SpfType uint8
+
+const (
+	NoSpf          SpfType = 0
+	PartialSpf     SpfType = 1 // Type-3/4/5/7 LSA changed. No Dijkstra
+	IncrementalSpf SpfType = 2 // Only stub links changed. Reuse SPF tree
+	FullSpf        SpfType = 3
+)
+
+/* Default SPF throttle timers in msec */
+const (
+	SPF_INITIAL_DELAY = 50
+	SPF_HOLD_TIME     = 200
+	SPF_MAX_WAIT_TIME = 5000
+)
+
+type SpfThrottle struct {
+	timer      *time.Timer
+	scheduled  bool
+	pendingMap map[uint32]SpfType
+	holdTime   time.Duration
+	lastRun    time.Time
+}
+
+/* Result of the last intra area calculation */
+type AreaSpfCache struct {
+	RootVKey    VertexKey
+	AreaGraph   map[VertexKey]Vertex
+	AreaStubs   map[VertexKey]StubVertex
+	SPFTree     map[VertexKey]TreeVertex
+	TreeRoutes  map[RoutingTblEntryKey]RoutingTblEntry  // routers and transit networks
+	IntraRoutes map[RoutingTblEntryKey]RoutingTblEntry  // TreeRoutes and stub networks
+	LfaRoutes   map[RoutingTblEntryKey]map[NextHop]bool // loop free alternates
+}
+
+func (server *OSPFServer) initSpfThrottle() {
+	server.spfThrottle.timer = time.NewTimer(time.Duration(SPF_INITIAL_DELAY) * time.Millisecond)
+	server.spfThrottle.timer.Stop()
+	server.spfThrottle.scheduled = false
+	server.spfThrottle.pendingMap = make(map[uint32]SpfType)
+	server.spfThrottle.holdTime = 0
+	server.spfThrottle.lastRun = time.Time{}
+	server.SpfAreaCache = make(map[uint32]AreaSpfCache)
+}
+
+func (server *OSPFServer) getSpfTimers() (initial time.Duration, hold time.Duration, maxWait time.Duration) {
+	initial = time.Duration(SPF_INITIAL_DELAY) * time.Millisecond
+	hold = time.Duration(SPF_HOLD_TIME) * time.Millisecond
+	maxWait = time.Duration(SPF_MAX_WAIT_TIME) * time.Millisecond
+	if server.ospfGlobalConf.SpfInitialDelay > 0 {
+		initial = time.Duration(server.ospfGlobalConf.SpfInitialDelay) * time.Millisecond
+	}
+	if server.ospfGlobalConf.SpfHoldTime > 0 {
+		hold = time.Duration(server.ospfGlobalConf.SpfHoldTime) * time.Millisecond
+	}
+	if server.ospfGlobalConf.SpfMaxWaitTime > 0 {
+		maxWait = time.Duration(server.ospfGlobalConf.SpfMaxWaitTime) * time.Millisecond
+	}
+	if hold < initial {
+		hold = initial
+	}
+	if maxWait < hold {
+		maxWait = hold
+	}
+	return initial, hold, maxWait
+}
+
+/*@fn scheduleSpf
+Record the route calculation needed for the area and
+start the throttle timer if it is not running.
+*/
+func (server *OSPFServer) scheduleSpf(areaId uint32, spfType SpfType) {
+	if server.spfThrottle.pendingMap[areaId] < spfType {
+		server.spfThrottle.pendingMap[areaId] = spfType
+	}
+	if server.spfThrottle.scheduled {
+		return
+	}
+	initial, hold, maxWait := server.getSpfTimers()
+	delay := initial
+	if !server.spfThrottle.lastRun.IsZero() &&
+		time.Since(server.spfThrottle.lastRun) < maxWait {
+		// Network is not stable yet. Back off
+		if server.spfThrottle.holdTime == 0 {
+			server.spfThrottle.holdTime = hold
+		} else {
+			server.spfThrottle.holdTime = 2 * server.spfThrottle.holdTime
+			if server.spfThrottle.holdTime > maxWait {
+				server.spfThrottle.holdTime = maxWait
+			}
+		}
+		delay = server.spfThrottle.holdTime - time.Since(server.spfThrottle.lastRun)
+		if delay < initial {
+			delay = initial
+		}
+	} else {
+		server.spfThrottle.holdTime = 0
+	}
+	server.logger.Info(fmt.Sprintln("SPF: Scheduled after ", delay))
+	server.spfThrottle.timer.Reset(delay)
+	server.spfThrottle.scheduled = true
+}
+
+func (server *OSPFServer) scheduleFullSpf() {
+	for key, _ := range server.AreaConfMap {
+		areaId := convertAreaOrRouterIdUint32(string(key.AreaId))
+		server.scheduleSpf(areaId, FullSpf)
+	}
+}
+
+/*@fn runScheduledSpf
+Throttle timer expired. Run the route calculation
+for all the pending LSDB changes.
+*/
+func (server *OSPFServer) runScheduledSpf() {
+	server.spfThrottle.scheduled = false
+	server.SpfRunMap = server.spfThrottle.pendingMap
+	server.spfThrottle.pendingMap = make(map[uint32]SpfType)
+	server.spfThrottle.lastRun = time.Now()
+	server.StartCalcSPFCh <- true
+	spfStatus := <-server.DoneCalcSPFCh
+	server.logger.Info(fmt.Sprintln("SPF Calculation Return Status", spfStatus))
+	server.SpfRunMap = nil
+	if server.ospfGlobalConf.AreaBdrRtrStatus == true {
+		server.installSummaryLsa()
+	}
+	server.processNssaTranslation()
+}
+
+type routerLinkKey struct {
+	LinkId     uint32
+	LinkData   uint32
+	LinkType   uint8
+	LinkMetric uint16
+}
+
+/*@fn isRouterLsaStubChange
+Returns true if the two router LSAs differ only in
+stub links. SPF tree does not change in that case.
+*/
+func isRouterLsaStubChange(oldLsa RouterLsa, newLsa RouterLsa) bool {
+	if oldLsa.LsaMd.LSAge == config.MaxAge || newLsa.LsaMd.LSAge == config.MaxAge {
+		return false
+	}
+	if oldLsa.LsaMd.Options != newLsa.LsaMd.Options ||
+		oldLsa.BitV != newLsa.BitV || oldLsa.BitE != newLsa.BitE ||
+		oldLsa.BitB != newLsa.BitB || oldLsa.BitNt != newLsa.BitNt {
+		return false
+	}
+	links := make(map[routerLinkKey]int)
+	for _, link := range oldLsa.LinkDetails {
+		if link.LinkType == StubLink {
+			continue
+		}
+		key := routerLinkKey{link.LinkId, link.LinkData, link.LinkType, link.LinkMetric}
+		links[key]++
+	}
+	for _, link := range newLsa.LinkDetails {
+		if link.LinkType == StubLink {
+			continue
+		}
+		key := routerLinkKey{link.LinkId, link.LinkData, link.LinkType, link.LinkMetric}
+		if links[key] == 0 {
+			return false
+		}
+		links[key]--
+	}
+	for _, cnt := range links {
+		if cnt != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*@fn getLsaSpfType
+Find the route calculation needed for the received LSA.
+Must be called before the LSA is installed in the LSDB.
+Short or malformed LSA data falls back to a full SPF.
+*/
+func (server *OSPFServer) getLsaSpfType(data []byte, areaId uint32, del bool) SpfType {
+	if len(data) < OSPF_LSA_HEADER_SIZE {
+		return FullSpf
+	}
+	switch data[3] {
+	case RouterLSA:
+		if del {
+			return FullSpf
+		}
+		// Router LSA body is 4 bytes followed by 12 bytes per link
+		if len(data) < OSPF_LSA_HEADER_SIZE+4 ||
+			len(data) < int(binary.BigEndian.Uint16(data[18:20])) ||
+			len(data) < OSPF_LSA_HEADER_SIZE+4+12*int(binary.BigEndian.Uint16(data[22:24])) {
+			return FullSpf
+		}
+		lsaKey := NewLsaKey()
+		routerLsa := NewRouterLsa()
+		decodeRouterLsa(data, routerLsa, lsaKey)
+		lsdbKey := LsdbKey{
+			AreaId: areaId,
+		}
+		lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+		if !exist {
+			return FullSpf
+		}
+		oldLsa, exist := lsDbEnt.RouterLsaMap[*lsaKey]
+		if !exist || !isRouterLsaStubChange(oldLsa, *routerLsa) {
+			return FullSpf
+		}
+		return IncrementalSpf
+	case NetworkLSA:
+		return FullSpf
+	}
+	return PartialSpf
+}
+
+func copyRoutingTblMap(rMap map[RoutingTblEntryKey]RoutingTblEntry) map[RoutingTblEntryKey]RoutingTblEntry {
+	newMap := make(map[RoutingTblEntryKey]RoutingTblEntry)
+	for key, ent := range rMap {
+		nextHops := make(map[NextHop]bool)
+		for nh, val := range ent.NextHops {
+			nextHops[nh] = val
+		}
+		ent.NextHops = nextHops
+		newMap[key] = ent
+	}
+	return newMap
+}
+
+/* Stub links of all the routers in the area graph */
+func (server *OSPFServer) updateAreaStubs(areaId uint32) {
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return
+	}
+	for vKey, ent := range server.AreaGraph {
+		if vKey.Type != RouterVertex {
+			continue
+		}
+		lsaEnt, exist := lsDbEnt.RouterLsaMap[ent.LsaKey]
+		if !exist {
+			continue
+		}
+		for _, linkDetail := range lsaEnt.LinkDetails {
+			if linkDetail.LinkType == StubLink {
+				server.addAreaStub(areaId, vKey, ent.LsaKey, linkDetail)
+			}
+		}
+	}
+}
+
+func (server *OSPFServer) getAreaSpfType(areaId uint32) SpfType {
+	if server.SpfRunMap == nil {
+		return FullSpf
+	}
+	if _, exist := server.SpfAreaCache[areaId]; !exist {
+		return FullSpf
+	}
+	return server.SpfRunMap[areaId]
+}
+
+/*@fn calcIntraAreaRoutes
+Full SPF builds the area graph and runs Dijkstra.
+Incremental SPF reuses the SPF tree of the last run
+and recomputes the stub networks only. Otherwise the
+intra area routes of the last run are used.
+*/
+func (server *OSPFServer) calcIntraAreaRoutes(areaId uint32, spfType SpfType) error {
+	areaIdKey := AreaIdKey{
+		AreaId: areaId,
+	}
+	tempRoutingTbl := server.TempAreaRoutingTbl[areaIdKey]
+	switch spfType {
+	case FullSpf:
+		delete(server.SpfAreaCache, areaId)
+		areaConfKey := AreaConfKey{
+			AreaId: config.AreaId(convertUint32ToIPv4(areaId)),
+		}
+		aEnt := server.AreaConfMap[areaConfKey]
+		aEnt.TransitCapability = false
+		server.AreaConfMap[areaConfKey] = aEnt
+		server.initialiseSPFStructs()
+		vKey, err := server.CreateAreaGraph(areaId)
+		if err != nil {
+			server.logger.Err(fmt.Sprintln("Error while creating graph for areaId:", areaId))
+			return err
+		}
+		err = server.ExecuteDijkstra(vKey, areaId)
+		if err != nil {
+			server.logger.Err(fmt.Sprintln("Error while executing Dijkstra for areaId:", areaId))
+			return err
+		}
+		server.UpdateRoutingTbl(vKey, areaId)
+		cache := AreaSpfCache{
+			RootVKey:   vKey,
+			AreaGraph:  server.AreaGraph,
+			SPFTree:    server.SPFTree,
+			TreeRoutes: copyRoutingTblMap(tempRoutingTbl.RoutingTblMap),
+		}
+		server.logger.Info("==============Handling Stub links...====================")
+		server.HandleStubs(vKey, areaId)
+		cache.AreaStubs = server.AreaStubs
+		cache.IntraRoutes = copyRoutingTblMap(tempRoutingTbl.RoutingTblMap)
+		cache.LfaRoutes = server.calcAreaLfa(areaId, cache)
+		server.SpfAreaCache[areaId] = cache
+	case IncrementalSpf:
+		cache := server.SpfAreaCache[areaId]
+		server.AreaGraph = cache.AreaGraph
+		server.SPFTree = cache.SPFTree
+		server.AreaStubs = make(map[VertexKey]StubVertex)
+		server.updateAreaStubs(areaId)
+		tempRoutingTbl.RoutingTblMap = copyRoutingTblMap(cache.TreeRoutes)
+		server.TempAreaRoutingTbl[areaIdKey] = tempRoutingTbl
+		server.HandleStubs(cache.RootVKey, areaId)
+		cache.AreaStubs = server.AreaStubs
+		cache.IntraRoutes = copyRoutingTblMap(tempRoutingTbl.RoutingTblMap)
+		cache.LfaRoutes = server.calcAreaLfa(areaId, cache)
+		server.SpfAreaCache[areaId] = cache
+	default:
+		cache := server.SpfAreaCache[areaId]
+		tempRoutingTbl.RoutingTblMap = copyRoutingTblMap(cache.IntraRoutes)
+		server.TempAreaRoutingTbl[areaIdKey] = tempRoutingTbl
+	}
+	server.AreaGraph = nil
+	server.AreaStubs = nil
+	server.SPFTree = nil
+	return nil
+}
+
+func (server *OSPFServer) updateSpfStats(spfRunMap map[uint32]SpfType, spfTime time.Duration) {
+	for areaId, spfType := range spfRunMap {
+		if spfType == NoSpf {
+			continue
+		}
+		areaConfKey := AreaConfKey{
+			AreaId: config.AreaId(convertUint32ToIPv4(areaId)),
+		}
+		ent, exist := server.AreaStateMap[areaConfKey]
+		if !exist {
+			continue
+		}
+		switch spfType {
+		case FullSpf:
+			ent.SpfRuns++
+			ent.SpfFullRuns++
+		case IncrementalSpf:
+			ent.SpfRuns++
+			ent.SpfIncrementalRuns++
+		case PartialSpf:
+			ent.SpfPartialRuns++
+		}
+		ent.SpfLastDuration = int32(spfTime / time.Microsecond)
+		server.AreaStateMap[areaConfKey] = ent
+	}
+}
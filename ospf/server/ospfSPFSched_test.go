@@ -0,0 +1,169 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOspfSpfType(t *testing.T) {
+	server := getServerObject()
+	areaId := uint32(0)
+	server.initLSDatabase(areaId)
+	lsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      convertAreaOrRouterIdUint32("2.2.2.2"),
+		AdvRouter: convertAreaOrRouterIdUint32("2.2.2.2"),
+	}
+	transit := LinkDetail{
+		LinkId:     convertAreaOrRouterIdUint32("10.1.1.1"),
+		LinkData:   convertAreaOrRouterIdUint32("10.1.1.2"),
+		LinkType:   TransitLink,
+		LinkMetric: 10,
+	}
+	stub := LinkDetail{
+		LinkId:     convertAreaOrRouterIdUint32("20.1.1.0"),
+		LinkData:   convertAreaOrRouterIdUint32("255.255.255.0"),
+		LinkType:   StubLink,
+		LinkMetric: 10,
+	}
+	rlsa := RouterLsa{
+		NumOfLinks:  1,
+		LinkDetails: []LinkDetail{transit},
+	}
+	rlsa.LsaMd.LSSequenceNum = InitialSequenceNumber
+	rlsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + 12)
+	if spfType := server.getLsaSpfType(encodeRouterLsa(rlsa, lsaKey), areaId, false); spfType != FullSpf {
+		t.Fatal("New router LSA must trigger full SPF", spfType)
+	}
+	lsDbEnt := server.AreaLsdb[LsdbKey{AreaId: areaId}]
+	lsDbEnt.RouterLsaMap[lsaKey] = rlsa
+
+	// Stub link added
+	nlsa := rlsa
+	nlsa.NumOfLinks = 2
+	nlsa.LinkDetails = []LinkDetail{stub, transit}
+	nlsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + 24)
+	if spfType := server.getLsaSpfType(encodeRouterLsa(nlsa, lsaKey), areaId, false); spfType != IncrementalSpf {
+		t.Fatal("Stub link change must trigger incremental SPF", spfType)
+	}
+
+	// Transit link metric changed
+	nlsa.LinkDetails[1].LinkMetric = 20
+	if spfType := server.getLsaSpfType(encodeRouterLsa(nlsa, lsaKey), areaId, false); spfType != FullSpf {
+		t.Fatal("Transit link change must trigger full SPF", spfType)
+	}
+
+	slsa := SummaryLsa{
+		Netmask: convertAreaOrRouterIdUint32("255.255.255.0"),
+		Metric:  10,
+	}
+	slsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 8)
+	sKey := LsaKey{
+		LSType:    Summary3LSA,
+		LSId:      convertAreaOrRouterIdUint32("30.1.1.0"),
+		AdvRouter: lsaKey.AdvRouter,
+	}
+	if spfType := server.getLsaSpfType(encodeSummaryLsa(slsa, sKey), areaId, false); spfType != PartialSpf {
+		t.Fatal("Summary LSA must trigger partial SPF", spfType)
+	}
+}
+
+func TestOspfSpfTypeMalformedLsa(t *testing.T) {
+	server := getServerObject()
+	areaId := uint32(0)
+	server.initLSDatabase(areaId)
+	lsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      convertAreaOrRouterIdUint32("2.2.2.2"),
+		AdvRouter: convertAreaOrRouterIdUint32("2.2.2.2"),
+	}
+	rlsa := RouterLsa{
+		NumOfLinks: 1,
+		LinkDetails: []LinkDetail{{
+			LinkId:     convertAreaOrRouterIdUint32("20.1.1.0"),
+			LinkData:   convertAreaOrRouterIdUint32("255.255.255.0"),
+			LinkType:   StubLink,
+			LinkMetric: 10,
+		}},
+	}
+	rlsa.LsaMd.LSLen = uint16(OSPF_LSA_HEADER_SIZE + 4 + 12)
+	data := encodeRouterLsa(rlsa, lsaKey)
+	lsDbEnt := server.AreaLsdb[LsdbKey{AreaId: areaId}]
+	lsDbEnt.RouterLsaMap[lsaKey] = rlsa
+
+	tests := [][]byte{
+		nil,
+		data[:3],
+		data[:OSPF_LSA_HEADER_SIZE-1],
+		data[:OSPF_LSA_HEADER_SIZE+2],
+		data[:len(data)-1],
+	}
+	for _, lsa := range tests {
+		if spfType := server.getLsaSpfType(lsa, areaId, false); spfType != FullSpf {
+			t.Fatal("Malformed LSA of length", len(lsa), "must trigger full SPF", spfType)
+		}
+	}
+}
+
+func TestOspfSpfThrottle(t *testing.T) {
+	server := getServerObject()
+	server.initSpfThrottle()
+	initial, hold, maxWait := server.getSpfTimers()
+	if initial != SPF_INITIAL_DELAY*time.Millisecond || hold != SPF_HOLD_TIME*time.Millisecond ||
+		maxWait != SPF_MAX_WAIT_TIME*time.Millisecond {
+		t.Fatal("Invalid default SPF timers", initial, hold, maxWait)
+	}
+	server.scheduleSpf(0, PartialSpf)
+	server.scheduleSpf(0, FullSpf)
+	server.scheduleSpf(0, IncrementalSpf)
+	if !server.spfThrottle.scheduled || server.spfThrottle.pendingMap[0] != FullSpf {
+		t.Fatal("SPF events are not merged", server.spfThrottle.pendingMap)
+	}
+	if server.spfThrottle.holdTime != 0 {
+		t.Fatal("First SPF must use the initial delay", server.spfThrottle.holdTime)
+	}
+
+	// SPF events after a recent run back off
+	server.spfThrottle.timer.Stop()
+	for _, expHold := range []time.Duration{hold, 2 * hold, 4 * hold} {
+		server.spfThrottle.scheduled = false
+		server.spfThrottle.lastRun = time.Now()
+		server.scheduleSpf(0, FullSpf)
+		server.spfThrottle.timer.Stop()
+		if server.spfThrottle.holdTime != expHold {
+			t.Fatal("Invalid SPF hold time", server.spfThrottle.holdTime, expHold)
+		}
+	}
+
+	// Network is stable again
+	server.spfThrottle.scheduled = false
+	server.spfThrottle.lastRun = time.Now().Add(-2 * maxWait)
+	server.scheduleSpf(0, FullSpf)
+	server.spfThrottle.timer.Stop()
+	if server.spfThrottle.holdTime != 0 {
+		t.Fatal("SPF hold time is not reset", server.spfThrottle.holdTime)
+	}
+}
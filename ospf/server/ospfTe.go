@@ -0,0 +1,445 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"encoding/binary"
+	"fmt"
+	"l3/ospf/config"
+	"math"
+	"sort"
+)
+
+/*
+RFC 3630 Traffic Engineering Extensions to OSPF.
+TE information is carried in area scope opaque LSAs of opaque
+type 1. One LSA carries the router address TLV and one LSA per
+link carries the link TLV built from the interface TE config.
+*/
+
+const (
+	TeLsaOpaqueType uint8 = 1
+)
+
+const (
+	TeTlvRouterAddr uint16 = 1
+	TeTlvLink       uint16 = 2
+)
+
+const (
+	TeSubTlvLinkType   uint16 = 1
+	TeSubTlvLinkId     uint16 = 2
+	TeSubTlvLocalAddr  uint16 = 3
+	TeSubTlvRemoteAddr uint16 = 4
+	TeSubTlvMetric     uint16 = 5
+	TeSubTlvMaxBw      uint16 = 6
+	TeSubTlvMaxResvBw  uint16 = 7
+	TeSubTlvUnresvBw   uint16 = 8
+	TeSubTlvAdminGroup uint16 = 9
+	TeNumOfPriorities         = 8
+)
+
+const (
+	TeLinkP2P         uint8 = 1
+	TeLinkMultiAccess uint8 = 2
+)
+
+/* Bandwidth is in bytes per second on the wire */
+type TeLink struct {
+	LinkType   uint8
+	LinkId     uint32
+	LocalAddr  []uint32
+	RemoteAddr []uint32
+	TeMetric   uint32
+	MaxBw      float32
+	MaxResvBw  float32
+	UnresvBw   [TeNumOfPriorities]float32
+	AdminGroup uint32
+}
+
+/* TE opaque information is either a router address TLV or a link TLV */
+type TeLsa struct {
+	RouterAddr uint32
+	Links      []TeLink
+}
+
+/*
+    0                   1                   2                   3
+    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |              Type             |             Length            |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+   |                            Value...                           |
+   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+*/
+
+func encodeTeTlv(tlvType uint16, value []byte) []byte {
+	// values are padded to 4 bytes
+	tlv := make([]byte, 4+((len(value)+3)/4)*4)
+	binary.BigEndian.PutUint16(tlv[0:2], tlvType)
+	binary.BigEndian.PutUint16(tlv[2:4], uint16(len(value)))
+	copy(tlv[4:], value)
+	return tlv
+}
+
+func encodeTeUint32(val uint32) []byte {
+	buf := make([]byte, 4)
+	binary.BigEndian.PutUint32(buf, val)
+	return buf
+}
+
+func encodeTeRouterAddrTlv(rtrAddr uint32) []byte {
+	return encodeTeTlv(TeTlvRouterAddr, encodeTeUint32(rtrAddr))
+}
+
+func encodeTeLinkTlv(link TeLink) []byte {
+	var subTlvs []byte
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvLinkType, []byte{link.LinkType})...)
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvLinkId, encodeTeUint32(link.LinkId))...)
+	if len(link.LocalAddr) > 0 {
+		var addrs []byte
+		for _, addr := range link.LocalAddr {
+			addrs = append(addrs, encodeTeUint32(addr)...)
+		}
+		subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvLocalAddr, addrs)...)
+	}
+	if len(link.RemoteAddr) > 0 {
+		var addrs []byte
+		for _, addr := range link.RemoteAddr {
+			addrs = append(addrs, encodeTeUint32(addr)...)
+		}
+		subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvRemoteAddr, addrs)...)
+	}
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvMetric, encodeTeUint32(link.TeMetric))...)
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvMaxBw,
+		encodeTeUint32(math.Float32bits(link.MaxBw)))...)
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvMaxResvBw,
+		encodeTeUint32(math.Float32bits(link.MaxResvBw)))...)
+	var unresvBw []byte
+	for prio := 0; prio < TeNumOfPriorities; prio++ {
+		unresvBw = append(unresvBw, encodeTeUint32(math.Float32bits(link.UnresvBw[prio]))...)
+	}
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvUnresvBw, unresvBw)...)
+	subTlvs = append(subTlvs, encodeTeTlv(TeSubTlvAdminGroup, encodeTeUint32(link.AdminGroup))...)
+	return encodeTeTlv(TeTlvLink, subTlvs)
+}
+
+func decodeTeLinkTlv(data []byte) (link TeLink) {
+	start := 0
+	for start+4 <= len(data) {
+		tlvType := binary.BigEndian.Uint16(data[start : start+2])
+		tlvLen := int(binary.BigEndian.Uint16(data[start+2 : start+4]))
+		start = start + 4
+		if start+tlvLen > len(data) {
+			return link
+		}
+		value := data[start : start+tlvLen]
+		switch tlvType {
+		case TeSubTlvLinkType:
+			if tlvLen == 1 {
+				link.LinkType = value[0]
+			}
+		case TeSubTlvLinkId:
+			if tlvLen == 4 {
+				link.LinkId = binary.BigEndian.Uint32(value)
+			}
+		case TeSubTlvLocalAddr:
+			for i := 0; i+4 <= tlvLen; i += 4 {
+				link.LocalAddr = append(link.LocalAddr, binary.BigEndian.Uint32(value[i:i+4]))
+			}
+		case TeSubTlvRemoteAddr:
+			for i := 0; i+4 <= tlvLen; i += 4 {
+				link.RemoteAddr = append(link.RemoteAddr, binary.BigEndian.Uint32(value[i:i+4]))
+			}
+		case TeSubTlvMetric:
+			if tlvLen == 4 {
+				link.TeMetric = binary.BigEndian.Uint32(value)
+			}
+		case TeSubTlvMaxBw:
+			if tlvLen == 4 {
+				link.MaxBw = math.Float32frombits(binary.BigEndian.Uint32(value))
+			}
+		case TeSubTlvMaxResvBw:
+			if tlvLen == 4 {
+				link.MaxResvBw = math.Float32frombits(binary.BigEndian.Uint32(value))
+			}
+		case TeSubTlvUnresvBw:
+			if tlvLen == 4*TeNumOfPriorities {
+				for prio := 0; prio < TeNumOfPriorities; prio++ {
+					link.UnresvBw[prio] = math.Float32frombits(
+						binary.BigEndian.Uint32(value[prio*4 : prio*4+4]))
+				}
+			}
+		case TeSubTlvAdminGroup:
+			if tlvLen == 4 {
+				link.AdminGroup = binary.BigEndian.Uint32(value)
+			}
+		}
+		start = start + ((tlvLen+3)/4)*4
+	}
+	return link
+}
+
+/*@fn decodeTeLsa
+Decode the opaque information of a TE LSA.
+Unknown TLVs are skipped.
+*/
+func decodeTeLsa(data []byte, lsa *TeLsa) {
+	start := 0
+	for start+4 <= len(data) {
+		tlvType := binary.BigEndian.Uint16(data[start : start+2])
+		tlvLen := int(binary.BigEndian.Uint16(data[start+2 : start+4]))
+		start = start + 4
+		if start+tlvLen > len(data) {
+			return
+		}
+		switch tlvType {
+		case TeTlvRouterAddr:
+			if tlvLen == 4 {
+				lsa.RouterAddr = binary.BigEndian.Uint32(data[start : start+4])
+			}
+		case TeTlvLink:
+			lsa.Links = append(lsa.Links, decodeTeLinkTlv(data[start:start+tlvLen]))
+		}
+		start = start + ((tlvLen+3)/4)*4
+	}
+}
+
+/* Config is in kbps, TE bandwidth is in bytes per second */
+func convertKbpsToTeBw(kbps uint32) float32 {
+	return float32(kbps) * 1000 / 8
+}
+
+func convertTeBwToKbps(bw float32) uint32 {
+	return uint32(bw * 8 / 1000)
+}
+
+/*@fn getTeLinkOpaqueId
+Opaque id 0 is the router address LSA. Link LSAs use the
+interface address or the ifindex for unnumbered links.
+*/
+func getTeLinkOpaqueId(key IntfConfKey, ent IntfConf) uint32 {
+	if ent.IfType == config.UnnumberedP2P {
+		return getOpaqueId(uint32(key.IntfIdx))
+	}
+	return getOpaqueId(convertAreaOrRouterIdUint32(ent.IfIpAddr.String()))
+}
+
+/*@fn getTeLink
+Build the TE link for the interface. Only links with
+a neighbor (or a DR) are advertised.
+*/
+func (server *OSPFServer) getTeLink(key IntfConfKey, ent IntfConf) (link TeLink, valid bool) {
+	switch ent.IfType {
+	case config.Broadcast, config.Nbma:
+		drIp := convertIPv4ToUint32(ent.IfDRIp)
+		if len(ent.NeighborMap) == 0 || drIp == 0 {
+			return link, false
+		}
+		link.LinkType = TeLinkMultiAccess
+		link.LinkId = drIp
+		link.LocalAddr = []uint32{convertAreaOrRouterIdUint32(ent.IfIpAddr.String())}
+	case config.NumberedP2P, config.UnnumberedP2P:
+		nbrData, exist := ospfIntfToNbrMap[key]
+		if !exist || len(nbrData.nbrList) == 0 {
+			return link, false
+		}
+		nbr, exist := server.NeighborConfigMap[nbrData.nbrList[0]]
+		if !exist {
+			return link, false
+		}
+		link.LinkType = TeLinkP2P
+		link.LinkId = nbr.OspfNbrRtrId
+		if ent.IfType == config.NumberedP2P {
+			link.LocalAddr = []uint32{convertAreaOrRouterIdUint32(ent.IfIpAddr.String())}
+			link.RemoteAddr = []uint32{convertAreaOrRouterIdUint32(nbr.OspfNbrIPAddr.String())}
+		}
+	default:
+		// Point to multipoint and virtual links are not advertised
+		return link, false
+	}
+	link.TeMetric = ent.IfTeMetric
+	if link.TeMetric == 0 {
+		link.TeMetric = ent.IfCost
+	}
+	link.MaxBw = convertKbpsToTeBw(ent.IfMaxBandwidth)
+	link.MaxResvBw = convertKbpsToTeBw(ent.IfMaxReservableBandwidth)
+	// No bandwidth is reserved as there is no signalling protocol
+	for prio := 0; prio < TeNumOfPriorities; prio++ {
+		link.UnresvBw[prio] = link.MaxResvBw
+	}
+	link.AdminGroup = ent.IfAdminGroup
+	return link, true
+}
+
+/*@fn generateTeLsa
+Originate TE LSAs for the area and flush the ones
+of links which are not up anymore.
+Called whenever router LSA of the area is generated.
+*/
+func (server *OSPFServer) generateTeLsa(areaId uint32) {
+	if !server.ospfGlobalConf.OpaqueLsaSupport || server.isGrRestarting() {
+		return
+	}
+	lsdbKey := LsdbKey{
+		AreaId: areaId,
+	}
+	selfOrigLsaEnt, exist := server.AreaSelfOrigLsa[lsdbKey]
+	if !exist {
+		return
+	}
+	rtrId := convertIPv4ToUint32(server.ospfGlobalConf.RouterId)
+	originated := make(map[LsaKey]bool)
+	for key, ent := range server.IntfConfMap {
+		if convertIPv4ToUint32(ent.IfAreaId) != areaId ||
+			ent.IfFSMState <= config.Waiting {
+			continue
+		}
+		link, valid := server.getTeLink(key, ent)
+		if !valid {
+			continue
+		}
+		lsaKey := LsaKey{
+			LSType:    AreaOpaqueLSA,
+			LSId:      getOpaqueLsId(TeLsaOpaqueType, getTeLinkOpaqueId(key, ent)),
+			AdvRouter: rtrId,
+		}
+		server.originateOpaqueLsa(areaId, lsaKey, encodeTeLinkTlv(link))
+		originated[lsaKey] = true
+	}
+	if len(originated) > 0 {
+		lsaKey := LsaKey{
+			LSType:    AreaOpaqueLSA,
+			LSId:      getOpaqueLsId(TeLsaOpaqueType, 0),
+			AdvRouter: rtrId,
+		}
+		server.originateOpaqueLsa(areaId, lsaKey, encodeTeRouterAddrTlv(rtrId))
+		originated[lsaKey] = true
+	}
+
+	flush := false
+	for lsaKey, _ := range selfOrigLsaEnt {
+		if lsaKey.LSType != AreaOpaqueLSA ||
+			getOpaqueType(lsaKey.LSId) != TeLsaOpaqueType || originated[lsaKey] {
+			continue
+		}
+		server.flushOpaqueLsa(areaId, lsaKey)
+		flush = true
+	}
+	if flush {
+		server.ospfNbrLsaUpdSendCh <- ospfFloodMsg{
+			lsOp: LSAAGE,
+		}
+	}
+}
+
+type teLsaKeySlice []LsdbSliceEnt
+
+func (s teLsaKeySlice) Len() int      { return len(s) }
+func (s teLsaKeySlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s teLsaKeySlice) Less(i, j int) bool {
+	if s[i].AreaId != s[j].AreaId {
+		return s[i].AreaId < s[j].AreaId
+	}
+	if s[i].AdvRtr != s[j].AdvRtr {
+		return s[i].AdvRtr < s[j].AdvRtr
+	}
+	return s[i].LSId < s[j].LSId
+}
+
+/*@fn getTeLinkStateList
+TE database. One entry per link TLV found in the
+TE LSAs of all the areas.
+*/
+func (server *OSPFServer) getTeLinkStateList() []config.TeLinkState {
+	var keys teLsaKeySlice
+	rtrAddrs := make(map[LsdbSliceEnt]uint32)
+	for lsdbKey, lsDbEnt := range server.AreaLsdb {
+		for lsaKey, _ := range lsDbEnt.AreaOpaqueLsaMap {
+			if getOpaqueType(lsaKey.LSId) != TeLsaOpaqueType {
+				continue
+			}
+			keys = append(keys, LsdbSliceEnt{
+				AreaId: lsdbKey.AreaId,
+				LSType: lsaKey.LSType,
+				LSId:   lsaKey.LSId,
+				AdvRtr: lsaKey.AdvRouter,
+			})
+		}
+	}
+	sort.Sort(keys)
+
+	var teLinks []config.TeLinkState
+	for _, ent := range keys {
+		lsaKey := LsaKey{
+			LSType:    ent.LSType,
+			LSId:      ent.LSId,
+			AdvRouter: ent.AdvRtr,
+		}
+		lsa, ret := server.getAreaOpaqueLsaFromLsdb(ent.AreaId, lsaKey)
+		if ret == LsdbEntryNotFound {
+			continue
+		}
+		var teLsa TeLsa
+		decodeTeLsa(lsa.Data, &teLsa)
+		if teLsa.RouterAddr != 0 {
+			rtrKey := LsdbSliceEnt{
+				AreaId: ent.AreaId,
+				AdvRtr: ent.AdvRtr,
+			}
+			rtrAddrs[rtrKey] = teLsa.RouterAddr
+		}
+		for _, link := range teLsa.Links {
+			var teLink config.TeLinkState
+			teLink.TeAreaId = config.AreaId(convertUint32ToIPv4(ent.AreaId))
+			teLink.TeAdvRouterId = config.RouterId(convertUint32ToIPv4(ent.AdvRtr))
+			teLink.TeLsid = config.IpAddress(convertUint32ToIPv4(ent.LSId))
+			teLink.TeLinkType = int32(link.LinkType)
+			teLink.TeLinkId = config.IpAddress(convertUint32ToIPv4(link.LinkId))
+			teLink.TeLocalIpAddr = "0.0.0.0"
+			if len(link.LocalAddr) > 0 {
+				teLink.TeLocalIpAddr = config.IpAddress(convertUint32ToIPv4(link.LocalAddr[0]))
+			}
+			teLink.TeRemoteIpAddr = "0.0.0.0"
+			if len(link.RemoteAddr) > 0 {
+				teLink.TeRemoteIpAddr = config.IpAddress(convertUint32ToIPv4(link.RemoteAddr[0]))
+			}
+			teLink.TeMetric = link.TeMetric
+			teLink.TeMaxBandwidth = convertTeBwToKbps(link.MaxBw)
+			teLink.TeMaxReservableBandwidth = convertTeBwToKbps(link.MaxResvBw)
+			teLink.TeUnreservedBandwidth = convertTeBwToKbps(link.UnresvBw[0])
+			teLink.TeAdminGroup = link.AdminGroup
+			teLinks = append(teLinks, teLink)
+		}
+	}
+	for idx, _ := range teLinks {
+		rtrKey := LsdbSliceEnt{
+			AreaId: convertAreaOrRouterIdUint32(string(teLinks[idx].TeAreaId)),
+			AdvRtr: convertAreaOrRouterIdUint32(string(teLinks[idx].TeAdvRouterId)),
+		}
+		teLinks[idx].TeRouterAddress = config.IpAddress(convertUint32ToIPv4(rtrAddrs[rtrKey]))
+	}
+	server.logger.Info(fmt.Sprintln("TE: Number of TE links ", len(teLinks)))
+	return teLinks
+}
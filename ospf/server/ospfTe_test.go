@@ -0,0 +1,79 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"testing"
+)
+
+func TestOspfTeLsaEncodeDecode(t *testing.T) {
+	link := TeLink{
+		LinkType:   TeLinkP2P,
+		LinkId:     0x02020202,
+		LocalAddr:  []uint32{0x0a000001},
+		RemoteAddr: []uint32{0x0a000002},
+		TeMetric:   20,
+		MaxBw:      convertKbpsToTeBw(1000000),
+		MaxResvBw:  convertKbpsToTeBw(800000),
+		AdminGroup: 0x5,
+	}
+	for prio := 0; prio < TeNumOfPriorities; prio++ {
+		link.UnresvBw[prio] = link.MaxResvBw
+	}
+	lsaKey := LsaKey{
+		LSType:    AreaOpaqueLSA,
+		LSId:      getOpaqueLsId(TeLsaOpaqueType, 1),
+		AdvRouter: 0x01010101,
+	}
+	lsa := NewOpaqueLsa()
+	lsa.Data = append(encodeTeRouterAddrTlv(0x01010101), encodeTeLinkTlv(link)...)
+	lsaEnc := encodeOpaqueLsa(*lsa, lsaKey)
+
+	dlsa := NewOpaqueLsa()
+	decodeOpaqueLsa(lsaEnc, dlsa, &lsaKey)
+	if getOpaqueType(lsaKey.LSId) != TeLsaOpaqueType || getOpaqueId(lsaKey.LSId) != 1 {
+		t.Fatal("Invalid opaque type/id", lsaKey.LSId)
+	}
+	var teLsa TeLsa
+	decodeTeLsa(dlsa.Data, &teLsa)
+	if teLsa.RouterAddr != 0x01010101 {
+		t.Fatal("Invalid router address", teLsa.RouterAddr)
+	}
+	if len(teLsa.Links) != 1 {
+		t.Fatal("Invalid number of links", len(teLsa.Links))
+	}
+	dlink := teLsa.Links[0]
+	if dlink.LinkType != link.LinkType || dlink.LinkId != link.LinkId ||
+		dlink.TeMetric != link.TeMetric || dlink.AdminGroup != link.AdminGroup ||
+		dlink.UnresvBw != link.UnresvBw {
+		t.Fatal("Decoded link does not match", dlink, link)
+	}
+	if len(dlink.LocalAddr) != 1 || dlink.LocalAddr[0] != 0x0a000001 ||
+		len(dlink.RemoteAddr) != 1 || dlink.RemoteAddr[0] != 0x0a000002 {
+		t.Fatal("Invalid link addresses", dlink.LocalAddr, dlink.RemoteAddr)
+	}
+	if convertTeBwToKbps(dlink.MaxBw) != 1000000 || convertTeBwToKbps(dlink.MaxResvBw) != 800000 {
+		t.Fatal("Invalid bandwidth", dlink.MaxBw, dlink.MaxResvBw)
+	}
+}
@@ -25,7 +25,10 @@ package server
 
 import (
 	//"fmt"
+	"encoding/binary"
 	"errors"
+	"l3/ospf/config"
+	"net"
 )
 
 func (server *OSPFServer) StopSendHelloPkt(key IntfConfKey) {
@@ -35,6 +38,10 @@ func (server *OSPFServer) StopSendHelloPkt(key IntfConfKey) {
 		return
 	}
 	ent.HelloIntervalTicker.Stop()
+	if ent.PollIntervalTicker != nil {
+		ent.PollIntervalTicker.Stop()
+		ent.PollIntervalTicker = nil
+	}
 	server.logger.Info("Successfully stopped sending Hello Pkt")
 	ent.HelloIntervalTicker = nil
 	server.IntfConfMap[key] = ent
@@ -43,6 +50,10 @@ func (server *OSPFServer) StopSendHelloPkt(key IntfConfKey) {
 
 func (server *OSPFServer) StartSendHelloPkt(key IntfConfKey) {
 	ent, _ := server.IntfConfMap[key]
+	if ent.IfType == config.Nbma {
+		server.sendNbmaHelloPkt(key, false)
+		return
+	}
 	//server.logger.Info(fmt.Sprintln("Started Send Hello Pkt Thread", ent.IfName))
 	ospfHelloPkt := server.BuildHelloPkt(ent)
 	err := server.SendOspfPkt(key, ospfHelloPkt)
@@ -53,6 +64,23 @@ func (server *OSPFServer) StartSendHelloPkt(key IntfConfKey) {
 }
 
 func (server *OSPFServer) SendOspfPkt(key IntfConfKey, ospfPkt []byte) error {
+	ent, _ := server.IntfConfMap[key]
+	switch ent.IfType {
+	case config.Nbma:
+		if isOspfMcastPkt(ospfPkt) {
+			return server.sendNbmaMcastPkt(key, ent, ospfPkt)
+		}
+	case config.VirtualLink:
+		ospfPkt = server.getVirtLinkPkt(key, ospfPkt)
+		if ospfPkt == nil {
+			err := errors.New("Virtual link is down")
+			return err
+		}
+	}
+	return server.writeOspfPkt(key, ospfPkt)
+}
+
+func (server *OSPFServer) writeOspfPkt(key IntfConfKey, ospfPkt []byte) error {
 	entry, _ := server.IntfTxMap[key]
 	handle := entry.SendPcapHdl
 	if handle == nil {
@@ -65,3 +93,30 @@ func (server *OSPFServer) SendOspfPkt(key IntfConfKey, ospfPkt []byte) error {
 	entry.SendMutex.Unlock()
 	return err
 }
+
+func isOspfMcastPkt(ospfPkt []byte) bool {
+	if len(ospfPkt) < ETH_HEADER_LEN+IP_HEADER_MIN_LEN {
+		return false
+	}
+	dstIp := net.IP(ospfPkt[ETH_HEADER_LEN+16 : ETH_HEADER_LEN+20])
+	return dstIp.IsMulticast()
+}
+
+// getOspfUnicastPkt rewrites the destination of an encoded ospf packet.
+// Used where the packets can not be multicast i.e. NBMA neighbors and
+// virtual links.
+func getOspfUnicastPkt(ospfPkt []byte, dstIp net.IP, dstMac net.HardwareAddr, ttl uint8) []byte {
+	if len(ospfPkt) < ETH_HEADER_LEN+IP_HEADER_MIN_LEN ||
+		dstIp.To4() == nil || len(dstMac) != 6 {
+		return nil
+	}
+	pkt := make([]byte, len(ospfPkt))
+	copy(pkt, ospfPkt)
+	copy(pkt[0:6], dstMac)
+	ipHdr := pkt[ETH_HEADER_LEN : ETH_HEADER_LEN+IP_HEADER_MIN_LEN]
+	ipHdr[8] = ttl
+	copy(ipHdr[16:20], dstIp.To4())
+	binary.BigEndian.PutUint16(ipHdr[10:12], 0)
+	binary.BigEndian.PutUint16(ipHdr[10:12], computeCheckSum(ipHdr))
+	return pkt
+}
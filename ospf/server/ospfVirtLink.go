@@ -0,0 +1,477 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/ospf/config"
+	"net"
+	"time"
+)
+
+// Virtual link pseudo interfaces are indexed above the ifIndex range
+// used by asicd so that they never collide with physical interfaces.
+const (
+	VirtLinkIfIdxBase = 0x7f000000
+)
+
+type VirtLinkKey struct {
+	TransitAreaId uint32
+	NbrRtrId      uint32
+}
+
+type VirtLinkEntry struct {
+	Conf       config.VirtIfConf
+	IntfKey    IntfConfKey
+	Up         bool
+	PhyIntfKey IntfConfKey
+	LocalIp    net.IP
+	RemoteIp   net.IP
+	NextHopIp  net.IP
+	Cost       uint16
+}
+
+func (server *OSPFServer) processVirtIfConfig(conf config.VirtIfConf) error {
+	transitArea := convertAreaOrRouterId(string(conf.VirtIfAreaId))
+	if transitArea == nil {
+		err := errors.New("Invalid transit area id")
+		return err
+	}
+	transitAreaId := convertIPv4ToUint32(transitArea)
+	if transitAreaId == 0 {
+		err := errors.New("Backbone can not be a transit area")
+		return err
+	}
+	areaEnt, exist := server.AreaConfMap[AreaConfKey{AreaId: conf.VirtIfAreaId}]
+	if !exist {
+		err := errors.New("Transit area does not exist")
+		return err
+	}
+	if areaEnt.ImportAsExtern != config.ImportExternal {
+		err := errors.New("Virtual links can not be configured through stub or NSSA areas")
+		return err
+	}
+	nbrRtrId := convertAreaOrRouterId(string(conf.VirtIfNeighbor))
+	if nbrRtrId == nil || convertIPv4ToUint32(nbrRtrId) == 0 {
+		err := errors.New("Invalid virtual neighbor router id")
+		return err
+	}
+	if conf.VirtIfHelloInterval == 0 || conf.VirtIfRtrDeadInterval == 0 {
+		err := errors.New("Invalid hello or router dead interval")
+		return err
+	}
+
+	vKey := VirtLinkKey{
+		TransitAreaId: transitAreaId,
+		NbrRtrId:      convertIPv4ToUint32(nbrRtrId),
+	}
+	vEnt, exist := server.VirtLinkMap[vKey]
+	if !exist {
+		server.virtLinkIfIdx++
+		vEnt.IntfKey = IntfConfKey{
+			IPAddr:  config.IpAddress(conf.VirtIfNeighbor),
+			IntfIdx: config.InterfaceIndexOrZero(VirtLinkIfIdxBase + server.virtLinkIfIdx),
+		}
+	}
+	vEnt.Conf = conf
+	server.VirtLinkMap[vKey] = vEnt
+	server.logger.Info(fmt.Sprintln("VLINK: Configured virtual link", vKey, vEnt.IntfKey))
+	if vEnt.Up {
+		// Parameters are picked up when the link comes up after next SPF
+		server.virtLinkDown(vKey, vEnt, false)
+	} else {
+		go server.sendAreaRouterLsaChange(transitAreaId)
+	}
+	return nil
+}
+
+func (server *OSPFServer) processVirtIfDelete(conf config.VirtIfConf) {
+	vKey := VirtLinkKey{
+		TransitAreaId: convertAreaOrRouterIdUint32(string(conf.VirtIfAreaId)),
+		NbrRtrId:      convertAreaOrRouterIdUint32(string(conf.VirtIfNeighbor)),
+	}
+	vEnt, exist := server.VirtLinkMap[vKey]
+	if !exist {
+		server.logger.Err(fmt.Sprintln("VLINK: No such virtual link", conf.VirtIfAreaId, conf.VirtIfNeighbor))
+		return
+	}
+	if vEnt.Up {
+		server.virtLinkDown(vKey, vEnt, true)
+	}
+	delete(server.VirtLinkMap, vKey)
+	server.logger.Info(fmt.Sprintln("VLINK: Deleted virtual link", vKey))
+}
+
+/*
+Regenerate router LSA of the area. Zero interface key only triggers the
+router LSA generation and SPF.
+*/
+func (server *OSPFServer) sendAreaRouterLsaChange(areaId uint32) {
+	msg := NetworkLSAChangeMsg{
+		areaId: areaId,
+	}
+	server.IntfStateChangeCh <- msg
+}
+
+func (server *OSPFServer) isVirtLinkTransitArea(areaId uint32) bool {
+	for vKey, vEnt := range server.VirtLinkMap {
+		if vKey.TransitAreaId == areaId && vEnt.Up {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+RFC 2328 15
+Virtual link comes up once intra area path to the other end point is
+found in the transit area. Called after the per area routing tables are
+built.
+*/
+func (server *OSPFServer) updateVirtLinks() {
+	for vKey, vEnt := range server.VirtLinkMap {
+		nEnt, reachable := server.resolveVirtLink(vKey, vEnt)
+		switch {
+		case reachable && !vEnt.Up:
+			server.virtLinkUp(vKey, nEnt)
+		case !reachable && vEnt.Up:
+			server.virtLinkDown(vKey, vEnt, false)
+		case reachable && vEnt.Up:
+			if nEnt.Cost == vEnt.Cost &&
+				nEnt.NextHopIp.Equal(vEnt.NextHopIp) &&
+				nEnt.RemoteIp.Equal(vEnt.RemoteIp) {
+				server.updateVirtLinkNextHops(vEnt)
+				continue
+			}
+			server.logger.Info(fmt.Sprintln("VLINK: Virtual link path changed", vKey, nEnt.Cost))
+			nEnt.Up = true
+			server.VirtLinkMap[vKey] = nEnt
+			if ent, exist := server.IntfConfMap[nEnt.IntfKey]; exist {
+				ent.IfCost = uint32(nEnt.Cost)
+				ent.IfIpAddr = nEnt.LocalIp
+				server.IntfConfMap[nEnt.IntfKey] = ent
+			}
+			server.updateVirtLinkNextHops(nEnt)
+			go server.sendAreaRouterLsaChange(0)
+		}
+	}
+}
+
+func (server *OSPFServer) resolveVirtLink(vKey VirtLinkKey, vEnt VirtLinkEntry) (VirtLinkEntry, bool) {
+	areaIdKey := AreaIdKey{
+		AreaId: vKey.TransitAreaId,
+	}
+	tempAreaRoutingTbl, exist := server.TempAreaRoutingTbl[areaIdKey]
+	if !exist || tempAreaRoutingTbl.RoutingTblMap == nil {
+		return vEnt, false
+	}
+	rKey := RoutingTblEntryKey{
+		DestId:   vKey.NbrRtrId,
+		AddrMask: 0,
+		DestType: AreaBdrRouter,
+	}
+	rEnt, exist := tempAreaRoutingTbl.RoutingTblMap[rKey]
+	if !exist {
+		rKey.DestType = ASAreaBdrRouter
+		rEnt, exist = tempAreaRoutingTbl.RoutingTblMap[rKey]
+		if !exist {
+			return vEnt, false
+		}
+	}
+	if rEnt.PathType != IntraArea || len(rEnt.NextHops) == 0 {
+		return vEnt, false
+	}
+
+	// Pick one next hop so that the virtual link address is stable
+	var nextHop NextHop
+	first := true
+	for nh, _ := range rEnt.NextHops {
+		if first || nh.IfIPAddr < nextHop.IfIPAddr {
+			nextHop = nh
+			first = false
+		}
+	}
+
+	remoteIp := server.getVirtLinkRemoteIp(vKey)
+	if remoteIp == 0 {
+		return vEnt, false
+	}
+	phyKey, exist := server.getVirtLinkPhyIntf(vKey.TransitAreaId, nextHop.IfIPAddr)
+	if !exist {
+		return vEnt, false
+	}
+
+	vEnt.PhyIntfKey = phyKey
+	vEnt.LocalIp = net.ParseIP(convertUint32ToIPv4(nextHop.IfIPAddr))
+	vEnt.RemoteIp = net.ParseIP(convertUint32ToIPv4(remoteIp))
+	if nextHop.NextHopIP == 0 {
+		vEnt.NextHopIp = vEnt.RemoteIp
+	} else {
+		vEnt.NextHopIp = net.ParseIP(convertUint32ToIPv4(nextHop.NextHopIP))
+	}
+	vEnt.Cost = rEnt.Cost
+	return vEnt, true
+}
+
+/*
+RFC 2328 16.1
+IP address of the other end point is taken from its router LSA in the
+transit area.
+*/
+func (server *OSPFServer) getVirtLinkRemoteIp(vKey VirtLinkKey) uint32 {
+	lsdbKey := LsdbKey{
+		AreaId: vKey.TransitAreaId,
+	}
+	lsDbEnt, exist := server.AreaLsdb[lsdbKey]
+	if !exist {
+		return 0
+	}
+	lsaKey := LsaKey{
+		LSType:    RouterLSA,
+		LSId:      vKey.NbrRtrId,
+		AdvRouter: vKey.NbrRtrId,
+	}
+	lsaEnt, exist := lsDbEnt.RouterLsaMap[lsaKey]
+	if !exist {
+		return 0
+	}
+	var p2pIp uint32
+	for _, link := range lsaEnt.LinkDetails {
+		if link.LinkType == TransitLink {
+			return link.LinkData
+		}
+		if link.LinkType == P2PLink && p2pIp == 0 {
+			p2pIp = link.LinkData
+		}
+	}
+	return p2pIp
+}
+
+func (server *OSPFServer) getVirtLinkPhyIntf(areaId uint32, ipAddr uint32) (IntfConfKey, bool) {
+	for key, ent := range server.IntfConfMap {
+		if ent.IfType == config.VirtualLink ||
+			convertIPv4ToUint32(ent.IfAreaId) != areaId {
+			continue
+		}
+		if convertAreaOrRouterIdUint32(ent.IfIpAddr.String()) == ipAddr {
+			return key, true
+		}
+	}
+	return IntfConfKey{}, false
+}
+
+/*
+Routes computed over the virtual link point to the other end point which
+is not directly connected. Replace them with the next hop in the transit
+area.
+*/
+func (server *OSPFServer) updateVirtLinkNextHops(vEnt VirtLinkEntry) {
+	areaIdKey := AreaIdKey{
+		AreaId: 0,
+	}
+	tempAreaRoutingTbl, exist := server.TempAreaRoutingTbl[areaIdKey]
+	if !exist || tempAreaRoutingTbl.RoutingTblMap == nil {
+		return
+	}
+	localIp := convertAreaOrRouterIdUint32(vEnt.LocalIp.String())
+	remoteIp := convertAreaOrRouterIdUint32(vEnt.RemoteIp.String())
+	nextHopIp := convertAreaOrRouterIdUint32(vEnt.NextHopIp.String())
+	for rKey, rEnt := range tempAreaRoutingTbl.RoutingTblMap {
+		for nextHop, _ := range rEnt.NextHops {
+			if nextHop.IfIPAddr != localIp ||
+				nextHop.NextHopIP != remoteIp {
+				continue
+			}
+			delete(rEnt.NextHops, nextHop)
+			nextHop.IfIdx = uint32(vEnt.PhyIntfKey.IntfIdx)
+			nextHop.NextHopIP = nextHopIp
+			rEnt.NextHops[nextHop] = true
+		}
+		rEnt.NumOfPaths = len(rEnt.NextHops)
+		tempAreaRoutingTbl.RoutingTblMap[rKey] = rEnt
+	}
+	server.TempAreaRoutingTbl[areaIdKey] = tempAreaRoutingTbl
+}
+
+func (server *OSPFServer) virtLinkUp(vKey VirtLinkKey, vEnt VirtLinkEntry) {
+	phyEnt, exist := server.IntfConfMap[vEnt.PhyIntfKey]
+	if !exist {
+		return
+	}
+	authType, cryptoAlgo := getIntfAuthType(vEnt.Conf.VirtIfAuthType)
+	authKey := getIntfAuthKey(vEnt.Conf.VirtIfAuthType, vEnt.Conf.VirtIfAuthKey)
+	if authKey == nil {
+		server.logger.Err(fmt.Sprintln("VLINK: Invalid authentication key", vKey))
+		return
+	}
+	key := vEnt.IntfKey
+	var ent IntfConf
+	ent.IfAreaId = []byte{0, 0, 0, 0}
+	ent.IfType = config.VirtualLink
+	// Virtual links are driven by SPF and not by interface admin state
+	ent.IfAdminStat = config.Disabled
+	ent.IfRtrPriority = 0
+	ent.IfTransitDelay = vEnt.Conf.VirtIfTransitDelay
+	ent.IfRetransInterval = vEnt.Conf.VirtIfRetransInterval
+	ent.IfHelloInterval = uint16(vEnt.Conf.VirtIfHelloInterval)
+	ent.IfRtrDeadInterval = uint32(vEnt.Conf.VirtIfRtrDeadInterval)
+	ent.IfPollInterval = 0
+	ent.IfAuthKey = authKey
+	ent.IfMulticastForwarding = config.Blocked
+	ent.IfDemand = false
+	ent.IfAuthType = authType
+	ent.IfAuthKeyId = 0
+	ent.IfCryptoAlgo = cryptoAlgo
+	ent.IfAuthState = newIntfAuthState()
+	ent.FSMCtrlCh = make(chan bool)
+	ent.FSMCtrlStatusCh = make(chan bool)
+	ent.BackupSeenCh = make(chan BackupSeenMsg)
+	ent.NeighCreateCh = make(chan NeighCreateMsg)
+	ent.NeighChangeCh = make(chan NeighChangeMsg)
+	ent.NbrStateChangeCh = make(chan NbrStateChangeMsg)
+	ent.NbrFullStateCh = make(chan NbrFullStateMsg)
+	ent.WaitTimer = nil
+	ent.PollIntervalTicker = nil
+	ent.NeighborMap = make(map[NeighborConfKey]NeighborData)
+	ent.IfNetmask = []byte{0, 0, 0, 0}
+	ent.IfIpAddr = vEnt.LocalIp
+	ent.IfName = phyEnt.IfName
+	ent.IfMtu = phyEnt.IfMtu
+	ent.IfMacAddr = phyEnt.IfMacAddr
+	ent.IfCost = uint32(vEnt.Cost)
+	ent.IfDRIp = []byte{0, 0, 0, 0}
+	ent.IfBDRIp = []byte{0, 0, 0, 0}
+	ent.IfDRtrId = 0
+	ent.IfBDRtrId = 0
+	ent.IfEvents = 1
+	ent.IfLsaCount = 0
+	ent.IfLsaCksumSum = 0
+	ent.IfMetricTOSMap = make(map[uint8]uint32)
+	helloInterval := time.Duration(ent.IfHelloInterval) * time.Second
+	ent.HelloIntervalTicker = time.NewTicker(helloInterval)
+	ent.IfFSMState = config.P2P
+	server.IntfConfMap[key] = ent
+	server.IntfTxMap[key] = server.IntfTxMap[vEnt.PhyIntfKey]
+
+	vEnt.Up = true
+	server.VirtLinkMap[vKey] = vEnt
+	server.updateVirtLinkNextHops(vEnt)
+	server.updateIntfToAreaMap(key, "none", "0.0.0.0")
+	server.logger.Info(fmt.Sprintln("VLINK: Virtual link up", vKey, "local", vEnt.LocalIp, "remote", vEnt.RemoteIp, "cost", vEnt.Cost))
+	go server.StartOspfIntfFSM(key)
+	go server.sendAreaRouterLsaChange(vKey.TransitAreaId)
+}
+
+func (server *OSPFServer) virtLinkDown(vKey VirtLinkKey, vEnt VirtLinkEntry, remove bool) {
+	server.logger.Info(fmt.Sprintln("VLINK: Virtual link down", vKey))
+	vEnt.Up = false
+	if !remove {
+		server.VirtLinkMap[vKey] = vEnt
+	}
+	server.updateIntfToAreaMap(vEnt.IntfKey, "0.0.0.0", "none")
+	go server.stopVirtLinkIntf(vKey, vEnt.IntfKey, remove)
+}
+
+func (server *OSPFServer) stopVirtLinkIntf(vKey VirtLinkKey, key IntfConfKey, remove bool) {
+	server.StopOspfIntfFSM(key)
+	if remove {
+		delete(server.IntfConfMap, key)
+		delete(server.IntfTxMap, key)
+	} else {
+		ent, _ := server.IntfConfMap[key]
+		ent.NeighborMap = nil
+		ent.IfEvents = ent.IfEvents + 1
+		ent.IfFSMState = config.Down
+		server.IntfConfMap[key] = ent
+	}
+	// Clears the neighbors on the virtual link and backbone router LSA
+	msg := NetworkLSAChangeMsg{
+		areaId:  0,
+		intfKey: key,
+	}
+	server.IntfStateChangeCh <- msg
+	server.sendAreaRouterLsaChange(vKey.TransitAreaId)
+}
+
+/*
+Used when ospf is globally disabled. Virtual links come up again after
+the first SPF run.
+*/
+func (server *OSPFServer) stopVirtLinks() {
+	for vKey, vEnt := range server.VirtLinkMap {
+		if !vEnt.Up {
+			continue
+		}
+		server.StopOspfIntfFSM(vEnt.IntfKey)
+		ent, _ := server.IntfConfMap[vEnt.IntfKey]
+		ent.NeighborMap = nil
+		ent.IfFSMState = config.Down
+		server.IntfConfMap[vEnt.IntfKey] = ent
+		server.updateIntfToAreaMap(vEnt.IntfKey, "0.0.0.0", "none")
+		vEnt.Up = false
+		server.VirtLinkMap[vKey] = vEnt
+	}
+}
+
+func (server *OSPFServer) getVirtLinkPkt(key IntfConfKey, ospfPkt []byte) []byte {
+	for _, vEnt := range server.VirtLinkMap {
+		if vEnt.IntfKey != key {
+			continue
+		}
+		if !vEnt.Up {
+			return nil
+		}
+		nbrKey := NeighborConfKey{
+			IPAddr:  config.IpAddress(vEnt.NextHopIp.String()),
+			IntfIdx: vEnt.PhyIntfKey.IntfIdx,
+		}
+		return getOspfUnicastPkt(ospfPkt, vEnt.RemoteIp, getNbrDstMAC(nbrKey), VIRT_LINK_TTL)
+	}
+	return nil
+}
+
+/*
+RFC 2328 8.2
+Packet with backbone area id received on the transit area interface is
+associated with the virtual link to the originating router.
+*/
+func (server *OSPFServer) getVirtLinkRxIntfKey(ent IntfConf, ospfPkt []byte) (IntfConfKey, bool) {
+	if len(ospfPkt) < OSPF_HEADER_SIZE || len(server.VirtLinkMap) == 0 {
+		return IntfConfKey{}, false
+	}
+	ifAreaId := convertIPv4ToUint32(ent.IfAreaId)
+	if ifAreaId == 0 || convertIPv4ToUint32(ospfPkt[8:12]) != 0 {
+		return IntfConfKey{}, false
+	}
+	vKey := VirtLinkKey{
+		TransitAreaId: ifAreaId,
+		NbrRtrId:      convertIPv4ToUint32(ospfPkt[4:8]),
+	}
+	vEnt, exist := server.VirtLinkMap[vKey]
+	if !exist || !vEnt.Up {
+		return IntfConfKey{}, false
+	}
+	return vEnt.IntfKey, true
+}
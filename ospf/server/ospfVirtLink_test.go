@@ -0,0 +1,249 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/ospf/config"
+	"net"
+	"testing"
+)
+
+func TestOspfNbmaIntf(t *testing.T) {
+	fmt.Println("\n**************** NBMA INTF ************\n")
+	var tNum int
+	heard := NeighborConfKey{IPAddr: "10.1.1.2"}
+	eligibleNbr := NeighborConfKey{IPAddr: "10.1.1.3"}
+	ineligibleNbr := NeighborConfKey{IPAddr: "10.1.1.4"}
+	staticNbrs := map[NeighborConfKey]NbmaNbrConf{
+		heard:         NbmaNbrConf{NbrPriority: 1},
+		eligibleNbr:   NbmaNbrConf{NbrPriority: 1},
+		ineligibleNbr: NbmaNbrConf{NbrPriority: 0},
+	}
+	ent := IntfConf{
+		IfType:        config.Nbma,
+		IfRtrPriority: 1,
+		IfFSMState:    config.Waiting,
+		IfDRIp:        []byte{0, 0, 0, 0},
+		IfBDRIp:       []byte{0, 0, 0, 0},
+		NeighborMap: map[NeighborConfKey]NeighborData{
+			heard: NeighborData{RtrPrio: 0},
+		},
+	}
+
+	tNum = 1
+	fmt.Println(tNum, ": Running nbmaHelloDstList hello interval")
+	dstList := nbmaHelloDstList(ent, staticNbrs, false)
+	if len(dstList) != 1 || dstList[0] != eligibleNbr {
+		t.Fatal("Waiting eligible router should send hello to eligible neighbors", dstList)
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running nbmaHelloDstList poll interval")
+	dstList = nbmaHelloDstList(ent, staticNbrs, true)
+	if len(dstList) != 1 || dstList[0] != ineligibleNbr {
+		t.Fatal("Down neighbors should be polled", dstList)
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running nbmaHelloDstList DR")
+	ent.IfFSMState = config.DesignatedRouter
+	dstList = nbmaHelloDstList(ent, staticNbrs, false)
+	if len(dstList) != 1 || dstList[0] != heard {
+		t.Fatal("DR should send hello to all the neighbors heard from", dstList)
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running getOspfUnicastPkt")
+	pkt := make([]byte, ETH_HEADER_LEN+IP_HEADER_MIN_LEN+OSPF_HEADER_SIZE)
+	ipHdr := pkt[ETH_HEADER_LEN:]
+	ipHdr[0] = 0x45
+	ipHdr[8] = 1
+	copy(ipHdr[12:16], []byte{10, 1, 1, 1})
+	copy(ipHdr[16:20], []byte{224, 0, 0, 5})
+	if !isOspfMcastPkt(pkt) {
+		t.Fatal("Packet to AllSPFRouters should be multicast")
+	}
+	dstMac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	ucastPkt := getOspfUnicastPkt(pkt, net.ParseIP("10.1.1.2"), dstMac, VIRT_LINK_TTL)
+	if ucastPkt == nil || isOspfMcastPkt(ucastPkt) {
+		t.Fatal("Unable to build unicast packet")
+	}
+	ipHdr = ucastPkt[ETH_HEADER_LEN : ETH_HEADER_LEN+IP_HEADER_MIN_LEN]
+	if !bytesEqual(ucastPkt[0:6], dstMac) || ipHdr[8] != VIRT_LINK_TTL ||
+		!bytesEqual(ipHdr[16:20], []byte{10, 1, 1, 2}) || computeCheckSum(ipHdr) != 0 {
+		t.Fatal("Unicast packet header mismatch", ipHdr)
+	}
+	if pkt[ETH_HEADER_LEN+16] != 224 {
+		t.Fatal("Original packet should not be modified")
+	}
+}
+
+func TestOspfP2MPRouterLsa(t *testing.T) {
+	fmt.Println("\n**************** P2MP ROUTER LSA ************\n")
+	server := getServerObject()
+	intfKey := IntfConfKey{
+		IPAddr:  "10.1.1.1",
+		IntfIdx: 0,
+	}
+	ent := IntfConf{
+		IfType:   config.PointToMultipoint,
+		IfIpAddr: net.ParseIP("10.1.1.1"),
+		IfCost:   10,
+	}
+	nbrKey := NeighborConfKey{IPAddr: "10.1.1.2"}
+	if ospfIntfToNbrMap == nil {
+		ospfIntfToNbrMap = make(map[IntfConfKey]ospfNbrMdata)
+	}
+	ospfIntfToNbrMap[intfKey] = ospfNbrMdata{
+		nbrList: []NeighborConfKey{nbrKey},
+	}
+	server.NeighborConfigMap[nbrKey] = OspfNeighborEntry{
+		OspfNbrRtrId: convertAreaOrRouterIdUint32("2.2.2.2"),
+		OspfNbrState: config.NbrFull,
+	}
+
+	fmt.Println(1, ": Running constructP2MPLinks")
+	links := server.constructP2MPLinks(intfKey, ent)
+	if len(links) != 2 {
+		t.Fatal("P2MP interface should advertise host route and p2p link", links)
+	}
+	ipAddr := convertAreaOrRouterIdUint32("10.1.1.1")
+	if links[0].LinkType != StubLink || links[0].LinkId != ipAddr ||
+		links[0].LinkData != 0xffffffff || links[0].LinkMetric != 0 {
+		t.Fatal("Host route mismatch", links[0])
+	}
+	if links[1].LinkType != P2PLink || links[1].LinkData != ipAddr ||
+		links[1].LinkId != convertAreaOrRouterIdUint32("2.2.2.2") ||
+		links[1].LinkMetric != 10 {
+		t.Fatal("P2P link mismatch", links[1])
+	}
+	delete(ospfIntfToNbrMap, intfKey)
+}
+
+func TestOspfVirtLink(t *testing.T) {
+	fmt.Println("\n**************** VIRTUAL LINK ************\n")
+	server := getServerObject()
+	var tNum int
+	transitArea := AreaConfKey{
+		AreaId: config.AreaId("0.0.0.1"),
+	}
+	server.AreaConfMap[transitArea] = AreaConf{
+		ImportAsExtern: config.ImportExternal,
+		IntfListMap:    make(map[IntfConfKey]bool),
+	}
+	server.AreaConfMap[AreaConfKey{AreaId: config.AreaId("0.0.0.2")}] = AreaConf{
+		ImportAsExtern: config.ImportNoExternal,
+		IntfListMap:    make(map[IntfConfKey]bool),
+	}
+	conf := config.VirtIfConf{
+		VirtIfAreaId:          config.AreaId("0.0.0.1"),
+		VirtIfNeighbor:        config.RouterId("3.3.3.3"),
+		VirtIfTransitDelay:    1,
+		VirtIfRetransInterval: 5,
+		VirtIfHelloInterval:   10,
+		VirtIfRtrDeadInterval: 40,
+		VirtIfAuthType:        config.NoAuth,
+	}
+	go func() {
+		for {
+			<-server.IntfStateChangeCh
+		}
+	}()
+
+	tNum = 1
+	fmt.Println(tNum, ": Running processVirtIfConfig invalid areas")
+	bbConf := conf
+	bbConf.VirtIfAreaId = config.AreaId("0.0.0.0")
+	if server.processVirtIfConfig(bbConf) == nil {
+		t.Fatal("Virtual link through backbone should be rejected")
+	}
+	stubConf := conf
+	stubConf.VirtIfAreaId = config.AreaId("0.0.0.2")
+	if server.processVirtIfConfig(stubConf) == nil {
+		t.Fatal("Virtual link through stub area should be rejected")
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running processVirtIfConfig")
+	err := server.processVirtIfConfig(conf)
+	if err != nil {
+		t.Fatal("Failed to configure virtual link", err)
+	}
+	vKey := VirtLinkKey{
+		TransitAreaId: convertAreaOrRouterIdUint32("0.0.0.1"),
+		NbrRtrId:      convertAreaOrRouterIdUint32("3.3.3.3"),
+	}
+	vEnt, exist := server.VirtLinkMap[vKey]
+	if !exist || vEnt.Up || vEnt.IntfKey.IntfIdx <= VirtLinkIfIdxBase {
+		t.Fatal("Virtual link entry mismatch", vEnt)
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running getVirtLinkRxIntfKey")
+	phyEnt := IntfConf{
+		IfAreaId: []byte{0, 0, 0, 1},
+	}
+	ospfPkt := make([]byte, OSPF_HEADER_SIZE)
+	copy(ospfPkt[4:8], []byte{3, 3, 3, 3})
+	if _, ok := server.getVirtLinkRxIntfKey(phyEnt, ospfPkt); ok {
+		t.Fatal("Packets should not be received on virtual link which is down")
+	}
+	vEnt.Up = true
+	server.VirtLinkMap[vKey] = vEnt
+	vIntfKey, ok := server.getVirtLinkRxIntfKey(phyEnt, ospfPkt)
+	if !ok || vIntfKey != vEnt.IntfKey {
+		t.Fatal("Backbone packet from virtual neighbor should map to virtual link")
+	}
+	copy(ospfPkt[8:12], []byte{0, 0, 0, 1})
+	if _, ok := server.getVirtLinkRxIntfKey(phyEnt, ospfPkt); ok {
+		t.Fatal("Transit area packet should stay on the physical interface")
+	}
+	if !server.isVirtLinkTransitArea(vKey.TransitAreaId) {
+		t.Fatal("Area with virtual link up should be transit area")
+	}
+
+	tNum++
+	fmt.Println(tNum, ": Running getVirtLinkPkt")
+	vEnt.RemoteIp = net.ParseIP("20.1.1.3")
+	vEnt.NextHopIp = net.ParseIP("10.1.1.2")
+	server.VirtLinkMap[vKey] = vEnt
+	pkt := make([]byte, ETH_HEADER_LEN+IP_HEADER_MIN_LEN+OSPF_HEADER_SIZE)
+	pkt[ETH_HEADER_LEN] = 0x45
+	copy(pkt[ETH_HEADER_LEN+16:ETH_HEADER_LEN+20], []byte{224, 0, 0, 5})
+	vPkt := server.getVirtLinkPkt(vEnt.IntfKey, pkt)
+	if vPkt == nil || vPkt[ETH_HEADER_LEN+8] != VIRT_LINK_TTL ||
+		!bytesEqual(vPkt[ETH_HEADER_LEN+16:ETH_HEADER_LEN+20], []byte{20, 1, 1, 3}) {
+		t.Fatal("Virtual link packet should be sent to the end point", vPkt)
+	}
+	vEnt.Up = false
+	server.VirtLinkMap[vKey] = vEnt
+
+	tNum++
+	fmt.Println(tNum, ": Running processVirtIfDelete")
+	server.processVirtIfDelete(conf)
+	if _, exist := server.VirtLinkMap[vKey]; exist {
+		t.Fatal("Virtual link is not deleted")
+	}
+}
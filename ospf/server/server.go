@@ -82,6 +82,13 @@ type OSPFServer struct {
 	AreaConfigCh           chan config.AreaConf
 	IntfConfigCh           chan config.InterfaceConf
 	IfMetricConfCh         chan config.IfMetricConf
+	NbrConfigCh            chan config.NbrConf
+	NbrDeleteCh            chan config.NbrConf
+	VirtIfConfigCh         chan config.VirtIfConf
+	VirtIfDeleteCh         chan config.VirtIfConf
+	NbmaNbrMap             map[NeighborConfKey]NbmaNbrConf
+	VirtLinkMap            map[VirtLinkKey]VirtLinkEntry
+	virtLinkIfIdx          int
 	GlobalConfigRetCh      chan error
 	AreaConfigRetCh        chan error
 	IntfConfigRetCh        chan error
@@ -89,6 +96,8 @@ type OSPFServer struct {
 	LsdbSlice              []LsdbSliceEnt
 	LsdbStateTimer         *time.Timer
 	AreaSelfOrigLsa        map[LsdbKey]SelfOrigLsa
+	NssaTranslatedLsa      map[LsaKey]bool
+	LinkOpaqueLsdb         map[IntfConfKey]map[LsaKey]OpaqueLsa
 	LsdbUpdateCh           chan LsdbUpdateMsg
 	LsaUpdateRetCodeCh     chan bool
 	IntfStateChangeCh      chan NetworkLSAChangeMsg
@@ -98,6 +107,14 @@ type OSPFServer struct {
 	AdjOKEvtCh             chan AdjOKEvtMsg
 	maxAgeLsaCh            chan maxAgeLsaMsg
 	ExternalRouteNotif     chan RouteMdata
+	GrHelperMap            map[NeighborConfKey]GrHelperEntry
+	GrHelperExitMap        map[NeighborConfKey]config.RestartExitReason
+	GrRestartNbrs          map[uint32]bool
+	GrRestartEnd           time.Time
+	GrRestartTimer         *time.Timer
+	GrRestartExitCh        chan config.RestartExitReason
+	GrPreservedRoutingTbl  map[RoutingTblEntryKey]GlobalRoutingTblEntry
+	grMutex                sync.Mutex
 
 	//	   connRoutesTimer         *time.Timer
 	ribSubSocket      *nanomsg.SubSocket
@@ -161,6 +178,9 @@ type OSPFServer struct {
 	AreaGraph      map[VertexKey]Vertex
 	SPFTree        map[VertexKey]TreeVertex
 	AreaStubs      map[VertexKey]StubVertex
+	SpfAreaCache   map[uint32]AreaSpfCache
+	SpfRunMap      map[uint32]SpfType
+	spfThrottle    SpfThrottle
 
 	dbHdl        *dbutils.DBUtil
 	DbReadConfig chan bool
@@ -176,6 +196,10 @@ func NewOSPFServer(logger *logging.Writer) *OSPFServer {
 	ospfServer.AreaConfigCh = make(chan config.AreaConf)
 	ospfServer.IntfConfigCh = make(chan config.InterfaceConf)
 	ospfServer.IfMetricConfCh = make(chan config.IfMetricConf)
+	ospfServer.NbrConfigCh = make(chan config.NbrConf)
+	ospfServer.NbrDeleteCh = make(chan config.NbrConf)
+	ospfServer.VirtIfConfigCh = make(chan config.VirtIfConf)
+	ospfServer.VirtIfDeleteCh = make(chan config.VirtIfConf)
 	ospfServer.GlobalConfigRetCh = make(chan error)
 	ospfServer.AreaConfigRetCh = make(chan error)
 	ospfServer.IntfConfigRetCh = make(chan error)
@@ -187,13 +211,21 @@ func NewOSPFServer(logger *logging.Writer) *OSPFServer {
 	ospfServer.IntfConfMap = make(map[IntfConfKey]IntfConf)
 	ospfServer.IntfTxMap = make(map[IntfConfKey]IntfTxHandle)
 	ospfServer.IntfRxMap = make(map[IntfConfKey]IntfRxHandle)
+	ospfServer.NbmaNbrMap = make(map[NeighborConfKey]NbmaNbrConf)
+	ospfServer.VirtLinkMap = make(map[VirtLinkKey]VirtLinkEntry)
 	ospfServer.AreaLsdb = make(map[LsdbKey]LSDatabase)
 	ospfServer.AreaSelfOrigLsa = make(map[LsdbKey]SelfOrigLsa)
+	ospfServer.NssaTranslatedLsa = make(map[LsaKey]bool)
+	ospfServer.LinkOpaqueLsdb = make(map[IntfConfKey]map[LsaKey]OpaqueLsa)
 	ospfServer.IntfStateChangeCh = make(chan NetworkLSAChangeMsg)
 	ospfServer.NetworkDRChangeCh = make(chan DrChangeMsg)
 	ospfServer.CreateNetworkLSACh = make(chan ospfNbrMdata)
 	ospfServer.FlushNetworkLSACh = make(chan NetworkLSAChangeMsg)
 	ospfServer.ExternalRouteNotif = make(chan RouteMdata)
+	ospfServer.GrHelperMap = make(map[NeighborConfKey]GrHelperEntry)
+	ospfServer.GrHelperExitMap = make(map[NeighborConfKey]config.RestartExitReason)
+	ospfServer.GrRestartExitCh = make(chan config.RestartExitReason)
+	ospfServer.grMutex = sync.Mutex{}
 	ospfServer.LsdbSlice = []LsdbSliceEnt{}
 	ospfServer.LsdbUpdateCh = make(chan LsdbUpdateMsg)
 	ospfServer.LsaUpdateRetCodeCh = make(chan bool)
@@ -244,6 +276,7 @@ func NewOSPFServer(logger *logging.Writer) *OSPFServer {
 	ospfServer.TempAreaRoutingTbl = make(map[AreaIdKey]AreaRoutingTbl)
 	ospfServer.StartCalcSPFCh = make(chan bool)
 	ospfServer.DoneCalcSPFCh = make(chan bool)
+	ospfServer.SpfAreaCache = make(map[uint32]AreaSpfCache)
 
 	return ospfServer
 }
@@ -333,6 +366,8 @@ func (server *OSPFServer) InitServer(paramFile string) {
 		server.logger.Err(fmt.Sprintln("DB Initialization faliure err:", err))
 	}
 	go server.StartDBListener()
+	server.initGrRestart()
+	go server.grSigHandler()
 	/*
 	   server.logger.Info("Listen for RIBd updates")
 	   server.listenForRIBUpdates(ribdCommonDefs.PUB_SOCKET_ADDR)
@@ -382,6 +417,21 @@ func (server *OSPFServer) StartServer(paramFile string) {
 			if err == nil {
 
 			}
+		case nbrConf := <-server.NbrConfigCh:
+			server.logger.Info(fmt.Sprintln("Received call for performing NBMA Neighbor Configuration", nbrConf))
+			server.processNbrConfig(nbrConf)
+		case nbrConf := <-server.NbrDeleteCh:
+			server.logger.Info(fmt.Sprintln("Received call for deleting NBMA Neighbor Configuration", nbrConf))
+			server.processNbrDelete(nbrConf)
+		case virtIfConf := <-server.VirtIfConfigCh:
+			server.logger.Info(fmt.Sprintln("Received call for performing Virtual Interface Configuration", virtIfConf))
+			err := server.processVirtIfConfig(virtIfConf)
+			if err != nil {
+				server.logger.Err(fmt.Sprintln("Virtual Interface Configuration failed", err))
+			}
+		case virtIfConf := <-server.VirtIfDeleteCh:
+			server.logger.Info(fmt.Sprintln("Received call for deleting Virtual Interface Configuration", virtIfConf))
+			server.processVirtIfDelete(virtIfConf)
 		case asicdrxBuf := <-server.asicdSubSocketCh:
 			server.processAsicdNotification(asicdrxBuf)
 		case <-server.asicdSubSocketErrCh:
@@ -39,12 +39,14 @@ const (
 	CONNECTED                               = 0
 	STATIC                                  = 1
 	OSPF                                    = 89
+	ISIS                                    = 124
 	EBGP                                    = 8
 	IBGP                                    = 9
 	BGP                                     = 17
 	PUB_SOCKET_ADDR                         = "ipc:///tmp/ribd.ipc"
 	PUB_SOCKET_BGPD_ADDR                    = "ipc:///tmp/ribd_bgpd.ipc"
 	PUB_SOCKET_OSPFD_ADDR                   = "ipc:///tmp/ribd_ospfd.ipc"
+	PUB_SOCKET_ISISD_ADDR                   = "ipc:///tmp/ribd_isisd.ipc"
 	PUB_SOCKET_BFDD_ADDR                    = "ipc:///tmp/ribd_bfdd.ipc"
 	PUB_SOCKET_VXLAND_ADDR                  = "ipc:///tmp/ribd_vxland.ipc"
 	PUB_SOCKET_POLICY_ADDR                  = "ipc:///tmp/ribd_policyd.ipc"
@@ -64,10 +66,12 @@ const (
 	NOTIFY_POLICY_PREFIX_SET_CREATED        = 14
 	NOTIFY_POLICY_PREFIX_SET_DELETED        = 15
 	NOTIFY_POLICY_PREFIX_SET_UPDATED        = 15
+	NOTIFY_NEXTHOP_TRACKING_UPDATE          = 16
 	DEFAULT_NOTIFICATION_SIZE               = 128
 	RoutePolicyStateChangetoValid           = 1
 	RoutePolicyStateChangetoInValid         = 2
 	RoutePolicyStateChangeNoChange          = 3
+	DEFAULT_VRF                             = "default"
 )
 
 type RibdNotifyMsg struct {
@@ -78,12 +82,45 @@ type RibdNotifyMsg struct {
 type RoutelistInfo struct {
 	RouteInfo ribdInt.Routes
 }
+
+/*
+OSPF graceful restart state saved in DB by ospfd before a
+planned restart. RIBd keeps OSPF routes till GracePeriodEnd.
+*/
+const OSPF_GR_DB_KEY = "OspfGracefulRestart"
+
+type OspfGrDbEntry struct {
+	GracePeriodEnd int64 //unix time
+	RestartReason  int
+	Neighbors      string //router ids of the full neighbors
+}
+
 type RouteReachabilityStatusMsgInfo struct {
+	Vrf         string
 	Network     string
 	IsReachable bool
 	NextHopIntf ribdInt.NextHopInfo
 }
 
+/*
+Resolution of a next hop registered for tracking. NextHopList has the
+directly connected next hops reached through the recursive lookup.
+*/
+type NextHopTrackingNextHop struct {
+	NextHopIp      string
+	NextHopIfIndex ribdInt.Int
+}
+
+type NextHopTrackingMsgInfo struct {
+	Vrf              string
+	NextHopIp        string
+	IsReachable      bool
+	ResolvingNetwork string
+	Protocol         string
+	Metric           ribdInt.Int
+	NextHopList      []NextHopTrackingNextHop
+}
+
 func GetNextHopIfTypeStr(nextHopIfType ribdInt.Int) (nextHopIfTypeStr string, err error) {
 	nextHopIfTypeStr = ""
 	switch nextHopIfType {
@@ -51,7 +51,8 @@ struct Routes {
 	17: bool NetworkStatement,
 	18: string RouteOrigin,
 	19: int Weight,
-	20: int IPAddrType
+	20: int IPAddrType,
+	21: string Vrf
 }
 struct RoutesGetInfo {
 	1: int StartIdx,
@@ -126,6 +127,7 @@ struct RouteNextHopInfo {
 	1 : string NextHopIp
 	2 : string NextHopIntRef
 	3 : i32 Weight
+	4 : list<i32> Labels
 }
 struct IPv4RouteState {
 	1 : string DestinationNw
@@ -147,6 +149,59 @@ struct IPv6RouteState {
 	7 : list<string> PolicyList
 	8 : NextBestRouteInfo NextBestRoute
 }
+struct Vrf {
+	1 : string Name
+	2 : list<string> IntfList
+}
+struct VrfRouteLeak {
+	1 : string SrcVrf
+	2 : string DstVrf
+	3 : string Policy
+}
+struct VrfRouteConfig {
+	1 : string Vrf
+	2 : string DestinationNw
+	3 : string NetworkMask
+	4 : string Protocol
+	5 : i32 Cost
+	6 : bool NullRoute
+	7 : list<RouteNextHopInfo> NextHop
+}
+struct VrfState {
+	1 : string Name
+	2 : list<string> IntfList
+	3 : i32 V4RouteCount
+	4 : i32 V6RouteCount
+	5 : list<VrfRouteLeak> LeakList
+}
+struct VrfStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<VrfState> VrfStateList
+}
+struct NextHopTrackingState {
+	1 : string Vrf
+	2 : string NextHopIp
+	3 : bool IsReachable
+	4 : string ResolvingNetwork
+	5 : string Protocol
+	6 : i32 Metric
+	7 : list<RouteNextHopInfo> NextHopList
+	8 : list<string> ClientList
+}
+struct NextHopTrackingClientState {
+	1 : string ClientName
+	2 : list<NextHopTrackingState> RegistrationList
+}
+struct NextHopTrackingClientStateGetInfo {
+	1: int StartIdx
+	2: int EndIdx
+	3: int Count
+	4: bool More
+	5: list<NextHopTrackingClientState> NextHopTrackingClientStateList
+}
 struct ApplyPolicyInfo {
 	1: string Source     
 	2: string Policy     
@@ -160,6 +215,20 @@ service RIBDINTServices
     //void printV4Routes();
 	RoutesGetInfo getBulkRoutesForProtocol(1: string srcProtocol, 2: int fromIndex ,3: int rcount)
     void TrackReachabilityStatus(1: string ipAddr, 2: string protocol, 3:string op) //op:"add"/"del"
+    NextHopInfo getVrfRouteReachabilityInfo(1: string vrf, 2: string desIPv4MasktNet,3: int ifIndex);
+    void TrackVrfReachabilityStatus(1: string vrf, 2: string ipAddr, 3: string protocol, 4:string op) //op:"add"/"del"
+	bool CreateVrf(1: Vrf config);
+	bool DeleteVrf(1: Vrf config);
+	bool CreateVrfRouteLeak(1: VrfRouteLeak config);
+	bool DeleteVrfRouteLeak(1: VrfRouteLeak config);
+	bool CreateVrfRoute(1: VrfRouteConfig config);
+	bool DeleteVrfRoute(1: VrfRouteConfig config);
+	VrfState getVrfState(1: string name);
+	VrfStateGetInfo getBulkVrfState(1: int fromIndex, 2: int rcount);
+	bool RegisterNextHop(1: string vrf, 2: string nextHopIp, 3: string client);
+	bool UnregisterNextHop(1: string vrf, 2: string nextHopIp, 3: string client);
+	NextHopTrackingState getNextHopTrackingState(1: string vrf, 2: string nextHopIp);
+	NextHopTrackingClientStateGetInfo getBulkNextHopTrackingClientState(1: int fromIndex, 2: int rcount);
 	//RoutesGetInfo getBulkRoutes(1: int fromIndex, 2: int count);
 	IPv4RouteState getv4Route(1: string destNetIp);
 	IPv6RouteState getv6Route(1: string destNetIp);
@@ -0,0 +1,72 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNextHopTrackingApis.go
+package rpc
+
+import (
+	"l3/rib/server"
+	"ribdInt"
+)
+
+/*
+   Register a next hop for tracking. The client receives the resolution of the
+   next hop right away and whenever it changes.
+*/
+func (m RIBDServicesHandler) RegisterNextHop(vrf string, nextHopIp string, client string) (val bool, err error) {
+	logger.Info("RegisterNextHop ", nextHopIp, " vrf ", vrf, " client ", client)
+	info := server.NextHopTrackingInfo{Vrf: vrf, NextHopIp: nextHopIp, Client: client}
+	err = m.server.NextHopTrackingConfigValidationCheck(info)
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: info,
+		Op:               "addNht",
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) UnregisterNextHop(vrf string, nextHopIp string, client string) (val bool, err error) {
+	logger.Info("UnregisterNextHop ", nextHopIp, " vrf ", vrf, " client ", client)
+	info := server.NextHopTrackingInfo{Vrf: vrf, NextHopIp: nextHopIp, Client: client}
+	err = m.server.NextHopTrackingConfigValidationCheck(info)
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: info,
+		Op:               "delNht",
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) GetNextHopTrackingState(vrf string, nextHopIp string) (*ribdInt.NextHopTrackingState, error) {
+	return m.server.GetNextHopTrackingState(vrf, nextHopIp)
+}
+
+func (m RIBDServicesHandler) GetBulkNextHopTrackingClientState(fromIndex ribdInt.Int, rcount ribdInt.Int) (clientStates *ribdInt.NextHopTrackingClientStateGetInfo, err error) {
+	return m.server.GetBulkNextHopTrackingClientState(fromIndex, rcount)
+}
@@ -272,7 +272,7 @@ func (m RIBDServicesHandler) GetBulkRoutesForProtocol(srcProtocol string, fromIn
    Api to track a route's reachability status
 */
 func (m RIBDServicesHandler) TrackReachabilityStatus(ipAddr string, protocol string, op string) (err error) {
-	m.server.TrackReachabilityCh <- server.TrackReachabilityInfo{ipAddr, protocol, op}
+	m.server.TrackReachabilityCh <- server.TrackReachabilityInfo{IpAddr: ipAddr, Protocol: protocol, Op: op}
 	return nil
 }
 
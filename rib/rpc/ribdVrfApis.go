@@ -0,0 +1,185 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdVrfApis.go
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"l3/rib/server"
+	"ribd"
+	"ribdInt"
+	netutils "utils/netUtils"
+)
+
+func (m RIBDServicesHandler) CreateVrf(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("CreateVrf ", cfg.Name, " intfList ", cfg.IntfList)
+	err = m.server.VrfConfigValidationCheck(cfg, "add")
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               "addVrf",
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) DeleteVrf(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("DeleteVrf ", cfg.Name)
+	err = m.server.VrfConfigValidationCheck(cfg, "del")
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               "delVrf",
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) CreateVrfRouteLeak(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("CreateVrfRouteLeak from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " policy ", cfg.Policy)
+	err = m.server.VrfRouteLeakConfigValidationCheck(cfg, "add")
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               "addVrfRouteLeak",
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) DeleteVrfRouteLeak(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("DeleteVrfRouteLeak from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf)
+	err = m.server.VrfRouteLeakConfigValidationCheck(cfg, "del")
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: cfg,
+		Op:               "delVrfRouteLeak",
+	}
+	return true, nil
+}
+
+/*
+   Vrf routes are processed as IPv4Route/IPv6Route objects tagged with the vrf
+*/
+func (m RIBDServicesHandler) processVrfRouteConfig(cfg *ribdInt.VrfRouteConfig, op string) (val bool, err error) {
+	if !m.server.IsVrfConfigured(cfg.Vrf) {
+		logger.Err("Vrf ", cfg.Vrf, " not configured")
+		return false, errors.New(fmt.Sprintln("Vrf ", cfg.Vrf, " not configured"))
+	}
+	nextHopList := make([]*ribd.NextHopInfo, 0)
+	nextHopLabels := make(map[string][]int32)
+	for _, nh := range cfg.NextHop {
+		nextHopList = append(nextHopList, &ribd.NextHopInfo{
+			NextHopIp:     nh.NextHopIp,
+			NextHopIntRef: nh.NextHopIntRef,
+			Weight:        nh.Weight,
+		})
+		if len(nh.Labels) > 0 {
+			nextHopLabels[nh.NextHopIp] = nh.Labels
+		}
+	}
+	if netutils.IsIPv6Addr(cfg.DestinationNw) {
+		v6Cfg := &ribd.IPv6Route{
+			DestinationNw: cfg.DestinationNw,
+			NetworkMask:   cfg.NetworkMask,
+			Protocol:      cfg.Protocol,
+			Cost:          cfg.Cost,
+			NullRoute:     cfg.NullRoute,
+			NextHop:       nextHopList,
+		}
+		err = m.server.IPv6RouteConfigValidationCheck(v6Cfg, op)
+		if err != nil {
+			logger.Err("validation check failed with error ", err)
+			return false, err
+		}
+		m.server.RouteConfCh <- server.RIBdServerConfig{
+			OrigConfigObject: v6Cfg,
+			Op:               op + "v6",
+			Vrf:              cfg.Vrf,
+			NextHopLabels:    nextHopLabels,
+		}
+		return true, nil
+	}
+	v4Cfg := &ribd.IPv4Route{
+		DestinationNw: cfg.DestinationNw,
+		NetworkMask:   cfg.NetworkMask,
+		Protocol:      cfg.Protocol,
+		Cost:          cfg.Cost,
+		NullRoute:     cfg.NullRoute,
+		NextHop:       nextHopList,
+	}
+	err = m.server.RouteConfigValidationCheck(v4Cfg, op)
+	if err != nil {
+		logger.Err("validation check failed with error ", err)
+		return false, err
+	}
+	m.server.RouteConfCh <- server.RIBdServerConfig{
+		OrigConfigObject: v4Cfg,
+		Op:               op,
+		Vrf:              cfg.Vrf,
+		NextHopLabels:    nextHopLabels,
+	}
+	return true, nil
+}
+
+func (m RIBDServicesHandler) CreateVrfRoute(cfg *ribdInt.VrfRouteConfig) (val bool, err error) {
+	logger.Info("CreateVrfRoute for vrf ", cfg.Vrf, " ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask)
+	return m.processVrfRouteConfig(cfg, "add")
+}
+
+func (m RIBDServicesHandler) DeleteVrfRoute(cfg *ribdInt.VrfRouteConfig) (val bool, err error) {
+	logger.Info("DeleteVrfRoute for vrf ", cfg.Vrf, " ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask)
+	return m.processVrfRouteConfig(cfg, "del")
+}
+
+func (m RIBDServicesHandler) GetVrfRouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	nh, err := m.server.GetVrfRouteReachabilityInfo(vrf, destNet, ifIndex)
+	return nh, err
+}
+
+/*
+   Api to track a route's reachability status in the vrf
+*/
+func (m RIBDServicesHandler) TrackVrfReachabilityStatus(vrf string, ipAddr string, protocol string, op string) (err error) {
+	m.server.TrackReachabilityCh <- server.TrackReachabilityInfo{IpAddr: ipAddr, Protocol: protocol, Op: op, Vrf: vrf}
+	return nil
+}
+
+func (m RIBDServicesHandler) GetVrfState(name string) (*ribdInt.VrfState, error) {
+	return m.server.GetVrfState(name)
+}
+
+func (m RIBDServicesHandler) GetBulkVrfState(fromIndex ribdInt.Int, rcount ribdInt.Int) (vrfStates *ribdInt.VrfStateGetInfo, err error) {
+	return m.server.GetBulkVrfState(fromIndex, rcount)
+}
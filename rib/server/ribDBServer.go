@@ -460,6 +460,11 @@ func (ribdServiceHandler *RIBDServer) StartDBServer() {
 					info.Op = "delv6"
 				}
 			}
+			if info.Op != "fetch" && info.OrigConfigObject.(RouteDBInfo).entry.vrf != ribdCommonDefs.DEFAULT_VRF {
+				//IPv4RouteState/IPv6RouteState objects are keyed by destination network only,
+				//routes in non default vrfs are reported through VrfState
+				continue
+			}
 			//logger.Info(" received message on DBRouteCh, op:", info.Op)
 			if info.Op == "add" {
 				ribdServiceHandler.WriteIPv4RouteStateEntryToDB(info.OrigConfigObject.(RouteDBInfo))
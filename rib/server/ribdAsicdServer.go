@@ -81,6 +81,10 @@ func addAsicdRoute(routeInfoRecord RouteInfoRecord) {
 	if asicdclnt.IsConnected == false {
 		return
 	}
+	if routeInfoRecord.vrf != ribdCommonDefs.DEFAULT_VRF {
+		addAsicdVrfRoute(routeInfoRecord)
+		return
+	}
 	/*	ipType := ""
 		if routeInfoRecord.ipType == ribdCommonDefs.IPv4 {
 			ipType = "IPv4"
@@ -152,10 +156,77 @@ func addAsicdRoute(routeInfoRecord RouteInfoRecord) {
 		}
 	}
 }
+/*
+   Routes in a non default vrf are programmed in the vrf's table in asicd
+*/
+func addAsicdVrfRoute(routeInfoRecord RouteInfoRecord) {
+	logger.Info("addAsicdVrfRoute for vrf ", routeInfoRecord.vrf, " ipType ", routeInfoRecord.ipType)
+	if routeInfoRecord.ipType == ribdCommonDefs.IPv4 {
+		asicdclnt.ClientHdl.OnewayCreateVrfIPv4Route(routeInfoRecord.vrf, []*asicdInt.IPv4Route{
+			&asicdInt.IPv4Route{
+				routeInfoRecord.destNetIp.String(),
+				routeInfoRecord.networkMask.String(),
+				[]*asicdInt.IPv4NextHop{
+					&asicdInt.IPv4NextHop{
+						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+						Weight:    int32(routeInfoRecord.weight + 1),
+					},
+				},
+			},
+		})
+	} else if routeInfoRecord.ipType == ribdCommonDefs.IPv6 {
+		asicdclnt.ClientHdl.OnewayCreateVrfIPv6Route(routeInfoRecord.vrf, []*asicdInt.IPv6Route{
+			&asicdInt.IPv6Route{
+				routeInfoRecord.destNetIp.String(),
+				routeInfoRecord.networkMask.String(),
+				[]*asicdInt.IPv6NextHop{
+					&asicdInt.IPv6NextHop{
+						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+						Weight:    int32(routeInfoRecord.weight + 1),
+					},
+				},
+			},
+		})
+	}
+}
+func delAsicdVrfRoute(routeInfoRecord RouteInfoRecord) {
+	logger.Info("delAsicdVrfRoute for vrf ", routeInfoRecord.vrf, " ipType ", routeInfoRecord.ipType)
+	if routeInfoRecord.ipType == ribdCommonDefs.IPv4 {
+		asicdclnt.ClientHdl.OnewayDeleteVrfIPv4Route(routeInfoRecord.vrf, []*asicdInt.IPv4Route{
+			&asicdInt.IPv4Route{
+				routeInfoRecord.destNetIp.String(),
+				routeInfoRecord.networkMask.String(),
+				[]*asicdInt.IPv4NextHop{
+					&asicdInt.IPv4NextHop{
+						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+						Weight:    int32(routeInfoRecord.weight + 1),
+					},
+				},
+			},
+		})
+	} else if routeInfoRecord.ipType == ribdCommonDefs.IPv6 {
+		asicdclnt.ClientHdl.OnewayDeleteVrfIPv6Route(routeInfoRecord.vrf, []*asicdInt.IPv6Route{
+			&asicdInt.IPv6Route{
+				routeInfoRecord.destNetIp.String(),
+				routeInfoRecord.networkMask.String(),
+				[]*asicdInt.IPv6NextHop{
+					&asicdInt.IPv6NextHop{
+						NextHopIp: routeInfoRecord.resolvedNextHopIpIntf.NextHopIp,
+						Weight:    int32(routeInfoRecord.weight + 1),
+					},
+				},
+			},
+		})
+	}
+}
 func delAsicdRoute(routeInfoRecord RouteInfoRecord) {
 	if asicdclnt.IsConnected == false {
 		return
 	}
+	if routeInfoRecord.vrf != ribdCommonDefs.DEFAULT_VRF {
+		delAsicdVrfRoute(routeInfoRecord)
+		return
+	}
 	logger.Info("delAsicdRoute with ipType ", routeInfoRecord.ipType)
 	if routeInfoRecord.ipType == ribdCommonDefs.IPv4 {
 		asicdclnt.ClientHdl.OnewayDeleteIPv4Route([]*asicdInt.IPv4Route{
@@ -268,7 +339,7 @@ func (ribdServiceHandler *RIBDServer) StartAsicdServer() {
 		case route := <-ribdServiceHandler.AsicdRouteCh:
 			logger.Info(" received message on AsicdRouteCh, op:", route.Op)
 			if route.Op == "add" {
-				if route.Bulk {
+				if route.Bulk && route.OrigConfigObject.(RouteInfoRecord).vrf == ribdCommonDefs.DEFAULT_VRF {
 					addAsicdRouteBulk(route.OrigConfigObject.(RouteInfoRecord), route.BulkEnd)
 				} else {
 					addAsicdRoute(route.OrigConfigObject.(RouteInfoRecord))
@@ -0,0 +1,254 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdBackupNextHop.go
+package server
+
+import (
+	"l3/rib/ribdCommonDefs"
+	"net"
+	"ribd"
+	"ribdInt"
+	"strconv"
+	"utils/patriciaDB"
+)
+
+/*
+   Backup next hops for IP fast reroute.
+   Protocols send precomputed loop free alternates along with the
+   primary next hops of a route. When the interface of the primary
+   next hop goes down or BFD reports the next hop as down, the route
+   is moved to the backup next hop in the FIB without waiting for the
+   protocol to reconverge. The next route update from the protocol
+   removes the backup.
+*/
+type BackupNextHopKey struct {
+	vrf      string
+	destNet  string
+	protocol string
+}
+
+type BackupNextHop struct {
+	nextHopIp      string
+	nextHopIfIndex ribd.Int
+}
+
+type BackupNextHopInfo struct {
+	nextHops     []BackupNextHop
+	active       bool
+	backupRecord RouteInfoRecord
+}
+
+/*
+   Failed next hop. nextHopIfIndex is -1 for BFD events and
+   nextHopIp is empty for interface down events
+*/
+type BackupSwitchoverInfo struct {
+	nextHopIfIndex ribd.Int
+	nextHopIp      string
+}
+
+var V4BackupNextHopMap = make(map[BackupNextHopKey]BackupNextHopInfo)
+
+func buildBackupNextHopRecord(vrf string, nextHop BackupNextHop) (routeInfoRecord RouteInfoRecord) {
+	routeInfoRecord.vrf = vrf
+	routeInfoRecord.ipType = ribdCommonDefs.IPv4
+	routeInfoRecord.nextHopIp = net.ParseIP(nextHop.nextHopIp)
+	routeInfoRecord.nextHopIfIndex = nextHop.nextHopIfIndex
+	_, routeInfoRecord.resolvedNextHopIpIntf, _ = ResolveVrfNextHop(vrf, nextHop.nextHopIp)
+	routeInfoRecord.resolvedNextHopIpIntf.NextHopIfIndex = ribdInt.Int(nextHop.nextHopIfIndex)
+	return routeInfoRecord
+}
+
+/*
+   Put the primary next hops removed by the switchover back in the FIB.
+   The protocol still uses them after it reconverged.
+*/
+func reinstallV4PrimaryNextHops(key BackupNextHopKey) {
+	item := RouteInfoMapGet(key.vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(key.destNet))
+	if item == nil {
+		return
+	}
+	routeInfoRecordList := item.(RouteInfoRecordList)
+	protocolRouteList := routeInfoRecordList.routeInfoProtocolMap[key.protocol]
+	for i := range protocolRouteList {
+		if !protocolRouteList[i].removedFromFIB {
+			continue
+		}
+		protocolRouteList[i].removedFromFIB = false
+		if routeInfoRecordList.selectedRouteProtocol == key.protocol {
+			RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: protocolRouteList[i], Op: "add"}
+		}
+	}
+	RouteInfoMapSet(key.vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(key.destNet), routeInfoRecordList)
+}
+
+/*
+   Remove the backup next hops of the route. If the route was
+   switched over, the backup is removed from the FIB as well, and
+   with reinstall set the primary next hops are put back in the FIB.
+   Otherwise they stay marked as removed so that deleting the route
+   does not remove them from the FIB again.
+*/
+func clearV4BackupNextHops(key BackupNextHopKey, reinstall bool) {
+	info, ok := V4BackupNextHopMap[key]
+	if !ok {
+		return
+	}
+	if info.active {
+		logger.Info("Removing backup next hop ", info.backupRecord.nextHopIp.String(), " for ", key.destNet, " vrf ", key.vrf)
+		RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: info.backupRecord, Op: "del"}
+		if reinstall {
+			reinstallV4PrimaryNextHops(key)
+		}
+	}
+	for _, nextHop := range info.nextHops {
+		routeInfoRecord := buildBackupNextHopRecord(key.vrf, nextHop)
+		if !arpResolveCalled(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			continue
+		}
+		refCount := updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, del)
+		if refCount == 0 {
+			RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "del"}
+		}
+	}
+	delete(V4BackupNextHopMap, key)
+}
+
+/*
+   Store the backup next hops of the route and resolve them
+   upfront so that the switchover does not wait for ARP
+*/
+func (m RIBDServer) updateV4BackupNextHops(vrf string, cfg *ribd.IPv4Route) {
+	destNet, err := getNetowrkPrefixFromStrings(cfg.DestinationNw, cfg.NetworkMask)
+	if err != nil {
+		return
+	}
+	key := BackupNextHopKey{vrf, string(destNet), cfg.Protocol}
+	clearV4BackupNextHops(key, true)
+	if len(cfg.BackupNextHop) == 0 {
+		return
+	}
+	info := BackupNextHopInfo{}
+	for _, nh := range cfg.BackupNextHop {
+		nextHopIfIndex := ribd.Int(-1)
+		if nh.NextHopIntRef != "" {
+			intRef, err := m.ConvertIntfStrToIfIndexStr(nh.NextHopIntRef)
+			if err != nil {
+				logger.Err("Invalid backup NextHop IntRef ", nh.NextHopIntRef)
+				continue
+			}
+			ifIndex, _ := strconv.Atoi(intRef)
+			nextHopIfIndex = ribd.Int(ifIndex)
+		}
+		nextHop := BackupNextHop{nh.NextHopIp, nextHopIfIndex}
+		info.nextHops = append(info.nextHops, nextHop)
+		routeInfoRecord := buildBackupNextHopRecord(vrf, nextHop)
+		if !arpResolveCalled(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "add"}
+		}
+		updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
+	}
+	if len(info.nextHops) > 0 {
+		logger.Debug("Backup next hops for ", cfg.DestinationNw, ":", cfg.NetworkMask, " vrf ", vrf, " ", info.nextHops)
+		V4BackupNextHopMap[key] = info
+	}
+}
+
+func (m RIBDServer) deleteV4BackupNextHops(vrf string, cfg *ribd.IPv4Route) {
+	destNet, err := getNetowrkPrefixFromStrings(cfg.DestinationNw, cfg.NetworkMask)
+	if err != nil {
+		return
+	}
+	clearV4BackupNextHops(BackupNextHopKey{vrf, string(destNet), cfg.Protocol}, false)
+}
+
+func isFailedNextHop(nextHopIp string, nextHopIfIndex ribd.Int, failed BackupSwitchoverInfo) bool {
+	if failed.nextHopIfIndex != -1 && nextHopIfIndex == failed.nextHopIfIndex {
+		return true
+	}
+	if failed.nextHopIp != "" && nextHopIp == failed.nextHopIp {
+		return true
+	}
+	return false
+}
+
+/*
+   Move the routes using the failed next hop to their backup next hop.
+   Routes with other working primary next hops only drop the failed one.
+   The failed primary next hops stay in the RIB until the protocol
+   reconverges, marked as removed from the FIB.
+*/
+func (m RIBDServer) ProcessV4BackupSwitchover(failed BackupSwitchoverInfo) {
+	for key, info := range V4BackupNextHopMap {
+		if info.active {
+			continue
+		}
+		item := RouteInfoMapGet(key.vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(key.destNet))
+		if item == nil {
+			continue
+		}
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		if routeInfoRecordList.selectedRouteProtocol != key.protocol {
+			continue
+		}
+		protocolRouteList := routeInfoRecordList.routeInfoProtocolMap[key.protocol]
+		failedRecords := make([]RouteInfoRecord, 0)
+		numInstalled := 0
+		for i, routeInfoRecord := range protocolRouteList {
+			if routeInfoRecord.removedFromFIB {
+				continue
+			}
+			numInstalled++
+			if isFailedNextHop(routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, failed) {
+				failedRecords = append(failedRecords, routeInfoRecord)
+				protocolRouteList[i].removedFromFIB = true
+			}
+		}
+		if len(failedRecords) == 0 {
+			continue
+		}
+		if len(failedRecords) == numInstalled {
+			for _, nextHop := range info.nextHops {
+				if isFailedNextHop(nextHop.nextHopIp, nextHop.nextHopIfIndex, failed) {
+					continue
+				}
+				backupRecord := failedRecords[0]
+				backupRecord.nextHopIp = net.ParseIP(nextHop.nextHopIp)
+				backupRecord.nextHopIfIndex = nextHop.nextHopIfIndex
+				_, backupRecord.resolvedNextHopIpIntf, _ = ResolveVrfNextHop(key.vrf, nextHop.nextHopIp)
+				backupRecord.resolvedNextHopIpIntf.NextHopIfIndex = ribdInt.Int(nextHop.nextHopIfIndex)
+				logger.Info("Switching ", backupRecord.destNetIp.String(), ":", backupRecord.networkMask.String(), " vrf ", key.vrf, " to backup next hop ", nextHop.nextHopIp)
+				RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: backupRecord, Op: "add"}
+				info.active = true
+				info.backupRecord = backupRecord
+				V4BackupNextHopMap[key] = info
+				break
+			}
+		}
+		for _, routeInfoRecord := range failedRecords {
+			RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "del"}
+		}
+		RouteInfoMapSet(key.vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(key.destNet), routeInfoRecordList)
+	}
+}
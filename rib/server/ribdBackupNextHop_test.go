@@ -0,0 +1,201 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/rib/ribdCommonDefs"
+	"ribd"
+	"testing"
+)
+
+var backupTestRouteList []*ribd.IPv4Route
+
+func InitBackupTestRouteList() {
+	backupTestRouteList = make([]*ribd.IPv4Route, 0)
+	//primary over lo1, backup over lo3
+	backupTestRouteList = append(backupTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "92.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "1"}},
+		BackupNextHop: []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "31.1.10.2", NextHopIntRef: "lo3"}},
+		Protocol:      "STATIC",
+	})
+	//primary next hop 11.1.10.3 tracked by BFD, backup over lo3
+	backupTestRouteList = append(backupTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "93.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.3", NextHopIntRef: "1"}},
+		BackupNextHop: []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "31.1.10.2", NextHopIntRef: "lo3"}},
+		Protocol:      "STATIC",
+	})
+	//ecmp primary next hops over lo1 and lo2
+	backupTestRouteList = append(backupTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "94.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "1"}},
+		BackupNextHop: []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "31.1.10.2", NextHopIntRef: "lo3"}},
+		Protocol:      "STATIC",
+	})
+	backupTestRouteList = append(backupTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "94.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "21.1.10.2", NextHopIntRef: "2"}},
+		BackupNextHop: []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "31.1.10.2", NextHopIntRef: "lo3"}},
+		Protocol:      "STATIC",
+	})
+}
+
+func getBackupTestInfo(t *testing.T, destNet string) (BackupNextHopInfo, bool) {
+	prefix, err := getNetowrkPrefixFromStrings(destNet, "255.255.255.0")
+	if err != nil {
+		t.Fatal("Invalid prefix ", destNet)
+	}
+	info, ok := V4BackupNextHopMap[BackupNextHopKey{ribdCommonDefs.DEFAULT_VRF, string(prefix), "STATIC"}]
+	return info, ok
+}
+
+func checkBackupTestActive(t *testing.T, destNet string, active bool) {
+	info, ok := getBackupTestInfo(t, destNet)
+	if !ok {
+		t.Error("Backup next hops of ", destNet, " not found")
+		return
+	}
+	if info.active != active {
+		t.Error("Backup next hop of ", destNet, " active:", info.active, " expected ", active)
+		return
+	}
+	if !active {
+		return
+	}
+	if info.backupRecord.nextHopIp.String() != "31.1.10.2" || info.backupRecord.nextHopIfIndex != 3 {
+		t.Error("Route ", destNet, " switched to ", info.backupRecord.nextHopIp, ":", info.backupRecord.nextHopIfIndex, " expected 31.1.10.2:3")
+	}
+	if info.backupRecord.networkAddr != destNet+"/24" {
+		t.Error("Backup route installed for ", info.backupRecord.networkAddr, " expected ", destNet+"/24")
+	}
+}
+
+func checkPrimaryRemovedFromFIB(t *testing.T, destNet string, nextHopIp string, removed bool) {
+	prefix, _ := getNetowrkPrefixFromStrings(destNet, "255.255.255.0")
+	item := RouteInfoMapGet(ribdCommonDefs.DEFAULT_VRF, ribdCommonDefs.IPv4, prefix)
+	if item == nil {
+		t.Error("Route ", destNet, " not found")
+		return
+	}
+	for _, routeInfoRecord := range item.(RouteInfoRecordList).routeInfoProtocolMap["STATIC"] {
+		if routeInfoRecord.nextHopIp.String() == nextHopIp && routeInfoRecord.removedFromFIB != removed {
+			t.Error("Next hop ", nextHopIp, " of ", destNet, " removed from FIB:", routeInfoRecord.removedFromFIB, " expected ", removed)
+		}
+	}
+}
+
+func TestInitBackupNextHopTestServer(t *testing.T) {
+	fmt.Println("****Init Backup Next Hop Test Server****")
+	StartTestServer()
+	TestProcessLogicalIntfCreateEvent(t)
+	TestIPv4IntfCreateEvent(t)
+	InitBackupTestRouteList()
+	fmt.Println("****************")
+}
+
+func TestBackupNextHopInstall(t *testing.T) {
+	fmt.Println("****TestBackupNextHopInstall****")
+	for _, route := range backupTestRouteList {
+		_, err := server.ProcessV4RouteCreateConfig(route, FIBAndRIB, ribd.Int(len(destNetSlice)))
+		if err != nil {
+			t.Fatal("Creating route ", route.DestinationNw, " failed with err ", err)
+		}
+	}
+	for _, destNet := range []string{"92.1.10.0", "93.1.10.0", "94.1.10.0"} {
+		info, ok := getBackupTestInfo(t, destNet)
+		if !ok {
+			t.Error("Backup next hops of ", destNet, " not stored")
+			continue
+		}
+		if len(info.nextHops) != 1 || info.nextHops[0] != (BackupNextHop{"31.1.10.2", 3}) {
+			t.Error("Backup next hops of ", destNet, " are ", info.nextHops, " expected [{31.1.10.2 3}]")
+		}
+		checkBackupTestActive(t, destNet, false)
+	}
+	//backup next hops are resolved upfront
+	if !arpResolveCalled(NextHopInfoKey{ribdCommonDefs.DEFAULT_VRF, "31.1.10.2"}) {
+		t.Error("Backup next hop 31.1.10.2 not resolved on install")
+	}
+	fmt.Println("************************************")
+}
+
+func TestBackupNextHopSwitchover(t *testing.T) {
+	fmt.Println("****TestBackupNextHopSwitchover****")
+	//next hop failures not used by the routes do not switch them over
+	server.ProcessV4BackupSwitchover(BackupSwitchoverInfo{nextHopIfIndex: 5})
+	for _, destNet := range []string{"92.1.10.0", "93.1.10.0", "94.1.10.0"} {
+		checkBackupTestActive(t, destNet, false)
+	}
+
+	//interface of the primary next hop down
+	server.ProcessV4BackupSwitchover(BackupSwitchoverInfo{nextHopIfIndex: 1})
+	checkBackupTestActive(t, "92.1.10.0", true)
+	checkBackupTestActive(t, "93.1.10.0", true)
+	//the route keeps forwarding over its working ecmp next hop
+	checkBackupTestActive(t, "94.1.10.0", false)
+	//the failed primaries stay in the RIB, marked as removed from the FIB
+	checkPrimaryRemovedFromFIB(t, "92.1.10.0", "11.1.10.2", true)
+	checkPrimaryRemovedFromFIB(t, "94.1.10.0", "11.1.10.2", true)
+	checkPrimaryRemovedFromFIB(t, "94.1.10.0", "21.1.10.2", false)
+	fmt.Println("************************************")
+}
+
+func TestBackupNextHopRevert(t *testing.T) {
+	fmt.Println("****TestBackupNextHopRevert****")
+	//the route update from the protocol after it reconverges removes the backup and puts the
+	//primary next hops back in the FIB. The next hops are unchanged, so the route itself is a duplicate.
+	for _, route := range backupTestRouteList[:2] {
+		server.ProcessV4RouteCreateConfig(route, FIBAndRIB, ribd.Int(len(destNetSlice)))
+		checkBackupTestActive(t, route.DestinationNw, false)
+	}
+	checkPrimaryRemovedFromFIB(t, "92.1.10.0", "11.1.10.2", false)
+	info, _ := getBackupTestInfo(t, "92.1.10.0")
+	if info.backupRecord.nextHopIp != nil {
+		t.Error("Backup route of 92.1.10.0 not cleared on revert, backup record:", info.backupRecord)
+	}
+
+	//BFD session of the primary next hop down
+	server.ProcessV4BackupSwitchover(BackupSwitchoverInfo{nextHopIfIndex: -1, nextHopIp: "11.1.10.3"})
+	checkBackupTestActive(t, "92.1.10.0", false)
+	checkBackupTestActive(t, "93.1.10.0", true)
+
+	for _, route := range backupTestRouteList {
+		_, err := server.ProcessV4RouteDeleteConfig(route, FIBAndRIB)
+		if err != nil {
+			t.Error("Deleting route ", route.DestinationNw, " failed with err ", err)
+		}
+	}
+	for _, destNet := range []string{"92.1.10.0", "93.1.10.0", "94.1.10.0"} {
+		if _, ok := getBackupTestInfo(t, destNet); ok {
+			t.Error("Backup next hops of ", destNet, " not removed with the route")
+		}
+	}
+	fmt.Println("************************************")
+}
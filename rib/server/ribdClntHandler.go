@@ -28,10 +28,12 @@ import (
 	"asicdServices"
 	"encoding/json"
 	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/garyburd/redigo/redis"
 	"infra/sysd/sysdCommonDefs"
 	"io/ioutil"
 	"l3/rib/ribdCommonDefs"
 	"strconv"
+	"sync"
 	"time"
 	"utils/ipcutils"
 	"utils/keepalive"
@@ -61,6 +63,11 @@ type BGPdClient struct {
 }
 type OSPFdClient struct {
 	baseClient
+	graceTimerLock sync.Mutex
+	graceTimer     *time.Timer
+}
+type ISISdClient struct {
+	baseClient
 }
 type ClientIf interface {
 	DmnDownHandler()
@@ -80,12 +87,13 @@ var asicdclnt AsicdClient
 var arpdclnt ArpdClient
 var bgpdclnt BGPdClient
 var ospfdclnt OSPFdClient
+var isisdclnt ISISdClient
 
-func deleteV4RoutesOfType(protocol string, destNet string) {
+func deleteV4RoutesOfType(vrf string, protocol string, destNet string) {
 	var testroutes []RouteInfoRecord
 	testroutes = make([]RouteInfoRecord, 0)
 
-	routeInfoRecordListItem := V4RouteInfoMap.Get(patriciaDB.Prefix(destNet))
+	routeInfoRecordListItem := RouteInfoMapGet(vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(destNet))
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected: no route for destNet:", destNet, " found in routeMap")
 		return
@@ -104,15 +112,19 @@ func deleteV4RoutesOfType(protocol string, destNet string) {
 	for _, protoroute := range testroutes { //protocolRouteList {
 		//logger.Info(len(testroutes), " number of ", protocol, " routes in routemap:", testroutes, " remaining")
 		//logger.Info("protoroute:", protoroute, " nexthop:", protoroute.nextHopIp.String())
-		_, err := deleteIPRoute(protoroute.destNetIp.String(), ribdCommonDefs.IPv4, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if protoroute.leakedFrom != "" {
+			//leaked routes are withdrawn along with the route in the source vrf
+			continue
+		}
+		_, err := deleteIPRoute(vrf, protoroute.destNetIp.String(), ribdCommonDefs.IPv4, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
 		logger.Info("err :", err, " while deleting ", protocol, " route with destNet:", protoroute.destNetIp.String(), " nexthopIP:", protoroute.nextHopIp.String())
 	}
 }
-func deleteV6RoutesOfType(protocol string, destNet string) {
+func deleteV6RoutesOfType(vrf string, protocol string, destNet string) {
 	var testroutes []RouteInfoRecord
 	testroutes = make([]RouteInfoRecord, 0)
 
-	routeInfoRecordListItem := V6RouteInfoMap.Get(patriciaDB.Prefix(destNet))
+	routeInfoRecordListItem := RouteInfoMapGet(vrf, ribdCommonDefs.IPv6, patriciaDB.Prefix(destNet))
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected: no route for destNet:", destNet, " found in routeMap")
 		return
@@ -131,13 +143,22 @@ func deleteV6RoutesOfType(protocol string, destNet string) {
 	for _, protoroute := range testroutes { //protocolRouteList {
 		//logger.Info(len(testroutes), " number of ", protocol, " routes in routemap:", testroutes, " remaining")
 		//logger.Info("protoroute:", protoroute, " nexthop:", protoroute.nextHopIp.String())
-		_, err := deleteIPRoute(protoroute.destNetIp.String(), ribdCommonDefs.IPv6, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if protoroute.leakedFrom != "" {
+			//leaked routes are withdrawn along with the route in the source vrf
+			continue
+		}
+		_, err := deleteIPRoute(vrf, protoroute.destNetIp.String(), ribdCommonDefs.IPv6, protoroute.networkMask.String(), protocol, protoroute.nextHopIp.String(), protoroute.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
 		logger.Info("err :", err, " while deleting ", protocol, " route with destNet:", protoroute.destNetIp.String(), " nexthopIP:", protoroute.nextHopIp.String())
 	}
 }
 func DeleteRoutesOfType(protocol string) {
-	func_mesg := "DeleteRoutesOfType of type:" + protocol
-	protocolRouteMap, ok := ProtocolRouteMap[protocol]
+	for vrf := range ProtocolRouteMap {
+		deleteVrfRoutesOfType(vrf, protocol)
+	}
+}
+func deleteVrfRoutesOfType(vrf string, protocol string) {
+	func_mesg := "DeleteRoutesOfType of type:" + protocol + " vrf:" + vrf
+	protocolRouteMap, ok := ProtocolRouteMap[vrf][protocol]
 	if !ok {
 		logger.Info(func_mesg, "No routes of ", protocol, " type configured")
 		return
@@ -147,7 +168,7 @@ func DeleteRoutesOfType(protocol string) {
 		for destNet, count := range protocolRouteMap.v4routeMap {
 			if count.totalcount > 0 {
 				logger.Info(func_mesg, ":", count, " number of v4 routes for destNet IP:", string(destNet))
-				deleteV4RoutesOfType(protocol, destNet)
+				deleteV4RoutesOfType(vrf, protocol, destNet)
 				//deleteV6RoutesOfType(protocol, destNet)
 				protocolRouteMap.totalcount.totalcount = protocolRouteMap.totalcount.totalcount - count.totalcount
 				protocolRouteMap.totalcount.ecmpcount = protocolRouteMap.totalcount.ecmpcount - count.ecmpcount
@@ -156,7 +177,7 @@ func DeleteRoutesOfType(protocol string) {
 				totalCount.ecmpcount = 0
 				protocolRouteMap.v4routeMap[destNet] = totalCount
 				//			protocolRouteMap.routeMap[destNet].ecmpcount = 0
-				ProtocolRouteMap[protocol] = protocolRouteMap
+				ProtocolRouteMap[vrf][protocol] = protocolRouteMap
 			}
 		}
 	}
@@ -166,7 +187,7 @@ func DeleteRoutesOfType(protocol string) {
 			if count.totalcount > 0 {
 				logger.Info(count, " number of v6 routes for destNet IP:", string(destNet))
 				//deleteV4RoutesOfType(protocol, destNet)
-				deleteV6RoutesOfType(protocol, destNet)
+				deleteV6RoutesOfType(vrf, protocol, destNet)
 				protocolRouteMap.totalcount.totalcount = protocolRouteMap.totalcount.totalcount - count.totalcount
 				protocolRouteMap.totalcount.ecmpcount = protocolRouteMap.totalcount.ecmpcount - count.ecmpcount
 				totalCount := protocolRouteMap.v6routeMap[destNet]
@@ -174,7 +195,7 @@ func DeleteRoutesOfType(protocol string) {
 				totalCount.ecmpcount = 0
 				protocolRouteMap.v6routeMap[destNet] = totalCount
 				//			protocolRouteMap.routeMap[destNet].ecmpcount = 0
-				ProtocolRouteMap[protocol] = protocolRouteMap
+				ProtocolRouteMap[vrf][protocol] = protocolRouteMap
 			}
 		}
 	}
@@ -196,12 +217,62 @@ func (clnt *BGPdClient) DmnDownHandler() {
 	logger.Info("DmnDownHandler for BGPd")
 	//uninstall all BGP routes
 	DeleteRoutesOfType("EBGP")
+	//remove the next hops registered by BGP for tracking
+	RouteServiceHandler.RouteConfCh <- RIBdServerConfig{OrigConfigObject: "BGP", Op: "delNhtClient"}
+}
+func getOspfGracePeriod() time.Duration {
+	var grEnt ribdCommonDefs.OspfGrDbEntry
+	if RouteServiceHandler == nil || RouteServiceHandler.DbHdl == nil {
+		return 0
+	}
+	val, err := redis.Values(RouteServiceHandler.DbHdl.Do("HGETALL", ribdCommonDefs.OSPF_GR_DB_KEY))
+	if err != nil || len(val) == 0 {
+		return 0
+	}
+	err = redis.ScanStruct(val, &grEnt)
+	if err != nil {
+		logger.Err("Failed to read OSPF graceful restart state, err:", err)
+		return 0
+	}
+	return time.Unix(grEnt.GracePeriodEnd, 0).Sub(time.Now())
 }
 func (clnt *OSPFdClient) DmnDownHandler() {
 	logger.Info("DmnDownHandler for OSPFd")
+	clnt.graceTimerLock.Lock()
+	defer clnt.graceTimerLock.Unlock()
+	if clnt.graceTimer != nil {
+		clnt.graceTimer.Stop()
+		clnt.graceTimer = nil
+	}
+	gracePeriod := getOspfGracePeriod()
+	if gracePeriod > 0 {
+		//keep OSPF routes, ospfd reconciles them after the restart
+		logger.Info("OSPFd graceful restart, keep OSPF routes for ", gracePeriod)
+		var graceTimer *time.Timer
+		graceTimer = time.AfterFunc(gracePeriod, func() {
+			clnt.graceTimerLock.Lock()
+			if clnt.graceTimer != graceTimer {
+				//ospfd came back or went down again while the timer fired
+				clnt.graceTimerLock.Unlock()
+				return
+			}
+			clnt.graceTimer = nil
+			clnt.graceTimerLock.Unlock()
+			logger.Info("OSPFd did not come back in grace period")
+			//routes are purged by the route processing loop
+			RouteServiceHandler.RouteConfCh <- RIBdServerConfig{OrigConfigObject: "OSPF", Op: "delRoutesOfType"}
+		})
+		clnt.graceTimer = graceTimer
+		return
+	}
 	//uninstall all OSPF routes
 	DeleteRoutesOfType("OSPF")
 }
+func (clnt *ISISdClient) DmnDownHandler() {
+	logger.Info("DmnDownHandler for ISISd")
+	//uninstall all ISIS routes
+	DeleteRoutesOfType("ISIS")
+}
 func (mgr *RIBDServer) DmnDownHandler(name string) error {
 	logger.Info("In DmnDownHandler call DmnDownHandler for client: ", name)
 	client, exist := mgr.Clients[name]
@@ -232,6 +303,15 @@ func (clnt *BGPdClient) DmnUpHandler() {
 	logger.Info("DmnUpHandler for BGPd")
 	//no op here since BGP calls GetBulkRoutesForProtocol
 }
+func (clnt *OSPFdClient) DmnUpHandler() {
+	logger.Info("DmnUpHandler for OSPFd")
+	clnt.graceTimerLock.Lock()
+	defer clnt.graceTimerLock.Unlock()
+	if clnt.graceTimer != nil {
+		clnt.graceTimer.Stop()
+		clnt.graceTimer = nil
+	}
+}
 func (clnt *baseClient) DmnUpHandler() {
 	logger.Info("DmnUpHandler for baseClient")
 }
@@ -383,6 +463,9 @@ func (ribdServiceHandler *RIBDServer) ConnectToClients(paramsFile string) {
 		if client.Name == "ospfd" {
 			ribdServiceHandler.Clients["ospfd"] = &ospfdclnt
 		}
+		if client.Name == "isisd" {
+			ribdServiceHandler.Clients["isisd"] = &isisdclnt
+		}
 		if client.Name == "asicd" {
 			logger.Info("found asicd at port ", client.Port)
 			asicdclnt.Address = "localhost:" + strconv.Itoa(client.Port)
@@ -29,6 +29,7 @@ import (
 	"encoding/json"
 	//"fmt"
 	"github.com/op/go-nanomsg"
+	"l3/bfd/bfddCommonDefs"
 	"net"
 	"ribd"
 	"strconv"
@@ -300,11 +301,37 @@ func (ribdServiceHandler *RIBDServer) ProcessAsicdEvents(sub *nanomsg.SubSocket)
 		}
 	}
 }
+func (ribdServiceHandler *RIBDServer) ProcessBfdEvents(sub *nanomsg.SubSocket) {
+	ribdServiceHandler.Logger.Info("in process Bfd events")
+	for {
+		rcvdMsg, err := sub.Recv(0)
+		if err != nil {
+			ribdServiceHandler.Logger.Info("Error in receiving ", err)
+			return
+		}
+		msg := bfddCommonDefs.BfddNotifyMsg{}
+		err = json.Unmarshal(rcvdMsg, &msg)
+		if err != nil {
+			ribdServiceHandler.Logger.Info("Error in Unmarshalling rcvdMsg Json")
+			continue
+		}
+		ribdServiceHandler.Logger.Info("Received BFD notification for ", msg.DestIp, " state ", msg.State)
+		if msg.State == false {
+			ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
+				AdditionalParams: BackupSwitchoverInfo{nextHopIfIndex: -1, nextHopIp: msg.DestIp},
+				Op:               "backupSwitchover",
+			}
+		}
+	}
+}
 func (ribdServiceHandler *RIBDServer) ProcessEvents(sub *nanomsg.SubSocket, subType ribd.Int) {
 	ribdServiceHandler.Logger.Info("in process events for sub ", subType)
 	if subType == SUB_ASICD {
 		ribdServiceHandler.Logger.Info("process Asicd events")
 		ribdServiceHandler.ProcessAsicdEvents(sub)
+	} else if subType == SUB_BFDD {
+		ribdServiceHandler.Logger.Info("process Bfd events")
+		ribdServiceHandler.ProcessBfdEvents(sub)
 	}
 }
 func (ribdServiceHandler *RIBDServer) SetupEventHandler(sub *nanomsg.SubSocket, address string, subtype ribd.Int) {
@@ -0,0 +1,435 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdNextHopTracking.go
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"l3/rib/ribdCommonDefs"
+	"net"
+	"ribdInt"
+	"sort"
+	"strconv"
+	"utils/patriciaDB"
+)
+
+/*
+   Next hop tracking (NHT).
+   Clients register next hops with RIBd. The next hop is resolved recursively
+   through the longest prefix match route till a directly connected next hop
+   is found. A notification is published to the registered clients whenever
+   the resolving route, its metric or the set of ECMP next hops changes.
+   Registrations are processed on the route server goroutine along with
+   the route updates.
+*/
+const (
+	NHT_MAX_RECURSION_DEPTH = 8
+)
+
+type NextHopTrackingInfo struct {
+	Vrf       string
+	NextHopIp string
+	Client    string
+}
+
+type NhtKey struct {
+	vrf       string
+	nextHopIp string
+}
+
+type NhtResolvedInfo struct {
+	isReachable      bool
+	resolvingNetwork string
+	protocol         string
+	metric           ribdInt.Int
+	nextHopList      []ribdCommonDefs.NextHopTrackingNextHop
+	resolveChain     []net.IP //next hop and the intermediate next hops looked up to resolve it
+}
+
+type NhtRegistration struct {
+	clientList []string
+	resolved   NhtResolvedInfo
+}
+
+var NhtRegistrationMap = make(map[NhtKey]*NhtRegistration)
+var NhtClientMap = make(map[string][]NhtKey)
+
+/*
+   Networks changed by the route updates yet to be evaluated for the registered next hops
+*/
+type NhtPendingUpdate struct {
+	vrf     string
+	network *net.IPNet
+}
+
+var NhtPendingUpdateList []NhtPendingUpdate
+
+type nhtNextHopList []ribdCommonDefs.NextHopTrackingNextHop
+
+func (l nhtNextHopList) Len() int      { return len(l) }
+func (l nhtNextHopList) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l nhtNextHopList) Less(i, j int) bool {
+	if l[i].NextHopIp != l[j].NextHopIp {
+		return l[i].NextHopIp < l[j].NextHopIp
+	}
+	return l[i].NextHopIfIndex < l[j].NextHopIfIndex
+}
+
+func nhtLookup(vrf string, ip net.IP) (routeInfoList []RouteInfoRecord) {
+	ipType := ribdCommonDefs.IPv4
+	lookupIp := ip.To4()
+	if lookupIp == nil {
+		ipType = ribdCommonDefs.IPv6
+		lookupIp = ip.To16()
+	}
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil || lookupIp == nil {
+		return nil
+	}
+	item := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(lookupIp))
+	if item == nil {
+		return nil
+	}
+	routeInfoRecordList := item.(RouteInfoRecordList)
+	if routeInfoRecordList.selectedRouteProtocol == "INVALID" {
+		return nil
+	}
+	return routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]
+}
+
+/*
+   Walks the ECMP paths of the route resolving ip and adds the directly
+   connected next hops to the resolved info
+*/
+func nhtResolve(vrf string, ip net.IP, depth int, resolved *NhtResolvedInfo) {
+	if depth > NHT_MAX_RECURSION_DEPTH {
+		logger.Err("Max recursion depth reached resolving next hop ", ip.String(), " in vrf ", vrf)
+		return
+	}
+	resolved.resolveChain = append(resolved.resolveChain, ip)
+	routeInfoList := nhtLookup(vrf, ip)
+	if len(routeInfoList) == 0 {
+		return
+	}
+	if depth == 0 {
+		resolved.resolvingNetwork = routeInfoList[0].networkAddr
+		resolved.protocol = ReverseRouteProtoTypeMapDB[int(routeInfoList[0].protocol)]
+		resolved.metric = ribdInt.Int(routeInfoList[0].metric)
+	}
+	for _, routeInfoRecord := range routeInfoList {
+		if routeInfoRecord.protocol == ribdCommonDefs.CONNECTED {
+			resolved.nextHopList = append(resolved.nextHopList, ribdCommonDefs.NextHopTrackingNextHop{
+				NextHopIp:      ip.String(),
+				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
+			})
+			continue
+		}
+		if routeInfoRecord.nextHopIp.Equal(net.ParseIP("255.255.255.255")) {
+			//null route
+			continue
+		}
+		nextHopVrf := getNextHopVrf(routeInfoRecord)
+		nextHopRouteList := nhtLookup(nextHopVrf, routeInfoRecord.nextHopIp)
+		if len(nextHopRouteList) != 0 && nextHopRouteList[0].protocol == ribdCommonDefs.CONNECTED {
+			resolved.nextHopList = append(resolved.nextHopList, ribdCommonDefs.NextHopTrackingNextHop{
+				NextHopIp:      routeInfoRecord.nextHopIp.String(),
+				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
+			})
+			continue
+		}
+		nhtResolve(nextHopVrf, routeInfoRecord.nextHopIp, depth+1, resolved)
+	}
+}
+
+func nhtResolveNextHop(key NhtKey) (resolved NhtResolvedInfo) {
+	ip := net.ParseIP(key.nextHopIp)
+	if ip == nil {
+		return resolved
+	}
+	resolved.nextHopList = make([]ribdCommonDefs.NextHopTrackingNextHop, 0)
+	nhtResolve(key.vrf, ip, 0, &resolved)
+	//remove the duplicate next hops found through different paths
+	sort.Sort(nhtNextHopList(resolved.nextHopList))
+	nextHopList := make([]ribdCommonDefs.NextHopTrackingNextHop, 0)
+	for idx, nextHop := range resolved.nextHopList {
+		if idx > 0 && nextHop == resolved.nextHopList[idx-1] {
+			continue
+		}
+		nextHopList = append(nextHopList, nextHop)
+	}
+	resolved.nextHopList = nextHopList
+	resolved.isReachable = len(resolved.nextHopList) > 0
+	return resolved
+}
+
+func nhtResolvedInfoChanged(oldInfo NhtResolvedInfo, newInfo NhtResolvedInfo) bool {
+	if oldInfo.isReachable != newInfo.isReachable || oldInfo.resolvingNetwork != newInfo.resolvingNetwork ||
+		oldInfo.protocol != newInfo.protocol || oldInfo.metric != newInfo.metric ||
+		len(oldInfo.nextHopList) != len(newInfo.nextHopList) {
+		return true
+	}
+	for idx := range oldInfo.nextHopList {
+		if oldInfo.nextHopList[idx] != newInfo.nextHopList[idx] {
+			return true
+		}
+	}
+	return false
+}
+
+func nhtNotificationSend(client string, key NhtKey, resolved NhtResolvedInfo) {
+	publisherInfo, ok := PublisherInfoMap[client]
+	if !ok {
+		logger.Info("Publisher not found for client ", client)
+		return
+	}
+	msgInfo := ribdCommonDefs.NextHopTrackingMsgInfo{
+		Vrf:              key.vrf,
+		NextHopIp:        key.nextHopIp,
+		IsReachable:      resolved.isReachable,
+		ResolvingNetwork: resolved.resolvingNetwork,
+		Protocol:         resolved.protocol,
+		Metric:           resolved.metric,
+		NextHopList:      resolved.nextHopList,
+	}
+	msgbufbytes, err := json.Marshal(msgInfo)
+	if err != nil {
+		logger.Err("Error in marshalling next hop tracking update for ", key.nextHopIp, " vrf ", key.vrf, " err ", err)
+		return
+	}
+	msg := ribdCommonDefs.RibdNotifyMsg{MsgType: uint16(ribdCommonDefs.NOTIFY_NEXTHOP_TRACKING_UPDATE), MsgBuf: msgbufbytes}
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		logger.Err("Error in marshalling Json")
+		return
+	}
+	eventInfo := "Next hop tracking update for " + key.nextHopIp + " vrf " + key.vrf + " reachable " + strconv.FormatBool(resolved.isReachable) + " via " + resolved.resolvingNetwork + " for client " + client
+	RouteServiceHandler.NotificationChannel <- NotificationMsg{publisherInfo.pub_socket, buf, eventInfo}
+}
+
+/*
+   Called when a route is installed in/removed from the FIB
+*/
+func nhtRouteChanged(routeInfoRecord RouteInfoRecord) {
+	if len(NhtRegistrationMap) == 0 {
+		return
+	}
+	_, network, err := net.ParseCIDR(routeInfoRecord.networkAddr)
+	if err != nil {
+		return
+	}
+	NhtPendingUpdateList = append(NhtPendingUpdateList, NhtPendingUpdate{getVrfName(routeInfoRecord.vrf), network})
+}
+
+func nhtUpdateAffectsRegistration(key NhtKey, reg *NhtRegistration) bool {
+	for _, update := range NhtPendingUpdateList {
+		if update.vrf == key.vrf && update.network.Contains(net.ParseIP(key.nextHopIp)) {
+			return true
+		}
+		for _, ip := range reg.resolved.resolveChain {
+			//vrf is not compared since leaked routes resolve in the source vrf
+			if update.network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+   Re-resolve the registered next hops affected by the route updates and
+   notify the clients of the changes
+*/
+func (m RIBDServer) ProcessNhtPendingUpdates() {
+	if len(NhtPendingUpdateList) == 0 {
+		return
+	}
+	for key, reg := range NhtRegistrationMap {
+		if !nhtUpdateAffectsRegistration(key, reg) {
+			continue
+		}
+		resolved := nhtResolveNextHop(key)
+		if !nhtResolvedInfoChanged(reg.resolved, resolved) {
+			reg.resolved = resolved
+			continue
+		}
+		logger.Info("Next hop tracking: resolution of ", key.nextHopIp, " in vrf ", key.vrf, " changed to ", resolved.resolvingNetwork, " reachable:", resolved.isReachable)
+		reg.resolved = resolved
+		for _, client := range reg.clientList {
+			nhtNotificationSend(client, key, resolved)
+		}
+	}
+	NhtPendingUpdateList = nil
+}
+
+func (m RIBDServer) NextHopTrackingConfigValidationCheck(info NextHopTrackingInfo) error {
+	if info.Client == "" {
+		return errors.New("Client name not specified")
+	}
+	if net.ParseIP(info.NextHopIp) == nil {
+		return errors.New(fmt.Sprintln("Invalid next hop ip ", info.NextHopIp))
+	}
+	if !m.IsVrfConfigured(info.Vrf) {
+		return errors.New(fmt.Sprintln("Vrf ", info.Vrf, " not configured"))
+	}
+	return nil
+}
+
+/*
+   Register the next hop for tracking and send the current resolution to the client
+*/
+func (m RIBDServer) ProcessNextHopTrackingRegister(info NextHopTrackingInfo) {
+	logger.Info("ProcessNextHopTrackingRegister for ", info.NextHopIp, " vrf ", info.Vrf, " client ", info.Client)
+	key := NhtKey{getVrfName(info.Vrf), net.ParseIP(info.NextHopIp).String()}
+	reg, ok := NhtRegistrationMap[key]
+	if !ok {
+		reg = &NhtRegistration{clientList: make([]string, 0)}
+		reg.resolved = nhtResolveNextHop(key)
+		NhtRegistrationMap[key] = reg
+	}
+	if findElement(reg.clientList, info.Client) == -1 {
+		reg.clientList = append(reg.clientList, info.Client)
+		NhtClientMap[info.Client] = append(NhtClientMap[info.Client], key)
+	}
+	nhtNotificationSend(info.Client, key, reg.resolved)
+}
+
+func (m RIBDServer) ProcessNextHopTrackingUnregister(info NextHopTrackingInfo) {
+	logger.Info("ProcessNextHopTrackingUnregister for ", info.NextHopIp, " vrf ", info.Vrf, " client ", info.Client)
+	key := NhtKey{getVrfName(info.Vrf), net.ParseIP(info.NextHopIp).String()}
+	reg, ok := NhtRegistrationMap[key]
+	if !ok {
+		logger.Err("Next hop ", info.NextHopIp, " not registered in vrf ", info.Vrf)
+		return
+	}
+	idx := findElement(reg.clientList, info.Client)
+	if idx == -1 {
+		logger.Err("Next hop ", info.NextHopIp, " not registered by client ", info.Client)
+		return
+	}
+	reg.clientList = append(reg.clientList[:idx], reg.clientList[idx+1:]...)
+	if len(reg.clientList) == 0 {
+		delete(NhtRegistrationMap, key)
+	}
+	keyList := NhtClientMap[info.Client]
+	for i, clientKey := range keyList {
+		if clientKey == key {
+			keyList = append(keyList[:i], keyList[i+1:]...)
+			break
+		}
+	}
+	if len(keyList) == 0 {
+		delete(NhtClientMap, info.Client)
+	} else {
+		NhtClientMap[info.Client] = keyList
+	}
+}
+
+/*
+   Remove all the registrations of the client, called when the client goes down
+*/
+func (m RIBDServer) ProcessNextHopTrackingClientDelete(client string) {
+	logger.Info("ProcessNextHopTrackingClientDelete for client ", client)
+	keyList := make([]NhtKey, len(NhtClientMap[client]))
+	copy(keyList, NhtClientMap[client])
+	for _, key := range keyList {
+		m.ProcessNextHopTrackingUnregister(NextHopTrackingInfo{Vrf: key.vrf, NextHopIp: key.nextHopIp, Client: client})
+	}
+}
+
+func buildNextHopTrackingState(key NhtKey, reg *NhtRegistration) *ribdInt.NextHopTrackingState {
+	state := ribdInt.NewNextHopTrackingState()
+	state.Vrf = key.vrf
+	state.NextHopIp = key.nextHopIp
+	state.IsReachable = reg.resolved.isReachable
+	state.ResolvingNetwork = reg.resolved.resolvingNetwork
+	state.Protocol = reg.resolved.protocol
+	state.Metric = int32(reg.resolved.metric)
+	state.NextHopList = make([]*ribdInt.RouteNextHopInfo, 0)
+	for _, nextHop := range reg.resolved.nextHopList {
+		state.NextHopList = append(state.NextHopList, &ribdInt.RouteNextHopInfo{
+			NextHopIp:     nextHop.NextHopIp,
+			NextHopIntRef: strconv.Itoa(int(nextHop.NextHopIfIndex)),
+		})
+	}
+	state.ClientList = make([]string, len(reg.clientList))
+	copy(state.ClientList, reg.clientList)
+	return state
+}
+
+func (m RIBDServer) GetNextHopTrackingState(vrf string, nextHopIp string) (*ribdInt.NextHopTrackingState, error) {
+	key := NhtKey{getVrfName(vrf), net.ParseIP(nextHopIp).String()}
+	reg, ok := NhtRegistrationMap[key]
+	if !ok {
+		return ribdInt.NewNextHopTrackingState(), errors.New(fmt.Sprintln("Next hop ", nextHopIp, " not registered in vrf ", vrf))
+	}
+	return buildNextHopTrackingState(key, reg), nil
+}
+
+/*
+   Per client list of the registered next hops
+*/
+func (m RIBDServer) GetBulkNextHopTrackingClientState(fromIndex ribdInt.Int, rcount ribdInt.Int) (clientStates *ribdInt.NextHopTrackingClientStateGetInfo, err error) {
+	var i, validCount, toIndex ribdInt.Int
+	var returnNodes []*ribdInt.NextHopTrackingClientState
+	var returnGetInfo ribdInt.NextHopTrackingClientStateGetInfo
+	clientStates = &returnGetInfo
+	more := true
+	clientList := make([]string, 0)
+	for client := range NhtClientMap {
+		clientList = append(clientList, client)
+	}
+	sort.Strings(clientList)
+	for ; ; i++ {
+		if i+fromIndex >= ribdInt.Int(len(clientList)) {
+			more = false
+			break
+		}
+		if validCount == rcount {
+			break
+		}
+		client := clientList[i+fromIndex]
+		clientState := ribdInt.NewNextHopTrackingClientState()
+		clientState.ClientName = client
+		clientState.RegistrationList = make([]*ribdInt.NextHopTrackingState, 0)
+		for _, key := range NhtClientMap[client] {
+			if reg, ok := NhtRegistrationMap[key]; ok {
+				clientState.RegistrationList = append(clientState.RegistrationList, buildNextHopTrackingState(key, reg))
+			}
+		}
+		if len(returnNodes) == 0 {
+			returnNodes = make([]*ribdInt.NextHopTrackingClientState, 0)
+		}
+		returnNodes = append(returnNodes, clientState)
+		toIndex = i + fromIndex
+		validCount++
+	}
+	clientStates.NextHopTrackingClientStateList = returnNodes
+	clientStates.StartIdx = fromIndex
+	clientStates.EndIdx = toIndex + 1
+	clientStates.More = more
+	clientStates.Count = validCount
+	return clientStates, err
+}
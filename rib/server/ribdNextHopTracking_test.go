@@ -0,0 +1,247 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/rib/ribdCommonDefs"
+	"ribd"
+	"testing"
+)
+
+/*
+   90.1.10.0/24 is reachable through 91.1.10.2, which in turn resolves over
+   the directly connected next hops of 91.1.10.0/24
+*/
+var nhtTestRouteList []*ribd.IPv4Route
+
+func InitNhtTestRouteList() {
+	nhtTestRouteList = make([]*ribd.IPv4Route, 0)
+	nhtTestRouteList = append(nhtTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "91.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "11.1.10.2", NextHopIntRef: "1"}},
+		Protocol:      "STATIC",
+	})
+	nhtTestRouteList = append(nhtTestRouteList, &ribd.IPv4Route{
+		DestinationNw: "90.1.10.0",
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "91.1.10.2"}},
+		Protocol:      "STATIC",
+	})
+}
+
+var nhtTestEcmpRoute = &ribd.IPv4Route{
+	DestinationNw: "91.1.10.0",
+	NetworkMask:   "255.255.255.0",
+	NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: "31.1.10.2", NextHopIntRef: "3"}},
+	Protocol:      "STATIC",
+}
+
+func getNhtTestResolvedInfo(t *testing.T, nextHopIp string) NhtResolvedInfo {
+	reg, ok := NhtRegistrationMap[NhtKey{ribdCommonDefs.DEFAULT_VRF, nextHopIp}]
+	if !ok {
+		t.Fatal("Next hop ", nextHopIp, " not registered")
+	}
+	return reg.resolved
+}
+
+func checkNhtTestNextHops(t *testing.T, resolved NhtResolvedInfo, nextHopList []ribdCommonDefs.NextHopTrackingNextHop) {
+	if len(resolved.nextHopList) != len(nextHopList) {
+		t.Error("Resolved next hops ", resolved.nextHopList, " expected ", nextHopList)
+		return
+	}
+	for idx, nextHop := range nextHopList {
+		if resolved.nextHopList[idx] != nextHop {
+			t.Error("Resolved next hops ", resolved.nextHopList, " expected ", nextHopList)
+			return
+		}
+	}
+}
+
+func TestInitNhtTestServer(t *testing.T) {
+	fmt.Println("****Init Next Hop Tracking Test Server****")
+	StartTestServer()
+	TestProcessLogicalIntfCreateEvent(t)
+	TestIPv4IntfCreateEvent(t)
+	InitNhtTestRouteList()
+	fmt.Println("****************")
+}
+
+func TestNhtRegisterUnreachable(t *testing.T) {
+	fmt.Println("****TestNhtRegisterUnreachable****")
+	server.ProcessNextHopTrackingRegister(NextHopTrackingInfo{NextHopIp: "90.1.10.5", Client: "nhtTestClient1"})
+	resolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	if resolved.isReachable || len(resolved.nextHopList) != 0 {
+		t.Error("Next hop 90.1.10.5 reachable before any route to it, resolved:", resolved)
+	}
+	fmt.Println("************************************")
+}
+
+func TestNhtRecursiveResolution(t *testing.T) {
+	fmt.Println("****TestNhtRecursiveResolution****")
+	for _, route := range nhtTestRouteList {
+		_, err := server.ProcessV4RouteCreateConfig(route, FIBAndRIB, ribd.Int(len(destNetSlice)))
+		if err != nil {
+			t.Fatal("Creating route ", route.DestinationNw, " failed with err ", err)
+		}
+	}
+	oldResolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	server.ProcessNhtPendingUpdates()
+	resolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	if !nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Next hop 90.1.10.5 becoming reachable not notified")
+	}
+	if !resolved.isReachable {
+		t.Error("Next hop 90.1.10.5 not reachable through 91.1.10.2, resolved:", resolved)
+	}
+	if resolved.resolvingNetwork != "90.1.10.0/24" || resolved.protocol != "STATIC" {
+		t.Error("Next hop 90.1.10.5 resolved through ", resolved.resolvingNetwork, " ", resolved.protocol, " expected 90.1.10.0/24 STATIC")
+	}
+	checkNhtTestNextHops(t, resolved, []ribdCommonDefs.NextHopTrackingNextHop{
+		ribdCommonDefs.NextHopTrackingNextHop{NextHopIp: "11.1.10.2", NextHopIfIndex: 1},
+	})
+	if len(NhtPendingUpdateList) != 0 {
+		t.Error("Pending next hop tracking updates not cleared")
+	}
+	fmt.Println("************************************")
+}
+
+func TestNhtEcmpChange(t *testing.T) {
+	fmt.Println("****TestNhtEcmpChange****")
+	_, err := server.ProcessV4RouteCreateConfig(nhtTestEcmpRoute, FIBAndRIB, ribd.Int(len(destNetSlice)))
+	if err != nil {
+		t.Fatal("Creating ecmp route 91.1.10.0/24 failed with err ", err)
+	}
+	oldResolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	server.ProcessNhtPendingUpdates()
+	resolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	if !nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Ecmp next hop added to 91.1.10.0/24 not notified for 90.1.10.5")
+	}
+	checkNhtTestNextHops(t, resolved, []ribdCommonDefs.NextHopTrackingNextHop{
+		ribdCommonDefs.NextHopTrackingNextHop{NextHopIp: "11.1.10.2", NextHopIfIndex: 1},
+		ribdCommonDefs.NextHopTrackingNextHop{NextHopIp: "31.1.10.2", NextHopIfIndex: 3},
+	})
+
+	//an update that does not change the resolution is not notified
+	nhtRouteChanged(RouteInfoRecord{networkAddr: "91.1.10.0/24"})
+	oldResolved = resolved
+	server.ProcessNhtPendingUpdates()
+	resolved = getNhtTestResolvedInfo(t, "90.1.10.5")
+	if nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Resolution of 90.1.10.5 changed without a route change, old:", oldResolved, " new:", resolved)
+	}
+
+	_, err = server.ProcessV4RouteDeleteConfig(nhtTestEcmpRoute, FIBAndRIB)
+	if err != nil {
+		t.Fatal("Deleting ecmp route 91.1.10.0/24 failed with err ", err)
+	}
+	oldResolved = resolved
+	server.ProcessNhtPendingUpdates()
+	resolved = getNhtTestResolvedInfo(t, "90.1.10.5")
+	if !nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Ecmp next hop removed from 91.1.10.0/24 not notified for 90.1.10.5")
+	}
+	checkNhtTestNextHops(t, resolved, []ribdCommonDefs.NextHopTrackingNextHop{
+		ribdCommonDefs.NextHopTrackingNextHop{NextHopIp: "11.1.10.2", NextHopIfIndex: 1},
+	})
+	fmt.Println("************************************")
+}
+
+func TestNhtReachabilityTransitions(t *testing.T) {
+	fmt.Println("****TestNhtReachabilityTransitions****")
+	_, err := server.ProcessV4RouteDeleteConfig(nhtTestRouteList[0], FIBAndRIB)
+	if err != nil {
+		t.Fatal("Deleting route 91.1.10.0/24 failed with err ", err)
+	}
+	oldResolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	server.ProcessNhtPendingUpdates()
+	resolved := getNhtTestResolvedInfo(t, "90.1.10.5")
+	if !nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Next hop 90.1.10.5 becoming unreachable not notified")
+	}
+	if resolved.isReachable || len(resolved.nextHopList) != 0 {
+		t.Error("Next hop 90.1.10.5 reachable without a route to 91.1.10.2, resolved:", resolved)
+	}
+
+	_, err = server.ProcessV4RouteCreateConfig(nhtTestRouteList[0], FIBAndRIB, ribd.Int(len(destNetSlice)))
+	if err != nil {
+		t.Fatal("Creating route 91.1.10.0/24 failed with err ", err)
+	}
+	oldResolved = resolved
+	server.ProcessNhtPendingUpdates()
+	resolved = getNhtTestResolvedInfo(t, "90.1.10.5")
+	if !nhtResolvedInfoChanged(oldResolved, resolved) {
+		t.Error("Next hop 90.1.10.5 becoming reachable again not notified")
+	}
+	if !resolved.isReachable {
+		t.Error("Next hop 90.1.10.5 not reachable after 91.1.10.0/24 is added back, resolved:", resolved)
+	}
+	checkNhtTestNextHops(t, resolved, []ribdCommonDefs.NextHopTrackingNextHop{
+		ribdCommonDefs.NextHopTrackingNextHop{NextHopIp: "11.1.10.2", NextHopIfIndex: 1},
+	})
+	fmt.Println("************************************")
+}
+
+func TestNhtClientDelete(t *testing.T) {
+	fmt.Println("****TestNhtClientDelete****")
+	server.ProcessNextHopTrackingRegister(NextHopTrackingInfo{NextHopIp: "90.1.10.5", Client: "nhtTestClient2"})
+	server.ProcessNextHopTrackingRegister(NextHopTrackingInfo{NextHopIp: "91.1.10.2", Client: "nhtTestClient1"})
+	if len(NhtClientMap["nhtTestClient1"]) != 2 || len(NhtClientMap["nhtTestClient2"]) != 1 {
+		t.Error("Client registrations not tracked, NhtClientMap:", NhtClientMap)
+	}
+
+	server.ProcessNextHopTrackingClientDelete("nhtTestClient1")
+	if _, ok := NhtClientMap["nhtTestClient1"]; ok {
+		t.Error("Registrations of nhtTestClient1 not removed on client delete")
+	}
+	if _, ok := NhtRegistrationMap[NhtKey{ribdCommonDefs.DEFAULT_VRF, "91.1.10.2"}]; ok {
+		t.Error("Next hop 91.1.10.2 registered only by nhtTestClient1 not removed on client delete")
+	}
+	reg, ok := NhtRegistrationMap[NhtKey{ribdCommonDefs.DEFAULT_VRF, "90.1.10.5"}]
+	if !ok {
+		t.Fatal("Next hop 90.1.10.5 still registered by nhtTestClient2 removed on client delete")
+	}
+	if len(reg.clientList) != 1 || reg.clientList[0] != "nhtTestClient2" {
+		t.Error("Client list of 90.1.10.5 is ", reg.clientList, " expected [nhtTestClient2]")
+	}
+
+	server.ProcessNextHopTrackingClientDelete("nhtTestClient2")
+	if _, ok := NhtRegistrationMap[NhtKey{ribdCommonDefs.DEFAULT_VRF, "90.1.10.5"}]; ok {
+		t.Error("Next hop 90.1.10.5 not removed on delete of its last client")
+	}
+	if _, ok := NhtClientMap["nhtTestClient2"]; ok {
+		t.Error("Registrations of nhtTestClient2 not removed on client delete")
+	}
+
+	for idx := len(nhtTestRouteList) - 1; idx >= 0; idx-- {
+		server.ProcessV4RouteDeleteConfig(nhtTestRouteList[idx], FIBAndRIB)
+	}
+	//no registrations left to re-resolve
+	if len(NhtPendingUpdateList) != 0 {
+		t.Error("Route updates queued for next hop tracking without registrations")
+	}
+	fmt.Println("************************************")
+}
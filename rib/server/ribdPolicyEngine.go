@@ -48,6 +48,8 @@ type PolicyRouteIndex struct {
    data-structure used to communicate with policy engine
 */
 type RouteParams struct {
+	vrf            string
+	leakedFrom     string
 	ipType         ribdCommonDefs.IPType
 	destNetIp      string
 	networkMask    string
@@ -61,6 +63,7 @@ type RouteParams struct {
 	weight         ribd.Int
 	bulk           bool
 	bulkEnd        bool
+	labels         []int32
 }
 
 type TraverseAndApplyPolicyData struct {
@@ -558,7 +561,7 @@ func DoesRouteExist(params interface{}) (exists bool) {
 		logger.Info("Error when getting ipPrefix, err= ", err)
 		return
 	}
-	routeInfoRecordList := RouteInfoMapGet(routeInfo.ipType, ipPrefix)
+	routeInfoRecordList := RouteInfoMapGet(routeInfo.vrf, routeInfo.ipType, ipPrefix)
 	if routeInfoRecordList == nil {
 		logger.Info("Route for type ", routeInfo.ipType, " and prefix", ipPrefix, " no longer exists")
 		routeDeleted = true
@@ -705,7 +708,7 @@ func policyEngineTraverseAndReverse(applyPolicyItem interface{}) {
 		//PolicyEngineDB.PolicyEngineUndoPolicyForEntity(entity, policy, params)
 		success := PolicyEngineDB.PolicyEngineUndoApplyPolicyForEntity(entity, updateInfo, params)
 		if success {
-			deleteRoutePolicyState(params.ipType, ipPrefix, policy.Name)
+			deleteRoutePolicyState(params.vrf, params.ipType, ipPrefix, policy.Name)
 			PolicyEngineDB.DeletePolicyEntityMapEntry(entity, policy.Name)
 		}
 	}
@@ -43,6 +43,8 @@ import (
    Data type of each route stored in the DB
 */
 type RouteInfoRecord struct {
+	vrf                     string //vrf the route is installed in
+	leakedFrom              string //source vrf when the route was leaked into vrf
 	ipType                  ribdCommonDefs.IPType
 	destNetIp               net.IP
 	networkMask             net.IP
@@ -58,6 +60,8 @@ type RouteInfoRecord struct {
 	isPolicyBasedStateValid bool
 	routeCreatedTime        string
 	routeUpdatedTime        string
+	labels                  []int32 //vpn label stack pushed toward the next hop
+	removedFromFIB          bool    //failed primary next hop removed from the FIB by a backup switchover
 }
 
 /*
@@ -96,6 +100,7 @@ type RouteReachabilityStatusInfo struct {
 	status      string
 	protocol    string
 	nextHopIntf ribdInt.NextHopInfo
+	vrf         string
 }
 
 var DummyRouteInfoRecord RouteInfoRecord
@@ -103,55 +108,57 @@ var destNetSlice []localDB
 var localRouteEventsDB []RouteEventInfo
 
 /*
-   RoutInfoMap operations functions
+   RoutInfoMap operations functions. Each of these operates on the route table of the vrf
 */
-func RouteInfoMapInsert(ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) (ok bool) {
-	logger.Debug("RouteInfoMapInsert prefix: %v", prefix, "ipType:", ipType)
-	if ipType == ribdCommonDefs.IPv4 {
-		ok = V4RouteInfoMap.Insert(prefix, routeInfoRecordList)
-	} else {
-		ok = V6RouteInfoMap.Insert(prefix, routeInfoRecordList)
+func RouteInfoMapInsert(vrf string, ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) (ok bool) {
+	logger.Debug("RouteInfoMapInsert prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return false
 	}
-	return ok
+	return routeInfoMap.Insert(prefix, routeInfoRecordList)
 }
-func RouteInfoMapSet(ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) {
-	logger.Debug("RouteInfoMapSet prefix: %v", prefix, "ipType:", ipType)
-	if ipType == ribdCommonDefs.IPv4 {
-		V4RouteInfoMap.Set(prefix, routeInfoRecordList)
-	} else {
-		V6RouteInfoMap.Set(prefix, routeInfoRecordList)
+func RouteInfoMapSet(vrf string, ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix, routeInfoRecordList interface{}) {
+	logger.Debug("RouteInfoMapSet prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return
 	}
+	routeInfoMap.Set(prefix, routeInfoRecordList)
 }
-func RouteInfoMapDelete(ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix) {
-	logger.Debug("RouteInfoMapDelete prefix: %v", prefix, "ipType:", ipType)
-	if ipType == ribdCommonDefs.IPv4 {
-		V4RouteInfoMap.Delete(prefix)
-	} else {
-		V6RouteInfoMap.Delete(prefix)
+func RouteInfoMapDelete(vrf string, ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix) {
+	logger.Debug("RouteInfoMapDelete prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return
 	}
+	routeInfoMap.Delete(prefix)
 }
-func RouteInfoMapGet(ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix) (item interface{}) {
-	logger.Debug("RouteInfoMapGet prefix: %v", prefix, "ipType:", ipType)
-	if ipType == ribdCommonDefs.IPv4 {
-		item = V4RouteInfoMap.Get(prefix)
-	} else {
-		item = V6RouteInfoMap.Get(prefix)
+func RouteInfoMapGet(vrf string, ipType ribdCommonDefs.IPType, prefix patriciaDB.Prefix) (item interface{}) {
+	logger.Debug("RouteInfoMapGet prefix: %v", prefix, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return nil
 	}
-	return item
+	return routeInfoMap.Get(prefix)
 }
-func RouteInfoMapVisitAndUpdate(ipType ribdCommonDefs.IPType, routeReachabilityStatusInfo RouteReachabilityStatusInfo) {
-	logger.Debug("RouteInfoMapVisitAndUpdate() routeReachabilityStatusInfo", routeReachabilityStatusInfo, "ipType:", ipType)
+func RouteInfoMapVisitAndUpdate(vrf string, ipType ribdCommonDefs.IPType, routeReachabilityStatusInfo RouteReachabilityStatusInfo) {
+	logger.Debug("RouteInfoMapVisitAndUpdate() routeReachabilityStatusInfo", routeReachabilityStatusInfo, "ipType:", ipType, " vrf:", vrf)
+	routeInfoMap := getVrfRouteInfoMap(vrf, ipType)
+	if routeInfoMap == nil {
+		return
+	}
 	if ipType == ribdCommonDefs.IPv4 {
-		V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, routeReachabilityStatusInfo)
+		routeInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, routeReachabilityStatusInfo)
 	} else {
-		V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, routeReachabilityStatusInfo)
+		routeInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, routeReachabilityStatusInfo)
 	}
 }
 
 /*
    Update Connected route info
 */
-func updateConnectedRoutes(destNetIPAddr string, networkMaskAddr string, nextHopIP string, nextHopIfIndex ribd.Int, op int, sliceIdx ribd.Int) {
+func updateConnectedRoutes(vrf string, destNetIPAddr string, networkMaskAddr string, nextHopIP string, nextHopIfIndex ribd.Int, op int, sliceIdx ribd.Int) {
 	var temproute ribdInt.Routes
 	route := &temproute
 	//logger.Debug("number of connectd routes = ", len(ConnectedRoutes), "current op is to ", op, " ipAddr:mask = ", destNetIPAddr, ":", networkMaskAddr)
@@ -167,12 +174,13 @@ func updateConnectedRoutes(destNetIPAddr string, networkMaskAddr string, nextHop
 		route.IfIndex = ribdInt.Int(nextHopIfIndex)
 		route.IsValid = true
 		route.SliceIdx = ribdInt.Int(sliceIdx)
+		route.Vrf = vrf
 		ConnectedRoutes[0] = route
 		return
 	}
 	for i := 0; i < len(ConnectedRoutes); i++ {
 		//		if(!strings.EqualFold(ConnectedRoutes[i].Ipaddr,destNetIPAddr) && !strings.EqualFold(ConnectedRoutes[i].Mask,networkMaskAddr)){
-		if ConnectedRoutes[i].Ipaddr == destNetIPAddr && ConnectedRoutes[i].Mask == networkMaskAddr {
+		if ConnectedRoutes[i].Ipaddr == destNetIPAddr && ConnectedRoutes[i].Mask == networkMaskAddr && ConnectedRoutes[i].Vrf == vrf {
 			if op == del {
 				if len(ConnectedRoutes) <= i+1 {
 					ConnectedRoutes = ConnectedRoutes[:i]
@@ -194,6 +202,7 @@ func updateConnectedRoutes(destNetIPAddr string, networkMaskAddr string, nextHop
 	route.IfIndex = ribdInt.Int(nextHopIfIndex)
 	route.IsValid = true
 	route.SliceIdx = ribdInt.Int(sliceIdx)
+	route.Vrf = vrf
 	ConnectedRoutes = append(ConnectedRoutes, route)
 }
 
@@ -336,7 +345,8 @@ func (m RIBDServer) GetBulkRouteDistanceState(fromIndex ribd.Int, rcount ribd.In
 }
 func (m RIBDServer) GetPerProtocolRouteCountList() (retList []*ribd.PerProtocolRouteCount) {
 	retList = make([]*ribd.PerProtocolRouteCount, 0)
-	for k, v := range ProtocolRouteMap {
+	//route counts and stats are of the default vrf
+	for k, v := range ProtocolRouteMap[ribdCommonDefs.DEFAULT_VRF] {
 		retList = append(retList, &ribd.PerProtocolRouteCount{
 			Protocol:   k,
 			RouteCount: int32(v.totalcount.totalcount),
@@ -394,7 +404,16 @@ func (m RIBDServer) GetBulkRoutesForProtocol(srcProtocol string, fromIndex ribdI
 	This function adds and removes ipAddr from the TrachReachabilityMap based on the op value
 */
 func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op string) error {
-	logger.Debug("TrackReachabilityStatus for ipAddr: ", ipAddr, " by protocol ", protocol, " op = ", op)
+	return m.TrackVrfReachabilityStatus(ribdCommonDefs.DEFAULT_VRF, ipAddr, protocol, op)
+}
+
+/*
+    Track reachability status of ipAddr in the route table of vrf
+*/
+func (m RIBDServer) TrackVrfReachabilityStatus(vrf string, ipAddr string, protocol string, op string) error {
+	logger.Debug("TrackReachabilityStatus for ipAddr: ", ipAddr, " vrf: ", vrf, " by protocol ", protocol, " op = ", op)
+	vrf = getVrfName(vrf)
+	trackKey := TrackReachabilityKey{vrf, ipAddr}
 	if op != "add" && op != "del" {
 		logger.Err("Invalid operation ", op)
 		return errors.New("Invalid operation")
@@ -402,7 +421,7 @@ func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op s
 	/*
 	   Check if this ipAddr is being tracked.
 	*/
-	protocolList, ok := TrackReachabilityMap[ipAddr]
+	protocolList, ok := TrackReachabilityMap[trackKey]
 	if !ok {
 		if op == "del" {
 			logger.Err("ipAddr ", ipAddr, " not being tracked currently")
@@ -435,7 +454,7 @@ func (m RIBDServer) TrackReachabilityStatus(ipAddr string, protocol string, op s
 	/*
 	   Update the TrackReachabilityMap for this ip
 	*/
-	TrackReachabilityMap[ipAddr] = protocolList
+	TrackReachabilityMap[trackKey] = protocolList
 	return nil
 }
 func (m RIBDServer) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count ribd.Int) (stats *ribd.RouteStatsPerProtocolStateGetInfo, err error) {
@@ -443,9 +462,9 @@ func (m RIBDServer) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count
 	stats = &returnInfo
 	count = 0
 	var tempNode []*ribd.RouteStatsPerProtocolState = make([]*ribd.RouteStatsPerProtocolState, 0)
-	for protocol, _ := range ProtocolRouteMap {
-		routes := Getv4RoutesPerProtocol(protocol)
-		v6routes := Getv6RoutesPerProtocol(protocol)
+	for protocol, _ := range ProtocolRouteMap[ribdCommonDefs.DEFAULT_VRF] {
+		routes := Getv4RoutesPerProtocol(ribdCommonDefs.DEFAULT_VRF, protocol)
+		v6routes := Getv6RoutesPerProtocol(ribdCommonDefs.DEFAULT_VRF, protocol)
 		/*		for destNet, _ := range routemapInfo.routeMap {
 				routes = Getv4RoutesPerProtocol(destNet, protocol)
 				v6routes = Getv6RoutesPerProtocol(destNet, protocol)
@@ -464,8 +483,8 @@ func (m RIBDServer) GetBulkRouteStatsPerProtocolState(fromIndex ribd.Int, count
 }
 func (m RIBDServer) GetRouteStatsPerProtocolState(protocol string) (stats *ribd.RouteStatsPerProtocolState, err error) {
 
-	routes := Getv4RoutesPerProtocol(protocol)
-	v6routes := Getv6RoutesPerProtocol(protocol)
+	routes := Getv4RoutesPerProtocol(ribdCommonDefs.DEFAULT_VRF, protocol)
+	v6routes := Getv6RoutesPerProtocol(ribdCommonDefs.DEFAULT_VRF, protocol)
 	stats = &ribd.RouteStatsPerProtocolState{
 		Protocol: protocol,
 		V4Routes: routes,
@@ -478,9 +497,9 @@ func (m RIBDServer) GetBulkRouteStatsPerInterfaceState(fromIndex ribd.Int, count
 	stats = &returnInfo
 	count = 0
 	var tempNode []*ribd.RouteStatsPerInterfaceState = make([]*ribd.RouteStatsPerInterfaceState, 0)
-	for intfref, _ := range InterfaceRouteMap {
-		routes := Getv4RoutesPerInterface(intfref)
-		v6routes := Getv6RoutesPerInterface(intfref)
+	for intfref, _ := range InterfaceRouteMap[ribdCommonDefs.DEFAULT_VRF] {
+		routes := Getv4RoutesPerInterface(ribdCommonDefs.DEFAULT_VRF, intfref)
+		v6routes := Getv6RoutesPerInterface(ribdCommonDefs.DEFAULT_VRF, intfref)
 		tempNode = append(tempNode, &ribd.RouteStatsPerInterfaceState{
 			Intfref:  intfref,
 			V4Routes: routes,
@@ -495,8 +514,8 @@ func (m RIBDServer) GetBulkRouteStatsPerInterfaceState(fromIndex ribd.Int, count
 }
 func (m RIBDServer) GetRouteStatsPerInterfaceState(intfref string) (stats *ribd.RouteStatsPerInterfaceState, err error) {
 
-	routes := Getv4RoutesPerInterface(intfref)
-	v6routes := Getv6RoutesPerInterface(intfref)
+	routes := Getv4RoutesPerInterface(ribdCommonDefs.DEFAULT_VRF, intfref)
+	v6routes := Getv6RoutesPerInterface(ribdCommonDefs.DEFAULT_VRF, intfref)
 	stats = &ribd.RouteStatsPerInterfaceState{
 		Intfref:  intfref,
 		V4Routes: routes,
@@ -553,11 +572,18 @@ func (m RIBDServer) GetBulkRIBEventState(fromIndex ribd.Int, rcount ribd.Int) (e
 */
 
 func (m RIBDServer) GetRouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.GetVrfRouteReachabilityInfo(ribdCommonDefs.DEFAULT_VRF, destNet, ifIndex)
+}
+
+/*
+   Returns the longest prefix match route to reach the destination network destNet in vrf
+*/
+func (m RIBDServer) GetVrfRouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
 	//logger.Debug("GetRouteReachabilityInfo of ", destNet)
-	nextHopIntf, err = RouteServiceHandler.GetV4RouteReachabilityInfo(destNet, ifIndex)
+	nextHopIntf, err = RouteServiceHandler.GetVrfV4RouteReachabilityInfo(vrf, destNet, ifIndex)
 	if err != nil {
 		//logger.Info("next hop ", destNet, " not reachable via ipv4 network")
-		nextHopIntf, err = RouteServiceHandler.GetV6RouteReachabilityInfo(destNet, ifIndex)
+		nextHopIntf, err = RouteServiceHandler.GetVrfV6RouteReachabilityInfo(vrf, destNet, ifIndex)
 		if err != nil {
 			logger.Err("next hop ", destNet, " not reachable")
 		}
@@ -677,7 +703,7 @@ func UpdateRouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the nod
 					   this prefix and call reachability status
 */
 /*
-		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 			//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
 			RouteInfoMap.VisitAndUpdate(UpdateRouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, "Down", k, nextHopIntf})
 		}
@@ -698,7 +724,7 @@ func UpdateRouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the nod
 		   this prefix and call reachability status
 */
 /*
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
 						RouteInfoMap.VisitAndUpdate(UpdateRouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, "Up", k, nextHopIntf})
 					}
@@ -713,8 +739,15 @@ func UpdateRouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the nod
    Resolve and determine the immediate next hop info for a given ipAddr
 */
 func ResolveNextHop(ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNextHopIntf ribdInt.NextHopInfo, err error) {
-	func_mesg := "ResolveNextHop() for " + ipAddr
-	logger.Debug("ResolveNextHop for ", ipAddr)
+	return ResolveVrfNextHop(ribdCommonDefs.DEFAULT_VRF, ipAddr)
+}
+
+/*
+   Resolve the immediate next hop info for ipAddr using the route table of vrf
+*/
+func ResolveVrfNextHop(vrf string, ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNextHopIntf ribdInt.NextHopInfo, err error) {
+	func_mesg := "ResolveNextHop() for " + ipAddr + " vrf " + vrf
+	logger.Debug("ResolveNextHop for ", ipAddr, " vrf ", vrf)
 	var prev_intf ribdInt.NextHopInfo
 	nextHopIntf.NextHopIp = ipAddr
 	prev_intf.NextHopIp = ipAddr
@@ -725,7 +758,7 @@ func ResolveNextHop(ipAddr string) (nextHopIntf ribdInt.NextHopInfo, resolvedNex
 	}
 	ip := ipAddr
 	for {
-		intf, err := RouteServiceHandler.GetRouteReachabilityInfo(ip, -1)
+		intf, err := RouteServiceHandler.GetVrfRouteReachabilityInfo(vrf, ip, -1)
 		if err != nil {
 			logger.Err(func_mesg, "next hop ", ip, " not reachable")
 			return nextHopIntf, nextHopIntf, err
@@ -977,7 +1010,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 	} else {
 		logger.Debug("This is a new route for selectedProtocolType being added, create destNetSlice entry at index ", len(destNetSlice))
 		routeInfoRecord.sliceIdx = len(destNetSlice)
-		localDBRecord := localDB{prefix: destNetPrefix, isValid: true, nextHopIp: routeInfoRecord.nextHopIp.String()}
+		localDBRecord := localDB{prefix: destNetPrefix, vrf: routeInfoRecord.vrf, isValid: true, nextHopIp: routeInfoRecord.nextHopIp.String()}
 		if destNetSlice == nil {
 			destNetSlice = make([]localDB, 0)
 		}
@@ -1005,7 +1038,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 	/*
 	   Update route info in RouteMap
 	*/
-	RouteInfoMapSet(routeInfoRecord.ipType, patriciaDB.Prefix(destNetPrefix), routeInfoRecordList)
+	RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, patriciaDB.Prefix(destNetPrefix), routeInfoRecordList)
 	if routeInfoRecord.ipType == ribdCommonDefs.IPv4 {
 		v4rtCount++
 		v4routeCreatedTimeMap[v4rtCount] = routeInfoRecord.routeCreatedTime
@@ -1017,8 +1050,8 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 	if !found {
 		ecmp = true
 	}
-	UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
-	UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
+	UpdateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
+	UpdateInterfaceRouteMap(routeInfoRecord.vrf, int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNetPrefix), ecmp)
 
 	if ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)] != routeInfoRecordList.selectedRouteProtocol {
 		logger.Debug("This is not a selected route, so nothing more to do here")
@@ -1032,7 +1065,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 		Op:               "add",
 	}
 
-	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid}
+	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid, Vrf: routeInfoRecord.vrf}
 	var params RouteParams
 	params = BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
 	if policyPath == policyCommonDefs.PolicyPath_Export {
@@ -1041,7 +1074,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 		/*
 		   Find resolved next hop
 		*/
-		nhIntf, resolvedNextHopIntf, res_err := ResolveNextHop(routeInfoRecord.nextHopIp.String())
+		nhIntf, resolvedNextHopIntf, res_err := ResolveVrfNextHop(getNextHopVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String())
 		//logger.Debug("nhIntf:ipAddr:mask = ", nhIntf.Ipaddr, ":", nhIntf.Mask, " nexthop ip :", routeInfoRecord.nextHopIp.String())
 		routeInfoRecord.resolvedNextHopIpIntf = resolvedNextHopIntf
 		//call asicd to add
@@ -1057,7 +1090,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 			/*
 			   Call arp resolve only if it has not yet been called for this next hop
 			*/
-			if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			if !arpResolveCalled(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 				//call arpd to resolve the ip
 				logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "add"}
@@ -1065,7 +1098,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 			/*
 			   Update next hop map for this next hop ip
 			*/
-			updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
+			updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
 		}
 		//update in the event log
 		eventInfo := "Installed " + ReverseRouteProtoTypeMapDB[int(policyRoute.Prototype)] + " route " + policyRoute.Ipaddr + ":" + policyRoute.Mask + " nextHopIp :" + routeInfoRecord.nextHopIp.String() + " in Hardware and RIB "
@@ -1077,7 +1110,7 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 		if res_err == nil {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, add)
+				updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), string(nhPrefix)}, add)
 			}
 		}
 		if routeInfoRecord.resolvedNextHopIpIntf.IsReachable {
@@ -1087,12 +1120,15 @@ func addNewRoute(destNetPrefix patriciaDB.Prefix,
 				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
 			}
 			//check if there are routes depending on this network as next hop
-			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
-				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
+			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(routeInfoRecord.vrf), string(destNetPrefix)}].refCount > 0 {
+				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
 				RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-				RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
+				RouteInfoMapVisitAndUpdate(routeInfoRecord.vrf, routeInfoRecord.ipType, routeReachabilityStatusInfo)
 			}
 		}
+		//leak the installed route into the vrfs importing from this vrf
+		leakVrfRoute(routeInfoRecord, add)
+		nhtRouteChanged(routeInfoRecord)
 	}
 	params.deleteType = Invalid
 	PolicyEngineFilter(policyRoute, policyPath, params)
@@ -1159,18 +1195,18 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 			if deleteNode == true {
 				//logger.Debug("Route deleted for this destination, traverse dependent routes to update routeReachability status")
 				//check if there are routes dependent on this network
-				if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
+				if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(routeInfoRecord.vrf), string(destNetPrefix)}].refCount > 0 {
 					nextHopIntf := ribdInt.NextHopInfo{}
-					routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
+					routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
 					RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-					RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
+					RouteInfoMapVisitAndUpdate(routeInfoRecord.vrf, routeInfoRecord.ipType, routeReachabilityStatusInfo)
 				}
 				//get the network address associated with the nexthop and update its refcount
-				nhIntf, err := RouteServiceHandler.GetRouteReachabilityInfo(routeInfoRecord.nextHopIp.String(), -1)
+				nhIntf, err := RouteServiceHandler.GetVrfRouteReachabilityInfo(getNextHopVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String(), -1)
 				if err == nil {
 					nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 					if err == nil {
-						updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, del)
+						updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), string(nhPrefix)}, del)
 					}
 				}
 				/*
@@ -1180,9 +1216,9 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 					OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
 					Op:               "del",
 				}
-				RouteInfoMapDelete(routeInfoRecord.ipType, destNetPrefix)
-				UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), false)
-				UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), false)
+				RouteInfoMapDelete(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix)
+				UpdateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), false)
+				UpdateInterfaceRouteMap(routeInfoRecord.vrf, int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), false)
 				nodeDeleted = true
 			}
 		}
@@ -1191,9 +1227,9 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 				OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
 				Op:               "add",
 			}
-			RouteInfoMapSet(routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
-			UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), true)
-			UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), true)
+			RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
+			UpdateProtocolRouteMap(routeInfoRecord.vrf, ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], "del", routeInfoRecord.ipType, string(destNetPrefix), true)
+			UpdateInterfaceRouteMap(routeInfoRecord.vrf, int(routeInfoRecord.nextHopIfIndex), "del", routeInfoRecord.ipType, string(destNetPrefix), true)
 		}
 	} else if delType == FIBOnly {
 		/*
@@ -1211,18 +1247,18 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 		routeInfoRecordList.routeInfoProtocolMap[ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]] = routeInfoList
 		//logger.Debug("Route deleted for this destination, traverse dependent routes to update routeReachability status")
 		//check if there are routes dependent on this network
-		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNetPrefix)}].refCount > 0 {
+		if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(routeInfoRecord.vrf), string(destNetPrefix)}].refCount > 0 {
 			nextHopIntf := ribdInt.NextHopInfo{}
-			routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
+			routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Down", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
 			RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
-			RouteInfoMapVisitAndUpdate(routeInfoRecord.ipType, routeReachabilityStatusInfo)
+			RouteInfoMapVisitAndUpdate(routeInfoRecord.vrf, routeInfoRecord.ipType, routeReachabilityStatusInfo)
 		}
 		//get the network address associated with the nexthop and update its refcount
-		nhIntf, err := RouteServiceHandler.GetRouteReachabilityInfo(routeInfoRecord.nextHopIp.String(), -1)
+		nhIntf, err := RouteServiceHandler.GetVrfRouteReachabilityInfo(getNextHopVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String(), -1)
 		if err == nil {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, del)
+				updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), string(nhPrefix)}, del)
 			}
 		}
 		logger.Debug("Adding to DBRouteCh from deletev4Route")
@@ -1230,13 +1266,13 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 			OrigConfigObject: RouteDBInfo{routeInfoRecord, routeInfoRecordList},
 			Op:               "add",
 		}
-		RouteInfoMapSet(routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
+		RouteInfoMapSet(routeInfoRecord.vrf, routeInfoRecord.ipType, destNetPrefix, routeInfoRecordList)
 	}
 	if routeInfoRecordList.selectedRouteProtocol != ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)] {
 		logger.Debug("This is not the selected protocol, nothing more to do here")
 		return
 	}
-	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid}
+	policyRoute := ribdInt.Routes{Ipaddr: routeInfoRecord.destNetIp.String(), Mask: routeInfoRecord.networkMask.String(), IPAddrType: ribdInt.Int(routeInfoRecord.ipType), NextHopIp: routeInfoRecord.nextHopIp.String(), IfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex), Metric: ribdInt.Int(routeInfoRecord.metric), Prototype: ribdInt.Int(routeInfoRecord.protocol), IsPolicyBasedStateValid: routeInfoRecordList.isPolicyBasedStateValid, Vrf: routeInfoRecord.vrf}
 	if policyPath != policyCommonDefs.PolicyPath_Export {
 		//logger.Debug("Expected export path for delete op")
 		return
@@ -1244,14 +1280,19 @@ func deleteRoute(destNetPrefix patriciaDB.Prefix, //route prefix of the route be
 	//delete in asicd
 	//if asicdclnt.IsConnected {
 	logger.Debug("This is the selected protocol:Calling asicd to delete this route- ip", routeInfoRecord.destNetIp.String(), " mask ", routeInfoRecord.networkMask.String(), " nextHopIP ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp)
-	RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "del"}
+	if !routeInfoRecord.removedFromFIB {
+		RouteServiceHandler.AsicdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "del"}
+	}
 	//}
+	//withdraw the route from the vrfs it was leaked into
+	leakVrfRoute(routeInfoRecord, del)
+	nhtRouteChanged(routeInfoRecord)
 	//if arpdclnt.IsConnected &&
 	if routeInfoRecord.protocol != ribdCommonDefs.CONNECTED {
-		if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+		if !arpResolveCalled(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 			logger.Debug("ARP resolve was never called for ", routeInfoRecord.nextHopIp.String())
 		} else {
-			refCount := updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, del)
+			refCount := updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, del)
 			if refCount == 0 {
 				logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "del"}
@@ -1348,6 +1389,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 	  policyStateChange int,
 	  sliceIdx ribd.Int) (rc ribd.Int, err error) {)*/
 
+	vrf := getVrfName(routeInfo.vrf)
 	ipType := routeInfo.ipType
 	destNetIp := routeInfo.destNetIp
 	networkMask := routeInfo.networkMask
@@ -1393,6 +1435,8 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 	routePrototype := int8(routeType)
 	//nwAddr := (destNetIpAddr.Mask(net.IPMask(networkMaskAddr))).String() + "/" + strconv.Itoa(prefixLen)
 	routeInfoRecord := RouteInfoRecord{
+		vrf:            vrf,
+		leakedFrom:     routeInfo.leakedFrom,
 		ipType:         ipType,
 		destNetIp:      destNetIpAddr,
 		networkMask:    networkMaskAddr,
@@ -1404,20 +1448,21 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		metric:         metric,
 		sliceIdx:       int(sliceIdx),
 		weight:         weight,
+		labels:         routeInfo.labels,
 	}
 
-	policyRoute := ribdInt.Routes{Ipaddr: destNetIp, IPAddrType: ribdInt.Int(ipType), Mask: networkMask, NextHopIp: nextHopIp, IfIndex: ribdInt.Int(nextHopIfIndex), Metric: ribdInt.Int(metric), Prototype: ribdInt.Int(routeType), Weight: ribdInt.Int(weight)}
+	policyRoute := ribdInt.Routes{Ipaddr: destNetIp, IPAddrType: ribdInt.Int(ipType), Mask: networkMask, NextHopIp: nextHopIp, IfIndex: ribdInt.Int(nextHopIfIndex), Metric: ribdInt.Int(metric), Prototype: ribdInt.Int(routeType), Weight: ribdInt.Int(weight), Vrf: vrf}
 	//logger.Info("createroute:,setting ipaddrtype to :", policyRoute.IPAddrType, " from iptype:", ipType)
 	routeInfoRecord.resolvedNextHopIpIntf.NextHopIp = routeInfoRecord.nextHopIp.String()
 	routeInfoRecord.resolvedNextHopIpIntf.NextHopIfIndex = ribdInt.Int(routeInfoRecord.nextHopIfIndex)
 
-	nhIntf, resolvedNextHopIntf, res_err := ResolveNextHop(routeInfoRecord.nextHopIp.String())
+	nhIntf, resolvedNextHopIntf, res_err := ResolveVrfNextHop(getNextHopVrf(routeInfoRecord), routeInfoRecord.nextHopIp.String())
 	//_, resolvedNextHopIntf, _ := ResolveNextHop(routeInfoRecord.nextHopIp.String())
 	routeInfoRecord.resolvedNextHopIpIntf = resolvedNextHopIntf
 	logger.Info("nhIntf ipaddr/mask: ", nhIntf.Ipaddr, ":", nhIntf.Mask, " resolvedNex ", resolvedNextHopIntf.NextHopIp, " nexthop ", nextHopIp, "Is reachable:", resolvedNextHopIntf.IsReachable)
 
 	routeInfoRecord.routeCreatedTime = time.Now().String()
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, destNet)
+	routeInfoRecordListItem := RouteInfoMapGet(vrf, ipType, destNet)
 	if routeInfoRecordListItem == nil {
 		/*
 		   no routes for this destination are currently configured
@@ -1439,7 +1484,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		} else if policyStateChange == ribdCommonDefs.RoutePolicyStateChangetoValid {
 			newRouteInfoRecordList.isPolicyBasedStateValid = true
 		}
-		if ok := RouteInfoMapInsert(ipType, destNet, newRouteInfoRecordList); ok != true {
+		if ok := RouteInfoMapInsert(vrf, ipType, destNet, newRouteInfoRecordList); ok != true {
 			logger.Err("Route map insert return value not ok")
 			return 0, err
 		}
@@ -1450,9 +1495,9 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 			v6rtCount++
 			v6routeCreatedTimeMap[v6rtCount] = routeInfoRecord.routeCreatedTime
 		}
-		UpdateProtocolRouteMap(ReverseRouteProtoTypeMapDB[int(routeType)], "add", ipType, string(destNet), false)
-		UpdateInterfaceRouteMap(int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNet), false)
-		localDBRecord := localDB{prefix: destNet, isValid: true, nextHopIp: nextHopIp}
+		UpdateProtocolRouteMap(vrf, ReverseRouteProtoTypeMapDB[int(routeType)], "add", ipType, string(destNet), false)
+		UpdateInterfaceRouteMap(routeInfoRecord.vrf, int(routeInfoRecord.nextHopIfIndex), "add", routeInfoRecord.ipType, string(destNet), false)
+		localDBRecord := localDB{prefix: destNet, vrf: vrf, isValid: true, nextHopIp: nextHopIp}
 		if destNetSlice == nil {
 			destNetSlice = make([]localDB, 0)
 		}
@@ -1464,13 +1509,13 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		//		}
 		//if arpdclnt.IsConnected &&
 		if routeInfoRecord.protocol != ribdCommonDefs.CONNECTED {
-			if !arpResolveCalled(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
+			if !arpResolveCalled(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}) {
 				//call arpd to resolve the ip
 				//logger.Debug("Adding ", routeInfoRecord.resolvedNextHopIpIntf.NextHopIp, " to ArpdRouteCh")
 				RouteServiceHandler.ArpdRouteCh <- RIBdServerConfig{OrigConfigObject: routeInfoRecord, Op: "add"}
 			}
 			//update the ref count for the resolved next hop ip
-			updateNextHopMap(NextHopInfoKey{routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
+			updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), routeInfoRecord.resolvedNextHopIpIntf.NextHopIp}, add)
 		}
 		//logger.Debug("Adding to DBRouteCh from createv4Route")
 		RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
@@ -1487,7 +1532,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 			nhPrefix, err := getNetowrkPrefixFromStrings(nhIntf.Ipaddr, nhIntf.Mask)
 			if err == nil {
 				logger.Debug("network address of the nh route: ", nhPrefix)
-				updateNextHopMap(NextHopInfoKey{string(nhPrefix)}, add)
+				updateNextHopMap(NextHopInfoKey{getNextHopVrf(routeInfoRecord), string(nhPrefix)}, add)
 			}
 		}
 		if routeInfoRecord.resolvedNextHopIpIntf.IsReachable {
@@ -1496,13 +1541,15 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 				NextHopIp:      routeInfoRecord.nextHopIp.String(),
 				NextHopIfIndex: ribdInt.Int(routeInfoRecord.nextHopIfIndex),
 			}
-			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(destNet)}].refCount > 0 {
-				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf}
+			if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(vrf), string(destNet)}].refCount > 0 {
+				routeReachabilityStatusInfo := RouteReachabilityStatusInfo{routeInfoRecord.networkAddr, routeInfoRecord.ipType, "Up", ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], nextHopIntf, routeInfoRecord.vrf}
 				RouteReachabilityStatusUpdate(routeReachabilityStatusInfo.protocol, routeReachabilityStatusInfo)
 				//If there are dependent routes for this ip, then bring them up
-				RouteInfoMapVisitAndUpdate(ipType, routeReachabilityStatusInfo)
+				RouteInfoMapVisitAndUpdate(vrf, ipType, routeReachabilityStatusInfo)
 			}
 		}
+		leakVrfRoute(routeInfoRecord, add)
+		nhtRouteChanged(routeInfoRecord)
 		var params RouteParams
 		params = BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
 		params.createType = addType
@@ -1556,7 +1603,7 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
 		}
 	}
 	if addType != FIBOnly && routePrototype == ribdCommonDefs.CONNECTED { //PROTOCOL_CONNECTED {
-		updateConnectedRoutes(destNetIp, networkMask, nextHopIp, nextHopIfIndex, add, sliceIdx)
+		updateConnectedRoutes(vrf, destNetIp, networkMask, nextHopIp, nextHopIfIndex, add, sliceIdx)
 	}
 	return 0, err
 
@@ -1567,7 +1614,8 @@ func createRoute(routeInfo RouteParams) (rc ribd.Int, err error) {
    -  a user/protocol deletes a route - delType = FIBAndRIB
    - when a link goes down and we have connected routes on that link - delType = FIBOnly
 **/
-func deleteIPRoute(destNetIp string,
+func deleteIPRoute(vrf string,
+	destNetIp string,
 	ipType ribdCommonDefs.IPType,
 	networkMask string,
 	routeType string,
@@ -1575,7 +1623,8 @@ func deleteIPRoute(destNetIp string,
 	nextHopIfIndex ribd.Int,
 	delType ribd.Int,
 	policyStateChange int) (rc ribd.Int, err error) {
-	logger.Debug("deleteIPRoute for destNetIp:", destNetIp, " networkMask:", networkMask, " with routeType:", routeType, " nextHopIP", nextHopIP, " del type ", delType)
+	logger.Debug("deleteIPRoute for destNetIp:", destNetIp, " networkMask:", networkMask, " vrf:", vrf, " with routeType:", routeType, " nextHopIP", nextHopIP, " del type ", delType)
+	vrf = getVrfName(vrf)
 
 	destNetIpAddr, err := getIP(destNetIp)
 	if err != nil {
@@ -1605,7 +1654,7 @@ func deleteIPRoute(destNetIp string,
 		}
 	}
 	//logger.Debug("destNet = ", destNet)
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, destNet)
+	routeInfoRecordListItem := RouteInfoMapGet(vrf, ipType, destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Err("Destnet ", destNet, " not found")
 		return 0, errors.New("No match found ")
@@ -1634,9 +1683,9 @@ func deleteIPRoute(destNetIp string,
 
 	if routeType == "CONNECTED" { //PROTOCOL_CONNECTED {
 		if delType == FIBOnly { //link gone down, just invalidate the connected route
-			updateConnectedRoutes(destNetIp, networkMask, "", 0, invalidate, 0)
+			updateConnectedRoutes(vrf, destNetIp, networkMask, "", 0, invalidate, 0)
 		} else {
-			updateConnectedRoutes(destNetIp, networkMask, "", 0, del, 0)
+			updateConnectedRoutes(vrf, destNetIp, networkMask, "", 0, del, 0)
 		}
 	}
 
@@ -27,6 +27,7 @@ package server
 import (
 	"l3/rib/ribdCommonDefs"
 	"ribd"
+	"ribdInt"
 	"strconv"
 )
 
@@ -40,8 +41,10 @@ type TrackReachabilityInfo struct {
 	IpAddr   string
 	Protocol string
 	Op       string
+	Vrf      string
 }
 type NextHopInfoKey struct {
+	vrf       string
 	nextHopIp string
 }
 type NextHopInfo struct {
@@ -57,19 +60,61 @@ type PerProtocolRouteInfo struct {
 	totalcount RouteCountInfo
 }
 
-var ProtocolRouteMap map[string]PerProtocolRouteInfo  //map[string]int
-var InterfaceRouteMap map[string]PerProtocolRouteInfo //map[string]int
-
-func UpdateV4ProtocolRouteMap(protocol string, op string, value string, ecmp bool) {
-	var info PerProtocolRouteInfo
+var ProtocolRouteMap map[string]map[string]PerProtocolRouteInfo  //vrf -> protocol -> routes
+var InterfaceRouteMap map[string]map[string]PerProtocolRouteInfo //vrf -> intfref -> routes
 
+/*
+   Returns the per protocol route counts of the vrf, the map is created for
+   the "add" op
+*/
+func getVrfProtocolRouteMap(vrf string, op string) map[string]PerProtocolRouteInfo {
 	if ProtocolRouteMap == nil {
 		if op == "del" {
-			return
+			return nil
 		}
-		ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+		ProtocolRouteMap = make(map[string]map[string]PerProtocolRouteInfo)
+	}
+	protocolRouteMap, ok := ProtocolRouteMap[getVrfName(vrf)]
+	if !ok {
+		if op == "del" {
+			return nil
+		}
+		protocolRouteMap = make(map[string]PerProtocolRouteInfo)
+		ProtocolRouteMap[getVrfName(vrf)] = protocolRouteMap
+	}
+	return protocolRouteMap
+}
+
+/*
+   Returns the per interface route counts of the vrf, the map is created for
+   the "add" op
+*/
+func getVrfInterfaceRouteMap(vrf string, op string) map[string]PerProtocolRouteInfo {
+	if InterfaceRouteMap == nil {
+		if op == "del" {
+			return nil
+		}
+		InterfaceRouteMap = make(map[string]map[string]PerProtocolRouteInfo)
+	}
+	interfaceRouteMap, ok := InterfaceRouteMap[getVrfName(vrf)]
+	if !ok {
+		if op == "del" {
+			return nil
+		}
+		interfaceRouteMap = make(map[string]PerProtocolRouteInfo)
+		InterfaceRouteMap[getVrfName(vrf)] = interfaceRouteMap
+	}
+	return interfaceRouteMap
+}
+
+func UpdateV4ProtocolRouteMap(vrf string, protocol string, op string, value string, ecmp bool) {
+	var info PerProtocolRouteInfo
+
+	protocolRouteMap := getVrfProtocolRouteMap(vrf, op)
+	if protocolRouteMap == nil {
+		return
 	}
-	info, ok := ProtocolRouteMap[protocol]
+	info, ok := protocolRouteMap[protocol]
 	if !ok || info.v4routeMap == nil {
 		if op == "del" {
 			return
@@ -84,7 +129,7 @@ func UpdateV4ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	}
 	totalcount := info.totalcount
 	count, ok := protocolroutemap[value]
-	if !ok {
+	if !ok || count.totalcount == 0 {
 		if op == "del" {
 			return
 		}
@@ -116,18 +161,16 @@ func UpdateV4ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	protocolroutemap[value] = count
 	info.v4routeMap = protocolroutemap
 	info.totalcount = totalcount
-	ProtocolRouteMap[protocol] = info
+	protocolRouteMap[protocol] = info
 }
-func UpdateV6ProtocolRouteMap(protocol string, op string, value string, ecmp bool) {
+func UpdateV6ProtocolRouteMap(vrf string, protocol string, op string, value string, ecmp bool) {
 	var info PerProtocolRouteInfo
 
-	if ProtocolRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	protocolRouteMap := getVrfProtocolRouteMap(vrf, op)
+	if protocolRouteMap == nil {
+		return
 	}
-	info, ok := ProtocolRouteMap[protocol]
+	info, ok := protocolRouteMap[protocol]
 	if !ok || info.v6routeMap == nil {
 		if op == "del" {
 			return
@@ -142,7 +185,7 @@ func UpdateV6ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	}
 	totalcount := info.totalcount
 	count, ok := protocolroutemap[value]
-	if !ok {
+	if !ok || count.totalcount == 0 {
 		if op == "del" {
 			return
 		}
@@ -174,28 +217,26 @@ func UpdateV6ProtocolRouteMap(protocol string, op string, value string, ecmp boo
 	protocolroutemap[value] = count
 	info.v6routeMap = protocolroutemap
 	info.totalcount = totalcount
-	ProtocolRouteMap[protocol] = info
+	protocolRouteMap[protocol] = info
 }
-func UpdateProtocolRouteMap(protocol string, op string, ipType ribdCommonDefs.IPType, value string, ecmp bool) {
+func UpdateProtocolRouteMap(vrf string, protocol string, op string, ipType ribdCommonDefs.IPType, value string, ecmp bool) {
 	//logger.Debug("UpdateProtocolRouteMap,protocol:", protocol, " iptype:", ipType)
 	if ipType == ribdCommonDefs.IPv4 {
-		UpdateV4ProtocolRouteMap(protocol, op, value, ecmp)
+		UpdateV4ProtocolRouteMap(vrf, protocol, op, value, ecmp)
 	} else {
-		UpdateV6ProtocolRouteMap(protocol, op, value, ecmp)
+		UpdateV6ProtocolRouteMap(vrf, protocol, op, value, ecmp)
 	}
 
 }
 
-func UpdateV4InterfaceRouteMap(intfref string, op string, value string, ecmp bool) {
+func UpdateV4InterfaceRouteMap(vrf string, intfref string, op string, value string, ecmp bool) {
 	var info PerProtocolRouteInfo
 
-	if InterfaceRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		InterfaceRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	interfaceRouteMap := getVrfInterfaceRouteMap(vrf, op)
+	if interfaceRouteMap == nil {
+		return
 	}
-	info, ok := InterfaceRouteMap[intfref]
+	info, ok := interfaceRouteMap[intfref]
 	if !ok || info.v4routeMap == nil {
 		if op == "del" {
 			return
@@ -210,7 +251,7 @@ func UpdateV4InterfaceRouteMap(intfref string, op string, value string, ecmp boo
 	}
 	totalcount := info.totalcount
 	count, ok := interfaceroutemap[value]
-	if !ok {
+	if !ok || count.totalcount == 0 {
 		if op == "del" {
 			return
 		}
@@ -242,18 +283,16 @@ func UpdateV4InterfaceRouteMap(intfref string, op string, value string, ecmp boo
 	interfaceroutemap[value] = count
 	info.v4routeMap = interfaceroutemap
 	info.totalcount = totalcount
-	InterfaceRouteMap[intfref] = info
+	interfaceRouteMap[intfref] = info
 }
-func UpdateV6InterfaceRouteMap(intfref string, op string, value string, ecmp bool) {
+func UpdateV6InterfaceRouteMap(vrf string, intfref string, op string, value string, ecmp bool) {
 	var info PerProtocolRouteInfo
 
-	if InterfaceRouteMap == nil {
-		if op == "del" {
-			return
-		}
-		InterfaceRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	interfaceRouteMap := getVrfInterfaceRouteMap(vrf, op)
+	if interfaceRouteMap == nil {
+		return
 	}
-	info, ok := InterfaceRouteMap[intfref]
+	info, ok := interfaceRouteMap[intfref]
 	if !ok || info.v6routeMap == nil {
 		if op == "del" {
 			return
@@ -268,7 +307,7 @@ func UpdateV6InterfaceRouteMap(intfref string, op string, value string, ecmp boo
 	}
 	totalcount := info.totalcount
 	count, ok := interfaceroutemap[value]
-	if !ok {
+	if !ok || count.totalcount == 0 {
 		if op == "del" {
 			return
 		}
@@ -300,9 +339,9 @@ func UpdateV6InterfaceRouteMap(intfref string, op string, value string, ecmp boo
 	interfaceroutemap[value] = count
 	info.v6routeMap = interfaceroutemap
 	info.totalcount = totalcount
-	InterfaceRouteMap[intfref] = info
+	interfaceRouteMap[intfref] = info
 }
-func UpdateInterfaceRouteMap(intf int, op string, ipType ribdCommonDefs.IPType, value string, ecmp bool) {
+func UpdateInterfaceRouteMap(vrf string, intf int, op string, ipType ribdCommonDefs.IPType, value string, ecmp bool) {
 	intfref := strconv.Itoa(int(intf))
 	intfEntry, ok := IntfIdNameMap[int32(intf)]
 	if ok {
@@ -310,29 +349,31 @@ func UpdateInterfaceRouteMap(intf int, op string, ipType ribdCommonDefs.IPType,
 		intfref = intfEntry.name
 	}
 	if ipType == ribdCommonDefs.IPv4 {
-		UpdateV4InterfaceRouteMap(intfref, op, value, ecmp)
+		UpdateV4InterfaceRouteMap(vrf, intfref, op, value, ecmp)
 	} else {
-		UpdateV6InterfaceRouteMap(intfref, op, value, ecmp)
+		UpdateV6InterfaceRouteMap(vrf, intfref, op, value, ecmp)
 	}
 
 }
 func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 	logger.Info("Starting the routeserver loop")
-	ProtocolRouteMap = make(map[string]PerProtocolRouteInfo) //map[string]int)
+	ProtocolRouteMap = make(map[string]map[string]PerProtocolRouteInfo)
 	for {
 		select {
 		case routeConf := <-ribdServiceHandler.RouteConfCh:
 			//logger.Debug(fmt.Sprintln("received message on RouteConfCh channel, op: ", routeConf.Op)
 			if routeConf.Op == "add" {
-				ribdServiceHandler.ProcessV4RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBAndRIB, ribd.Int(len(destNetSlice)))
+				ribdServiceHandler.ProcessVrfV4LabeledRouteCreateConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv4Route), routeConf.NextHopLabels, FIBAndRIB, ribd.Int(len(destNetSlice)))
 			} else if routeConf.Op == "addFIBOnly" {
-				ribdServiceHandler.ProcessV4RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
+				ribdServiceHandler.ProcessVrfV4RouteCreateConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
 			} else if routeConf.Op == "addBulk" {
 				ribdServiceHandler.ProcessBulkRouteCreateConfig(routeConf.OrigBulkRouteConfigObject) //.([]*ribd.IPv4Route))
 			} else if routeConf.Op == "del" {
-				ribdServiceHandler.ProcessV4RouteDeleteConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBAndRIB)
+				ribdServiceHandler.ProcessVrfV4RouteDeleteConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBAndRIB)
 			} else if routeConf.Op == "delFIBOnly" {
-				ribdServiceHandler.ProcessV4RouteDeleteConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBOnly)
+				ribdServiceHandler.ProcessVrfV4RouteDeleteConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv4Route), FIBOnly)
+			} else if routeConf.Op == "backupSwitchover" {
+				ribdServiceHandler.ProcessV4BackupSwitchover(routeConf.AdditionalParams.(BackupSwitchoverInfo))
 			} else if routeConf.Op == "update" {
 				if routeConf.PatchOp == nil || len(routeConf.PatchOp) == 0 {
 					ribdServiceHandler.Processv4RouteUpdateConfig(routeConf.OrigConfigObject.(*ribd.IPv4Route), routeConf.NewConfigObject.(*ribd.IPv4Route), routeConf.AttrSet)
@@ -341,16 +382,16 @@ func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 				}
 			} else if routeConf.Op == "addv6" {
 				//create ipv6 route
-				ribdServiceHandler.ProcessV6RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBAndRIB, ribd.Int(len(destNetSlice)))
+				ribdServiceHandler.ProcessVrfV6LabeledRouteCreateConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv6Route), routeConf.NextHopLabels, FIBAndRIB, ribd.Int(len(destNetSlice)))
 			} else if routeConf.Op == "addv6FIBOnly" {
 				//create ipv6 route
-				ribdServiceHandler.ProcessV6RouteCreateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
+				ribdServiceHandler.ProcessVrfV6RouteCreateConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBOnly, routeConf.AdditionalParams.(ribd.Int))
 			} else if routeConf.Op == "delv6" {
 				//delete ipv6 route
-				ribdServiceHandler.ProcessV6RouteDeleteConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBAndRIB)
+				ribdServiceHandler.ProcessVrfV6RouteDeleteConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBAndRIB)
 			} else if routeConf.Op == "delv6FIBOnly" {
 				//delete ipv6 route
-				ribdServiceHandler.ProcessV6RouteDeleteConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBOnly)
+				ribdServiceHandler.ProcessVrfV6RouteDeleteConfig(routeConf.Vrf, routeConf.OrigConfigObject.(*ribd.IPv6Route), FIBOnly)
 			} else if routeConf.Op == "updatev6" {
 				//update ipv6 route
 				if routeConf.PatchOp == nil || len(routeConf.PatchOp) == 0 {
@@ -358,7 +399,25 @@ func (ribdServiceHandler *RIBDServer) StartRouteProcessServer() {
 				} else {
 					ribdServiceHandler.Processv6RoutePatchUpdateConfig(routeConf.OrigConfigObject.(*ribd.IPv6Route), routeConf.NewConfigObject.(*ribd.IPv6Route), routeConf.PatchOp)
 				}
+			} else if routeConf.Op == "addVrf" {
+				ribdServiceHandler.ProcessVrfCreateConfig(routeConf.OrigConfigObject.(*ribdInt.Vrf))
+			} else if routeConf.Op == "delVrf" {
+				ribdServiceHandler.ProcessVrfDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.Vrf))
+			} else if routeConf.Op == "addVrfRouteLeak" {
+				ribdServiceHandler.ProcessVrfRouteLeakCreateConfig(routeConf.OrigConfigObject.(*ribdInt.VrfRouteLeak))
+			} else if routeConf.Op == "delVrfRouteLeak" {
+				ribdServiceHandler.ProcessVrfRouteLeakDeleteConfig(routeConf.OrigConfigObject.(*ribdInt.VrfRouteLeak))
+			} else if routeConf.Op == "addNht" {
+				ribdServiceHandler.ProcessNextHopTrackingRegister(routeConf.OrigConfigObject.(NextHopTrackingInfo))
+			} else if routeConf.Op == "delNht" {
+				ribdServiceHandler.ProcessNextHopTrackingUnregister(routeConf.OrigConfigObject.(NextHopTrackingInfo))
+			} else if routeConf.Op == "delNhtClient" {
+				ribdServiceHandler.ProcessNextHopTrackingClientDelete(routeConf.OrigConfigObject.(string))
+			} else if routeConf.Op == "delRoutesOfType" {
+				DeleteRoutesOfType(routeConf.OrigConfigObject.(string))
 			}
+			//notify next hop tracking clients of the resolution changes caused by this update
+			ribdServiceHandler.ProcessNhtPendingUpdates()
 		}
 	}
 }
@@ -29,6 +29,7 @@ import (
 	//	"database/sql"
 	"fmt"
 	"github.com/op/go-nanomsg"
+	"l3/bfd/bfddCommonDefs"
 	//"l3/rib/ribdCommonDefs"
 	"net"
 	//	"os"
@@ -55,6 +56,8 @@ type RIBdServerConfig struct {
 	PatchOp                   []*ribd.PatchOpInfo
 	PolicyList                ApplyPolicyList
 	AdditionalParams          interface{}
+	Vrf                       string
+	NextHopLabels             map[string][]int32 //vpn label stack per next hop of a vrf route
 }
 
 type V4IntfGetInfo struct {
@@ -122,10 +125,12 @@ const (
 )
 const (
 	SUB_ASICD = 0
+	SUB_BFDD  = 1
 )
 
 type localDB struct {
 	prefix     patriciaDB.Prefix
+	vrf        string
 	isValid    bool
 	precedence int
 	nextHopIp  string
@@ -138,6 +143,7 @@ var count int
 var ConnectedRoutes []*ribdInt.Routes
 var logger *logging.Writer
 var AsicdSub *nanomsg.SubSocket
+var BfddSub *nanomsg.SubSocket
 var RouteServiceHandler *RIBDServer
 var IntfIdNameMap map[int32]IntfEntry
 var IfNameToIfIndex map[string]int32
@@ -169,6 +175,12 @@ func (ribdServiceHandler *RIBDServer) ProcessIPv4IntfDownEvent(ipAddr string, if
 	ipAddrStr := ip.String()
 	ipMaskStr := net.IP(ipMask).String()
 	logger.Info(" processIPv4IntfDownEvent for  ipaddr ", ipAddrStr, " mask ", ipMaskStr)
+	if ifIndex != -1 {
+		ribdServiceHandler.RouteConfCh <- RIBdServerConfig{
+			AdditionalParams: BackupSwitchoverInfo{nextHopIfIndex: ribd.Int(ifIndex)},
+			Op:               "backupSwitchover",
+		}
+	}
 	//deleteIPRoute(ConnectedRoutes[i].Ipaddr, ribdCommonDefs.IPv4, ConnectedRoutes[i].Mask, "CONNECTED", ConnectedRoutes[i].NextHopIp, ribd.Int(ConnectedRoutes[i].IfIndex), FIBOnly, ribdCommonDefs.RoutePolicyStateChangeNoChange)
 	cfg := ribd.IPv4Route{
 		DestinationNw: ipAddrStr,
@@ -500,6 +512,7 @@ func (ribdServiceHandler *RIBDServer) AcceptConfigActions() {
 		logger.Err("DB read failed")
 	}
 	go ribdServiceHandler.SetupEventHandler(AsicdSub, asicdCommonDefs.PUB_SOCKET_ADDR, SUB_ASICD)
+	go ribdServiceHandler.SetupEventHandler(BfddSub, bfddCommonDefs.PUB_SOCKET_ADDR, SUB_BFDD)
 	logger.Info("All set to signal start the RIBd server")
 	ribdServiceHandler.ServerUpCh <- true
 }
@@ -547,13 +560,14 @@ func (ribdServiceHandler *RIBDServer) InitializePolicyDB() *policy.PolicyEngineD
 func NewRIBDServicesHandler(dbHdl *dbutils.DBUtil, loggerC *logging.Writer) *RIBDServer {
 	V4RouteInfoMap = patriciaDB.NewTrie()
 	V6RouteInfoMap = patriciaDB.NewTrie()
+	initVrfInfoMap()
 	ribdServicesHandler := &RIBDServer{}
 	ribdServicesHandler.Logger = loggerC
 	logger = loggerC
 	localRouteEventsDB = make([]RouteEventInfo, 0)
 	RedistributeRouteMap = make(map[string][]RedistributeRouteInfo)
 	ribdServicesHandler.Clients = make(map[string]ClientIf)
-	TrackReachabilityMap = make(map[string][]string)
+	TrackReachabilityMap = make(map[TrackReachabilityKey][]string)
 	v4routeCreatedTimeMap = make(map[int]string)
 	v6routeCreatedTimeMap = make(map[int]string)
 	RouteProtocolTypeMapDB = make(map[string]int)
@@ -629,7 +643,7 @@ func (ribdServiceHandler *RIBDServer) StartServer(paramsDir string) {
 		ribdServiceHandler.PolicyUpdateApplyCh <- list)*/
 		case info := <-ribdServiceHandler.TrackReachabilityCh:
 			//logger.Debug("received message on TrackReachabilityCh channel")
-			ribdServiceHandler.TrackReachabilityStatus(info.IpAddr, info.Protocol, info.Op)
+			ribdServiceHandler.TrackVrfReachabilityStatus(info.Vrf, info.IpAddr, info.Protocol, info.Op)
 		}
 	}
 }
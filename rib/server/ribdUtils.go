@@ -61,7 +61,12 @@ type PublisherMapInfo struct {
 
 var RedistributeRouteMap map[string][]RedistributeRouteInfo
 var RedistributionPolicyMap map[string]RedistributionPolicyInfo
-var TrackReachabilityMap map[string][]string //map[ipAddr][]protocols
+type TrackReachabilityKey struct {
+	vrf    string
+	ipAddr string
+}
+
+var TrackReachabilityMap map[TrackReachabilityKey][]string //map[vrf,ipAddr][]protocols
 var RouteProtocolTypeMapDB map[string]int
 var ReverseRouteProtoTypeMapDB map[int]string
 var ProtocolAdminDistanceMapDB map[string]RouteDistanceConfig
@@ -118,6 +123,7 @@ func BuildRouteProtocolTypeMapDB() {
 	RouteProtocolTypeMapDB["IBGP"] = ribdCommonDefs.IBGP
 	RouteProtocolTypeMapDB["BGP"] = ribdCommonDefs.BGP
 	RouteProtocolTypeMapDB["OSPF"] = ribdCommonDefs.OSPF
+	RouteProtocolTypeMapDB["ISIS"] = ribdCommonDefs.ISIS
 	RouteProtocolTypeMapDB["STATIC"] = ribdCommonDefs.STATIC
 
 	//reverse
@@ -127,6 +133,7 @@ func BuildRouteProtocolTypeMapDB() {
 	ReverseRouteProtoTypeMapDB[ribdCommonDefs.BGP] = "BGP"
 	ReverseRouteProtoTypeMapDB[ribdCommonDefs.STATIC] = "STATIC"
 	ReverseRouteProtoTypeMapDB[ribdCommonDefs.OSPF] = "OSPF"
+	ReverseRouteProtoTypeMapDB[ribdCommonDefs.ISIS] = "ISIS"
 }
 func BuildProtocolAdminDistanceMapDB() {
 	ProtocolAdminDistanceMapDB["CONNECTED"] = RouteDistanceConfig{defaultDistance: 0, configuredDistance: -1}
@@ -134,6 +141,7 @@ func BuildProtocolAdminDistanceMapDB() {
 	ProtocolAdminDistanceMapDB["EBGP"] = RouteDistanceConfig{defaultDistance: 20, configuredDistance: -1}
 	ProtocolAdminDistanceMapDB["IBGP"] = RouteDistanceConfig{defaultDistance: 200, configuredDistance: -1}
 	ProtocolAdminDistanceMapDB["OSPF"] = RouteDistanceConfig{defaultDistance: 110, configuredDistance: -1}
+	ProtocolAdminDistanceMapDB["ISIS"] = RouteDistanceConfig{defaultDistance: 115, configuredDistance: -1}
 }
 func (slice AdminDistanceSlice) Len() int {
 	return len(slice)
@@ -195,7 +203,7 @@ func arpResolveCalled(key NextHopInfoKey) bool {
 	}
 	info, ok := RouteServiceHandler.NextHopInfoMap[key]
 	if !ok || info.refCount == 0 {
-		logger.Info("Arp resolve not called for ", key.nextHopIp)
+		logger.Info("Arp resolve not called for ", key.nextHopIp, " in vrf ", key.vrf)
 		return false
 	}
 	return true
@@ -261,6 +269,8 @@ func buildPolicyEntityFromRoute(route ribdInt.Routes, params interface{}) (entit
 }
 func BuildRouteParamsFromRouteInoRecord(routeInfoRecord RouteInfoRecord) RouteParams {
 	var params RouteParams
+	params.vrf = routeInfoRecord.vrf
+	params.leakedFrom = routeInfoRecord.leakedFrom
 	params.ipType = routeInfoRecord.ipType
 	params.routeType = ribd.Int(routeInfoRecord.protocol)
 	params.destNetIp = routeInfoRecord.destNetIp.String()
@@ -483,7 +493,7 @@ func deleteRoutePolicyStateAll(route ribdInt.Routes) {
 		return
 	}
 
-	routeInfoRecordListItem := RouteInfoMapGet(ribdCommonDefs.IPType(route.IPAddrType), destNet)
+	routeInfoRecordListItem := RouteInfoMapGet(route.Vrf, ribdCommonDefs.IPType(route.IPAddrType), destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Info(" entry not found for prefix %v", destNet)
 		return
@@ -491,7 +501,7 @@ func deleteRoutePolicyStateAll(route ribdInt.Routes) {
 	routeInfoRecordList := routeInfoRecordListItem.(RouteInfoRecordList)
 	routeInfoRecordList.policyHitCounter = ribd.Int(route.PolicyHitCounter)
 	routeInfoRecordList.policyList = nil //append(routeInfoRecordList.policyList[:0])
-	RouteInfoMapSet(ribdCommonDefs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
+	RouteInfoMapSet(route.Vrf, ribdCommonDefs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
 	return
 }
 func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string) {
@@ -501,7 +511,7 @@ func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string)
 		return
 	}
 
-	routeInfoRecordListItem := RouteInfoMapGet(ribdCommonDefs.IPType(route.IPAddrType), destNet)
+	routeInfoRecordListItem := RouteInfoMapGet(route.Vrf, ribdCommonDefs.IPType(route.IPAddrType), destNet)
 	if routeInfoRecordListItem == nil {
 		logger.Info("Unexpected - entry not found for prefix ", destNet)
 		return
@@ -531,7 +541,7 @@ func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string)
 		policyStmtList = append(policyStmtList,policyStmt)
 	    routeInfoRecordList.policyList[policy] = policyStmtList*/
 	routeInfoRecordList.policyList = append(routeInfoRecordList.policyList, policy)
-	RouteInfoMapSet(ribdCommonDefs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
+	RouteInfoMapSet(route.Vrf, ribdCommonDefs.IPType(route.IPAddrType), destNet, routeInfoRecordList)
 	//logger.Debug("Adding to DBRouteCh from addRoutePolicyState")
 	RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 		OrigConfigObject: RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList},
@@ -540,11 +550,11 @@ func addRoutePolicyState(route ribdInt.Routes, policy string, policyStmt string)
 	//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList})
 	return
 }
-func deleteRoutePolicyState(ipType ribdCommonDefs.IPType, ipPrefix patriciaDB.Prefix, policyName string) {
+func deleteRoutePolicyState(vrf string, ipType ribdCommonDefs.IPType, ipPrefix patriciaDB.Prefix, policyName string) {
 	//logger.Info("deleteRoutePolicyState")
 	found := false
 	idx := 0
-	routeInfoRecordListItem := RouteInfoMapGet(ipType, ipPrefix)
+	routeInfoRecordListItem := RouteInfoMapGet(vrf, ipType, ipPrefix)
 	if routeInfoRecordListItem == nil {
 		logger.Info("routeInfoRecordListItem nil for prefix ", ipPrefix)
 		return
@@ -570,7 +580,7 @@ func deleteRoutePolicyState(ipType ribdCommonDefs.IPType, ipPrefix patriciaDB.Pr
 	} else {
 		routeInfoRecordList.policyList = append(routeInfoRecordList.policyList[:idx], routeInfoRecordList.policyList[idx+1:]...)
 	}
-	RouteInfoMapSet(ipType, ipPrefix, routeInfoRecordList)
+	RouteInfoMapSet(vrf, ipType, ipPrefix, routeInfoRecordList)
 	//logger.Debug("Adding to DBRouteCh from deleteRoutePolicyState")
 	RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 		OrigConfigObject: RouteDBInfo{routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol][0], routeInfoRecordList},
@@ -653,6 +663,7 @@ func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteRe
 	evt := ribdCommonDefs.NOTIFY_ROUTE_REACHABILITY_STATUS_UPDATE
 	PUB := publisherInfo.pub_socket
 	msgInfo := ribdCommonDefs.RouteReachabilityStatusMsgInfo{}
+	msgInfo.Vrf = getVrfName(info.vrf)
 	msgInfo.Network = info.destNet
 	if info.status == "Up" || info.status == "Updated" {
 		msgInfo.IsReachable = true
@@ -666,7 +677,7 @@ func RouteReachabilityStatusNotificationSend(targetProtocol string, info RouteRe
 		logger.Err("Error in marshalling Json")
 		return
 	}
-	eventInfo := "Update Route Reachability status " + info.status + " for network " + info.destNet + " for protocol " + targetProtocol
+	eventInfo := "Update Route Reachability status " + info.status + " for network " + info.destNet + " vrf " + msgInfo.Vrf + " for protocol " + targetProtocol
 	if info.status == "Up" {
 		eventInfo = eventInfo + " NextHop IP: " + info.nextHopIntf.NextHopIp + " Index: " + strconv.Itoa(int(info.nextHopIntf.NextHopIfIndex))
 	}
@@ -694,16 +705,20 @@ func RouteReachabilityStatusUpdate(targetProtocol string, info RouteReachability
 		return
 	}
 	//check the TrackReachabilityMap to see if any other protocols are interested in receiving updates for this network
+	vrf := getVrfName(info.vrf)
 	for k, list := range TrackReachabilityMap {
-		prefix, err := getNetowrkPrefixFromStrings(k, ipMaskStr)
+		if k.vrf != vrf {
+			continue
+		}
+		prefix, err := getNetowrkPrefixFromStrings(k.ipAddr, ipMaskStr)
 		if err != nil {
-			logger.Err("Error getting ip prefix for ip:", k, " mask:", ipMaskStr)
+			logger.Err("Error getting ip prefix for ip:", k.ipAddr, " mask:", ipMaskStr)
 			return
 		}
 		if bytes.Equal(destIpPrefix, prefix) {
 			for idx := 0; idx < len(list); idx++ {
 				//logger.Info(" protocol ", list[idx], " interested in receving reachability updates for ipAddr ", info.destNet)
-				info.destNet = k
+				info.destNet = k.ipAddr
 				RouteReachabilityStatusNotificationSend(list[idx], info)
 			}
 		}
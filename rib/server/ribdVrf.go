@@ -0,0 +1,585 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//	 Unless required by applicable law or agreed to in writing, software
+//	 distributed under the License is distributed on an "AS IS" BASIS,
+//	 WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//	 See the License for the specific language governing permissions and
+//	 limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+// ribdVrf.go
+package server
+
+import (
+	"errors"
+	"fmt"
+	"l3/rib/ribdCommonDefs"
+	"ribd"
+	"ribdInt"
+	"sort"
+	"strconv"
+	netUtils "utils/netUtils"
+	"utils/patriciaDB"
+	"utils/policy"
+)
+
+/*
+   Route leak from srcVrf into dstVrf. Routes selected in srcVrf are
+   installed in dstVrf when the policy permits them. An empty policy
+   leaks all the routes.
+*/
+type VrfRouteLeakInfo struct {
+	srcVrf string
+	dstVrf string
+	policy string
+}
+
+/*
+   Per vrf route tables. The default vrf uses V4RouteInfoMap and
+   V6RouteInfoMap so that the existing users of the global tries
+   continue to see the default routing table.
+*/
+type VrfInfo struct {
+	name           string
+	v4RouteInfoMap *patriciaDB.Trie
+	v6RouteInfoMap *patriciaDB.Trie
+	intfList       []int32            //ifIndexes bound to the vrf
+	leakList       []VrfRouteLeakInfo //leaks with this vrf as the source
+}
+
+var VrfInfoMap map[string]*VrfInfo
+var IfIndexToVrfMap map[int32]string
+
+func initVrfInfoMap() {
+	VrfInfoMap = make(map[string]*VrfInfo)
+	IfIndexToVrfMap = make(map[int32]string)
+	VrfInfoMap[ribdCommonDefs.DEFAULT_VRF] = &VrfInfo{
+		name:           ribdCommonDefs.DEFAULT_VRF,
+		v4RouteInfoMap: V4RouteInfoMap,
+		v6RouteInfoMap: V6RouteInfoMap,
+	}
+}
+
+func getVrfName(vrf string) string {
+	if vrf == "" {
+		return ribdCommonDefs.DEFAULT_VRF
+	}
+	return vrf
+}
+
+func getVrfRouteInfoMap(vrf string, ipType ribdCommonDefs.IPType) *patriciaDB.Trie {
+	vrfInfo, ok := VrfInfoMap[getVrfName(vrf)]
+	if !ok {
+		return nil
+	}
+	if ipType == ribdCommonDefs.IPv6 {
+		return vrfInfo.v6RouteInfoMap
+	}
+	return vrfInfo.v4RouteInfoMap
+}
+
+/*
+   Returns the vrf the interface is bound to. intfRef is either the
+   ifIndex or the name of the interface.
+*/
+func getIntfRefVrf(intfRef string) string {
+	ifIndex, err := strconv.Atoi(intfRef)
+	if err != nil {
+		val, ok := IfNameToIfIndex[intfRef]
+		if !ok {
+			return ribdCommonDefs.DEFAULT_VRF
+		}
+		ifIndex = int(val)
+	}
+	vrf, ok := IfIndexToVrfMap[int32(ifIndex)]
+	if !ok {
+		return ribdCommonDefs.DEFAULT_VRF
+	}
+	return vrf
+}
+
+/*
+   Next hops of leaked routes are resolved in the vrf the route was leaked from
+*/
+func getNextHopVrf(routeInfoRecord RouteInfoRecord) string {
+	if routeInfoRecord.leakedFrom != "" {
+		return routeInfoRecord.leakedFrom
+	}
+	return getVrfName(routeInfoRecord.vrf)
+}
+
+func (m RIBDServer) IsVrfConfigured(name string) bool {
+	_, ok := VrfInfoMap[getVrfName(name)]
+	return ok
+}
+
+type leakPolicyStmtList []policy.PolicyDefinitionStmtPrecedence
+
+func (l leakPolicyStmtList) Len() int           { return len(l) }
+func (l leakPolicyStmtList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l leakPolicyStmtList) Less(i, j int) bool { return l[i].Precedence < l[j].Precedence }
+
+/*
+   Walks the statements of the leak policy in the order of precedence,
+   the action of the first statement whose conditions match the route decides
+   if the route is leaked
+*/
+func vrfLeakPolicyPermits(policyName string, routeInfoRecord RouteInfoRecord) bool {
+	if policyName == "" {
+		return true
+	}
+	policyItem := PolicyEngineDB.PolicyDB.Get(patriciaDB.Prefix(policyName))
+	if policyItem == nil {
+		logger.Err("Leak policy ", policyName, " not found, not leaking route ", routeInfoRecord.networkAddr)
+		return false
+	}
+	policyInfo := policyItem.(policy.Policy)
+	stmtList := make([]policy.PolicyDefinitionStmtPrecedence, len(policyInfo.PolicyDefinitionStatements))
+	copy(stmtList, policyInfo.PolicyDefinitionStatements)
+	sort.Sort(leakPolicyStmtList(stmtList))
+	route := ribdInt.Routes{
+		Ipaddr:     routeInfoRecord.destNetIp.String(),
+		Mask:       routeInfoRecord.networkMask.String(),
+		IPAddrType: ribdInt.Int(routeInfoRecord.ipType),
+		NextHopIp:  routeInfoRecord.nextHopIp.String(),
+		IfIndex:    ribdInt.Int(routeInfoRecord.nextHopIfIndex),
+		Metric:     ribdInt.Int(routeInfoRecord.metric),
+		Prototype:  ribdInt.Int(routeInfoRecord.protocol),
+		Vrf:        routeInfoRecord.vrf,
+	}
+	params := BuildRouteParamsFromRouteInoRecord(routeInfoRecord)
+	params.createType = FIBAndRIB
+	params.deleteType = Invalid
+	entity, err := buildPolicyEntityFromRoute(route, params)
+	if err != nil {
+		logger.Err("Error building policy entity params for route ", routeInfoRecord.networkAddr)
+		return false
+	}
+	for _, stmt := range stmtList {
+		stmtItem := PolicyEngineDB.PolicyStmtDB.Get(patriciaDB.Prefix(stmt.Statement))
+		if stmtItem == nil {
+			continue
+		}
+		policyStmt := stmtItem.(policy.PolicyStmt)
+		if !PolicyEngineDB.ConditionCheckValid(entity, policyStmt.Conditions, policyStmt) {
+			continue
+		}
+		if len(policyStmt.Actions) == 0 {
+			return false
+		}
+		return policyStmt.Actions[0] != "deny" && policyStmt.Actions[0] != "Reject"
+	}
+	return false
+}
+
+/*
+   Install or withdraw the leaked copy of the route in the destination vrf of the leak
+*/
+func leakVrfRouteInto(leak VrfRouteLeakInfo, routeInfoRecord RouteInfoRecord, op int) {
+	protocol := ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)]
+	if op == add {
+		if !vrfLeakPolicyPermits(leak.policy, routeInfoRecord) {
+			return
+		}
+		logger.Info("Leaking ", protocol, " route ", routeInfoRecord.networkAddr, " from vrf ", leak.srcVrf, " into vrf ", leak.dstVrf)
+		params := RouteParams{
+			vrf:            leak.dstVrf,
+			leakedFrom:     leak.srcVrf,
+			ipType:         routeInfoRecord.ipType,
+			destNetIp:      routeInfoRecord.destNetIp.String(),
+			networkMask:    routeInfoRecord.networkMask.String(),
+			nextHopIp:      routeInfoRecord.nextHopIp.String(),
+			nextHopIfIndex: routeInfoRecord.nextHopIfIndex,
+			metric:         routeInfoRecord.metric,
+			weight:         routeInfoRecord.weight,
+			routeType:      ribd.Int(routeInfoRecord.protocol),
+			createType:     FIBAndRIB,
+			deleteType:     Invalid,
+			sliceIdx:       ribd.Int(len(destNetSlice)),
+		}
+		_, err := createRoute(params)
+		if err != nil {
+			logger.Err("Leaking route ", routeInfoRecord.networkAddr, " into vrf ", leak.dstVrf, " failed with err ", err)
+		}
+		return
+	}
+	item := RouteInfoMapGet(leak.dstVrf, routeInfoRecord.ipType, patriciaDB.Prefix(routeInfoRecord.networkAddr))
+	if item == nil {
+		return
+	}
+	routeInfoRecordList := item.(RouteInfoRecordList)
+	for _, leakedRecord := range routeInfoRecordList.routeInfoProtocolMap[protocol] {
+		if leakedRecord.leakedFrom != leak.srcVrf || !leakedRecord.nextHopIp.Equal(routeInfoRecord.nextHopIp) {
+			continue
+		}
+		logger.Info("Withdrawing ", protocol, " route ", routeInfoRecord.networkAddr, " leaked from vrf ", leak.srcVrf, " from vrf ", leak.dstVrf)
+		_, err := deleteIPRoute(leak.dstVrf, leakedRecord.destNetIp.String(), leakedRecord.ipType, leakedRecord.networkMask.String(), protocol, leakedRecord.nextHopIp.String(), leakedRecord.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if err != nil {
+			logger.Err("Withdrawing leaked route ", routeInfoRecord.networkAddr, " from vrf ", leak.dstVrf, " failed with err ", err)
+		}
+		return
+	}
+}
+
+/*
+   Called when a route is installed in/removed from the FIB of its vrf.
+   Leaked routes are not leaked any further.
+*/
+func leakVrfRoute(routeInfoRecord RouteInfoRecord, op int) {
+	if routeInfoRecord.leakedFrom != "" {
+		return
+	}
+	vrfInfo, ok := VrfInfoMap[getVrfName(routeInfoRecord.vrf)]
+	if !ok {
+		return
+	}
+	for _, leak := range vrfInfo.leakList {
+		leakVrfRouteInto(leak, routeInfoRecord, op)
+	}
+}
+
+/*
+   Returns the selected routes of the vrf route table
+*/
+func getVrfSelectedRoutes(routeInfoMap *patriciaDB.Trie) (routeList []RouteInfoRecord) {
+	routeList = make([]RouteInfoRecord, 0)
+	if routeInfoMap == nil {
+		return routeList
+	}
+	routeInfoMap.Visit(func(prefix patriciaDB.Prefix, item patriciaDB.Item) error {
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		if routeInfoRecordList.routeInfoProtocolMap == nil {
+			return nil
+		}
+		routeList = append(routeList, routeInfoRecordList.routeInfoProtocolMap[routeInfoRecordList.selectedRouteProtocol]...)
+		return nil
+	})
+	return routeList
+}
+
+/*
+   Returns all the routes of the vrf route table
+*/
+func getVrfRoutes(routeInfoMap *patriciaDB.Trie) (routeList []RouteInfoRecord) {
+	routeList = make([]RouteInfoRecord, 0)
+	if routeInfoMap == nil {
+		return routeList
+	}
+	routeInfoMap.Visit(func(prefix patriciaDB.Prefix, item patriciaDB.Item) error {
+		routeInfoRecordList := item.(RouteInfoRecordList)
+		for _, protocolRouteList := range routeInfoRecordList.routeInfoProtocolMap {
+			routeList = append(routeList, protocolRouteList...)
+		}
+		return nil
+	})
+	return routeList
+}
+
+/*
+   Move the connected routes of the interface from one vrf to another
+*/
+func moveConnectedRoutes(ifIndex int32, fromVrf string, toVrf string) {
+	routeList := make([]ribdInt.Routes, 0)
+	for _, route := range ConnectedRoutes {
+		if int32(route.IfIndex) == ifIndex && getVrfName(route.Vrf) == fromVrf {
+			routeList = append(routeList, *route)
+		}
+	}
+	for _, route := range routeList {
+		ipType := ribdCommonDefs.IPv4
+		if netUtils.IsIPv6Addr(route.Ipaddr) {
+			ipType = ribdCommonDefs.IPv6
+		}
+		logger.Info("Moving connected route ", route.Ipaddr, ":", route.Mask, " from vrf ", fromVrf, " to vrf ", toVrf)
+		_, err := deleteIPRoute(fromVrf, route.Ipaddr, ipType, route.Mask, "CONNECTED", route.NextHopIp, ribd.Int(route.IfIndex), FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if err != nil {
+			logger.Err("Deleting connected route ", route.Ipaddr, " in vrf ", fromVrf, " failed with err ", err)
+			continue
+		}
+		if !route.IsValid {
+			//interface is down, the route is added when it comes up
+			continue
+		}
+		params := RouteParams{
+			vrf:            toVrf,
+			ipType:         ipType,
+			destNetIp:      route.Ipaddr,
+			networkMask:    route.Mask,
+			nextHopIp:      route.NextHopIp,
+			nextHopIfIndex: ribd.Int(route.IfIndex),
+			routeType:      ribdCommonDefs.CONNECTED,
+			createType:     FIBAndRIB,
+			deleteType:     Invalid,
+			sliceIdx:       ribd.Int(len(destNetSlice)),
+		}
+		_, err = createRoute(params)
+		if err != nil {
+			logger.Err("Creating connected route ", route.Ipaddr, " in vrf ", toVrf, " failed with err ", err)
+		}
+	}
+}
+
+func (m RIBDServer) VrfConfigValidationCheck(cfg *ribdInt.Vrf, op string) (err error) {
+	if cfg.Name == "" || cfg.Name == ribdCommonDefs.DEFAULT_VRF {
+		return errors.New(fmt.Sprintln("Invalid vrf name ", cfg.Name))
+	}
+	_, ok := VrfInfoMap[cfg.Name]
+	if op == "del" {
+		if !ok {
+			return errors.New(fmt.Sprintln("Vrf ", cfg.Name, " not configured"))
+		}
+		return nil
+	}
+	if ok {
+		return errors.New(fmt.Sprintln("Vrf ", cfg.Name, " already configured"))
+	}
+	for _, intf := range cfg.IntfList {
+		intfRef, err := m.ConvertIntfStrToIfIndexStr(intf)
+		if err != nil {
+			return errors.New(fmt.Sprintln("Invalid interface ", intf, " for vrf ", cfg.Name))
+		}
+		if vrf := getIntfRefVrf(intfRef); vrf != ribdCommonDefs.DEFAULT_VRF {
+			return errors.New(fmt.Sprintln("Interface ", intf, " already bound to vrf ", vrf))
+		}
+	}
+	return nil
+}
+
+/*
+   Create the route tables of the vrf and move the interfaces, along with
+   their connected routes, from the default vrf
+*/
+func (m RIBDServer) ProcessVrfCreateConfig(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("ProcessVrfCreateConfig for vrf ", cfg.Name, " intfList ", cfg.IntfList)
+	if _, ok := VrfInfoMap[cfg.Name]; ok {
+		logger.Err("Vrf ", cfg.Name, " already configured")
+		return false, errors.New(fmt.Sprintln("Vrf ", cfg.Name, " already configured"))
+	}
+	vrfInfo := &VrfInfo{
+		name:           cfg.Name,
+		v4RouteInfoMap: patriciaDB.NewTrie(),
+		v6RouteInfoMap: patriciaDB.NewTrie(),
+		intfList:       make([]int32, 0),
+		leakList:       make([]VrfRouteLeakInfo, 0),
+	}
+	VrfInfoMap[cfg.Name] = vrfInfo
+	for _, intf := range cfg.IntfList {
+		intfRef, err := m.ConvertIntfStrToIfIndexStr(intf)
+		if err != nil {
+			logger.Err("Invalid interface ", intf, " for vrf ", cfg.Name)
+			continue
+		}
+		ifIndex, _ := strconv.Atoi(intfRef)
+		oldVrf := getIntfRefVrf(intfRef)
+		IfIndexToVrfMap[int32(ifIndex)] = cfg.Name
+		vrfInfo.intfList = append(vrfInfo.intfList, int32(ifIndex))
+		moveConnectedRoutes(int32(ifIndex), oldVrf, cfg.Name)
+	}
+	return true, nil
+}
+
+/*
+   Remove all the routes of the vrf, the leaks from/to the vrf and move
+   the interfaces back to the default vrf
+*/
+func (m RIBDServer) ProcessVrfDeleteConfig(cfg *ribdInt.Vrf) (val bool, err error) {
+	logger.Info("ProcessVrfDeleteConfig for vrf ", cfg.Name)
+	if cfg.Name == ribdCommonDefs.DEFAULT_VRF {
+		return false, errors.New("Cannot delete the default vrf")
+	}
+	vrfInfo, ok := VrfInfoMap[cfg.Name]
+	if !ok {
+		logger.Err("Vrf ", cfg.Name, " not configured")
+		return false, errors.New(fmt.Sprintln("Vrf ", cfg.Name, " not configured"))
+	}
+	//stop leaking routes into this vrf
+	for vrf, srcVrfInfo := range VrfInfoMap {
+		for _, leak := range srcVrfInfo.leakList {
+			if leak.dstVrf == cfg.Name {
+				m.ProcessVrfRouteLeakDeleteConfig(&ribdInt.VrfRouteLeak{SrcVrf: vrf, DstVrf: cfg.Name, Policy: leak.policy})
+			}
+		}
+	}
+	//connected routes go back to the default vrf with the interfaces
+	for _, ifIndex := range vrfInfo.intfList {
+		delete(IfIndexToVrfMap, ifIndex)
+		moveConnectedRoutes(ifIndex, cfg.Name, ribdCommonDefs.DEFAULT_VRF)
+	}
+	routeList := append(getVrfRoutes(vrfInfo.v4RouteInfoMap), getVrfRoutes(vrfInfo.v6RouteInfoMap)...)
+	for _, routeInfoRecord := range routeList {
+		_, err = deleteIPRoute(cfg.Name, routeInfoRecord.destNetIp.String(), routeInfoRecord.ipType, routeInfoRecord.networkMask.String(), ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if err != nil {
+			logger.Err("Deleting route ", routeInfoRecord.networkAddr, " in vrf ", cfg.Name, " failed with err ", err)
+		}
+	}
+	delete(VrfInfoMap, cfg.Name)
+	delete(ProtocolRouteMap, cfg.Name)
+	delete(InterfaceRouteMap, cfg.Name)
+	return true, nil
+}
+
+func (m RIBDServer) VrfRouteLeakConfigValidationCheck(cfg *ribdInt.VrfRouteLeak, op string) (err error) {
+	srcVrfInfo, ok := VrfInfoMap[getVrfName(cfg.SrcVrf)]
+	if !ok {
+		return errors.New(fmt.Sprintln("Source vrf ", cfg.SrcVrf, " not configured"))
+	}
+	if _, ok = VrfInfoMap[getVrfName(cfg.DstVrf)]; !ok {
+		return errors.New(fmt.Sprintln("Destination vrf ", cfg.DstVrf, " not configured"))
+	}
+	if getVrfName(cfg.SrcVrf) == getVrfName(cfg.DstVrf) {
+		return errors.New("Source and destination vrf of the route leak cannot be the same")
+	}
+	found := false
+	for _, leak := range srcVrfInfo.leakList {
+		if leak.dstVrf == getVrfName(cfg.DstVrf) {
+			found = true
+			break
+		}
+	}
+	if op == "add" && found {
+		return errors.New(fmt.Sprintln("Route leak from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " already configured"))
+	}
+	if op == "del" && !found {
+		return errors.New(fmt.Sprintln("Route leak from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " not configured"))
+	}
+	return nil
+}
+
+/*
+   Start leaking the routes of the source vrf and leak the routes already selected there
+*/
+func (m RIBDServer) ProcessVrfRouteLeakCreateConfig(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("ProcessVrfRouteLeakCreateConfig from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf, " policy ", cfg.Policy)
+	err = m.VrfRouteLeakConfigValidationCheck(cfg, "add")
+	if err != nil {
+		logger.Err(err)
+		return false, err
+	}
+	leak := VrfRouteLeakInfo{srcVrf: getVrfName(cfg.SrcVrf), dstVrf: getVrfName(cfg.DstVrf), policy: cfg.Policy}
+	srcVrfInfo := VrfInfoMap[leak.srcVrf]
+	srcVrfInfo.leakList = append(srcVrfInfo.leakList, leak)
+	routeList := append(getVrfSelectedRoutes(srcVrfInfo.v4RouteInfoMap), getVrfSelectedRoutes(srcVrfInfo.v6RouteInfoMap)...)
+	for _, routeInfoRecord := range routeList {
+		if routeInfoRecord.leakedFrom != "" {
+			continue
+		}
+		leakVrfRouteInto(leak, routeInfoRecord, add)
+	}
+	return true, nil
+}
+
+/*
+   Withdraw the routes leaked from the source vrf and stop leaking
+*/
+func (m RIBDServer) ProcessVrfRouteLeakDeleteConfig(cfg *ribdInt.VrfRouteLeak) (val bool, err error) {
+	logger.Info("ProcessVrfRouteLeakDeleteConfig from vrf ", cfg.SrcVrf, " to vrf ", cfg.DstVrf)
+	err = m.VrfRouteLeakConfigValidationCheck(cfg, "del")
+	if err != nil {
+		logger.Err(err)
+		return false, err
+	}
+	srcVrf := getVrfName(cfg.SrcVrf)
+	dstVrf := getVrfName(cfg.DstVrf)
+	srcVrfInfo := VrfInfoMap[srcVrf]
+	for idx, leak := range srcVrfInfo.leakList {
+		if leak.dstVrf == dstVrf {
+			srcVrfInfo.leakList = append(srcVrfInfo.leakList[:idx], srcVrfInfo.leakList[idx+1:]...)
+			break
+		}
+	}
+	dstVrfInfo := VrfInfoMap[dstVrf]
+	routeList := append(getVrfRoutes(dstVrfInfo.v4RouteInfoMap), getVrfRoutes(dstVrfInfo.v6RouteInfoMap)...)
+	for _, routeInfoRecord := range routeList {
+		if routeInfoRecord.leakedFrom != srcVrf {
+			continue
+		}
+		_, err = deleteIPRoute(dstVrf, routeInfoRecord.destNetIp.String(), routeInfoRecord.ipType, routeInfoRecord.networkMask.String(), ReverseRouteProtoTypeMapDB[int(routeInfoRecord.protocol)], routeInfoRecord.nextHopIp.String(), routeInfoRecord.nextHopIfIndex, FIBAndRIB, ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		if err != nil {
+			logger.Err("Withdrawing leaked route ", routeInfoRecord.networkAddr, " from vrf ", dstVrf, " failed with err ", err)
+		}
+	}
+	return true, nil
+}
+
+func getVrfRouteCount(routeInfoMap *patriciaDB.Trie) (count int32) {
+	if routeInfoMap == nil {
+		return count
+	}
+	routeInfoMap.Visit(func(prefix patriciaDB.Prefix, item patriciaDB.Item) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+func buildVrfState(vrfInfo *VrfInfo) *ribdInt.VrfState {
+	state := ribdInt.NewVrfState()
+	state.Name = vrfInfo.name
+	state.IntfList = make([]string, 0)
+	for _, ifIndex := range vrfInfo.intfList {
+		state.IntfList = append(state.IntfList, strconv.Itoa(int(ifIndex)))
+	}
+	state.V4RouteCount = getVrfRouteCount(vrfInfo.v4RouteInfoMap)
+	state.V6RouteCount = getVrfRouteCount(vrfInfo.v6RouteInfoMap)
+	state.LeakList = make([]*ribdInt.VrfRouteLeak, 0)
+	for _, leak := range vrfInfo.leakList {
+		state.LeakList = append(state.LeakList, &ribdInt.VrfRouteLeak{SrcVrf: leak.srcVrf, DstVrf: leak.dstVrf, Policy: leak.policy})
+	}
+	return state
+}
+
+func (m RIBDServer) GetVrfState(name string) (*ribdInt.VrfState, error) {
+	vrfInfo, ok := VrfInfoMap[getVrfName(name)]
+	if !ok {
+		return ribdInt.NewVrfState(), errors.New(fmt.Sprintln("Vrf ", name, " not configured"))
+	}
+	return buildVrfState(vrfInfo), nil
+}
+
+func (m RIBDServer) GetBulkVrfState(fromIndex ribdInt.Int, rcount ribdInt.Int) (vrfStates *ribdInt.VrfStateGetInfo, err error) {
+	var i, validCount, toIndex ribdInt.Int
+	var returnNodes []*ribdInt.VrfState
+	var returnGetInfo ribdInt.VrfStateGetInfo
+	vrfStates = &returnGetInfo
+	more := true
+	vrfNameList := make([]string, 0)
+	for name := range VrfInfoMap {
+		vrfNameList = append(vrfNameList, name)
+	}
+	sort.Strings(vrfNameList)
+	for ; ; i++ {
+		if i+fromIndex >= ribdInt.Int(len(vrfNameList)) {
+			more = false
+			break
+		}
+		if validCount == rcount {
+			break
+		}
+		if len(returnNodes) == 0 {
+			returnNodes = make([]*ribdInt.VrfState, 0)
+		}
+		returnNodes = append(returnNodes, buildVrfState(VrfInfoMap[vrfNameList[i+fromIndex]]))
+		toIndex = i + fromIndex
+		validCount++
+	}
+	vrfStates.VrfStateList = returnNodes
+	vrfStates.StartIdx = fromIndex
+	vrfStates.EndIdx = toIndex + 1
+	vrfStates.More = more
+	vrfStates.Count = validCount
+	return vrfStates, err
+}
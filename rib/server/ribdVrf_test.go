@@ -0,0 +1,321 @@
+//
+//Copyright [2016] [SnapRoute Inc]
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//       Unless required by applicable law or agreed to in writing, software
+//       distributed under the License is distributed on an "AS IS" BASIS,
+//       WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//       See the License for the specific language governing permissions and
+//       limitations under the License.
+//
+// _______  __       __________   ___      _______.____    __    ____  __  .___________.  ______  __    __
+// |   ____||  |     |   ____\  \ /  /     /       |\   \  /  \  /   / |  | |           | /      ||  |  |  |
+// |  |__   |  |     |  |__   \  V  /     |   (----` \   \/    \/   /  |  | `---|  |----`|  ,----'|  |__|  |
+// |   __|  |  |     |   __|   >   <       \   \      \            /   |  |     |  |     |  |     |   __   |
+// |  |     |  `----.|  |____ /  .  \  .----)   |      \    /\    /    |  |     |  |     |  `----.|  |  |  |
+// |__|     |_______||_______/__/ \__\ |_______/        \__/  \__/     |__|     |__|      \______||__|  |__|
+//
+
+package server
+
+import (
+	"fmt"
+	"l3/rib/ribdCommonDefs"
+	"ribd"
+	"ribdInt"
+	"testing"
+)
+
+var vrfLeakConditionsList []*ribd.PolicyCondition
+var vrfLeakStmtsList []*ribd.PolicyStmt
+var vrfLeakPolicyDefinition *ribd.PolicyDefinition
+
+func InitVrfLeakPolicyList() {
+	vrfLeakConditionsList = make([]*ribd.PolicyCondition, 0)
+	vrfLeakConditionsList = append(vrfLeakConditionsList, &ribd.PolicyCondition{
+		Name:            "Match70.1.10Network",
+		ConditionType:   "MatchDstIpPrefix",
+		IpPrefix:        "70.1.10.0/24",
+		MaskLengthRange: "exact",
+	})
+	vrfLeakConditionsList = append(vrfLeakConditionsList, &ribd.PolicyCondition{
+		Name:            "Match80.1.10Network",
+		ConditionType:   "MatchDstIpPrefix",
+		IpPrefix:        "80.1.10.0/24",
+		MaskLengthRange: "exact",
+	})
+	vrfLeakStmtsList = make([]*ribd.PolicyStmt, 0)
+	vrfLeakStmtsList = append(vrfLeakStmtsList, &ribd.PolicyStmt{
+		Name:            "leak70.1.10NetworkStmt",
+		MatchConditions: "all",
+		Conditions:      []string{"Match70.1.10Network"},
+		Action:          "permit",
+	})
+	vrfLeakStmtsList = append(vrfLeakStmtsList, &ribd.PolicyStmt{
+		Name:            "deny80.1.10NetworkStmt",
+		MatchConditions: "all",
+		Conditions:      []string{"Match80.1.10Network"},
+		Action:          "deny",
+	})
+	vrfLeakPolicyDefinition = &ribd.PolicyDefinition{
+		Name:       "leakRed",
+		Priority:   1,
+		MatchType:  "all",
+		PolicyType: "ALL",
+		StatementList: []*ribd.PolicyDefinitionStmtPriority{
+			&ribd.PolicyDefinitionStmtPriority{Priority: 1, Statement: "leak70.1.10NetworkStmt"},
+			&ribd.PolicyDefinitionStmtPriority{Priority: 2, Statement: "deny80.1.10NetworkStmt"},
+		},
+	}
+}
+
+func vrfTestRoute(destNet string, nextHopIp string, intfRef string) *ribd.IPv4Route {
+	return &ribd.IPv4Route{
+		DestinationNw: destNet,
+		NetworkMask:   "255.255.255.0",
+		NextHop:       []*ribd.NextHopInfo{&ribd.NextHopInfo{NextHopIp: nextHopIp, NextHopIntRef: intfRef}},
+		Protocol:      "STATIC",
+	}
+}
+
+/*
+   Returns the route records of the protocol for the prefix in the vrf
+*/
+func getVrfTestRouteRecords(vrf string, destNet string, protocol string) []RouteInfoRecord {
+	prefix, err := getNetowrkPrefixFromStrings(destNet, "255.255.255.0")
+	if err != nil {
+		return nil
+	}
+	item := RouteInfoMapGet(vrf, ribdCommonDefs.IPv4, prefix)
+	if item == nil {
+		return nil
+	}
+	return item.(RouteInfoRecordList).routeInfoProtocolMap[protocol]
+}
+
+func getVrfTestRouteCount(vrf string, destNet string, protocol string) int {
+	prefix, err := getNetowrkPrefixFromStrings(destNet, "255.255.255.0")
+	if err != nil {
+		return 0
+	}
+	return ProtocolRouteMap[vrf][protocol].v4routeMap[string(prefix)].totalcount
+}
+
+func TestInitVrfTestServer(t *testing.T) {
+	fmt.Println("****Init Vrf Test Server****")
+	StartTestServer()
+	TestProcessLogicalIntfCreateEvent(t)
+	TestIPv4IntfCreateEvent(t)
+	InitVrfLeakPolicyList()
+	fmt.Println("****************")
+}
+
+func TestVrfCreate(t *testing.T) {
+	fmt.Println("****TestVrfCreate****")
+	for _, name := range []string{"red", "blue"} {
+		if server.IsVrfConfigured(name) {
+			t.Fatal("vrf ", name, " configured before the test")
+		}
+	}
+	if _, err := server.ProcessVrfCreateConfig(&ribdInt.Vrf{Name: "red", IntfList: []string{"lo2"}}); err != nil {
+		t.Fatal("Creating vrf red failed with err ", err)
+	}
+	if _, err := server.ProcessVrfCreateConfig(&ribdInt.Vrf{Name: "blue", IntfList: []string{"lo3"}}); err != nil {
+		t.Fatal("Creating vrf blue failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("red", "21.1.10.0", "CONNECTED")) == 0 {
+		t.Error("Connected route of lo2 not moved to vrf red")
+	}
+	if len(getVrfTestRouteRecords(ribdCommonDefs.DEFAULT_VRF, "21.1.10.0", "CONNECTED")) != 0 {
+		t.Error("Connected route of lo2 still in the default vrf")
+	}
+	fmt.Println("************************************")
+}
+
+func TestVrfRouteCreateDelete(t *testing.T) {
+	fmt.Println("****TestVrfRouteCreateDelete****")
+	_, err := server.ProcessVrfV4RouteCreateConfig("red", vrfTestRoute("70.1.10.0", "21.1.10.2", "2"), FIBAndRIB, ribd.Int(len(destNetSlice)))
+	if err != nil {
+		t.Fatal("Creating route 70.1.10.0/24 in vrf red failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("red", "70.1.10.0", "STATIC")) != 1 {
+		t.Error("Route 70.1.10.0/24 not installed in vrf red")
+	}
+	if len(getVrfTestRouteRecords(ribdCommonDefs.DEFAULT_VRF, "70.1.10.0", "STATIC")) != 0 {
+		t.Error("Route 70.1.10.0/24 of vrf red installed in the default vrf")
+	}
+	if _, err = server.GetVrfV4RouteReachabilityInfo("red", "70.1.10.1", -1); err != nil {
+		t.Error("70.1.10.1 not reachable in vrf red, err ", err)
+	}
+	if _, err = server.GetVrfV4RouteReachabilityInfo(ribdCommonDefs.DEFAULT_VRF, "70.1.10.1", -1); err == nil {
+		t.Error("70.1.10.1 of vrf red reachable in the default vrf")
+	}
+	if getVrfTestRouteCount("red", "70.1.10.0", "STATIC") != 1 {
+		t.Error("STATIC route count of 70.1.10.0/24 in vrf red is not 1")
+	}
+	if !arpResolveCalled(NextHopInfoKey{"red", "21.1.10.2"}) {
+		t.Error("Next hop 21.1.10.2 not tracked in vrf red")
+	}
+	if arpResolveCalled(NextHopInfoKey{ribdCommonDefs.DEFAULT_VRF, "21.1.10.2"}) {
+		t.Error("Next hop 21.1.10.2 of vrf red tracked in the default vrf")
+	}
+
+	//the same prefix in the default vrf is independent of the one in vrf red
+	_, err = server.ProcessVrfV4RouteCreateConfig(ribdCommonDefs.DEFAULT_VRF, vrfTestRoute("70.1.10.0", "11.1.10.2", "1"), FIBAndRIB, ribd.Int(len(destNetSlice)))
+	if err != nil {
+		t.Fatal("Creating route 70.1.10.0/24 in the default vrf failed with err ", err)
+	}
+	if getVrfTestRouteCount(ribdCommonDefs.DEFAULT_VRF, "70.1.10.0", "STATIC") != 1 {
+		t.Error("STATIC route count of 70.1.10.0/24 in the default vrf is not 1")
+	}
+	_, err = server.ProcessVrfV4RouteDeleteConfig("red", vrfTestRoute("70.1.10.0", "21.1.10.2", "2"), FIBAndRIB)
+	if err != nil {
+		t.Error("Deleting route 70.1.10.0/24 in vrf red failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("red", "70.1.10.0", "STATIC")) != 0 {
+		t.Error("Route 70.1.10.0/24 still in vrf red after delete")
+	}
+	if getVrfTestRouteCount("red", "70.1.10.0", "STATIC") != 0 {
+		t.Error("STATIC route count of 70.1.10.0/24 in vrf red not cleared on delete")
+	}
+	if arpResolveCalled(NextHopInfoKey{"red", "21.1.10.2"}) {
+		t.Error("Next hop 21.1.10.2 still tracked in vrf red after delete")
+	}
+	records := getVrfTestRouteRecords(ribdCommonDefs.DEFAULT_VRF, "70.1.10.0", "STATIC")
+	if len(records) != 1 || records[0].nextHopIp.String() != "11.1.10.2" {
+		t.Error("Route 70.1.10.0/24 of the default vrf changed by the delete in vrf red, records:", records)
+	}
+	if getVrfTestRouteCount(ribdCommonDefs.DEFAULT_VRF, "70.1.10.0", "STATIC") != 1 {
+		t.Error("STATIC route count of 70.1.10.0/24 in the default vrf changed by the delete in vrf red")
+	}
+	_, err = server.ProcessVrfV4RouteDeleteConfig(ribdCommonDefs.DEFAULT_VRF, vrfTestRoute("70.1.10.0", "11.1.10.2", "1"), FIBAndRIB)
+	if err != nil {
+		t.Error("Deleting route 70.1.10.0/24 in the default vrf failed with err ", err)
+	}
+	fmt.Println("************************************")
+}
+
+func TestVrfRouteLeakPolicy(t *testing.T) {
+	fmt.Println("****TestVrfRouteLeakPolicy****")
+	for _, cond := range vrfLeakConditionsList {
+		if _, err := server.ProcessPolicyConditionConfigCreate(cond, PolicyEngineDB); err != nil {
+			t.Fatal("Creating condition ", cond.Name, " failed with err ", err)
+		}
+	}
+	for _, stmt := range vrfLeakStmtsList {
+		if err := server.ProcessPolicyStmtConfigCreate(stmt, PolicyEngineDB); err != nil {
+			t.Fatal("Creating statement ", stmt.Name, " failed with err ", err)
+		}
+	}
+	if err := server.ProcessPolicyDefinitionConfigCreate(vrfLeakPolicyDefinition, PolicyEngineDB); err != nil {
+		t.Fatal("Creating policy ", vrfLeakPolicyDefinition.Name, " failed with err ", err)
+	}
+	for _, destNet := range []string{"70.1.10.0", "80.1.10.0"} {
+		_, err := server.ProcessVrfV4RouteCreateConfig("red", vrfTestRoute(destNet, "21.1.10.2", "2"), FIBAndRIB, ribd.Int(len(destNetSlice)))
+		if err != nil {
+			t.Fatal("Creating route ", destNet, " in vrf red failed with err ", err)
+		}
+	}
+	_, err := server.ProcessVrfRouteLeakCreateConfig(&ribdInt.VrfRouteLeak{SrcVrf: "red", DstVrf: "blue", Policy: "leakRed"})
+	if err != nil {
+		t.Fatal("Creating route leak from vrf red to vrf blue failed with err ", err)
+	}
+	records := getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")
+	if len(records) != 1 || records[0].leakedFrom != "red" {
+		t.Error("Route 70.1.10.0/24 permitted by the leak policy not leaked into vrf blue, records:", records)
+	}
+	if len(getVrfTestRouteRecords("blue", "80.1.10.0", "STATIC")) != 0 {
+		t.Error("Route 80.1.10.0/24 denied by the leak policy leaked into vrf blue")
+	}
+	if _, err = server.GetVrfV4RouteReachabilityInfo("blue", "70.1.10.1", -1); err != nil {
+		t.Error("Leaked route 70.1.10.0/24 not reachable in vrf blue, err ", err)
+	}
+	//next hop of the leaked route resolves in the source vrf
+	if arpResolveCalled(NextHopInfoKey{"blue", "21.1.10.2"}) {
+		t.Error("Next hop 21.1.10.2 of the leaked route tracked in vrf blue")
+	}
+	if len(getVrfTestRouteRecords("blue", "21.1.10.0", "CONNECTED")) != 0 {
+		t.Error("Connected route 21.1.10.0/24 not permitted by the leak policy leaked into vrf blue")
+	}
+	//routes created after the leak are leaked as they are installed
+	_, err = server.ProcessVrfV4RouteCreateConfig("red", vrfTestRoute("70.1.10.0", "21.1.10.3", "2"), FIBAndRIB, ribd.Int(len(destNetSlice)))
+	if err != nil {
+		t.Error("Creating ecmp route 70.1.10.0/24 in vrf red failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")) != 2 {
+		t.Error("Ecmp path of 70.1.10.0/24 not leaked into vrf blue, records:", getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC"))
+	}
+	fmt.Println("************************************")
+}
+
+func TestVrfRouteLeakWithdraw(t *testing.T) {
+	fmt.Println("****TestVrfRouteLeakWithdraw****")
+	_, err := server.ProcessVrfV4RouteDeleteConfig("red", vrfTestRoute("70.1.10.0", "21.1.10.3", "2"), FIBAndRIB)
+	if err != nil {
+		t.Error("Deleting ecmp route 70.1.10.0/24 in vrf red failed with err ", err)
+	}
+	records := getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")
+	if len(records) != 1 || records[0].nextHopIp.String() != "21.1.10.2" {
+		t.Error("Leaked ecmp path of 70.1.10.0/24 not withdrawn from vrf blue, records:", records)
+	}
+	//leaked routes are withdrawn by the route leak delete, the source vrf keeps its routes
+	_, err = server.ProcessVrfRouteLeakDeleteConfig(&ribdInt.VrfRouteLeak{SrcVrf: "red", DstVrf: "blue"})
+	if err != nil {
+		t.Fatal("Deleting route leak from vrf red to vrf blue failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")) != 0 {
+		t.Error("Leaked route 70.1.10.0/24 not withdrawn from vrf blue on leak delete")
+	}
+	if getVrfTestRouteCount("blue", "70.1.10.0", "STATIC") != 0 {
+		t.Error("STATIC route count of 70.1.10.0/24 in vrf blue not cleared on leak delete")
+	}
+	if len(getVrfTestRouteRecords("red", "70.1.10.0", "STATIC")) != 1 {
+		t.Error("Route 70.1.10.0/24 removed from vrf red on leak delete")
+	}
+	//leaked routes are withdrawn with the route in the source vrf
+	_, err = server.ProcessVrfRouteLeakCreateConfig(&ribdInt.VrfRouteLeak{SrcVrf: "red", DstVrf: "blue", Policy: "leakRed"})
+	if err != nil {
+		t.Fatal("Creating route leak from vrf red to vrf blue failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")) != 1 {
+		t.Error("Route 70.1.10.0/24 not leaked into vrf blue on leak create")
+	}
+	_, err = server.ProcessVrfV4RouteDeleteConfig("red", vrfTestRoute("70.1.10.0", "21.1.10.2", "2"), FIBAndRIB)
+	if err != nil {
+		t.Error("Deleting route 70.1.10.0/24 in vrf red failed with err ", err)
+	}
+	if len(getVrfTestRouteRecords("blue", "70.1.10.0", "STATIC")) != 0 {
+		t.Error("Leaked route 70.1.10.0/24 not withdrawn from vrf blue on delete in vrf red")
+	}
+	if _, err = server.GetVrfV4RouteReachabilityInfo("blue", "70.1.10.1", -1); err == nil {
+		t.Error("Withdrawn route 70.1.10.0/24 reachable in vrf blue")
+	}
+	fmt.Println("************************************")
+}
+
+func TestVrfDelete(t *testing.T) {
+	fmt.Println("****TestVrfDelete****")
+	for _, name := range []string{"blue", "red"} {
+		if _, err := server.ProcessVrfDeleteConfig(&ribdInt.Vrf{Name: name}); err != nil {
+			t.Error("Deleting vrf ", name, " failed with err ", err)
+		}
+		if _, ok := ProtocolRouteMap[name]; ok {
+			t.Error("Route counts of vrf ", name, " not removed with the vrf")
+		}
+		if _, ok := InterfaceRouteMap[name]; ok {
+			t.Error("Interface route counts of vrf ", name, " not removed with the vrf")
+		}
+	}
+	if len(getVrfTestRouteRecords(ribdCommonDefs.DEFAULT_VRF, "21.1.10.0", "CONNECTED")) == 0 {
+		t.Error("Connected route of lo2 not moved back to the default vrf")
+	}
+	if len(getVrfTestRouteRecords(ribdCommonDefs.DEFAULT_VRF, "80.1.10.0", "STATIC")) != 0 {
+		t.Error("Route 80.1.10.0/24 of vrf red in the default vrf after the vrf delete")
+	}
+	fmt.Println("************************************")
+}
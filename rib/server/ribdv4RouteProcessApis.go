@@ -47,7 +47,14 @@ var V4RouteInfoMap *patriciaDB.Trie //Routes are stored in patricia trie
    Returns the longest prefix match route to reach the destination network destNet
 */
 func (m RIBDServer) GetV4RouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
-	logger.Debug("GetV4RouteReachabilityInfo of ", destNet, " ifIndex:", ifIndex)
+	return m.GetVrfV4RouteReachabilityInfo(ribdCommonDefs.DEFAULT_VRF, destNet, ifIndex)
+}
+
+/*
+   Returns the longest prefix match route to reach destNet in the ipv4 route table of vrf
+*/
+func (m RIBDServer) GetVrfV4RouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	logger.Debug("GetV4RouteReachabilityInfo of ", destNet, " ifIndex:", ifIndex, " vrf:", vrf)
 	//t1 := time.Now()
 	var retnextHopIntf ribdInt.NextHopInfo
 	nextHopIntf = &retnextHopIntf
@@ -63,7 +70,11 @@ func (m RIBDServer) GetV4RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		return nextHopIntf, errors.New("Incorrect ip type lookup")
 	}
 	destNetIp = lookupIp
-	rmapInfoListItem := V4RouteInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
+	routeInfoMap := getVrfRouteInfoMap(vrf, ribdCommonDefs.IPv4)
+	if routeInfoMap == nil {
+		return nextHopIntf, errors.New(fmt.Sprintln("vrf ", vrf, " not configured"))
+	}
+	rmapInfoListItem := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
 	if rmapInfoListItem != nil {
 		//fmt.Println("Madhavi!! GetV4RouteReachabilityInfo:, rmapInfoList not nil for ", destNetIp)
 		rmapInfoList := rmapInfoListItem.(RouteInfoRecordList)
@@ -119,6 +130,11 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 		return err
 	}
 	routeReachabilityStatusInfo := item.(RouteReachabilityStatusInfo)
+	routeInfoMap := getVrfRouteInfoMap(routeReachabilityStatusInfo.vrf, ribdCommonDefs.IPv4)
+	if routeInfoMap == nil {
+		logger.Err("route table for vrf ", routeReachabilityStatusInfo.vrf, " not found")
+		return err
+	}
 	var ipMask net.IP
 	ip, ipNet, err := net.ParseCIDR(routeReachabilityStatusInfo.destNet)
 	if err != nil {
@@ -158,7 +174,7 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 				if routeReachabilityStatusInfo.status == "Down" && v[i].resolvedNextHopIpIntf.IsReachable == true {
 					v[i].resolvedNextHopIpIntf.IsReachable = false
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V4RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 1")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
@@ -166,34 +182,34 @@ func UpdateV4RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
 					//logger.Debug("Bringing down route : ip: ", v[i].networkAddr)
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+						routeInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					}
 				} else if routeReachabilityStatusInfo.status == "Up" && v[i].resolvedNextHopIpIntf.IsReachable == false {
 					//logger.Debug("Bringing up route : ip: ", v[i].networkAddr)
 					v[i].resolvedNextHopIpIntf.IsReachable = true
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V4RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 2")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
 						Op:               "add",
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V4RouteInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+						routeInfoMap.VisitAndUpdate(UpdateV4RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					}
 				}
 			}
@@ -544,9 +560,9 @@ func (m RIBDServer) RouteConfigValidationCheck(cfg *ribd.IPv4Route, op string) (
 	}
 	return nil
 }
-func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
+func Getv4RoutesPerProtocol(vrf string, protocol string) []*ribd.RouteInfoSummary {
 	routes := make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := ProtocolRouteMap[protocol]
+	routemapInfo := ProtocolRouteMap[getVrfName(vrf)][protocol]
 	if routemapInfo.v4routeMap == nil {
 		return routes
 	}
@@ -554,7 +570,7 @@ func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 		if val.totalcount == 0 {
 			continue
 		}
-		v4Item := V4RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v4Item := RouteInfoMapGet(vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(destNetIp))
 		if v4Item == nil {
 			continue
 		}
@@ -593,9 +609,9 @@ func Getv4RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 	}
 	return routes
 }
-func Getv4RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary {
+func Getv4RoutesPerInterface(vrf string, intfref string) []string { //*ribd.RouteInfoSummary {
 	routes := make([]string, 0) //[]*ribd.RouteInfoSummary, 0)
-	routemapInfo := InterfaceRouteMap[intfref]
+	routemapInfo := InterfaceRouteMap[getVrfName(vrf)][intfref]
 	if routemapInfo.v4routeMap == nil {
 		return routes
 	}
@@ -603,7 +619,7 @@ func Getv4RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary
 		if val.totalcount == 0 {
 			continue
 		}
-		v4Item := V4RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v4Item := RouteInfoMapGet(vrf, ribdCommonDefs.IPv4, patriciaDB.Prefix(destNetIp))
 		if v4Item == nil {
 			continue
 		}
@@ -674,6 +690,10 @@ func (m RIBDServer) GetBulkIPv4RouteState(fromIndex ribd.Int, rcount ribd.Int) (
 			//logger.Debug("Enough routes fetched")
 			break
 		}
+		if destNetSlice[i+fromIndex].vrf != ribdCommonDefs.DEFAULT_VRF {
+			//routes in non default vrfs are fetched using GetBulkVrfState
+			continue
+		}
 		prefixNode := V4RouteInfoMap.Get(destNetSlice[i+fromIndex].prefix)
 		if prefixNode != nil {
 			prefixNodeRouteList = prefixNode.(RouteInfoRecordList)
@@ -826,7 +846,27 @@ func (m RIBDServer) Getv4RouteCreatedTime(number int) (time string, err error) {
 }
 
 func (m RIBDServer) ProcessV4RouteCreateConfig(cfg *ribd.IPv4Route, addType int, sliceIdx ribd.Int) (val bool, err error) {
-	logger.Debug("ProcessV4RouteCreateConfig: Received create route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " number of next hops: ", len(cfg.NextHop), " null Route:", cfg.NullRoute, " sliceIdx:", sliceIdx)
+	return m.ProcessVrfV4RouteCreateConfig(ribdCommonDefs.DEFAULT_VRF, cfg, addType, sliceIdx)
+}
+
+func (m RIBDServer) ProcessVrfV4RouteCreateConfig(vrf string, cfg *ribd.IPv4Route, addType int, sliceIdx ribd.Int) (val bool, err error) {
+	return m.ProcessVrfV4LabeledRouteCreateConfig(vrf, cfg, nil, addType, sliceIdx)
+}
+
+/*
+   labels holds the vpn label stack for each next hop ip of a route imported from a vpn
+*/
+func (m RIBDServer) ProcessVrfV4LabeledRouteCreateConfig(vrf string, cfg *ribd.IPv4Route, labels map[string][]int32, addType int, sliceIdx ribd.Int) (val bool, err error) {
+	logger.Debug("ProcessV4RouteCreateConfig: Received create route request for ip ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " vrf ", vrf, " number of next hops: ", len(cfg.NextHop), " null Route:", cfg.NullRoute, " sliceIdx:", sliceIdx)
+	vrf = getVrfName(vrf)
+	if cfg.Protocol == "CONNECTED" && len(cfg.NextHop) > 0 {
+		//connected routes are installed in the vrf the interface is bound to
+		vrf = getIntfRefVrf(cfg.NextHop[0].NextHopIntRef)
+	}
+	if getVrfRouteInfoMap(vrf, ribdCommonDefs.IPv4) == nil {
+		logger.Err("vrf ", vrf, " not configured")
+		return false, errors.New(fmt.Sprintln("vrf ", vrf, " not configured"))
+	}
 	newCfg := ribd.IPv4Route{
 		DestinationNw: cfg.DestinationNw,
 		NetworkMask:   cfg.NetworkMask,
@@ -845,8 +885,11 @@ func (m RIBDServer) ProcessV4RouteCreateConfig(cfg *ribd.IPv4Route, addType int,
 		newCfg.NextHop = append(newCfg.NextHop, &nh)
 		//policyRoute := BuildPolicyRouteFromribdIPv4Route(&newCfg)
 		params := BuildRouteParamsFromribdIPv4Route(&newCfg, addType, Invalid, sliceIdx)
+		params.vrf = vrf
+		params.labels = labels[nh.NextHopIp]
 		_, err = createRoute(params)
 	}
+	m.updateV4BackupNextHops(vrf, cfg)
 
 	return true, err
 }
@@ -890,11 +933,20 @@ func (m RIBDServer) ProcessBulkRouteCreateConfig(bulkCfg []*ribdInt.IPv4RouteCon
 }
 
 func (m RIBDServer) ProcessV4RouteDeleteConfig(cfg *ribd.IPv4Route, delType int) (val bool, err error) {
-	logger.Debug("ProcessV4RouteDeleteConfig:Received Route Delete request for ", cfg.DestinationNw, ":", cfg.NetworkMask, "number of nextHops:", len(cfg.NextHop), "Protocol ", cfg.Protocol)
+	return m.ProcessVrfV4RouteDeleteConfig(ribdCommonDefs.DEFAULT_VRF, cfg, delType)
+}
+
+func (m RIBDServer) ProcessVrfV4RouteDeleteConfig(vrf string, cfg *ribd.IPv4Route, delType int) (val bool, err error) {
+	logger.Debug("ProcessV4RouteDeleteConfig:Received Route Delete request for ", cfg.DestinationNw, ":", cfg.NetworkMask, " vrf ", vrf, "number of nextHops:", len(cfg.NextHop), "Protocol ", cfg.Protocol)
 	if !RouteServiceHandler.AcceptConfig {
 		logger.Debug("Not ready to accept config")
 		//return 0,err
 	}
+	vrf = getVrfName(vrf)
+	if cfg.Protocol == "CONNECTED" && len(cfg.NextHop) > 0 {
+		vrf = getIntfRefVrf(cfg.NextHop[0].NextHopIntRef)
+	}
+	m.deleteV4BackupNextHops(vrf, cfg)
 	var nextHopIfIndex ribd.Int
 	for i := 0; i < len(cfg.NextHop); i++ {
 		if cfg.NullRoute == true { //commonDefs.IfTypeNull {
@@ -912,7 +964,7 @@ func (m RIBDServer) ProcessV4RouteDeleteConfig(cfg *ribd.IPv4Route, delType int)
 			nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
 			nextHopIfIndex = ribd.Int(nextHopIntRef)
 		}
-		_, err = deleteIPRoute(cfg.DestinationNw, ribdCommonDefs.IPv4, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		_, err = deleteIPRoute(vrf, cfg.DestinationNw, ribdCommonDefs.IPv4, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), ribdCommonDefs.RoutePolicyStateChangetoInValid)
 	}
 	return true, err
 }
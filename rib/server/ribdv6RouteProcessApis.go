@@ -25,6 +25,13 @@ var V6RouteInfoMap *patriciaDB.Trie //Routes are stored in patricia trie
    Returns the longest prefix match route to reach the destination network destNet
 */
 func (m RIBDServer) GetV6RouteReachabilityInfo(destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
+	return m.GetVrfV6RouteReachabilityInfo(ribdCommonDefs.DEFAULT_VRF, destNet, ifIndex)
+}
+
+/*
+   Returns the longest prefix match route to reach destNet in the ipv6 route table of vrf
+*/
+func (m RIBDServer) GetVrfV6RouteReachabilityInfo(vrf string, destNet string, ifIndex ribdInt.Int) (nextHopIntf *ribdInt.NextHopInfo, err error) {
 	//logger.Debug("GetRouteReachabilityInfo of ", destNet)
 	//t1 := time.Now()
 	var retnextHopIntf ribdInt.NextHopInfo
@@ -41,7 +48,11 @@ func (m RIBDServer) GetV6RouteReachabilityInfo(destNet string, ifIndex ribdInt.I
 		return nextHopIntf, errors.New("Invalid dest ip address")
 	}
 	destNetIp = lookupIp
-	rmapInfoListItem := V6RouteInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
+	routeInfoMap := getVrfRouteInfoMap(vrf, ribdCommonDefs.IPv6)
+	if routeInfoMap == nil {
+		return nextHopIntf, errors.New(fmt.Sprintln("vrf ", vrf, " not configured"))
+	}
+	rmapInfoListItem := routeInfoMap.GetLongestPrefixNode(patriciaDB.Prefix(destNetIp))
 	if rmapInfoListItem != nil {
 		rmapInfoList := rmapInfoListItem.(RouteInfoRecordList)
 		if rmapInfoList.selectedRouteProtocol != "INVALID" {
@@ -92,6 +103,11 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 		return err
 	}
 	routeReachabilityStatusInfo := item.(RouteReachabilityStatusInfo)
+	routeInfoMap := getVrfRouteInfoMap(routeReachabilityStatusInfo.vrf, ribdCommonDefs.IPv6)
+	if routeInfoMap == nil {
+		logger.Err("route table for vrf ", routeReachabilityStatusInfo.vrf, " not found")
+		return err
+	}
 	var ipMask net.IP
 	ip, ipNet, err := net.ParseCIDR(routeReachabilityStatusInfo.destNet)
 	if err != nil {
@@ -131,7 +147,7 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 				if routeReachabilityStatusInfo.status == "Down" && v[i].resolvedNextHopIpIntf.IsReachable == true {
 					v[i].resolvedNextHopIpIntf.IsReachable = false
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V6RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 1")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
@@ -139,34 +155,34 @@ func UpdateV6RouteReachabilityStatus(prefix patriciaDB.Prefix, //prefix of the n
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
 					//logger.Debug("Bringing down route : ip: ", v[i].networkAddr)
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf})
+						routeInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Down", k, nextHopIntf, v[i].vrf})
 					}
 				} else if routeReachabilityStatusInfo.status == "Up" && v[i].resolvedNextHopIpIntf.IsReachable == false {
 					//logger.Debug("Bringing up route : ip: ", v[i].networkAddr)
 					v[i].resolvedNextHopIpIntf.IsReachable = true
 					rmapInfoRecordList.routeInfoProtocolMap[k] = v
-					V6RouteInfoMap.Set(prefix, rmapInfoRecordList)
+					routeInfoMap.Set(prefix, rmapInfoRecordList)
 					//logger.Debug("Adding to DBRouteCh from updateRouteReachability case 2")
 					RouteServiceHandler.DBRouteCh <- RIBdServerConfig{
 						OrigConfigObject: RouteDBInfo{v[i], rmapInfoRecordList},
 						Op:               "add",
 					}
 					//RouteServiceHandler.WriteIPv4RouteStateEntryToDB(RouteDBInfo{v[i], rmapInfoRecordList})
-					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+					RouteReachabilityStatusUpdate(k, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					/*
 					   The reachability status for this network has been updated, now check if there are routes dependent on
 					   this prefix and call reachability status
 					*/
-					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{string(prefix)}].refCount > 0 {
+					if RouteServiceHandler.NextHopInfoMap[NextHopInfoKey{getVrfName(v[i].vrf), string(prefix)}].refCount > 0 {
 						//logger.Debug("There are dependent routes for this ip ", v[i].networkAddr)
-						V6RouteInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf})
+						routeInfoMap.VisitAndUpdate(UpdateV6RouteReachabilityStatus, RouteReachabilityStatusInfo{v[i].networkAddr, v[i].ipType, "Up", k, nextHopIntf, v[i].vrf})
 					}
 				}
 			}
@@ -534,9 +550,9 @@ func (m RIBDServer) IPv6RouteConfigValidationCheck(cfg *ribd.IPv6Route, op strin
 func (m RIBDServer) GetTotalv6RouteCount() (number int, err error) {
 	return v6rtCount, err
 }
-func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
+func Getv6RoutesPerProtocol(vrf string, protocol string) []*ribd.RouteInfoSummary {
 	v6routes := make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := ProtocolRouteMap[protocol]
+	routemapInfo := ProtocolRouteMap[getVrfName(vrf)][protocol]
 	if routemapInfo.v6routeMap == nil {
 		return v6routes
 	}
@@ -544,7 +560,7 @@ func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 		if val.totalcount == 0 {
 			continue
 		}
-		v6Item := V6RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v6Item := RouteInfoMapGet(vrf, ribdCommonDefs.IPv6, patriciaDB.Prefix(destNetIp))
 		if v6Item == nil {
 			continue
 		}
@@ -582,9 +598,9 @@ func Getv6RoutesPerProtocol(protocol string) []*ribd.RouteInfoSummary {
 	}
 	return v6routes
 }
-func Getv6RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary {
+func Getv6RoutesPerInterface(vrf string, intfref string) []string { //*ribd.RouteInfoSummary {
 	v6routes := make([]string, 0) //make([]*ribd.RouteInfoSummary, 0)
-	routemapInfo := InterfaceRouteMap[intfref]
+	routemapInfo := InterfaceRouteMap[getVrfName(vrf)][intfref]
 	if routemapInfo.v6routeMap == nil {
 		return v6routes
 	}
@@ -592,7 +608,7 @@ func Getv6RoutesPerInterface(intfref string) []string { //*ribd.RouteInfoSummary
 		if val.totalcount == 0 {
 			continue
 		}
-		v6Item := V6RouteInfoMap.Get(patriciaDB.Prefix(destNetIp))
+		v6Item := RouteInfoMapGet(vrf, ribdCommonDefs.IPv6, patriciaDB.Prefix(destNetIp))
 		if v6Item == nil {
 			continue
 		}
@@ -698,7 +714,27 @@ func (m RIBDServer) Getv6Route(destNetIp string) (route *ribdInt.IPv6RouteState,
 }
 
 func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int, sliceIdx ribd.Int) (val bool, err error) {
-	logger.Debug("ProcessV6RouteCreate: Received create route request for ip: ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " number of next hops: ", len(cfg.NextHop), " sliceIdx:", sliceIdx)
+	return m.ProcessVrfV6RouteCreateConfig(ribdCommonDefs.DEFAULT_VRF, cfg, addType, sliceIdx)
+}
+
+func (m RIBDServer) ProcessVrfV6RouteCreateConfig(vrf string, cfg *ribd.IPv6Route, addType int, sliceIdx ribd.Int) (val bool, err error) {
+	return m.ProcessVrfV6LabeledRouteCreateConfig(vrf, cfg, nil, addType, sliceIdx)
+}
+
+/*
+   labels holds the vpn label stack for each next hop ip of a route imported from a vpn
+*/
+func (m RIBDServer) ProcessVrfV6LabeledRouteCreateConfig(vrf string, cfg *ribd.IPv6Route, labels map[string][]int32, addType int, sliceIdx ribd.Int) (val bool, err error) {
+	logger.Debug("ProcessV6RouteCreate: Received create route request for ip: ", cfg.DestinationNw, " mask ", cfg.NetworkMask, " vrf ", vrf, " number of next hops: ", len(cfg.NextHop), " sliceIdx:", sliceIdx)
+	vrf = getVrfName(vrf)
+	if cfg.Protocol == "CONNECTED" && len(cfg.NextHop) > 0 {
+		//connected routes are installed in the vrf the interface is bound to
+		vrf = getIntfRefVrf(cfg.NextHop[0].NextHopIntRef)
+	}
+	if getVrfRouteInfoMap(vrf, ribdCommonDefs.IPv6) == nil {
+		logger.Err("vrf ", vrf, " not configured")
+		return false, errors.New(fmt.Sprintln("vrf ", vrf, " not configured"))
+	}
 	newCfg := ribd.IPv6Route{
 		DestinationNw: cfg.DestinationNw,
 		NetworkMask:   cfg.NetworkMask,
@@ -719,6 +755,8 @@ func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int,
 
 	//	policyRoute := BuildPolicyRouteFromribdIPv6Route(&newCfg)
 	params := BuildRouteParamsFromribdIPv6Route(&newCfg, addType, Invalid, sliceIdx)
+	params.vrf = vrf
+	params.labels = labels[newCfg.NextHop[0].NextHopIp]
 
 	logger.Debug("createType = ", params.createType, "deleteType = ", params.deleteType)
 	//	PolicyEngineFilter(policyRoute, policyCommonDefs.PolicyPath_Import, params)
@@ -728,11 +766,19 @@ func (m RIBDServer) ProcessV6RouteCreateConfig(cfg *ribd.IPv6Route, addType int,
 }
 
 func (m RIBDServer) ProcessV6RouteDeleteConfig(cfg *ribd.IPv6Route, delType int) (val bool, err error) {
-	logger.Debug("ProcessRoutev6DeleteConfig:Received Route Delete request for ", cfg.DestinationNw, ":", cfg.NetworkMask, "number of nextHops:", len(cfg.NextHop), "Protocol ", cfg.Protocol)
+	return m.ProcessVrfV6RouteDeleteConfig(ribdCommonDefs.DEFAULT_VRF, cfg, delType)
+}
+
+func (m RIBDServer) ProcessVrfV6RouteDeleteConfig(vrf string, cfg *ribd.IPv6Route, delType int) (val bool, err error) {
+	logger.Debug("ProcessRoutev6DeleteConfig:Received Route Delete request for ", cfg.DestinationNw, ":", cfg.NetworkMask, " vrf ", vrf, "number of nextHops:", len(cfg.NextHop), "Protocol ", cfg.Protocol)
 	if !RouteServiceHandler.AcceptConfig {
 		logger.Debug("Not ready to accept config")
 		//return 0,err
 	}
+	vrf = getVrfName(vrf)
+	if cfg.Protocol == "CONNECTED" && len(cfg.NextHop) > 0 {
+		vrf = getIntfRefVrf(cfg.NextHop[0].NextHopIntRef)
+	}
 	var nextHopIfIndex ribd.Int
 	for i := 0; i < len(cfg.NextHop); i++ {
 		nextHopIfIndex = -1
@@ -750,7 +796,7 @@ func (m RIBDServer) ProcessV6RouteDeleteConfig(cfg *ribd.IPv6Route, delType int)
 		}
 		nextHopIntRef, _ := strconv.Atoi(cfg.NextHop[i].NextHopIntRef)
 		nextHopIfIndex = ribd.Int(nextHopIntRef)
-		_, err = deleteIPRoute(cfg.DestinationNw, ribdCommonDefs.IPv6, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), ribdCommonDefs.RoutePolicyStateChangetoInValid)
+		_, err = deleteIPRoute(vrf, cfg.DestinationNw, ribdCommonDefs.IPv6, cfg.NetworkMask, cfg.Protocol, cfg.NextHop[i].NextHopIp, nextHopIfIndex, ribd.Int(delType), ribdCommonDefs.RoutePolicyStateChangetoInValid)
 	}
 	return true, err
 }
@@ -90,6 +90,7 @@ func GetClientPort(paramsFile string, c string) int {
 // 2) ribd - provision: next hop ip retreival
 //           notifications: next hop reachability changes
 // 3) arpd - provision: resolve next hop ip
+// 4) bgpd - notifications: remote vteps and macs learnt via evpn
 func (intf VXLANSnapClient) ConnectToClients(clientFile string) {
 	allclientsconnect := 0
 	clientList := [3]string{"asicd", "ribd", "arpd"}
@@ -141,6 +142,8 @@ func (intf VXLANSnapClient) ConnectToClients(clientFile string) {
 	go intf.createRIBdSubscriber()
 	// need to listen for por vlan membership notifications
 	go intf.createASICdSubscriber()
+	// need to listen for remote vteps and macs learnt via evpn
+	go intf.createBGPdSubscriber()
 }
 
 func asicDGetLoopbackInfo() (success bool, lbname string, mac net.HardwareAddr, ip net.IP) {
@@ -199,3 +202,13 @@ func asicDLearnFwdDbEntry(mac net.HardwareAddr, vtepName string, ifindex int32)
 	}
 	*/
 }
+
+func asicDFlushFwdDbEntry(mac net.HardwareAddr, vtepName string, ifindex int32) {
+	//macstr := mac.String()
+	/* Add as another interface
+	// run standalone
+	if softswitch != nil {
+		softswitch.FlushFdbVtep(macstr, vtepName, ifindex)
+	}
+	*/
+}
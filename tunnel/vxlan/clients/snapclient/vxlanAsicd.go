@@ -503,6 +503,20 @@ func (intf VXLANSnapClient) DeleteVtep(vtep *vxlan.VtepDbEntry) {
 	}
 }
 
+// CreateFdbEntry:
+// Install a remote mac learnt via evpn against the VTEP interface
+func (intf VXLANSnapClient) CreateFdbEntry(vtep *vxlan.VtepDbEntry, mac net.HardwareAddr) {
+	logger.Info(fmt.Sprintln("Create fdb entry", mac, vtep.VtepName))
+	asicDLearnFwdDbEntry(mac, vtep.VtepName, vtep.VtepIfIndex)
+}
+
+// DeleteFdbEntry:
+// Remove a remote mac learnt via evpn from the VTEP interface
+func (intf VXLANSnapClient) DeleteFdbEntry(vtep *vxlan.VtepDbEntry, mac net.HardwareAddr) {
+	logger.Info(fmt.Sprintln("Delete fdb entry", mac, vtep.VtepName))
+	asicDFlushFwdDbEntry(mac, vtep.VtepName, vtep.VtepIfIndex)
+}
+
 func (intf VXLANSnapClient) GetIntfInfo(IfName string, intfchan chan<- vxlan.MachineEvent) {
 	// TODO
 	nextindex := 0
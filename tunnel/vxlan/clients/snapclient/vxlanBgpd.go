@@ -0,0 +1,106 @@
+package snapclient
+
+import (
+	"encoding/json"
+	"fmt"
+	nanomsg "github.com/op/go-nanomsg"
+	"l3/bgp/bgpdCommonDefs"
+	vxlan "l3/tunnel/vxlan/protocol"
+	"net"
+)
+
+// createBGPdSubscriber
+// bgpd publishes the remote vteps and macs which are learnt from evpn routes
+func (intf VXLANSnapClient) createBGPdSubscriber() error {
+	logger.Info("Listen for BGPd updates")
+	address := bgpdCommonDefs.PUB_SOCKET_ADDR
+	var err error
+	if intf.bgpdSubSocket, err = nanomsg.NewSubSocket(); err != nil {
+		logger.Err(fmt.Sprintln("Failed to create BGPd subscribe socket, error:", err))
+		return err
+	}
+
+	if _, err = intf.bgpdSubSocket.Connect(address); err != nil {
+		logger.Err(fmt.Sprintln("Failed to connect to BGPd publisher socket, address:", address, "error:", err))
+		return err
+	}
+
+	if err = intf.bgpdSubSocket.Subscribe(""); err != nil {
+		logger.Err(fmt.Sprintln("Failed to subscribe to \"\" on BGPd subscribe socket, error:", err))
+		return err
+	}
+
+	logger.Info(fmt.Sprintln("Connected to BGPd publisher at address:", address))
+	if err = intf.bgpdSubSocket.SetRecvBuffer(1024 * 1024); err != nil {
+		logger.Err(fmt.Sprintln("Failed to set the buffer size for BGPd publisher socket, error:", err))
+		return err
+	}
+	for {
+		rxBuf, err := intf.bgpdSubSocket.Recv(0)
+		if err != nil {
+			logger.Err(fmt.Sprintln("Recv on BGPd subscriber socket failed with error:", err))
+			intf.bgpdSubSocketErrCh <- err
+			continue
+		}
+		intf.bgpdSubSocketCh <- rxBuf
+	}
+	return nil
+}
+
+// processBgpdNotification:
+// Processes the evpn notifications from bgpd
+// 1) Vtep create/delete - inclusive multicast routes from the remote vteps
+// 2) Mac create/delete - mac/ip advertisement routes from the remote vteps
+func (intf VXLANSnapClient) processBgpdNotification(rxBuf []byte) error {
+	var msg bgpdCommonDefs.BGPdNotification
+	err := json.Unmarshal(rxBuf, &msg)
+	if err != nil {
+		logger.Err(fmt.Sprintln("Unable to unmarshal rxBuf:", rxBuf))
+		return err
+	}
+	switch msg.MsgType {
+	case bgpdCommonDefs.NOTIFY_EVPN_VTEP_CREATE, bgpdCommonDefs.NOTIFY_EVPN_VTEP_DELETE:
+		var vtepMsg bgpdCommonDefs.EVPNVtepNotifyMsg
+		err = json.Unmarshal(msg.Msg, &vtepMsg)
+		if err != nil {
+			logger.Err(fmt.Sprintln("Unable to unmarshal evpnVtepNotifyMsg:", msg.Msg))
+			return err
+		}
+		logger.Info(fmt.Sprintln("Received EVPN vtep notification", msg.MsgType, vtepMsg))
+		command := vxlan.VxlanCommandCreate
+		if msg.MsgType == bgpdCommonDefs.NOTIFY_EVPN_VTEP_DELETE {
+			command = vxlan.VxlanCommandDelete
+		}
+		serverchannels.EVPNVtepUpdate <- vxlan.EVPNVtepConfig{
+			Command:  command,
+			Vni:      vtepMsg.Vni,
+			RemoteIp: net.ParseIP(vtepMsg.RemoteIp),
+		}
+
+	case bgpdCommonDefs.NOTIFY_EVPN_MAC_CREATE, bgpdCommonDefs.NOTIFY_EVPN_MAC_DELETE:
+		var macMsg bgpdCommonDefs.EVPNMacNotifyMsg
+		err = json.Unmarshal(msg.Msg, &macMsg)
+		if err != nil {
+			logger.Err(fmt.Sprintln("Unable to unmarshal evpnMacNotifyMsg:", msg.Msg))
+			return err
+		}
+		logger.Info(fmt.Sprintln("Received EVPN mac notification", msg.MsgType, macMsg))
+		mac, err := net.ParseMAC(macMsg.MacAddr)
+		if err != nil {
+			logger.Err(fmt.Sprintln("Invalid mac in evpnMacNotifyMsg:", macMsg.MacAddr))
+			return err
+		}
+		command := vxlan.VxlanCommandCreate
+		if msg.MsgType == bgpdCommonDefs.NOTIFY_EVPN_MAC_DELETE {
+			command = vxlan.VxlanCommandDelete
+		}
+		serverchannels.EVPNMacUpdate <- vxlan.EVPNMacConfig{
+			Command:  command,
+			Vni:      macMsg.Vni,
+			Mac:      mac,
+			Ip:       net.ParseIP(macMsg.IpAddr),
+			RemoteIp: net.ParseIP(macMsg.RemoteIp),
+		}
+	}
+	return nil
+}
@@ -28,6 +28,9 @@ type VXLANClientIntf interface {
 	UpdateAccessPorts()
 	CreateAccessPortVlan(vlan uint16, intfList []int)
 	DeleteAccessPortVlan(vlan uint16, intfList []int)
+	// remote macs learnt via evpn
+	CreateFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr)
+	DeleteFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr)
 	// vtep fsm
 	GetIntfInfo(name string, intfchan chan<- MachineEvent)
 	GetNextHopInfo(ip net.IP, nexthopchan chan<- MachineEvent)
@@ -88,6 +91,12 @@ func (b BaseClientIntf) CreateAccessPortVlan(vlan uint16, intfList []int) {
 }
 func (b BaseClientIntf) DeleteAccessPortVlan(vlan uint16, intfList []int) {
 
+}
+func (b BaseClientIntf) CreateFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+
+}
+func (b BaseClientIntf) DeleteFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+
 }
 func (b BaseClientIntf) GetNextHopInfo(ip net.IP, nexthopchan chan<- MachineEvent) {
 
@@ -51,6 +51,24 @@ type VxLanConfigChannels struct {
 	VxlanNextHopUpdate        chan VxlanNextHopIp
 	VxlanPortCreate           chan PortConfig
 	Vxlanintfinfo             chan VxlanIntfInfo
+	EVPNVtepUpdate            chan EVPNVtepConfig
+	EVPNMacUpdate             chan EVPNMacConfig
+}
+
+// remote vtep learnt from a bgp evpn inclusive multicast route
+type EVPNVtepConfig struct {
+	Command  int
+	Vni      uint32
+	RemoteIp net.IP
+}
+
+// remote mac learnt from a bgp evpn mac/ip advertisement route
+type EVPNMacConfig struct {
+	Command  int
+	Vni      uint32
+	Mac      net.HardwareAddr
+	Ip       net.IP
+	RemoteIp net.IP
 }
 
 type VxlanIntfInfo struct {
@@ -93,6 +111,8 @@ type VxlanConfig struct {
 	VlanId uint16 // used to tag inner ethernet frame when egressing
 	Group  net.IP // multicast group IP
 	MTU    uint32 // MTU size for each VTEP
+	// source interface used by the vteps which are learnt via bgp evpn
+	SrcIfName string
 }
 
 type PortConfig struct {
@@ -161,8 +181,9 @@ func ConvertVxlanInstanceToVxlanConfig(c *vxland.VxlanInstance) (*VxlanConfig, e
 	}
 
 	return &VxlanConfig{
-		VNI:    uint32(c.Vni),
-		VlanId: uint16(c.VlanId),
+		VNI:       uint32(c.Vni),
+		VlanId:    uint16(c.VlanId),
+		SrcIfName: c.IntfRef,
 	}, nil
 }
 
@@ -363,6 +384,20 @@ func (s *VXLANServer) ConfigListener() {
                                         logger.Info("Saving Port Config to db", *portcfg)
 					PortConfigMap[port.IfIndex] = portcfg
 				}
+			case evpnvtep := <-cc.EVPNVtepUpdate:
+				if evpnvtep.Command == VxlanCommandCreate {
+					CreateEVPNVtep(evpnvtep.Vni, evpnvtep.RemoteIp)
+				} else if evpnvtep.Command == VxlanCommandDelete {
+					DeleteEVPNVtep(evpnvtep.Vni, evpnvtep.RemoteIp)
+				}
+
+			case evpnmac := <-cc.EVPNMacUpdate:
+				if evpnmac.Command == VxlanCommandCreate {
+					CreateEVPNMac(&evpnmac)
+				} else if evpnmac.Command == VxlanCommandDelete {
+					DeleteEVPNMac(&evpnmac)
+				}
+
 			case intfinfo := <-cc.Vxlanintfinfo:
 				for _, vtep := range GetVtepDB() {
 					logger.Info(fmt.Sprintln("received intf info", intfinfo, vtep))
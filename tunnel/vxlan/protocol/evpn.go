@@ -0,0 +1,228 @@
+// evpn.go
+// File contains the db of the remote VTEPs and MACs which are learnt from
+// bgp evpn.  A VTEP is created per remote endpoint of a VNI once either an
+// inclusive multicast route or a mac/ip advertisement route is received from it
+// and the remote MACs are installed in the fdb against that VTEP.
+package vxlan
+
+import (
+	"fmt"
+	"net"
+)
+
+const (
+	// IANA vxlan udp port
+	EVPNVtepUDPPort = 4789
+	EVPNVtepTTL     = 64
+)
+
+// evpnVtepKey
+// Holds the key for the evpnVtepDB
+type evpnVtepKey struct {
+	vni      uint32
+	remoteIp string
+}
+
+type evpnVtepEntry struct {
+	vni      uint32
+	remoteIp net.IP
+	vtepName string
+	// inclusive multicast route received from the remote vtep
+	imet bool
+	// remote macs behind the remote vtep
+	macs map[string]net.HardwareAddr
+	// vtep has been created, vxlan must exist for the vni
+	provisioned bool
+}
+
+// vni + remote ip to evpn vtep data
+var evpnVtepDB map[evpnVtepKey]*evpnVtepEntry
+
+// used to generate unique vtep names as the linux interface name
+// can not hold the vni and the remote ip
+var evpnVtepId uint32
+
+func getEVPNVtepEntry(vni uint32, remoteIp net.IP) *evpnVtepEntry {
+	key := evpnVtepKey{
+		vni:      vni,
+		remoteIp: remoteIp.String(),
+	}
+	if entry, ok := evpnVtepDB[key]; ok {
+		return entry
+	}
+	return nil
+}
+
+func getOrCreateEVPNVtepEntry(vni uint32, remoteIp net.IP) *evpnVtepEntry {
+	entry := getEVPNVtepEntry(vni, remoteIp)
+	if entry == nil {
+		evpnVtepId++
+		entry = &evpnVtepEntry{
+			vni:      vni,
+			remoteIp: remoteIp,
+			vtepName: fmt.Sprintf("evpn%d", evpnVtepId),
+			macs:     make(map[string]net.HardwareAddr),
+		}
+		evpnVtepDB[evpnVtepKey{vni: vni, remoteIp: remoteIp.String()}] = entry
+	}
+	return entry
+}
+
+func (entry *evpnVtepEntry) getVtep() *VtepDbEntry {
+	return GetVtepDBEntry(&VtepDbKey{name: entry.vtepName})
+}
+
+// provision:
+// create the vtep to the remote endpoint and install all the remote macs
+// learnt so far. The vtep will remain pending till the vxlan is created
+func (entry *evpnVtepEntry) provision() {
+	if entry.provisioned {
+		return
+	}
+
+	vxlan := GetVxlanDBEntry(entry.vni)
+	if vxlan == nil {
+		logger.Info(fmt.Sprintln("EVPN: vxlan not configured, vtep pending", entry.vni, entry.remoteIp))
+		return
+	}
+
+	logger.Info(fmt.Sprintln("EVPN: create vtep", entry.vtepName, entry.vni, entry.remoteIp))
+	vtep := CreateVtep(&VtepConfig{
+		Vni:         entry.vni,
+		VtepName:    entry.vtepName,
+		SrcIfName:   vxlan.SrcIfName,
+		UDP:         EVPNVtepUDPPort,
+		TTL:         EVPNVtepTTL,
+		TunnelDstIp: entry.remoteIp,
+		VlanId:      vxlan.VlanId,
+	})
+	entry.provisioned = true
+
+	for _, mac := range entry.macs {
+		for _, client := range ClientIntf {
+			client.CreateFdbEntry(vtep, mac)
+		}
+	}
+}
+
+// deProvision:
+// remove the remote macs and the vtep to the remote endpoint
+func (entry *evpnVtepEntry) deProvision() {
+	if !entry.provisioned {
+		return
+	}
+
+	logger.Info(fmt.Sprintln("EVPN: delete vtep", entry.vtepName, entry.vni, entry.remoteIp))
+	if vtep := entry.getVtep(); vtep != nil {
+		for _, mac := range entry.macs {
+			for _, client := range ClientIntf {
+				client.DeleteFdbEntry(vtep, mac)
+			}
+		}
+	}
+
+	DeleteVtep(&VtepConfig{
+		Vni:      entry.vni,
+		VtepName: entry.vtepName,
+	})
+	entry.provisioned = false
+}
+
+// cleanup:
+// the vtep is removed once there are no routes left from the remote endpoint
+func (entry *evpnVtepEntry) cleanup() {
+	if entry.imet || len(entry.macs) > 0 {
+		return
+	}
+
+	entry.deProvision()
+	delete(evpnVtepDB, evpnVtepKey{vni: entry.vni, remoteIp: entry.remoteIp.String()})
+}
+
+// CreateEVPNVtep:
+// inclusive multicast route received from the remote vtep
+func CreateEVPNVtep(vni uint32, remoteIp net.IP) {
+	entry := getOrCreateEVPNVtepEntry(vni, remoteIp)
+	entry.imet = true
+	entry.provision()
+}
+
+// DeleteEVPNVtep:
+// inclusive multicast route withdrawn by the remote vtep
+func DeleteEVPNVtep(vni uint32, remoteIp net.IP) {
+	entry := getEVPNVtepEntry(vni, remoteIp)
+	if entry == nil {
+		return
+	}
+
+	entry.imet = false
+	entry.cleanup()
+}
+
+// CreateEVPNMac:
+// mac/ip advertisement route received from the remote vtep
+func CreateEVPNMac(c *EVPNMacConfig) {
+	entry := getOrCreateEVPNVtepEntry(c.Vni, c.RemoteIp)
+	macStr := c.Mac.String()
+	if _, ok := entry.macs[macStr]; ok {
+		return
+	}
+
+	entry.macs[macStr] = c.Mac
+	if !entry.provisioned {
+		// the macs will be installed once the vtep is created
+		entry.provision()
+		return
+	}
+
+	if vtep := entry.getVtep(); vtep != nil {
+		for _, client := range ClientIntf {
+			client.CreateFdbEntry(vtep, c.Mac)
+		}
+	}
+}
+
+// DeleteEVPNMac:
+// mac/ip advertisement route withdrawn by the remote vtep
+func DeleteEVPNMac(c *EVPNMacConfig) {
+	entry := getEVPNVtepEntry(c.Vni, c.RemoteIp)
+	if entry == nil {
+		return
+	}
+
+	macStr := c.Mac.String()
+	mac, ok := entry.macs[macStr]
+	if !ok {
+		return
+	}
+
+	if entry.provisioned {
+		if vtep := entry.getVtep(); vtep != nil {
+			for _, client := range ClientIntf {
+				client.DeleteFdbEntry(vtep, mac)
+			}
+		}
+	}
+	delete(entry.macs, macStr)
+	entry.cleanup()
+}
+
+// ProvisionEVPNVteps:
+// create the evpn vteps which were waiting for the vxlan to be configured
+func ProvisionEVPNVteps(vni uint32) {
+	for _, entry := range evpnVtepDB {
+		if entry.vni == vni {
+			entry.provision()
+		}
+	}
+}
+
+// DeProvisionEVPNVteps:
+// vxlan is being deleted, the evpn vteps stay pending till it is configured again
+func DeProvisionEVPNVteps(vni uint32) {
+	for _, entry := range evpnVtepDB {
+		if entry.vni == vni {
+			entry.deProvision()
+		}
+	}
+}
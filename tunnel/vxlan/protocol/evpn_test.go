@@ -0,0 +1,245 @@
+// evpn_test.go
+package vxlan
+
+import (
+	"net"
+	"testing"
+)
+
+// evpnmockintf:
+// records the remote macs installed in the fdb per vtep
+type evpnmockintf struct {
+	mockintf
+	fdb map[string]map[string]bool
+}
+
+func newEVPNMockIntf() *evpnmockintf {
+	return &evpnmockintf{
+		fdb: make(map[string]map[string]bool),
+	}
+}
+
+func (b *evpnmockintf) CreateFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+	logger.Info("MOCK: Calling CreateFdbEntry", vtep.VtepName, mac)
+	if _, ok := b.fdb[vtep.VtepName]; !ok {
+		b.fdb[vtep.VtepName] = make(map[string]bool)
+	}
+	b.fdb[vtep.VtepName][mac.String()] = true
+}
+
+func (b *evpnmockintf) DeleteFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+	logger.Info("MOCK: Calling DeleteFdbEntry", vtep.VtepName, mac)
+	delete(b.fdb[vtep.VtepName], mac.String())
+	if len(b.fdb[vtep.VtepName]) == 0 {
+		delete(b.fdb, vtep.VtepName)
+	}
+}
+
+// getEVPNTestVtep:
+// returns the vtep created for the remote endpoint of the vni
+func getEVPNTestVtep(t *testing.T, vni uint32, remoteIp net.IP) *VtepDbEntry {
+	entry := getEVPNVtepEntry(vni, remoteIp)
+	if entry == nil {
+		t.Errorf("EVPN vtep entry not found for vni %d remote %s", vni, remoteIp)
+		return nil
+	}
+	vtep := entry.getVtep()
+	if vtep == nil {
+		t.Errorf("Vtep %s not created for vni %d remote %s", entry.vtepName, vni, remoteIp)
+		return nil
+	}
+	if vtep.Vni != vni || !vtep.DstIp.Equal(remoteIp) || vtep.UDP != EVPNVtepUDPPort ||
+		vtep.SrcIfName != "eth0" || vtep.VlanId != 200 {
+		t.Errorf("Vtep not as expected %#v", vtep)
+	}
+	return vtep
+}
+
+// TestEVPNImetRouteInstallWithdraw:
+// Test the type-3 inclusive multicast route creates the vtep to the
+// remote endpoint and the withdraw removes it
+func TestEVPNImetRouteInstallWithdraw(t *testing.T) {
+
+	// setup common test info
+	setup()
+	defer teardown()
+
+	oldRxTx := VxlanVtepRxTx
+	VxlanVtepRxTx = MockFuncRxTx
+
+	defer func() {
+		VxlanVtepRxTx = oldRxTx
+	}()
+
+	RegisterClients(newEVPNMockIntf())
+
+	vxlanConfig := &VxlanConfig{
+		VNI:       100,
+		VlanId:    200,
+		SrcIfName: "eth0",
+	}
+	remoteIp := net.ParseIP("100.1.1.2")
+
+	CreateVxLAN(vxlanConfig)
+	<-vxlancreatedone
+
+	CreateEVPNVtep(100, remoteIp)
+	<-vtepcreatedone
+
+	vtep := getEVPNTestVtep(t, 100, remoteIp)
+	if vtep != nil && vtep.VxlanVtepMachineFsm.Machine.Curr.CurrentState() < VxlanVtepStateHwConfig {
+		t.Errorf("State not as expected expected[%s] actual[%s]", VxlanVtepStateHwConfig, vtep.VxlanVtepMachineFsm.Machine.Curr.CurrentState())
+	}
+
+	// route from the same remote endpoint again does not create another vtep
+	CreateEVPNVtep(100, remoteIp)
+	if len(GetVtepDB()) != 1 {
+		t.Errorf("Vtep db has %d entries expected 1", len(GetVtepDB()))
+	}
+
+	DeleteEVPNVtep(100, remoteIp)
+	<-vtepdeletedone
+
+	if getEVPNVtepEntry(100, remoteIp) != nil {
+		t.Errorf("EVPN vtep entry not removed on withdraw")
+	}
+	if len(GetVtepDB()) != 0 {
+		t.Errorf("Vtep db not empty as expected")
+	}
+
+	DeleteVxLAN(vxlanConfig)
+	<-vxlandeletedone
+}
+
+// TestEVPNMacRouteInstallWithdraw:
+// Test the type-2 mac/ip advertisement routes install the remote macs
+// against the vtep and the withdraw of the last mac removes the vtep
+func TestEVPNMacRouteInstallWithdraw(t *testing.T) {
+
+	// setup common test info
+	setup()
+	defer teardown()
+
+	oldRxTx := VxlanVtepRxTx
+	VxlanVtepRxTx = MockFuncRxTx
+
+	defer func() {
+		VxlanVtepRxTx = oldRxTx
+	}()
+
+	x := newEVPNMockIntf()
+	RegisterClients(x)
+
+	vxlanConfig := &VxlanConfig{
+		VNI:       100,
+		VlanId:    200,
+		SrcIfName: "eth0",
+	}
+	remoteIp := net.ParseIP("100.1.1.2")
+	mac1, _ := net.ParseMAC("00:11:22:33:44:01")
+	mac2, _ := net.ParseMAC("00:11:22:33:44:02")
+
+	CreateVxLAN(vxlanConfig)
+	<-vxlancreatedone
+
+	CreateEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac1, Ip: net.ParseIP("10.1.1.1"), RemoteIp: remoteIp})
+	<-vtepcreatedone
+
+	vtep := getEVPNTestVtep(t, 100, remoteIp)
+	if vtep == nil {
+		return
+	}
+	CreateEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac2, Ip: net.ParseIP("10.1.1.2"), RemoteIp: remoteIp})
+	// duplicate advertisement
+	CreateEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac2, Ip: net.ParseIP("10.1.1.2"), RemoteIp: remoteIp})
+
+	if len(x.fdb[vtep.VtepName]) != 2 || !x.fdb[vtep.VtepName][mac1.String()] || !x.fdb[vtep.VtepName][mac2.String()] {
+		t.Errorf("Remote macs not installed against vtep %s, fdb %v", vtep.VtepName, x.fdb)
+	}
+	if len(GetVtepDB()) != 1 {
+		t.Errorf("Vtep db has %d entries expected 1", len(GetVtepDB()))
+	}
+
+	DeleteEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac1, RemoteIp: remoteIp})
+	if x.fdb[vtep.VtepName][mac1.String()] || !x.fdb[vtep.VtepName][mac2.String()] {
+		t.Errorf("Remote mac %s not removed from vtep %s, fdb %v", mac1, vtep.VtepName, x.fdb)
+	}
+	if getEVPNTestVtep(t, 100, remoteIp) == nil {
+		t.Errorf("Vtep removed while remote macs are still learnt against it")
+	}
+
+	DeleteEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac2, RemoteIp: remoteIp})
+	<-vtepdeletedone
+
+	if len(x.fdb) != 0 {
+		t.Errorf("Fdb not empty as expected %v", x.fdb)
+	}
+	if getEVPNVtepEntry(100, remoteIp) != nil {
+		t.Errorf("EVPN vtep entry not removed on withdraw of the last mac")
+	}
+	if len(GetVtepDB()) != 0 {
+		t.Errorf("Vtep db not empty as expected")
+	}
+
+	DeleteVxLAN(vxlanConfig)
+	<-vxlandeletedone
+}
+
+// TestEVPNRoutesBeforeVxlan:
+// Test the routes received before the vxlan is configured are installed
+// once it is created, and stay pending when it is deleted
+func TestEVPNRoutesBeforeVxlan(t *testing.T) {
+
+	// setup common test info
+	setup()
+	defer teardown()
+
+	oldRxTx := VxlanVtepRxTx
+	VxlanVtepRxTx = MockFuncRxTx
+
+	defer func() {
+		VxlanVtepRxTx = oldRxTx
+	}()
+
+	x := newEVPNMockIntf()
+	RegisterClients(x)
+
+	vxlanConfig := &VxlanConfig{
+		VNI:       100,
+		VlanId:    200,
+		SrcIfName: "eth0",
+	}
+	remoteIp := net.ParseIP("100.1.1.2")
+	mac, _ := net.ParseMAC("00:11:22:33:44:01")
+
+	CreateEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac, RemoteIp: remoteIp})
+	if len(GetVtepDB()) != 0 || len(x.fdb) != 0 {
+		t.Errorf("Vtep created before the vxlan is configured")
+	}
+
+	CreateVxLAN(vxlanConfig)
+	<-vxlancreatedone
+	<-vtepcreatedone
+
+	vtep := getEVPNTestVtep(t, 100, remoteIp)
+	if vtep != nil && !x.fdb[vtep.VtepName][mac.String()] {
+		t.Errorf("Pending remote mac %s not installed once the vxlan is created, fdb %v", mac, x.fdb)
+	}
+
+	DeleteVxLAN(vxlanConfig)
+	<-vtepdeletedone
+	<-vxlandeletedone
+
+	entry := getEVPNVtepEntry(100, remoteIp)
+	if entry == nil || entry.provisioned {
+		t.Errorf("EVPN vtep entry not pending after the vxlan is deleted")
+	}
+	if len(GetVtepDB()) != 0 || len(x.fdb) != 0 {
+		t.Errorf("Vtep not removed with the vxlan, fdb %v", x.fdb)
+	}
+
+	DeleteEVPNMac(&EVPNMacConfig{Vni: 100, Mac: mac, RemoteIp: remoteIp})
+	if getEVPNVtepEntry(100, remoteIp) != nil {
+		t.Errorf("EVPN vtep entry not removed on withdraw")
+	}
+}
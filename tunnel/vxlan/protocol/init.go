@@ -31,6 +31,7 @@ func init() {
 	vtepDB = make(map[VtepDbKey]*VtepDbEntry, 0)
 	vxlanDB = make(map[uint32]*vxlanDbEntry, 0)
 	vxlanVlanToVniDb = make(map[uint16]uint32, 0)
+	evpnVtepDB = make(map[evpnVtepKey]*evpnVtepEntry, 0)
 
 	PortConfigMap = make(map[int32]*PortConfig, 0)
 	portDB = make(map[string]*VxlanPort, 0)
@@ -110,6 +110,8 @@ func NewVXLANServer(l *logging.Writer, paramspath string) *VXLANServer {
 				VxlanAccessPortVlanUpdate: make(chan VxlanAccessPortVlan, 0),
 				VxlanNextHopUpdate:        make(chan VxlanNextHopIp, 0),
 				VxlanPortCreate:           make(chan PortConfig, 0),
+				EVPNVtepUpdate:            make(chan EVPNVtepConfig, 0),
+				EVPNMacUpdate:             make(chan EVPNMacConfig, 0),
 			},
 		}
 
@@ -112,6 +112,12 @@ func (b mockintf) DeleteAccessPortVlan(vlan uint16, intfList []int) {
 	if !b.failDeleteAccessPortVlan {
 
 	}
+}
+func (b mockintf) CreateFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+
+}
+func (b mockintf) DeleteFdbEntry(vtep *VtepDbEntry, mac net.HardwareAddr) {
+
 }
 func (b mockintf) GetNextHopInfo(ip net.IP, nexthopchan chan<- MachineEvent) {
 	logger.Info("MOCK: Calling GetNextHopInfo")
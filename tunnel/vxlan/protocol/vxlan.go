@@ -20,6 +20,8 @@ type vxlanDbEntry struct {
 	Group net.IP
 	// Shortcut to apply MTU to each VTEP
 	MTU uint32
+	// Source interface used by the VTEP's learnt via bgp evpn
+	SrcIfName string
 	// VTEP's associated with this vxlan domain
 	// Vlan db will hold port membership for access
 	VtepMembers []uint32
@@ -36,6 +38,7 @@ func NewVxlanDbEntry(c *VxlanConfig) *vxlanDbEntry {
 		VlanId:      c.VlanId,
 		Group:       c.Group,
 		MTU:         c.MTU,
+		SrcIfName:   c.SrcIfName,
 		VtepMembers: make([]uint32, 0),
 	}
 }
@@ -84,12 +87,17 @@ func CreateVxLAN(c *VxlanConfig) {
 			}
 		}
 	}
+
+	// vteps learnt via evpn before the vxlan was configured
+	ProvisionEVPNVteps(c.VNI)
 }
 
 // DeleteVxLAN:
 // Configuration interface for deleting the vlxlan instance
 func DeleteVxLAN(c *VxlanConfig) {
 
+	DeProvisionEVPNVteps(c.VNI)
+
 	// delete vxlan resources in hw
 	for _, client := range ClientIntf {
 		client.DeleteVxlan(c)